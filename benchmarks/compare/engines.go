@@ -0,0 +1,120 @@
+//go:build compare
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// Engine은 비교 대상 저장소를 같은 키-값 API로 감쌉니다.
+// 모든 엔진은 같은 워크로드를 같은 순서로 받으며, Close는 디스크에 남기는 작업까지 포함합니다.
+type Engine interface {
+	Put(key, value []byte) error
+	Get(key []byte) ([]byte, error)
+	Close() error
+}
+
+// opener는 dir 안에 새 엔진을 엽니다. p.Sync가 true면 쓰기는 fsync가 끝난 뒤 반환해야 합니다.
+type opener func(dir string, p Params) (Engine, error)
+
+// engines는 -engines 플래그에서 쓰는 이름과 생성자입니다.
+var engines = map[string]opener{
+	"golite-btree": openBtree,
+	"golite-lsm":   openLSM,
+	"golite-file":  openFile,
+	"bbolt":        openBolt,
+	"badger":       openBadger,
+}
+
+// portEngine은 StoragePort 어댑터(B-트리, File)를 BytesPort로 감쌉니다.
+type portEngine struct {
+	port  ports.BytesPort
+	sync  func() error // nil이면 쓰기마다 fsync하지 않음
+	close func() error
+}
+
+func (e *portEngine) Put(key, value []byte) error {
+	if err := e.port.InsertBytes(key, value); err != nil {
+		return err
+	}
+	if e.sync != nil {
+		return e.sync()
+	}
+	return nil
+}
+
+func (e *portEngine) Get(key []byte) ([]byte, error) { return e.port.GetBytes(key) }
+func (e *portEngine) Close() error                   { return e.close() }
+
+// btreePageSize는 B-트리 페이지 크기입니다. 차수는 -value 크기에 맞춰 노드가 한 페이지에 들어가도록 정합니다.
+const btreePageSize = 4096
+
+func openBtree(dir string, p Params) (Engine, error) {
+	degree := btreePageSize / (2 * (p.ValueSize + 32))
+	if degree < 2 {
+		return nil, fmt.Errorf("value size %d does not fit a %d-byte B-tree page", p.ValueSize, btreePageSize)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "btree.db"), os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: degree, PageSize: btreePageSize, ThreadSafe: true, CacheSize: 1024})
+	e := &portEngine{port: ports.AsBytes(bt), close: func() error {
+		if err := bt.Close(); err != nil {
+			return err
+		}
+		return f.Close()
+	}}
+	if p.Sync {
+		e.sync = bt.Sync
+	}
+	return e, nil
+}
+
+func openFile(dir string, p Params) (Engine, error) {
+	durability := ports.DurabilityAsync
+	if p.Sync {
+		durability = ports.DurabilityFsyncPerCommit
+	}
+	f, err := file.NewFile(file.FileConfig{FilePath: filepath.Join(dir, "file.db"), ThreadSafe: true, Durability: durability})
+	if err != nil {
+		return nil, err
+	}
+	return &portEngine{port: ports.AsBytes(f), close: f.Close}, nil
+}
+
+// lsmEngine은 string API인 LSMTree를 감쌉니다.
+type lsmEngine struct{ lsm *lsmtree.LSMTree }
+
+func openLSM(dir string, p Params) (Engine, error) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = dir
+	config.LogLevel = "error"
+	config.Durability = ports.DurabilityAsync
+	if p.Sync {
+		config.Durability = ports.DurabilityFsyncPerCommit
+	}
+	lsm, err := lsmtree.NewLSMTree(config)
+	if err != nil {
+		return nil, err
+	}
+	return &lsmEngine{lsm: lsm}, nil
+}
+
+func (e *lsmEngine) Put(key, value []byte) error { return e.lsm.Insert(string(key), string(value)) }
+func (e *lsmEngine) Close() error                { return e.lsm.Close() }
+
+func (e *lsmEngine) Get(key []byte) ([]byte, error) {
+	value, err := e.lsm.Get(string(key))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
@@ -0,0 +1,90 @@
+//go:build compare
+
+package main
+
+import (
+	"path/filepath"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/sukryu/GoLite/pkg/ports"
+	bolt "go.etcd.io/bbolt"
+)
+
+// 외부 기준 엔진: bbolt(B+트리, 쓰기마다 트랜잭션)와 badger(LSM, 키/값 분리).
+// 둘 다 키 하나당 트랜잭션 하나로 호출해 GoLite의 단건 Insert/Get과 같은 조건을 맞춥니다.
+// 찾지 못한 키는 GoLite와 같이 ports.ErrKeyNotFound로 보고합니다.
+
+var boltBucket = []byte("bench")
+
+type boltEngine struct{ db *bolt.DB }
+
+func openBolt(dir string, p Params) (Engine, error) {
+	db, err := bolt.Open(filepath.Join(dir, "bolt.db"), 0666, &bolt.Options{NoSync: !p.Sync})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltEngine{db: db}, nil
+}
+
+func (e *boltEngine) Put(key, value []byte) error {
+	return e.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (e *boltEngine) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := e.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get(key)
+		if v == nil {
+			return ports.ErrKeyNotFound
+		}
+		value = append([]byte(nil), v...) // 트랜잭션이 끝나면 v는 무효
+		return nil
+	})
+	return value, err
+}
+
+func (e *boltEngine) Close() error { return e.db.Close() }
+
+type badgerEngine struct{ db *badger.DB }
+
+func openBadger(dir string, p Params) (Engine, error) {
+	opts := badger.DefaultOptions(filepath.Join(dir, "badger")).WithSyncWrites(p.Sync).WithLogger(nil)
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &badgerEngine{db: db}, nil
+}
+
+func (e *badgerEngine) Put(key, value []byte) error {
+	return e.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+func (e *badgerEngine) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := e.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return ports.ErrKeyNotFound
+		} else if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	return value, err
+}
+
+func (e *badgerEngine) Close() error { return e.db.Close() }
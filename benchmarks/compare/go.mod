@@ -0,0 +1,11 @@
+module github.com/sukryu/GoLite/benchmarks/compare
+
+go 1.23.6
+
+require (
+	github.com/dgraph-io/badger/v4 v4.5.1
+	github.com/sukryu/GoLite v0.0.0
+	go.etcd.io/bbolt v1.3.11
+)
+
+replace github.com/sukryu/GoLite => ../..
@@ -0,0 +1,167 @@
+//go:build compare
+
+// Command compare는 GoLite 엔진(B-트리, LSM, File)과 bbolt, badger에 같은 워크로드를 실행하고
+// 처리량, 지연 분위수, 닫기 시간, 디스크 사용량을 비교하는 보고서를 출력합니다.
+//
+// 외부 엔진 의존성이 루트 모듈에 들어가지 않도록 별도 모듈이며, compare 빌드 태그 뒤에 있습니다.
+// 처음 한 번은 네트워크가 되는 환경에서 의존성을 받아야 합니다:
+//
+//	cd benchmarks/compare && go mod tidy
+//	go run -tags compare . -n 100000 -value 100
+//	go run -tags compare . -engines golite-lsm,badger -workloads fillrandom,readrandom -format json
+//
+// 모든 엔진은 키 하나당 트랜잭션 하나로 호출되며, 기본값은 fsync 없이(-sync=false) 실행합니다.
+// 회귀 비교에는 -format json 출력을 저장해 두고 같은 -n, -value, -seed로 다시 실행한 결과와 비교합니다.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	var (
+		engineList   = flag.String("engines", "", "comma-separated engines (default: all)")
+		workloadList = flag.String("workloads", "", "comma-separated workloads (default: all)")
+		format       = flag.String("format", "text", "report format: text, json or csv")
+		dir          = flag.String("dir", "", "directory for temporary data (default: system temp dir)")
+		p            Params
+	)
+	flag.IntVar(&p.N, "n", 100000, "operations per workload (and number of keys)")
+	flag.IntVar(&p.ValueSize, "value", 100, "value size in bytes")
+	flag.IntVar(&p.ReadPct, "read-pct", 80, "percentage of reads in the mixed workload")
+	flag.Int64Var(&p.Seed, "seed", 1, "random seed for key order and values")
+	flag.BoolVar(&p.Sync, "sync", false, "fsync every write")
+	flag.Parse()
+
+	if p.N <= 0 || p.ValueSize <= 0 || p.ReadPct < 0 || p.ReadPct > 100 {
+		fmt.Fprintln(os.Stderr, "invalid -n, -value or -read-pct")
+		os.Exit(2)
+	}
+	names, err := selectEngines(*engineList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	selected, err := selectWorkloads(*workloadList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var results []Result
+	failed := false
+	for _, w := range selected {
+		for _, name := range names {
+			r, err := run(name, engines[name], w, p, *dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s/%s: %v\n", name, w.Name, err)
+				failed = true
+				continue
+			}
+			results = append(results, r)
+		}
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(struct {
+			Params  Params   `json:"params"`
+			Results []Result `json:"results"`
+		}{p, results})
+	case "csv":
+		fmt.Println("engine,workload,ops,ops_per_sec,p50_ns,p99_ns,max_ns,close_ns,disk_bytes")
+		for _, r := range results {
+			fmt.Printf("%s,%s,%d,%.0f,%d,%d,%d,%d,%d\n", r.Engine, r.Workload, r.Ops, r.OpsPerSec,
+				r.P50.Nanoseconds(), r.P99.Nanoseconds(), r.Max.Nanoseconds(), r.Close.Nanoseconds(), r.DiskBytes)
+		}
+	default:
+		printReport(results, p)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func selectEngines(list string) ([]string, error) {
+	if list == "" {
+		var names []string
+		for name := range engines {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	names := strings.Split(list, ",")
+	for _, name := range names {
+		if engines[name] == nil {
+			return nil, fmt.Errorf("unknown engine %q", name)
+		}
+	}
+	return names, nil
+}
+
+func selectWorkloads(list string) ([]Workload, error) {
+	if list == "" {
+		return workloads, nil
+	}
+	var selected []Workload
+	for _, name := range strings.Split(list, ",") {
+		found := false
+		for _, w := range workloads {
+			if w.Name == name {
+				selected, found = append(selected, w), true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown workload %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// printReport는 워크로드별 표를 출력합니다. "vs best"는 해당 워크로드에서 가장 빠른 엔진 대비 처리량입니다.
+func printReport(results []Result, p Params) {
+	fmt.Printf("n=%d value=%dB read-pct=%d seed=%d sync=%v\n", p.N, p.ValueSize, p.ReadPct, p.Seed, p.Sync)
+	best := make(map[string]float64)
+	for _, r := range results {
+		if r.OpsPerSec > best[r.Workload] {
+			best[r.Workload] = r.OpsPerSec
+		}
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "workload\tengine\tops/s\tvs best\tp50\tp99\tmax\tclose\tdisk\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%.0f\t%.2fx\t%v\t%v\t%v\t%v\t%s\t\n", r.Workload, r.Engine, r.OpsPerSec,
+			r.OpsPerSec/best[r.Workload], round(r.P50), round(r.P99), round(r.Max), round(r.Close), formatBytes(r.DiskBytes))
+	}
+	tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	case d >= time.Microsecond:
+		return d.Round(10 * time.Nanosecond)
+	}
+	return d
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
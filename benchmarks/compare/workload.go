@@ -0,0 +1,205 @@
+//go:build compare
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 워크로드는 모든 엔진에 같은 시드로 같은 키 순서를 재생합니다. 준비 단계(읽기 워크로드의 사전 적재)는
+// 측정하지 않으며, 각 연산의 지연을 개별로 재서 처리량과 함께 분위수를 보고합니다.
+
+// Params는 모든 워크로드가 공유하는 설정입니다.
+type Params struct {
+	N         int   // 연산 수 (그리고 키 공간 크기)
+	ValueSize int   // 값 크기 (바이트)
+	ReadPct   int   // mixed 워크로드에서 읽기 비율 (0-100)
+	Seed      int64 // 키 순서와 값의 난수 시드
+	Sync      bool  // 쓰기마다 fsync
+}
+
+// Workload는 이름이 있는 측정 단위입니다. prepare는 측정 전에, run은 연산마다 op를 호출합니다.
+type Workload struct {
+	Name    string
+	prepare func(e Engine, p Params, values *valueSource) error
+	ops     func(p Params, rng *rand.Rand) []op
+}
+
+// op는 하나의 Put(write=true) 또는 Get입니다.
+type op struct {
+	key   []byte
+	write bool
+}
+
+// workloads는 -workloads 플래그에서 쓰는 순서대로 나열됩니다.
+var workloads = []Workload{
+	{Name: "fillseq", ops: func(p Params, rng *rand.Rand) []op {
+		return keyOps(seqKeys(p.N), true)
+	}},
+	{Name: "fillrandom", ops: func(p Params, rng *rand.Rand) []op {
+		return keyOps(randKeys(p.N, rng), true)
+	}},
+	{Name: "readrandom", prepare: load, ops: func(p Params, rng *rand.Rand) []op {
+		return keyOps(randKeys(p.N, rng), false)
+	}},
+	{Name: "mixed", prepare: load, ops: func(p Params, rng *rand.Rand) []op {
+		ops := keyOps(randKeys(p.N, rng), false)
+		for i := range ops {
+			ops[i].write = rng.Intn(100) >= p.ReadPct
+		}
+		return ops
+	}},
+}
+
+func benchKey(i int) []byte { return []byte(fmt.Sprintf("key%016d", i)) }
+
+func seqKeys(n int) [][]byte {
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = benchKey(i)
+	}
+	return keys
+}
+
+func randKeys(n int, rng *rand.Rand) [][]byte {
+	keys := seqKeys(n)
+	rng.Shuffle(n, func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	return keys
+}
+
+func keyOps(keys [][]byte, write bool) []op {
+	ops := make([]op, len(keys))
+	for i, k := range keys {
+		ops[i] = op{key: k, write: write}
+	}
+	return ops
+}
+
+// valueSource는 난수 버퍼의 서로 다른 구간을 값으로 돌려줍니다. 모든 키에 같은 값을 쓰면
+// 압축하는 엔진(GoLite LSM의 snappy 등)이 비현실적으로 유리해지므로 값마다 내용이 다릅니다.
+type valueSource struct {
+	buf  []byte
+	size int
+	pos  int
+}
+
+func newValueSource(size int, rng *rand.Rand) *valueSource {
+	buf := make([]byte, size+1<<20)
+	rng.Read(buf)
+	return &valueSource{buf: buf, size: size}
+}
+
+func (v *valueSource) next() []byte {
+	if v.pos+v.size > len(v.buf) {
+		v.pos = 0
+	}
+	value := v.buf[v.pos : v.pos+v.size]
+	v.pos += 97 // 값 크기와 서로소인 보폭으로 이웃 값이 겹치지 않게 함
+	return value
+}
+
+// load는 읽기 워크로드를 위해 모든 키를 순서대로 적재합니다.
+func load(e Engine, p Params, values *valueSource) error {
+	for _, k := range seqKeys(p.N) {
+		if err := e.Put(k, values.next()); err != nil {
+			return fmt.Errorf("load %s: %v", k, err)
+		}
+	}
+	return nil
+}
+
+// Result는 한 엔진에서 한 워크로드를 실행한 측정값입니다.
+type Result struct {
+	Engine    string        `json:"engine"`
+	Workload  string        `json:"workload"`
+	Ops       int           `json:"ops"`
+	Elapsed   time.Duration `json:"elapsed_ns"`
+	OpsPerSec float64       `json:"ops_per_sec"`
+	P50       time.Duration `json:"p50_ns"`
+	P99       time.Duration `json:"p99_ns"`
+	Max       time.Duration `json:"max_ns"`
+	Close     time.Duration `json:"close_ns"`   // 닫기(flush/fsync)에 걸린 시간, 처리량에는 포함하지 않음
+	DiskBytes int64         `json:"disk_bytes"` // 닫은 뒤 데이터 디렉토리 크기
+}
+
+// run은 새 디렉토리에 엔진을 열어 워크로드 하나를 실행합니다.
+func run(name string, open opener, w Workload, p Params, baseDir string) (Result, error) {
+	dir, err := os.MkdirTemp(baseDir, name+"-"+w.Name+"-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	rng := rand.New(rand.NewSource(p.Seed))
+	values := newValueSource(p.ValueSize, rng)
+	ops := w.ops(p, rng)
+
+	e, err := open(dir, p)
+	if err != nil {
+		return Result{}, fmt.Errorf("open: %v", err)
+	}
+	if w.prepare != nil {
+		if err := w.prepare(e, p, values); err != nil {
+			e.Close()
+			return Result{}, err
+		}
+	}
+
+	latencies := make([]time.Duration, len(ops))
+	start := time.Now()
+	for i, o := range ops {
+		var value []byte
+		if o.write {
+			value = values.next()
+		}
+		t := time.Now()
+		if o.write {
+			err = e.Put(o.key, value)
+		} else {
+			_, err = e.Get(o.key)
+		}
+		latencies[i] = time.Since(t)
+		if err != nil {
+			e.Close()
+			return Result{}, fmt.Errorf("op %d (%s): %v", i, o.key, err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	closeStart := time.Now()
+	if err := e.Close(); err != nil {
+		return Result{}, fmt.Errorf("close: %v", err)
+	}
+	r := Result{
+		Engine:    name,
+		Workload:  w.Name,
+		Ops:       len(ops),
+		Elapsed:   elapsed,
+		OpsPerSec: float64(len(ops)) / elapsed.Seconds(),
+		Close:     time.Since(closeStart),
+		DiskBytes: dirSize(dir),
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	if n := len(latencies); n > 0 {
+		r.P50 = latencies[n*50/100]
+		r.P99 = latencies[n*99/100]
+		r.Max = latencies[n-1]
+	}
+	return r, nil
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sukryu/GoLite/pkg/repair"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// runFsck implements `golite fsck -file <db>`: it checks a database that no server has open
+// and optionally copies its readable records to a new file. The exit status is 0 if the
+// database is intact, 1 if problems were found and 2 if it could not be checked.
+func runFsck(args []string) {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: golite fsck -file <db> [flags]")
+		fs.PrintDefaults()
+	}
+	path := fs.String("file", "", "Database file to check")
+	var opts repair.Options
+	fs.IntVar(&opts.PageSize, "page-size", repair.DefaultPageSize, "Page size the B-tree file was created with")
+	fs.StringVar(&opts.Salvage, "salvage", "", "Copy the readable records to a new database at this path")
+	fs.Parse(args)
+	if *path == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	report, err := repair.Check(*path, opts)
+	if err != nil {
+		if errors.Is(err, utils.ErrLocked) {
+			fmt.Fprintf(os.Stderr, "fsck: %v (stop the server first)\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "fsck: %v\n", err)
+		}
+		os.Exit(2)
+	}
+	fmt.Printf("%s: %s storage", report.Path, report.Format)
+	if report.Format == repair.FormatBtree {
+		fmt.Printf(", %d tables, %d pages (%d unreachable)", report.Tables, report.Pages, report.Orphaned)
+	}
+	fmt.Printf(", %d readable records\n", report.Records)
+	for _, w := range report.Warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+	for _, p := range report.Problems {
+		fmt.Printf("problem: %s\n", p)
+	}
+	if opts.Salvage != "" {
+		fmt.Printf("Salvaged %d records to %s\n", report.Salvaged, opts.Salvage)
+	}
+	if !report.OK() {
+		fmt.Printf("%d problems found\n", len(report.Problems))
+		os.Exit(1)
+	}
+	fmt.Println("No problems found")
+}
@@ -2,79 +2,259 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sukryu/GoLite/pkg/adapters/btree"
 	"github.com/sukryu/GoLite/pkg/adapters/file"
 	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/capture"
+	"github.com/sukryu/GoLite/pkg/config"
 	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/lifecycle"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/replication"
+	"github.com/sukryu/GoLite/pkg/server"
 	"github.com/sukryu/GoLite/pkg/utils"
 )
 
-type Config struct {
-	StorageType string
-	FilePath    string
-	ThreadSafe  bool
-}
-
 func main() {
-	config := Config{}
-	flag.StringVar(&config.StorageType, "storage", "btree", "Storage type (btree or file)")
-	flag.StringVar(&config.FilePath, "file", "golite.db", "Database file path")
-	flag.BoolVar(&config.ThreadSafe, "threadsafe", true, "Enable thread safety")
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		runFsck(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		runExport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import-sqlite" {
+		runImportSQLite(os.Args[2:])
+		return
+	}
+	defaults := config.Defaults()
+	cfg := defaults
+	var configPath string
+	flag.StringVar(&configPath, "config", "", "JSON config file; reloaded on SIGHUP (flags take precedence)")
+	flag.StringVar(&cfg.StorageType, "storage", defaults.StorageType, "Storage type (btree or file)")
+	flag.StringVar(&cfg.FilePath, "file", defaults.FilePath, "Database file path")
+	flag.BoolVar(&cfg.ThreadSafe, "threadsafe", defaults.ThreadSafe, "Enable thread safety")
+	flag.StringVar(&cfg.ReplListen, "repl-listen", "", "Accept replicas on this address (e.g. :7070)")
+	flag.StringVar(&cfg.ReplicaOf, "replica-of", "", "Run as a read-only replica of the primary at this address")
+	flag.StringVar(&cfg.RaftID, "raft-id", "", "Join a Raft cluster with this node ID")
+	flag.StringVar(&cfg.RaftListen, "raft-listen", "", "Serve Raft RPCs on this address (e.g. :7080)")
+	flag.StringVar(&cfg.RaftPeers, "raft-peers", "", "Other Raft members as id=host:port,id=host:port")
+	flag.StringVar(&cfg.Durability, "durability", "", "Write durability: none, async (default), fsync-per-commit or fsync-per-batch")
+	flag.StringVar(&cfg.Capture, "capture", "", "Record committed mutations to this file (replay them with: golite replay <file>)")
+	flag.BoolVar(&cfg.KeyDict, "key-dict", false, "Store hot key prefixes in a shared dictionary (btree storage)")
+	flag.BoolVar(&cfg.Mmap, "mmap", false, "Read B-tree pages through a memory mapping of the database file")
+	flag.StringVar(&cfg.LogLevel, "log-level", defaults.LogLevel, "Minimum log level (info, warn, error, silent)")
+	flag.StringVar(&cfg.LogFormat, "log-format", "", "Log output format (text or json)")
+	flag.Float64Var(&cfg.WriteRateLimit, "write-rate-limit", 0, "Maximum commands per second (0 = unlimited)")
+	flag.Float64Var(&cfg.OpsLimit, "ops-limit", 0, "Operations per second before requests are throttled (0 = unlimited)")
+	flag.Float64Var(&cfg.BytesLimit, "bytes-limit", 0, "Written bytes per second before commands are throttled (0 = unlimited)")
+	flag.Float64Var(&cfg.ClientOpsLimit, "client-ops-limit", 0, "Operations per second of each client (0 = unlimited)")
+	flag.Float64Var(&cfg.ClientBytesLimit, "client-bytes-limit", 0, "Written bytes per second of each client (0 = unlimited)")
+	flag.IntVar(&cfg.CacheSize, "cache-size", defaults.CacheSize, "B-tree node cache capacity")
+	flag.Var(&cfg.CompactionInterval, "compaction-interval", "File storage compaction interval (0 = default)")
+	flag.Int64Var(&cfg.RecoveryMaxWAL, "recovery-max-wal", 0, "Compact after startup if the replayed WAL exceeds this many bytes (0 = 64MB)")
+	flag.Var(&cfg.RecoveryMaxReplay, "recovery-max-replay", "Compact after startup if WAL replay took longer than this (0 = 5s)")
+	flag.StringVar(&cfg.MasterKeyFile, "master-key-file", "", "File with the hex-encoded master key for encrypted tables")
+	flag.StringVar(&cfg.StorageKeyEnv, "storage-key-env", "", "Environment variable with the hex-encoded key encrypting the whole storage")
+	flag.Float64Var(&cfg.SoftLimitRatio, "soft-limit-ratio", 0, "Warn when usage reaches this fraction of a hard limit (0 = 0.8, negative = off)")
+	flag.Var(&cfg.SLOReadP99, "slo-read-p99", "Shed low-priority requests while read p99 stays above this (0 = no target)")
+	flag.Var(&cfg.SLOWriteP99, "slo-write-p99", "Shed low-priority requests while write p99 stays above this (0 = no target)")
+	flag.BoolVar(&cfg.Changefeed, "changefeed", false, "Record committed changes in the __changefeed system table")
+	flag.IntVar(&cfg.ChangefeedRetention, "changefeed-retention", 0, "Changefeed records to keep (0 = all)")
+	flag.IntVar(&cfg.VersionHistory, "version-history", 0, "Versions of each key kept in the __versions system table (0 = no limit)")
+	flag.Var(&cfg.VersionRetention, "version-retention", "Keep key versions younger than this (0 = no limit; versioning is on if this or -version-history is set)")
+	flag.BoolVar(&cfg.OpStats, "op-stats", false, "Account heap allocations and bytes per operation in the stats")
+	flag.BoolVar(&cfg.ReadAmplification, "read-amplification", false, "Account the pages, SSTables and blocks touched per read in the stats")
+	flag.Var(&cfg.ReadTimeout, "read-timeout", "Fail queries that run longer than this (0 = no limit)")
+	flag.Var(&cfg.WriteTimeout, "write-timeout", "Fail commands that run longer than this (0 = no limit)")
+	flag.Var(&cfg.CompactionTimeout, "compaction-timeout", "Stop requested and scheduled compactions after this long (0 = no limit)")
+	flag.Int64Var(&cfg.MaxDatabaseBytes, "max-database-bytes", 0, "Fail inserts once the storage reaches this size in bytes (0 = unlimited)")
+	flag.Int64Var(&cfg.MaxKeysPerTable, "max-keys-per-table", 0, "Fail inserts of new keys into a table holding this many keys (0 = unlimited)")
+	flag.StringVar(&cfg.HealthListen, "health-listen", "", "Serve the /healthz and /readyz probes on this address (e.g. :8081)")
+	flag.Var(&cfg.ShutdownTimeout, "shutdown-timeout", "Give up closing components after this long on shutdown (0 = wait)")
+	flag.StringVar(&cfg.CommandOutbox, "command-outbox", "", "Record async commands in this file before running them, in order (re-run after a crash)")
 	flag.Parse()
 
-	logger := utils.NewSimpleLogger()
+	// 명시적으로 지정한 플래그는 설정 파일보다 우선하며, 재적용 시에도 유지됨.
+	var setFlags []string
+	flag.Visit(func(f *flag.Flag) { setFlags = append(setFlags, strings.ReplaceAll(f.Name, "-", "_")) })
+	flagCfg := cfg
+	override := func(c *config.Config) { config.Override(c, flagCfg, setFlags) }
 
-	dbConfig := domain.DatabaseConfig{
-		Name:       "golite",
-		FilePath:   config.FilePath,
-		MaxTables:  100,
-		ThreadSafe: config.ThreadSafe,
-	}
-	var db *domain.Database
-	var err error
-	if config.StorageType == "file" {
-		dbConfig.UsePages = false // File adapter doesn't use pages
-		f, err := file.NewFile(file.FileConfig{FilePath: config.FilePath, ThreadSafe: config.ThreadSafe})
+	level, _ := utils.ParseLogLevel(cfg.LogLevel)
+	format, _ := utils.ParseLogFormat(cfg.LogFormat)
+	logger := utils.NewLevelLogger(utils.NewStructuredLogger(os.Stdout, format), level)
+	if configPath != "" {
+		loaded, err := config.Load(configPath, defaults)
 		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to initialize file storage: %v", err))
+			logger.Error("Failed to load configuration", "path", configPath, "error", err)
 			os.Exit(1)
 		}
-		fileHandle, _ := os.OpenFile(config.FilePath, os.O_RDWR|os.O_CREATE, 0666)
-		db, err = domain.NewDatabaseWithStorage(dbConfig, f, fileHandle, logger)
+		override(&loaded)
+		cfg = loaded
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	level, _ = utils.ParseLogLevel(cfg.LogLevel)
+	if f, _ := utils.ParseLogFormat(cfg.LogFormat); f != format {
+		logger = utils.NewLevelLogger(utils.NewStructuredLogger(os.Stdout, f), level)
+	}
+	logger.SetLevel(level)
+
+	if cfg.ReplicaOf != "" {
+		runReplica(cfg, logger)
+		return
+	}
+	bus := events.NewBus()
+
+	db, fileStore, err := openDatabase(cfg, bus, logger)
+	if err != nil {
+		logger.Error("Failed to open database", "path", cfg.FilePath, "error", err)
+		os.Exit(1)
+	}
+	// 구성 요소는 시작 순서대로 등록하고 종료 시 역순으로 닫음 (핸들러 → ... → 데이터베이스)
+	lc := lifecycle.New(logger)
+	defer shutdown(lc, time.Duration(cfg.ShutdownTimeout), logger)
+	lc.AddCloser("database", db)
+
+	if cfg.Capture != "" {
+		recorder, err := capture.Start(db, cfg.Capture)
 		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to initialize database with file storage: %v", err))
+			logger.Error("Failed to start capture", "path", cfg.Capture, "error", err)
 			os.Exit(1)
 		}
-	} else {
-		dbConfig.UsePages = true
-		dbConfig.BtConfig = btree.BtConfig{
-			Degree:     32,
-			PageSize:   4096,
-			ThreadSafe: config.ThreadSafe,
-			CacheSize:  10,
-		}
-		db, err = domain.NewDatabase(dbConfig, logger)
+		lc.AddCloser("capture", recorder)
+		logger.Info("Capturing mutations", "path", cfg.Capture)
+	}
+
+	if cfg.ReplListen != "" {
+		primary, err := replication.NewPrimary(db, replication.PrimaryConfig{Addr: cfg.ReplListen}, logger)
+		if err == nil {
+			err = primary.Start()
+		}
 		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to initialize database: %v", err))
+			logger.Error("Failed to start replication primary", "error", err)
 			os.Exit(1)
 		}
+		lc.AddCloser("replication", primary)
 	}
-	defer db.Close()
 
 	cmdHandler := application.NewCommandHandler(db, logger)
 	queryHandler := application.NewQueryHandler(db, logger)
+	cmdHandler.SetWriteRateLimit(cfg.WriteRateLimit)
+	admission := application.NewAdmission(admissionLimits(cfg))
+	cmdHandler.SetAdmission(admission)
+	queryHandler.SetAdmission(admission)
+	drainHandlers := func(context.Context) error {
+		cmdHandler.Wait()
+		queryHandler.Wait()
+		return nil
+	}
+
+	if cfg.HealthListen != "" {
+		ln, err := net.Listen("tcp", cfg.HealthListen)
+		if err != nil {
+			logger.Error("Failed to start health server", "addr", cfg.HealthListen, "error", err)
+			os.Exit(1)
+		}
+		srv := &http.Server{Handler: server.NewHealthHandler(queryHandler, 0)}
+		go srv.Serve(ln)
+		lc.Add("health", srv.Shutdown)
+		logger.Info("Serving health probes", "addr", ln.Addr().String())
+	}
+
+	reload := func() {}
+	if configPath != "" {
+		reloader := config.NewReloader(configPath, cfg, logger)
+		reloader.SetOverride(override)
+		cacheSize := cfg.CacheSize
+		reloader.OnReload(func(c config.Config) error {
+			level, err := utils.ParseLogLevel(c.LogLevel)
+			if err != nil {
+				return err
+			}
+			// 실패할 수 있는 항목을 먼저 적용해 부분 적용을 막음
+			if c.CacheSize != cacheSize {
+				if err := db.SetCacheSize(c.CacheSize); err != nil {
+					return err
+				}
+				cacheSize = c.CacheSize
+			}
+			if fileStore != nil {
+				fileStore.SetCompactionInterval(time.Duration(c.CompactionInterval))
+			}
+			logger.SetLevel(level)
+			cmdHandler.SetWriteRateLimit(c.WriteRateLimit)
+			if limits := admissionLimits(c); limits != admission.Limits() {
+				admission.SetLimits(limits)
+			}
+			return nil
+		})
+		reload = func() { reloader.Reload() }
+	}
 
 	ctx := context.Background()
 
+	if cfg.RaftID != "" {
+		node, err := startRaft(cfg, db, logger)
+		if err != nil {
+			logger.Error("Failed to start raft node", "error", err)
+			os.Exit(1)
+		}
+		lc.AddCloser("raft", node)
+		cmdHandler.SetProposer(node)
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		leader, err := node.WaitForLeader(waitCtx)
+		cancel()
+		if err != nil || leader != cfg.RaftID {
+			// 팔로워는 리더가 복제한 변경만 적용하므로 데모 쓰기를 건너뜀.
+			logger.Info("Raft node following leader", "node", cfg.RaftID, "leader", leader)
+			lc.Add("handlers", drainHandlers)
+			waitForSignal(reload)
+			return
+		}
+	}
+
+	if cfg.CommandOutbox != "" {
+		pending, err := cmdHandler.EnableOutbox(cfg.CommandOutbox)
+		if err != nil {
+			logger.Error("Failed to open command outbox", "path", cfg.CommandOutbox, "error", err)
+			os.Exit(1)
+		}
+		lc.Add("outbox", func(context.Context) error { return cmdHandler.CloseOutbox() })
+		logger.Info("Command outbox enabled", "path", cfg.CommandOutbox, "pending", pending)
+	}
+
+	lc.Add("handlers", drainHandlers)
+
 	err = cmdHandler.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "users"})
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to create table: %v", err))
+		logger.Error("Failed to create table", "error", err)
 		os.Exit(1)
 	}
 
@@ -85,23 +265,225 @@ func main() {
 	resultChan := queryHandler.ExecuteQueryAsync(ctx, &application.GetValueQuery{TableName: "users", Key: "user1"})
 	res := <-resultChan
 	if res.Err != nil {
-		logger.Error(fmt.Sprintf("Failed to query user1: %v", res.Err))
+		logger.Error("Failed to query user1", "error", res.Err)
 	} else {
 		fmt.Printf("User1: %s\n", res.Result)
 	}
 
 	statusResult, err := queryHandler.ExecuteQuery(ctx, &application.GetStatusQuery{})
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to query status: %v", err))
+		logger.Error("Failed to query status", "error", err)
 	} else {
 		status := statusResult.(domain.DatabaseStatus)
-		fmt.Printf("Database Status: Ready=%v, TableCount=%d\n", status.Ready, status.TableCount)
+		fmt.Printf("Database Status: Ready=%v, TableCount=%d, RecoveryDebt=%d, DiskBytes=%d, CacheHitRatio=%.2f\n",
+			status.Ready, status.TableCount, status.RecoveryDebt, status.Storage.DiskBytes, status.Storage.CacheHitRatio)
 	}
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	waitForSignal(reload)
 	logger.Info("Shutting down GoLite...")
-	cmdHandler.Wait()
-	queryHandler.Wait()
+}
+
+// shutdown closes the components registered in lc, giving up after timeout (0 = no limit).
+func shutdown(lc *lifecycle.Manager, timeout time.Duration, logger utils.Logger) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := lc.Shutdown(ctx); err != nil {
+		logger.Error("Shutdown did not complete", "error", err)
+	}
+}
+
+// admissionLimits returns the rate limits of the handlers' admission control in cfg.
+func admissionLimits(cfg config.Config) application.AdmissionLimits {
+	return application.AdmissionLimits{
+		OpsPerSecond:         cfg.OpsLimit,
+		BytesPerSecond:       cfg.BytesLimit,
+		ClientOpsPerSecond:   cfg.ClientOpsLimit,
+		ClientBytesPerSecond: cfg.ClientBytesLimit,
+	}
+}
+
+// openDatabase opens the database described by cfg. fileStore is set for file storage.
+func openDatabase(cfg config.Config, bus *events.Bus, logger utils.Logger) (db *domain.Database, fileStore *file.File, err error) {
+	dbConfig := domain.DatabaseConfig{
+		Name:       "golite",
+		FilePath:   cfg.FilePath,
+		MaxTables:  100,
+		ThreadSafe: cfg.ThreadSafe,
+		Events:     bus,
+		Durability: ports.Durability(cfg.Durability),
+		SoftLimits: domain.SoftLimits{
+			Tables: cfg.SoftLimitRatio,
+			Header: cfg.SoftLimitRatio,
+			Entry:  cfg.SoftLimitRatio,
+		},
+		LatencySLO: domain.LatencySLO{
+			ReadP99:  time.Duration(cfg.SLOReadP99),
+			WriteP99: time.Duration(cfg.SLOWriteP99),
+		},
+		Changefeed:          cfg.Changefeed,
+		ChangefeedRetention: cfg.ChangefeedRetention,
+		OpStats:             cfg.OpStats,
+		ReadAmplification:   cfg.ReadAmplification,
+		ReadTimeout:         time.Duration(cfg.ReadTimeout),
+		WriteTimeout:        time.Duration(cfg.WriteTimeout),
+		CompactionTimeout:   time.Duration(cfg.CompactionTimeout),
+		MaxDatabaseBytes:    cfg.MaxDatabaseBytes,
+		MaxKeysPerTable:     cfg.MaxKeysPerTable,
+		VersionHistory:      cfg.VersionHistory,
+		VersionRetention:    time.Duration(cfg.VersionRetention),
+	}
+	for _, t := range cfg.Maintenance {
+		dbConfig.Maintenance = append(dbConfig.Maintenance, domain.MaintenanceTask{
+			Name:   t.Name,
+			Kind:   domain.MaintenanceKind(t.Kind),
+			Every:  time.Duration(t.Every),
+			Jitter: time.Duration(t.Jitter),
+			Target: t.Target,
+		})
+	}
+	if cfg.MasterKeyFile != "" {
+		if dbConfig.MasterKey, err = readMasterKey(cfg.MasterKeyFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	// B-tree는 NewDatabase가 KeyProvider에서 키를 받고, 파일 어댑터는 여기서 받아 넘김
+	var storageKey, previousKey []byte
+	if cfg.StorageKeyEnv != "" {
+		dbConfig.KeyProvider = pagecrypt.EnvKey(cfg.StorageKeyEnv)
+		if storageKey, previousKey, err = dbConfig.KeyProvider.Keys(context.Background()); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.StorageType == "file" {
+		dbConfig.UsePages = false // File adapter doesn't use pages
+		f, err := file.NewFile(file.FileConfig{
+			FilePath:              cfg.FilePath,
+			ThreadSafe:            cfg.ThreadSafe,
+			Events:                bus,
+			CompactionInterval:    time.Duration(cfg.CompactionInterval),
+			Durability:            ports.Durability(cfg.Durability),
+			RecoveryMaxWAL:        cfg.RecoveryMaxWAL,
+			RecoveryMaxReplay:     time.Duration(cfg.RecoveryMaxReplay),
+			Logger:                logger,
+			EncryptionKey:         storageKey,
+			PreviousEncryptionKey: previousKey,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize file storage: %v", err)
+		}
+		fileHandle, _ := os.OpenFile(cfg.FilePath, os.O_RDWR|os.O_CREATE, 0666)
+		db, err = domain.NewDatabaseWithStorage(dbConfig, f, fileHandle, logger)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to initialize database with file storage: %v", err)
+		}
+		return db, f, nil
+	}
+
+	dbConfig.UsePages = true
+	dbConfig.BtConfig = btree.BtConfig{
+		Degree:     32,
+		PageSize:   4096,
+		ThreadSafe: cfg.ThreadSafe,
+		CacheSize:  cfg.CacheSize,
+		UseMmap:    cfg.Mmap,
+	}
+	if cfg.KeyDict {
+		dict, err := keydict.Open(cfg.FilePath+".keydict", keydict.Config{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open key dictionary: %v", err)
+		}
+		dbConfig.BtConfig.KeyDict = dict
+	}
+	db, err = domain.NewDatabase(dbConfig, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize database: %v", err)
+	}
+	return db, nil, nil
+}
+
+// runReplica follows a primary until SIGINT/SIGTERM.
+func runReplica(cfg config.Config, logger utils.Logger) {
+	open := func(filePath string) (*domain.Database, error) {
+		return domain.NewDatabase(domain.DatabaseConfig{
+			Name:       "golite-replica",
+			FilePath:   filePath,
+			MaxTables:  100,
+			ThreadSafe: true,
+			BtConfig:   btree.BtConfig{Degree: 32, PageSize: 4096, ThreadSafe: true, CacheSize: 10},
+		}, logger)
+	}
+	replica, err := replication.NewReplica(replication.ReplicaConfig{PrimaryAddr: cfg.ReplicaOf, FilePath: cfg.FilePath}, open, logger)
+	if err != nil {
+		logger.Error("Failed to start replica", "error", err)
+		os.Exit(1)
+	}
+	replica.Start()
+
+	waitForSignal(func() {})
+	logger.Info("Shutting down replica...", "seq", replica.LastSeq())
+	replica.Close()
+}
+
+// startRaft joins the Raft cluster described by the -raft-* flags.
+func startRaft(cfg config.Config, db *domain.Database, logger utils.Logger) (*replication.RaftNode, error) {
+	addrs := make(map[string]string)
+	var peers []string
+	for _, p := range strings.Split(cfg.RaftPeers, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid raft peer %q (want id=host:port)", p)
+		}
+		addrs[id] = addr
+		peers = append(peers, id)
+	}
+	node, err := replication.NewRaftNode(db, replication.RaftConfig{
+		ID:        cfg.RaftID,
+		Peers:     peers,
+		Transport: replication.NewTCPTransport(addrs),
+		StatePath: cfg.FilePath + ".raft",
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RaftListen != "" {
+		if _, err := replication.ServeRaft(node, cfg.RaftListen); err != nil {
+			return nil, err
+		}
+	}
+	node.Start()
+	return node, nil
+}
+
+// waitForSignal blocks until SIGINT or SIGTERM, calling reload on every SIGHUP.
+func waitForSignal(reload func()) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			return
+		}
+		reload()
+	}
+}
+
+// readMasterKey reads a hex-encoded master key from path.
+func readMasterKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key: %v", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("master key file %s must contain a hex-encoded key: %v", path, err)
+	}
+	return key, nil
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/sukryu/GoLite/pkg/config"
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/migrate/sqlite"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// runImportSQLite implements `golite import-sqlite -sqlite <file> [flags]`: it copies the
+// tables of an SQLite database file into the database, one JSON value per row.
+func runImportSQLite(args []string) {
+	cfg := config.Defaults()
+	fs := flag.NewFlagSet("import-sqlite", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: golite import-sqlite -sqlite <file> [flags]")
+		fs.PrintDefaults()
+	}
+	srcPath := fs.String("sqlite", "", "SQLite database file to import")
+	tables := fs.String("tables", "", "Comma-separated SQLite tables to import (default all)")
+	var opts sqlite.ImportOptions
+	fs.StringVar(&opts.Prefix, "prefix", "", "Prefix for the names of the created tables")
+	fs.StringVar(&cfg.StorageType, "storage", cfg.StorageType, "Storage type (btree or file)")
+	fs.StringVar(&cfg.FilePath, "file", cfg.FilePath, "Database file path")
+	fs.StringVar(&cfg.LogLevel, "log-level", "warn", "Minimum log level (info, warn, error, silent)")
+	fs.Parse(args)
+
+	level, err := utils.ParseLogLevel(cfg.LogLevel)
+	logger := utils.NewLevelLogger(utils.NewStructuredLogger(os.Stderr, utils.LogFormatText), level)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+	if *srcPath == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *tables != "" {
+		opts.Tables = strings.Split(*tables, ",")
+	}
+
+	src, err := sqlite.Open(*srcPath)
+	if err != nil {
+		logger.Error("Failed to open SQLite database", "path", *srcPath, "error", err)
+		os.Exit(1)
+	}
+	defer src.Close()
+	db, _, err := openDatabase(cfg, events.NewBus(), logger)
+	if err != nil {
+		logger.Error("Failed to open database", "path", cfg.FilePath, "error", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	results, err := sqlite.Import(ctx, src, db, opts)
+	stop()
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	for _, r := range results {
+		if r.Skipped != nil {
+			fmt.Fprintf(os.Stderr, "%s: skipped (%v)\n", r.Table, r.Skipped)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d rows imported into %s\n", r.Table, r.Rows, r.Target)
+	}
+	if err != nil {
+		logger.Error("SQLite import failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/capture"
+	"github.com/sukryu/GoLite/pkg/config"
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// runReplay implements `golite replay [flags] <capture>`: it applies a mutation capture
+// recorded with -capture to a test instance and reports the achieved throughput.
+func runReplay(args []string) {
+	cfg := config.Defaults()
+	cfg.FilePath = "golite-replay.db"
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: golite replay [flags] <capture>")
+		fs.PrintDefaults()
+	}
+	speedFlag := fs.String("speed", "1x", "Replay speed: max, or a multiplier of the captured pacing such as 1x or 10x")
+	progress := fs.Duration("progress", 5*time.Second, "Progress report interval (0 = off)")
+	fs.StringVar(&cfg.StorageType, "storage", cfg.StorageType, "Target storage type (btree or file)")
+	fs.StringVar(&cfg.FilePath, "file", cfg.FilePath, "Target database file")
+	fs.BoolVar(&cfg.ThreadSafe, "threadsafe", cfg.ThreadSafe, "Enable thread safety")
+	fs.StringVar(&cfg.Durability, "durability", "", "Target write durability: none, async (default), fsync-per-commit or fsync-per-batch")
+	fs.StringVar(&cfg.MasterKeyFile, "master-key-file", "", "File with the hex-encoded master key for encrypted tables")
+	fs.StringVar(&cfg.StorageKeyEnv, "storage-key-env", "", "Environment variable with the hex-encoded key encrypting the whole storage")
+	fs.StringVar(&cfg.LogLevel, "log-level", "warn", "Minimum log level (info, warn, error, silent)")
+	fs.StringVar(&cfg.LogFormat, "log-format", "", "Log output format (text or json)")
+	fs.Parse(args)
+
+	level, err := utils.ParseLogLevel(cfg.LogLevel)
+	format, ferr := utils.ParseLogFormat(cfg.LogFormat)
+	logger := utils.NewLevelLogger(utils.NewStructuredLogger(os.Stdout, format), level)
+	if err == nil {
+		err = ferr
+	}
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	speed, err := capture.ParseSpeed(*speedFlag)
+	if err != nil {
+		logger.Error("Invalid replay speed", "speed", *speedFlag, "error", err)
+		os.Exit(2)
+	}
+
+	reader, err := capture.Open(fs.Arg(0))
+	if err != nil {
+		logger.Error("Failed to open capture", "path", fs.Arg(0), "error", err)
+		os.Exit(1)
+	}
+	defer reader.Close()
+	db, _, err := openDatabase(cfg, events.NewBus(), logger)
+	if err != nil {
+		logger.Error("Failed to open database", "path", cfg.FilePath, "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var lastReport time.Time
+	opts := capture.ReplayOptions{Speed: speed}
+	if *progress > 0 {
+		opts.Progress = func(s capture.ReplayStats) {
+			if time.Since(lastReport) < *progress {
+				return
+			}
+			lastReport = time.Now()
+			fmt.Printf("replayed %d mutations (%d failed), %.0f/s, capture offset %v\n", s.Applied, s.Failed, s.Rate(), s.Captured.Round(time.Millisecond))
+		}
+	}
+	stats, err := capture.Replay(ctx, reader, db, opts)
+	fmt.Printf("Replayed %d mutations (%d failed) in %v: %.0f mutations/s, captured span %v, max lag %v\n",
+		stats.Applied, stats.Failed, stats.Elapsed.Round(time.Millisecond), stats.Rate(),
+		stats.Captured.Round(time.Millisecond), stats.MaxLag.Round(time.Millisecond))
+	if err != nil && err != context.Canceled {
+		logger.Error("Replay failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sukryu/GoLite/pkg/config"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/transfer"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// runExport implements `golite export -table <name> [flags]`: it writes a table as JSON or
+// CSV to a file or stdout.
+func runExport(args []string) {
+	runTransfer("export", "out", "Output file (- = stdout)", args, func(ctx context.Context, db *domain.Database, table string, format transfer.Format, path string) (int, error) {
+		if path == "-" {
+			return transfer.Export(ctx, db, table, format, os.Stdout)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return 0, err
+		}
+		n, err := transfer.Export(ctx, db, table, format, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return n, err
+	})
+}
+
+// runImport implements `golite import -table <name> [flags]`: it reads a table exported with
+// golite export from a file or stdin, creating the table if needed.
+func runImport(args []string) {
+	runTransfer("import", "in", "Input file (- = stdin)", args, func(ctx context.Context, db *domain.Database, table string, format transfer.Format, path string) (int, error) {
+		r := io.Reader(os.Stdin)
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return 0, err
+			}
+			defer f.Close()
+			r = f
+		}
+		return transfer.Import(ctx, db, table, format, r, transfer.DefaultBatchSize)
+	})
+}
+
+// runTransfer parses the flags shared by export and import, opens the database and runs fn.
+func runTransfer(name, pathFlag, pathUsage string, args []string, fn func(ctx context.Context, db *domain.Database, table string, format transfer.Format, path string) (int, error)) {
+	cfg := config.Defaults()
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: golite %s -table <name> [flags]\n", name)
+		fs.PrintDefaults()
+	}
+	table := fs.String("table", "", "Table to "+name)
+	formatFlag := fs.String("format", "json", "Data format (json or csv)")
+	path := fs.String(pathFlag, "-", pathUsage)
+	fs.StringVar(&cfg.StorageType, "storage", cfg.StorageType, "Storage type (btree or file)")
+	fs.StringVar(&cfg.FilePath, "file", cfg.FilePath, "Database file path")
+	fs.StringVar(&cfg.MasterKeyFile, "master-key-file", "", "File with the hex-encoded master key for encrypted tables")
+	fs.StringVar(&cfg.StorageKeyEnv, "storage-key-env", "", "Environment variable with the hex-encoded key encrypting the whole storage")
+	fs.StringVar(&cfg.LogLevel, "log-level", "warn", "Minimum log level (info, warn, error, silent)")
+	fs.Parse(args)
+
+	// 내보낸 데이터가 stdout으로 나가므로 로그는 stderr에 씀
+	level, err := utils.ParseLogLevel(cfg.LogLevel)
+	logger := utils.NewLevelLogger(utils.NewStructuredLogger(os.Stderr, utils.LogFormatText), level)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+	format, err := transfer.ParseFormat(*formatFlag)
+	if err != nil || *table == "" || fs.NArg() != 0 {
+		if err != nil {
+			fmt.Fprintln(fs.Output(), err)
+		}
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, _, err := openDatabase(cfg, events.NewBus(), logger)
+	if err != nil {
+		logger.Error("Failed to open database", "path", cfg.FilePath, "error", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	n, err := fn(ctx, db, *table, format, *path)
+	stop()
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		logger.Error("Failed to "+name+" table", "table", *table, "records", n, "error", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d records of table %s\n", name, n, *table)
+}
@@ -0,0 +1,181 @@
+// Command configstore는 GoLite 위에 계층형 설정 저장소를 구현한 예제입니다.
+//
+// 사용하는 공개 API: 테이블, Insert/Get/Delete, prefix Scan, WriteBatch(여러 키의 원자적 갱신),
+// MutationListener(변경 감시), Snapshot/RestoreFromSnapshot(백업).
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+const configTable = "config"
+
+// Store keeps configuration values under slash-separated keys such as "db/pool/size".
+type Store struct {
+	db       *domain.Database
+	mu       sync.Mutex
+	watchers map[int]chan Change
+	nextID   int
+	remove   func()
+}
+
+// Change is a configuration update delivered to watchers.
+type Change struct {
+	Key     string
+	Value   string
+	Deleted bool
+}
+
+// OpenStore opens (or creates) a configuration store at path.
+func OpenStore(path string) (*Store, error) {
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:       "configstore",
+		FilePath:   path,
+		BtConfig:   btree.BtConfig{Degree: 16, PageSize: 4096, ThreadSafe: true, CacheSize: 64, Checksums: true},
+		ThreadSafe: true,
+	}, &utils.SilentLogger{})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := db.GetSpec().Tables[configTable]; !ok {
+		if err := db.CreateTable(configTable); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	s := &Store{db: db, watchers: make(map[int]chan Change)}
+	s.remove = db.AddMutationListener(s.onMutation)
+	return s, nil
+}
+
+// Set stores a single value.
+func (s *Store) Set(key, value string) error {
+	return s.db.Insert(configTable, key, value)
+}
+
+// Get returns the value stored under key.
+func (s *Store) Get(key string) (string, error) {
+	return s.db.Get(configTable, key)
+}
+
+// Apply replaces several values at once; readers never see a partial update.
+func (s *Store) Apply(set map[string]string, unset ...string) error {
+	batch := domain.NewWriteBatch()
+	for k, v := range set {
+		batch.Insert(configTable, k, v)
+	}
+	for _, k := range unset {
+		batch.Delete(configTable, k)
+	}
+	return s.db.Write(batch)
+}
+
+// List returns all values below the given key prefix, e.g. "db/".
+func (s *Store) List(prefix string) (map[string]string, error) {
+	out := make(map[string]string)
+	err := s.db.Scan(configTable, prefix, func(key, value string) bool {
+		out[key] = value
+		return true
+	})
+	return out, err
+}
+
+// Watch returns a channel receiving every change below prefix and a function to stop watching.
+func (s *Store) Watch(prefix string) (<-chan Change, func()) {
+	ch := make(chan Change, 64)
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.watchers[id] = ch
+	s.mu.Unlock()
+	filtered := make(chan Change, 64)
+	done := make(chan struct{})
+	go func() {
+		defer close(filtered)
+		for {
+			select {
+			case c := <-ch:
+				if !strings.HasPrefix(c.Key, prefix) {
+					continue
+				}
+				select {
+				case filtered <- c:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return filtered, func() {
+		s.mu.Lock()
+		delete(s.watchers, id)
+		s.mu.Unlock()
+		close(done)
+	}
+}
+
+// onMutation runs under the database write lock, so it only hands changes off without blocking.
+func (s *Store) onMutation(m domain.Mutation) {
+	if m.Table != configTable || (m.Op != domain.MutationInsert && m.Op != domain.MutationDelete) {
+		return
+	}
+	c := Change{Key: m.Key, Value: m.Value, Deleted: m.Op == domain.MutationDelete}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.watchers {
+		select {
+		case ch <- c:
+		default: // 느린 watcher는 변경을 놓칠 수 있음
+		}
+	}
+}
+
+// Backup writes a consistent copy of the store to path.
+func (s *Store) Backup(path string) error {
+	return s.db.Snapshot(path)
+}
+
+// Close releases the store.
+func (s *Store) Close() error {
+	s.remove()
+	return s.db.Close()
+}
+
+func main() {
+	dir, err := os.MkdirTemp("", "configstore")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenStore(filepath.Join(dir, "config.db"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	changes, stop := store.Watch("db/")
+	defer stop()
+	if err := store.Apply(map[string]string{"db/host": "localhost", "db/port": "5432", "log/level": "info"}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for i := 0; i < 2; i++ {
+		c := <-changes
+		fmt.Printf("changed %s=%s\n", c.Key, c.Value)
+	}
+	values, _ := store.List("db/")
+	fmt.Printf("db settings: %v\n", values)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestConfigStoreEndToEnd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.db")
+	store, err := OpenStore(path)
+	assert.NoError(t, err)
+
+	changes, stop := store.Watch("db/")
+	assert.NoError(t, store.Apply(map[string]string{"db/host": "localhost", "db/port": "5432", "log/level": "info"}))
+	assert.NoError(t, store.Set("db/port", "6432"))
+	assert.NoError(t, store.Apply(nil, "db/host"))
+
+	var seen []Change
+	for len(seen) < 4 {
+		select {
+		case c := <-changes:
+			seen = append(seen, c)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("missing watch notifications, got %v", seen)
+		}
+	}
+	stop()
+	assert.Equal(t, Change{Key: "db/host", Deleted: true}, seen[3])
+
+	values, err := store.List("db/")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db/port": "6432"}, values)
+
+	backup := filepath.Join(dir, "backup.db")
+	assert.NoError(t, store.Backup(backup))
+	assert.NoError(t, store.Set("log/level", "debug"))
+	assert.NoError(t, store.Close())
+
+	// 백업에서 복원하면 백업 이후의 변경은 사라져야 함.
+	assert.NoError(t, domain.RestoreFromSnapshot(backup, path))
+	store, err = OpenStore(path)
+	assert.NoError(t, err)
+	defer store.Close()
+	level, err := store.Get("log/level")
+	assert.NoError(t, err)
+	assert.Equal(t, "info", level)
+}
@@ -0,0 +1,162 @@
+// Command httpcache는 GoLite를 백엔드로 사용하는 TTL 기반 HTTP 응답 캐시 예제입니다.
+//
+// 값에 만료 시각을 함께 인코딩("<unix nano>|<status>|<body>")해 TTL을 구현하고, 만료된 항목은
+// 조회 시점과 주기적인 prefix Scan 정리(Purge)에서 삭제합니다.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+const cacheTable = "responses"
+
+// Cache is an http.Handler that serves GET responses from GoLite and forwards misses upstream.
+type Cache struct {
+	db       *domain.Database
+	upstream http.Handler
+	ttl      time.Duration
+	now      func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCache opens a cache database at path in front of upstream.
+func NewCache(path string, upstream http.Handler, ttl time.Duration) (*Cache, error) {
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:       "httpcache",
+		FilePath:   path,
+		BtConfig:   btree.BtConfig{Degree: 16, PageSize: 4096, ThreadSafe: true, CacheSize: 128},
+		ThreadSafe: true,
+	}, &utils.SilentLogger{})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := db.GetSpec().Tables[cacheTable]; !ok {
+		if err := db.CreateTable(cacheTable); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &Cache{db: db, upstream: upstream, ttl: ttl, now: time.Now}, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (c *Cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		c.upstream.ServeHTTP(w, r)
+		return
+	}
+	key := r.URL.RequestURI()
+	if raw, err := c.db.Get(cacheTable, key); err == nil {
+		if status, body, ok := c.decode(raw); ok {
+			c.hits.Add(1)
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(status)
+			w.Write(body)
+			return
+		}
+		c.db.Delete(cacheTable, key) // 만료된 항목
+	}
+	c.misses.Add(1)
+
+	rec := httptest.NewRecorder()
+	c.upstream.ServeHTTP(rec, r)
+	res := rec.Result()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode == http.StatusOK {
+		c.db.Insert(cacheTable, key, c.encode(res.StatusCode, body))
+	}
+	for k, v := range res.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("X-Cache", "MISS")
+	w.WriteHeader(res.StatusCode)
+	w.Write(body)
+}
+
+// Purge removes expired entries below the URL prefix and returns how many were removed.
+func (c *Cache) Purge(prefix string) (int, error) {
+	batch := domain.NewWriteBatch()
+	err := c.db.Scan(cacheTable, prefix, func(key, value string) bool {
+		if _, _, ok := c.decode(value); !ok {
+			batch.Delete(cacheTable, key)
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return batch.Len(), c.db.Write(batch)
+}
+
+// Stats returns hit and miss counters.
+func (c *Cache) Stats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Close releases the cache database.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+func (c *Cache) encode(status int, body []byte) string {
+	expires := c.now().Add(c.ttl).UnixNano()
+	return fmt.Sprintf("%d|%d|%s", expires, status, body)
+}
+
+// decode parses a cached value; ok is false for expired or malformed entries.
+func (c *Cache) decode(raw string) (status int, body []byte, ok bool) {
+	parts := strings.SplitN(raw, "|", 3)
+	if len(parts) != 3 {
+		return 0, nil, false
+	}
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || c.now().UnixNano() >= expires {
+		return 0, nil, false
+	}
+	status, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, nil, false
+	}
+	return status, []byte(parts[2]), true
+}
+
+func main() {
+	dir, err := os.MkdirTemp("", "httpcache")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "generated %s at %s", r.URL.Path, time.Now().Format(time.RFC3339Nano))
+	})
+	cache, err := NewCache(filepath.Join(dir, "cache.db"), upstream, time.Minute)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer cache.Close()
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		cache.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+		fmt.Printf("%s %s\n", rec.Header().Get("X-Cache"), bytes.TrimSpace(rec.Body.Bytes()))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPCacheEndToEnd(t *testing.T) {
+	var calls atomic.Int64
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "%s #%d", r.URL.Path, n)
+	})
+	cache, err := NewCache(filepath.Join(t.TempDir(), "cache.db"), upstream, time.Minute)
+	assert.NoError(t, err)
+	defer cache.Close()
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	server := httptest.NewServer(cache)
+	defer server.Close()
+	get := func(path string) (string, string, int) {
+		res, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return string(body), res.Header.Get("X-Cache"), res.StatusCode
+	}
+
+	body, state, _ := get("/a")
+	assert.Equal(t, "/a #1", body)
+	assert.Equal(t, "MISS", state)
+	body, state, _ = get("/a")
+	assert.Equal(t, "/a #1", body)
+	assert.Equal(t, "HIT", state)
+
+	_, _, status := get("/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+	_, state, _ = get("/missing")
+	assert.Equal(t, "MISS", state, "errors are not cached")
+
+	get("/b")
+	now = now.Add(2 * time.Minute)
+	body, state, _ = get("/a")
+	assert.Equal(t, "MISS", state, "expired entries are refetched")
+	assert.Equal(t, "/a #5", body)
+
+	purged, err := cache.Purge("/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged, "only /b is expired")
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, int64(1), hits)
+	assert.Equal(t, int64(5), misses)
+}
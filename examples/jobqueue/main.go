@@ -0,0 +1,182 @@
+// Command jobqueue는 GoLite 위에 내구성 있는 FIFO 작업 큐를 구현한 예제입니다.
+//
+// 작업은 0으로 채운 순번 키("00000000000000000042")로 pending 테이블에 저장되므로 prefix Scan이
+// 곧 FIFO 순서가 됩니다. 상태 전이(pending -> running -> 삭제)는 WriteBatch로 원자적으로 수행하고,
+// 큐 길이는 스캔 없이 Count로 구합니다.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+const (
+	pendingTable = "pending"
+	runningTable = "running"
+	metaTable    = "meta"
+	nextIDKey    = "next_id"
+)
+
+// ErrEmpty is returned by Dequeue when no job is pending.
+var ErrEmpty = errors.New("queue is empty")
+
+// Job is a unit of work.
+type Job struct {
+	ID      string
+	Payload string
+}
+
+// Queue is a persistent FIFO job queue.
+type Queue struct {
+	db     *domain.Database
+	mu     sync.Mutex // Serializes ID allocation and dequeue
+	nextID uint64
+}
+
+// OpenQueue opens (or creates) a queue at path. Jobs left running by a crashed worker
+// are moved back to pending.
+func OpenQueue(path string) (*Queue, error) {
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:       "jobqueue",
+		FilePath:   path,
+		BtConfig:   btree.BtConfig{Degree: 16, PageSize: 4096, ThreadSafe: true, CacheSize: 64},
+		ThreadSafe: true,
+	}, &utils.SilentLogger{})
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range []string{pendingTable, runningTable, metaTable} {
+		if _, ok := db.GetSpec().Tables[table]; ok {
+			continue
+		}
+		if err := db.CreateTable(table); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	q := &Queue{db: db}
+	if v, err := db.Get(metaTable, nextIDKey); err == nil {
+		q.nextID, _ = strconv.ParseUint(v, 10, 64)
+	}
+	if err := q.requeueRunning(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// Enqueue adds jobs in order and returns their IDs. All jobs are added in one batch.
+func (q *Queue) Enqueue(payloads ...string) ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	batch := domain.NewWriteBatch()
+	ids := make([]string, len(payloads))
+	next := q.nextID
+	for i, p := range payloads {
+		next++
+		ids[i] = fmt.Sprintf("%020d", next)
+		batch.Insert(pendingTable, ids[i], p)
+	}
+	batch.Insert(metaTable, nextIDKey, strconv.FormatUint(next, 10))
+	if err := q.db.Write(batch); err != nil {
+		return nil, err
+	}
+	q.nextID = next
+	return ids, nil
+}
+
+// Dequeue moves the oldest pending job to running and returns it.
+func (q *Queue) Dequeue() (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var job Job
+	found := false
+	err := q.db.Scan(pendingTable, "", func(key, value string) bool {
+		job, found = Job{ID: key, Payload: value}, true
+		return false
+	})
+	if err != nil {
+		return Job{}, err
+	}
+	if !found {
+		return Job{}, ErrEmpty
+	}
+	batch := domain.NewWriteBatch().Delete(pendingTable, job.ID).Insert(runningTable, job.ID, job.Payload)
+	if err := q.db.Write(batch); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Complete removes a finished job.
+func (q *Queue) Complete(id string) error {
+	return q.db.Delete(runningTable, id)
+}
+
+// Depth returns the number of pending and running jobs.
+func (q *Queue) Depth() (pending, running int64, err error) {
+	if pending, err = q.db.Count(pendingTable); err != nil {
+		return 0, 0, err
+	}
+	running, err = q.db.Count(runningTable)
+	return pending, running, err
+}
+
+// requeueRunning returns jobs that were running when the queue was last closed to pending.
+func (q *Queue) requeueRunning() error {
+	batch := domain.NewWriteBatch()
+	err := q.db.Scan(runningTable, "", func(key, value string) bool {
+		batch.Delete(runningTable, key).Insert(pendingTable, key, value)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return q.db.Write(batch)
+}
+
+// Close releases the queue.
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+func main() {
+	dir, err := os.MkdirTemp("", "jobqueue")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := OpenQueue(filepath.Join(dir, "jobs.db"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer q.Close()
+
+	if _, err := q.Enqueue("resize image 1", "resize image 2", "send email"); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for {
+		job, err := q.Dequeue()
+		if errors.Is(err, ErrEmpty) {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("processing %s: %s\n", job.ID, job.Payload)
+		q.Complete(job.ID)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobQueueEndToEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	q, err := OpenQueue(path)
+	assert.NoError(t, err)
+
+	ids, err := q.Enqueue("a", "b", "c")
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+
+	job, err := q.Dequeue()
+	assert.NoError(t, err)
+	assert.Equal(t, Job{ID: ids[0], Payload: "a"}, job, "jobs are dequeued in FIFO order")
+	assert.NoError(t, q.Complete(job.ID))
+
+	job, err = q.Dequeue()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", job.Payload)
+	pending, running, err := q.Depth()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+	assert.Equal(t, int64(1), running)
+
+	// 실행 중이던 작업은 재시작 후 다시 pending으로 돌아와 먼저 처리되어야 함.
+	assert.NoError(t, q.Close())
+	q, err = OpenQueue(path)
+	assert.NoError(t, err)
+	defer q.Close()
+	job, err = q.Dequeue()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", job.Payload)
+	assert.NoError(t, q.Complete(job.ID))
+
+	more, err := q.Enqueue("d")
+	assert.NoError(t, err)
+	assert.True(t, more[0] > ids[2], "IDs keep increasing across reopen")
+}
+
+func TestJobQueueConcurrentWorkers(t *testing.T) {
+	q, err := OpenQueue(filepath.Join(t.TempDir(), "jobs.db"))
+	assert.NoError(t, err)
+	defer q.Close()
+
+	const jobs = 50
+	for i := 0; i < jobs; i++ {
+		_, err := q.Enqueue(fmt.Sprintf("job-%d", i))
+		assert.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	done := make(map[string]bool)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := q.Dequeue()
+				if errors.Is(err, ErrEmpty) {
+					return
+				}
+				assert.NoError(t, err)
+				mu.Lock()
+				assert.False(t, done[job.ID], "job %s processed twice", job.ID)
+				done[job.ID] = true
+				mu.Unlock()
+				assert.NoError(t, q.Complete(job.ID))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, done, jobs)
+	pending, running, err := q.Depth()
+	assert.NoError(t, err)
+	assert.Zero(t, pending+running)
+}
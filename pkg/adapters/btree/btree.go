@@ -2,16 +2,34 @@ package btree
 
 import (
 	"bytes"
-	"container/list"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
 	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
-var _ ports.StoragePort = (*Btree)(nil)
+var (
+	_ ports.ManagedStorage = (*Btree)(nil)
+	_ ports.ScannablePort  = (*Btree)(nil)
+	_ ports.SubtreePort    = (*Btree)(nil)
+	_ ports.PrefixDeleter  = (*Btree)(nil)
+	_ ports.RangeDeleter   = (*Btree)(nil)
+	_ ports.BytesPort      = (*Btree)(nil)
+	_ ports.EntryLimiter   = (*Btree)(nil)
+	_ ports.Rekeyer        = (*Btree)(nil)
+	_ ports.SizeReporter   = (*Btree)(nil)
+	_ ports.StatusReporter = (*Btree)(nil)
+)
 
 // BtConfig holds configuration for the B-tree.
 type BtConfig struct {
@@ -19,62 +37,257 @@ type BtConfig struct {
 	PageSize   int
 	ThreadSafe bool
 	CacheSize  int // Max Number of nodes to cache (0 = no caching)
+	// CacheBytes is the memory budget of the node buffer pool in bytes, estimated from the
+	// keys and values of the cached nodes. It takes precedence over CacheSize when set.
+	CacheBytes int64
+	// CacheShards is the number of independently locked cache segments (rounded down to a
+	// power of two). 0 picks up to 16 depending on CacheSize.
+	CacheShards int
+	// Checksums appends a CRC32 to every node page and verifies it on read. The setting is
+	// recorded in the header page; an existing tree keeps the mode it was created with.
+	Checksums bool
+	// FallbackPath is a redundant copy of the database file (a mirror or a recent snapshot)
+	// used to serve and repair pages that fail validation.
+	FallbackPath string
+	// MirrorWrites keeps FallbackPath up to date by writing every page to it as well.
+	MirrorWrites bool
+	// ReservedPages is the number of pages after the B-tree header (page 0) that the
+	// embedding layer owns, e.g. the database header on page 1. Nodes are never allocated there.
+	ReservedPages int
+	// KeyDict stores keys with a known hot prefix as (dictID, suffix). The dictionary must be
+	// persisted and reopened with the tree once any page has been written with it.
+	KeyDict *keydict.Dictionary
+	// UseMmap memory-maps the tree file and decodes node pages straight from the mapping
+	// instead of reading each page into a new buffer. Where mmap is unavailable, pages are
+	// read with ReadAt as usual.
+	UseMmap bool
+	// WriteBack keeps modified nodes dirty in the buffer pool instead of writing every change
+	// through. Dirty pages are written when evicted, by a background flusher every
+	// FlushInterval (default 1s, thread-safe trees only) and by Sync and Close. Writes that
+	// were not yet written back are lost on a crash, so callers needing durability must Sync.
+	// Without a cache budget every write goes straight to disk.
+	WriteBack     bool
+	FlushInterval time.Duration
+	// EncryptionKey seals every node page with AES-256-GCM (pagecrypt.KeySize bytes; see
+	// encryption.go). A tree created with a key can only be opened with it; Rekey changes it.
+	EncryptionKey []byte
+	// PreviousEncryptionKey is the key an interrupted Rekey was replacing. Opening with both
+	// keys finishes the rekey.
+	PreviousEncryptionKey []byte
 }
 
 // Btree represents a disk-based B-tree.
 type Btree struct {
-	Degree     int          // Minimum degree (t)
-	Length     int          // Total number of items in the tree
-	RootOffset int64        // Offset of the root node in the disk file
-	file       *os.File     // Disk file handle
-	pageSize   int          // Page size in bytes
-	nextOffset int64        // Next available offset for new nodes
-	mu         sync.RWMutex // Mutex for thread safety
-	threadSafe bool         // Flag for thread safety
-
-	// Cache fields
-	cache     map[int64]*Node // Offset to Node mapping
-	cacheList *list.List      // LRU list for eviction
-	cacheSize int             // Max cache capacity
-	cacheMu   sync.RWMutex    // Separate mutex for cache operations
+	*pager
+	Degree     int   // Minimum degree (t)
+	Length     int   // Total number of items in the tree
+	RootOffset int64 // Offset of the root node in the disk file
+	// persist stores the root and length of a subtree; nil for the main tree,
+	// whose metadata lives in the header page (page 0).
+	persist func(root int64, length int) error
+
+	rootLatch sync.RWMutex // Guards RootOffset during latch crabbing (see latch.go)
+	metaMu    sync.Mutex   // Guards Length, RootOffset and header writes
+}
+
+// pager holds the state shared by the main tree and its subtrees: the file, the page
+// allocator, the node cache and the integrity machinery. All trees of a file share one lock
+// and one latch table.
+type pager struct {
+	file       *os.File                          // Disk file handle
+	pageSize   int                               // Page size in bytes
+	nextOffset atomic.Int64                      // Next available offset for new nodes
+	firstNode  int64                             // Offset of the first allocatable node page
+	checksums  bool                              // Node pages carry a CRC32 trailer
+	keys       atomic.Pointer[pagecrypt.Keyring] // Seals node pages (nil = unencrypted, see encryption.go)
+	rekeying   bool                              // A Rekey has not finished re-sealing the pages
+	keyDict    *keydict.Dictionary               // Key prefix dictionary (nil = keys stored verbatim)
+	mu         sync.RWMutex                      // Shared by latching operations, exclusive for the others
+	latches    *latchTable                       // Page latches (nil unless thread-safe)
+	threadSafe bool                              // Flag for thread safety
+	closed     bool                              // Close was called
+
+	cache *bufferPool // Sharded node buffer pool (see bufferpool.go)
+
+	headerDirty bool          // Write-back mode: page 0 must be written at the next flush
+	stopFlush   chan struct{} // Closed to stop the background flusher (nil if none)
+	flushDone   sync.WaitGroup
+	stopOnce    sync.Once
+
+	// Memory mapping (see mmap.go)
+	useMmap atomic.Bool  // Cleared if mapping fails or the tree is closed
+	mmapMu  sync.RWMutex // Held for reading while a page of mmap is decoded
+	mmap    []byte       // Mapped prefix of the file (nil until the first mapped read)
+
+	// Integrity fields
+	fallback     *os.File         // Redundant copy for page recovery (nil if not configured)
+	mirrorWrites bool             // Write every page to fallback as well
+	corruptions  atomic.Int64     // Number of corrupt pages/nodes detected
+	repairs      atomic.Int64     // Number of pages repaired from the fallback copy
+	quarantineMu sync.Mutex       // Protects quarantine
+	quarantine   map[int64]string // Offsets of unrecoverable pages and why
 }
 
 // Node represents a single node in the B-tree.
 type Node struct {
-	items           []Item        // Stored key-value pairs
-	childrenOffsets []int64       // Offsets of child nodes
-	offset          int64         // Disk offset of this node
-	elem            *list.Element // LRU list element reference
+	items           []Item  // Stored key-value pairs
+	childrenOffsets []int64 // Offsets of child nodes
+	offset          int64   // Disk offset of this node
 }
 
 // Item represents a key-value pair with fixed-size fields for optimization.
 type Item struct {
 	Key   string // Variable-length key (length prefixed)
-	Value string // Fixed as string for simplicity (interface{} 대신)
+	Value []byte // Raw value bytes; shared with the node cache, never modified in place
 }
 
 func (b *Btree) GetRootOffset() int64 {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
 	return b.RootOffset
 }
 
 // GetLength returns the total number of items in the B-tree.
 func (b *Btree) GetLength() int {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	return b.Length
+	return b.length()
 }
 
 // GetCacheSize returns the current number of nodes in the cache.
 func (b *Btree) GetCacheSize() int {
-	b.cacheMu.RLock()
-	defer b.cacheMu.RUnlock()
-	return b.cacheList.Len()
+	return b.cache.len()
+}
+
+// DiskSize returns the size of the tree file up to its last allocated page. Pages of deleted
+// nodes are not reclaimed, so it does not shrink.
+func (b *Btree) DiskSize() int64 {
+	return b.nextOffset.Load()
+}
+
+// StorageStatus reports the node cache counters. The tree is never compacted.
+func (b *Btree) StorageStatus() ports.StorageStatus {
+	return ports.StorageStatus{CacheHits: b.cache.hits.Load(), CacheMisses: b.cache.misses.Load()}
+}
+
+// Stats returns tree shape, cache and integrity counters.
+func (b *Btree) Stats() map[string]interface{} {
+	b.metaMu.Lock()
+	stats := map[string]interface{}{
+		"length":      b.Length,
+		"degree":      b.Degree,
+		"page_size":   b.pageSize,
+		"root_offset": b.RootOffset,
+		"file_size":   b.nextOffset.Load(),
+		"checksums":   b.checksums,
+	}
+	b.metaMu.Unlock()
+	if b.useMmap.Load() {
+		b.mmapMu.RLock()
+		stats["mmap_bytes"] = len(b.mmap)
+		b.mmapMu.RUnlock()
+	}
+	stats["cached_nodes"] = b.GetCacheSize()
+	stats["cache_capacity"] = b.cache.capacity.Load()
+	stats["cache_shards"] = len(b.cache.shards)
+	stats["cache_hits"] = b.cache.hits.Load()
+	stats["cache_misses"] = b.cache.misses.Load()
+	poolBytes, dirty, pinned := b.cache.usage()
+	stats["cache_bytes"] = poolBytes
+	stats["cache_bytes_budget"] = b.cache.budget.Load()
+	stats["dirty_pages"] = dirty
+	stats["pinned_pages"] = pinned
+	stats["write_backs"] = b.cache.writeBacks.Load()
+	stats["corruptions"] = b.CorruptionCount()
+	stats["repairs"] = b.RepairCount()
+	stats["quarantined_pages"] = len(b.QuarantinedPages())
+	if b.keyDict != nil {
+		stats["key_dict"] = b.keyDict.Stats()
+	}
+	return stats
+}
+
+// EntryLimits reports the entry sizes the tree can always store: keys and values carry 16-bit
+// lengths (keys lose the top bit to keydict.EncodedFlag), and MaxEntry is the largest entry
+// size at which a full node (2*Degree-1 entries and 2*Degree child offsets) still fits a page.
+// Larger entries can be written as long as their node has room.
+func (b *Btree) EntryLimits() ports.EntryLimits {
+	maxItems := 2*b.Degree - 1
+	room := b.pageCapacity() - 8 - 2*b.Degree*8 // item/child counts and child offsets
+	return ports.EntryLimits{
+		MaxKey:   int(keydict.EncodedFlag) - 1,
+		MaxValue: 1<<16 - 1,
+		MaxEntry: room/maxItems - 4, // 4 = key and value length fields
+	}
+}
+
+// Sync writes the header and fsyncs the tree file (and the mirror, if any).
+func (b *Btree) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sync()
+}
+
+func (b *Btree) sync() error {
+	if err := b.flushPages(); err != nil {
+		return err
+	}
+	if err := b.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync tree file: %v", err)
+	}
+	if b.fallback != nil && b.mirrorWrites {
+		if err := b.fallback.Sync(); err != nil {
+			return fmt.Errorf("failed to sync mirror file: %v", err)
+		}
+	}
+	return nil
+}
+
+// Close syncs the tree, closes the fallback copy and releases the lock on the tree file.
+// The file itself belongs to the caller that passed it to NewBtree and stays open.
+func (b *Btree) Close() error {
+	b.stopFlusher()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	err := b.sync()
+	b.unmap()
+	if b.fallback != nil {
+		if cerr := b.fallback.Close(); err == nil {
+			err = cerr
+		}
+		b.fallback = nil
+	}
+	utils.UnlockFile(b.file)
+	return err
 }
 
-// NewBtree creates a new B-tree instance.
+// NewBtree creates a new B-tree instance. A header that cannot be read, including one
+// written in another byte order, is replaced with an empty tree; see OpenBtree. NewBtree
+// returns nil if file is locked by another process or the encryption keys don't match it;
+// use OpenBtree to get the error.
 func NewBtree(file *os.File, config BtConfig) *Btree {
+	b, _ := newBtree(file, config, false)
+	return b
+}
+
+// OpenBtree is like NewBtree but fails with ErrByteOrder instead of overwriting the header
+// of a file written in another byte order. Both take an exclusive lock on file that fails
+// with utils.ErrLocked while another process has the file open; Close releases it.
+func OpenBtree(file *os.File, config BtConfig) (*Btree, error) {
+	return newBtree(file, config, true)
+}
+
+func newBtree(file *os.File, config BtConfig, strict bool) (*Btree, error) {
+	if err := utils.LockFile(file); err != nil {
+		return nil, err
+	}
+	keys, err := pagecrypt.NewKeyring(config.EncryptionKey, config.PreviousEncryptionKey)
+	if err != nil {
+		utils.UnlockFile(file)
+		return nil, err
+	}
 	degree := config.Degree
 	if degree <= 0 {
 		degree = 32
@@ -87,24 +300,75 @@ func NewBtree(file *os.File, config BtConfig) *Btree {
 	if cacheSize < 0 {
 		cacheSize = 0 // Disable caching if negative
 	}
+	reserved := config.ReservedPages
+	if reserved < 0 {
+		reserved = 0
+	}
 	b := &Btree{
 		Degree:     degree,
-		file:       file,
-		pageSize:   pageSize,
 		RootOffset: 0,
-		nextOffset: int64(pageSize),
-		threadSafe: config.ThreadSafe,
-		cache:      make(map[int64]*Node),
-		cacheList:  list.New(),
-		cacheSize:  cacheSize,
-	}
+		pager: &pager{
+			file:       file,
+			pageSize:   pageSize,
+			firstNode:  int64(pageSize * (1 + reserved)),
+			checksums:  config.Checksums,
+			keyDict:    config.KeyDict,
+			threadSafe: config.ThreadSafe,
+			cache:      newBufferPool(cacheSize, config.CacheBytes, config.CacheShards),
+			quarantine: make(map[int64]string),
+		},
+	}
+	b.keys.Store(keys)
+	b.nextOffset.Store(b.firstNode)
+	if config.ThreadSafe {
+		b.latches = &latchTable{}
+		b.cache.latches = b.latches
+	}
+	b.cache.writeBack = config.WriteBack
+	b.cache.write = func(n *Node) error { return b.writeNodeToDisk(n, n.offset) }
+	b.useMmap.Store(config.UseMmap)
+	b.openFallback(config)
 
 	// Load metadata from header page (page 0)
 	if err := b.loadHeader(); err != nil {
+		if strict && errors.Is(err, ErrByteOrder) || pagecrypt.IsKeyError(err) {
+			if b.fallback != nil {
+				b.fallback.Close()
+			}
+			utils.UnlockFile(file)
+			return nil, err
+		}
 		// If file is new or empty, initialize with default values
 		b.saveHeader()
 	}
-	return b
+	if b.rekeying {
+		if err := b.rekeyPages(); err != nil {
+			if b.fallback != nil {
+				b.fallback.Close()
+			}
+			utils.UnlockFile(file)
+			return nil, fmt.Errorf("failed to finish rekey: %v", err)
+		}
+	}
+	if config.WriteBack && config.ThreadSafe {
+		b.startFlusher(config.FlushInterval)
+	}
+	return b, nil
+}
+
+// Subtree returns an independent tree stored in the same file, sharing the page allocator,
+// cache and lock with b. A new subtree starts with root 0 and length 0. The subtree's
+// metadata is not written to page 0; instead persist is called whenever its root or
+// length changes so the owner can record them (e.g. in a table header).
+// Pages of a discarded subtree are not reclaimed.
+func (b *Btree) Subtree(root int64, length int, persist func(root int64, length int) error) ports.StoragePort {
+	return &Btree{
+		pager:      b.pager,
+		Degree:     b.Degree,
+		RootOffset: root,
+		Length:     length,
+		persist:    persist,
+	}
 }
 
 // loadHeader reads the root offset and length from the header page.
@@ -117,30 +381,86 @@ func (b *Btree) loadHeader() error {
 	buf := bytes.NewReader(data)
 	var rootOffset int64
 	var length int32
-	if err := binary.Read(buf, binary.LittleEndian, &rootOffset); err != nil {
+	if err := binary.Read(buf, byteOrder, &rootOffset); err != nil {
 		return nil // New file, no header yet
 	}
-	if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
+	if err := binary.Read(buf, byteOrder, &length); err != nil {
 		return nil // Partial header, treat as new
 	}
+	var flags, mark, keyID, previousID uint32
+	if err := binary.Read(buf, byteOrder, &flags); err == nil && (length > 0 || flags != 0) {
+		// 기존 트리는 생성 시의 체크섬 모드를 유지 (이전 포맷의 헤더는 flags가 0).
+		b.checksums = flags&headerFlagChecksums != 0
+	}
+	if err := binary.Read(buf, byteOrder, &mark); err == nil {
+		if err := checkByteOrderMark(mark); err != nil {
+			return err
+		}
+	}
+	binary.Read(buf, byteOrder, &keyID)
+	binary.Read(buf, byteOrder, &previousID)
+	var size int64
+	if stat, err := b.file.Stat(); err == nil {
+		size = stat.Size()
+	}
+	if err := b.checkKeys(flags, keyID, previousID, size > b.firstNode); err != nil {
+		return err
+	}
 	b.RootOffset = rootOffset
 	b.Length = int(length)
-	b.nextOffset = int64(b.pageSize) // Reset if needed
-	if stat, err := b.file.Stat(); err == nil && stat.Size() > int64(b.pageSize) {
-		b.nextOffset = stat.Size() // Use file size for existing data
+	b.nextOffset.Store(b.firstNode) // Reset if needed
+	if size > b.firstNode {
+		b.nextOffset.Store(size) // Use file size for existing data
 	}
 	return nil
 }
 
-// saveHeader writes the root offset and length to the header page.
+// saveHeader records the root offset and length: in the header page, or through persist
+// for a subtree. In write-back mode the header page is written with the next flush instead.
 func (b *Btree) saveHeader() error {
+	if b.persist != nil {
+		return b.persist(b.RootOffset, b.Length)
+	}
+	if b.writeBack() {
+		b.headerDirty = true
+		return nil
+	}
+	return b.writeHeader()
+}
+
+// writeHeader writes the root offset and length to the header page.
+func (b *Btree) writeHeader() error {
 	buf := bytes.NewBuffer(make([]byte, 0, b.pageSize))
-	if err := binary.Write(buf, binary.LittleEndian, b.RootOffset); err != nil {
+	if err := binary.Write(buf, byteOrder, b.RootOffset); err != nil {
 		return fmt.Errorf("failed to write root offset: %v", err)
 	}
-	if err := binary.Write(buf, binary.LittleEndian, int32(b.Length)); err != nil {
+	if err := binary.Write(buf, byteOrder, int32(b.Length)); err != nil {
 		return fmt.Errorf("failed to write length: %v", err)
 	}
+	var flags uint32
+	if b.checksums {
+		flags |= headerFlagChecksums
+	}
+	if b.keys.Load() != nil {
+		flags |= headerFlagEncrypted
+	}
+	if b.rekeying {
+		flags |= headerFlagRekeying
+	}
+	if err := binary.Write(buf, byteOrder, flags); err != nil {
+		return fmt.Errorf("failed to write header flags: %v", err)
+	}
+	if err := binary.Write(buf, byteOrder, byteOrderMark); err != nil {
+		return fmt.Errorf("failed to write byte order mark: %v", err)
+	}
+	if keys := b.keys.Load(); keys != nil {
+		var previousID uint32
+		if b.rekeying {
+			previousID = keys.Previous().ID()
+		}
+		binary.Write(buf, byteOrder, keys.Current().ID())
+		binary.Write(buf, byteOrder, previousID)
+	}
 	data := buf.Bytes()
 	padded := make([]byte, b.pageSize)
 	copy(padded, data)
@@ -153,196 +473,184 @@ func (b *Btree) saveHeader() error {
 
 // readNodeFromDisk reads a node directly from disk.
 func (b *Btree) readNodeFromDisk(offset int64) (*Node, error) {
+	if b.useMmap.Load() {
+		if n, mapped, err := b.readNodeMapped(offset); mapped {
+			return n, err
+		}
+	}
 	data := make([]byte, b.pageSize)
 	_, err := b.file.ReadAt(data, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read node from disk: %v", err)
 	}
+	return b.decodeNode(data, offset)
+}
+
+// decodeNode parses and validates a node page. Malformed pages are reported as *CorruptPageError.
+func (b *Btree) decodeNode(data []byte, offset int64) (*Node, error) {
+	n, err := b.parseNode(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.validateNode(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// parseNode is decodeNode without validateNode: the page only has to be well-formed.
+func (b *Btree) parseNode(data []byte, offset int64) (*Node, error) {
+	data, err := b.decryptPage(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.verifyPage(data, offset); err != nil {
+		return nil, err
+	}
 	buf := bytes.NewReader(data)
 	var itemsCount, childrenCount uint32
-	if err := binary.Read(buf, binary.LittleEndian, &itemsCount); err != nil {
-		return nil, fmt.Errorf("failed to read items count: %v", err)
+	if err := binary.Read(buf, byteOrder, &itemsCount); err != nil {
+		return nil, corruptPage(offset, "failed to read items count: %v", err)
+	}
+	if err := binary.Read(buf, byteOrder, &childrenCount); err != nil {
+		return nil, corruptPage(offset, "failed to read children count: %v", err)
 	}
-	if err := binary.Read(buf, binary.LittleEndian, &childrenCount); err != nil {
-		return nil, fmt.Errorf("failed to read children count: %v", err)
+	// 각 아이템은 최소 4바이트, 자식 오프셋은 8바이트이므로 페이지 크기로 상한을 검사.
+	if int(itemsCount)*4+int(childrenCount)*8 > len(data) {
+		return nil, corruptPage(offset, "implausible counts: %d items, %d children", itemsCount, childrenCount)
 	}
 	n := &Node{offset: offset}
 	n.items = make([]Item, itemsCount)
 	for i := uint32(0); i < itemsCount; i++ {
 		var keyLen uint16
-		if err := binary.Read(buf, binary.LittleEndian, &keyLen); err != nil {
-			return nil, fmt.Errorf("failed to read key length: %v", err)
+		if err := binary.Read(buf, byteOrder, &keyLen); err != nil {
+			return nil, corruptPage(offset, "failed to read key length: %v", err)
 		}
-		keyBytes := make([]byte, keyLen)
-		if _, err := buf.Read(keyBytes); err != nil {
-			return nil, fmt.Errorf("failed to read key: %v", err)
+		encoded := keyLen&keydict.EncodedFlag != 0
+		keyBytes := make([]byte, keyLen&^keydict.EncodedFlag)
+		if _, err := io.ReadFull(buf, keyBytes); err != nil {
+			return nil, corruptPage(offset, "failed to read key: %v", err)
+		}
+		key := string(keyBytes)
+		if encoded {
+			if b.keyDict == nil {
+				return nil, fmt.Errorf("node at offset %d uses a key dictionary but none is configured", offset)
+			}
+			var err error
+			if key, err = b.keyDict.Decode(key); err != nil {
+				return nil, corruptPage(offset, "failed to decode key: %v", err)
+			}
 		}
 		var valueLen uint16
-		if err := binary.Read(buf, binary.LittleEndian, &valueLen); err != nil {
-			return nil, fmt.Errorf("failed to read value length: %v", err)
+		if err := binary.Read(buf, byteOrder, &valueLen); err != nil {
+			return nil, corruptPage(offset, "failed to read value length: %v", err)
 		}
 		valueBytes := make([]byte, valueLen)
-		if _, err := buf.Read(valueBytes); err != nil {
-			return nil, fmt.Errorf("failed to read value: %v", err)
+		if _, err := io.ReadFull(buf, valueBytes); err != nil {
+			return nil, corruptPage(offset, "failed to read value: %v", err)
 		}
-		n.items[i] = Item{Key: string(keyBytes), Value: string(valueBytes)}
+		n.items[i] = Item{Key: key, Value: valueBytes}
 	}
 	n.childrenOffsets = make([]int64, childrenCount)
 	for i := uint32(0); i < childrenCount; i++ {
 		var childOffset int64
-		if err := binary.Read(buf, binary.LittleEndian, &childOffset); err != nil {
-			return nil, fmt.Errorf("failed to read child offset: %v", err)
+		if err := binary.Read(buf, byteOrder, &childOffset); err != nil {
+			return nil, corruptPage(offset, "failed to read child offset: %v", err)
 		}
 		n.childrenOffsets[i] = childOffset
 	}
 	return n, nil
 }
 
-// writeNodeToDisk serializes and writes a node to disk.
+// writeNodeToDisk serializes and writes a node to disk. It only reads n, so eviction may
+// write a node back while readers hold it.
 func (b *Btree) writeNodeToDisk(n *Node, offset int64) error {
 	buf := bytes.NewBuffer(make([]byte, 0, b.pageSize))
-	err := binary.Write(buf, binary.LittleEndian, uint32(len(n.items)))
+	err := binary.Write(buf, byteOrder, uint32(len(n.items)))
 	if err != nil {
 		return fmt.Errorf("failed to write items count: %v", err)
 	}
-	err = binary.Write(buf, binary.LittleEndian, uint32(len(n.childrenOffsets)))
+	err = binary.Write(buf, byteOrder, uint32(len(n.childrenOffsets)))
 	if err != nil {
 		return fmt.Errorf("failed to write children count: %v", err)
 	}
 	for _, item := range n.items {
-		keyLen := uint16(len(item.Key))
-		if err := binary.Write(buf, binary.LittleEndian, keyLen); err != nil {
+		key, encoded := b.keyDict.Encode(item.Key)
+		keyLen := uint16(len(key))
+		if encoded {
+			keyLen |= keydict.EncodedFlag
+		}
+		if err := binary.Write(buf, byteOrder, keyLen); err != nil {
 			return fmt.Errorf("failed to write key length: %v", err)
 		}
-		if _, err := buf.WriteString(item.Key); err != nil {
+		if _, err := buf.WriteString(key); err != nil {
 			return fmt.Errorf("failed to write key: %v", err)
 		}
 		valueLen := uint16(len(item.Value))
-		if err := binary.Write(buf, binary.LittleEndian, valueLen); err != nil {
+		if err := binary.Write(buf, byteOrder, valueLen); err != nil {
 			return fmt.Errorf("failed to write value length: %v", err)
 		}
-		if _, err := buf.WriteString(item.Value); err != nil {
+		if _, err := buf.Write(item.Value); err != nil {
 			return fmt.Errorf("failed to write value: %v", err)
 		}
 	}
 	for _, childOffset := range n.childrenOffsets {
-		if err := binary.Write(buf, binary.LittleEndian, childOffset); err != nil {
+		if err := binary.Write(buf, byteOrder, childOffset); err != nil {
 			return fmt.Errorf("failed to write child offset: %v", err)
 		}
 	}
 	data := buf.Bytes()
-	if len(data) > b.pageSize {
-		return fmt.Errorf("node data exceeds page size: %d > %d", len(data), b.pageSize)
+	if len(data) > b.pageCapacity() {
+		return fmt.Errorf("node data exceeds page size: %d > %d", len(data), b.pageCapacity())
 	}
-	padded := make([]byte, b.pageSize)
+	padded := make([]byte, b.plainPageSize())
 	copy(padded, data)
-	_, err = b.file.WriteAt(padded, offset)
+	b.sealPage(padded)
+	page, err := b.encryptPage(padded, offset)
 	if err != nil {
+		return err
+	}
+	if _, err := b.file.WriteAt(page, offset); err != nil {
 		return fmt.Errorf("failed to write node to disk: %v", err)
 	}
-	n.offset = offset
+	b.mirrorPage(page, offset)
 	return nil
 }
 
-// Insert adds a key-value pair to the B-tree.
+// Insert adds a key-value pair to the B-tree. value must be a string or []byte;
+// it is a compatibility wrapper around InsertBytes.
 func (b *Btree) Insert(key string, value interface{}) error {
-	if b.threadSafe {
-		b.mu.Lock()
-		defer b.mu.Unlock()
-	}
-	valStr, ok := value.(string) // Temporary string restriction
-	if !ok {
-		return fmt.Errorf("value must be string")
-	}
-	if b.Length == 0 {
-		newNode := &Node{
-			items:           []Item{{Key: key, Value: valStr}},
-			childrenOffsets: nil,
-		}
-		offset := b.allocateNode()
-		newNode.offset = offset
-		if err := b.writeNode(newNode, offset); err != nil {
-			return err
-		}
-		b.RootOffset = offset
-		b.Length++
-		if err := b.saveHeader(); err != nil {
-			return err
-		}
-		b.cacheNode(newNode)
-		return nil
-	}
-	// Read the root node.
-	root, err := b.readNode(b.RootOffset)
-	if err != nil {
-		return err
-	}
-	if len(root.items) == 2*b.Degree-1 {
-		newRoot := &Node{
-			items:           []Item{},
-			childrenOffsets: []int64{root.offset},
-		}
-		newRootOffset := b.allocateNode()
-		newRoot.offset = newRootOffset
-		if err := b.splitChild(newRoot, 0, root); err != nil {
-			return err
-		}
-		if err := b.insertNonFull(newRoot, key, valStr); err != nil {
-			return err
-		}
-		if err := b.writeNode(newRoot, newRootOffset); err != nil {
-			return err
-		}
-		b.RootOffset = newRootOffset
-		b.cacheNode(newRoot) // Cache the new root
-	} else {
-		if err := b.insertNonFull(root, key, valStr); err != nil {
-			return err
-		}
-	}
-	b.Length++
-	if err := b.saveHeader(); err != nil { // Save updated metadata
-		return err
-	}
-	return nil
+	switch v := value.(type) {
+	case string:
+		return b.insert(key, []byte(v))
+	case []byte:
+		return b.insert(key, bytes.Clone(v))
+	}
+	_, err := ports.ValueBytes(value)
+	return err
 }
 
-// insertNonFull inserts a key-value pair into a node that is guaranteed not to be full.
-func (b *Btree) insertNonFull(n *Node, key string, value interface{}) error {
-	i := len(n.items) - 1
-	if isLeaf(n) {
-		// Insert the new item into the correct position.
-		n.items = append(n.items, Item{})
-		for i >= 0 && key < n.items[i].Key {
-			n.items[i+1] = n.items[i]
-			i--
-		}
-		n.items[i+1] = Item{Key: key, Value: value.(string)}
-		return b.writeNode(n, n.offset)
-	}
-	// Find the child which is going to have the new key.
-	for i >= 0 && key < n.items[i].Key {
-		i--
+// InsertBytes adds a key-value pair to the B-tree. value is copied, so the caller may reuse it.
+func (b *Btree) InsertBytes(key, value []byte) error {
+	return b.insert(string(key), bytes.Clone(value))
+}
+
+// insert stores val, which the tree owns from now on. Inserts share b.mu and coordinate
+// through page latches (see latch.go).
+func (b *Btree) insert(key string, valStr []byte) error {
+	if b.threadSafe {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
 	}
-	i++
-	child, err := b.readNode(n.childrenOffsets[i])
-	if err != nil {
-		return err
+	if valStr == nil {
+		valStr = []byte{} // 빈 값도 nil과 구분되는 값으로 저장
 	}
-	if len(child.items) == 2*b.Degree-1 {
-		if err := b.splitChild(n, i, child); err != nil {
-			return err
-		}
-		// Determine which child to descend after split.
-		if key > n.items[i].Key {
-			i++
-		}
-		child, err = b.readNode(n.childrenOffsets[i])
-		if err != nil {
-			return err
-		}
+	if len(key) >= int(keydict.EncodedFlag) {
+		return fmt.Errorf("key too long: %d bytes", len(key))
 	}
-	return b.insertNonFull(child, key, value)
+	b.keyDict.Observe(key)
+	// 이미 존재하는 키는 제자리에서 값을 덮어씀 (StoragePort 계약).
+	return b.insertCrabbing(key, valStr)
 }
 
 // splitChild splits the full child node and adjusts the parent accordingly.
@@ -381,35 +689,97 @@ func (b *Btree) splitChild(parent *Node, index int, child *Node) error {
 	return b.writeNode(parent, parent.offset)
 }
 
-// Get retrieves the value associated with the given key, using cache if available.
+// Get retrieves the value associated with the given key as a string, using cache if available.
+// It is a compatibility wrapper around GetBytes.
 func (b *Btree) Get(key string) (interface{}, error) {
+	return b.GetCost(key, nil)
+}
+
+// GetCost is Get counting the visited pages into cost. It implements ports.ReadCostPort.
+func (b *Btree) GetCost(key string, cost *ports.ReadCost) (interface{}, error) {
+	val, err := b.get(key, cost)
+	if err != nil {
+		return nil, err
+	}
+	return string(val), nil
+}
+
+// GetBytes retrieves the value associated with the given key without copying it.
+// The returned slice is shared with the node cache and must not be modified.
+func (b *Btree) GetBytes(key []byte) ([]byte, error) {
+	return b.get(string(key), nil)
+}
+
+func (b *Btree) get(key string, cost *ports.ReadCost) ([]byte, error) {
 	if b.threadSafe {
 		b.mu.RLock()
 		defer b.mu.RUnlock()
 	}
-	if b.Length == 0 {
-		return nil, ports.ErrKeyNotFound
+	return b.searchCrabbing(key, cost)
+}
+
+// Scan calls fn for every key starting with prefix, in ascending key order, until fn returns false.
+func (b *Btree) Scan(prefix string, fn func(key string, value interface{}) bool) error {
+	return b.ScanCost(prefix, fn, nil)
+}
+
+// ScanCost is Scan counting the visited pages into cost. It implements ports.ReadCostPort.
+func (b *Btree) ScanCost(prefix string, fn func(key string, value interface{}) bool, cost *ports.ReadCost) error {
+	if b.threadSafe {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
 	}
-	return b.searchValue(b.RootOffset, key)
+	return b.scanTree(prefix, fn, cost)
 }
 
-// searchValue recursively searches for a key starting from the node at the given offset.
-func (b *Btree) searchValue(offset int64, key string) (interface{}, error) {
-	n, err := b.readNode(offset)
+// scanTree scans the whole tree, holding shared latches on the path to the current node.
+func (b *Btree) scanTree(prefix string, fn func(key string, value interface{}) bool, cost *ports.ReadCost) error {
+	b.lockRoot(false)
+	if b.length() == 0 {
+		b.unlockRoot(false)
+		return nil
+	}
+	root, err := b.latchNodeCost(b.RootOffset, false, cost)
+	b.unlockRoot(false)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	i := 0
-	for i < len(n.items) && key > n.items[i].Key {
-		i++
+	_, err = b.scanNode(root, prefix, fn, cost)
+	return err
+}
+
+// scanChild latches the child at offset and scans it.
+func (b *Btree) scanChild(offset int64, prefix string, fn func(key string, value interface{}) bool, cost *ports.ReadCost) (bool, error) {
+	child, err := b.latchNodeCost(offset, false, cost)
+	if err != nil {
+		return false, err
 	}
-	if i < len(n.items) && key == n.items[i].Key {
-		return n.items[i].Value, nil
+	return b.scanNode(child, prefix, fn, cost)
+}
+
+// scanNode walks the subtree of a latched node in order, skipping children that can't hold
+// prefix, and releases the node. It returns false once the scan should stop.
+func (b *Btree) scanNode(n latched, prefix string, fn func(key string, value interface{}) bool, cost *ports.ReadCost) (bool, error) {
+	defer b.unlatch(n)
+	for i, item := range n.items {
+		// childrenOffsets[i]의 키는 모두 item.Key 이하이므로 prefix보다 작으면 건너뜀.
+		if !isLeaf(n.Node) && item.Key >= prefix {
+			if cont, err := b.scanChild(n.childrenOffsets[i], prefix, fn, cost); err != nil || !cont {
+				return cont, err
+			}
+		}
+		if strings.HasPrefix(item.Key, prefix) {
+			if !fn(item.Key, string(item.Value)) {
+				return false, nil
+			}
+		} else if item.Key > prefix {
+			return false, nil // 이후 키는 모두 prefix 범위를 벗어남
+		}
 	}
-	if isLeaf(n) {
-		return nil, fmt.Errorf("key not found")
+	if !isLeaf(n.Node) {
+		return b.scanChild(n.childrenOffsets[len(n.childrenOffsets)-1], prefix, fn, cost)
 	}
-	return b.searchValue(n.childrenOffsets[i], key)
+	return true, nil
 }
 
 // Delete removes the key-value pair identified by the key from the B-tree.
@@ -418,6 +788,78 @@ func (b *Btree) Delete(key string) error {
 		b.mu.Lock()
 		defer b.mu.Unlock()
 	}
+	return b.deleteKey(key)
+}
+
+// DeleteBytes removes the key-value pair identified by the key from the B-tree.
+func (b *Btree) DeleteBytes(key []byte) error {
+	return b.Delete(string(key))
+}
+
+// DeletePrefix removes every key starting with prefix and returns how many were removed.
+// Freed pages are not reclaimed.
+func (b *Btree) DeletePrefix(prefix string) (int, error) {
+	if b.threadSafe {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+	var keys []string
+	if err := b.scanTree(prefix, func(key string, _ interface{}) bool {
+		keys = append(keys, key)
+		return true
+	}, nil); err != nil {
+		return 0, err
+	}
+	for i, key := range keys {
+		if err := b.deleteKey(key); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// DeleteRange removes every key in [start, end) ("" = no upper bound) and returns how many
+// were removed. Freed pages are not reclaimed.
+func (b *Btree) DeleteRange(start, end string) (int, error) {
+	if b.threadSafe {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+	var keys []string
+	// 두 경계의 공통 접두어로 범위 밖의 서브트리를 건너뜀.
+	if err := b.scanTree(commonPrefix(start, end), func(key string, _ interface{}) bool {
+		if end != "" && key >= end {
+			return false
+		}
+		if key >= start {
+			keys = append(keys, key)
+		}
+		return true
+	}, nil); err != nil {
+		return 0, err
+	}
+	for i, key := range keys {
+		if err := b.deleteKey(key); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// commonPrefix returns the longest common prefix of start and end, or "" if end is "".
+func commonPrefix(start, end string) string {
+	if end == "" {
+		return ""
+	}
+	i := 0
+	for i < len(start) && i < len(end) && start[i] == end[i] {
+		i++
+	}
+	return start[:i]
+}
+
+// deleteKey removes a single key. Caller holds b.mu when the tree is thread-safe.
+func (b *Btree) deleteKey(key string) error {
 	if b.Length == 0 {
 		return ports.ErrKeyNotFound
 	}
@@ -430,14 +872,12 @@ func (b *Btree) Delete(key string) error {
 		return err
 	}
 	if len(root.items) == 0 && !isLeaf(root) {
+		b.metaMu.Lock()
 		b.RootOffset = root.childrenOffsets[0]
+		b.metaMu.Unlock()
 		b.cacheNode(root)
 	}
-	b.Length--
-	if err := b.saveHeader(); err != nil { // Save updated metadata
-		return err
-	}
-	return nil
+	return b.saveMeta(-1) // Save updated metadata
 }
 
 // deleteFromNode recursively deletes a key from the subtree rooted at the node with the given offset.
@@ -497,7 +937,7 @@ func (b *Btree) deleteFromNode(offset int64, key string) error {
 	}
 	// Key is not in this node.
 	if isLeaf(n) {
-		return fmt.Errorf("key not found")
+		return ports.ErrKeyNotFound
 	}
 	childOffset := n.childrenOffsets[idx]
 	child, err := b.readNode(childOffset)
@@ -505,6 +945,7 @@ func (b *Btree) deleteFromNode(offset int64, key string) error {
 		return err
 	}
 	if len(child.items) < b.Degree {
+		before := len(n.childrenOffsets)
 		if err := b.fill(n, idx); err != nil {
 			return err
 		}
@@ -512,6 +953,10 @@ func (b *Btree) deleteFromNode(offset int64, key string) error {
 		if err != nil {
 			return err
 		}
+		// fill이 왼쪽 형제와 병합했다면 대상 자식은 idx-1로 이동함.
+		if len(n.childrenOffsets) < before && idx > 0 {
+			idx--
+		}
 		childOffset = n.childrenOffsets[idx]
 	}
 	return b.deleteFromNode(childOffset, key)
@@ -649,9 +1094,7 @@ func (b *Btree) borrowFromNext(parent *Node, idx int) error {
 
 // allocateNode reserves a new page for a node and returns its offset.
 func (b *Btree) allocateNode() int64 {
-	offset := b.nextOffset
-	b.nextOffset += int64(b.pageSize)
-	return offset
+	return b.nextOffset.Add(int64(b.pageSize)) - int64(b.pageSize)
 }
 
 // isLeaf returns true if the node is a leaf node.
@@ -664,84 +1107,78 @@ func (i Item) Less(than Item) bool {
 }
 
 // cacheNode adds or updates a node in the cache with LRU eviction.
-// This method is thread-safe and ensures the cache stays within its size limit.
 func (b *Btree) cacheNode(n *Node) {
-	if b.cacheSize <= 0 {
-		return // Caching disabled
-	}
-
-	b.cacheMu.Lock()
-	defer b.cacheMu.Unlock()
-
-	// If node is already in cache, update it and move to front
-	if cached, ok := b.cache[n.offset]; ok {
-		if cached.elem != nil { // check for nil elem
-			b.cacheList.MoveToFront(cached.elem)
-		}
-		b.cache[n.offset] = n // Update with latest node data
-		return
-	}
-
-	// Add new node to cache
-	elem := b.cacheList.PushFront(n)
-	b.cache[n.offset] = n
-	n.elem = elem // Store the list element reference in the node
-
-	// Evict the least recently used node if cache exceeds size limit
-	if b.cacheList.Len() > b.cacheSize {
-		oldest := b.cacheList.Back()
-		if oldest != nil {
-			oldNode := oldest.Value.(*Node)
-			delete(b.cache, oldNode.offset)
-			b.cacheList.Remove(oldest)
-			oldNode.elem = nil // Clear reference to avoid memory leak
-		}
+	if b.cache.enabled() {
+		b.cache.put(n, false)
 	}
 }
 
-// moveToFront updates the LRU order for an existing cached node.
-func (b *Btree) moveToFront(offset int64) {
-	b.cacheMu.Lock()
-	defer b.cacheMu.Unlock()
-	if elem, ok := b.cache[offset]; ok && elem.elem != nil { // Additional nil check
-		b.cacheList.MoveToFront(elem.elem)
+// SetCacheSize changes the node cache capacity at runtime, evicting least recently used
+// nodes that no longer fit. 0 disables caching. The number of cache shards does not change.
+func (b *Btree) SetCacheSize(n int) {
+	if b.threadSafe {
+		b.mu.RLock() // 노드를 고정하지 않는 삭제가 도는 동안 퇴출이 dirty 노드를 쓰지 않게 함
+		defer b.mu.RUnlock()
 	}
+	b.cache.resize(n)
 }
 
 // readNode retrieves a node from cache or disk.
+// Cached nodes failing validation are evicted and re-read; corrupt disk pages are
+// quarantined and recovered from the fallback copy when one is configured.
 func (b *Btree) readNode(offset int64) (*Node, error) {
+	return b.readNodeCost(offset, nil)
+}
+
+// readNodeCost is readNode counting the page into cost, if not nil.
+func (b *Btree) readNodeCost(offset int64, cost *ports.ReadCost) (*Node, error) {
+	if cost != nil {
+		cost.Pages++
+	}
 	// Check cache first
-	if b.cacheSize > 0 {
-		b.cacheMu.RLock()
-		if node, ok := b.cache[offset]; ok {
-			b.cacheMu.RUnlock()
-			b.moveToFront(offset) // Update LRU
-			return node, nil
+	if b.cache.enabled() {
+		if node, ok := b.cache.get(offset); ok {
+			if err := b.validateNode(node); err == nil {
+				return node, nil
+			}
+			b.corruptions.Add(1)
+			b.evictNode(offset)
 		}
-		b.cacheMu.RUnlock()
 	}
 
 	// Read from disk if not cached
+	if cost != nil {
+		cost.PageReads++
+	}
 	node, err := b.readNodeFromDisk(offset)
 	if err != nil {
-		return nil, err
+		node, err = b.recoverPage(offset, err)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Cache the node
-	if b.cacheSize > 0 {
-		b.cacheNode(node)
-	}
+	b.cacheNode(node)
 	return node, nil
 }
 
-// writeNode writes a node to disk and updates the cache.
+// writeNode writes a node to disk and updates the cache. In write-back mode the node is
+// only marked dirty in the buffer pool, after checking that it fits a page.
 func (b *Btree) writeNode(n *Node, offset int64) error {
+	if b.writeBack() {
+		if size := b.encodedSize(n); size > b.pageCapacity() {
+			return fmt.Errorf("node data exceeds page size: %d > %d", size, b.pageCapacity())
+		}
+		n.offset = offset
+		b.cache.put(n, true)
+		return nil
+	}
 	err := b.writeNodeToDisk(n, offset)
 	if err != nil {
 		return err
 	}
-	if b.cacheSize > 0 {
-		b.cacheNode(n) // Update cache after write
-	}
+	n.offset = offset
+	b.cacheNode(n) // Update cache after write
 	return nil
 }
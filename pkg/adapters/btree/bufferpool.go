@@ -0,0 +1,451 @@
+package btree
+
+import (
+	"container/list"
+	"fmt"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 버퍼 풀은 디코딩된 노드를 페이지 offset으로 보관합니다. offset 해시로 나눈 여러 세그먼트(shard)로
+// 구성되며, 세그먼트마다 자체 잠금과 LRU 목록을 가지므로 여러 코어에서 동시에 읽어도 하나의 잠금에
+// 몰리지 않습니다. LRU 순서는 세그먼트 안에서만 유지되며, 예산은 세그먼트에 균등하게 나뉩니다.
+//
+// 예산은 노드 수(CacheSize) 또는 바이트(CacheBytes, 노드의 키/값 크기로 추정)로 정합니다.
+// 각 프레임은 참조 카운트(pin)와 dirty 표시를 가집니다. 고정(pin)된 프레임은 퇴출되지 않으며,
+// WriteBack 모드에서 writeNode는 페이지를 바로 쓰지 않고 프레임을 dirty로 표시합니다. dirty
+// 프레임은 퇴출될 때(세그먼트 잠금 안에서 먼저 기록), 백그라운드 flusher가 돌 때, 그리고
+// Sync/Close 때 디스크에 기록됩니다. 삽입은 쓰기 래치를 잡은 노드를 고정하므로(latch.go) 퇴출이
+// 변경 중인 노드를 쓰지 않습니다.
+
+const (
+	defaultCacheShards = 16
+	minNodesPerShard   = 8 // 세그먼트가 너무 작으면 LRU가 무의미해지므로 세그먼트 수를 줄임
+
+	nodeOverhead = 64 // 노드 하나의 고정 메모리 추정치 (구조체, 슬라이스 헤더)
+	itemOverhead = 48 // 아이템 하나의 고정 메모리 추정치 (문자열/슬라이스 헤더)
+)
+
+// bufferPool is a sharded LRU pool of decoded nodes keyed by page offset.
+type bufferPool struct {
+	shards   []*poolShard
+	shift    uint         // 64 - log2(len(shards)) for Fibonacci hashing
+	capacity atomic.Int64 // Total node budget (0 = no node limit)
+	budget   atomic.Int64 // Total byte budget (0 = no byte limit)
+	hits     atomic.Int64
+	misses   atomic.Int64
+	// writeBack defers page writes to eviction, the flusher and Sync; write stores dirty pages.
+	writeBack  bool
+	write      func(n *Node) error
+	writeBacks atomic.Int64 // Dirty pages written
+	latches    *latchTable  // Page latches held while flushing (nil if the tree is not thread-safe)
+}
+
+type poolShard struct {
+	mu       sync.Mutex
+	frames   map[int64]*frame
+	lru      *list.List // Of *frame, most recently used first
+	capacity int        // Node budget (0 with bytes > 0 = no node limit)
+	budget   int64      // Byte budget (0 = no byte limit)
+	bytes    int64      // Estimated bytes of the cached nodes
+	dirty    int
+}
+
+// frame is a pool slot holding one node.
+type frame struct {
+	node  *Node
+	bytes int64
+	dirty bool
+	pins  int
+	elem  *list.Element
+}
+
+// newBufferPool creates a pool holding up to capacity nodes or budget bytes, whichever is
+// set (a byte budget takes precedence). shards is rounded down to a power of two and reduced
+// for small node capacities; 0 picks a default. The shard count is fixed for the pool's
+// lifetime, while the budget can be changed with resize and resizeBytes.
+func newBufferPool(capacity int, budget int64, shards int) *bufferPool {
+	if shards <= 0 {
+		shards = defaultCacheShards
+		for budget <= 0 && shards > 1 && capacity/shards < minNodesPerShard {
+			shards /= 2
+		}
+	}
+	shards = 1 << (bits.Len(uint(shards)) - 1)
+	p := &bufferPool{
+		shards: make([]*poolShard, shards),
+		shift:  uint(64 - bits.TrailingZeros(uint(shards))),
+	}
+	for i := range p.shards {
+		p.shards[i] = &poolShard{frames: make(map[int64]*frame), lru: list.New()}
+	}
+	if budget > 0 {
+		p.resizeBytes(budget)
+	} else {
+		p.resize(capacity)
+	}
+	return p
+}
+
+// enabled reports whether caching is on; callers skip the pool entirely otherwise.
+func (p *bufferPool) enabled() bool {
+	return p.capacity.Load() > 0 || p.budget.Load() > 0
+}
+
+func (p *bufferPool) shard(offset int64) *poolShard {
+	if len(p.shards) == 1 {
+		return p.shards[0]
+	}
+	// Fibonacci hashing: page offsets are multiples of the page size, so the low bits are useless.
+	return p.shards[(uint64(offset)*0x9E3779B97F4A7C15)>>p.shift]
+}
+
+// nodeBytes estimates the memory held by a decoded node.
+func nodeBytes(n *Node) int64 {
+	size := int64(nodeOverhead + 8*len(n.childrenOffsets))
+	for _, item := range n.items {
+		size += int64(itemOverhead + len(item.Key) + len(item.Value))
+	}
+	return size
+}
+
+// get returns the cached node at offset and marks it most recently used.
+func (p *bufferPool) get(offset int64) (*Node, bool) {
+	s := p.shard(offset)
+	s.mu.Lock()
+	f, ok := s.frames[offset]
+	if ok {
+		s.lru.MoveToFront(f.elem)
+	}
+	s.mu.Unlock()
+	if ok {
+		p.hits.Add(1)
+		return f.node, true
+	}
+	p.misses.Add(1)
+	return nil, false
+}
+
+// put adds or replaces the node at n.offset, evicting the shard's least recently used
+// unpinned nodes when it is over budget. dirty marks a node that must be written back;
+// a dirty frame stays dirty when it is replaced by a clean copy.
+func (p *bufferPool) put(n *Node, dirty bool) {
+	s := p.shard(n.offset)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.capacity <= 0 && s.budget <= 0 {
+		return
+	}
+	f, ok := s.frames[n.offset]
+	if ok {
+		s.bytes -= f.bytes
+		s.lru.MoveToFront(f.elem)
+	} else {
+		f = &frame{}
+		f.elem = s.lru.PushFront(f)
+		s.frames[n.offset] = f
+	}
+	f.node = n
+	f.bytes = nodeBytes(n)
+	s.bytes += f.bytes
+	if dirty && !f.dirty {
+		f.dirty = true
+		s.dirty++
+	}
+	p.evict(s)
+}
+
+// pin keeps n resident until unpin, adding its frame if it was evicted since it was read.
+// It reports false if the pool is disabled.
+func (p *bufferPool) pin(n *Node) bool {
+	s := p.shard(n.offset)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.capacity <= 0 && s.budget <= 0 {
+		return false
+	}
+	f, ok := s.frames[n.offset]
+	if !ok {
+		f = &frame{node: n, bytes: nodeBytes(n)}
+		f.elem = s.lru.PushFront(f)
+		s.frames[n.offset] = f
+		s.bytes += f.bytes
+	}
+	f.pins++
+	return true
+}
+
+// unpin releases a pin taken by pin, evicting nodes if the shard is over budget.
+func (p *bufferPool) unpin(offset int64) {
+	s := p.shard(offset)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.frames[offset]; ok && f.pins > 0 {
+		f.pins--
+	}
+	p.evict(s)
+}
+
+// remove drops the node at offset, e.g. after it failed validation. A dirty copy is discarded.
+func (p *bufferPool) remove(offset int64) {
+	s := p.shard(offset)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.frames[offset]; ok {
+		s.drop(f)
+	}
+}
+
+// resize changes the total node budget, evicting nodes that no longer fit. It clears any
+// byte budget.
+func (p *bufferPool) resize(capacity int) {
+	if capacity < 0 {
+		capacity = 0
+	}
+	p.capacity.Store(int64(capacity))
+	p.budget.Store(0)
+	per := (capacity + len(p.shards) - 1) / len(p.shards)
+	for _, s := range p.shards {
+		s.mu.Lock()
+		s.capacity, s.budget = per, 0
+		p.evict(s)
+		s.mu.Unlock()
+	}
+}
+
+// resizeBytes changes the total byte budget, evicting nodes that no longer fit. It clears
+// any node budget.
+func (p *bufferPool) resizeBytes(budget int64) {
+	if budget < 0 {
+		budget = 0
+	}
+	p.budget.Store(budget)
+	p.capacity.Store(0)
+	per := (budget + int64(len(p.shards)) - 1) / int64(len(p.shards))
+	for _, s := range p.shards {
+		s.mu.Lock()
+		s.capacity, s.budget = 0, per
+		p.evict(s)
+		s.mu.Unlock()
+	}
+}
+
+// len returns the number of cached nodes across all shards.
+func (p *bufferPool) len() int {
+	total := 0
+	for _, s := range p.shards {
+		s.mu.Lock()
+		total += s.lru.Len()
+		s.mu.Unlock()
+	}
+	return total
+}
+
+// usage returns the estimated bytes and the number of dirty and pinned nodes in the pool.
+func (p *bufferPool) usage() (bytes int64, dirty, pinned int) {
+	for _, s := range p.shards {
+		s.mu.Lock()
+		bytes += s.bytes
+		dirty += s.dirty
+		for _, f := range s.frames {
+			if f.pins > 0 {
+				pinned++
+			}
+		}
+		s.mu.Unlock()
+	}
+	return bytes, dirty, pinned
+}
+
+// over reports whether the shard exceeds its budget.
+func (s *poolShard) over() bool {
+	if s.budget > 0 {
+		return s.bytes > s.budget
+	}
+	return s.lru.Len() > s.capacity
+}
+
+// evict removes least recently used unpinned nodes until the shard fits its budget, writing
+// dirty ones back first. A node whose write-back fails stays cached and dirty, so the next
+// flush retries it and reports the error. Caller holds s.mu.
+func (p *bufferPool) evict(s *poolShard) {
+	for e := s.lru.Back(); e != nil && s.over(); {
+		f := e.Value.(*frame)
+		e = e.Prev()
+		if f.pins > 0 {
+			continue
+		}
+		if f.dirty {
+			if err := p.write(f.node); err != nil {
+				continue
+			}
+			p.writeBacks.Add(1)
+		}
+		s.drop(f)
+	}
+}
+
+// drop removes a frame. Caller holds s.mu.
+func (s *poolShard) drop(f *frame) {
+	s.lru.Remove(f.elem)
+	delete(s.frames, f.node.offset)
+	s.bytes -= f.bytes
+	if f.dirty {
+		s.dirty--
+	}
+}
+
+// flush writes every dirty node back. Each batch of a shard's dirty frames is pinned while
+// it is written, so the frames stay resident and readers are not blocked on the shard. Each
+// page is written under its shared latch, so inserts changing it wait; deletes, which do not
+// latch, must be kept out (callers hold b.mu).
+func (p *bufferPool) flush() error {
+	for _, s := range p.shards {
+		s.mu.Lock()
+		var dirty []*frame
+		var offsets []int64
+		for offset, f := range s.frames {
+			if f.dirty {
+				f.pins++
+				dirty = append(dirty, f)
+				offsets = append(offsets, offset)
+			}
+		}
+		s.mu.Unlock()
+
+		var firstErr error
+		for i, f := range dirty {
+			if p.latches != nil {
+				p.latches.acquire(offsets[i], false)
+			}
+			s.mu.Lock()
+			n, current := f.node, s.frames[offsets[i]] == f
+			s.mu.Unlock()
+			var err error
+			if current {
+				err = p.write(n)
+			}
+			s.mu.Lock()
+			f.pins--
+			if err == nil && current && f.dirty {
+				f.dirty = false
+				s.dirty--
+				p.writeBacks.Add(1)
+			}
+			s.mu.Unlock()
+			if p.latches != nil {
+				p.latches.release(offsets[i], false)
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+		s.mu.Lock()
+		p.evict(s) // 고정이 풀린 프레임이 예산을 넘었으면 정리
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// defaultFlushInterval is used when BtConfig.FlushInterval is unset.
+const defaultFlushInterval = time.Second
+
+// writeBack reports whether node writes are deferred to the buffer pool.
+func (b *Btree) writeBack() bool {
+	return b.cache.writeBack && b.cache.enabled()
+}
+
+// encodedSize returns the size of the page writeNodeToDisk would write for n.
+func (b *Btree) encodedSize(n *Node) int {
+	size := 8 + 8*len(n.childrenOffsets) // item/child counts and child offsets
+	for _, item := range n.items {
+		key, _ := b.keyDict.Encode(item.Key)
+		size += 2 + len(key) + 2 + len(item.Value)
+	}
+	return size
+}
+
+// checkFits rejects a change that would grow n by delta bytes past a page before the node is
+// modified; in write-back mode a cached node that failed to encode would stay dirty.
+func (b *Btree) checkFits(n *Node, delta int) error {
+	if !b.writeBack() {
+		return nil
+	}
+	if size := b.encodedSize(n) + delta; size > b.pageCapacity() {
+		return fmt.Errorf("node data exceeds page size: %d > %d", size, b.pageCapacity())
+	}
+	return nil
+}
+
+// flushPages writes the dirty nodes and then the header page, so the header never points at
+// pages that are not on disk yet. Caller holds b.mu (or the tree is not thread-safe).
+func (b *Btree) flushPages() error {
+	if err := b.cache.flush(); err != nil {
+		return fmt.Errorf("failed to write back dirty pages: %v", err)
+	}
+	if b.persist != nil {
+		return b.persist(b.RootOffset, b.Length)
+	}
+	if err := b.writeHeader(); err != nil {
+		return err
+	}
+	b.headerDirty = false
+	return nil
+}
+
+// SetCacheBytes changes the buffer pool's memory budget in bytes, evicting (and writing back)
+// least recently used nodes that no longer fit. It replaces a node-count budget; 0 disables caching.
+func (b *Btree) SetCacheBytes(n int64) {
+	if b.threadSafe {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+	}
+	b.cache.resizeBytes(n)
+}
+
+// startFlusher writes dirty pages back every interval. It holds b.mu for reading, which
+// keeps deletes out while inserts and readers continue under page latches.
+func (b *Btree) startFlusher(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	b.stopFlush = make(chan struct{})
+	b.flushDone.Add(1)
+	go func() {
+		defer b.flushDone.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stopFlush:
+				return
+			case <-ticker.C:
+				b.mu.RLock()
+				if !b.closed {
+					if err := b.cache.flush(); err == nil {
+						b.metaMu.Lock()
+						if b.headerDirty && b.writeHeader() == nil {
+							b.headerDirty = false
+						}
+						b.metaMu.Unlock()
+					}
+				}
+				b.mu.RUnlock()
+			}
+		}
+	}()
+}
+
+// stopFlusher stops the background flusher, if any, and waits for it.
+func (b *Btree) stopFlusher() {
+	if b.stopFlush == nil {
+		return
+	}
+	b.stopOnce.Do(func() { close(b.stopFlush) })
+	b.flushDone.Wait()
+}
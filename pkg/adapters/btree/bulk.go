@@ -0,0 +1,203 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/keydict"
+)
+
+// 대량 적재: BulkLoad는 이미 정렬된 입력으로 빈 트리를 아래에서 위로 만듭니다. 항목을 차례로 리프에
+// 채우고, 리프가 가득 차면 다음 항목을 구분 키로 부모 수준에 올리는 식으로 수준마다 노드를 한 번씩만
+// 씁니다. 삽입처럼 루트부터 내려가거나 노드를 나누고 다시 쓰지 않으므로 수백만 건을 가져올 때 반복
+// Insert보다 훨씬 빠릅니다.
+//
+// 노드는 가득 채워(2*Degree-1개) 쓰되, 수준마다 노드 하나 분량을 더 쌓아 두었다가 입력이 끝나면 남은
+// 항목을 한 노드 또는 반씩 나눈 두 노드로 써서 마지막 노드도 최소 항목 수(Degree-1)를 지킵니다. 루트와
+// 길이는 모든 페이지를 쓴 뒤에야 기록하므로, 도중에 실패하면 트리는 빈 채로 남고 쓰인 페이지는 회수되지
+// 않습니다. 가득 찬 노드는 이후의 삽입이 곧바로 나누므로, 적재 뒤 쓰기가 많다면 Insert가 더 낫습니다.
+
+var (
+	// ErrTreeNotEmpty is returned by BulkLoad when the tree already holds keys.
+	ErrTreeNotEmpty = errors.New("bulk load requires an empty tree")
+	// ErrUnsortedInput is returned by BulkLoad when the keys are not strictly ascending.
+	ErrUnsortedInput = errors.New("bulk load input is not sorted")
+)
+
+// BulkIterator supplies the entries of BulkLoad in strictly ascending key order.
+type BulkIterator interface {
+	// Next advances to the next entry and reports whether there is one.
+	Next() bool
+	Key() string
+	Value() []byte
+	// Err returns the error that ended the iteration, if any.
+	Err() error
+}
+
+// ItemIterator returns a BulkIterator over items, which must be sorted by key.
+func ItemIterator(items []Item) BulkIterator {
+	return &itemIterator{items: items, pos: -1}
+}
+
+type itemIterator struct {
+	items []Item
+	pos   int
+}
+
+func (it *itemIterator) Next() bool {
+	it.pos++
+	return it.pos < len(it.items)
+}
+
+func (it *itemIterator) Key() string   { return it.items[it.pos].Key }
+func (it *itemIterator) Value() []byte { return it.items[it.pos].Value }
+func (it *itemIterator) Err() error    { return nil }
+
+// BulkLoad builds the tree bottom-up from the entries of it, which must be in strictly
+// ascending key order, and returns how many were loaded. The tree must be empty, and every
+// entry must fit EntryLimits().MaxEntry. Values are copied.
+func (b *Btree) BulkLoad(it BulkIterator) (int, error) {
+	if b.threadSafe {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+	if b.length() != 0 {
+		return 0, ErrTreeNotEmpty
+	}
+	limits := b.EntryLimits()
+	l := &bulkLoader{b: b, max: 2*b.Degree - 1}
+	var last string
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if l.count > 0 && key <= last {
+			return 0, fmt.Errorf("%w: %q after %q", ErrUnsortedInput, key, last)
+		}
+		if len(key) >= int(keydict.EncodedFlag) {
+			return 0, fmt.Errorf("key too long: %d bytes", len(key))
+		}
+		if len(value) > limits.MaxValue || len(key)+len(value) > limits.MaxEntry {
+			return 0, fmt.Errorf("entry %q of %d bytes exceeds the bulk load limit of %d bytes", key, len(key)+len(value), limits.MaxEntry)
+		}
+		b.keyDict.Observe(key)
+		v := make([]byte, len(value))
+		copy(v, value)
+		if err := l.add(0, Item{Key: key, Value: v}, 0); err != nil {
+			return 0, err
+		}
+		last = key
+		l.count++
+	}
+	if err := it.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read bulk load input: %v", err)
+	}
+	if l.count == 0 {
+		return 0, nil
+	}
+	root, err := l.finish()
+	if err != nil {
+		return 0, err
+	}
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	b.RootOffset = root
+	b.Length = l.count
+	if err := b.saveHeader(); err != nil {
+		return 0, err
+	}
+	return l.count, nil
+}
+
+// bulkLoader holds the pending entries of each level of a bulk load, leaves first. At an
+// inner level an item separates the children before and after it.
+type bulkLoader struct {
+	b      *Btree
+	max    int // Items of a full node
+	count  int
+	levels []bulkLevel
+}
+
+type bulkLevel struct {
+	items    []Item
+	children []int64 // Empty at the leaf level
+}
+
+// add appends item to level, after child at inner levels, and writes a full node once two
+// nodes' worth of items are pending so that the rest can always form valid nodes.
+func (l *bulkLoader) add(level int, item Item, child int64) error {
+	for len(l.levels) <= level {
+		l.levels = append(l.levels, bulkLevel{})
+	}
+	lv := &l.levels[level]
+	if level > 0 {
+		lv.children = append(lv.children, child)
+	}
+	lv.items = append(lv.items, item)
+	if len(lv.items) < 2*l.max+1 {
+		return nil
+	}
+	offset, err := l.write(lv.items[:l.max], childrenOf(lv.children, 0, l.max+1))
+	if err != nil {
+		return err
+	}
+	sep := lv.items[l.max]
+	lv.items = append([]Item(nil), lv.items[l.max+1:]...)
+	if level > 0 {
+		lv.children = append([]int64(nil), lv.children[l.max+1:]...)
+	}
+	return l.add(level+1, sep, offset)
+}
+
+// finish writes the pending entries of every level, bottom-up, and returns the root offset.
+func (l *bulkLoader) finish() (int64, error) {
+	for level := 0; ; level++ {
+		lv := &l.levels[level]
+		var last int64 // 이 수준의 마지막 노드; 위 수준에 마지막 자식으로 붙음
+		if n := len(lv.items); n <= l.max {
+			var err error
+			if last, err = l.write(lv.items, lv.children); err != nil {
+				return 0, err
+			}
+		} else {
+			h := (n - 1) / 2
+			left, err := l.write(lv.items[:h], childrenOf(lv.children, 0, h+1))
+			if err != nil {
+				return 0, err
+			}
+			if last, err = l.write(lv.items[h+1:], childrenOf(lv.children, h+1, len(lv.children))); err != nil {
+				return 0, err
+			}
+			if level+1 == len(l.levels) {
+				l.levels = append(l.levels, bulkLevel{})
+			}
+			up := &l.levels[level+1]
+			up.children = append(up.children, left)
+			up.items = append(up.items, lv.items[h])
+		}
+		if level+1 == len(l.levels) {
+			return last, nil
+		}
+		up := &l.levels[level+1]
+		up.children = append(up.children, last)
+	}
+}
+
+// write writes a new node and returns its offset.
+func (l *bulkLoader) write(items []Item, children []int64) (int64, error) {
+	n := &Node{
+		items:           append([]Item(nil), items...),
+		childrenOffsets: append([]int64(nil), children...),
+	}
+	offset := l.b.allocateNode()
+	if err := l.b.writeNode(n, offset); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// childrenOf returns children[i:j], or nil at the leaf level.
+func childrenOf(children []int64, i, j int) []int64 {
+	if len(children) == 0 {
+		return nil
+	}
+	return children[i:j]
+}
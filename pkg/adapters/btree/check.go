@@ -0,0 +1,132 @@
+package btree
+
+import "fmt"
+
+// 구조 점검: CheckTree는 캐시와 대체 사본(FallbackPath)을 거치지 않고 디스크의 페이지를 직접 읽어
+// 트리 하나를 끝까지 검사합니다. golite fsck(pkg/repair)가 사용하며, 손상된 페이지를 만나도 멈추지
+// 않고 읽을 수 있는 페이지의 항목을 모두 넘겨 주므로 레코드를 살려 내는 데에도 쓰입니다.
+//
+//   - 페이지 체크섬과 노드 형식 (decodeNode와 같음)
+//   - 노드 불변식: 키 순서, 자식 수, 자식 오프셋 (validateNode)
+//   - 자식의 키가 부모의 구분 키 범위 안에 있는지, 모든 리프가 같은 깊이에 있는지
+//   - 페이지가 파일 끝을 넘지 않는지, 두 번 이상 참조되지 않는지 (seen을 여러 트리에 공유하면
+//     트리 사이에서 공유된 페이지도 찾음)
+//   - 도달한 항목 수가 헤더(또는 서브트리의 persist)에 기록된 길이와 같은지
+//
+// write-back 모드에서 아직 기록되지 않은 페이지는 보이지 않으므로 닫힌 파일을 열어 점검합니다.
+
+// TreeCheck is the result of CheckTree.
+type TreeCheck struct {
+	Pages    int     // Node pages reached from the root
+	Items    int     // Items of the pages that could be read
+	Depth    int     // Depth of the first leaf reached (1 = the root is a leaf)
+	Problems []error // What was found wrong, in walk order
+}
+
+// CheckTree walks the tree with the given root and length as described in check.go and calls
+// fn, if not nil, with every item of every page that could be read. Pages reached are added to
+// seen; a page already in seen is reported and not walked again. Use b.RootOffset and b.Length
+// for the main tree.
+func (b *Btree) CheckTree(root int64, length int, seen map[int64]bool, fn func(key string, value []byte)) TreeCheck {
+	if b.threadSafe {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+	c := &treeChecker{b: b, seen: seen, fn: fn}
+	if stat, err := b.file.Stat(); err == nil {
+		c.end = stat.Size()
+	} else {
+		c.problem(fmt.Errorf("failed to stat tree file: %v", err))
+		return c.TreeCheck
+	}
+	if root == 0 {
+		if length != 0 {
+			c.problem(fmt.Errorf("tree has no root but a length of %d", length))
+		}
+		return c.TreeCheck
+	}
+	c.walk(root, 1, nil, nil)
+	if c.Items != length {
+		c.problem(fmt.Errorf("tree holds %d items but its length is %d", c.Items, length))
+	}
+	return c.TreeCheck
+}
+
+type treeChecker struct {
+	TreeCheck
+	b    *Btree
+	seen map[int64]bool
+	fn   func(key string, value []byte)
+	end  int64 // File size
+}
+
+func (c *treeChecker) problem(err error) {
+	c.Problems = append(c.Problems, err)
+}
+
+// walk checks the node at offset, whose keys must lie in [lo, hi] (nil = unbounded).
+func (c *treeChecker) walk(offset int64, depth int, lo, hi *string) {
+	b := c.b
+	if c.seen[offset] {
+		c.problem(corruptPage(offset, "page is referenced more than once"))
+		return
+	}
+	c.seen[offset] = true
+	c.Pages++
+	if offset < b.firstNode || offset%int64(b.pageSize) != 0 || offset+int64(b.pageSize) > c.end {
+		c.problem(corruptPage(offset, "not a node page of a %d-byte file", c.end))
+		return
+	}
+	page := make([]byte, b.pageSize)
+	if _, err := b.file.ReadAt(page, offset); err != nil {
+		c.problem(fmt.Errorf("failed to read page at offset %d: %v", offset, err))
+		return
+	}
+	n, err := b.parseNode(page, offset)
+	if err != nil {
+		c.problem(err)
+		return
+	}
+	// 불변식이 깨진 노드도 항목은 읽을 수 있으므로 계속 진행
+	verr := b.validateNode(n)
+	valid := verr == nil
+	if !valid {
+		c.problem(verr)
+	}
+
+	c.Items += len(n.items)
+	for _, item := range n.items {
+		if (lo != nil && item.Key < *lo) || (hi != nil && item.Key > *hi) {
+			c.problem(corruptPage(offset, "key %q lies outside the range of its parent", item.Key))
+		}
+		if c.fn != nil {
+			c.fn(item.Key, item.Value)
+		}
+	}
+
+	if isLeaf(n) {
+		if c.Depth == 0 {
+			c.Depth = depth
+		} else if depth != c.Depth {
+			c.problem(corruptPage(offset, "leaf at depth %d, expected %d", depth, c.Depth))
+		}
+		return
+	}
+	for i, child := range n.childrenOffsets {
+		if !valid {
+			// 자식과 구분 키의 대응을 믿을 수 없으므로 범위 검사 없이 살릴 수 있는 자식만 따라감
+			if child >= b.firstNode && child%int64(b.pageSize) == 0 && child != offset {
+				c.walk(child, depth+1, nil, nil)
+			}
+			continue
+		}
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = &n.items[i-1].Key
+		}
+		if i < len(n.items) {
+			childHi = &n.items[i].Key
+		}
+		c.walk(child, depth+1, childLo, childHi)
+	}
+}
@@ -0,0 +1,181 @@
+package btree
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+)
+
+// 저장 데이터 암호화: BtConfig.EncryptionKey를 주면 노드 페이지 전체를 pagecrypt로 봉인해 기록합니다.
+// 페이지 오프셋을 AAD로 쓰므로 페이지를 다른 위치로 옮기면 열리지 않으며, 봉인에 pagecrypt.Overhead
+// 바이트가 들어가 노드 데이터에 쓸 수 있는 공간이 그만큼 줄어듭니다. CRC32 체크섬을 켜면 봉인 전의
+// 평문 페이지 끝에 기록됩니다. 헤더 페이지(page 0)와 ReservedPages는 평문으로 남습니다.
+//
+// 헤더 페이지에는 byteOrderMark 뒤에 현재 키 ID와 이전 키 ID(u32 둘)를 기록합니다. Rekey는
+//  1. 헤더에 rekeying 플래그와 두 키 ID를 기록하고 fsync
+//  2. 이전 키로 봉인된 노드 페이지를 모두 새 키로 다시 봉인하고 fsync
+//  3. 플래그를 지운 헤더를 기록하고 fsync
+// 순서로 진행하므로, 도중에 장애가 나면 새 키를 EncryptionKey, 이전 키를 PreviousEncryptionKey로
+// 주고 다시 열어 마저 진행합니다. 아직 헤더가 이전 키를 가리키는 파일을 두 키로 열어도 같은 방법으로
+// 열 때 키를 바꿉니다.
+
+const (
+	// headerFlagEncrypted is set in the header page when node pages are sealed with pagecrypt.
+	headerFlagEncrypted uint32 = 2
+	// headerFlagRekeying is set while a Rekey is re-sealing pages with the new key.
+	headerFlagRekeying uint32 = 4
+)
+
+// plainPageSize returns the size of a node page before it is sealed.
+func (b *Btree) plainPageSize() int {
+	if b.keys.Load() != nil {
+		return b.pageSize - pagecrypt.Overhead
+	}
+	return b.pageSize
+}
+
+// pageAAD binds a sealed page to its offset.
+func pageAAD(offset int64) []byte {
+	return byteOrder.AppendUint64(nil, uint64(offset))
+}
+
+// encryptPage seals a plaintext node page for offset, or returns it as is without encryption.
+func (b *Btree) encryptPage(plain []byte, offset int64) ([]byte, error) {
+	keys := b.keys.Load()
+	if keys == nil {
+		return plain, nil
+	}
+	page, err := keys.Current().Seal(make([]byte, 0, b.pageSize), plain, pageAAD(offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt page: %v", err)
+	}
+	return page, nil
+}
+
+// decryptPage opens a node page read from offset, or returns it as is without encryption.
+func (b *Btree) decryptPage(page []byte, offset int64) ([]byte, error) {
+	keys := b.keys.Load()
+	if keys == nil {
+		return page, nil
+	}
+	plain, err := keys.Open(nil, page, pageAAD(offset))
+	if err != nil {
+		return nil, corruptPage(offset, "%v", err)
+	}
+	return plain, nil
+}
+
+// checkKeys validates the configured keys against the encryption state of an existing tree:
+// its header flags and key IDs, or, without the encrypted flag, whether it has node pages.
+func (b *Btree) checkKeys(flags, keyID, previousID uint32, hasNodes bool) error {
+	keys := b.keys.Load()
+	if flags&headerFlagEncrypted == 0 {
+		if keys != nil && hasNodes {
+			return fmt.Errorf("%w: B-tree file has unencrypted pages", pagecrypt.ErrNotEncrypted)
+		}
+		return nil
+	}
+	if keys == nil {
+		return pagecrypt.ErrKeyRequired
+	}
+	current, previous := keys.Current().ID(), keys.Previous()
+	switch {
+	case keyID == current && flags&headerFlagRekeying == 0:
+		b.keys.Store(keys.WithoutPrevious())
+	case keyID == current:
+		if previous == nil || previous.ID() != previousID {
+			return fmt.Errorf("%w: pages are sealed with keys %08x and %08x", pagecrypt.ErrRekeyIncomplete, keyID, previousID)
+		}
+		b.rekeying = true
+	case previous != nil && keyID == previous.ID() && flags&headerFlagRekeying == 0:
+		b.rekeying = true // 두 키로 열면 새 키로 바꿈
+	default:
+		return fmt.Errorf("%w: B-tree pages are sealed with key %08x", pagecrypt.ErrWrongKey, keyID)
+	}
+	return nil
+}
+
+// Rekey re-encrypts every node page of the file with newKey, which is used for all later
+// writes. It blocks every other operation on the file until done and must be called on the
+// main tree; see encryption.go for what happens if it is interrupted.
+func (b *Btree) Rekey(newKey []byte) error {
+	if b.persist != nil {
+		return fmt.Errorf("rekey must be called on the main tree")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return fmt.Errorf("tree is closed")
+	}
+	keys := b.keys.Load()
+	if keys == nil {
+		return pagecrypt.ErrNotEncrypted
+	}
+	if err := b.flushPages(); err != nil {
+		return err
+	}
+	if b.rekeying {
+		// 실패한 Rekey를 같은 키로 다시 호출하면 이어서 진행
+		if pagecrypt.KeyID(newKey) != keys.Current().ID() {
+			return fmt.Errorf("a rekey to key %08x is unfinished; call Rekey with that key first", keys.Current().ID())
+		}
+		return b.rekeyPages()
+	}
+	next, err := keys.Rotate(newKey)
+	if err != nil {
+		return err
+	}
+	b.keys.Store(next)
+	b.rekeying = true
+	return b.rekeyPages()
+}
+
+// rekeyPages re-seals with the current key every node page sealed with the previous key.
+// Caller holds b.mu exclusively (or is opening the tree).
+func (b *Btree) rekeyPages() error {
+	if err := b.writeHeader(); err != nil {
+		return err
+	}
+	if err := b.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync tree file: %v", err)
+	}
+	keys := b.keys.Load()
+	current := keys.Current()
+	page := make([]byte, b.pageSize)
+	zero := make([]byte, b.pageSize)
+	end := b.nextOffset.Load()
+	for offset := b.firstNode; offset+int64(b.pageSize) <= end; offset += int64(b.pageSize) {
+		if _, err := b.file.ReadAt(page, offset); err != nil {
+			return fmt.Errorf("failed to read page at offset %d: %v", offset, err)
+		}
+		if id, err := pagecrypt.SealedID(page); err == nil && id == current.ID() || bytes.Equal(page, zero) {
+			continue
+		}
+		plain, err := keys.Open(nil, page, pageAAD(offset))
+		if err != nil {
+			b.corruptions.Add(1) // 어느 키로도 열리지 않는 페이지는 이미 손상됨
+			continue
+		}
+		sealed, err := current.Seal(make([]byte, 0, b.pageSize), plain, pageAAD(offset))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt page: %v", err)
+		}
+		if _, err := b.file.WriteAt(sealed, offset); err != nil {
+			return fmt.Errorf("failed to write page at offset %d: %v", offset, err)
+		}
+		b.mirrorPage(sealed, offset)
+	}
+	if err := b.sync(); err != nil {
+		return err
+	}
+	b.rekeying = false
+	b.keys.Store(keys.WithoutPrevious())
+	if err := b.writeHeader(); err != nil {
+		return err
+	}
+	if err := b.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync tree file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// 바이트 순서: B-트리 파일의 모든 정수(헤더, 노드 페이지, 체크섬)는 리틀 엔디안으로 기록됩니다.
+// 인코딩은 encoding/binary로만 하고 메모리를 그대로 쓰는 일은 없으므로 (mmap 읽기도 같은 디코더를
+// 거침) 파일은 실행 아키텍처와 무관하게 amd64, arm64, 빅 엔디안 머신 사이에서 그대로 옮길 수 있습니다.
+//
+// 헤더 페이지 (offset 0):
+//
+//	rootOffset(i64) length(i32) flags(u32) byteOrderMark(u32) [keyID(u32) previousKeyID(u32)]
+//
+// 키 ID는 암호화된 트리(flags의 headerFlagEncrypted)에만 기록됩니다 (encryption.go 참고).
+//
+// byteOrderMark는 0x01020304를 byteOrder로 쓴 값이라 디스크에서는 04 03 02 01입니다. 표시가
+// 없는(0인) 헤더는 표시가 추가되기 전의 파일로 리틀 엔디안으로 읽고, 바이트가 뒤집힌 표시는 다른
+// 순서로 쓴 도구가 만든 파일이므로 열지 않습니다.
+
+// byteOrder is the byte order of every integer in a B-tree file.
+var byteOrder = binary.LittleEndian
+
+// byteOrderMark is stored in the header page after the flags.
+const byteOrderMark uint32 = 0x01020304
+
+// ErrByteOrder is matched (errors.Is) by the error OpenBtree returns for a header whose
+// byte order mark is not the one this package writes.
+var ErrByteOrder = errors.New("unsupported byte order")
+
+// checkByteOrderMark validates the mark read from the header page.
+func checkByteOrderMark(mark uint32) error {
+	switch mark {
+	case byteOrderMark, 0: // 0: written before the mark was added
+		return nil
+	case 0x04030201:
+		return fmt.Errorf("%w: B-tree header was written in big-endian byte order", ErrByteOrder)
+	}
+	return fmt.Errorf("%w: invalid byte order mark %#08x in B-tree header", ErrByteOrder, mark)
+}
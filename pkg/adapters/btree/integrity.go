@@ -0,0 +1,182 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+// headerFlagChecksums is set in the header page when node pages carry a CRC32 trailer.
+const headerFlagChecksums uint32 = 1
+
+// pageChecksumSize is the size of the CRC32 trailer at the end of each node page.
+const pageChecksumSize = 4
+
+// ErrPageCorrupted is matched (errors.Is) by every error reporting an invalid node page.
+var ErrPageCorrupted = errors.New("page corrupted")
+
+// CorruptPageError describes a node page that failed decoding or validation.
+type CorruptPageError struct {
+	Offset int64  // Disk offset of the page
+	Reason string // What was wrong with it
+}
+
+func (e *CorruptPageError) Error() string {
+	return fmt.Sprintf("corrupt page at offset %d: %s", e.Offset, e.Reason)
+}
+
+// Is reports whether target is ErrPageCorrupted.
+func (e *CorruptPageError) Is(target error) bool {
+	return target == ErrPageCorrupted
+}
+
+func corruptPage(offset int64, format string, args ...interface{}) error {
+	return &CorruptPageError{Offset: offset, Reason: fmt.Sprintf(format, args...)}
+}
+
+// pageCapacity returns the number of bytes available for node data in a page.
+func (b *Btree) pageCapacity() int {
+	if b.checksums {
+		return b.plainPageSize() - pageChecksumSize
+	}
+	return b.plainPageSize()
+}
+
+// sealPage stores the CRC32 of the page body in its trailer when checksums are enabled.
+func (b *Btree) sealPage(page []byte) {
+	if !b.checksums {
+		return
+	}
+	body := len(page) - pageChecksumSize
+	byteOrder.PutUint32(page[body:], crc32.ChecksumIEEE(page[:body]))
+}
+
+// verifyPage checks the CRC32 trailer of a page read from offset.
+func (b *Btree) verifyPage(page []byte, offset int64) error {
+	if !b.checksums {
+		return nil
+	}
+	body := len(page) - pageChecksumSize
+	stored := byteOrder.Uint32(page[body:])
+	if computed := crc32.ChecksumIEEE(page[:body]); stored != computed {
+		return corruptPage(offset, "checksum mismatch: stored %08x, computed %08x", stored, computed)
+	}
+	return nil
+}
+
+// validateNode checks the structural invariants every node must satisfy.
+// 중복 키 삽입이 허용되므로 키는 비내림차순(non-decreasing)이면 유효하다.
+func (b *Btree) validateNode(n *Node) error {
+	if n == nil {
+		return corruptPage(-1, "nil node")
+	}
+	for i := 1; i < len(n.items); i++ {
+		if n.items[i].Key < n.items[i-1].Key {
+			return corruptPage(n.offset, "keys out of order at index %d", i)
+		}
+	}
+	if len(n.childrenOffsets) != 0 && len(n.childrenOffsets) != len(n.items)+1 {
+		return corruptPage(n.offset, "%d children for %d items", len(n.childrenOffsets), len(n.items))
+	}
+	for _, child := range n.childrenOffsets {
+		if child < b.firstNode || child%int64(b.pageSize) != 0 || child == n.offset {
+			return corruptPage(n.offset, "invalid child offset %d", child)
+		}
+	}
+	return nil
+}
+
+// openFallback opens the redundant copy configured in BtConfig.FallbackPath.
+// A missing copy only disables recovery; it never prevents the tree from opening.
+func (b *Btree) openFallback(config BtConfig) {
+	if config.FallbackPath == "" {
+		return
+	}
+	flags := os.O_RDONLY
+	if config.MirrorWrites {
+		flags = os.O_RDWR | os.O_CREATE
+	}
+	f, err := os.OpenFile(config.FallbackPath, flags, 0666)
+	if err != nil {
+		return
+	}
+	b.fallback = f
+	b.mirrorWrites = config.MirrorWrites
+}
+
+// mirrorPage writes a page to the fallback copy when MirrorWrites is enabled.
+// Mirror failures are not fatal: the primary write already succeeded.
+func (b *Btree) mirrorPage(page []byte, offset int64) {
+	if b.fallback == nil || !b.mirrorWrites {
+		return
+	}
+	b.fallback.WriteAt(page, offset)
+}
+
+// recoverPage handles a node page that failed to load. Corrupt pages are quarantined,
+// re-read once (to rule out a torn read) and then served from the fallback copy, which
+// is also written back to repair the main file. Non-corruption errors are returned as is.
+func (b *Btree) recoverPage(offset int64, cause error) (*Node, error) {
+	if !errors.Is(cause, ErrPageCorrupted) {
+		return nil, cause
+	}
+	b.corruptions.Add(1)
+	b.quarantineMu.Lock()
+	b.quarantine[offset] = cause.Error()
+	b.quarantineMu.Unlock()
+
+	if n, err := b.readNodeFromDisk(offset); err == nil {
+		b.release(offset)
+		return n, nil
+	}
+	if b.fallback == nil {
+		return nil, cause
+	}
+	page := make([]byte, b.pageSize)
+	if _, err := b.fallback.ReadAt(page, offset); err != nil {
+		return nil, fmt.Errorf("%w (fallback read failed: %v)", cause, err)
+	}
+	n, err := b.decodeNode(page, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%w (fallback copy also corrupt)", cause)
+	}
+	if _, err := b.file.WriteAt(page, offset); err == nil {
+		b.repairs.Add(1)
+		b.release(offset)
+	}
+	return n, nil
+}
+
+// release removes an offset from quarantine after it was read or repaired successfully.
+func (b *Btree) release(offset int64) {
+	b.quarantineMu.Lock()
+	delete(b.quarantine, offset)
+	b.quarantineMu.Unlock()
+}
+
+// evictNode drops a node from the cache, e.g. after it failed validation.
+func (b *Btree) evictNode(offset int64) {
+	b.cache.remove(offset)
+}
+
+// CorruptionCount returns the number of corrupt pages or cached nodes detected so far.
+func (b *Btree) CorruptionCount() int64 {
+	return b.corruptions.Load()
+}
+
+// RepairCount returns the number of pages rewritten from the fallback copy.
+func (b *Btree) RepairCount() int64 {
+	return b.repairs.Load()
+}
+
+// QuarantinedPages returns the offsets of pages that could not be recovered.
+func (b *Btree) QuarantinedPages() []int64 {
+	b.quarantineMu.Lock()
+	defer b.quarantineMu.Unlock()
+	offsets := make([]int64, 0, len(b.quarantine))
+	for offset := range b.quarantine {
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
@@ -0,0 +1,373 @@
+package btree
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 래치 크래빙(latch crabbing): 트리 전체 잠금 대신 페이지마다 읽기/쓰기 래치를 두고, 루트에서
+// 잎으로 내려가며 자식의 래치를 잡은 뒤에 부모의 래치를 놓습니다. 삽입은 가득 찬 자식을 내려가기
+// 전에 미리 나누므로(선제 분할) 지나온 노드는 다시 바뀌지 않고, 한 번에 부모와 자식 두 래치만
+// 가집니다. 그래서 서로 다른 서브트리로 가는 삽입과 조회, Scan은 병렬로 진행됩니다.
+//
+//   - 루트 위치는 트리별 rootLatch가 보호합니다. 조회는 루트 노드의 래치를 잡을 때까지 공유로,
+//     삽입은 루트가 분할될지 알 때까지 배타적으로 잡습니다.
+//   - 래치는 항상 위에서 아래로만 잡으므로 교착이 생기지 않습니다.
+//   - 삭제(병합과 재분배로 형제와 부모를 함께 바꿈), Sync, Close는 pager.mu를 배타적으로 잡아
+//     래치를 쓰는 연산 전체를 막습니다. 삽입, 조회, Scan과 백그라운드 flusher는 공유로 잡습니다.
+//   - 쓰기 래치를 잡은 노드는 버퍼 풀에 고정(pin)되므로, 퇴출의 write-back이 변경 중인 노드를
+//     쓰지 않습니다. flusher는 dirty 페이지를 쓰는 동안 그 페이지의 읽기 래치를 잡습니다.
+//   - Length, RootOffset, 헤더 기록(headerDirty)은 트리별 metaMu가 보호합니다.
+//
+// ThreadSafe가 아닌 트리는 래치와 고정을 모두 건너뜁니다.
+
+const latchShards = 16
+
+// latchTable holds a reader/writer latch per page offset while any goroutine uses it.
+type latchTable struct {
+	shards [latchShards]latchShard
+}
+
+type latchShard struct {
+	mu      sync.Mutex
+	latches map[int64]*latch
+}
+
+type latch struct {
+	sync.RWMutex
+	refs int // Goroutines holding or waiting for the latch
+}
+
+func (t *latchTable) shard(offset int64) *latchShard {
+	return &t.shards[(uint64(offset)*0x9E3779B97F4A7C15)>>(64-4)]
+}
+
+// acquire latches the page at offset, shared or exclusive.
+func (t *latchTable) acquire(offset int64, exclusive bool) {
+	s := t.shard(offset)
+	s.mu.Lock()
+	if s.latches == nil {
+		s.latches = make(map[int64]*latch)
+	}
+	l, ok := s.latches[offset]
+	if !ok {
+		l = &latch{}
+		s.latches[offset] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+	if exclusive {
+		l.Lock()
+	} else {
+		l.RLock()
+	}
+}
+
+// release unlatches the page at offset. The latch is dropped once nobody uses it.
+func (t *latchTable) release(offset int64, exclusive bool) {
+	s := t.shard(offset)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.latches[offset]
+	if exclusive {
+		l.Unlock()
+	} else {
+		l.RUnlock()
+	}
+	if l.refs--; l.refs == 0 {
+		delete(s.latches, offset)
+	}
+}
+
+// latched is a node held under its page latch.
+type latched struct {
+	*Node
+	held      bool // The page latch is held (thread-safe trees only)
+	exclusive bool
+	pinned    bool // The node is pinned in the buffer pool
+}
+
+// latchNode latches the page at offset and reads its node. Exclusive latches also pin the
+// node, since the caller is about to change it.
+func (b *Btree) latchNode(offset int64, exclusive bool) (latched, error) {
+	return b.latchNodeCost(offset, exclusive, nil)
+}
+
+// latchNodeCost is latchNode counting the page into cost, if not nil.
+func (b *Btree) latchNodeCost(offset int64, exclusive bool, cost *ports.ReadCost) (latched, error) {
+	if !b.threadSafe {
+		n, err := b.readNodeCost(offset, cost)
+		return latched{Node: n}, err
+	}
+	b.latches.acquire(offset, exclusive)
+	n, err := b.readNodeCost(offset, cost)
+	if err != nil {
+		b.latches.release(offset, exclusive)
+		return latched{}, err
+	}
+	l := latched{Node: n, held: true, exclusive: exclusive}
+	if exclusive {
+		l.pinned = b.cache.pin(n)
+	}
+	return l, nil
+}
+
+// unlatch releases a node returned by latchNode.
+func (b *Btree) unlatch(l latched) {
+	if l.pinned {
+		b.cache.unpin(l.offset)
+	}
+	if l.held {
+		b.latches.release(l.offset, l.exclusive)
+	}
+}
+
+// lockRoot latches the root position of the tree; unlockRoot releases it.
+func (b *Btree) lockRoot(exclusive bool) {
+	if !b.threadSafe {
+		return
+	}
+	if exclusive {
+		b.rootLatch.Lock()
+	} else {
+		b.rootLatch.RLock()
+	}
+}
+
+func (b *Btree) unlockRoot(exclusive bool) {
+	if !b.threadSafe {
+		return
+	}
+	if exclusive {
+		b.rootLatch.Unlock()
+	} else {
+		b.rootLatch.RUnlock()
+	}
+}
+
+// length returns the number of keys under metaMu.
+func (b *Btree) length() int {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	return b.Length
+}
+
+// saveMeta adds added to the length and records the root and length.
+func (b *Btree) saveMeta(added int) error {
+	b.metaMu.Lock()
+	defer b.metaMu.Unlock()
+	b.Length += added
+	return b.saveHeader()
+}
+
+// findKey returns the index of the first item of n not less than key and whether it is key.
+// For an inner node the index is also the child that may hold key.
+func findKey(n *Node, key string) (int, bool) {
+	i := sort.Search(len(n.items), func(i int) bool { return n.items[i].Key >= key })
+	return i, i < len(n.items) && n.items[i].Key == key
+}
+
+// insertCrabbing inserts or replaces key. It first tries insertOptimistic and otherwise
+// descends from the root with exclusive latches, splitting full nodes on the way down.
+func (b *Btree) insertCrabbing(key string, value []byte) error {
+	if done, err := b.insertOptimistic(key, value); done || err != nil {
+		return err
+	}
+	b.lockRoot(true)
+	if b.length() == 0 {
+		defer b.unlockRoot(true)
+		n := &Node{items: []Item{{Key: key, Value: value}}, offset: b.allocateNode()}
+		if err := b.writeNode(n, n.offset); err != nil {
+			return err
+		}
+		b.metaMu.Lock()
+		b.RootOffset = n.offset
+		b.metaMu.Unlock()
+		return b.saveMeta(1)
+	}
+	n, err := b.latchNode(b.RootOffset, true)
+	if err != nil {
+		b.unlockRoot(true)
+		return err
+	}
+	rootMoved := false
+	if _, found := findKey(n.Node, key); !found && len(n.items) == 2*b.Degree-1 {
+		newRoot := &Node{childrenOffsets: []int64{n.offset}, offset: b.allocateNode()}
+		err := b.splitChild(newRoot, 0, n.Node)
+		b.unlatch(n)
+		if err == nil {
+			n, err = b.latchNode(newRoot.offset, true)
+		}
+		if err != nil {
+			b.unlockRoot(true)
+			return err
+		}
+		b.metaMu.Lock()
+		b.RootOffset = newRoot.offset
+		b.metaMu.Unlock()
+		rootMoved = true
+	}
+	b.unlockRoot(true) // 루트는 이 삽입에서 더 이상 분할되지 않음
+
+	for {
+		i, found := findKey(n.Node, key)
+		if found {
+			err := b.replaceValue(n.Node, i, value)
+			b.unlatch(n)
+			if err != nil || !rootMoved {
+				return err
+			}
+			return b.saveMeta(0)
+		}
+		if isLeaf(n.Node) {
+			err := b.insertIntoLeaf(n.Node, i, key, value)
+			b.unlatch(n)
+			if err != nil {
+				return err
+			}
+			return b.saveMeta(1)
+		}
+		child, err := b.latchNode(n.childrenOffsets[i], true)
+		if err != nil {
+			b.unlatch(n)
+			return err
+		}
+		if _, found := findKey(child.Node, key); !found && len(child.items) == 2*b.Degree-1 {
+			if err := b.splitChild(n.Node, i, child.Node); err != nil {
+				b.unlatch(child)
+				b.unlatch(n)
+				return err
+			}
+			if key > n.items[i].Key {
+				b.unlatch(child)
+				if child, err = b.latchNode(n.childrenOffsets[i+1], true); err != nil {
+					b.unlatch(n)
+					return err
+				}
+			}
+		}
+		b.unlatch(n)
+		n = child
+	}
+}
+
+// insertOptimistic handles the common case with shared latches down to the leaf and an
+// exclusive latch on the leaf only, so inserts share the upper levels of the tree. It reports
+// false, holding nothing, when the leaf is full or key is stored in an inner node; the caller
+// then takes the exclusive path, which can split nodes.
+func (b *Btree) insertOptimistic(key string, value []byte) (bool, error) {
+	if !b.threadSafe {
+		return false, nil
+	}
+	b.lockRoot(false)
+	if b.length() == 0 {
+		b.unlockRoot(false)
+		return false, nil
+	}
+	n, err := b.latchNode(b.RootOffset, false)
+	if err != nil {
+		b.unlockRoot(false)
+		return false, err
+	}
+	// 부모의 읽기 래치(루트에서는 루트 위치 래치)는 자식의 래치를 잡을 때까지 유지함.
+	var parent latched
+	releaseParent := func() {
+		if parent.Node != nil {
+			b.unlatch(parent)
+		} else {
+			b.unlockRoot(false)
+		}
+	}
+	for !isLeaf(n.Node) {
+		i, found := findKey(n.Node, key)
+		if found {
+			b.unlatch(n)
+			releaseParent()
+			return false, nil
+		}
+		child, err := b.latchNode(n.childrenOffsets[i], false)
+		if err != nil {
+			b.unlatch(n)
+			releaseParent()
+			return false, err
+		}
+		releaseParent()
+		parent, n = n, child
+	}
+	// 잎의 읽기 래치를 쓰기 래치로 바꿈. 부모의 읽기 래치가 남아 있으므로 그 사이에 잎이 분할되어
+	// 키가 다른 노드로 옮겨 가지 않음 (분할은 부모의 쓰기 래치가 필요).
+	offset := n.offset
+	b.unlatch(n)
+	leaf, err := b.latchNode(offset, true)
+	releaseParent()
+	if err != nil {
+		return false, err
+	}
+	defer b.unlatch(leaf)
+	i, found := findKey(leaf.Node, key)
+	if found {
+		return true, b.replaceValue(leaf.Node, i, value)
+	}
+	if len(leaf.items) == 2*b.Degree-1 {
+		return false, nil
+	}
+	if err := b.insertIntoLeaf(leaf.Node, i, key, value); err != nil {
+		return true, err
+	}
+	return true, b.saveMeta(1)
+}
+
+// replaceValue overwrites the value of item i of n.
+func (b *Btree) replaceValue(n *Node, i int, value []byte) error {
+	if err := b.checkFits(n, len(value)-len(n.items[i].Value)); err != nil {
+		return err
+	}
+	n.items[i].Value = value
+	return b.writeNode(n, n.offset)
+}
+
+// insertIntoLeaf inserts a new item at position i of leaf n.
+func (b *Btree) insertIntoLeaf(n *Node, i int, key string, value []byte) error {
+	encKey, _ := b.keyDict.Encode(key)
+	if err := b.checkFits(n, 2+len(encKey)+2+len(value)); err != nil {
+		return err
+	}
+	n.items = append(n.items, Item{})
+	copy(n.items[i+1:], n.items[i:])
+	n.items[i] = Item{Key: key, Value: value}
+	return b.writeNode(n, n.offset)
+}
+
+// searchCrabbing looks key up, descending with shared latches.
+func (b *Btree) searchCrabbing(key string, cost *ports.ReadCost) ([]byte, error) {
+	b.lockRoot(false)
+	if b.length() == 0 {
+		b.unlockRoot(false)
+		return nil, ports.ErrKeyNotFound
+	}
+	n, err := b.latchNodeCost(b.RootOffset, false, cost)
+	b.unlockRoot(false)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		i, found := findKey(n.Node, key)
+		if found {
+			value := n.items[i].Value // 값은 제자리에서 바뀌지 않으므로 래치 밖에서도 유효
+			b.unlatch(n)
+			return value, nil
+		}
+		if isLeaf(n.Node) {
+			b.unlatch(n)
+			return nil, ports.ErrKeyNotFound
+		}
+		child, err := b.latchNodeCost(n.childrenOffsets[i], false, cost)
+		b.unlatch(n)
+		if err != nil {
+			return nil, err
+		}
+		n = child
+	}
+}
@@ -0,0 +1,74 @@
+package btree
+
+// 메모리 매핑 읽기: UseMmap이면 노드 페이지를 파일 매핑에서 바로 디코딩해 페이지마다 버퍼를
+// 할당하지 않습니다. 쓰기는 계속 WriteAt으로 하며, MAP_SHARED 매핑은 같은 페이지 캐시를
+// 보므로 쓴 내용이 바로 보입니다. 디코딩은 키와 값을 복사하므로 노드와 캐시는 매핑을 참조하지 않습니다.
+//
+// 매핑은 첫 읽기 때 파일 크기만큼 만들고, 매핑 밖의 페이지를 읽을 때 파일이 매핑보다 1/4 이상
+// 커졌으면 다시 매핑합니다. 그보다 적게 늘어난 끝부분(대개 방금 쓰여 캐시에 있는 페이지)은
+// ReadAt으로 읽어 추가 페이지마다 다시 매핑하지 않습니다. 매핑을 교체하거나 해제할 때는
+// mmapMu를 배타적으로 잡으므로 디코딩 중인 매핑이 사라지지 않습니다. 매핑에 실패하면
+// (mmap을 지원하지 않는 플랫폼 등) 그 트리는 ReadAt만 사용합니다.
+
+// readNodeMapped decodes the node at offset from the mapping. mapped is false if the page
+// lies outside the mapping; the caller then reads it with ReadAt.
+func (b *Btree) readNodeMapped(offset int64) (n *Node, mapped bool, err error) {
+	end := offset + int64(b.pageSize)
+	b.mmapMu.RLock()
+	if end > int64(len(b.mmap)) {
+		b.mmapMu.RUnlock()
+		if !b.remap(end) {
+			return nil, false, nil
+		}
+		b.mmapMu.RLock()
+		if end > int64(len(b.mmap)) {
+			b.mmapMu.RUnlock()
+			return nil, false, nil
+		}
+	}
+	defer b.mmapMu.RUnlock()
+	n, err = b.decodeNode(b.mmap[offset:end], offset)
+	return n, true, err
+}
+
+// remap maps the file again if it has grown enough for the mapping to cover end.
+func (b *Btree) remap(end int64) bool {
+	b.mmapMu.Lock()
+	defer b.mmapMu.Unlock()
+	current := int64(len(b.mmap))
+	if end <= current {
+		return true // 다른 고루틴이 이미 다시 매핑함
+	}
+	if !b.useMmap.Load() {
+		return false
+	}
+	stat, err := b.file.Stat()
+	if err != nil || stat.Size() < end {
+		return false
+	}
+	size := stat.Size()
+	if current > 0 && size < current+current/4 {
+		return false
+	}
+	data, err := mmapFile(b.file, size)
+	if err != nil {
+		b.useMmap.Store(false)
+		return false
+	}
+	if b.mmap != nil {
+		munmapFile(b.mmap)
+	}
+	b.mmap = data
+	return true
+}
+
+// unmap releases the mapping, e.g. on Close.
+func (b *Btree) unmap() {
+	b.mmapMu.Lock()
+	defer b.mmapMu.Unlock()
+	if b.mmap != nil {
+		munmapFile(b.mmap)
+		b.mmap = nil
+	}
+	b.useMmap.Store(false)
+}
@@ -0,0 +1,130 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+)
+
+// 오프라인 점검: CheckFiles는 저장소를 열지 않고 메인 파일과 WAL을 읽기만 하면서 형식을 검사합니다.
+// golite fsck(pkg/repair)가 사용합니다. 열 때와 달리 손상된 엔트리나 레코드를 만나도 그 앞까지
+// 읽은 내용을 유지하므로, 넘겨받은 키와 값으로 레코드를 살려 낼 수 있습니다. GLB3 WAL의 손상된
+// 레코드는 하나씩 문제로 보고하고 건너뛰어 뒤의 레코드도 살립니다. WAL 끝의 잘린 레코드는
+// 열 때 조용히 버려지는 것과 같이 문제가 아닌 TornBytes로만 보고합니다. 암호화된 파일은 키 없이
+// 읽을 수 없으므로 문제로 보고합니다.
+
+// FileCheck is the result of CheckFiles.
+type FileCheck struct {
+	Entries   int   // Entries decoded from the main file
+	Records   int   // Records decoded from the WAL
+	TornBytes int64 // Bytes of a record cut short at the end of the WAL, dropped on open
+	Live      int   // Keys left after replaying the WAL over the main file
+	Problems  []error
+}
+
+// CheckFiles validates the main file at path and its WAL as described in check.go and calls
+// fn, if not nil, with every live key and value in key order.
+func CheckFiles(path string, fn func(key, value string)) FileCheck {
+	var c FileCheck
+	live := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		c.Problems = append(c.Problems, fmt.Errorf("failed to read main file: %v", err))
+	}
+	if bytes.HasPrefix(data, magicEncrypted) {
+		c.Problems = append(c.Problems, fmt.Errorf("main file is encrypted: %w", pagecrypt.ErrKeyRequired))
+		data = nil
+	}
+	if len(data) > 0 {
+		entries, err := decodeMainEntries(data)
+		if err != nil {
+			c.Problems = append(c.Problems, err)
+		}
+		c.Entries = len(entries)
+		for _, e := range entries {
+			live[e.key] = e.value
+		}
+	}
+
+	wal, err := os.ReadFile(path + ".wal")
+	if err != nil && !os.IsNotExist(err) {
+		c.Problems = append(c.Problems, fmt.Errorf("failed to read WAL: %v", err))
+	}
+	if len(wal) > 0 {
+		c.checkWAL(wal, live)
+	}
+
+	c.Live = len(live)
+	if fn != nil {
+		keys := make([]string, 0, len(live))
+		for k := range live {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fn(k, live[k])
+		}
+	}
+	return c
+}
+
+// checkWAL replays wal over live, counting the bytes of the records it decodes.
+func (c *FileCheck) checkWAL(wal []byte, live map[string]string) {
+	if len(wal) < len(magicNumber) {
+		c.Problems = append(c.Problems, fmt.Errorf("WAL is too short for its magic number: %d bytes", len(wal)))
+		return
+	}
+	if bytes.Equal(wal[:len(magicNumber)], magicEncrypted) {
+		c.Problems = append(c.Problems, fmt.Errorf("WAL is encrypted: %w", pagecrypt.ErrKeyRequired))
+		return
+	}
+	version := formatVersion(wal[:len(magicNumber)])
+	if version == 0 || version == 4 {
+		c.Problems = append(c.Problems, fmt.Errorf("invalid WAL format: expected %s, got %q", walMagicNumber, wal[:len(magicNumber)]))
+		return
+	}
+	used := int64(len(magicNumber))
+	r := bufio.NewReader(bytes.NewReader(wal[len(magicNumber):]))
+	replay := func(rec walRecord) {
+		c.Records++
+		if rec.op == OpInsert {
+			live[rec.key] = rec.value
+		} else {
+			delete(live, rec.key)
+		}
+	}
+	if version == 3 {
+		rp := readCheckedWAL(r, int64(len(wal))-used, true, replay)
+		for _, err := range rp.corrupt {
+			c.Problems = append(c.Problems, fmt.Errorf("corrupted WAL %v", err))
+		}
+		c.TornBytes = int64(len(wal)) - used - rp.end
+		return
+	}
+	err := readWAL(r, version, int64(len(wal)), func(rec walRecord) {
+		replay(rec)
+		used += recordSize(version, rec)
+	})
+	if err != nil {
+		c.Problems = append(c.Problems, fmt.Errorf("corrupted WAL after record %d at offset %d: %v", c.Records, used, err))
+		return
+	}
+	c.TornBytes = int64(len(wal)) - used
+}
+
+// recordSize returns the encoded size of a GLB1 or GLB2 WAL record.
+func recordSize(version int, rec walRecord) int64 {
+	if version == 1 {
+		n := 1 + 2 + len(rec.key)
+		if rec.op == OpInsert {
+			n += 2 + len(rec.value)
+		}
+		return int64(n)
+	}
+	return int64(len(appendRecord(nil, rec.op, rec.key, rec.value)))
+}
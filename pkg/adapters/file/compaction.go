@@ -0,0 +1,58 @@
+package file
+
+// compaction 트리거: 주기(CompactionInterval)와 Close 외에도 쓰기가 끝날 때마다 두 기준을 보고, 넘으면
+// 워커에게 compaction을 요청합니다. 요청한 compaction이 끝날 때까지는 다시 요청하지 않습니다.
+//
+//   - WAL 크기: 마지막 compaction 이후 WAL에 기록된 바이트가 CompactionWALBytes를 넘을 때.
+//   - 죽은 엔트리 비율: 메모리의 엔트리 중 덮어쓰이거나 지워진 엔트리(tombstone 포함)의 비율이
+//     CompactionDeadRatio를 넘을 때. 엔트리가 minDeadRatioEntries개보다 적으면 보지 않습니다.
+//
+// compaction은 잠금을 쥔 채 엔트리의 스냅숏만 뜨고, 병합과 파일 기록은 잠금 없이 합니다. 읽기는
+// 원래 잠금을 잡지 않으며 쓰기도 그동안 계속됩니다. 새 파일을 rename한 뒤 다시 잠금을 잡고, 그사이에
+// 쓰기가 없었으면 WAL을 비웁니다. 쓰기가 있었으면 그 레코드가 WAL에만 있으므로 WAL을 그대로 둡니다.
+// WAL 전체를 새 파일 위에 재생해도 결과는 같기 때문입니다. 이때는 다음 compaction이 쓰기를 막은 채
+// 진행해 WAL을 비우므로, 쓰기가 계속되어도 WAL은 두 번의 compaction 사이 이상 자라지 않습니다.
+
+const (
+	defaultCompactionWALBytes  = 64 << 20 // 64MB
+	defaultCompactionDeadRatio = 0.5
+	minDeadRatioEntries        = 1024
+)
+
+// ForceCompaction rewrites the main file with the live entries and resets the WAL now, like
+// the ForceCompaction of types.Storage.
+func (f *File) ForceCompaction() error {
+	return f.compact()
+}
+
+// checkCompaction requests a compaction if the WAL or the dead entries exceed their
+// thresholds. Caller holds f.mu.
+func (f *File) checkCompaction() {
+	if f.compactRequested.Load() {
+		return
+	}
+	maxWAL, maxDead := f.config.CompactionWALBytes, f.config.CompactionDeadRatio
+	if maxWAL == 0 {
+		maxWAL = defaultCompactionWALBytes
+	}
+	if maxDead == 0 {
+		maxDead = defaultCompactionDeadRatio
+	}
+	walFull := maxWAL > 0 && f.walBytes.Load() > maxWAL
+	entries := len(f.data)
+	deadFull := maxDead > 0 && entries >= minDeadRatioEntries && float64(entries-f.live) > maxDead*float64(entries)
+	if walFull || deadFull {
+		f.compactTriggers.Add(1)
+		f.requestCompaction()
+	}
+}
+
+// requestCompaction asks the compaction worker to run now. Must be called after the worker
+// has started.
+func (f *File) requestCompaction() {
+	f.compactRequested.Store(true)
+	select {
+	case f.compactCh <- struct{}{}:
+	default: // 이미 요청이 대기 중
+	}
+}
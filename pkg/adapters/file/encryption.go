@@ -0,0 +1,186 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+)
+
+// 저장 데이터 암호화: FileConfig.EncryptionKey를 주면 두 파일을 GLE2 포맷으로 기록합니다.
+//
+//	메인 파일: "GLE2" + pagecrypt 봉인(엔트리 수 + GLB2 엔트리들, AAD "main")
+//	WAL:      "GLE2" + 프레임마다 봉인 길이(u32) + pagecrypt 봉인(GLB2 레코드들, AAD 프레임 오프셋)
+//
+// WAL flush 한 번이 프레임 하나이며, 파일 끝의 잘린 프레임은 열 때 잘라 냅니다. 프레임은 봉인으로
+// 인증되므로 레코드에 GLB3의 crc와 순번을 붙이지 않고, 인증에 실패한 프레임을 평문 WAL의 손상된
+// 레코드처럼 RecoveryMode에 따라 멈추거나 건너뜁니다. 봉인된 데이터마다
+// 키 ID가 있으므로, Rekey(새 키로 compaction)가 메인 파일을 바꾼 뒤 WAL을 비우기 전에 멈췄다면 WAL의
+// 레코드는 이미 메인 파일에 들어 있어 이전 키 없이 열 때는 버립니다. 메인 파일을 바꾸기 전에 멈췄다면
+// 새 키를 EncryptionKey, 이전 키를 PreviousEncryptionKey로 주고 열면 열면서 compaction합니다.
+
+// magicEncrypted starts the main file and WAL of encrypted storage.
+var magicEncrypted = []byte("GLE2")
+
+// mainAAD binds the sealed main file contents.
+var mainAAD = []byte("main")
+
+// walMagic returns the magic number the WAL starts with.
+func (f *File) walMagic() []byte {
+	if f.keys != nil {
+		return magicEncrypted
+	}
+	return walMagicNumber
+}
+
+// sealMain encrypts a GLB2 main file (magic included) with the current key of keys in the
+// encrypted format, or returns it as is if keys is nil.
+func sealMain(keys *pagecrypt.Keyring, data []byte) ([]byte, error) {
+	if keys == nil {
+		return data, nil
+	}
+	out := append(make([]byte, 0, len(data)+pagecrypt.Overhead), magicEncrypted...)
+	out, err := keys.Current().Seal(out, data[len(magicNumber):], mainAAD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt main file: %v", err)
+	}
+	return out, nil
+}
+
+// openMain returns the GLB2 main file of data, decrypting it if it is encrypted, and records
+// the key it was sealed with.
+func (f *File) openMain(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, magicEncrypted) {
+		if f.keys != nil {
+			return nil, fmt.Errorf("%w: main file is %q", pagecrypt.ErrNotEncrypted, data[:min(4, len(data))])
+		}
+		return data, nil
+	}
+	if f.keys == nil {
+		return nil, pagecrypt.ErrKeyRequired
+	}
+	sealed := data[len(magicEncrypted):]
+	id, err := pagecrypt.SealedID(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("corrupted main file: %v", err)
+	}
+	plain, err := f.keys.Open(append([]byte(nil), magicV2...), sealed, mainAAD)
+	if err != nil {
+		if errors.Is(err, pagecrypt.ErrWrongKey) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("corrupted main file: %v", err)
+	}
+	f.mainKey = id
+	return plain, nil
+}
+
+// sealFrame encrypts WAL records as a frame written at offset.
+func (f *File) sealFrame(records []byte, offset int64) ([]byte, error) {
+	frame := make([]byte, 4, 4+len(records)+pagecrypt.Overhead)
+	frame, err := f.keys.Current().Seal(frame, records, frameAAD(offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt wal: %v", err)
+	}
+	byteOrder.PutUint32(frame, uint32(len(frame)-4))
+	return frame, nil
+}
+
+func frameAAD(offset int64) []byte {
+	return byteOrder.AppendUint64(nil, uint64(offset))
+}
+
+// readFrames decodes the frames of an encrypted WAL following the magic number and returns
+// the offset where the valid frames end and the corrupted frames. A frame cut short at the
+// end of the log ends the replay without error; a frame sealed with an unknown key after a
+// main file sealed with the current key is left by an interrupted rekey and ends it too (see
+// encryption.go). A frame failing authentication is corrupted and ends the replay in strict
+// recovery mode; best_effort skips it.
+func (f *File) readFrames(r *bufio.Reader, size int64, fn func(walRecord)) (int64, []error, error) {
+	offset := int64(len(magicEncrypted))
+	var header [4]byte
+	var corrupt []error
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return offset, corrupt, nil
+		}
+		n := int64(byteOrder.Uint32(header[:]))
+		if n > size-offset-4 {
+			return offset, corrupt, nil // 잘린 프레임
+		}
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return offset, corrupt, nil
+		}
+		plain, err := f.keys.Open(nil, sealed, frameAAD(offset))
+		if errors.Is(err, pagecrypt.ErrWrongKey) && f.mainKey == f.keys.Current().ID() {
+			f.config.Logger.Warn("Discarding WAL left by an interrupted rekey", "offset", offset)
+			return offset, corrupt, nil
+		}
+		if errors.Is(err, pagecrypt.ErrWrongKey) {
+			return offset, corrupt, err
+		}
+		if err == nil {
+			// 프레임은 인증되었으므로 레코드를 모두 읽은 뒤에 반영
+			var recs []walRecord
+			err = readWAL(bufio.NewReader(bytes.NewReader(plain)), 2, int64(len(plain)), func(rec walRecord) {
+				recs = append(recs, rec)
+			})
+			for _, rec := range recs {
+				if err == nil {
+					fn(rec)
+				}
+			}
+		}
+		if err != nil {
+			corrupt = append(corrupt, fmt.Errorf("frame at offset %d: %v", offset, err))
+			if f.config.RecoveryMode != "best_effort" {
+				return offset, corrupt, nil
+			}
+		}
+		offset += 4 + n
+	}
+}
+
+// Rekey re-encrypts the storage with newKey by compacting it, and uses newKey for all later
+// writes. Until the compaction is done the previous key is still needed to read the files.
+func (f *File) Rekey(newKey []byte) error {
+	if f == nil {
+		return fmt.Errorf("file adapter is nil")
+	}
+	f.mu.Lock()
+	f.flushMu.Lock()
+	if f.keys == nil {
+		f.flushMu.Unlock()
+		f.mu.Unlock()
+		return pagecrypt.ErrNotEncrypted
+	}
+	next, err := f.keys.Rotate(newKey)
+	if err == nil {
+		f.keys = next
+	}
+	f.flushMu.Unlock()
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := f.compact(); err != nil {
+		return fmt.Errorf("failed to rekey: %v", err)
+	}
+	f.dropPreviousKey()
+	return nil
+}
+
+// dropPreviousKey forgets the previous key once the files are sealed with the current one.
+func (f *File) dropPreviousKey() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+	if f.keys != nil && f.keys.Previous() != nil && f.mainKey == f.keys.Current().ID() {
+		f.keys = f.keys.WithoutPrevious()
+	}
+}
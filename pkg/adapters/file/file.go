@@ -2,44 +2,119 @@ package file
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
 	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
 // FileConfig defines configuration for the File storage adapter.
 type FileConfig struct {
-	FilePath   string
+	FilePath string
+	// ThreadSafe는 이전 설정과의 호환을 위해 남아 있습니다. 쓰기는 백그라운드 워커(WAL flush,
+	// compaction)와도 겹치므로 이 값과 관계없이 항상 잠금 안에서 WAL 기록 후 메모리에 반영됩니다.
 	ThreadSafe bool
+	Events     *events.Bus // 컴팩션 이벤트 발행 대상 (nil이면 발행하지 않음)
+	// MemoryMode는 모든 데이터를 메모리에만 두고 WAL을 쓰지 않습니다. 파일은 PersistInterval마다,
+	// Persist/Sync 호출 시, 그리고 Close 시에만 기록되므로 마지막 영속화 이후의 쓰기는 장애 시 유실됩니다.
+	MemoryMode bool
+	// PersistInterval은 MemoryMode에서 변경이 있을 때 파일에 기록하는 주기입니다 (0이면 명시적 호출만).
+	PersistInterval time.Duration
+	// CompactionInterval는 주기적 compaction 간격입니다 (0이면 기본값 968초).
+	// 실행 중에는 SetCompactionInterval로 변경할 수 있습니다.
+	CompactionInterval time.Duration
+	// CompactionWALBytes와 CompactionDeadRatio는 주기와 별개로 compaction을 요청하는 기준입니다.
+	// WAL이 CompactionWALBytes 바이트를 넘거나, 엔트리 중 덮어쓰이거나 지워진 엔트리의 비율이
+	// CompactionDeadRatio를 넘으면 요청합니다 (0이면 기본값 64MB, 0.5, 음수는 끔). compaction.go 참고.
+	CompactionWALBytes  int64
+	CompactionDeadRatio float64
+	// Durability는 쓰기가 반환되기 전의 WAL 기록 수준입니다 (빈 값은 async).
+	// none은 MemoryMode와 같이 WAL을 쓰지 않고, fsync-per-commit/batch는 fsync가 끝난 뒤 반환합니다.
+	Durability ports.Durability
+	// RecoveryMaxWAL(바이트)과 RecoveryMaxReplay는 시작 시 재생한 WAL이 이 값을 넘으면 복구 직후
+	// compaction을 예약하는 기준입니다 (0이면 기본값 64MB, 5초). recovery.go 참고.
+	RecoveryMaxWAL    int64
+	RecoveryMaxReplay time.Duration
+	// RecoveryMode는 시작 시 WAL에서 손상된 레코드를 만났을 때의 처리입니다. strict(빈 값)는 그 앞에서
+	// 재생을 멈추고, best_effort는 건너뛰고 이어서 재생합니다 (format.go 참고).
+	RecoveryMode string
+	// Logger는 백그라운드 작업과 복구 로그를 받습니다 (nil이면 utils.DefaultLogger).
+	Logger utils.Logger
+	// EncryptionKey를 주면 메인 파일과 WAL을 AES-256-GCM으로 암호화합니다 (pagecrypt.KeySize 바이트,
+	// encryption.go 참고). 암호화된 저장소는 같은 키로만 열 수 있으며 Rekey로 키를 바꿉니다.
+	EncryptionKey []byte
+	// PreviousEncryptionKey는 중단된 Rekey가 바꾸던 이전 키입니다. 두 키로 열면 Rekey를 마칩니다.
+	PreviousEncryptionKey []byte
 }
 
+// defaultCompactionInterval is used when FileConfig.CompactionInterval is unset.
+const defaultCompactionInterval = 968 * time.Second
+
 // File implements the StoragePort interface using a file-based backend.
 type File struct {
-	config    FileConfig
-	file      *os.File
-	walFile   *os.File
-	data      []entry         // 모든 엔트리를 보관 (compaction 대상)
-	index     *sync.Map       // 빠른 조회를 위한 인메모리 해시 인덱스
-	isSorted  bool            // compaction 후 정렬 여부
-	mu        sync.RWMutex    // data와 isSorted 보호
-	walMu     sync.Mutex      // WAL 버퍼 관련 동기화
-	compactCh chan struct{}   // compaction 요청 채널
-	stopCh    chan struct{}   // 워커 종료 채널
-	walCh     chan []WalEntry // 배치 WAL 엔트리 전송 채널
-	wg        sync.WaitGroup
-	walBuffer []byte // WAL 바이너리 버퍼
-	walBufIdx int
-	flushSize int
-	seqBuffer []byte // ThreadSafe=false일 때의 WAL 버퍼
-	seqBufIdx int
+	config       FileConfig
+	file         *os.File
+	walFile      *os.File
+	data         []entry       // 모든 엔트리를 보관 (compaction 대상)
+	index        *sync.Map     // 빠른 조회를 위한 인메모리 해시 인덱스
+	isSorted     bool          // compaction 후 정렬 여부
+	mu           sync.RWMutex  // data와 isSorted 보호, 쓰기의 WAL 기록과 반영을 한 구간으로 묶음
+	walMu        sync.Mutex    // walBuffer와 walSeq 보호
+	flushMu      sync.Mutex    // WAL 파일 기록 순서 보장 (flushing, walErr 보호)
+	compactCh    chan struct{} // compaction 요청 채널
+	stopCh       chan struct{} // 워커 종료 채널
+	wg           sync.WaitGroup
+	walBuffer    pendingWAL    // 기록 대기 중인 WAL 레코드
+	flushing     pendingWAL    // 기록 중인 WAL 레코드 (flushMu 소유)
+	flushSize    int           // 버퍼가 이 크기에 도달하면 기록
+	walSeq       uint64        // WAL 버퍼에 기록된 쓰기 수 (walMu)
+	recordSeq    uint64        // 마지막으로 기록한 WAL 레코드의 순번 (flushMu)
+	durableSeq   atomic.Uint64 // fsync까지 끝난 마지막 쓰기의 walSeq
+	walErr       error         // 실패한 WAL 기록; 다음 compaction까지 모든 쓰기가 실패함 (flushMu)
+	closeOnce    sync.Once
+	closeErr     error
+	dirty        atomic.Bool        // MemoryMode: 마지막 Persist 이후 변경 여부
+	legacyWAL    bool               // WAL이 GLB1/GLB2 포맷이라 새 레코드를 추가하기 전에 compaction이 필요
+	intervalCh   chan time.Duration // compaction 주기 변경 요청 채널
+	walBytes     atomic.Int64       // 마지막 compaction 이후 WAL에 기록된 레코드 바이트 (복구 부채)
+	recovery     RecoveryInfo       // 열 때 수행한 WAL 재생 결과
+	walCoalesced atomic.Int64       // 같은 키의 대기 중인 레코드에 합쳐진 WAL 레코드 수
+	keys         *pagecrypt.Keyring // 저장 데이터 암호화 키 (nil이면 평문, mu와 flushMu를 모두 잡고 변경)
+	mainKey      uint32             // 메인 파일을 봉인한 키의 ID
+	compactedAt  atomic.Int64       // 마지막으로 성공한 compaction 시각 (UnixNano, 0 = 없음)
+
+	compactMu        sync.Mutex   // compaction을 한 번에 하나씩 실행
+	compactRequested atomic.Bool  // 트리거가 요청한 compaction이 아직 끝나지 않음
+	compactTriggers  atomic.Int64 // 트리거가 요청한 compaction 수
+	walKept          bool         // 앞선 compaction이 경합한 쓰기 때문에 WAL을 비우지 못함 (mu)
+	live             int          // 인덱스의 키 수; len(data)-live가 죽은 엔트리 수 (mu)
 }
 
+var (
+	_ ports.ManagedStorage   = (*File)(nil)
+	_ ports.ScannablePort    = (*File)(nil)
+	_ ports.PrefixDeleter    = (*File)(nil)
+	_ ports.RangeDeleter     = (*File)(nil)
+	_ ports.BytesPort        = (*File)(nil)
+	_ ports.HealthChecker    = (*File)(nil)
+	_ ports.Rekeyer          = (*File)(nil)
+	_ ports.SizeReporter     = (*File)(nil)
+	_ ports.StatusReporter   = (*File)(nil)
+	_ ports.RecoveryReporter = (*File)(nil)
+)
+
 // WalEntry represents a write-ahead log entry.
 type WalEntry struct {
 	Op    string
@@ -59,13 +134,41 @@ const (
 	OpDelete byte = 0x01
 )
 
-// Magic number for binary WAL format (version 1).
-var magicNumber = []byte("GLB1")
-
+// NewFile opens the storage at config.FilePath, replaying its WAL. It fails with
+// utils.ErrLocked while another process has the storage open.
 func NewFile(config FileConfig) (*File, error) {
 	if config.FilePath == "" {
 		return nil, fmt.Errorf("file path is required")
 	}
+	if config.CompactionInterval <= 0 {
+		config.CompactionInterval = defaultCompactionInterval
+	}
+	if config.CompactionDeadRatio > 1 {
+		return nil, fmt.Errorf("compaction dead ratio must be <= 1, got %v", config.CompactionDeadRatio)
+	}
+	durability, err := ports.ParseDurability(string(config.Durability))
+	if err != nil {
+		return nil, err
+	}
+	config.Durability = durability
+	switch config.RecoveryMode {
+	case "":
+		config.RecoveryMode = "strict"
+	case "strict", "best_effort":
+	default:
+		return nil, fmt.Errorf("unknown recovery mode %q", config.RecoveryMode)
+	}
+	if config.Logger == nil {
+		config.Logger = utils.DefaultLogger()
+	}
+	config.Logger = utils.With(config.Logger, "adapter", "file", "path", config.FilePath)
+	if durability == ports.DurabilityNone {
+		config.MemoryMode = true
+	}
+	keys, err := pagecrypt.NewKeyring(config.EncryptionKey, config.PreviousEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
 
 	file, err := os.OpenFile(config.FilePath, os.O_RDWR|os.O_CREATE, 0666)
 	if err != nil {
@@ -77,44 +180,79 @@ func NewFile(config FileConfig) (*File, error) {
 		file.Close()
 		return nil, fmt.Errorf("failed to open wal file: %v", err)
 	}
+	// 메인 파일은 컴팩션 때 새 파일로 바뀌므로 계속 같은 파일인 WAL을 잠금
+	if err := utils.LockFile(walFile); err != nil {
+		file.Close()
+		walFile.Close()
+		return nil, err
+	}
 
 	f := &File{
-		config:    config,
-		file:      file,
-		walFile:   walFile,
-		data:      make([]entry, 0, 1000),
-		index:     &sync.Map{},
-		isSorted:  true,
-		compactCh: make(chan struct{}, 1),
-		stopCh:    make(chan struct{}),
-		walCh:     make(chan []WalEntry, 1000),
-		walBuffer: make([]byte, 4*1024*1024),
-		flushSize: 4 * 1024 * 1024,
-		seqBuffer: make([]byte, 4*1024*1024),
+		config:     config,
+		file:       file,
+		walFile:    walFile,
+		data:       make([]entry, 0, 1000),
+		index:      &sync.Map{},
+		isSorted:   true,
+		compactCh:  make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+		intervalCh: make(chan time.Duration, 1),
+		walBuffer:  newPendingWAL(4 * 1024 * 1024),
+		flushing:   newPendingWAL(4 * 1024 * 1024),
+		flushSize:  4 * 1024 * 1024,
+		keys:       keys,
 	}
 
 	if err := f.loadFromFile(); err != nil {
 		file.Close()
 		walFile.Close()
-		return nil, fmt.Errorf("failed to load main file: %v", err)
+		return nil, fmt.Errorf("failed to load main file: %w", err)
+	}
+	// 메인 파일 데이터로 인덱스 구축 (WAL 재생이 그 위에 쓰기와 삭제를 반영)
+	for _, e := range f.data {
+		f.index.Store(e.key, e.value)
 	}
+	start := time.Now()
 	if err := f.loadFromWAL(); err != nil {
 		file.Close()
 		walFile.Close()
-		return nil, fmt.Errorf("failed to load wal file: %v", err)
+		return nil, fmt.Errorf("failed to load wal file: %w", err)
 	}
+	f.recovery.Duration = time.Since(start)
+	f.index.Range(func(_, _ interface{}) bool {
+		f.live++
+		return true
+	})
 
-	// 초기 데이터로 인덱스 구축
-	for _, e := range f.data {
-		if !e.deleted {
-			f.index.Store(e.key, e.value)
+	// 이전 포맷의 WAL 뒤에 GLB3 레코드를 이어 쓸 수 없으므로 워커 시작 전에 두 파일을 다시 씀
+	if f.legacyWAL {
+		if err := f.compact(); err != nil {
+			file.Close()
+			walFile.Close()
+			return nil, fmt.Errorf("failed to upgrade wal format: %v", err)
+		}
+	}
+	// 이전 키와 함께 열었으면 새 키로 다시 써서 중단된 Rekey를 마침
+	if keys != nil && keys.Previous() != nil {
+		if err := f.compact(); err != nil {
+			file.Close()
+			walFile.Close()
+			return nil, fmt.Errorf("failed to finish rekey: %v", err)
 		}
+		f.dropPreviousKey()
 	}
 
 	f.wg.Add(1)
 	go f.walWorker()
 	f.wg.Add(1)
 	go f.compactWorker()
+	if config.MemoryMode && config.PersistInterval > 0 {
+		f.wg.Add(1)
+		go f.persistWorker()
+	}
+	if !f.legacyWAL {
+		f.scheduleRecoveryCompaction()
+	}
 
 	return f, nil
 }
@@ -136,34 +274,18 @@ func (f *File) loadFromFile() error {
 		return fmt.Errorf("failed to read file: %v", err)
 	}
 
-	if len(data) < 8 || string(data[:4]) != string(magicNumber) {
-		log.Printf("loadFromFile: invalid format, len=%d, magic=%s", len(data), data[:4])
-		return fmt.Errorf("invalid main file format")
+	plain, err := f.openMain(data)
+	if err != nil {
+		return err
 	}
-	numEntries := binary.LittleEndian.Uint32(data[4:8])
-	f.data = make([]entry, 0, numEntries)
-	pos := 8
-	for i := uint32(0); i < numEntries; i++ {
-		if pos+4 > len(data) {
-			log.Printf("loadFromFile: insufficient data at pos=%d, len=%d", pos, len(data))
-			return fmt.Errorf("corrupted main file: insufficient data")
-		}
-		// 기록 순서: keyLen (2), valLen (2), key, value
-		keyLen := binary.LittleEndian.Uint16(data[pos : pos+2])
-		valLen := binary.LittleEndian.Uint16(data[pos+2 : pos+4])
-		pos += 4
-		if pos+int(keyLen)+int(valLen) > len(data) {
-			log.Printf("loadFromFile: data overflow at pos=%d, keyLen=%d, valLen=%d, len=%d", pos, keyLen, valLen, len(data))
-			return fmt.Errorf("corrupted main file: data overflow")
-		}
-		key := string(data[pos : pos+int(keyLen)])
-		pos += int(keyLen)
-		value := string(data[pos : pos+int(valLen)])
-		pos += int(valLen)
-		f.data = append(f.data, entry{key: key, value: value})
+	entries, err := decodeMainEntries(plain)
+	if err != nil {
+		f.config.Logger.Error("Failed to decode main file", "bytes", len(data), "error", err)
+		return err
 	}
+	f.data = entries
 	sort.Slice(f.data, func(i, j int) bool { return f.data[i].key < f.data[j].key })
-	log.Printf("loadFromFile: loaded entries=%d, final pos=%d, data len=%d", len(f.data), pos, len(data))
+	f.config.Logger.Info("Loaded main file", "entries", len(f.data), "bytes", len(data))
 	return nil
 }
 
@@ -176,7 +298,7 @@ func (f *File) loadFromWAL() error {
 		return fmt.Errorf("failed to stat wal file: %v", err)
 	}
 	if stat.Size() == 0 {
-		if _, err := f.walFile.Write(magicNumber); err != nil {
+		if _, err := f.walFile.Write(f.walMagic()); err != nil {
 			return fmt.Errorf("failed to write magic number: %v", err)
 		}
 		return f.walFile.Sync()
@@ -184,160 +306,146 @@ func (f *File) loadFromWAL() error {
 
 	scanner := bufio.NewReader(f.walFile)
 	magic := make([]byte, len(magicNumber))
-	if _, err := scanner.Read(magic); err != nil {
+	if _, err := io.ReadFull(scanner, magic); err != nil {
 		return fmt.Errorf("failed to read magic number: %v", err)
 	}
-	if string(magic) != string(magicNumber) {
-		return fmt.Errorf("invalid WAL format: expected %s, got %s", magicNumber, magic)
+	replay := func(rec walRecord) {
+		f.recovery.Records++
+		if rec.op == OpInsert {
+			f.data = append(f.data, entry{key: rec.key, value: rec.value})
+			f.index.Store(rec.key, rec.value)
+		} else {
+			f.data = append(f.data, entry{key: rec.key, deleted: true})
+			f.index.Delete(rec.key)
+		}
 	}
-
-	for {
-		op, err := scanner.ReadByte()
-		if err != nil {
-			break // EOF 정상 종료
+	if bytes.Equal(magic, magicEncrypted) {
+		return f.loadEncryptedWAL(scanner, stat.Size(), replay)
+	}
+	version := formatVersion(magic)
+	if version == 0 || version == 4 {
+		return fmt.Errorf("invalid WAL format: expected %s, got %s", walMagicNumber, magic)
+	}
+	if f.keys != nil {
+		if stat.Size() > int64(len(magic)) {
+			return fmt.Errorf("%w: WAL has unencrypted records", pagecrypt.ErrNotEncrypted)
 		}
+		return f.resetWAL() // 레코드가 없는 평문 WAL은 암호화 magic으로 바꿈
+	}
 
-		keyLenBuf := make([]byte, 2)
-		if _, err := scanner.Read(keyLenBuf); err != nil {
-			return fmt.Errorf("failed to read key length: %v", err)
+	end := stat.Size()
+	if version == 3 {
+		rp := readCheckedWAL(scanner, stat.Size()-int64(len(magic)), f.config.RecoveryMode == "best_effort", replay)
+		f.recordSeq = rp.lastSeq
+		f.recovery.Corrupted = len(rp.corrupt)
+		for _, err := range rp.corrupt {
+			f.config.Logger.Warn("Corrupted WAL record", "mode", f.config.RecoveryMode, "error", err)
 		}
-		keyLen := binary.LittleEndian.Uint16(keyLenBuf)
-		if keyLen > uint16(f.flushSize) {
-			return fmt.Errorf("key length %d exceeds max buffer size %d", keyLen, f.flushSize)
+		if end = int64(len(magic)) + rp.end; end < stat.Size() {
+			f.config.Logger.Warn("Truncating WAL after the last valid record", "offset", end, "bytes", stat.Size()-end)
+			if err := f.walFile.Truncate(end); err != nil {
+				return fmt.Errorf("failed to truncate wal: %v", err)
+			}
 		}
-
-		key := make([]byte, keyLen)
-		if _, err := scanner.Read(key); err != nil {
-			return fmt.Errorf("failed to read key: %v", err)
+	} else {
+		if err := readWAL(scanner, version, stat.Size(), replay); err != nil {
+			return err
 		}
+		f.legacyWAL = true
+	}
+	f.isSorted = false
+	f.recovery.WALBytes = end - int64(len(magic))
+	f.walBytes.Store(f.recovery.WALBytes)
+	return nil
+}
 
-		switch op {
-		case OpInsert:
-			valLenBuf := make([]byte, 2)
-			if _, err := scanner.Read(valLenBuf); err != nil {
-				return fmt.Errorf("failed to read value length: %v", err)
-			}
-			valLen := binary.LittleEndian.Uint16(valLenBuf)
-			if valLen > uint16(f.flushSize) {
-				return fmt.Errorf("value length %d exceeds max buffer size %d", valLen, f.flushSize)
-			}
-
-			value := make([]byte, valLen)
-			if _, err := scanner.Read(value); err != nil {
-				return fmt.Errorf("failed to read value: %v", err)
-			}
-			f.data = append(f.data, entry{key: string(key), value: string(value)})
-			f.index.Store(string(key), string(value))
-		case OpDelete:
-			f.data = append(f.data, entry{key: string(key), deleted: true})
-			f.index.Delete(string(key))
-		default:
-			return fmt.Errorf("unknown operation code: %d", op)
+// loadEncryptedWAL replays the frames of an encrypted WAL, cutting off a torn frame at its
+// end so that new frames directly follow the valid ones. Caller holds f.mu.
+func (f *File) loadEncryptedWAL(r *bufio.Reader, size int64, replay func(walRecord)) error {
+	if f.keys == nil {
+		return pagecrypt.ErrKeyRequired
+	}
+	end, corrupt, err := f.readFrames(r, size, replay)
+	if err != nil {
+		return err
+	}
+	f.recovery.Corrupted = len(corrupt)
+	for _, err := range corrupt {
+		f.config.Logger.Warn("Corrupted WAL frame", "mode", f.config.RecoveryMode, "error", err)
+	}
+	if end < size {
+		f.config.Logger.Warn("Truncating torn WAL tail", "offset", end, "bytes", size-end)
+		if err := f.walFile.Truncate(end); err != nil {
+			return fmt.Errorf("failed to truncate torn wal tail: %v", err)
 		}
 	}
 	f.isSorted = false
+	f.recovery.WALBytes = end - int64(len(magicEncrypted))
+	f.walBytes.Store(f.recovery.WALBytes)
 	return nil
 }
 
-func (f *File) Insert(key string, value interface{}) error {
-	valStr, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("value must be string")
+// resetWAL empties the WAL and writes its magic number.
+func (f *File) resetWAL() error {
+	if err := f.walFile.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate wal: %v", err)
 	}
-	if f.config.ThreadSafe {
-		f.mu.Lock()
-		f.data = append(f.data, entry{key: key, value: valStr})
-		f.isSorted = false
-		f.mu.Unlock()
-		go f.index.Store(key, valStr)
-		f.walCh <- []WalEntry{{Op: "INSERT", Key: key, Value: valStr}}
-	} else {
-		f.data = append(f.data, entry{key: key, value: valStr})
-		f.isSorted = false
-		go f.index.Store(key, valStr)
-		keyLen := uint16(len(key))
-		valLen := uint16(len(valStr))
-		entryLen := 1 + 2 + int(keyLen) + 2 + int(valLen)
-		if f.seqBufIdx+entryLen > f.flushSize {
-			f.flushSeqBuffer()
-		}
-		buf := f.seqBuffer[f.seqBufIdx : f.seqBufIdx+entryLen]
-		buf[0] = OpInsert
-		buf[1] = byte(keyLen & 0xFF)
-		buf[2] = byte(keyLen >> 8)
-		copy(buf[3:3+keyLen], key)
-		buf[3+keyLen] = byte(valLen & 0xFF)
-		buf[4+keyLen] = byte(valLen >> 8)
-		copy(buf[5+keyLen:], valStr)
-		f.seqBufIdx += entryLen
+	if _, err := f.walFile.Seek(0, 0); err != nil {
+		return fmt.Errorf("failed to reset wal: %v", err)
+	}
+	if _, err := f.walFile.Write(f.walMagic()); err != nil {
+		return fmt.Errorf("failed to write magic number: %v", err)
+	}
+	if err := f.walFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wal: %v", err)
 	}
 	return nil
 }
 
+// 파일 어댑터는 값을 string으로 보관합니다. Go string은 임의의 바이트열을 담을 수 있어 바이너리 값도
+// 그대로 보존되고, 불변이므로 data와 index가 복사 없이 같은 값을 공유할 수 있습니다.
+// []byte API(InsertBytes/GetBytes/DeleteBytes)는 경계에서 한 번만 변환합니다.
+
+// InsertBytes inserts a key-value pair; value is copied.
+func (f *File) InsertBytes(key, value []byte) error {
+	return f.Insert(string(key), string(value))
+}
+
+// GetBytes returns a copy of the value stored under key.
+func (f *File) GetBytes(key []byte) ([]byte, error) {
+	v, err := f.Get(string(key))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v.(string)), nil
+}
+
+// DeleteBytes removes key.
+func (f *File) DeleteBytes(key []byte) error {
+	return f.Delete(string(key))
+}
+
+// Insert stores value, which must be a string or []byte, under key.
+func (f *File) Insert(key string, value interface{}) error {
+	valStr, err := ports.ValueString(value)
+	if err != nil {
+		return err
+	}
+	return f.write([]walRecord{{op: OpInsert, key: key, value: valStr}}, nil)
+}
+
+// InsertBatch applies entries ("INSERT" or "DELETE") as one write.
 func (f *File) InsertBatch(entries []WalEntry) error {
-	if f.config.ThreadSafe {
-		f.mu.Lock()
-		for _, e := range entries {
-			if e.Op == "INSERT" {
-				f.data = append(f.data, entry{key: e.Key, value: e.Value})
-				f.index.Store(e.Key, e.Value)
-			} else if e.Op == "DELETE" {
-				f.data = append(f.data, entry{key: e.Key, deleted: true})
-				f.index.Delete(e.Key)
-			}
-		}
-		f.isSorted = false
-		f.mu.Unlock()
-		f.walCh <- entries
-	} else {
-		totalLen := 0
-		for _, e := range entries {
-			if e.Op == "INSERT" {
-				totalLen += 1 + 2 + len(e.Key) + 2 + len(e.Value)
-			} else if e.Op == "DELETE" {
-				totalLen += 1 + 2 + len(e.Key)
-			}
-		}
-		if f.seqBufIdx+totalLen > f.flushSize {
-			f.flushSeqBuffer()
+	recs := make([]walRecord, 0, len(entries))
+	for _, e := range entries {
+		switch e.Op {
+		case "INSERT":
+			recs = append(recs, walRecord{op: OpInsert, key: e.Key, value: e.Value})
+		case "DELETE":
+			recs = append(recs, walRecord{op: OpDelete, key: e.Key})
 		}
-		buf := f.seqBuffer[f.seqBufIdx : f.seqBufIdx+totalLen]
-		pos := 0
-		for _, e := range entries {
-			if e.Op == "INSERT" {
-				keyLen := uint16(len(e.Key))
-				valLen := uint16(len(e.Value))
-				buf[pos] = OpInsert
-				pos++
-				buf[pos] = byte(keyLen & 0xFF)
-				buf[pos+1] = byte(keyLen >> 8)
-				pos += 2
-				copy(buf[pos:pos+int(keyLen)], e.Key)
-				pos += int(keyLen)
-				buf[pos] = byte(valLen & 0xFF)
-				buf[pos+1] = byte(valLen >> 8)
-				pos += 2
-				copy(buf[pos:pos+int(valLen)], e.Value)
-				pos += int(valLen)
-				f.data = append(f.data, entry{key: e.Key, value: e.Value})
-				f.index.Store(e.Key, e.Value)
-			} else if e.Op == "DELETE" {
-				keyLen := uint16(len(e.Key))
-				buf[pos] = OpDelete
-				pos++
-				buf[pos] = byte(keyLen & 0xFF)
-				buf[pos+1] = byte(keyLen >> 8)
-				pos += 2
-				copy(buf[pos:pos+int(keyLen)], e.Key)
-				pos += int(keyLen)
-				f.data = append(f.data, entry{key: e.Key, deleted: true})
-				f.index.Delete(e.Key)
-			}
-		}
-		f.isSorted = false
-		f.seqBufIdx += totalLen
 	}
-	return nil
+	return f.write(recs, nil)
 }
 
 func (f *File) Get(key string) (interface{}, error) {
@@ -350,134 +458,250 @@ func (f *File) Get(key string) (interface{}, error) {
 	return nil, ports.ErrKeyNotFound
 }
 
-func (f *File) Delete(key string) error {
-	if f.config.ThreadSafe {
-		f.mu.Lock()
-		found := false
-		newData := f.data[:0]
-		for _, e := range f.data {
-			if e.key == key {
-				if !e.deleted {
-					found = true
-				}
-			} else {
-				newData = append(newData, e)
-			}
-		}
-		if !found {
-			f.mu.Unlock()
-			return ports.ErrKeyNotFound
-		}
-		f.data = append(newData, entry{key: key, deleted: true})
-		f.index.Delete(key)
-		f.isSorted = false
-		f.mu.Unlock()
-		f.walCh <- []WalEntry{{Op: "DELETE", Key: key, Value: ""}}
-	} else {
-		found := false
-		newData := f.data[:0]
-		for _, e := range f.data {
-			if e.key == key {
-				if !e.deleted {
-					found = true
-				}
-			} else {
-				newData = append(newData, e)
-			}
+// Scan calls fn for every key starting with prefix, in ascending key order, until fn returns false.
+// 인덱스의 시점 스냅샷을 정렬해 순회하므로 순회 중의 쓰기는 반영되지 않을 수 있음.
+func (f *File) Scan(prefix string, fn func(key string, value interface{}) bool) error {
+	return f.ScanContext(context.Background(), prefix, fn)
+}
+
+// ScanContext is Scan that stops with ctx.Err() once ctx is done, both while collecting the
+// matching keys from the index and while calling fn.
+func (f *File) ScanContext(ctx context.Context, prefix string, fn func(key string, value interface{}) bool) error {
+	type kv struct {
+		key   string
+		value interface{}
+	}
+	var matches []kv
+	f.index.Range(func(k, v interface{}) bool {
+		if key := k.(string); strings.HasPrefix(key, prefix) {
+			matches = append(matches, kv{key: key, value: v})
 		}
-		if !found {
-			return ports.ErrKeyNotFound
+		return ctx.Err() == nil
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].key < matches[j].key })
+	for _, m := range matches {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		f.data = append(newData, entry{key: key, deleted: true})
-		f.index.Delete(key)
-		f.isSorted = false
-		keyLen := uint16(len(key))
-		entryLen := 1 + 2 + int(keyLen)
-		if f.seqBufIdx+entryLen > f.flushSize {
-			f.flushSeqBuffer()
+		if !fn(m.key, m.value) {
+			break
 		}
-		buf := f.seqBuffer[f.seqBufIdx : f.seqBufIdx+entryLen]
-		buf[0] = OpDelete
-		buf[1] = byte(keyLen & 0xFF)
-		buf[2] = byte(keyLen >> 8)
-		copy(buf[3:], key)
-		f.seqBufIdx += entryLen
-		f.walCh <- []WalEntry{{Op: "DELETE", Key: key, Value: ""}}
 	}
 	return nil
 }
 
-func (f *File) flushBuffer() error {
-	f.walMu.Lock()
-	defer f.walMu.Unlock()
+// GetCost is Get for ports.ReadCostPort. Every key lives in the in-memory index, so a read
+// never touches the file and counts as one cache hit.
+func (f *File) GetCost(key string, cost *ports.ReadCost) (interface{}, error) {
+	if cost != nil {
+		cost.CacheHits++
+	}
+	return f.Get(key)
+}
 
-	if f.walBufIdx == 0 {
-		return nil
+// ScanCost is Scan for ports.ReadCostPort; the scan reads the in-memory index only.
+func (f *File) ScanCost(prefix string, fn func(key string, value interface{}) bool, cost *ports.ReadCost) error {
+	if cost != nil {
+		cost.CacheHits++
 	}
+	return f.Scan(prefix, fn)
+}
 
-	if _, err := f.walFile.Write(f.walBuffer[:f.walBufIdx]); err != nil {
-		return fmt.Errorf("failed to write to wal: %v", err)
+// DeletePrefix removes every key starting with prefix and returns how many were removed.
+// The tombstones are written to the WAL as one batch.
+func (f *File) DeletePrefix(prefix string) (int, error) {
+	return f.deleteMatching(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// DeleteRange removes every key in [start, end) ("" = no upper bound) and returns how many
+// were removed. The tombstones are written to the WAL as one batch.
+func (f *File) DeleteRange(start, end string) (int, error) {
+	return f.deleteMatching(func(key string) bool { return ports.InRange(key, start, end) })
+}
+
+// deleteMatching removes every key match accepts, writing the tombstones as one batch.
+func (f *File) deleteMatching(match func(key string) bool) (int, error) {
+	if f == nil {
+		return 0, fmt.Errorf("file adapter is nil")
 	}
-	if err := f.walFile.Sync(); err != nil {
-		return fmt.Errorf("failed to sync wal: %v", err)
+	var keys []string
+	f.index.Range(func(k, _ interface{}) bool {
+		if key := k.(string); match(key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	entries := make([]WalEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = WalEntry{Op: "DELETE", Key: key}
 	}
+	if err := f.InsertBatch(entries); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
 
-	f.walBufIdx = 0
-	return nil
+func (f *File) Delete(key string) error {
+	return f.write([]walRecord{{op: OpDelete, key: key}}, func() error {
+		if _, ok := f.index.Load(key); !ok {
+			return ports.ErrKeyNotFound
+		}
+		return nil
+	})
 }
 
-func (f *File) flushSeqBuffer() error {
-	if f.seqBufIdx == 0 {
+// 쓰기 순서: 쓰기는 f.mu 안에서 레코드를 WAL 버퍼에 추가(순번 walSeq 부여)한 뒤에야 메모리에
+// 반영합니다. 그래서 보이는 쓰기는 모두 WAL에서 그보다 먼저 보인 쓰기 뒤에 있고, compaction(같은
+// 잠금)과도 엇갈리지 않습니다. 버퍼는 flushMu 아래에서 통째로 떼어 내 기록하므로 파일 안의 순서도
+// 버퍼에 추가된 순서와 같습니다. Insert와 Delete는 인덱스에 반영한 뒤에 반환하므로, 반환된 쓰기는
+// Durability와 관계없이 바로 다음 Get과 Scan에 보입니다. Durability는 반환 전에 WAL이 어디까지
+// 기록되는지만 정합니다.
+//
+//   - async: 버퍼가 가득 차거나 워커의 주기(1초), Sync, Close 때 기록합니다.
+//   - fsync-per-commit: 잠금을 쥔 채 fsync까지 끝낸 뒤 반영하므로, 읽기는 fsync되지 않은 쓰기를
+//     보지 않습니다.
+//   - fsync-per-batch: 반영한 뒤 잠금을 놓고 자신의 순번이 fsync될 때까지 기다립니다. 그사이
+//     버퍼에 모인 다른 쓰기는 같은 fsync를 함께 씁니다(그룹 커밋).
+
+// write logs recs to the WAL and then applies them, after check (if any) passes.
+func (f *File) write(recs []walRecord, check func() error) error {
+	f.mu.Lock()
+	if check != nil {
+		if err := check(); err != nil {
+			f.mu.Unlock()
+			return err
+		}
+	}
+	if f.inMemory() {
+		f.apply(recs)
+		f.checkCompaction()
+		f.mu.Unlock()
 		return nil
 	}
-
-	f.walMu.Lock()
-	defer f.walMu.Unlock()
-	if _, err := f.walFile.Write(f.seqBuffer[:f.seqBufIdx]); err != nil {
-		return fmt.Errorf("failed to write to wal: %v", err)
+	seq, err := f.logWAL(recs)
+	if err != nil {
+		f.mu.Unlock()
+		return err
 	}
-	if err := f.walFile.Sync(); err != nil {
-		return fmt.Errorf("failed to sync wal: %v", err)
+	f.apply(recs)
+	f.checkCompaction()
+	f.mu.Unlock()
+	if f.config.Durability == ports.DurabilityFsyncPerBatch {
+		return f.waitDurable(seq)
 	}
-
-	f.seqBufIdx = 0
 	return nil
 }
 
-func (f *File) appendWAL(entries []WalEntry) {
-	for _, entry := range entries {
-		if entry.Op == "INSERT" {
-			keyLen := uint16(len(entry.Key))
-			valLen := uint16(len(entry.Value))
-			entryLen := 1 + 2 + int(keyLen) + 2 + int(valLen)
-			if f.walBufIdx+entryLen > f.flushSize {
-				f.flushBuffer()
+// apply makes recs visible. Caller holds f.mu.
+func (f *File) apply(recs []walRecord) {
+	for _, r := range recs {
+		if r.op == OpInsert {
+			f.data = append(f.data, entry{key: r.key, value: r.value})
+			if _, loaded := f.index.Swap(r.key, r.value); !loaded {
+				f.live++
 			}
-			buf := f.walBuffer[f.walBufIdx : f.walBufIdx+entryLen]
-			buf[0] = OpInsert
-			buf[1] = byte(keyLen & 0xFF)
-			buf[2] = byte(keyLen >> 8)
-			copy(buf[3:3+keyLen], entry.Key)
-			buf[3+keyLen] = byte(valLen & 0xFF)
-			buf[4+keyLen] = byte(valLen >> 8)
-			copy(buf[5+keyLen:], entry.Value)
-			f.walBufIdx += entryLen
-		} else if entry.Op == "DELETE" {
-			keyLen := uint16(len(entry.Key))
-			entryLen := 1 + 2 + int(keyLen)
-			if f.walBufIdx+entryLen > f.flushSize {
-				f.flushBuffer()
+		} else {
+			f.data = append(f.data, entry{key: r.key, deleted: true})
+			if _, loaded := f.index.LoadAndDelete(r.key); loaded {
+				f.live--
 			}
-			buf := f.walBuffer[f.walBufIdx : f.walBufIdx+entryLen]
-			buf[0] = OpDelete
-			buf[1] = byte(keyLen & 0xFF)
-			buf[2] = byte(keyLen >> 8)
-			copy(buf[3:], entry.Key)
-			f.walBufIdx += entryLen
 		}
 	}
+	f.isSorted = false
+}
+
+// logWAL adds recs to the WAL buffer as one write and returns its sequence number. The buffer
+// is written first if it is full or every commit is fsynced. Once a WAL write has failed,
+// recs are rejected before they reach the buffer. Caller holds f.mu.
+func (f *File) logWAL(recs []walRecord) (uint64, error) {
+	// 버퍼만 채우는 async 쓰기도 실패를 돌려받아야 기록되지 않을 변경이 메모리에 반영되지 않음
+	f.flushMu.Lock()
+	err := f.walErr
+	f.flushMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	f.walMu.Lock()
+	for _, r := range recs {
+		f.addWAL(&f.walBuffer, r.op, r.key, r.value)
+	}
+	f.walSeq++
+	seq := f.walSeq
+	full := f.walBuffer.size >= f.flushSize
+	f.walMu.Unlock()
+	if full || f.config.Durability == ports.DurabilityFsyncPerCommit {
+		if err := f.flushWAL(); err != nil {
+			return 0, err
+		}
+	}
+	return seq, nil
+}
+
+// flushWAL writes every buffered record and fsyncs the WAL file. Once a write fails, every
+// later flush returns the error, since the records are lost and later ones must not follow them.
+func (f *File) flushWAL() error {
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+	if f.walErr != nil {
+		return f.walErr
+	}
+	f.walMu.Lock()
+	upto := f.walSeq
+	if f.walBuffer.empty() {
+		f.walMu.Unlock()
+		f.durableSeq.Store(upto)
+		return nil
+	}
+	f.walBuffer, f.flushing = f.flushing, f.walBuffer
+	f.walMu.Unlock()
+
+	var data []byte
+	var err error
+	if f.keys != nil {
+		data, err = f.sealFrame(f.flushing.encode(), int64(len(magicEncrypted))+f.walBytes.Load())
+	} else {
+		data = f.flushing.encodeChecked(f.recordSeq)
+		f.recordSeq += uint64(len(f.flushing.records))
+	}
+	var n int
+	if err == nil {
+		n, err = f.walFile.Write(data)
+	}
+	f.walBytes.Add(int64(n))
+	if err == nil {
+		err = f.walFile.Sync()
+	}
+	f.flushing.reset(f.flushSize)
+	if err != nil {
+		f.walErr = fmt.Errorf("failed to write wal: %v", err)
+		return f.walErr
+	}
+	f.durableSeq.Store(upto)
+	return nil
+}
+
+// waitDurable returns once the write with sequence number seq is fsynced.
+func (f *File) waitDurable(seq uint64) error {
+	if f.durableSeq.Load() >= seq {
+		return nil // 다른 쓰기의 fsync에 함께 기록됨
+	}
+	return f.flushWAL()
 }
 
+// addWAL queues a record in buf, counting records coalesced with a pending one.
+func (f *File) addWAL(buf *pendingWAL, op byte, key, value string) {
+	if buf.add(op, key, value) {
+		f.walCoalesced.Add(1)
+	}
+}
+
+// walWorker writes the buffered WAL records every second.
 func (f *File) walWorker() {
 	defer f.wg.Done()
 	ticker := time.NewTicker(1 * time.Second)
@@ -485,43 +709,217 @@ func (f *File) walWorker() {
 
 	for {
 		select {
-		case entries, ok := <-f.walCh:
-			if !ok {
-				f.flushBuffer()
-				return
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+			if err := f.flushWAL(); err != nil {
+				f.config.Logger.Error("WAL flush failed", "error", err)
 			}
-			f.appendWAL(entries)
+		}
+	}
+}
+
+// Sync flushes all buffered WAL entries to disk and fsyncs the WAL file.
+// It returns only after every write acknowledged before the call is durable.
+func (f *File) Sync() error {
+	if f == nil {
+		return fmt.Errorf("file adapter is nil")
+	}
+	if f.config.MemoryMode {
+		return f.Persist()
+	}
+	return f.flushWAL()
+}
+
+// SyncContext is Sync that returns ctx.Err() once ctx is done. A flush already writing the
+// WAL cannot be interrupted; it completes in the background and later writes wait for it.
+func (f *File) SyncContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- f.Sync() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Snapshot writes a consistent copy of the main file and WAL to path and path+".wal".
+// Writers and compaction are paused only while the files are copied.
+func (f *File) Snapshot(path string) error {
+	if f.config.MemoryMode {
+		if err := f.Persist(); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.flushWAL(); err != nil {
+		return err
+	}
+	if err := utils.CopyFileAtomic(f.config.FilePath, path); err != nil {
+		return fmt.Errorf("failed to snapshot main file: %v", err)
+	}
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+	if err := utils.CopyFileAtomic(f.config.FilePath+".wal", path+".wal"); err != nil {
+		return fmt.Errorf("failed to snapshot wal file: %v", err)
+	}
+	return nil
+}
+
+// inMemory marks the adapter dirty and reports whether the WAL should be skipped.
+func (f *File) inMemory() bool {
+	if !f.config.MemoryMode {
+		return false
+	}
+	f.dirty.Store(true)
+	return true
+}
+
+// Persist writes all data to the main file and resets the WAL. In MemoryMode this is the
+// only way (besides PersistInterval and Close) writes become durable.
+func (f *File) Persist() error {
+	if f == nil {
+		return fmt.Errorf("file adapter is nil")
+	}
+	if !f.dirty.Load() && f.config.MemoryMode {
+		return nil
+	}
+	return f.compact()
+}
+
+// persistWorker persists changes every PersistInterval in MemoryMode.
+func (f *File) persistWorker() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.config.PersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopCh:
+			return
 		case <-ticker.C:
-			f.flushBuffer()
+			if err := f.Persist(); err != nil {
+				f.config.Logger.Error("Persist failed", "error", err)
+			}
+		}
+	}
+}
+
+// SetCompactionInterval changes the periodic compaction interval of a running adapter.
+// The new interval takes effect immediately; 0 or less restores the default.
+func (f *File) SetCompactionInterval(d time.Duration) {
+	if d <= 0 {
+		d = defaultCompactionInterval
+	}
+	// 아직 처리되지 않은 이전 요청은 최신 값으로 대체
+	for {
+		select {
+		case f.intervalCh <- d:
+			return
+		default:
+			select {
+			case <-f.intervalCh:
+			default:
+			}
 		}
 	}
 }
 
 func (f *File) compactWorker() {
 	defer f.wg.Done()
-	ticker := time.NewTicker(968 * time.Second)
+	ticker := time.NewTicker(f.config.CompactionInterval)
 	defer ticker.Stop()
+	// 종료 시 진행 중인 컴팩션을 취소해 Close가 오래 기다리지 않도록 함 (Close가 마지막에 다시 컴팩션함)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-f.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
 	for {
 		select {
 		case <-f.stopCh:
 			return
+		case d := <-f.intervalCh:
+			ticker.Reset(d)
 		case <-f.compactCh:
-			f.compact()
+			f.compactContext(ctx)
 		case <-ticker.C:
-			f.compact()
+			f.compactContext(ctx)
 		}
 	}
 }
 
+// CompactContext rewrites the main file with the live entries and resets the WAL. If ctx ends
+// before the new file is written, the old file and WAL are kept and ctx.Err() is returned.
+func (f *File) CompactContext(ctx context.Context) error {
+	if f == nil {
+		return fmt.Errorf("file adapter is nil")
+	}
+	return f.compactContext(ctx)
+}
+
 func (f *File) compact() error {
+	return f.compactContext(context.Background())
+}
+
+func (f *File) compactContext(ctx context.Context) (err error) {
+	f.compactMu.Lock()
+	defer f.compactMu.Unlock()
+	kept := false
+	defer func() {
+		f.compactRequested.Store(false)
+		if kept {
+			// WAL을 남겼으므로 기준을 여전히 넘으면 쓰기를 막는 compaction을 바로 요청
+			f.mu.Lock()
+			f.checkCompaction()
+			f.mu.Unlock()
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 스냅숏을 뜬 뒤에는 잠금을 놓고 파일을 씀. 앞선 compaction이 WAL을 비우지 못했으면 이번에는
+	// 쓰기를 막은 채 진행해 WAL이 계속 자라지 않게 함 (compaction.go 참고).
 	f.mu.Lock()
-	defer f.mu.Unlock()
+	locked := f.walKept
+	n, keys := len(f.data), f.keys
+	snapshot := f.data[:n:n]
+	if !locked {
+		f.mu.Unlock()
+	}
+	unlock := func() {
+		if locked {
+			f.mu.Unlock()
+		}
+	}
+
+	f.config.Events.Publish(events.Event{
+		Type:    events.CompactionStarted,
+		Source:  "file",
+		Details: map[string]interface{}{"entries": n},
+	})
+	defer func() {
+		if err == nil {
+			f.compactedAt.Store(time.Now().UnixNano())
+		}
+		f.config.Events.Publish(events.Event{Type: events.CompactionFinished, Source: "file", Err: err})
+	}()
 
 	// Build compacted slice: 마지막 유효 엔트리만 유지
-	compacted := make([]entry, 0, len(f.data))
+	compacted := make([]entry, 0, n)
 	seen := make(map[string]int)
-	for i, e := range f.data {
+	for i, e := range snapshot {
 		if !e.deleted {
 			seen[e.key] = i
 		} else {
@@ -529,85 +927,146 @@ func (f *File) compact() error {
 		}
 	}
 	for _, idx := range seen {
-		compacted = append(compacted, f.data[idx])
+		compacted = append(compacted, snapshot[idx])
 	}
 	sort.Slice(compacted, func(i, j int) bool { return compacted[i].key < compacted[j].key })
 
-	totalSize := 4 + 4 // magicNumber (4) + numEntries (4)
+	totalSize := 4 + 4 + mainFooterSize // magicNumber (4) + numEntries (4) + crc (4)
 	for _, e := range compacted {
-		totalSize += 2 + 2 + len(e.key) + len(e.value)
+		totalSize += 2*binary.MaxVarintLen64 + len(e.key) + len(e.value)
 	}
 
-	buf := make([]byte, totalSize)
+	buf := make([]byte, 8, totalSize)
 	copy(buf[0:4], magicNumber)
-	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(compacted)))
-	pos := 8
+	byteOrder.PutUint32(buf[4:8], uint32(len(compacted)))
 	for _, e := range compacted {
-		keyLen := uint16(len(e.key))
-		valLen := uint16(len(e.value))
-		// Write keyLen and valLen
-		buf[pos] = byte(keyLen & 0xFF)
-		buf[pos+1] = byte(keyLen >> 8)
-		buf[pos+2] = byte(valLen & 0xFF)
-		buf[pos+3] = byte(valLen >> 8)
-		pos += 4
-		copy(buf[pos:pos+int(keyLen)], e.key)
-		pos += int(keyLen)
-		copy(buf[pos:pos+int(valLen)], e.value)
-		pos += int(valLen)
-	}
-
-	log.Printf("Compaction: buffer size=%d, entries=%d", len(buf), len(compacted))
-	if err := os.WriteFile(f.config.FilePath, buf, 0666); err != nil {
-		log.Printf("Compaction failed: failed to write file: %v", err)
+		buf = appendEntry(buf, e.key, e.value)
+	}
+
+	// 새 파일을 쓰기 전이 마지막 취소 지점: 이후에는 파일과 WAL이 함께 교체되어야 함
+	if err := ctx.Err(); err != nil {
+		unlock()
+		return err
+	}
+	f.config.Logger.Info("Compacting", "bytes", len(buf), "entries", len(compacted))
+	if keys == nil {
+		buf = appendMainFooter(buf)
+	}
+	if buf, err = sealMain(keys, buf); err != nil {
+		unlock()
+		return err
+	}
+	// 임시 파일에 쓴 뒤 rename하므로 기록 도중 장애가 나도 이전 파일이 남음. WAL은 아직 그대로이므로
+	// 여기서 장애가 나면 WAL 전체를 새 파일 위에 재생함.
+	if err := utils.WriteFileAtomic(f.config.FilePath, buf); err != nil {
+		unlock()
+		f.config.Logger.Error("Compaction failed: failed to write file", "error", err)
 		return fmt.Errorf("failed to write file: %v", err)
 	}
-	if err := f.file.Sync(); err != nil {
-		log.Printf("Compaction failed: failed to sync file: %v", err)
-		return fmt.Errorf("failed to sync file: %v", err)
+	if !locked {
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+	if nf, err := os.OpenFile(f.config.FilePath, os.O_RDWR, 0666); err == nil {
+		f.file.Close()
+		f.file = nf
+	}
+	if keys != nil {
+		f.mainKey = keys.Current().ID()
 	}
 
+	// 인덱스는 쓰기마다 갱신되어 이미 live 키를 가지므로 교체하지 않음
+	// (교체하면 잠금 없이 인덱스를 읽는 Get과 경합함).
+	tail := f.data[n:]
+	f.data = append(compacted, tail...)
+	f.isSorted = len(tail) == 0
+	if len(tail) == 0 {
+		f.dirty.Store(false) // MemoryMode: 스냅숏 뒤의 쓰기가 없으면 파일이 메모리와 같음
+	}
+	if len(tail) > 0 && !f.config.MemoryMode {
+		// 스냅숏 뒤의 쓰기는 WAL에만 있으므로 WAL을 남김. 새 파일에 이미 든 레코드를 다시 재생해도
+		// 결과는 같음.
+		f.walKept, kept = true, true
+		return nil
+	}
+
+	// 버퍼의 레코드는 모두 f.data에 반영되어 방금 쓴 파일에 들어 있으므로 버림
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
 	f.walMu.Lock()
 	defer f.walMu.Unlock()
+	f.walBuffer.reset(f.flushSize)
+	f.durableSeq.Store(f.walSeq)
 	if err := f.walFile.Truncate(0); err != nil {
-		log.Printf("Compaction failed: failed to truncate wal: %v", err)
+		f.config.Logger.Error("Compaction failed: failed to truncate wal", "error", err)
 		return fmt.Errorf("failed to truncate wal: %v", err)
 	}
 	if _, err := f.walFile.Seek(0, 0); err != nil {
-		log.Printf("Compaction failed: failed to reset wal: %v", err)
+		f.config.Logger.Error("Compaction failed: failed to reset wal", "error", err)
 		return fmt.Errorf("failed to reset wal: %v", err)
 	}
-	if _, err := f.walFile.Write(magicNumber); err != nil {
-		log.Printf("Compaction failed: failed to write magic number: %v", err)
+	if _, err := f.walFile.Write(f.walMagic()); err != nil {
+		f.config.Logger.Error("Compaction failed: failed to write magic number", "error", err)
 		return fmt.Errorf("failed to write magic number: %v", err)
 	}
 	if err := f.walFile.Sync(); err != nil {
-		log.Printf("Compaction failed: failed to sync wal: %v", err)
+		f.config.Logger.Error("Compaction failed: failed to sync wal", "error", err)
 		return fmt.Errorf("failed to sync wal: %v", err)
 	}
 
-	f.data = compacted
-	newIndex := &sync.Map{}
-	for _, e := range compacted {
-		newIndex.Store(e.key, e.value)
-	}
-	f.index = newIndex
-	f.isSorted = true
+	f.legacyWAL = false
+	f.walKept = false
+	f.walErr = nil
+	f.walBytes.Store(0)
 	return nil
 }
 
+// Stats returns entry counts and the number of WAL batches waiting for the worker.
+func (f *File) Stats() map[string]interface{} {
+	f.mu.RLock()
+	entries, sorted, live := len(f.data), f.isSorted, f.live
+	f.mu.RUnlock()
+	f.walMu.Lock()
+	defer f.walMu.Unlock()
+	return map[string]interface{}{
+		"keys":                live,
+		"entries":             entries,
+		"sorted":              sorted,
+		"wal_pending":         f.walSeq - f.durableSeq.Load(), // fsync되지 않은 쓰기 수
+		"memory_mode":         f.config.MemoryMode,
+		"dirty":               f.dirty.Load(),
+		"wal_coalesced":       f.walCoalesced.Load(),
+		"dead_entries":        entries - live,
+		"compaction_triggers": f.compactTriggers.Load(),
+		"recovery_debt":       f.walBytes.Load(),
+		"recovery": map[string]interface{}{
+			"wal_bytes":            f.recovery.WALBytes,
+			"records":              f.recovery.Records,
+			"corrupted":            f.recovery.Corrupted,
+			"duration_ms":          f.recovery.Duration.Milliseconds(),
+			"compaction_scheduled": f.recovery.Compacted,
+		},
+	}
+}
+
+// Close stops the WAL and compaction workers, compacts the data file and closes it.
+// Subsequent calls return the result of the first one.
 func (f *File) Close() error {
 	if f == nil {
 		return fmt.Errorf("file adapter is nil")
 	}
-	close(f.walCh)
+	f.closeOnce.Do(func() {
+		f.closeErr = f.close()
+	})
+	return f.closeErr
+}
+
+func (f *File) close() error {
 	close(f.stopCh)
 	f.wg.Wait()
 
-	if f.config.ThreadSafe {
-		f.flushBuffer()
-	} else {
-		f.flushSeqBuffer()
+	if err := f.flushWAL(); err != nil {
+		f.config.Logger.Warn("WAL flush before close failed", "error", err) // compaction below still saves the data
 	}
 	if err := f.compact(); err != nil {
 		return err
@@ -0,0 +1,328 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// 파일 포맷 버전
+//
+//	GLB1: 키와 값의 길이를 uint16으로 기록 (최대 64KB). 읽기만 지원합니다.
+//	GLB2: 길이를 uvarint로 기록해 수 MB 이상의 값도 저장할 수 있습니다. 암호화된 메인 파일의 평문에 사용됩니다.
+//	GLB3: WAL 전용. GLB2 레코드 앞에 CRC32와 본문 길이, 순번을 붙입니다. 새로 쓰는 WAL에 사용됩니다.
+//	GLB4: 메인 파일 전용. GLB2 메인 파일 끝에 체크섬을 붙입니다. 새로 쓰는 평문 메인 파일에 사용됩니다.
+//
+// 메인 파일: magic(4) + 엔트리 수(uint32) + 엔트리마다 keyLen, valLen, key, value (+ GLB4는 crc(uint32))
+// WAL GLB2: magic(4) + 레코드마다 op(1), keyLen, key, [valLen, value] (INSERT만 값 포함)
+// WAL GLB3: magic(4) + 레코드마다 crc(uint32), bodyLen, 본문(seq, op(1), keyLen, key, [valLen, value])
+//
+// GLB3의 crc는 bodyLen과 본문의 CRC32(IEEE)이고, seq는 레코드마다 1씩 늘어나는 순번입니다. 재생은
+// crc가 맞지 않거나 순번이 앞 레코드보다 크지 않은 레코드를 손상으로 보고, FileConfig.RecoveryMode가
+// strict(기본값)면 그 앞에서 멈추고 best_effort면 건너뜁니다. 멈춘 경우 WAL을 그 자리에서 잘라 새
+// 레코드가 유효한 레코드 바로 뒤에 이어지게 합니다. 끝의 잘린 레코드도 잘라 냅니다.
+//
+// GLB4의 crc는 magic부터 마지막 엔트리까지의 CRC32(IEEE)입니다. 맞지 않거나 마지막 엔트리 뒤에 다른
+// 바이트가 있으면 잘리거나 손상된 파일로 보고 열지 않습니다. 암호화된 메인 파일은 봉인이 이미 전체를
+// 인증하므로 체크섬을 붙이지 않습니다.
+//
+// GLB1이나 GLB2 WAL을 열면 곧바로 compaction해 메인 파일을 GLB2, WAL을 GLB3으로 바꾼 뒤 새 레코드를
+// 추가합니다.
+// 고정 길이 정수(엔트리 수, GLB1 길이)는 두 버전 모두 리틀 엔디안이며, magic이 포맷과 함께 바이트
+// 순서를 나타내므로 파일은 아키텍처와 무관하게 옮길 수 있습니다.
+
+// byteOrder is the byte order of the fixed-size integers of both format versions.
+var byteOrder = binary.LittleEndian
+
+var (
+	magicV1 = []byte("GLB1")
+	magicV2 = []byte("GLB2")
+	magicV3 = []byte("GLB3")
+	magicV4 = []byte("GLB4")
+)
+
+// magicNumber is written to every new plaintext main file.
+var magicNumber = magicV4
+
+// walMagicNumber is written to every new plaintext WAL.
+var walMagicNumber = magicV3
+
+// formatVersion returns 1 to 4 for a known magic, 0 otherwise.
+func formatVersion(magic []byte) int {
+	switch string(magic) {
+	case string(magicV1):
+		return 1
+	case string(magicV2):
+		return 2
+	case string(magicV3):
+		return 3
+	case string(magicV4):
+		return 4
+	}
+	return 0
+}
+
+// mainFooterSize is the size of the checksum ending a GLB4 main file.
+const mainFooterSize = 4
+
+// appendMainFooter appends the checksum of a GLB4 main file to buf, which holds the rest of it.
+func appendMainFooter(buf []byte) []byte {
+	return byteOrder.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+// appendRecord appends a GLB2 WAL record to buf.
+func appendRecord(buf []byte, op byte, key, value string) []byte {
+	buf = append(buf, op)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	if op == OpInsert {
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		buf = append(buf, value...)
+	}
+	return buf
+}
+
+// appendCheckedRecord appends a GLB3 WAL record numbered seq to buf.
+func appendCheckedRecord(buf []byte, seq uint64, op byte, key, value string) []byte {
+	n := uvarintSize(seq) + 1 + uvarintSize(uint64(len(key))) + len(key)
+	if op == OpInsert {
+		n += uvarintSize(uint64(len(value))) + len(value)
+	}
+	start := len(buf)
+	buf = append(buf, 0, 0, 0, 0) // crc 자리
+	buf = binary.AppendUvarint(buf, uint64(n))
+	buf = binary.AppendUvarint(buf, seq)
+	buf = appendRecord(buf, op, key, value)
+	byteOrder.PutUint32(buf[start:], crc32.ChecksumIEEE(buf[start+4:]))
+	return buf
+}
+
+func uvarintSize(x uint64) int {
+	var b [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(b[:], x)
+}
+
+// appendEntry appends a GLB2 main file entry to buf.
+func appendEntry(buf []byte, key, value string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	buf = append(buf, key...)
+	return append(buf, value...)
+}
+
+// lengthReader reads the length fields of one format version. limit bounds every length so
+// that a corrupt length can't trigger a huge allocation.
+type lengthReader struct {
+	version int
+	limit   int64
+}
+
+func (lr lengthReader) readLen(r *bufio.Reader) (int, error) {
+	var n uint64
+	if lr.version == 1 {
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		n = uint64(byteOrder.Uint16(b[:]))
+	} else {
+		var err error
+		if n, err = binary.ReadUvarint(r); err != nil {
+			return 0, err
+		}
+	}
+	if n > uint64(lr.limit) {
+		return 0, fmt.Errorf("length %d exceeds remaining file size %d", n, lr.limit)
+	}
+	return int(n), nil
+}
+
+func (lr lengthReader) readBytes(r *bufio.Reader) (string, error) {
+	n, err := lr.readLen(r)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decodeMainEntries parses a main file of any version. On a corrupt entry it returns the
+// entries before it along with the error; on a GLB4 checksum mismatch, every entry it could
+// decode.
+func decodeMainEntries(data []byte) ([]entry, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("invalid main file format")
+	}
+	version := formatVersion(data[:4])
+	if version == 0 || version == 3 {
+		return nil, fmt.Errorf("invalid main file format: magic %q", data[:4])
+	}
+	var sumErr error
+	if version == 4 {
+		if len(data) < 8+mainFooterSize {
+			return nil, fmt.Errorf("corrupted main file: missing checksum")
+		}
+		body := data[:len(data)-mainFooterSize]
+		if crc32.ChecksumIEEE(body) != byteOrder.Uint32(data[len(body):]) {
+			sumErr = fmt.Errorf("corrupted main file: checksum mismatch")
+		}
+		data = body
+	}
+	numEntries := byteOrder.Uint32(data[4:8])
+	lr := lengthReader{version: version, limit: int64(len(data))}
+	r := bufio.NewReader(bytes.NewReader(data[8:]))
+	entries := make([]entry, 0, min(int(numEntries), len(data)/2))
+	for i := uint32(0); i < numEntries; i++ {
+		var keyLen, valLen int
+		var err error
+		if keyLen, err = lr.readLen(r); err == nil {
+			valLen, err = lr.readLen(r)
+		}
+		if err != nil {
+			return entries, fmt.Errorf("corrupted main file: entry %d: %v", i, err)
+		}
+		buf := make([]byte, keyLen+valLen)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return entries, fmt.Errorf("corrupted main file: data overflow at entry %d", i)
+		}
+		entries = append(entries, entry{key: string(buf[:keyLen]), value: string(buf[keyLen:])})
+	}
+	if sumErr != nil {
+		return entries, sumErr
+	}
+	if _, err := r.ReadByte(); version == 4 && err == nil {
+		return entries, fmt.Errorf("corrupted main file: trailing bytes after entry %d", numEntries)
+	}
+	return entries, nil
+}
+
+// walRecord is one decoded WAL record.
+type walRecord struct {
+	op    byte
+	key   string
+	value string
+}
+
+// readWAL decodes the GLB1 or GLB2 records following the magic number. A record cut short at the end of
+// the log (a torn final write) ends the replay without error.
+func readWAL(r *bufio.Reader, version int, size int64, fn func(walRecord)) error {
+	lr := lengthReader{version: version, limit: size}
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil // EOF 정상 종료
+		}
+		if op != OpInsert && op != OpDelete {
+			return fmt.Errorf("unknown operation code: %d", op)
+		}
+		rec := walRecord{op: op}
+		if rec.key, err = lr.readBytes(r); err != nil {
+			return tornRecord(err, "key")
+		}
+		if op == OpInsert {
+			if rec.value, err = lr.readBytes(r); err != nil {
+				return tornRecord(err, "value")
+			}
+		}
+		fn(rec)
+	}
+}
+
+// walReplay describes how the records of a GLB3 WAL were read.
+type walReplay struct {
+	end     int64   // Offset after the last record replayed or skipped, from the first record
+	records int     // Records replayed
+	corrupt []error // Records failing their checksum or sequence, with their offsets
+	lastSeq uint64  // Sequence number of the last record replayed
+}
+
+// readCheckedWAL decodes the GLB3 records following the magic number, size bytes in all. A
+// record cut short at the end of the log ends the replay. A corrupted record ends it as well,
+// unless skip is set; then the replay continues after it.
+func readCheckedWAL(r *bufio.Reader, size int64, skip bool, fn func(walRecord)) walReplay {
+	var rp walReplay
+	for {
+		var sum [4]byte
+		if _, err := io.ReadFull(r, sum[:]); err != nil {
+			return rp
+		}
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
+				rp.corrupt = append(rp.corrupt, fmt.Errorf("record at offset %d: invalid length: %v", rp.end, err))
+			}
+			return rp
+		}
+		head := int64(4 + uvarintSize(n))
+		if size-rp.end-head < 0 || n > uint64(size-rp.end-head) {
+			return rp // 잘린 레코드
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return rp
+		}
+		seq, rec, err := decodeCheckedRecord(byteOrder.Uint32(sum[:]), body)
+		if err == nil && seq <= rp.lastSeq {
+			err = fmt.Errorf("sequence %d after %d", seq, rp.lastSeq)
+		}
+		if err != nil {
+			rp.corrupt = append(rp.corrupt, fmt.Errorf("record at offset %d: %v", rp.end, err))
+			if !skip {
+				return rp
+			}
+		} else {
+			fn(rec)
+			rp.records++
+			rp.lastSeq = seq
+		}
+		rp.end += head + int64(n)
+	}
+}
+
+// decodeCheckedRecord checks body against the crc of its record and decodes it.
+func decodeCheckedRecord(sum uint32, body []byte) (uint64, walRecord, error) {
+	var rec walRecord
+	length := binary.AppendUvarint(nil, uint64(len(body)))
+	if crc32.Update(crc32.ChecksumIEEE(length), crc32.IEEETable, body) != sum {
+		return 0, rec, fmt.Errorf("checksum mismatch")
+	}
+	seq, k := binary.Uvarint(body)
+	if k <= 0 || k >= len(body) {
+		return 0, rec, fmt.Errorf("invalid sequence number")
+	}
+	rec.op, body = body[k], body[k+1:]
+	if rec.op != OpInsert && rec.op != OpDelete {
+		return 0, rec, fmt.Errorf("unknown operation code: %d", rec.op)
+	}
+	var ok bool
+	rec.key, body, ok = cutField(body)
+	if ok && rec.op == OpInsert {
+		rec.value, body, ok = cutField(body)
+	}
+	if !ok || len(body) != 0 {
+		return 0, rec, fmt.Errorf("malformed record")
+	}
+	return seq, rec, nil
+}
+
+// cutField splits a uvarint-prefixed field off b.
+func cutField(b []byte) (string, []byte, bool) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b)-k) {
+		return "", nil, false
+	}
+	return string(b[k : k+int(n)]), b[k+int(n):], true
+}
+
+func tornRecord(err error, what string) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	return fmt.Errorf("failed to read %s: %v", what, err)
+}
@@ -0,0 +1,63 @@
+package file
+
+// pendingWAL holds WAL records that have not been written yet. A later record for a key
+// replaces the earlier one in place, so a flush writes only the final outcome of each key.
+// 한 번의 flush는 하나의 write로 기록되므로 같은 버퍼 안에서의 순서는 재생 결과에 영향을 주지 않습니다.
+type pendingWAL struct {
+	records []walRecord
+	index   map[string]int // key -> records 위치
+	size    int            // 인코딩된 크기의 근사값
+	buf     []byte         // 인코딩 버퍼 (flush 사이에 재사용)
+}
+
+func newPendingWAL(capacity int) pendingWAL {
+	return pendingWAL{index: make(map[string]int), buf: make([]byte, 0, capacity)}
+}
+
+// add queues a record and reports whether it replaced a pending record for the same key.
+func (p *pendingWAL) add(op byte, key, value string) bool {
+	rec := walRecord{op: op, key: key, value: value}
+	if i, ok := p.index[key]; ok {
+		p.size += len(value) - len(p.records[i].value)
+		p.records[i] = rec
+		return true
+	}
+	p.index[key] = len(p.records)
+	p.records = append(p.records, rec)
+	p.size += 1 + 2*4 + len(key) + len(value)
+	return false
+}
+
+func (p *pendingWAL) empty() bool {
+	return len(p.records) == 0
+}
+
+// encode returns the pending records in the GLB2 WAL format, as sealed in encrypted frames.
+func (p *pendingWAL) encode() []byte {
+	p.buf = p.buf[:0]
+	for _, rec := range p.records {
+		p.buf = appendRecord(p.buf, rec.op, rec.key, rec.value)
+	}
+	return p.buf
+}
+
+// encodeChecked returns the pending records in the GLB3 WAL format, numbered after seq.
+func (p *pendingWAL) encodeChecked(seq uint64) []byte {
+	p.buf = p.buf[:0]
+	for _, rec := range p.records {
+		seq++
+		p.buf = appendCheckedRecord(p.buf, seq, rec.op, rec.key, rec.value)
+	}
+	return p.buf
+}
+
+// reset empties the buffer after a write, releasing capacity grown by a very large record.
+func (p *pendingWAL) reset(flushSize int) {
+	clear(p.index)
+	clear(p.records) // 값 문자열 참조 해제
+	p.records = p.records[:0]
+	p.size = 0
+	if cap(p.buf) > 2*flushSize {
+		p.buf = make([]byte, 0, flushSize)
+	}
+}
@@ -0,0 +1,119 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 복구 부채(recovery debt): 마지막 compaction 이후 WAL에 쌓인 바이트 수로, 지금 프로세스가 죽으면
+// 다음 시작 때 재생해야 하는 양입니다. 시작 시 재생한 WAL이 RecoveryMaxWAL이나 RecoveryMaxReplay를
+// 넘으면 복구 직후 compaction을 예약해, 장애가 반복될 때마다 재생할 WAL이 더 커지지 않게 합니다.
+
+const (
+	defaultRecoveryMaxWAL    = 64 << 20 // 64MB
+	defaultRecoveryMaxReplay = 5 * time.Second
+)
+
+// RecoveryInfo describes the WAL replay done when the adapter was opened.
+type RecoveryInfo struct {
+	WALBytes  int64         // Bytes of WAL records replayed
+	Records   int           // Number of WAL records replayed
+	Duration  time.Duration // Time spent replaying
+	Corrupted int           // Corrupted records (frames if encrypted) replay stopped at or skipped
+	Compacted bool          // The replay exceeded a threshold and a compaction was scheduled
+}
+
+// Recovery returns the WAL replay done at open.
+func (f *File) Recovery() RecoveryInfo {
+	return f.recovery
+}
+
+// RecoveryReport returns the WAL replay done at open in the form of ports.RecoveryReport.
+func (f *File) RecoveryReport() ports.RecoveryReport {
+	return ports.RecoveryReport{
+		WALBytes:   f.recovery.WALBytes,
+		WALRecords: int64(f.recovery.Records),
+		Corrupted:  int64(f.recovery.Corrupted),
+		Duration:   f.recovery.Duration,
+	}
+}
+
+// RecoveryDebt returns the bytes of WAL that would be replayed if the process restarted now.
+// Buffered records that have not been written yet are not counted.
+func (f *File) RecoveryDebt() int64 {
+	return f.walBytes.Load()
+}
+
+// DiskSize returns the size of the main file plus the WAL records written since the last
+// compaction.
+func (f *File) DiskSize() int64 {
+	size := f.walBytes.Load()
+	if fi, err := os.Stat(f.config.FilePath); err == nil {
+		size += fi.Size()
+	}
+	return size
+}
+
+// StorageStatus reports the time of the last compaction. The adapter keeps every entry in
+// memory, so it has no cache.
+func (f *File) StorageStatus() ports.StorageStatus {
+	var status ports.StorageStatus
+	if t := f.compactedAt.Load(); t != 0 {
+		status.LastCompaction = time.Unix(0, t)
+	}
+	return status
+}
+
+// CheckHealth reports a failed WAL write, which fails every write until the next compaction,
+// and a WAL file that can't be read back or does not start with the format's magic number.
+func (f *File) CheckHealth() error {
+	select {
+	case <-f.stopCh:
+		return errors.New("storage is closed")
+	default:
+	}
+	// compaction은 flushMu를 잡고 WAL을 비운 뒤 magic을 다시 쓰므로 그동안 읽지 않음
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+	if f.walErr != nil {
+		return f.walErr
+	}
+	want := f.walMagic()
+	magic := make([]byte, len(want))
+	if _, err := f.walFile.ReadAt(magic, 0); err != nil {
+		return fmt.Errorf("failed to read wal header: %v", err)
+	}
+	if !bytes.Equal(magic, want) {
+		return fmt.Errorf("wal starts with %q instead of %q", magic, want)
+	}
+	return nil
+}
+
+// needsRecoveryCompaction reports whether the replay at open exceeded a threshold.
+func (f *File) needsRecoveryCompaction() bool {
+	maxWAL, maxReplay := f.config.RecoveryMaxWAL, f.config.RecoveryMaxReplay
+	if maxWAL <= 0 {
+		maxWAL = defaultRecoveryMaxWAL
+	}
+	if maxReplay <= 0 {
+		maxReplay = defaultRecoveryMaxReplay
+	}
+	return f.recovery.WALBytes > maxWAL || f.recovery.Duration > maxReplay
+}
+
+// scheduleRecoveryCompaction asks the compaction worker to run now if the replay at open was
+// too large. Must be called after the worker has started.
+func (f *File) scheduleRecoveryCompaction() {
+	if !f.needsRecoveryCompaction() {
+		return
+	}
+	f.recovery.Compacted = true
+	f.config.Logger.Info("Recovery replay exceeded limits; scheduling compaction",
+		"wal_bytes", f.recovery.WALBytes, "records", f.recovery.Records, "duration", f.recovery.Duration)
+	f.requestCompaction()
+}
@@ -2,9 +2,10 @@
 package lockfree
 
 import (
-	"fmt"
 	"sync/atomic"
 	"time"
+
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
 // SSTable represents a simplified SSTable structure for demonstration.
@@ -29,6 +30,7 @@ type LockFreeCompactor struct {
 	taskQueue *LFQueue[*SSTable] // lock‑free 큐: 병합할 SSTable 작업을 저장
 	stopCh    chan struct{}      // 컴팩터 종료 신호
 	running   atomic.Bool        // 실행 여부
+	logger    utils.Logger
 }
 
 // NewLockFreeCompactor creates and returns a new lock‑free compactor.
@@ -36,6 +38,7 @@ func NewLockFreeCompactor() *LockFreeCompactor {
 	return &LockFreeCompactor{
 		taskQueue: NewLFQueue[*SSTable](),
 		stopCh:    make(chan struct{}),
+		logger:    utils.With(utils.DefaultLogger(), "adapter", "lockfree"),
 	}
 }
 
@@ -87,9 +90,9 @@ func (c *LockFreeCompactor) compact() {
 	// 예시로, 정렬된 순서가 유지되도록 간단하게 처리합니다.
 	// 재삽입: 새 병합 결과를 다시 큐에 넣어 후속 컴팩션 작업으로 연결합니다.
 	c.taskQueue.Enqueue(newSST)
-	// 구조화된 로깅: 실제 환경에서는 klog 등의 라이브러리를 사용합니다.
-	fmt.Printf("Merged SSTables: [%s, %s] + [%s, %s] -> [%s, %s]\n",
-		sst1.MinKey, sst1.MaxKey, sst2.MinKey, sst2.MaxKey, newSST.MinKey, newSST.MaxKey)
+	c.logger.Info("Merged SSTables",
+		"left", [2]string{sst1.MinKey, sst1.MaxKey}, "right", [2]string{sst2.MinKey, sst2.MaxKey},
+		"merged", [2]string{newSST.MinKey, newSST.MaxKey})
 }
 
 // Stop signals the compactor to stop and waits for termination.
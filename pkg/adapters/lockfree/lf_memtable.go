@@ -4,10 +4,9 @@ package lockfree
 
 import (
 	"errors"
+	"log/slog"
 	"math/rand"
 	"sync/atomic"
-
-	"k8s.io/klog/v2" // Kubernetes 스타일의 구조화된 로깅 (선택 사항)
 )
 
 // Constants for the skip list.
@@ -118,7 +117,7 @@ func (m *lfMemtable) Insert(key, value string) error {
 		// 0레벨부터 새 노드 삽입 시도.
 		if !preds[0].next[0].CompareAndSwap(succs[0], newNode) {
 			// 실패하면 재시도.
-			klog.V(4).Infof("Insert CAS failed at level 0 for key: %s", key)
+			slog.Debug("Insert CAS failed at level 0", "key", key)
 			continue
 		}
 
@@ -9,6 +9,9 @@ import (
 	"time"
 )
 
+// byteOrder is the byte order of the WAL length fields, the same as the LSM WAL.
+var byteOrder = binary.BigEndian
+
 // WalEntry represents a log entry.
 type WalEntry struct {
 	Op    byte   // 0x00 for insert, 0x01 for delete
@@ -87,7 +90,7 @@ func (w *LFWAL) Flush() error {
 		}
 		// Write key length and key.
 		keyBytes := []byte(entry.Key)
-		if err := binary.Write(&buf, binary.BigEndian, uint16(len(keyBytes))); err != nil {
+		if err := binary.Write(&buf, byteOrder, uint16(len(keyBytes))); err != nil {
 			return err
 		}
 		if _, err := buf.Write(keyBytes); err != nil {
@@ -95,7 +98,7 @@ func (w *LFWAL) Flush() error {
 		}
 		// Write value length and value.
 		valBytes := []byte(entry.Value)
-		if err := binary.Write(&buf, binary.BigEndian, uint16(len(valBytes))); err != nil {
+		if err := binary.Write(&buf, byteOrder, uint16(len(valBytes))); err != nil {
 			return err
 		}
 		if _, err := buf.Write(valBytes); err != nil {
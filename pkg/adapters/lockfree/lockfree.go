@@ -20,103 +20,66 @@ package lockfree
 import (
 	"sync/atomic"
 	"time"
-	"unsafe"
 )
 
 // LFQueue is a lock-free queue implementation using the Michael-Scott algorithm.
-// It is safe for concurrent use by multiple goroutines.
+//
+// Concurrency contract: every method may be called from any number of goroutines at once.
+// All shared state (head, tail, each node's next link and the length counter) is accessed
+// only through sync/atomic typed values, so the queue is free of data races under the Go
+// memory model on every GOARCH, including 32-bit platforms, and uses no unsafe code.
+// A value is written before the node is published by a CAS and read only after it was
+// observed through an atomic load, so Dequeue happens-after the matching Enqueue.
+// Nodes are never reused; the garbage collector makes the ABA problem impossible, so no
+// tagged pointers are needed. Length and IsEmpty are snapshots that may be stale on return.
 type LFQueue[T any] struct {
-	head   unsafe.Pointer // *node[T]
-	tail   unsafe.Pointer // *node[T]
-	length int64          // tracks approximate length for metrics
+	head   atomic.Pointer[node[T]]
+	tail   atomic.Pointer[node[T]]
+	length atomic.Int64 // tracks approximate length for metrics
 }
 
-// node represents a single element in the queue.
-// The next pointer uses tagged pointers to prevent the ABA problem.
+// node represents a single element in the queue. value is immutable once the node is linked.
 type node[T any] struct {
-	value    T
-	next     *nodePointer[T]
-	dequeued uint32 // 0: not dequeued, 1: dequeued
-}
-
-// nodePointer is a pointer wrapper with a tag to prevent the ABA problem.
-type nodePointer[T any] struct {
-	ptr *node[T]
-	tag uint64 // Tag to prevent ABA problem
+	value T
+	next  atomic.Pointer[node[T]]
 }
 
 // NewLFQueue creates a new lock-free queue.
 func NewLFQueue[T any]() *LFQueue[T] {
 	// Create a sentinel node (dummy node) to simplify operations
-	sentinel := &node[T]{
-		next: &nodePointer[T]{
-			ptr: nil,
-			tag: 0,
-		},
-		dequeued: 0,
-	}
-
-	q := &LFQueue[T]{
-		length: 0,
-	}
-	// Initialize both head and tail to point to the sentinel node
-	q.head = unsafe.Pointer(sentinel)
-	q.tail = unsafe.Pointer(sentinel)
+	sentinel := &node[T]{}
+	q := &LFQueue[T]{}
+	q.head.Store(sentinel)
+	q.tail.Store(sentinel)
 	return q
 }
 
 // Enqueue adds an item to the end of the queue.
 // It returns true if the operation was successful.
 func (q *LFQueue[T]) Enqueue(value T) bool {
-	newNode := &node[T]{
-		value: value,
-		next: &nodePointer[T]{
-			ptr: nil,
-			tag: 0,
-		},
-		dequeued: 0,
-	}
+	newNode := &node[T]{value: value}
 
 	for {
-		tail := (*node[T])(atomic.LoadPointer(&q.tail))
-		next := atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&tail.next)))
-		tailNext := (*nodePointer[T])(next)
+		tail := q.tail.Load()
+		next := tail.next.Load()
 
-		// Check if tail is still valid (hasn't been moved by another thread)
-		if atomic.LoadPointer(&q.tail) != unsafe.Pointer(tail) {
-			continue // Retry from the beginning
+		// Check if tail is still valid (hasn't been moved by another goroutine)
+		if q.tail.Load() != tail {
+			continue
 		}
 
-		// If the tail is pointing to a node with a non-nil next pointer,
-		// help advance the tail pointer
-		if tailNext.ptr != nil {
-			// Help advance the tail pointer (tail is lagging)
-			atomic.CompareAndSwapPointer(
-				&q.tail,
-				unsafe.Pointer(tail),
-				unsafe.Pointer(tailNext.ptr),
-			)
-			continue // Retry from the beginning
+		// If the tail has a successor, the tail is lagging: help advance it and retry
+		if next != nil {
+			q.tail.CompareAndSwap(tail, next)
+			continue
 		}
 
 		// Try to link the new node at the end of the list
-		newNodePointer := &nodePointer[T]{
-			ptr: newNode,
-			tag: tailNext.tag + 1, // Increment tag for ABA prevention
-		}
-		if atomic.CompareAndSwapPointer(
-			(*unsafe.Pointer)(unsafe.Pointer(&tail.next)),
-			next,
-			unsafe.Pointer(newNodePointer),
-		) {
+		if tail.next.CompareAndSwap(nil, newNode) {
 			// Successfully linked the new node, now try to advance the tail
-			atomic.CompareAndSwapPointer(
-				&q.tail,
-				unsafe.Pointer(tail),
-				unsafe.Pointer(newNode),
-			)
-			atomic.AddInt64(&q.length, 1)
-			return true // Enqueue successful
+			q.tail.CompareAndSwap(tail, newNode)
+			q.length.Add(1)
+			return true
 		}
 		// CAS failed - retry
 	}
@@ -128,33 +91,30 @@ func (q *LFQueue[T]) Dequeue() (T, bool) {
 	var value T
 
 	for {
-		// Load current head and tail pointers.
-		headPtr := atomic.LoadPointer(&q.head)
-		tailPtr := atomic.LoadPointer(&q.tail)
-		head := (*node[T])(headPtr)
-		tail := (*node[T])(tailPtr)
+		head := q.head.Load()
+		tail := q.tail.Load()
+		next := head.next.Load()
 
-		// Load the next pointer from the current head.
-		nextPtr := atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&head.next)))
-		headNext := (*nodePointer[T])(nextPtr)
+		// Check if head is still consistent with the loaded next pointer.
+		if q.head.Load() != head {
+			continue
+		}
 
-		// If the next node is nil (i.e. head.next.ptr is nil), the queue is empty.
-		if headNext == nil || headNext.ptr == nil {
+		// If the sentinel has no successor, the queue is empty.
+		if next == nil {
 			return value, false
 		}
 
-		// If head equals tail, help advance the tail pointer.
+		// If head equals tail, the tail is lagging: help advance it.
 		if head == tail {
-			atomic.CompareAndSwapPointer(&q.tail, tailPtr, unsafe.Pointer(headNext.ptr))
+			q.tail.CompareAndSwap(tail, next)
 			continue
 		}
 
-		// Retrieve the value from the next node.
-		value = headNext.ptr.value
-
-		// Try to advance the head pointer atomically.
-		if atomic.CompareAndSwapPointer(&q.head, headPtr, unsafe.Pointer(headNext.ptr)) {
-			atomic.AddInt64(&q.length, -1)
+		// Read the value before the CAS; next becomes the new sentinel.
+		value = next.value
+		if q.head.CompareAndSwap(head, next) {
+			q.length.Add(-1)
 			return value, true
 		}
 		// CAS failed, retry.
@@ -164,51 +124,24 @@ func (q *LFQueue[T]) Dequeue() (T, bool) {
 // Length returns the approximate number of elements in the queue.
 // This is not guaranteed to be exact due to concurrent operations.
 func (q *LFQueue[T]) Length() int {
-	return int(atomic.LoadInt64(&q.length))
+	return int(q.length.Load())
 }
 
 // IsEmpty returns true if the queue is likely empty.
 // Due to concurrency, this is only an approximation.
 func (q *LFQueue[T]) IsEmpty() bool {
-	head := (*node[T])(atomic.LoadPointer(&q.head))
-	next := atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&head.next)))
-	headNext := (*nodePointer[T])(next)
-	return headNext.ptr == nil
+	return q.head.Load().next.Load() == nil
 }
 
 // Peek returns the value at the front of the queue without removing it.
 // If the queue is empty, it returns the zero value for type T and false.
 func (q *LFQueue[T]) Peek() (T, bool) {
 	var value T
-
-	for {
-		head := (*node[T])(atomic.LoadPointer(&q.head))
-		next := atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&head.next)))
-		headNext := (*nodePointer[T])(next)
-
-		// Check if head is still valid
-		if atomic.LoadPointer(&q.head) != unsafe.Pointer(head) {
-			continue // Retry from the beginning
-		}
-
-		// If queue is empty
-		if headNext.ptr == nil {
-			return value, false
-		}
-
-		// If node is already dequeued, help advance the head
-		if headNext.ptr.dequeued != 0 {
-			atomic.CompareAndSwapPointer(
-				&q.head,
-				unsafe.Pointer(head),
-				unsafe.Pointer(headNext.ptr),
-			)
-			continue // Retry
-		}
-
-		// Return the value without modifying the queue
-		return headNext.ptr.value, true
+	next := q.head.Load().next.Load()
+	if next == nil {
+		return value, false
 	}
+	return next.value, true
 }
 
 // TryDequeue attempts to dequeue an item from the queue.
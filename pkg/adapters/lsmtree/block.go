@@ -0,0 +1,475 @@
+package lsmtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 블록 SSTable
+//
+// 엔트리를 약 sstableBlockSize 바이트의 블록으로 묶어 블록마다 압축하고(CompressionType "none"이면
+// 그대로), 파일 끝에 블록마다 첫 키와 마지막 키만 담은 희소 인덱스를 둡니다. 테이블을 열 때는 이
+// 인덱스만 메모리에 올리고, 조회는 키가 속한 블록 하나만 읽기 때문에 메모리 사용량이 키 수가 아니라
+// 블록 수에 비례합니다.
+//
+//	[block 0] ... [block n-1]                블록: 압축된 엔트리들 (엔트리 인코딩은 비압축 SSTable과 같음)
+//	[index]                                  블록마다 firstKey, lastKey, offset, length, rawLen (uvarint) + CRC32
+//	                                         키는 엔트리처럼 사전으로 인코딩: uvarint(len<<1 | encoded) + 키
+//	[footer] indexOffset(u64) indexLen(u32) indexCRC(u32) codec(1) magic "GLSB"
+//
+// 암호화된 테이블은 압축한 블록과 인덱스를 각각 pagecrypt로 봉인해 기록하고(CRC32는 봉인된 바이트의
+// 것) footer의 magic이 "GLSE"입니다 (encryption.go).
+//
+// 블록 이전의 flat 포맷(엔트리 + CRC32)도 읽을 수 있으며, 그 경우에는 전체 키 인덱스를 만듭니다.
+// 블록 SSTable은 열 때 데이터 블록을 읽지 않으므로 Bloom은 사이드카 파일에 저장합니다 (bloom.go).
+//
+// LSM의 모든 파일(SSTable, Bloom 사이드카, WAL)의 정수는 빅 엔디안입니다. SSTable과 Bloom 사이드카는
+// magic("GLSB", "GLBF")이 포맷과 바이트 순서를 함께 나타내고, 헤더가 없는 WAL과 flat SSTable은
+// 이 순서로만 쓰이고 읽힙니다.
+
+// byteOrder is the byte order of every integer in the LSM files.
+var byteOrder = binary.BigEndian
+
+const (
+	sstableBlockSize  = 4096
+	tombstoneValLen   = 1<<16 - 1 // Value length marking a deleted key; values are shorter
+	maxSSTValue       = tombstoneValLen - 1
+	blockFooterSize   = 8 + 4 + 4 + 1 + 4
+	sstableBlockMagic = "GLSB"
+	// sstableSealedMagic ends the footer of a block table whose blocks and index are sealed.
+	sstableSealedMagic = "GLSE"
+)
+
+// Block compression codecs as stored in the footer.
+const (
+	codecNone byte = iota
+	codecSnappy
+	codecZstd
+)
+
+func compressionCodec(compressionType string) byte {
+	switch compressionType {
+	case "snappy":
+		return codecSnappy
+	case "zstd":
+		return codecZstd
+	}
+	return codecNone
+}
+
+// blockHandle locates one block of a block SSTable.
+type blockHandle struct {
+	firstKey string
+	lastKey  string
+	offset   int64
+	length   int    // Compressed length
+	rawLen   int    // Uncompressed length
+	crc      uint32 // CRC32 of the compressed block
+}
+
+var (
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+)
+
+// zstdCodec returns the shared encoder and decoder; EncodeAll/DecodeAll are safe for concurrent use.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEnc, _ = zstd.NewWriter(nil)
+		zstdDec, _ = zstd.NewReader(nil)
+	})
+	return zstdEnc, zstdDec
+}
+
+func compressBlock(codec byte, raw []byte) []byte {
+	switch codec {
+	case codecSnappy:
+		return snappy.Encode(nil, raw)
+	case codecZstd:
+		enc, _ := zstdCodec()
+		return enc.EncodeAll(raw, nil)
+	}
+	return raw
+}
+
+func decompressBlock(codec byte, data []byte, rawLen int) ([]byte, error) {
+	var raw []byte
+	var err error
+	switch codec {
+	case codecNone:
+		raw = data
+	case codecSnappy:
+		raw, err = snappy.Decode(nil, data)
+	case codecZstd:
+		_, dec := zstdCodec()
+		raw, err = dec.DecodeAll(data, make([]byte, 0, rawLen))
+	default:
+		return nil, fmt.Errorf("unknown block codec %d", codec)
+	}
+	if err != nil || len(raw) != rawLen {
+		return nil, ErrSSTableCorrupted
+	}
+	return raw, nil
+}
+
+// appendSSTEntry appends [keyLen u16][key][valLen u16][value], storing the key through dict.
+// A tombstone is written as valLen tombstoneValLen without value bytes.
+func appendSSTEntry(buf []byte, dict *keydict.Dictionary, key, value string) ([]byte, error) {
+	if len(key) >= int(keydict.EncodedFlag) {
+		return nil, fmt.Errorf("key too long: %d bytes", len(key))
+	}
+	if len(value) > maxSSTValue {
+		return nil, fmt.Errorf("value too long: %d bytes", len(value))
+	}
+	stored, encoded := dict.Encode(key)
+	keyLen := uint16(len(stored))
+	if encoded {
+		keyLen |= keydict.EncodedFlag
+	}
+	buf = byteOrder.AppendUint16(buf, keyLen)
+	buf = append(buf, stored...)
+	if value == tombstone {
+		return byteOrder.AppendUint16(buf, tombstoneValLen), nil
+	}
+	buf = byteOrder.AppendUint16(buf, uint16(len(value)))
+	return append(buf, value...), nil
+}
+
+// parseSSTEntries calls fn for each entry in data until fn returns false. Tombstones are
+// passed as the tombstone value.
+func parseSSTEntries(data []byte, dict *keydict.Dictionary, fn func(key string, value []byte) bool) error {
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return ErrSSTableCorrupted
+		}
+		keyLen := byteOrder.Uint16(data)
+		n := int(keyLen &^ keydict.EncodedFlag)
+		if len(data) < 4+n {
+			return ErrSSTableCorrupted
+		}
+		key, err := decodeKey(dict, keyLen, data[2:2+n])
+		if err != nil {
+			return err
+		}
+		valLen := int(byteOrder.Uint16(data[2+n:]))
+		if valLen == tombstoneValLen {
+			if !fn(key, []byte(tombstone)) {
+				return nil
+			}
+			data = data[4+n:]
+			continue
+		}
+		if len(data) < 4+n+valLen {
+			return ErrSSTableCorrupted
+		}
+		if !fn(key, data[4+n:4+n+valLen]) {
+			return nil
+		}
+		data = data[4+n+valLen:]
+	}
+	return nil
+}
+
+// writeBlockSSTable writes entries (sorted by key) as compressed blocks followed by the block
+// index and footer, sealing the blocks and index with the current key of keys if not nil.
+// It returns the block handles, the file size and the index checksum.
+func writeBlockSSTable(w io.Writer, entries []sstEntry, codec byte, dict *keydict.Dictionary, keys *pagecrypt.Keyring) ([]blockHandle, int64, uint32, error) {
+	bw := newBlockWriter(w, codec, dict, keys)
+	for _, e := range entries {
+		if err := bw.add(e.key, e.value); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	return bw.finish()
+}
+
+// blockWriter writes a block SSTable one entry at a time, in key order.
+type blockWriter struct {
+	w      *offsetWriter
+	codec  byte
+	dict   *keydict.Dictionary
+	keys   *pagecrypt.Keyring
+	blocks []blockHandle
+	raw    []byte // Entries of the block being filled
+	first  string // First key of the block being filled
+	last   string // Last key added
+}
+
+func newBlockWriter(w io.Writer, codec byte, dict *keydict.Dictionary, keys *pagecrypt.Keyring) *blockWriter {
+	return &blockWriter{w: &offsetWriter{w: w}, codec: codec, dict: dict, keys: keys}
+}
+
+// add appends an entry, writing the block once it reaches sstableBlockSize.
+func (bw *blockWriter) add(key, value string) error {
+	if len(bw.raw) == 0 {
+		bw.first = key
+	}
+	var err error
+	if bw.raw, err = appendSSTEntry(bw.raw, bw.dict, key, value); err != nil {
+		return err
+	}
+	bw.last = key
+	if len(bw.raw) >= sstableBlockSize {
+		return bw.flush()
+	}
+	return nil
+}
+
+// flush writes the block being filled.
+func (bw *blockWriter) flush() error {
+	if len(bw.raw) == 0 {
+		return nil
+	}
+	block, err := sealBlock(bw.keys, compressBlock(bw.codec, bw.raw), bw.w.n)
+	if err != nil {
+		return err
+	}
+	bw.blocks = append(bw.blocks, blockHandle{
+		firstKey: bw.first,
+		lastKey:  bw.last,
+		offset:   bw.w.n,
+		length:   len(block),
+		rawLen:   len(bw.raw),
+		crc:      crc32.ChecksumIEEE(block),
+	})
+	bw.raw = bw.raw[:0]
+	_, err = bw.w.Write(block)
+	return err
+}
+
+// finish writes the last block, the block index and the footer. It returns the block
+// handles, the file size and the index checksum.
+func (bw *blockWriter) finish() ([]blockHandle, int64, uint32, error) {
+	if err := bw.flush(); err != nil {
+		return nil, 0, 0, err
+	}
+	var index []byte
+	for _, b := range bw.blocks {
+		index = appendIndexKey(index, bw.dict, b.firstKey)
+		index = appendIndexKey(index, bw.dict, b.lastKey)
+		index = binary.AppendUvarint(index, uint64(b.offset))
+		index = binary.AppendUvarint(index, uint64(b.length))
+		index = binary.AppendUvarint(index, uint64(b.rawLen))
+		index = byteOrder.AppendUint32(index, b.crc)
+	}
+	index, err := sealBlock(bw.keys, index, bw.w.n)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	indexCRC := crc32.ChecksumIEEE(index)
+	footer := byteOrder.AppendUint64(nil, uint64(bw.w.n))
+	footer = byteOrder.AppendUint32(footer, uint32(len(index)))
+	footer = byteOrder.AppendUint32(footer, indexCRC)
+	footer = append(footer, bw.codec)
+	if bw.keys != nil {
+		footer = append(footer, sstableSealedMagic...)
+	} else {
+		footer = append(footer, sstableBlockMagic...)
+	}
+	if _, err := bw.w.Write(append(index, footer...)); err != nil {
+		return nil, 0, 0, err
+	}
+	return bw.blocks, bw.w.n, indexCRC, nil
+}
+
+// appendIndexKey appends a block index key, storing it through dict like entry keys.
+func appendIndexKey(buf []byte, dict *keydict.Dictionary, key string) []byte {
+	stored, encoded := dict.Encode(key)
+	n := uint64(len(stored)) << 1
+	if encoded {
+		n |= 1
+	}
+	buf = binary.AppendUvarint(buf, n)
+	return append(buf, stored...)
+}
+
+// readBlockIndex reads the footer and block index of a block SSTable into a table holding
+// its codec, blocks, index checksum and, for a sealed table, keys and key ID. ok is false if
+// the file is not in the block format.
+func readBlockIndex(file *os.File, size int64, dict *keydict.Dictionary, keys *pagecrypt.Keyring) (sst *SSTable, ok bool, err error) {
+	if size < blockFooterSize {
+		return nil, false, nil
+	}
+	footer := make([]byte, blockFooterSize)
+	if _, err := file.ReadAt(footer, size-blockFooterSize); err != nil {
+		return nil, false, err
+	}
+	switch magic := string(footer[blockFooterSize-4:]); {
+	case magic == sstableSealedMagic && keys == nil:
+		return nil, true, pagecrypt.ErrKeyRequired
+	case magic == sstableBlockMagic && keys != nil:
+		return nil, true, fmt.Errorf("%w: SSTable %s is not encrypted", pagecrypt.ErrNotEncrypted, file.Name())
+	case magic != sstableBlockMagic && magic != sstableSealedMagic:
+		return nil, false, nil
+	}
+	sst = &SSTable{keys: keys}
+	indexOffset := int64(byteOrder.Uint64(footer))
+	indexLen := int64(byteOrder.Uint32(footer[8:]))
+	sst.checksum = byteOrder.Uint32(footer[12:])
+	sst.codec = footer[16]
+	if indexOffset < 0 || indexOffset+indexLen != size-blockFooterSize {
+		return nil, true, ErrSSTableCorrupted
+	}
+	index := make([]byte, indexLen)
+	if _, err := file.ReadAt(index, indexOffset); err != nil {
+		return nil, true, err
+	}
+	if crc32.ChecksumIEEE(index) != sst.checksum {
+		return nil, true, ErrSSTableCorrupted
+	}
+	if keys != nil {
+		if sst.keyID, err = pagecrypt.SealedID(index); err != nil {
+			return nil, true, ErrSSTableCorrupted
+		}
+		if index, err = openBlock(keys, index, indexOffset); err != nil {
+			return nil, true, err
+		}
+	}
+
+	r := bytes.NewReader(index)
+	readString := func() (string, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n>>1 > uint64(r.Len()) {
+			return "", ErrSSTableCorrupted
+		}
+		b := make([]byte, n>>1)
+		io.ReadFull(r, b)
+		if n&1 == 0 {
+			return string(b), nil
+		}
+		return decodeKey(dict, keydict.EncodedFlag, b)
+	}
+	readInt := func() (int64, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n > 1<<32 {
+			return 0, ErrSSTableCorrupted
+		}
+		return int64(n), nil
+	}
+	for r.Len() > 0 {
+		var b blockHandle
+		var offset, length, rawLen int64
+		if b.firstKey, err = readString(); err == nil {
+			b.lastKey, err = readString()
+		}
+		if err == nil {
+			offset, err = readInt()
+		}
+		if err == nil {
+			length, err = readInt()
+		}
+		if err == nil {
+			rawLen, err = readInt()
+		}
+		if err != nil && !errors.Is(err, ErrSSTableCorrupted) {
+			return nil, true, err // 키 사전 없이 연 경우
+		}
+		if err != nil || offset+length > indexOffset {
+			return nil, true, ErrSSTableCorrupted
+		}
+		if err := binary.Read(r, byteOrder, &b.crc); err != nil {
+			return nil, true, ErrSSTableCorrupted
+		}
+		b.offset, b.length, b.rawLen = offset, int(length), int(rawLen)
+		sst.blocks = append(sst.blocks, b)
+	}
+	return sst, true, nil
+}
+
+// readBlock reads, verifies and decompresses block i.
+func (s *SSTable) readBlock(file *os.File, i int) ([]byte, error) {
+	b := s.blocks[i]
+	data := make([]byte, b.length)
+	if _, err := file.ReadAt(data, b.offset); err != nil {
+		return nil, ErrSSTableCorrupted
+	}
+	if crc32.ChecksumIEEE(data) != b.crc {
+		return nil, ErrSSTableCorrupted
+	}
+	if s.keys != nil {
+		var err error
+		if data, err = openBlock(s.keys, data, b.offset); err != nil {
+			return nil, ErrSSTableCorrupted
+		}
+	}
+	return decompressBlock(s.codec, data, b.rawLen)
+}
+
+// lookupBlock finds key in the one block whose range may contain it.
+func (s *SSTable) lookupBlock(key string, cost *ports.ReadCost) (string, bool, error) {
+	i := sort.Search(len(s.blocks), func(i int) bool { return s.blocks[i].lastKey >= key })
+	if i == len(s.blocks) || s.blocks[i].firstKey > key {
+		return "", false, nil
+	}
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+	cost.Blocks++
+	raw, err := s.readBlock(file, i)
+	if err != nil {
+		return "", false, err
+	}
+	var value string
+	var found bool
+	err = parseSSTEntries(raw, s.dict, func(k string, v []byte) bool {
+		if k == key {
+			value, found = string(v), true
+		}
+		return k < key
+	})
+	if err != nil {
+		return "", false, ErrSSTableCorrupted
+	}
+	return value, found, nil
+}
+
+// blockEntries decompresses every block of the table.
+func (s *SSTable) blockEntries() (map[string]string, error) {
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	result := make(map[string]string)
+	for i := range s.blocks {
+		raw, err := s.readBlock(file, i)
+		if err != nil {
+			return nil, err
+		}
+		if err := parseSSTEntries(raw, s.dict, func(k string, v []byte) bool {
+			result[k] = string(v)
+			return true
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// offsetWriter counts the bytes written through it.
+type offsetWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.n += int64(n)
+	return n, err
+}
@@ -1,9 +1,42 @@
 package lsmtree
 
 import (
+	"errors"
+	"hash/crc32"
 	"hash/fnv"
+	"os"
 )
 
+// 블룸 필터 사이드카: 블록 SSTable은 열 때 데이터 블록을 읽지 않으므로 필터를 "<sstable>.bloom"
+// 파일에 따로 저장하고 열 때 읽어 옵니다. 사이드카가 없거나 손상되었으면 SSTable은 필터 없이
+// 열리고(조회는 정확하지만 매번 블록을 읽음), LSMTree가 백그라운드에서 데이터로부터 다시 만들어
+// 씁니다 (rebuild.go).
+//
+//	magic "GLBF" | size(u32) | bits (size/8 올림 바이트) | CRC32(u32, 앞의 모든 바이트)
+
+const (
+	bloomMagic         = "GLBF"
+	bloomSidecarSuffix = ".bloom"
+	bloomBitsPerKey    = 10
+	minBloomSize       = 1000
+)
+
+// errBloomSidecar is returned when a bloom sidecar is malformed.
+var errBloomSidecar = errors.New("invalid bloom filter sidecar")
+
+// bloomSidecarPath returns the sidecar file of an SSTable.
+func bloomSidecarPath(sstPath string) string {
+	return sstPath + bloomSidecarSuffix
+}
+
+// bloomSize returns the filter size for n keys.
+func bloomSize(n int) uint {
+	if size := uint(n * bloomBitsPerKey); size > minBloomSize {
+		return size
+	}
+	return minBloomSize
+}
+
 // BloomFilter is a simple bloom filter implementation.
 type BloomFilter struct {
 	bitset []bool
@@ -47,3 +80,51 @@ func (bf *BloomFilter) getHashes(key string) []uint {
 	idx2 := uint(hashVal>>16) % bf.size
 	return []uint{idx1, idx2}
 }
+
+// encode serializes the filter in the sidecar format.
+func (bf *BloomFilter) encode() []byte {
+	buf := append([]byte(bloomMagic), make([]byte, 4+(bf.size+7)/8)...)
+	byteOrder.PutUint32(buf[4:], uint32(bf.size))
+	bits := buf[8:]
+	for i, set := range bf.bitset {
+		if set {
+			bits[i/8] |= 1 << (i % 8)
+		}
+	}
+	return byteOrder.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+func decodeBloomFilter(data []byte) (*BloomFilter, error) {
+	if len(data) < 12 || string(data[:4]) != bloomMagic {
+		return nil, errBloomSidecar
+	}
+	body, sum := data[:len(data)-4], byteOrder.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, errBloomSidecar
+	}
+	size := uint(byteOrder.Uint32(body[4:]))
+	bits := body[8:]
+	if size == 0 || uint(len(bits)) != (size+7)/8 {
+		return nil, errBloomSidecar
+	}
+	bf := NewBloomFilter(size)
+	for i := range bf.bitset {
+		bf.bitset[i] = bits[i/8]&(1<<(i%8)) != 0
+	}
+	return bf, nil
+}
+
+// writeBloomSidecar stores bf next to the SSTable at sstPath. It is not fsynced: a torn or
+// lost sidecar fails its checksum and is rebuilt.
+func writeBloomSidecar(sstPath string, bf *BloomFilter) error {
+	return os.WriteFile(bloomSidecarPath(sstPath), bf.encode(), 0644)
+}
+
+// readBloomSidecar loads the filter of the SSTable at sstPath.
+func readBloomSidecar(sstPath string) (*BloomFilter, error) {
+	data, err := os.ReadFile(bloomSidecarPath(sstPath))
+	if err != nil {
+		return nil, err
+	}
+	return decodeBloomFilter(data)
+}
@@ -2,11 +2,24 @@ package lsmtree
 
 import (
 	"container/list"
+	"hash/maphash"
 	"sync"
+	"sync/atomic"
 )
 
-// Cache implements a simple LRU cache.
+// cacheShards is the number of independently locked segments of a Cache. Keys are spread by
+// hash, so concurrent readers of different keys rarely contend on the same lock.
+const cacheShards = 16
+
+// Cache implements a sharded LRU cache. LRU order is kept per shard.
 type Cache struct {
+	seed   maphash.Seed
+	shards [cacheShards]cacheShard
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type cacheShard struct {
 	capacity int
 	mu       sync.Mutex
 	items    map[string]*list.Element
@@ -21,42 +34,88 @@ type cacheEntry struct {
 // NewCache creates a new Cache with the specified capacity in bytes.
 // For simplicity, capacity is converted to an approximate number of entries.
 func NewCache(capacity int) *Cache {
-	return &Cache{
-		capacity: capacity / 64, // assume average 64 bytes per entry
-		items:    make(map[string]*list.Element),
-		order:    list.New(),
+	entries := capacity / 64 // assume average 64 bytes per entry
+	c := &Cache{seed: maphash.MakeSeed()}
+	for i := range c.shards {
+		c.shards[i] = cacheShard{
+			capacity: (entries + cacheShards - 1) / cacheShards,
+			items:    make(map[string]*list.Element),
+			order:    list.New(),
+		}
 	}
+	return c
+}
+
+func (c *Cache) shard(key string) *cacheShard {
+	return &c.shards[maphash.String(c.seed, key)%cacheShards]
 }
 
 // Get retrieves a value from the cache.
 func (c *Cache) Get(key string) (string, bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if elem, ok := c.items[key]; ok {
-		c.order.MoveToFront(elem)
-		return elem.Value.(*cacheEntry).value, true
+	s := c.shard(key)
+	s.mu.Lock()
+	elem, ok := s.items[key]
+	var value string
+	if ok {
+		s.order.MoveToFront(elem)
+		value = elem.Value.(*cacheEntry).value
+	}
+	s.mu.Unlock()
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
 	}
-	return "", false
+	return value, ok
 }
 
 // Put inserts or updates a key-value pair in the cache.
 func (c *Cache) Put(key, value string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if elem, ok := c.items[key]; ok {
-		c.order.MoveToFront(elem)
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		s.order.MoveToFront(elem)
 		elem.Value.(*cacheEntry).value = value
 		return
 	}
 	entry := &cacheEntry{key: key, value: value}
-	elem := c.order.PushFront(entry)
-	c.items[key] = elem
-	if c.order.Len() > c.capacity {
+	elem := s.order.PushFront(entry)
+	s.items[key] = elem
+	if s.order.Len() > s.capacity {
 		// Remove least recently used element.
-		lru := c.order.Back()
+		lru := s.order.Back()
 		if lru != nil {
-			c.order.Remove(lru)
-			delete(c.items, lru.Value.(*cacheEntry).key)
+			s.order.Remove(lru)
+			delete(s.items, lru.Value.(*cacheEntry).key)
 		}
 	}
 }
+
+// Remove drops key from the cache.
+func (c *Cache) Remove(key string) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		s.order.Remove(elem)
+		delete(s.items, key)
+	}
+}
+
+// Stats returns entry and hit counters aggregated over all shards.
+func (c *Cache) Stats() map[string]interface{} {
+	entries := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		entries += s.order.Len()
+		s.mu.Unlock()
+	}
+	return map[string]interface{}{
+		"entries": entries,
+		"shards":  cacheShards,
+		"hits":    c.hits.Load(),
+		"misses":  c.misses.Load(),
+	}
+}
@@ -1,26 +1,70 @@
 package lsmtree
 
 import (
-	"encoding/binary"
+	"context"
 	"fmt"
-	"hash/crc32"
-	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
-// Compactor handles background compaction using leveling.
+// 컴팩션 전략 (Config.CompactionStrategy)
+//
+// leveling: level0에는 flush된 SSTable이 키 범위가 겹친 채로 쌓입니다. level0 테이블 수가
+// Level0CompactionTrigger에 이르면 level0 전체와 키 범위가 겹치는 level1 테이블을 병합해
+// SSTableSize 단위의 겹치지 않는 level1 테이블로 다시 씁니다. level i(≥1)의 전체 크기가 목표
+// (SSTableSize × LevelSizeMultiplier^i)를 넘으면 테이블 하나를 돌아가며 골라 level i+1에서
+// 겹치는 테이블과 병합합니다.
+//
+// sizing (size-tiered): 모든 테이블을 level0에 두고, 생성 순서상 연속하면서 크기가 비슷한
+// (평균의 0.5~1.5배) 테이블이 Level0CompactionTrigger개 이상 모이면 하나로 병합합니다.
+//
+// 테이블은 생성 순서(seq)를 가지며 같은 키가 여러 테이블에 있으면 seq가 큰 쪽이 최신입니다.
+// 병합 결과는 입력 중 가장 큰 seq를 물려받으므로 병합되지 않은 테이블과의 선후 관계가 유지되고,
+// 파일 이름에 seq와 레벨이 기록되어 재시작 후에도 레벨 구성이 복원됩니다.
+//
+// 컴팩션은 라운드 단위로 진행합니다. 트리를 읽기 잠금한 채 서로 다른 테이블을 다루는 병합을 최대
+// CompactionWorkers개까지 고르고, 잠금을 푼 뒤 각 병합을 고루틴에서 실행합니다. 입력 SSTable은 불변이라
+// 병합 중에도 읽기, 쓰기와 flush가 막히지 않습니다. 병합이 모두 끝나면 짧게 쓰기 잠금을 잡고 결과를
+// 입력과 한꺼번에 바꾼 뒤 입력 파일을 지웁니다. 같은 레벨에 결과를 쓰는 병합끼리는 키 범위가 겹치지
+// 않아야 하고, 병합 중에 flush된 level0 테이블은 그대로 남습니다. Compactor.mu는 라운드를 직렬화하며,
+// 레벨을 바꾸는 수집과 Rekey도 이를 잡아 진행 중인 컴팩션을 기다립니다.
+
+const (
+	tierMinRatio         = 0.5 // size-tiered: 버킷 평균 대비 허용 크기 범위
+	tierMaxRatio         = 1.5
+	maxCompactionsPerRun = 16 // 한 번의 Compact 호출에서 수행하는 최대 병합 수
+)
+
+// Compactor handles background compaction using the configured strategy.
 type Compactor struct {
 	lsm *LSMTree
-	mu  sync.Mutex
+	mu  sync.Mutex // Serializes compaction rounds with ingestion and Rekey
+	// next[i]는 level i에서 다음에 내려보낼 테이블을 고르는 기준 키입니다 (라운드 로빈).
+	next map[int]string
+	// wake runs a compaction before the next tick, e.g. for a stalled write (see stall.go).
+	wake chan struct{}
+	// limiter caps the bytes per second merges write (see throttle.go).
+	limiter   *utils.RateLimiter
+	throttled atomic.Int64 // Nanoseconds merges waited for the limiter
 }
 
 // NewCompactor creates a new Compactor for the given LSMTree.
 func NewCompactor(lsm *LSMTree) (*Compactor, error) {
 	return &Compactor{
-		lsm: lsm,
+		lsm:     lsm,
+		next:    make(map[int]string),
+		wake:    make(chan struct{}, 1),
+		limiter: newCompactionLimiter(lsm.config.CompactionBytesPerSecond),
 	}, nil
 }
 
@@ -28,93 +72,492 @@ func NewCompactor(lsm *LSMTree) (*Compactor, error) {
 func (c *Compactor) Run(stopCh <-chan struct{}) {
 	ticker := time.NewTicker(c.lsm.config.CompactionInterval)
 	defer ticker.Stop()
+	// 종료 시 진행 중인 Compact가 다음 병합으로 넘어가지 않도록 취소
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 	for {
 		select {
 		case <-stopCh:
 			return
 		case <-ticker.C:
-			if err := c.Compact(); err != nil {
-				fmt.Printf("Compaction error: %v\n", err)
-			}
+		case <-c.wake:
+		}
+		if err := c.CompactContext(ctx); err != nil && ctx.Err() == nil {
+			c.lsm.config.logger().Error("Compaction failed", "adapter", "lsmtree", "path", c.lsm.config.FilePath, "error", err)
 		}
 	}
 }
 
-// Compact performs leveling compaction on level0 if threshold is reached.
+// wakeUp makes Run compact without waiting for the next tick.
+func (c *Compactor) wakeUp() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Compact runs the compactions that are due under the configured strategy.
 func (c *Compactor) Compact() error {
+	return c.compact(context.Background(), false)
+}
+
+// CompactContext is Compact that stops before the next round of merges once ctx is done,
+// returning ctx.Err(). Each merge either completes or leaves its input tables in place.
+func (c *Compactor) CompactContext(ctx context.Context) error {
+	return c.compact(ctx, false)
+}
+
+// CompactAll merges level0 regardless of the trigger (leveling) or all tables into one
+// (size-tiered), then runs the compactions that are due.
+func (c *Compactor) CompactAll() error {
+	return c.compact(context.Background(), true)
+}
+
+func (c *Compactor) compact(ctx context.Context, force bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	lsm := c.lsm
+
+	for done := 0; done < maxCompactionsPerRun; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		limit := min(lsm.config.compactionWorkers(), maxCompactionsPerRun-done)
+		lsm.mu.RLock()
+		var jobs []*compactionJob
+		if lsm.config.CompactionStrategy == "sizing" {
+			jobs = c.planSizeTiered(force, limit)
+		} else {
+			jobs = c.planLeveled(force, limit)
+		}
+		keyring := lsm.keys.Load()
+		lsm.mu.RUnlock()
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		// 입력 SSTable은 불변이므로 병합 중에는 트리를 잠그지 않음
+		var wg sync.WaitGroup
+		for _, job := range jobs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				job.outputs, job.err = c.run(ctx, job.tables(), job.older, job.to, job.maxSize, job.details, keyring)
+			}()
+		}
+		wg.Wait()
+		installed, err := c.install(jobs)
+		if installed > 0 {
+			lsm.compactedAt.Store(time.Now().UnixNano())
+		}
+		if err != nil {
+			return err
+		}
+		done += len(jobs)
+		force = false
+	}
+	return nil
+}
+
+// compactionJob is a merge planned by a compaction round.
+type compactionJob struct {
+	from, to    int
+	inputs      []*SSTable // Tables of level from
+	overlapping []*SSTable // Tables of level to overlapping inputs (leveling)
+	older       []*SSTable // Tables that may hold older values of the merged keys
+	maxSize     int
+	details     map[string]interface{}
+	outputs     []*SSTable
+	err         error
+}
+
+// tables returns the tables the job merges, oldest first. The tables of level to are older
+// than those of level from whatever their seq: a table pushed down inherits the largest seq
+// of its inputs, which may be newer than data left above it.
+func (j *compactionJob) tables() []*SSTable {
+	return append(append([]*SSTable(nil), j.overlapping...), j.inputs...)
+}
+
+// planLeveled plans up to limit leveled compactions that touch disjoint tables: level0
+// into level1 if level0 reached the trigger (or force), then one table of each level over
+// its target into the next. Caller holds lsm.mu.
+func (c *Compactor) planLeveled(force bool, limit int) []*compactionJob {
+	lsm := c.lsm
+	var jobs []*compactionJob
+	claimed := make(map[*SSTable]bool)
+	add := func(from int, inputs []*SSTable, to int) {
+		job := c.levelJob(from, inputs, to)
+		minKey, maxKey := keyRange(job.tables())
+		for _, sst := range job.tables() {
+			if claimed[sst] {
+				return
+			}
+		}
+		// 같은 레벨에 결과를 쓰는 작업끼리 키 범위가 겹치면 그 레벨의 테이블이 겹치게 됨
+		for _, other := range jobs {
+			otherMin, otherMax := keyRange(other.tables())
+			if other.to == to && otherMax >= minKey && otherMin <= maxKey {
+				return
+			}
+		}
+		for _, sst := range job.tables() {
+			claimed[sst] = true
+		}
+		jobs = append(jobs, job)
+	}
+
+	if n := len(lsm.levels[0]); n > 0 && (n >= lsm.config.level0Trigger() || n >= lsm.config.level0SlowdownTrigger() || force) {
+		add(0, append([]*SSTable(nil), lsm.levels[0]...), 1)
+	}
+	for i := 1; i < len(lsm.levels) && i < lsm.config.maxLevels()-1 && len(jobs) < limit; i++ {
+		if levelSize(lsm.levels[i]) <= lsm.config.levelTarget(i) {
+			continue
+		}
+		add(i, []*SSTable{c.pick(i)}, i+1)
+	}
+	return jobs
+}
+
+// pick returns the next table of level i in key order, wrapping around.
+func (c *Compactor) pick(i int) *SSTable {
+	level := c.lsm.levels[i]
+	idx := sort.Search(len(level), func(j int) bool { return level[j].minKey > c.next[i] })
+	if idx == len(level) {
+		idx = 0
+	}
+	c.next[i] = level[idx].maxKey
+	return level[idx]
+}
+
+// levelJob plans merging inputs from level from with the overlapping tables of level to.
+// Caller holds lsm.mu.
+func (c *Compactor) levelJob(from int, inputs []*SSTable, to int) *compactionJob {
+	lsm := c.lsm
+	job := &compactionJob{
+		from:    from,
+		to:      to,
+		inputs:  inputs,
+		maxSize: lsm.config.SSTableSize,
+		details: map[string]interface{}{"from_level": from},
+	}
+	minKey, maxKey := keyRange(inputs)
+	if to < len(lsm.levels) {
+		for _, sst := range lsm.levels[to] {
+			if sst.maxKey >= minKey && sst.minKey <= maxKey {
+				job.overlapping = append(job.overlapping, sst)
+			}
+		}
+		for _, level := range lsm.levels[to+1:] {
+			job.older = append(job.older, level...)
+		}
+	}
+	return job
+}
+
+// planSizeTiered plans merging up to limit disjoint runs of similarly sized tables in
+// level0, or all of them into one if force. Caller holds lsm.mu.
+func (c *Compactor) planSizeTiered(force bool, limit int) []*compactionJob {
+	tables := c.lsm.levels[0] // seq 순으로 정렬되어 있음
+	if force {
+		if len(tables) < 2 {
+			return nil
+		}
+		return []*compactionJob{c.tierJob(tables, 0, len(tables))}
+	}
+	var jobs []*compactionJob
+	for offset := 0; len(jobs) < limit; {
+		start, end := similarRun(tables[offset:], c.lsm.config.level0Trigger())
+		if end == 0 {
+			break
+		}
+		jobs = append(jobs, c.tierJob(tables, offset+start, offset+end))
+		offset += end
+	}
+	return jobs
+}
+
+// tierJob plans merging tables[start:end] of level0. Caller holds lsm.mu.
+func (c *Compactor) tierJob(tables []*SSTable, start, end int) *compactionJob {
+	older := append([]*SSTable(nil), tables[:start]...)
+	for _, level := range c.lsm.levels[1:] {
+		older = append(older, level...)
+	}
+	return &compactionJob{
+		inputs:  append([]*SSTable(nil), tables[start:end]...),
+		older:   older,
+		details: map[string]interface{}{"strategy": "sizing"},
+	}
+}
+
+// similarRun returns the first run of at least min consecutive tables whose sizes stay
+// within the tier ratios of the run's average, or (0, 0).
+func similarRun(tables []*SSTable, min int) (int, int) {
+	for start := 0; start+min <= len(tables); start++ {
+		total := tables[start].size
+		end := start + 1
+		for end < len(tables) {
+			avg := float64(total) / float64(end-start)
+			size := float64(tables[end].size)
+			if size < avg*tierMinRatio || size > avg*tierMaxRatio {
+				break
+			}
+			total += tables[end].size
+			end++
+		}
+		if end-start >= min {
+			return start, end
+		}
+	}
+	return 0, 0
+}
+
+// install replaces the merged tables of the jobs that succeeded with their outputs and
+// removes the merged files. It returns the number of jobs installed and the first error
+// of a job that failed; the inputs of a failed job stay in place.
+func (c *Compactor) install(jobs []*compactionJob) (int, error) {
+	lsm := c.lsm
 	lsm.mu.Lock()
 	defer lsm.mu.Unlock()
-
-	// Trigger compaction if level0 has 4 or more SSTables.
-	if len(lsm.levels[0]) < 4 {
-		return nil
+	installed := 0
+	var firstErr error
+	for _, job := range jobs {
+		if job.err != nil {
+			if firstErr == nil {
+				firstErr = job.err
+			}
+			continue
+		}
+		for len(lsm.levels) <= job.to {
+			lsm.levels = append(lsm.levels, nil)
+		}
+		if job.to == job.from {
+			// 연속 구간을 결과로 바꾸므로 seq 순서가 유지됨. 병합 중 flush된 테이블은 뒤에 있음.
+			lsm.levels[0] = replaceRun(lsm.levels[0], job.inputs, job.outputs)
+		} else {
+			lsm.levels[job.from] = without(lsm.levels[job.from], job.inputs)
+			level := append(without(lsm.levels[job.to], job.overlapping), job.outputs...)
+			sort.Slice(level, func(i, j int) bool { return level[i].minKey < level[j].minKey })
+			lsm.levels[job.to] = level
+		}
+		// 읽기는 lsm.mu를 잡고 테이블을 찾으므로 교체 뒤에는 입력 파일을 지워도 됨
+		for _, sst := range job.tables() {
+			os.Remove(sst.filePath)
+			os.Remove(bloomSidecarPath(sst.filePath))
+		}
+		installed++
 	}
+	return installed, firstErr
+}
 
-	// Merge level0 SSTables using streaming merge.
-	merged, err := mergeSSTables(lsm.levels[0], lsm.config)
-	if err != nil {
-		return err
-	}
-	// Remove level0 files.
-	lsm.levels[0] = nil
-	// Append merged SSTable to level1.
-	if len(lsm.levels) < 2 {
-		lsm.levels = append(lsm.levels, []*SSTable{merged})
-	} else {
-		lsm.levels[1] = append(lsm.levels[1], merged)
-	}
-	// Sort level1 by minKey.
-	sort.Slice(lsm.levels[1], func(i, j int) bool {
-		return lsm.levels[1][i].minKey < lsm.levels[1][j].minKey
-	})
-	return nil
+// replaceRun returns level with the consecutive tables of run replaced by outputs.
+func replaceRun(level, run, outputs []*SSTable) []*SSTable {
+	start := 0
+	for start < len(level) && level[start] != run[0] {
+		start++
+	}
+	merged := append(append([]*SSTable(nil), level[:start]...), outputs...)
+	return append(merged, level[start+len(run):]...)
 }
 
-// mergeSSTables performs a streaming merge of the provided SSTables into one.
-func mergeSSTables(ssts []*SSTable, config Config) (*SSTable, error) {
-	newPath := fmt.Sprintf("%s/db.sst.%d.sst", config.FilePath, time.Now().UnixNano())
-	outFile, err := os.Create(newPath)
+// run merges tables into level at the compaction rate limit, publishing compaction events.
+// maxSize splits the output into tables of about that size (0 = one table). older are the
+// tables that may hold older values of the merged keys (see mergeTables).
+func (c *Compactor) run(ctx context.Context, tables, older []*SSTable, level, maxSize int, details map[string]interface{}, keyring *pagecrypt.Keyring) ([]*SSTable, error) {
+	lsm := c.lsm
+	started := map[string]interface{}{"level": level, "inputs": len(tables)}
+	for k, v := range details {
+		started[k] = v
+	}
+	lsm.config.Events.Publish(events.Event{Type: events.CompactionStarted, Source: "lsmtree", Details: started})
+	throttle := func(n int) error { return c.throttle(ctx, n) }
+	outputs, dropped, err := mergeTables(tables, older, level, maxSize, lsm.config, keyring, throttle)
 	if err != nil {
+		lsm.config.Events.Publish(events.Event{Type: events.CompactionFinished, Source: "lsmtree", Err: err})
 		return nil, err
 	}
-	defer outFile.Close()
+	paths := make([]string, len(outputs))
+	for i, sst := range outputs {
+		paths[i] = sst.filePath
+	}
+	lsm.config.Events.Publish(events.Event{
+		Type:    events.CompactionFinished,
+		Source:  "lsmtree",
+		Details: map[string]interface{}{"level": level, "inputs": len(tables), "outputs": paths, "tombstones_dropped": dropped},
+	})
+	return outputs, nil
+}
 
-	// For each SSTable, copy its data excluding the last 4 bytes (checksum).
-	for _, sst := range ssts {
-		f, err := os.Open(sst.filePath)
+// mergeTables merges tables, oldest first, into new SSTables for level; the newest value of
+// each key wins, folded with the merge records above it. The outputs inherit the largest
+// input seq. A
+// winning tombstone is dropped, and a winning merge record folded into a value, unless the
+// key lies in the range of a table in older, where it may still have an older value; the
+// number of dropped tombstones is returned. With keyring the outputs are sealed with its current key.
+// throttle, if not nil, is called with the output bytes every compactionThrottleStep; if it
+// fails the outputs written so far are removed and its error returned.
+func mergeTables(tables, older []*SSTable, level, maxSize int, config Config, keyring *pagecrypt.Keyring, throttle func(int) error) ([]*SSTable, int, error) {
+	merged := make(map[string]string)
+	var seq int64
+	for _, sst := range tables {
+		entries, err := sst.entries()
 		if err != nil {
-			return nil, err
+			return nil, 0, fmt.Errorf("failed to read %s: %w", sst.filePath, err)
+		}
+		for k, v := range entries {
+			if old, ok := merged[k]; ok && isMergeRecord(v) {
+				// 더 오래된 항목 위의 머지 레코드는 그 항목과 접음 (merge.go 참고)
+				if v, err = stackMerge(config.MergeOperator, k, old, v); err != nil {
+					return nil, 0, fmt.Errorf("failed to merge key %s: %w", k, err)
+				}
+			}
+			merged[k] = v // 오래된 테이블부터 덮어써 최신 값이 남음
+		}
+		seq = max(seq, sst.seq)
+	}
+
+	keys := make([]string, 0, len(merged))
+	dropped := 0
+	for k, v := range merged {
+		if v == tombstone && !coveredBy(older, k) {
+			dropped++ // 더 오래된 값이 남아 있을 수 없는 키의 tombstone은 버림
+			continue
+		}
+		if isMergeRecord(v) && !coveredBy(older, k) {
+			// 더 오래된 값이 없으므로 기존 값 없이 접어 일반 값으로 씀
+			folded, err := foldMerge(config.MergeOperator, k, []string{v}, nil)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to merge key %s: %w", k, err)
+			}
+			merged[k] = folded
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var outputs []*SSTable
+	var chunk []sstEntry
+	size := 0
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
 		}
-		fi, err := f.Stat()
+		path := filepath.Join(config.FilePath, sstableName(seq, level))
+		sst, err := createSortedSSTable(path, chunk, config.CompressionType, config.UseBloomFilter, config.KeyDict, keyring)
 		if err != nil {
-			f.Close()
-			return nil, err
+			return err
 		}
-		dataSize := fi.Size() - 4
-		if _, err := io.CopyN(outFile, f, dataSize); err != nil {
-			f.Close()
-			return nil, err
+		outputs = append(outputs, sst)
+		chunk = nil
+		size = 0
+		return nil
+	}
+	pending := 0 // 속도 제한에 아직 반영하지 않은 출력 바이트
+	write := func() error {
+		for _, k := range keys {
+			n := 4 + len(k) + len(merged[k])
+			chunk = append(chunk, sstEntry{k, merged[k]})
+			size += n
+			if pending += n; throttle != nil && pending >= compactionThrottleStep {
+				if err := throttle(pending); err != nil {
+					return err
+				}
+				pending = 0
+			}
+			if maxSize > 0 && size >= maxSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
 		}
-		f.Close()
+		if throttle != nil && pending > 0 {
+			if err := throttle(pending); err != nil {
+				return err
+			}
+		}
+		return flush()
 	}
-	// Compute checksum for the merged file.
-	outFile.Sync()
-	outFile.Seek(0, io.SeekStart)
-	data, err := io.ReadAll(outFile)
-	if err != nil {
-		return nil, err
+	if err := write(); err != nil {
+		for _, sst := range outputs {
+			os.Remove(sst.filePath)
+			os.Remove(bloomSidecarPath(sst.filePath))
+		}
+		return nil, 0, err
 	}
-	checksum := crc32.ChecksumIEEE(data)
-	if err := binary.Write(outFile, binary.BigEndian, checksum); err != nil {
-		return nil, err
+	return outputs, dropped, nil
+}
+
+// coveredBy reports whether key lies in the key range of any of tables.
+func coveredBy(tables []*SSTable, key string) bool {
+	for _, sst := range tables {
+		if key >= sst.minKey && key <= sst.maxKey {
+			return true
+		}
+	}
+	return false
+}
+
+// sstableName names a compaction output. Flushed tables are named db.sst.<seq>.sst.
+func sstableName(seq int64, level int) string {
+	return fmt.Sprintf("db.sst.%d.%d.l%d.sst", seq, time.Now().UnixNano(), level)
+}
+
+// parseSSTableName returns the seq and level recorded in an SSTable file name.
+func parseSSTableName(name string) (seq int64, level int, ok bool) {
+	if !strings.HasPrefix(name, "db.sst.") || !strings.HasSuffix(name, ".sst") {
+		return 0, 0, false
 	}
-	// Open new SSTable.
-	newSST, err := OpenSSTable(newPath, config.UseBloomFilter)
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, "db.sst."), ".sst"), ".")
+	seq, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
-		return nil, err
+		return 0, 0, false
+	}
+	if len(parts) == 3 && strings.HasPrefix(parts[2], "l") {
+		if level, err = strconv.Atoi(parts[2][1:]); err != nil {
+			return 0, 0, false
+		}
+	}
+	return seq, level, true
+}
+
+func keyRange(tables []*SSTable) (string, string) {
+	minKey, maxKey := tables[0].minKey, tables[0].maxKey
+	for _, sst := range tables[1:] {
+		if sst.minKey < minKey {
+			minKey = sst.minKey
+		}
+		if sst.maxKey > maxKey {
+			maxKey = sst.maxKey
+		}
+	}
+	return minKey, maxKey
+}
+
+func levelSize(level []*SSTable) int64 {
+	var total int64
+	for _, sst := range level {
+		total += sst.size
+	}
+	return total
+}
+
+// without returns level minus the tables in remove.
+func without(level, remove []*SSTable) []*SSTable {
+	drop := make(map[*SSTable]bool, len(remove))
+	for _, sst := range remove {
+		drop[sst] = true
+	}
+	var kept []*SSTable
+	for _, sst := range level {
+		if !drop[sst] {
+			kept = append(kept, sst)
+		}
 	}
-	return newSST, nil
+	return kept
 }
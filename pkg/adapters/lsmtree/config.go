@@ -2,6 +2,11 @@ package lsmtree
 
 import (
 	"time"
+
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
 // Config는 LSM Tree의 설정을 저장하는 구조체입니다.
@@ -35,6 +40,31 @@ type Config struct {
 	// "leveling" 또는 "sizing"이 가능합니다.
 	CompactionStrategy string
 
+	// Level0CompactionTrigger는 level0 컴팩션을 시작하는 테이블 수입니다. sizing에서는 함께 병합할
+	// 비슷한 크기의 테이블 수입니다. 0이면 기본값(4)을 사용합니다.
+	Level0CompactionTrigger int
+
+	// Level0SlowdownTrigger는 leveling에서 쓰기를 늦추기 시작하는 level0 테이블 수이고,
+	// Level0StopTrigger는 컴팩션이 따라올 때까지 쓰기를 막는 테이블 수입니다 (stall.go 참고).
+	// 0이면 각각 Level0CompactionTrigger의 5배와 9배를 사용합니다.
+	Level0SlowdownTrigger int
+	Level0StopTrigger     int
+
+	// LevelSizeMultiplier는 leveling에서 인접 레벨 간 목표 크기 배수입니다.
+	// level i(≥1)의 목표 크기는 SSTableSize × LevelSizeMultiplier^i입니다. 0이면 기본값(10)을 사용합니다.
+	LevelSizeMultiplier int
+
+	// MaxLevels는 leveling에서 사용할 최대 레벨 수(level0 포함)입니다. 0이면 기본값(7)을 사용합니다.
+	MaxLevels int
+
+	// CompactionWorkers는 한 컴팩션 라운드에서 동시에 실행할 수 있는 병합 수입니다. 병합은 서로 다른
+	// 테이블을 다룰 때만 함께 실행됩니다. 0이면 기본값(2)을 사용합니다.
+	CompactionWorkers int
+
+	// CompactionBytesPerSecond는 컴팩션이 SSTable을 쓰는 속도의 상한(바이트/초)으로, 모든 병합 워커가
+	// 나눠 씁니다. 0이면 제한하지 않습니다 (throttle.go 참고).
+	CompactionBytesPerSecond int64
+
 	// CompressionType은 SSTable 압축에 사용할 알고리즘을 지정합니다.
 	// "none", "snappy", "zstd" 중 하나가 가능합니다.
 	CompressionType string
@@ -43,16 +73,56 @@ type Config struct {
 	// 활성화하면 안전성이 증가하지만 성능이 저하됩니다.
 	SyncWrites bool
 
+	// Durability는 쓰기가 반환되기 전의 WAL 기록 수준입니다. 비어 있으면 SyncWrites에 따라
+	// fsync-per-batch(true) 또는 async(false)가 됩니다. none은 WAL을 쓰지 않으며,
+	// fsync-per-commit은 엔트리마다 별도의 fsync를 기다립니다.
+	Durability ports.Durability
+
+	// GroupCommitEntries는 한 번의 fsync로 함께 기록할 WAL 엔트리의 최대 개수입니다.
+	// 0이면 기본값(256)을 사용합니다.
+	GroupCommitEntries int
+
+	// GroupCommitDelay는 배치를 채우기 위해 fsync를 미루는 최대 시간입니다.
+	// 0이면 기다리지 않고 이미 대기 중인 엔트리만 함께 기록합니다.
+	GroupCommitDelay time.Duration
+
+	// WALSegmentSize는 WAL 세그먼트 파일의 최대 크기(바이트)입니다. 세그먼트가 이 크기에 이르면 다음
+	// 파일로 넘어가며, flush된 memTable의 세그먼트는 지웁니다 (wal.go 참고). 0이면 기본값(4MB)을 사용합니다.
+	WALSegmentSize int
+
 	// MaxOpenFiles는 동시에 열 수 있는 최대 SSTable 파일 수입니다.
 	MaxOpenFiles int
 
-	// RecoveryMode는 시작 시 복구 모드를 지정합니다.
-	// "strict" 또는 "best_effort"가 가능합니다.
+	// RecoveryMode는 시작 시 WAL에서 손상된 레코드를 만났을 때의 처리입니다. "strict"는 그 세그먼트의
+	// 재생을 손상된 레코드 앞에서 멈추고, "best_effort"는 건너뛰고 이어서 재생합니다 (recovery.go 참고).
 	RecoveryMode string
 
 	// LogLevel은 로깅 세부 정보 수준을 지정합니다.
 	// "debug", "info", "warn", "error" 중 하나가 가능합니다.
 	LogLevel string
+
+	// Events는 flush/컴팩션 라이프사이클 이벤트를 발행할 버스입니다.
+	// nil이면 이벤트를 발행하지 않습니다.
+	Events *events.Bus
+
+	// KeyDict는 SSTable에 키를 (dictID, suffix) 형태로 저장할 때 사용하는 prefix 사전입니다.
+	// B-tree와 같은 사전을 공유할 수 있으며, nil이면 키를 그대로 저장합니다.
+	KeyDict *keydict.Dictionary
+
+	// Logger는 백그라운드 컴팩션 오류를 받습니다. nil이면 utils.DefaultLogger를 사용합니다.
+	Logger utils.Logger
+
+	// EncryptionKey를 주면 SSTable 블록과 인덱스, WAL을 AES-256-GCM으로 암호화합니다
+	// (pagecrypt.KeySize 바이트, encryption.go 참고). 암호화된 저장소는 같은 키로만 열 수 있으며
+	// Rekey로 키를 바꿉니다.
+	EncryptionKey []byte
+
+	// PreviousEncryptionKey는 중단된 Rekey가 바꾸던 이전 키입니다. 두 키로 열면 Rekey를 마칩니다.
+	PreviousEncryptionKey []byte
+
+	// MergeOperator는 Merge로 기록한 피연산자를 읽기와 컴팩션 때 값으로 접습니다 (merge.go 참고).
+	// nil이면 Merge를 쓸 수 없습니다. 머지 피연산자가 남은 저장소는 같은 연산자로 열어야 합니다.
+	MergeOperator MergeOperator
 }
 
 // DefaultConfig는 기본 설정으로 Config 인스턴스를 반환합니다.
@@ -74,6 +144,77 @@ func DefaultConfig() Config {
 	}
 }
 
+// durability resolves Durability, falling back to SyncWrites when it is unset.
+func (c *Config) durability() ports.Durability {
+	if c.Durability == "" && c.SyncWrites {
+		return ports.DurabilityFsyncPerBatch
+	}
+	d, _ := ports.ParseDurability(string(c.Durability))
+	return d
+}
+
+const (
+	defaultLevel0CompactionTrigger = 4
+	defaultLevelSizeMultiplier     = 10
+	defaultMaxLevels               = 7
+	defaultCompactionWorkers       = 2
+)
+
+func (c *Config) level0Trigger() int {
+	if c.Level0CompactionTrigger > 0 {
+		return c.Level0CompactionTrigger
+	}
+	return defaultLevel0CompactionTrigger
+}
+
+func (c *Config) level0SlowdownTrigger() int {
+	if c.Level0SlowdownTrigger > 0 {
+		return c.Level0SlowdownTrigger
+	}
+	return min(5*c.level0Trigger(), c.level0StopTrigger())
+}
+
+func (c *Config) level0StopTrigger() int {
+	if c.Level0StopTrigger > 0 {
+		return c.Level0StopTrigger
+	}
+	return max(9*c.level0Trigger(), c.Level0SlowdownTrigger)
+}
+
+func (c *Config) maxLevels() int {
+	if c.MaxLevels > 0 {
+		return c.MaxLevels
+	}
+	return defaultMaxLevels
+}
+
+func (c *Config) compactionWorkers() int {
+	if c.CompactionWorkers > 0 {
+		return c.CompactionWorkers
+	}
+	return defaultCompactionWorkers
+}
+
+func (c *Config) walSegmentSize() int64 {
+	if c.WALSegmentSize > 0 {
+		return int64(c.WALSegmentSize)
+	}
+	return defaultSegmentSize
+}
+
+// levelTarget returns the target size in bytes of level i (≥1) under leveling.
+func (c *Config) levelTarget(i int) int64 {
+	mult := int64(defaultLevelSizeMultiplier)
+	if c.LevelSizeMultiplier > 0 {
+		mult = int64(c.LevelSizeMultiplier)
+	}
+	target := int64(c.SSTableSize)
+	for ; i > 0; i-- {
+		target *= mult
+	}
+	return target
+}
+
 // Validate는 설정의 유효성을 검사하고 잘못된 설정이 있으면 오류를 반환합니다.
 func (c *Config) Validate() error {
 	if c.MemTableSize <= 0 {
@@ -88,10 +229,35 @@ func (c *Config) Validate() error {
 	if c.CacheSize < 0 {
 		return ErrInvalidConfig{"CacheSize cannot be negative"}
 	}
+	if c.GroupCommitEntries < 0 || c.GroupCommitDelay < 0 {
+		return ErrInvalidConfig{"GroupCommitEntries and GroupCommitDelay cannot be negative"}
+	}
+	if c.WALSegmentSize < 0 {
+		return ErrInvalidConfig{"WALSegmentSize cannot be negative"}
+	}
+	if c.Level0CompactionTrigger < 0 || c.LevelSizeMultiplier < 0 || c.MaxLevels < 0 || c.CompactionWorkers < 0 {
+		return ErrInvalidConfig{"Level0CompactionTrigger, LevelSizeMultiplier, MaxLevels and CompactionWorkers cannot be negative"}
+	}
+	if c.Level0SlowdownTrigger < 0 || c.Level0StopTrigger < 0 {
+		return ErrInvalidConfig{"Level0SlowdownTrigger and Level0StopTrigger cannot be negative"}
+	}
+	if c.level0SlowdownTrigger() > c.level0StopTrigger() {
+		return ErrInvalidConfig{"Level0SlowdownTrigger cannot exceed Level0StopTrigger"}
+	}
+	if c.LevelSizeMultiplier == 1 || c.MaxLevels == 1 {
+		return ErrInvalidConfig{"LevelSizeMultiplier and MaxLevels must be at least 2"}
+	}
+	if c.CompactionBytesPerSecond < 0 {
+		return ErrInvalidConfig{"CompactionBytesPerSecond cannot be negative"}
+	}
 	if c.MaxOpenFiles <= 0 {
 		return ErrInvalidConfig{"MaxOpenFiles must be positive"}
 	}
 
+	if _, err := ports.ParseDurability(string(c.Durability)); err != nil {
+		return ErrInvalidConfig{err.Error()}
+	}
+
 	// 컴팩션 전략 검증
 	switch c.CompactionStrategy {
 	case "leveling", "sizing":
@@ -126,3 +292,11 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// logger returns Logger, or the default logger when it is unset.
+func (c *Config) logger() utils.Logger {
+	if c.Logger == nil {
+		return utils.DefaultLogger()
+	}
+	return c.Logger
+}
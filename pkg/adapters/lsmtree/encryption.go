@@ -0,0 +1,216 @@
+package lsmtree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+)
+
+// 저장 데이터 암호화: Config.EncryptionKey를 주면 SSTable과 WAL을 pagecrypt로 봉인해 기록합니다.
+//
+//	SSTable: 압축한 블록과 블록 인덱스를 각각 봉인 (AAD는 파일 안의 오프셋), footer magic "GLSE"
+//	WAL:     세그먼트마다 "GLWE" + 배치마다 봉인 길이(u32) + 봉인된 엔트리들 (AAD는 세그먼트 안의 프레임 오프셋)
+//
+// Bloom 사이드카는 키의 해시 비트만 담으므로 평문으로 남습니다. WAL 세그먼트 끝의 잘린 프레임은
+// 복구할 때 잘라 냅니다. 열린 WAL은 새 세그먼트에 이어 쓰므로 잘린 프레임 뒤에 기록하지 않습니다.
+// 인증에 실패한 프레임은 RecoveryMode에 따라 처리합니다 (recovery.go 참고).
+//
+// Rekey는 memTable을 flush한 뒤(이전 키로 봉인된 WAL 세그먼트가 지워짐) 이전 키로 봉인된 SSTable을
+// 새 키로 다시 쓰고 fsync한 다음에 이전 파일을 지웁니다. 도중에 멈추면 두 키로 봉인된 테이블이 섞여 남으므로, 새 키를
+// EncryptionKey, 이전 키를 PreviousEncryptionKey로 주고 열면 열면서 마저 다시 씁니다. 두 키로 연
+// 저장소는 모든 테이블이 새 키로 봉인된 뒤에 이전 키를 잊습니다.
+
+// walSealedMagic starts a WAL whose batches are sealed.
+var walSealedMagic = []byte("GLWE")
+
+// offsetAAD binds a sealed block or WAL frame to its offset in the file.
+func offsetAAD(offset int64) []byte {
+	return byteOrder.AppendUint64(nil, uint64(offset))
+}
+
+// sealBlock seals an SSTable block or index written at offset, or returns it as is without keys.
+func sealBlock(keys *pagecrypt.Keyring, data []byte, offset int64) ([]byte, error) {
+	if keys == nil {
+		return data, nil
+	}
+	sealed, err := keys.Current().Seal(nil, data, offsetAAD(offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt SSTable block: %v", err)
+	}
+	return sealed, nil
+}
+
+// openBlock opens a block or index read from offset. A block sealed with a key missing from
+// keys is reported as pagecrypt.ErrWrongKey, any other failure as ErrSSTableCorrupted.
+func openBlock(keys *pagecrypt.Keyring, data []byte, offset int64) ([]byte, error) {
+	plain, err := keys.Open(nil, data, offsetAAD(offset))
+	if errors.Is(err, pagecrypt.ErrWrongKey) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ErrSSTableCorrupted
+	}
+	return plain, nil
+}
+
+// checkWALMagic validates the WAL against keys: a sealed WAL needs keys and keys need a
+// sealed WAL. An empty WAL gets the sealed magic when keys are given and the checked one
+// otherwise.
+func checkWALMagic(file *os.File, keys *pagecrypt.Keyring) error {
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 {
+		magic := walCheckedMagic
+		if keys != nil {
+			magic = walSealedMagic
+		}
+		if _, err := file.Write(magic); err != nil {
+			return fmt.Errorf("failed to write WAL magic: %v", err)
+		}
+		return nil
+	}
+	magic := make([]byte, len(walSealedMagic))
+	n, _ := file.ReadAt(magic, 0)
+	sealed := n == len(magic) && bytes.Equal(magic, walSealedMagic)
+	switch {
+	case sealed && keys == nil:
+		return pagecrypt.ErrKeyRequired
+	case !sealed && keys != nil:
+		return fmt.Errorf("%w: WAL has unencrypted entries", pagecrypt.ErrNotEncrypted)
+	}
+	return nil
+}
+
+// sealFrame seals a batch of encoded entries as a frame appended to the WAL. Caller holds w.mu.
+func (w *WAL) sealFrame(entries []byte) ([]byte, error) {
+	offset, err := w.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, 4, 4+len(entries)+pagecrypt.Overhead)
+	frame, err = w.keys.Current().Seal(frame, entries, offsetAAD(offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt WAL: %v", err)
+	}
+	byteOrder.PutUint32(frame, uint32(len(frame)-4))
+	return frame, nil
+}
+
+// setKeys changes the keys later batches are sealed with.
+func (w *WAL) setKeys(keys *pagecrypt.Keyring) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keys = keys
+}
+
+// recoverSealedWAL replays the frames of a sealed WAL of size bytes into memTable and returns
+// the offset where the frames read end (see recovery.go).
+func recoverSealedWAL(file *os.File, size int64, memTable *MemTable, keys *pagecrypt.Keyring, skip bool, rp *walReplay) (int64, error) {
+	offset := int64(len(walSealedMagic))
+	r := bufio.NewReader(io.NewSectionReader(file, offset, size-offset))
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			break
+		}
+		n := int64(byteOrder.Uint32(header[:]))
+		if n > size-offset-4 {
+			break // 잘린 프레임
+		}
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			break
+		}
+		entries, err := keys.Open(nil, sealed, offsetAAD(offset))
+		if errors.Is(err, pagecrypt.ErrWrongKey) {
+			return offset, err
+		}
+		if err != nil {
+			rp.corrupt = append(rp.corrupt, fmt.Errorf("%w: %s: frame at offset %d: %v", ErrWALCorrupted, file.Name(), offset, err))
+			if !skip {
+				break
+			}
+		} else if err := replayWAL(bytes.NewReader(entries), memTable, rp); err != nil {
+			return offset, err
+		}
+		offset += 4 + n
+	}
+	return offset, nil
+}
+
+// Rekey re-encrypts the tree with newKey, which is used for all later writes: it flushes the
+// memTable and rewrites every SSTable sealed with another key. Lookups and compactions wait
+// while the tables are rewritten; see encryption.go for what happens if it is interrupted.
+func (l *LSMTree) Rekey(newKey []byte) error {
+	keys := l.keys.Load()
+	if keys == nil {
+		return pagecrypt.ErrNotEncrypted
+	}
+	next, err := keys.Rotate(newKey)
+	if err != nil {
+		return err
+	}
+	l.keys.Store(next)
+	l.wal.setKeys(next)
+	return l.finishRekey()
+}
+
+// finishRekey flushes the memTable and rewrites the SSTables that are not sealed with the
+// current key, then forgets the previous key.
+func (l *LSMTree) finishRekey() error {
+	if err := l.flushMemTable(); err != nil {
+		return err
+	}
+	l.compactor.mu.Lock()
+	defer l.compactor.mu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := l.keys.Load()
+	for level, tables := range l.levels {
+		for i, sst := range tables {
+			if sst.keyID == keys.Current().ID() {
+				continue
+			}
+			out, err := l.resealTable(sst, level, keys)
+			if err != nil {
+				return fmt.Errorf("failed to rekey %s: %v", sst.filePath, err)
+			}
+			tables[i] = out
+		}
+	}
+	keys = keys.WithoutPrevious()
+	l.keys.Store(keys)
+	l.wal.setKeys(keys)
+	return nil
+}
+
+// resealTable writes the entries of sst to a new table of the same seq and level sealed with
+// the current key of keys, and removes sst once the new table is on disk.
+func (l *LSMTree) resealTable(sst *SSTable, level int, keys *pagecrypt.Keyring) (*SSTable, error) {
+	data, err := sst.entries()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]sstEntry, 0, len(data))
+	for k, v := range data {
+		entries = append(entries, sstEntry{k, v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	path := filepath.Join(l.config.FilePath, sstableName(sst.seq, level))
+	out, err := createSortedSSTable(path, entries, l.config.CompressionType, l.config.UseBloomFilter, l.config.KeyDict, keys)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(sst.filePath)
+	os.Remove(bloomSidecarPath(sst.filePath))
+	return out, nil
+}
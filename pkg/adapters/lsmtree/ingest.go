@@ -0,0 +1,164 @@
+package lsmtree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// SSTable 수집: 대량의 정렬된 데이터는 SSTableWriter(또는 CreateSSTable)로 트리 밖에서 SSTable을 만든
+// 뒤 IngestSSTable이나 IngestExternalFile로 넣으면 WAL과 memTable을 거치지 않습니다. 수집은 먼저
+// memTable을 내보내므로, 수집한 값은 그 전에 쓴 같은 키의 값을 가리고 이후의 쓰기는 다시 수집한 값을
+// 가립니다.
+//
+//   - IngestSSTable은 파일을 가장 최신의 level0 테이블로 추가합니다. 겹치는 키는 이후 컴팩션이 정리합니다.
+//   - IngestExternalFile은 leveling 전략에서 파일의 키 범위가 그 레벨과 위의 모든 레벨의 테이블과
+//     겹치지 않는 가장 깊은 레벨에 바로 둡니다. 위에 더 최신 값이 없으므로 가리는 관계가 같고, 컴팩션이
+//     파일을 다시 쓰며 내려보내지 않아도 됩니다. level0과 겹치거나 size-tiered 전략이면 level0에 둡니다.
+//     레벨을 고르는 동안 컴팩션은 기다립니다.
+//
+// 원본 파일은 그대로 두고 데이터 디렉터리에 복사합니다. 수집 전에 모든 항목을 읽어 손상 여부를
+// 확인하며, 블룸 필터를 쓰는 트리는 이때 사이드카도 만듭니다. 암호화된 트리는 평문 테이블을 둘 수 없으므로
+// 항목을 현재 키로 봉인한 새 테이블로 다시 씁니다. 머지 레코드처럼 보이는 값이 든 테이블은 거절합니다.
+
+// IngestSSTable adds a copy of the plaintext SSTable at path to the tree as its newest table.
+// It fails if the table is corrupt; the tree is left unchanged then.
+func (l *LSMTree) IngestSSTable(path string) error {
+	_, err := l.ingest(path, false)
+	return err
+}
+
+// IngestExternalFile adds a copy of the plaintext SSTable at path, e.g. one built by
+// SSTableWriter, to the deepest level it fits without overlapping the key range of that
+// level or a level above, and returns the level. It fails if the table is corrupt; the tree
+// is left unchanged then.
+func (l *LSMTree) IngestExternalFile(path string) (int, error) {
+	return l.ingest(path, true)
+}
+
+// ingest adds a copy of the SSTable at path, to the level pickLevel chooses or to level0.
+func (l *LSMTree) ingest(path string, pickLevel bool) (int, error) {
+	entries, err := l.readIngested(path)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	// 수집한 테이블이 그 전의 쓰기보다 최신이 되도록 memTable을 먼저 내보냄
+	if err := l.flushMemTable(); err != nil {
+		return 0, err
+	}
+	// 레벨을 고른 뒤 테이블을 추가할 때까지 컴팩션이 레벨을 바꾸지 않게 함
+	l.compactor.mu.Lock()
+	defer l.compactor.mu.Unlock()
+	level := 0
+	if pickLevel {
+		level = l.ingestLevel(entries[0].key, entries[len(entries)-1].key)
+	}
+
+	dst := filepath.Join(l.config.FilePath, sstableName(time.Now().UnixNano(), level))
+	var sst *SSTable
+	if keys := l.keys.Load(); keys != nil {
+		sst, err = createSortedSSTable(dst, entries, l.config.CompressionType, l.config.UseBloomFilter, l.config.KeyDict, keys)
+	} else {
+		sst, err = l.copySSTable(path, dst, entries)
+	}
+	if err != nil {
+		os.Remove(dst)
+		os.Remove(bloomSidecarPath(dst))
+		return 0, fmt.Errorf("failed to ingest %s: %v", path, err)
+	}
+
+	l.mu.Lock()
+	for len(l.levels) <= level {
+		l.levels = append(l.levels, nil)
+	}
+	l.levels[level] = append(l.levels[level], sst) // level0은 seq 순서, 새 테이블이 가장 최신
+	if level > 0 {
+		sort.Slice(l.levels[level], func(i, j int) bool {
+			return l.levels[level][i].minKey < l.levels[level][j].minKey
+		})
+	}
+	// 캐시의 값은 이제 수집한 테이블에 가려졌을 수 있음. 읽기는 l.mu를 잡고 캐시를 채우므로 경합 없음.
+	for _, e := range entries {
+		l.cache.Remove(e.key)
+	}
+	l.mu.Unlock()
+	return level, nil
+}
+
+// readIngested reads and checks every entry of the plaintext SSTable at path.
+func (l *LSMTree) readIngested(path string) ([]sstEntry, error) {
+	src, err := openSSTable(path, false, l.config.KeyDict, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	var blocks int64
+	scan, err := src.scan("", "", &blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer scan.close()
+	var entries []sstEntry
+	for scan.next() {
+		key, value := scan.entry()
+		if isMergeRecord(value) {
+			return nil, fmt.Errorf("%s: key %s: %w", path, key, ErrInvalidValue)
+		}
+		entries = append(entries, sstEntry{key, value})
+	}
+	if err := scan.err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// ingestLevel returns the deepest level under leveling whose tables, and those of every
+// level above it, don't overlap [minKey, maxKey]. Caller holds l.compactor.mu.
+func (l *LSMTree) ingestLevel(minKey, maxKey string) int {
+	if l.config.CompactionStrategy == "sizing" {
+		return 0
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	overlaps := func(level int) bool {
+		if level >= len(l.levels) {
+			return false
+		}
+		for _, sst := range l.levels[level] {
+			if sst.maxKey >= minKey && sst.minKey <= maxKey {
+				return true
+			}
+		}
+		return false
+	}
+	level := 0
+	for i := 0; i < l.config.maxLevels(); i++ {
+		if overlaps(i) {
+			break
+		}
+		level = i
+	}
+	return level
+}
+
+// copySSTable copies the plaintext SSTable at path to dst, writes its bloom sidecar from
+// entries if the tree uses bloom filters, and opens the copy.
+func (l *LSMTree) copySSTable(path, dst string, entries []sstEntry) (*SSTable, error) {
+	if err := utils.CopyFileAtomic(path, dst); err != nil {
+		return nil, err
+	}
+	if l.config.UseBloomFilter {
+		bf := NewBloomFilter(bloomSize(len(entries)))
+		for _, e := range entries {
+			bf.Add(e.key)
+		}
+		if err := writeBloomSidecar(dst, bf); err != nil {
+			return nil, err
+		}
+	}
+	return openSSTable(dst, l.config.UseBloomFilter, l.config.KeyDict, nil)
+}
@@ -0,0 +1,270 @@
+package lsmtree
+
+import (
+	"container/heap"
+	"os"
+	"sort"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 범위 스캔: Scan은 호출 시점의 memTable과 모든 레벨의 SSTable을 하나의 정렬된 반복자로
+// 병합합니다. 같은 키가 여러 곳에 있으면 가장 최신 소스(memTable, level0의 최신 테이블, 그다음
+// 더 깊은 레벨 순)의 값이 이기며, tombstone이 이긴 키는 건너뜁니다.
+//
+// SSTable 파일은 Scan 시점에 열어 두므로 반복 중에 컴팩션이 파일을 지워도 계속 읽을 수 있습니다.
+// 블록 SSTable은 범위에 걸친 블록만, 필요할 때 하나씩 읽습니다. 반복이 끝나면 Close를 호출해야 합니다.
+
+// Iterator iterates over the live keys of a range in ascending order.
+type Iterator struct {
+	sources []scanSource
+	heap    sourceHeap
+	key     string
+	value   string
+	err     error
+	started bool
+	cost    ports.ReadCost // Sources merged and blocks read so far
+	merge   MergeOperator  // Folds merge records (see merge.go)
+}
+
+// scanSource yields the entries of one memTable or SSTable in key order.
+type scanSource interface {
+	// next advances to the next entry; it returns false at the end or on error.
+	next() bool
+	entry() (key, value string)
+	err() error
+	close()
+}
+
+// Scan returns an iterator over the keys in [start, end). An empty end scans to the last key.
+func (l *LSMTree) Scan(start, end string) (*Iterator, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	it := &Iterator{merge: l.config.MergeOperator}
+	// 우선순위 순서(최신 우선)로 소스를 추가.
+	var mem []sstEntry
+	l.memTable.Load().Ascend(start, end, func(key, value string) bool {
+		mem = append(mem, sstEntry{key, value})
+		return true
+	})
+	it.sources = append(it.sources, &sliceSource{entries: mem, pos: -1})
+	it.cost.CacheHits++
+
+	var tables []*SSTable
+	for i := len(l.levels[0]) - 1; i >= 0; i-- {
+		tables = append(tables, l.levels[0][i])
+	}
+	for _, level := range l.levels[1:] {
+		tables = append(tables, level...)
+	}
+	for _, sst := range tables {
+		if sst.Quarantined() || sst.maxKey < start || (end != "" && sst.minKey >= end) {
+			continue
+		}
+		src, err := sst.scan(start, end, &it.cost.Blocks)
+		if err != nil {
+			it.Close()
+			return nil, err
+		}
+		it.sources = append(it.sources, src)
+		it.cost.SSTables++
+	}
+	return it, nil
+}
+
+// Next advances to the next live key. It returns false at the end of the range or on error.
+func (it *Iterator) Next() bool {
+	if !it.started {
+		it.started = true
+		for i, src := range it.sources {
+			it.push(i, src)
+		}
+	}
+	for it.err == nil && it.heap.Len() > 0 {
+		key := it.heap[0].key
+		// 최신 항목부터 꺼내며, 머지 레코드는 값이나 tombstone을 만날 때까지 모으고 나머지는 건너뜀.
+		var records []string
+		value, settled := tombstone, false
+		for it.heap.Len() > 0 && it.heap[0].key == key {
+			item := heap.Pop(&it.heap).(heapItem)
+			if !settled {
+				if isMergeRecord(item.value) {
+					records = append(records, item.value)
+				} else {
+					value, settled = item.value, true
+				}
+			}
+			it.push(item.source, it.sources[item.source])
+		}
+		if len(records) > 0 {
+			var base *string
+			if value != tombstone {
+				base = &value
+			}
+			folded, err := foldMerge(it.merge, key, records, base)
+			if err != nil {
+				it.err = err
+				return false
+			}
+			value = folded
+		}
+		if value == tombstone {
+			continue
+		}
+		it.key, it.value = key, value
+		return true
+	}
+	return false
+}
+
+// push adds the next entry of src to the heap.
+func (it *Iterator) push(i int, src scanSource) {
+	if src.next() {
+		key, value := src.entry()
+		heap.Push(&it.heap, heapItem{key: key, value: value, source: i})
+	} else if err := src.err(); err != nil && it.err == nil {
+		it.err = err
+	}
+}
+
+// Key returns the current key.
+func (it *Iterator) Key() string { return it.key }
+
+// Value returns the current value.
+func (it *Iterator) Value() string { return it.value }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error { return it.err }
+
+// ReadCost returns the SSTables merged by the iterator and the blocks it has read so far.
+func (it *Iterator) ReadCost() ports.ReadCost { return it.cost }
+
+// Close releases the files held by the iterator.
+func (it *Iterator) Close() {
+	for _, src := range it.sources {
+		src.close()
+	}
+	it.sources = nil
+	it.heap = nil
+}
+
+// heapItem is the current entry of a source. Lower source indexes are newer.
+type heapItem struct {
+	key    string
+	value  string
+	source int
+}
+
+type sourceHeap []heapItem
+
+func (h sourceHeap) Len() int { return len(h) }
+func (h sourceHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].source < h[j].source
+}
+func (h sourceHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sourceHeap) Push(x interface{}) { *h = append(*h, x.(heapItem)) }
+func (h *sourceHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+type sstEntry struct {
+	key   string
+	value string
+}
+
+// sliceSource iterates over sorted in-memory entries.
+type sliceSource struct {
+	entries []sstEntry
+	pos     int
+}
+
+func (s *sliceSource) next() bool {
+	s.pos++
+	return s.pos < len(s.entries)
+}
+func (s *sliceSource) entry() (string, string) { return s.entries[s.pos].key, s.entries[s.pos].value }
+func (s *sliceSource) err() error              { return nil }
+func (s *sliceSource) close()                  {}
+
+// blockSource reads the blocks of a block SSTable that overlap the range one at a time.
+type blockSource struct {
+	sst        *SSTable
+	file       *os.File
+	start, end string
+	block      int // Next block to read
+	current    sliceSource
+	failure    error
+	blocks     *int64 // Iterator's count of blocks read
+}
+
+// scan returns a source over the entries of s in [start, end). Flat tables are read at once.
+// Blocks read from the file (the whole file for flat tables) are counted into blocks.
+func (s *SSTable) scan(start, end string, blocks *int64) (scanSource, error) {
+	inRange := func(key string) bool { return key >= start && (end == "" || key < end) }
+	if s.index != nil {
+		*blocks++
+		all, err := s.entries()
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]sstEntry, 0, len(all))
+		for k, v := range all {
+			if inRange(k) {
+				entries = append(entries, sstEntry{k, v})
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+		return &sliceSource{entries: entries, pos: -1}, nil
+	}
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+	first := sort.Search(len(s.blocks), func(i int) bool { return s.blocks[i].lastKey >= start })
+	return &blockSource{sst: s, file: file, start: start, end: end, block: first, current: sliceSource{pos: -1}, blocks: blocks}, nil
+}
+
+func (b *blockSource) next() bool {
+	for !b.current.next() {
+		if b.failure != nil || b.block >= len(b.sst.blocks) {
+			return false
+		}
+		if h := b.sst.blocks[b.block]; b.end != "" && h.firstKey >= b.end {
+			return false
+		}
+		*b.blocks++
+		raw, err := b.sst.readBlock(b.file, b.block)
+		if err != nil {
+			b.failure = err
+			return false
+		}
+		b.block++
+		var entries []sstEntry
+		err = parseSSTEntries(raw, b.sst.dict, func(k string, v []byte) bool {
+			if b.end != "" && k >= b.end {
+				return false
+			}
+			if k >= b.start {
+				entries = append(entries, sstEntry{k, string(v)})
+			}
+			return true
+		})
+		if err != nil {
+			b.failure = err
+			return false
+		}
+		b.current = sliceSource{entries: entries, pos: -1}
+	}
+	return true
+}
+
+func (b *blockSource) entry() (string, string) { return b.current.entry() }
+func (b *blockSource) err() error              { return b.failure }
+func (b *blockSource) close()                  { b.file.Close() }
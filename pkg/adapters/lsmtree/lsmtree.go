@@ -1,14 +1,30 @@
 package lsmtree
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+var (
+	_ ports.EntryLimiter     = (*LSMTree)(nil)
+	_ ports.ContextCompactor = (*LSMTree)(nil)
+	_ ports.Rekeyer          = (*LSMTree)(nil)
+	_ ports.SizeReporter     = (*LSMTree)(nil)
+	_ ports.StatusReporter   = (*LSMTree)(nil)
+	_ ports.RecoveryReporter = (*LSMTree)(nil)
 )
 
 // LSMTree represents the Log-Structured Merge Tree.
@@ -25,6 +41,15 @@ type LSMTree struct {
 	compactor *Compactor
 	stopCh    chan struct{}
 	wg        sync.WaitGroup
+	// missingBlooms are the tables opened without their bloom sidecar (see rebuild.go).
+	missingBlooms []*SSTable
+	bloomPending  atomic.Int64
+	// keys seals new SSTables (nil = plaintext); it holds a previous key only during Rekey.
+	keys atomic.Pointer[pagecrypt.Keyring]
+	// compactedAt is the time of the last compaction that merged tables (UnixNano, 0 = none).
+	compactedAt atomic.Int64
+	// recovery is the WAL replay done at open; RecoveryReport adds the quarantined tables.
+	recovery ports.RecoveryReport
 }
 
 // NewLSMTree creates a new LSMTree instance with the given configuration.
@@ -35,13 +60,22 @@ func NewLSMTree(config Config) (*LSMTree, error) {
 	if err := os.MkdirAll(config.FilePath, 0755); err != nil {
 		return nil, err
 	}
+	keys, err := pagecrypt.NewKeyring(config.EncryptionKey, config.PreviousEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
 	walPath := filepath.Join(config.FilePath, "db.wal")
-	wal, err := NewWAL(walPath, config.SyncWrites)
+	group := GroupCommit{MaxEntries: config.GroupCommitEntries, MaxDelay: config.GroupCommitDelay}
+	if config.durability() == ports.DurabilityFsyncPerCommit {
+		group = GroupCommit{MaxEntries: 1}
+	}
+	wal, err := newWAL(walPath, config.SyncWrites, group, keys, config.walSegmentSize())
 	if err != nil {
 		return nil, err
 	}
 	// 새로운 MemTable 생성 및 atomic.Pointer에 저장.
 	mt := NewMemTable(config.MemTableSize)
+	mt.merge = config.MergeOperator
 	lsm := &LSMTree{
 		config:  config,
 		wal:     wal,
@@ -51,12 +85,26 @@ func NewLSMTree(config Config) (*LSMTree, error) {
 		stopCh:  make(chan struct{}),
 	}
 	lsm.memTable.Store(mt)
+	lsm.keys.Store(keys)
 
 	// 기존 SSTable 로딩 및 WAL 복구는 그대로...
 	if err := lsm.loadSSTables(); err != nil {
+		wal.Close()
 		return nil, err
 	}
-	if err := RecoverFromWAL(walPath, mt); err != nil {
+	start := time.Now()
+	rp, err := recoverWAL(walPath, mt, keys, config.RecoveryMode == "best_effort")
+	for _, err := range rp.corrupt {
+		config.logger().Warn("Corrupted WAL record", "adapter", "lsmtree", "mode", config.RecoveryMode, "error", err)
+	}
+	lsm.recovery = ports.RecoveryReport{
+		WALBytes:   rp.bytes,
+		WALRecords: rp.records,
+		Corrupted:  int64(len(rp.corrupt)),
+		Duration:   time.Since(start),
+	}
+	if err != nil {
+		wal.Close()
 		return nil, err
 	}
 
@@ -65,15 +113,23 @@ func NewLSMTree(config Config) (*LSMTree, error) {
 		return nil, err
 	}
 	lsm.compactor = compactor
+	// 이전 키와 함께 열었으면 남은 테이블을 새 키로 다시 써서 중단된 Rekey를 마침
+	if keys != nil && keys.Previous() != nil {
+		if err := lsm.finishRekey(); err != nil {
+			wal.Close()
+			return nil, err
+		}
+	}
 	lsm.wg.Add(1)
 	go func() {
 		defer lsm.wg.Done()
 		compactor.Run(lsm.stopCh)
 	}()
+	lsm.startBloomRebuild()
 	return lsm, nil
 }
 
-// loadSSTables loads existing SSTable files from the data directory into level0.
+// loadSSTables loads existing SSTable files into the levels recorded in their names.
 func (l *LSMTree) loadSSTables() error {
 	files, err := os.ReadDir(l.config.FilePath)
 	if err != nil {
@@ -83,103 +139,225 @@ func (l *LSMTree) loadSSTables() error {
 		if file.IsDir() {
 			continue
 		}
+		if strings.HasSuffix(file.Name(), ".sst.tmp") {
+			// rename 전에 멈춘 SSTable 쓰기가 남긴 임시 파일
+			os.Remove(filepath.Join(l.config.FilePath, file.Name()))
+			continue
+		}
+		if strings.HasSuffix(file.Name(), ".sst"+bloomSidecarSuffix) {
+			// SSTable이 없는 사이드카는 컴팩션 중 재구축이 남긴 것이므로 지움.
+			sstPath := filepath.Join(l.config.FilePath, strings.TrimSuffix(file.Name(), bloomSidecarSuffix))
+			if _, err := os.Stat(sstPath); os.IsNotExist(err) {
+				os.Remove(filepath.Join(l.config.FilePath, file.Name()))
+			}
+			continue
+		}
 		if filepath.Ext(file.Name()) == ".sst" {
+			_, level, _ := parseSSTableName(file.Name())
 			sstPath := filepath.Join(l.config.FilePath, file.Name())
-			sst, err := OpenSSTable(sstPath, l.config.UseBloomFilter)
+			sst, err := openSSTable(sstPath, l.config.UseBloomFilter, l.config.KeyDict, l.keys.Load())
 			if err != nil {
 				return err
 			}
-			l.levels[0] = append(l.levels[0], sst)
+			if l.config.UseBloomFilter && !sst.HasBloom() {
+				l.missingBlooms = append(l.missingBlooms, sst)
+			}
+			for len(l.levels) <= level {
+				l.levels = append(l.levels, nil)
+			}
+			l.levels[level] = append(l.levels[level], sst)
 		}
 	}
-	// Sort level0 by minKey.
+	// level0은 생성 순서, 그 외 레벨은 minKey 순으로 정렬.
 	sort.Slice(l.levels[0], func(i, j int) bool {
-		return l.levels[0][i].minKey < l.levels[0][j].minKey
+		return l.levels[0][i].seq < l.levels[0][j].seq
 	})
+	for _, level := range l.levels[1:] {
+		sort.Slice(level, func(i, j int) bool {
+			return level[i].minKey < level[j].minKey
+		})
+	}
 	return nil
 }
 
 // Insert adds or updates a key-value pair in the LSM Tree.
 func (l *LSMTree) Insert(key string, value string) error {
-	entry := WalEntry{Op: 0x00, Key: key, Value: value}
-	if err := l.wal.Append(entry); err != nil {
-		return err
+	if value == tombstone || len(value) > maxSSTValue || isMergeRecord(value) {
+		return ErrInvalidValue
 	}
+	l.config.KeyDict.Observe(key)
+	entry := WalEntry{Op: 0x00, Key: key, Value: value}
+	return l.write(entry, func(mt *MemTable) error { return mt.Insert(key, value) })
+}
 
-	// 읽어온 memTable에 대해 삽입 시도.
-	mt := l.memTable.Load()
-	l.mu.RLock()
-	err := mt.Insert(key, value)
-	l.mu.RUnlock()
-	if err == nil {
-		l.metrics.IncWrites()
-		return nil
-	}
-	if !errors.Is(err, ErrMemTableFull) {
+// write logs entry and applies it to the memTable, flushing the memTable first if it is full.
+func (l *LSMTree) write(entry WalEntry, apply func(mt *MemTable) error) error {
+	if err := l.stallWrite(); err != nil {
 		return err
 	}
-	// memTable이 가득 찼다면 flush 전에, 먼저 현재 memTable을 atomic하게 교체.
-	if err := l.flushMemTable(); err != nil {
-		return err
+	err := l.logAndApply(entry, apply)
+	if errors.Is(err, ErrMemTableFull) {
+		// memTable이 가득 찼다면 flush로 새 memTable로 교체한 뒤 다시 기록.
+		if err := l.flushMemTable(); err != nil {
+			return err
+		}
+		err = l.logAndApply(entry, apply)
 	}
-	// flush 후 새 memTable에 다시 삽입.
-	mt = l.memTable.Load()
-	l.mu.RLock()
-	err = mt.Insert(key, value)
-	l.mu.RUnlock()
 	if err != nil {
 		return err
 	}
+	l.cache.Remove(entry.Key) // SSTable에서 읽어 둔 이전 값 제거
 	l.metrics.IncWrites()
 	return nil
 }
 
+// logAndApply logs entry and applies it to the memTable under l.mu, so that a flush, which
+// swaps the memTable and rotates the WAL under l.mu, finds every entry of the old memTable
+// in the segments it removes. An entry the full memTable rejected is logged again by the
+// retry, in a segment of the new memTable.
+func (l *LSMTree) logAndApply(entry WalEntry, apply func(mt *MemTable) error) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if err := l.appendWAL(entry); err != nil {
+		return err
+	}
+	return apply(l.memTable.Load())
+}
+
 // Get retrieves the value associated with the given key.
 func (l *LSMTree) Get(key string) (string, error) {
+	return l.GetCost(key, nil)
+}
+
+// GetCost is Get adding the SSTables, blocks and cache hits of the lookup to cost, if not nil.
+// The same numbers are accumulated in the read amplification of Stats.
+func (l *LSMTree) GetCost(key string, cost *ports.ReadCost) (string, error) {
+	var c ports.ReadCost
+	value, err := l.get(key, &c)
+	l.metrics.AddReadCost(c)
+	if cost != nil {
+		cost.Add(c)
+	}
+	return value, err
+}
+
+func (l *LSMTree) get(key string, cost *ports.ReadCost) (string, error) {
+	var records []string // 값 위에 쌓인 머지 레코드, 최신 순 (merge.go 참고)
+
 	// Check memTable.
 	mt := l.memTable.Load()
-	if value, ok := mt.Get(key); ok {
-		l.metrics.IncCacheHit()
-		return value, nil
+	if value, ok := mt.Entry(key); ok {
+		cost.CacheHits++
+		if value == tombstone {
+			return "", ErrKeyNotFound
+		}
+		if !isMergeRecord(value) {
+			l.metrics.IncCacheHit()
+			return value, nil
+		}
+		records = append(records, value)
 	}
 
 	// Check cache.
 	if value, ok := l.cache.Get(key); ok {
+		cost.CacheHits++
 		l.metrics.IncCacheHit()
-		return value, nil
+		return l.liveValue(key, records, value)
 	}
 
 	// Search SSTables across levels.
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	for _, level := range l.levels {
-		// Assume each level is sorted by minKey.
+	// level0 테이블은 키 범위가 겹칠 수 있으므로 최신 테이블부터 모두 확인.
+	level0 := l.levels[0]
+	for i := len(level0) - 1; i >= 0; i-- {
+		if val, ok := l.lookupSSTable(level0[i], key, cost); ok {
+			if !isMergeRecord(val) {
+				return l.liveValue(key, records, val)
+			}
+			records = append(records, val)
+		}
+	}
+	for _, level := range l.levels[1:] {
+		// level1 이상은 minKey 순으로 정렬되어 있고 키 범위가 겹치지 않음.
 		idx := sort.Search(len(level), func(i int) bool {
 			return level[i].maxKey >= key
 		})
-		if idx < len(level) && level[idx].minKey <= key {
-			if val, found := level[idx].Get(key); found {
-				l.cache.Put(key, val)
-				l.metrics.IncReads()
-				return val, nil
+		if idx < len(level) {
+			if val, ok := l.lookupSSTable(level[idx], key, cost); ok {
+				if !isMergeRecord(val) {
+					return l.liveValue(key, records, val)
+				}
+				records = append(records, val)
 			}
 		}
 	}
+	if len(records) > 0 {
+		return foldMerge(l.config.MergeOperator, key, records, nil)
+	}
 	return "", ErrKeyNotFound
 }
 
+// liveValue returns the value of key given the newest value or tombstone found for it and
+// the merge records above it, newest first; a tombstone hides older values.
+func (l *LSMTree) liveValue(key string, records []string, val string) (string, error) {
+	var base *string
+	if val != tombstone {
+		base = &val
+	}
+	if len(records) > 0 {
+		return foldMerge(l.config.MergeOperator, key, records, base)
+	}
+	if base == nil {
+		return "", ErrKeyNotFound
+	}
+	return val, nil
+}
+
+// lookupSSTable reads key from sst if its range covers the key. Tombstones and merge
+// records are returned as found but not cached. Caller holds l.mu.
+func (l *LSMTree) lookupSSTable(sst *SSTable, key string, cost *ports.ReadCost) (string, bool) {
+	if key < sst.minKey || key > sst.maxKey || sst.Quarantined() {
+		return "", false
+	}
+	if !sst.mayContain(key) {
+		cost.BloomSkips++
+		return "", false
+	}
+	cost.SSTables++
+	val, found, err := sst.lookup(key, cost)
+	if err != nil {
+		// 손상된 SSTable은 격리하고 더 오래된 데이터에서 계속 검색.
+		l.metrics.IncCorruptions()
+		sst.Quarantine()
+		return "", false
+	}
+	if !found {
+		return "", false
+	}
+	if val == tombstone || isMergeRecord(val) {
+		return val, true
+	}
+	l.cache.Put(key, val)
+	l.metrics.IncReads()
+	return val, true
+}
+
 // Delete marks a key as deleted using a tombstone.
 func (l *LSMTree) Delete(key string) error {
 	entry := WalEntry{Op: 0x01, Key: key, Value: ""}
-	if err := l.wal.Append(entry); err != nil {
-		return err
-	}
-	mt := l.memTable.Load()
-	if err := mt.Delete(key); err != nil {
-		return err
+	return l.write(entry, func(mt *MemTable) error { return mt.Delete(key) })
+}
+
+// appendWAL logs entry as Config.Durability requires.
+func (l *LSMTree) appendWAL(entry WalEntry) error {
+	switch d := l.config.durability(); {
+	case d == ports.DurabilityNone:
+		return nil
+	case d.Fsync():
+		return l.wal.AppendSync(entry)
 	}
-	l.metrics.IncWrites()
-	return nil
+	return l.wal.Append(entry)
 }
 
 // flushMemTable atomically flushes the current memTable.
@@ -195,33 +373,54 @@ func (l *LSMTree) flushMemTable() error {
 		l.mu.Unlock()
 		return nil
 	}
-	data := oldMT.Swap()
+	// 이후의 쓰기는 새 세그먼트에 기록되므로, SSTable을 쓴 뒤 그 앞의 세그먼트를 지울 수 있음.
+	segment, err := l.wal.Rotate()
+	if err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	data := oldMT.swapSorted() // 이미 키 순서이므로 정렬 없이 기록
 	// 새로운 memTable 생성.
 	newMT := NewMemTable(l.config.MemTableSize)
+	newMT.merge = l.config.MergeOperator
 	l.memTable.Store(newMT)
 	// SSTable 생성.
 	sstPath := filepath.Join(l.config.FilePath, fmt.Sprintf("db.sst.%d.sst", time.Now().UnixNano()))
-	sst, err := CreateSSTable(sstPath, data, l.config.CompressionType, l.config.UseBloomFilter)
+	sst, err := createSortedSSTable(sstPath, data, l.config.CompressionType, l.config.UseBloomFilter, l.config.KeyDict, l.keys.Load())
 	if err != nil {
 		l.mu.Unlock()
 		return err
 	}
-	l.levels[0] = append(l.levels[0], sst)
-	sort.Slice(l.levels[0], func(i, j int) bool {
-		return l.levels[0][i].minKey < l.levels[0][j].minKey
-	})
+	l.levels[0] = append(l.levels[0], sst) // level0은 생성 순서를 유지
+	// 쓰기 전에 시작한 읽기가 쓰기의 Remove 뒤에 이전 SSTable 값을 캐시에 넣었을 수 있음. memTable이
+	// 가리던 그 값이 flush 뒤에 보이지 않도록 지움. 읽기는 l.mu를 잡고 캐시를 채우므로 경합 없음.
+	for _, e := range data {
+		l.cache.Remove(e.key)
+	}
 	l.mu.Unlock()
 
-	// WAL 처리는 락 해제 후 진행.
-	l.wal.Flush()
-	if err := l.wal.Reset(); err != nil {
+	if err := l.wal.RemoveSegmentsBefore(segment); err != nil {
 		return err
 	}
+	l.config.Events.Publish(events.Event{
+		Type:    events.FlushCompleted,
+		Source:  "lsmtree",
+		Details: map[string]interface{}{"sstable": sstPath, "entries": len(data)},
+	})
 	return nil
 }
 
 // ForceCompaction triggers manual compaction.
 func (l *LSMTree) ForceCompaction() error {
+	return l.CompactContext(context.Background())
+}
+
+// CompactContext is ForceCompaction that stops before the next merge once ctx is done,
+// returning ctx.Err(). Merges already done are kept.
+func (l *LSMTree) CompactContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	// Flush memTable if not empty.
 	mt := l.memTable.Load()
 	if mt.Size() > 0 {
@@ -229,7 +428,7 @@ func (l *LSMTree) ForceCompaction() error {
 			return err
 		}
 	}
-	return l.compactor.Compact()
+	return l.compactor.compact(ctx, true)
 }
 
 // Stats returns current statistics of the LSM Tree.
@@ -239,16 +438,93 @@ func (l *LSMTree) Stats() map[string]interface{} {
 	mt := l.memTable.Load()
 	stats := make(map[string]interface{})
 	stats["memtable_size"] = mt.Size()
-	totalSSTables := 0
+	totalSSTables, quarantined := 0, 0
 	for _, level := range l.levels {
 		totalSSTables += len(level)
+		for _, sst := range level {
+			if sst.Quarantined() {
+				quarantined++
+			}
+		}
 	}
 	stats["sstable_count"] = totalSSTables
+	levelCounts := make([]int, len(l.levels))
+	for i, level := range l.levels {
+		levelCounts[i] = len(level)
+	}
+	stats["levels"] = levelCounts
+	stats["compaction_strategy"] = l.config.CompactionStrategy
+	stats["compaction_debt"] = l.compactionDebt()
+	stats["compaction_rate_limit"] = int64(l.compactor.limiter.Rate())
+	stats["compaction_throttled"] = time.Duration(l.compactor.throttled.Load())
+	stats["write_stalls"] = l.metrics.WriteStalls()
+	stats["quarantined_sstables"] = quarantined
+	stats["bloom_rebuilds_pending"] = l.bloomPending.Load()
+	stats["corruptions"] = atomic.LoadInt64(&l.metrics.Corruptions)
 	stats["writes"] = l.metrics.Writes
 	stats["reads"] = l.metrics.Reads
+	stats["read_amplification"] = l.metrics.ReadAmplification()
+	stats["cache"] = l.cache.Stats()
+	stats["wal"] = l.wal.Stats()
+	if l.config.KeyDict != nil {
+		stats["key_dict"] = l.config.KeyDict.Stats()
+	}
 	return stats
 }
 
+// DiskSize returns the size of the SSTables and the WAL. Bloom filter sidecars are not counted.
+func (l *LSMTree) DiskSize() int64 {
+	l.mu.RLock()
+	var size int64
+	for _, level := range l.levels {
+		for _, sst := range level {
+			size += sst.size
+		}
+	}
+	l.mu.RUnlock()
+	return size + l.wal.Size()
+}
+
+// RecoveryDebt returns the size of the WAL, which is replayed into the memtable on restart.
+func (l *LSMTree) RecoveryDebt() int64 {
+	return l.wal.Size()
+}
+
+// RecoveryReport returns the WAL replay done at open and the number of SSTables quarantined
+// since, whose entries are no longer read.
+func (l *LSMTree) RecoveryReport() ports.RecoveryReport {
+	report := l.recovery
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, level := range l.levels {
+		for _, sst := range level {
+			if sst.Quarantined() {
+				report.Quarantined++
+			}
+		}
+	}
+	return report
+}
+
+// StorageStatus reports the value cache counters and the time of the last compaction that
+// merged tables.
+func (l *LSMTree) StorageStatus() ports.StorageStatus {
+	status := ports.StorageStatus{CacheHits: l.cache.hits.Load(), CacheMisses: l.cache.misses.Load()}
+	if t := l.compactedAt.Load(); t != 0 {
+		status.LastCompaction = time.Unix(0, t)
+	}
+	return status
+}
+
+// EntryLimits reports the 16-bit key and value lengths of the WAL and SSTable formats.
+// Keys lose the top bit to keydict.EncodedFlag and values the largest length to tombstones.
+func (l *LSMTree) EntryLimits() ports.EntryLimits {
+	return ports.EntryLimits{
+		MaxKey:   int(keydict.EncodedFlag) - 1,
+		MaxValue: maxSSTValue,
+	}
+}
+
 // Close gracefully shuts down the LSM Tree.
 func (l *LSMTree) Close() error {
 	close(l.stopCh)
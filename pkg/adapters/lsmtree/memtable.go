@@ -5,103 +5,124 @@ import (
 	"sync/atomic"
 )
 
+// tombstone is the in-memory value of a deleted key. It is kept through flushes and
+// compactions (as a marker, see block.go) until no older value of the key can remain;
+// LSMTree.Insert rejects it as a user value.
 const tombstone = "<TOMBSTONE>"
 
-// MemTable represents the in-memory table.
+// MemTable represents the in-memory table. Entries are kept in key order in a skip list
+// (see skiplist.go), so flushes and scans read them without sorting.
 type MemTable struct {
-	table   *sync.Map
-	size    int64      // 이제 int64로 선언 (atomic으로 업데이트)
-	maxSize int64      // int64로 변경 (바이트 단위)
-	mu      sync.Mutex // 조건 검사와 테이블 업데이트를 위한 락
+	table   atomic.Pointer[skipList]
+	size    atomic.Int64  // 현재 크기 (32비트에서도 정렬되도록 atomic 타입 사용)
+	maxSize int64         // int64로 변경 (바이트 단위)
+	mu      sync.Mutex    // 조건 검사와 테이블 업데이트를 위한 락
+	merge   MergeOperator // Folds merge operands (nil = Merge fails, see merge.go)
 }
 
 // NewMemTable creates a new MemTable with the given maximum size.
 func NewMemTable(maxSize int) *MemTable {
-	return &MemTable{
-		table:   new(sync.Map),
-		maxSize: int64(maxSize),
-	}
+	m := &MemTable{maxSize: int64(maxSize)}
+	m.table.Store(newSkipList())
+	return m
 }
 
-// Insert inserts or updates a key-value pair atomically.
+// Insert inserts or updates a key-value pair atomically. An empty memTable takes any entry,
+// so one larger than the memTable is flushed on its own instead of never fitting.
 func (m *MemTable) Insert(key, value string) error {
 	addSize := int64(len(key) + len(value))
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	currentSize := atomic.LoadInt64(&m.size)
-	if currentSize+addSize > m.maxSize {
+	currentSize := m.size.Load()
+	if currentSize > 0 && currentSize+addSize > m.maxSize {
 		return ErrMemTableFull
 	}
-	m.table.Store(key, value)
-	atomic.AddInt64(&m.size, addSize)
+	m.table.Load().put(key, value)
+	m.size.Add(addSize)
 	return nil
 }
 
 // Get retrieves a value by key.
 func (m *MemTable) Get(key string) (string, bool) {
-	v, ok := m.table.Load(key)
-	if !ok {
-		return "", false
-	}
-	val := v.(string)
-	if val == tombstone {
+	val, ok := m.table.Load().get(key)
+	if !ok || val == tombstone {
 		return "", false
 	}
 	return val, true
 }
 
-// Delete marks a key as deleted.
+// Entry returns the value stored for key, which is tombstone if the key was deleted.
+func (m *MemTable) Entry(key string) (string, bool) {
+	return m.table.Load().get(key)
+}
+
+// Delete marks a key as deleted. The tombstone takes space like an entry so that a
+// memTable holding only deletes is still flushed.
 func (m *MemTable) Delete(key string) error {
-	m.table.Store(key, tombstone)
+	addSize := int64(len(key))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.size.Load()+addSize > m.maxSize {
+		return ErrMemTableFull
+	}
+	m.table.Load().put(key, tombstone)
+	m.size.Add(addSize)
 	return nil
 }
 
 // Dump returns all key-value pairs for non-tombstoned entries.
 func (m *MemTable) Dump() map[string]string {
 	data := make(map[string]string)
-	m.table.Range(func(k, v interface{}) bool {
-		key := k.(string)
-		value := v.(string)
-		if value == tombstone {
-			return true
+	m.table.Load().ascend("", "", func(key, value string) bool {
+		if value != tombstone {
+			data[key] = value
 		}
-		data[key] = value
 		return true
 	})
 	return data
 }
 
+// Ascend calls fn for the entries with keys in [start, end) in key order, including
+// tombstones, until fn returns false. An empty end means no upper bound.
+func (m *MemTable) Ascend(start, end string, fn func(key, value string) bool) {
+	m.table.Load().ascend(start, end, fn)
+}
+
 // Reset clears the memTable.
 func (m *MemTable) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.table = new(sync.Map)
-	atomic.StoreInt64(&m.size, 0)
+	m.table.Store(newSkipList())
+	m.size.Store(0)
 }
 
 // Size returns the current size.
 func (m *MemTable) Size() int64 {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return atomic.LoadInt64(&m.size)
+	return m.size.Load()
 }
 
-// Swap atomically swaps the current memTable with a new one and returns a snapshot of the old data.
+// Swap atomically swaps the current memTable with a new one and returns a snapshot of the
+// old data, including tombstones.
 func (m *MemTable) Swap() map[string]string {
+	data := make(map[string]string)
+	for _, e := range m.swapSorted() {
+		data[e.key] = e.value
+	}
+	return data
+}
+
+// swapSorted is Swap returning the old entries in key order.
+func (m *MemTable) swapSorted() []sstEntry {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	// Get snapshot from the current table.
-	data := make(map[string]string)
-	m.table.Range(func(k, v interface{}) bool {
-		key := k.(string)
-		value := v.(string)
-		if value != tombstone {
-			data[key] = value
-		}
+	old := m.table.Swap(newSkipList())
+	m.size.Store(0)
+	entries := make([]sstEntry, 0, old.count.Load())
+	old.ascend("", "", func(key, value string) bool {
+		entries = append(entries, sstEntry{key, value})
 		return true
 	})
-	// Swap in a new table and reset size.
-	m.table = new(sync.Map)
-	atomic.StoreInt64(&m.size, 0)
-	return data
+	return entries
 }
@@ -0,0 +1,230 @@
+package lsmtree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 머지 연산자: Config.MergeOperator를 주면 Merge(key, operand)는 현재 값을 읽지 않고 피연산자만
+// WAL과 memTable에 기록합니다. 카운터 증가나 리스트 추가처럼 읽고-고쳐-쓰기가 필요한 갱신을 읽기 없이
+// 받아들이므로, 같은 키에 몰리는 쓰기도 조회 비용 없이 처리됩니다.
+//
+// 피연산자는 머지 레코드(mergeMarker로 시작하는 값)로 쌓였다가 세 곳에서 접힙니다.
+//   - memTable: 키의 값이나 tombstone이 이미 memTable에 있으면 바로 FullMerge로 값을 만들고,
+//     머지 레코드가 있으면 피연산자를 덧붙입니다 (PartialMerger면 하나로 합칩니다).
+//   - 읽기(Get, Scan): 가장 최신 소스부터 값이나 tombstone을 만날 때까지 머지 레코드를 모아
+//     오래된 순서로 FullMerge합니다. 머지 레코드는 캐시에 넣지 않습니다.
+//   - 컴팩션: 같은 키의 레코드를 합치고, 더 오래된 값 위의 레코드는 값으로 접습니다. 더 오래된
+//     값이 남아 있을 수 없는 키의 레코드는 기존 값 없이 접어 일반 값으로 씁니다.
+//
+// 머지 레코드는 일반 값과 같은 길이 제한을 받으므로, PartialMerger를 구현하지 않는 연산자는 접히기
+// 전에 피연산자가 너무 많이 쌓이면 Merge가 ErrInvalidValue로 실패합니다. 연산자는 저장소를 열 때마다
+// 같은 것을 주어야 하며, 머지 레코드가 남은 저장소를 연산자 없이 열면 그 키의 읽기와 컴팩션이
+// ErrNoMergeOperator로 실패합니다.
+
+// ErrNoMergeOperator is returned by Merge, and by reads of keys holding merge operands, when
+// the tree has no Config.MergeOperator.
+var ErrNoMergeOperator = errors.New("no merge operator configured")
+
+// MergeOperator folds merge operands into the value of a key (see Config.MergeOperator).
+type MergeOperator interface {
+	// FullMerge applies operands, oldest first, to existing (nil if the key has no value)
+	// and returns the new value.
+	FullMerge(key string, existing *string, operands []string) (string, error)
+}
+
+// PartialMerger is implemented by merge operators that can combine consecutive operands
+// into one without the existing value, e.g. additions. Operands are then kept combined
+// instead of piling up until they are folded into a value.
+type PartialMerger interface {
+	// PartialMerge combines operands, oldest first, into one operand, or returns false if
+	// they can't be combined.
+	PartialMerge(key string, operands []string) (string, bool)
+}
+
+// CounterMerge is a MergeOperator adding decimal int64 operands to a decimal value (0 if
+// the key has none).
+type CounterMerge struct{}
+
+// FullMerge adds the operands to existing.
+func (CounterMerge) FullMerge(key string, existing *string, operands []string) (string, error) {
+	var sum int64
+	if existing != nil {
+		n, err := strconv.ParseInt(*existing, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("counter %s holds %q: %w", key, *existing, ErrInvalidValue)
+		}
+		sum = n
+	}
+	for _, op := range operands {
+		n, err := strconv.ParseInt(op, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("counter %s operand %q: %w", key, op, ErrInvalidValue)
+		}
+		sum += n
+	}
+	return strconv.FormatInt(sum, 10), nil
+}
+
+// PartialMerge adds the operands.
+func (c CounterMerge) PartialMerge(key string, operands []string) (string, bool) {
+	sum, err := c.FullMerge(key, nil, operands)
+	return sum, err == nil
+}
+
+// AppendMerge is a MergeOperator appending operands to a value, each after Separator.
+type AppendMerge struct {
+	Separator string
+}
+
+// FullMerge appends the operands to existing.
+func (a AppendMerge) FullMerge(key string, existing *string, operands []string) (string, error) {
+	if existing == nil {
+		return strings.Join(operands, a.Separator), nil
+	}
+	if len(operands) == 0 {
+		return *existing, nil
+	}
+	return *existing + a.Separator + strings.Join(operands, a.Separator), nil
+}
+
+// PartialMerge joins the operands.
+func (a AppendMerge) PartialMerge(key string, operands []string) (string, bool) {
+	return strings.Join(operands, a.Separator), true
+}
+
+var (
+	_ PartialMerger = CounterMerge{}
+	_ PartialMerger = AppendMerge{}
+)
+
+// mergeMarker starts a merge record. Insert rejects values starting with it.
+const mergeMarker = "\x00<MERGE>"
+
+func isMergeRecord(value string) bool {
+	return strings.HasPrefix(value, mergeMarker)
+}
+
+// encodeMergeRecord returns the merge record of operands, oldest first.
+func encodeMergeRecord(operands []string) string {
+	buf := []byte(mergeMarker)
+	for _, op := range operands {
+		buf = binary.AppendUvarint(buf, uint64(len(op)))
+		buf = append(buf, op...)
+	}
+	return string(buf)
+}
+
+// decodeMergeRecord returns the operands of a merge record, oldest first.
+func decodeMergeRecord(record string) ([]string, error) {
+	rest := []byte(strings.TrimPrefix(record, mergeMarker))
+	var operands []string
+	for len(rest) > 0 {
+		n, size := binary.Uvarint(rest)
+		if size <= 0 || n > uint64(len(rest)-size) {
+			return nil, fmt.Errorf("%w: truncated merge record", ErrInvalidValue)
+		}
+		rest = rest[size:]
+		operands = append(operands, string(rest[:n]))
+		rest = rest[n:]
+	}
+	return operands, nil
+}
+
+// stackMerge returns the entry of key replacing older with the newer merge record: older's
+// value or tombstone folded with the operands of newer, or the operands of both records.
+func stackMerge(op MergeOperator, key, older, newer string) (string, error) {
+	if op == nil {
+		return "", ErrNoMergeOperator
+	}
+	operands, err := decodeMergeRecord(newer)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case isMergeRecord(older):
+		prev, err := decodeMergeRecord(older)
+		if err != nil {
+			return "", err
+		}
+		return combineOperands(op, key, append(prev, operands...)), nil
+	case older == tombstone:
+		return op.FullMerge(key, nil, operands)
+	default:
+		return op.FullMerge(key, &older, operands)
+	}
+}
+
+// combineOperands returns the merge record of operands, combined into one operand if op is
+// a PartialMerger.
+func combineOperands(op MergeOperator, key string, operands []string) string {
+	if pm, ok := op.(PartialMerger); ok && len(operands) > 1 {
+		if combined, ok := pm.PartialMerge(key, operands); ok {
+			operands = []string{combined}
+		}
+	}
+	return encodeMergeRecord(operands)
+}
+
+// foldMerge applies the merge records of key, newest first, to base (nil if the key has no
+// older value).
+func foldMerge(op MergeOperator, key string, records []string, base *string) (string, error) {
+	if op == nil {
+		return "", ErrNoMergeOperator
+	}
+	var operands []string
+	for i := len(records) - 1; i >= 0; i-- {
+		ops, err := decodeMergeRecord(records[i])
+		if err != nil {
+			return "", err
+		}
+		operands = append(operands, ops...)
+	}
+	return op.FullMerge(key, base, operands)
+}
+
+// Merge records operand for key, to be folded into its value by Config.MergeOperator on
+// reads and compactions.
+func (l *LSMTree) Merge(key, operand string) error {
+	if l.config.MergeOperator == nil {
+		return ErrNoMergeOperator
+	}
+	if len(operand) > maxSSTValue {
+		return ErrInvalidValue
+	}
+	l.config.KeyDict.Observe(key)
+	entry := WalEntry{Op: walOpMerge, Key: key, Value: operand}
+	return l.write(entry, func(mt *MemTable) error { return mt.Merge(key, operand) })
+}
+
+// Merge applies a merge operand to the entry of key: a value or tombstone is folded with it
+// right away, a merge record gets it appended. Without a merge operator it fails with
+// ErrNoMergeOperator.
+func (m *MemTable) Merge(key, operand string) error {
+	if m.merge == nil {
+		return ErrNoMergeOperator
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	table := m.table.Load()
+	value := encodeMergeRecord([]string{operand})
+	if cur, ok := table.get(key); ok {
+		var err error
+		if value, err = stackMerge(m.merge, key, cur, value); err != nil {
+			return err
+		}
+	}
+	if len(value) > maxSSTValue {
+		return fmt.Errorf("%w: merge result of %s exceeds %d bytes", ErrInvalidValue, key, maxSSTValue)
+	}
+	addSize := int64(len(key) + len(value))
+	if m.size.Load()+addSize > m.maxSize {
+		return ErrMemTableFull
+	}
+	table.put(key, value)
+	m.size.Add(addSize)
+	return nil
+}
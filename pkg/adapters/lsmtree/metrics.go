@@ -1,12 +1,28 @@
 // 개선된 Metrics 구현 using atomic
 package lsmtree
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
 
 type Metrics struct {
 	Writes    int64
 	Reads     int64
 	CacheHits int64
+	// Corruptions counts SSTable reads that failed verification.
+	Corruptions int64
+	// Gets와 나머지 필드는 Get 한 번이 거친 구조의 누계입니다 (읽기 증폭).
+	Gets          int64
+	SSTableProbes int64
+	BloomSkips    int64
+	BlocksRead    int64
+	// 쓰기 지연 (stall.go 참고)
+	WriteSlowdowns int64
+	WriteStops     int64
+	WriteStallTime int64 // Nanoseconds
 }
 
 func NewMetrics() *Metrics {
@@ -24,3 +40,55 @@ func (m *Metrics) IncReads() {
 func (m *Metrics) IncCacheHit() {
 	atomic.AddInt64(&m.CacheHits, 1)
 }
+
+func (m *Metrics) IncCorruptions() {
+	atomic.AddInt64(&m.Corruptions, 1)
+}
+
+// AddReadCost accumulates the read cost of one Get.
+func (m *Metrics) AddReadCost(c ports.ReadCost) {
+	atomic.AddInt64(&m.Gets, 1)
+	atomic.AddInt64(&m.SSTableProbes, c.SSTables)
+	atomic.AddInt64(&m.BloomSkips, c.BloomSkips)
+	atomic.AddInt64(&m.BlocksRead, c.Blocks)
+}
+
+// ReadAmplification returns the number of Gets and the SSTables probed, SSTables skipped by
+// their bloom filter and blocks read per Get.
+func (m *Metrics) ReadAmplification() map[string]interface{} {
+	gets := atomic.LoadInt64(&m.Gets)
+	perGet := func(total *int64) float64 {
+		if gets == 0 {
+			return 0
+		}
+		return float64(atomic.LoadInt64(total)) / float64(gets)
+	}
+	return map[string]interface{}{
+		"gets":                gets,
+		"sstables_per_get":    perGet(&m.SSTableProbes),
+		"bloom_skips_per_get": perGet(&m.BloomSkips),
+		"blocks_per_get":      perGet(&m.BlocksRead),
+	}
+}
+
+func (m *Metrics) IncWriteSlowdowns() {
+	atomic.AddInt64(&m.WriteSlowdowns, 1)
+}
+
+func (m *Metrics) IncWriteStops() {
+	atomic.AddInt64(&m.WriteStops, 1)
+}
+
+// AddWriteStall accumulates the time a write was delayed or blocked.
+func (m *Metrics) AddWriteStall(d time.Duration) {
+	atomic.AddInt64(&m.WriteStallTime, int64(d))
+}
+
+// WriteStalls returns the writes slowed down and stopped by level0 and the time they waited.
+func (m *Metrics) WriteStalls() map[string]interface{} {
+	return map[string]interface{}{
+		"slowdowns": atomic.LoadInt64(&m.WriteSlowdowns),
+		"stops":     atomic.LoadInt64(&m.WriteStops),
+		"stalled":   time.Duration(atomic.LoadInt64(&m.WriteStallTime)),
+	}
+}
@@ -0,0 +1,97 @@
+package lsmtree
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sukryu/GoLite/pkg/events"
+)
+
+// 사이드카 재구축: 열 때 블룸 사이드카가 없거나 손상된 SSTable은 필터 없이 서비스되며(조회는
+// 정확하지만 키가 없어도 블록을 읽음), 백그라운드 고루틴이 테이블을 하나씩 읽어 필터를 다시 만들고
+// 사이드카를 쓴 뒤 적용합니다. 테이블마다 RebuildProgress 이벤트(done/total)를 발행하고,
+// Stats의 bloom_rebuilds_pending이 남은 수를 보여 줍니다. 재구축 중 컴팩션으로 지워진 테이블은
+// 건너뛰며(그래도 남은 고아 사이드카는 다음 오픈 때 지움), Close는 진행 중인 테이블까지만 기다립니다.
+
+// startBloomRebuild rebuilds the bloom sidecars of the tables opened without one.
+func (l *LSMTree) startBloomRebuild() {
+	tables := l.missingBlooms
+	l.missingBlooms = nil
+	if len(tables) == 0 {
+		return
+	}
+	l.bloomPending.Store(int64(len(tables)))
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		for i, sst := range tables {
+			select {
+			case <-l.stopCh:
+				return
+			default:
+			}
+			err := l.rebuildBloom(sst)
+			l.bloomPending.Add(-1)
+			l.config.Events.Publish(events.Event{
+				Type:   events.RebuildProgress,
+				Source: "lsmtree",
+				Details: map[string]interface{}{
+					"sidecar": bloomSidecarPath(sst.filePath),
+					"done":    i + 1,
+					"total":   len(tables),
+				},
+				Err: err,
+			})
+		}
+	}()
+}
+
+// rebuildBloom reads the keys of sst, writes its bloom sidecar and installs the filter.
+func (l *LSMTree) rebuildBloom(sst *SSTable) error {
+	if sst.Quarantined() || l.compacted(sst) {
+		return nil
+	}
+	var blocks int64
+	src, err := sst.scan("", "", &blocks)
+	if err != nil {
+		if l.compacted(sst) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %v", sst.filePath, err)
+	}
+	defer src.close()
+	var keys []string
+	for src.next() {
+		key, _ := src.entry()
+		keys = append(keys, key)
+	}
+	if err := src.err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", sst.filePath, err)
+	}
+	bf := NewBloomFilter(bloomSize(len(keys)))
+	for _, key := range keys {
+		bf.Add(key)
+	}
+	if err := writeBloomSidecar(sst.filePath, bf); err != nil {
+		return fmt.Errorf("failed to write bloom sidecar: %v", err)
+	}
+	sst.bloom.Store(bf)
+	if l.compacted(sst) {
+		os.Remove(bloomSidecarPath(sst.filePath)) // 컴팩션이 먼저 지웠다면 새로 쓴 사이드카도 정리
+	}
+	return nil
+}
+
+// compacted reports whether sst is no longer part of the tree.
+func (l *LSMTree) compacted(sst *SSTable) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, level := range l.levels {
+		for _, t := range level {
+			if t == sst {
+				return false
+			}
+		}
+	}
+	return true
+}
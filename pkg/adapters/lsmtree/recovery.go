@@ -1,14 +1,68 @@
 package lsmtree
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
 )
 
-// RecoverFromWAL replays the WAL file to restore the memTable.
+// 레코드 체크섬: 평문 WAL 세그먼트는 walCheckedMagic으로 시작하고, 엔트리마다 앞에 CRC32와 길이,
+// 순번을 붙입니다.
+//
+//	crc(u32) + 본문 길이(u32) + 본문(seq(u64) + op(1) + keyLen(u16) + key + valLen(u16) + value)
+//
+// crc는 길이와 본문의 CRC32(IEEE)이고, seq는 WAL을 연 뒤 엔트리마다 1씩 늘어나므로 한 세그먼트
+// 안에서 항상 커집니다. 복구는 crc가 맞지 않거나 순번이 앞 엔트리보다 크지 않은 레코드를 손상으로
+// 보고, Config.RecoveryMode가 strict면 그 앞에서 세그먼트 재생을 멈추고 best_effort면 건너뜁니다.
+// 봉인된 세그먼트는 프레임 단위로 인증되므로 같은 규칙을 프레임에 적용합니다. 재생을 멈춘 곳이나 잘린
+// 끝 뒤의 바이트는 잘라 냅니다. magic이 없는 이전 포맷의 세그먼트는 체크섬 없이 재생합니다.
+
+// walCheckedMagic starts a plaintext WAL segment whose records carry checksums.
+var walCheckedMagic = []byte("GLW2")
+
+// checkedHeaderSize is the size of the crc and length before each checked record.
+const checkedHeaderSize = 8
+
+// walReplay is the result of replaying the WAL at open.
+type walReplay struct {
+	bytes   int64   // Bytes of the segments replayed, up to the last record read
+	records int64   // Entries applied to the memTable
+	corrupt []error // Corrupted records (frames if sealed) replay stopped at or skipped
+}
+
+// RecoverFromWAL replays the segments of the WAL at walPath to restore the memTable,
+// stopping at a corrupted record of a segment.
 func RecoverFromWAL(walPath string, memTable *MemTable) error {
-	file, err := os.Open(walPath)
+	_, err := recoverWAL(walPath, memTable, nil, false)
+	return err
+}
+
+// recoverWAL replays the segments of the WAL in order, whose batches are sealed if keys is
+// not nil, counting the corrupted records it stopped at or, with skip, skipped.
+func recoverWAL(walPath string, memTable *MemTable, keys *pagecrypt.Keyring, skip bool) (walReplay, error) {
+	var rp walReplay
+	segments, err := walSegments(walPath)
+	if err != nil {
+		return rp, err
+	}
+	for _, n := range segments {
+		if err := recoverSegment(segmentPath(walPath, n), memTable, keys, skip, &rp); err != nil {
+			return rp, err
+		}
+	}
+	return rp, nil
+}
+
+// recoverSegment replays one WAL segment file into rp and cuts off the bytes after the last
+// record it replayed or skipped.
+func recoverSegment(path string, memTable *MemTable, keys *pagecrypt.Keyring, skip bool, rp *walReplay) error {
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
@@ -19,13 +73,134 @@ func RecoverFromWAL(walPath string, memTable *MemTable) error {
 	if err != nil {
 		return err
 	}
-	if fi.Size() == 0 {
+	size := fi.Size()
+	if size == 0 {
 		return nil
 	}
 
+	var end int64
+	if keys != nil {
+		if end, err = recoverSealedWAL(file, size, memTable, keys, skip, rp); err != nil {
+			return err
+		}
+	} else {
+		if err := checkWALMagic(file, nil); err != nil {
+			return err
+		}
+		magic := make([]byte, len(walCheckedMagic))
+		if n, _ := file.ReadAt(magic, 0); n < len(magic) || string(magic) != string(walCheckedMagic) {
+			rp.bytes += size
+			return replayWAL(bufio.NewReader(file), memTable, rp) // 체크섬이 없는 이전 포맷
+		}
+		end = replayCheckedWAL(file, size, memTable, skip, rp)
+	}
+	rp.bytes += end
+	if end < size {
+		if err := os.Truncate(path, end); err != nil {
+			return fmt.Errorf("failed to truncate WAL after its last valid record: %v", err)
+		}
+	}
+	return nil
+}
+
+// replayCheckedWAL applies the checked records of a segment of size bytes to memTable and
+// returns the offset where the records read end.
+func replayCheckedWAL(file *os.File, size int64, memTable *MemTable, skip bool, rp *walReplay) int64 {
+	offset := int64(len(walCheckedMagic))
+	r := bufio.NewReader(io.NewSectionReader(file, offset, size-offset))
+	var header [checkedHeaderSize]byte
+	var last uint64
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return offset
+		}
+		n := int64(byteOrder.Uint32(header[4:]))
+		if n > size-offset-checkedHeaderSize {
+			return offset // 잘린 레코드
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return offset
+		}
+		seq, entry, err := decodeCheckedEntry(header, body)
+		if err == nil && seq <= last {
+			err = fmt.Errorf("sequence %d after %d", seq, last)
+		}
+		if err != nil {
+			rp.corrupt = append(rp.corrupt, fmt.Errorf("%w: %s: record at offset %d: %v", ErrWALCorrupted, file.Name(), offset, err))
+			if !skip {
+				return offset
+			}
+		} else {
+			applyWalEntry(memTable, entry)
+			rp.records++
+			last = seq
+		}
+		offset += checkedHeaderSize + n
+	}
+}
+
+// encodeCheckedEntry appends entry numbered seq as a checked record.
+func encodeCheckedEntry(buf *bytes.Buffer, seq uint64, entry WalEntry) {
+	start := buf.Len()
+	buf.Write(make([]byte, checkedHeaderSize)) // crc와 길이 자리
+	binary.Write(buf, byteOrder, seq)
+	encodeWalEntry(buf, entry)
+	record := buf.Bytes()[start:]
+	byteOrder.PutUint32(record[4:], uint32(len(record)-checkedHeaderSize))
+	byteOrder.PutUint32(record, crc32.ChecksumIEEE(record[4:]))
+}
+
+// decodeCheckedEntry checks body against the header of its record and decodes it.
+func decodeCheckedEntry(header [checkedHeaderSize]byte, body []byte) (uint64, WalEntry, error) {
+	var entry WalEntry
+	if crc32.Update(crc32.ChecksumIEEE(header[4:]), crc32.IEEETable, body) != byteOrder.Uint32(header[:]) {
+		return 0, entry, fmt.Errorf("checksum mismatch")
+	}
+	if len(body) < 8+1+2 {
+		return 0, entry, fmt.Errorf("record too short")
+	}
+	seq := byteOrder.Uint64(body)
+	entry.Op, body = body[8], body[9:]
+	var ok bool
+	if entry.Key, body, ok = cutWalField(body); ok {
+		entry.Value, body, ok = cutWalField(body)
+	}
+	if !ok || len(body) != 0 {
+		return 0, entry, fmt.Errorf("malformed record")
+	}
+	return seq, entry, nil
+}
+
+// cutWalField splits a field with a uint16 length off b.
+func cutWalField(b []byte) (string, []byte, bool) {
+	if len(b) < 2 {
+		return "", nil, false
+	}
+	n := int(byteOrder.Uint16(b))
+	if len(b)-2 < n {
+		return "", nil, false
+	}
+	return string(b[2 : 2+n]), b[2+n:], true
+}
+
+// applyWalEntry applies a replayed WAL entry to memTable.
+func applyWalEntry(memTable *MemTable, entry WalEntry) {
+	switch entry.Op {
+	case 0x00:
+		memTable.Insert(entry.Key, entry.Value)
+	case 0x01:
+		memTable.Delete(entry.Key)
+	case walOpMerge:
+		memTable.Merge(entry.Key, entry.Value)
+	}
+}
+
+// replayWAL applies the entries read from r to memTable, stopping at an entry cut short.
+func replayWAL(r io.Reader, memTable *MemTable, rp *walReplay) error {
 	for {
 		var opByte [1]byte
-		_, err := file.Read(opByte[:])
+		_, err := r.Read(opByte[:])
 		if err != nil {
 			// 파일 끝이나 예상치 못한 EOF인 경우 종료
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
@@ -36,14 +211,14 @@ func RecoverFromWAL(walPath string, memTable *MemTable) error {
 		op := opByte[0]
 
 		var keyLen uint16
-		if err := binary.Read(file, binary.BigEndian, &keyLen); err != nil {
+		if err := binary.Read(r, byteOrder, &keyLen); err != nil {
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
 			return err
 		}
 		keyBytes := make([]byte, keyLen)
-		if _, err := io.ReadFull(file, keyBytes); err != nil {
+		if _, err := io.ReadFull(r, keyBytes); err != nil {
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
@@ -52,14 +227,14 @@ func RecoverFromWAL(walPath string, memTable *MemTable) error {
 		key := string(keyBytes)
 
 		var valLen uint16
-		if err := binary.Read(file, binary.BigEndian, &valLen); err != nil {
+		if err := binary.Read(r, byteOrder, &valLen); err != nil {
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
 			return err
 		}
 		valBytes := make([]byte, valLen)
-		if _, err := io.ReadFull(file, valBytes); err != nil {
+		if _, err := io.ReadFull(r, valBytes); err != nil {
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
@@ -67,11 +242,8 @@ func RecoverFromWAL(walPath string, memTable *MemTable) error {
 		}
 		value := string(valBytes)
 
-		if op == 0x00 {
-			memTable.Insert(key, value)
-		} else if op == 0x01 {
-			memTable.Delete(key)
-		}
+		applyWalEntry(memTable, WalEntry{Op: op, Key: key, Value: value})
+		rp.records++
 	}
 	return nil
 }
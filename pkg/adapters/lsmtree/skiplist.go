@@ -0,0 +1,114 @@
+package lsmtree
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// skipList는 memTable의 정렬된 저장소입니다. 쓰기는 MemTable.mu 아래에서 하나씩만 일어나고,
+// 읽기(get, seek, 순회)는 락 없이 진행됩니다. 새 노드는 모든 포인터를 채운 뒤 아래 레벨부터
+// 원자적으로 연결하므로, 읽기는 연결이 끝난 노드만 보거나 아직 안 보이는 노드를 건너뛸 뿐
+// 깨진 상태를 보지 않습니다. 값 갱신도 원자적 포인터 교체입니다. 노드는 지워지지 않고
+// 삭제는 tombstone 값으로 남습니다 (memTable은 flush 때 통째로 교체됨).
+//
+// 키가 정렬되어 있으므로 flush는 정렬 없이 순서대로 SSTable을 쓰고, Scan은 범위만 순회합니다.
+
+const (
+	skipListMaxLevel = 16
+	skipListP        = 4 // 다음 레벨로 올라갈 확률 1/skipListP
+)
+
+type skipNode struct {
+	key   string
+	value atomic.Pointer[string]
+	next  []atomic.Pointer[skipNode]
+}
+
+type skipList struct {
+	head  *skipNode
+	level atomic.Int32 // 사용 중인 최고 레벨
+	count atomic.Int64
+	rng   *rand.Rand // 쓰기 락 아래에서만 사용
+}
+
+func newSkipList() *skipList {
+	s := &skipList{
+		head: &skipNode{next: make([]atomic.Pointer[skipNode], skipListMaxLevel)},
+		rng:  rand.New(rand.NewSource(rand.Int63())),
+	}
+	s.level.Store(1)
+	return s
+}
+
+func (s *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && s.rng.Intn(skipListP) == 0 {
+		level++
+	}
+	return level
+}
+
+// seek returns the first node with a key >= key, or nil. If prev is non-nil it is filled
+// with the last node before key at each level.
+func (s *skipList) seek(key string, prev []*skipNode) *skipNode {
+	x := s.head
+	for i := int(s.level.Load()) - 1; i >= 0; i-- {
+		for {
+			next := x.next[i].Load()
+			if next == nil || next.key >= key {
+				break
+			}
+			x = next
+		}
+		if prev != nil {
+			prev[i] = x
+		}
+	}
+	return x.next[0].Load()
+}
+
+// get returns the value stored for key.
+func (s *skipList) get(key string) (string, bool) {
+	if n := s.seek(key, nil); n != nil && n.key == key {
+		return *n.value.Load(), true
+	}
+	return "", false
+}
+
+// put inserts or replaces the value of key. Callers serialize puts.
+func (s *skipList) put(key, value string) {
+	prev := make([]*skipNode, skipListMaxLevel)
+	if n := s.seek(key, prev); n != nil && n.key == key {
+		n.value.Store(&value)
+		return
+	}
+	level := s.randomLevel()
+	if current := int(s.level.Load()); level > current {
+		for i := current; i < level; i++ {
+			prev[i] = s.head
+		}
+		s.level.Store(int32(level))
+	}
+	n := &skipNode{key: key, next: make([]atomic.Pointer[skipNode], level)}
+	n.value.Store(&value)
+	for i := 0; i < level; i++ {
+		n.next[i].Store(prev[i].next[i].Load())
+	}
+	for i := 0; i < level; i++ {
+		prev[i].next[i].Store(n)
+	}
+	s.count.Add(1)
+}
+
+// ascend calls fn for the keys in [start, end) in order, including tombstones, until fn
+// returns false. An empty end means no upper bound.
+func (s *skipList) ascend(start, end string, fn func(key, value string) bool) {
+	for n := s.seek(start, nil); n != nil; n = n.next[0].Load() {
+		if end != "" && n.key >= end {
+			return
+		}
+		if !fn(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
@@ -3,10 +3,18 @@ package lsmtree
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"hash/crc32"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"sync/atomic"
+
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
 // SSTable represents a Sorted String Table stored on disk.
@@ -15,92 +23,95 @@ type SSTable struct {
 	minKey   string
 	maxKey   string
 	size     int64
-	index    map[string]int64 // Simplified index: key -> file offset.
-	Bloom    *BloomFilter
+	seq      int64            // Creation order; newer tables shadow older ones.
+	index    map[string]int64 // Full key -> offset index of a flat table (nil for block tables)
+	// bloom is nil while a block table's sidecar is missing and being rebuilt (see rebuild.go).
+	bloom    atomic.Pointer[BloomFilter]
 	checksum uint32
+	dict     *keydict.Dictionary // Decodes dictionary-encoded keys (nil = none)
+	codec    byte                // Block compression (see block.go)
+	blocks   []blockHandle       // Sparse block index, the only part of a block table kept in memory
+	keys     *pagecrypt.Keyring  // Opens the sealed blocks of an encrypted table (nil = plaintext)
+	keyID    uint32              // Key an encrypted table is sealed with
+	// quarantined is set once a read detects corruption; the table is then skipped.
+	quarantined atomic.Bool
 }
 
 // CreateSSTable creates a new SSTable file from the given data.
 func CreateSSTable(path string, data map[string]string, compressionType string, useBloom bool) (*SSTable, error) {
-	// Open file for writing.
-	file, err := os.Create(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// Prepare sorted keys.
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	index := make(map[string]int64)
-	var minKey, maxKey string
-	var offset int64 = 0
-	hasher := crc32.NewIEEE()
+	return CreateSSTableWithDict(path, data, compressionType, useBloom, nil)
+}
 
-	// Write entries: [KeyLen][Key][ValLen][Value]
-	for i, key := range keys {
-		value := data[key]
-		if i == 0 {
-			minKey = key
-		}
-		maxKey = key
-		keyLen := uint16(len(key))
-		valLen := uint16(len(value))
-		buf := new(bytes.Buffer)
-		if err := binary.Write(buf, binary.BigEndian, keyLen); err != nil {
-			return nil, err
-		}
-		if _, err := buf.Write([]byte(key)); err != nil {
-			return nil, err
-		}
-		if err := binary.Write(buf, binary.BigEndian, valLen); err != nil {
-			return nil, err
-		}
-		if _, err := buf.Write([]byte(value)); err != nil {
-			return nil, err
-		}
-		entryBytes := buf.Bytes()
-		// Update checksum.
-		hasher.Write(entryBytes)
-		// Write to file.
-		n, err := file.Write(entryBytes)
-		if err != nil {
-			return nil, err
-		}
-		index[key] = offset
-		offset += int64(n)
+// CreateSSTableWithDict creates a new SSTable whose keys are stored as (dictID, suffix)
+// when they start with a prefix known to dict. The in-memory index always holds full keys.
+func CreateSSTableWithDict(path string, data map[string]string, compressionType string, useBloom bool, dict *keydict.Dictionary) (*SSTable, error) {
+	entries := make([]sstEntry, 0, len(data))
+	for k, v := range data {
+		entries = append(entries, sstEntry{k, v})
 	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return createSortedSSTable(path, entries, compressionType, useBloom, dict, nil)
+}
 
-	// Write checksum at the end.
-	checksum := hasher.Sum32()
-	if err := binary.Write(file, binary.BigEndian, checksum); err != nil {
+// createSortedSSTable creates a new SSTable from entries that are already sorted by key,
+// as produced by a memTable flush or a compaction merge. With keys the table is sealed with
+// the current key. The table is written to a temp file and renamed into place once synced,
+// so a crash never leaves a partial table under an .sst name.
+func createSortedSSTable(path string, entries []sstEntry, compressionType string, useBloom bool, dict *keydict.Dictionary, keys *pagecrypt.Keyring) (*SSTable, error) {
+	codec := compressionCodec(compressionType)
+	var blocks []blockHandle
+	var size int64
+	var checksum uint32
+	err := utils.WriteAtomic(path, func(w io.Writer) error {
+		var err error
+		blocks, size, checksum, err = writeBlockSSTable(w, entries, codec, dict, keys)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	sst := &SSTable{
 		filePath: path,
-		minKey:   minKey,
-		maxKey:   maxKey,
-		size:     offset,
-		index:    index,
+		size:     size,
+		seq:      sstableSeq(path),
 		checksum: checksum,
+		dict:     dict,
+		codec:    codec,
+		blocks:   blocks,
+		keys:     keys,
+	}
+	if keys != nil {
+		sst.keyID = keys.Current().ID()
+	}
+	if len(entries) > 0 {
+		sst.minKey, sst.maxKey = entries[0].key, entries[len(entries)-1].key
 	}
 	if useBloom {
-		bf := NewBloomFilter(1000) // Arbitrary capacity.
-		for k := range data {
-			bf.Add(k)
+		bf := NewBloomFilter(bloomSize(len(entries)))
+		for _, e := range entries {
+			bf.Add(e.key)
 		}
-		sst.Bloom = bf
+		if err := writeBloomSidecar(path, bf); err != nil {
+			return nil, err
+		}
+		sst.bloom.Store(bf)
 	}
 	return sst, nil
 }
 
-// OpenSSTable opens an existing SSTable file and loads its index.
+// OpenSSTable opens an existing SSTable file and loads its index. Block tables only load
+// the block index; tables in the older flat format are scanned into a full key index.
 func OpenSSTable(path string, useBloom bool) (*SSTable, error) {
+	return OpenSSTableWithDict(path, useBloom, nil)
+}
+
+// OpenSSTableWithDict opens an SSTable that may contain dictionary-encoded keys.
+func OpenSSTableWithDict(path string, useBloom bool, dict *keydict.Dictionary) (*SSTable, error) {
+	return openSSTable(path, useBloom, dict, nil)
+}
+
+// openSSTable opens an SSTable, which must be sealed with one of keys if keys is not nil.
+func openSSTable(path string, useBloom bool, dict *keydict.Dictionary, keys *pagecrypt.Keyring) (*SSTable, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -113,7 +124,31 @@ func OpenSSTable(path string, useBloom bool) (*SSTable, error) {
 	}
 	fileSize := fi.Size()
 
-	// 마지막 4바이트는 체크섬임.
+	sst, isBlock, err := readBlockIndex(file, fileSize, dict, keys)
+	if err != nil {
+		return nil, err
+	}
+	if isBlock {
+		sst.filePath = path
+		sst.size = fileSize
+		sst.seq = sstableSeq(path)
+		sst.dict = dict
+		if len(sst.blocks) > 0 {
+			sst.minKey, sst.maxKey = sst.blocks[0].firstKey, sst.blocks[len(sst.blocks)-1].lastKey
+		}
+		if useBloom {
+			// 사이드카가 없거나 손상되었으면 필터 없이 열고 LSMTree가 다시 만듦.
+			if bf, err := readBloomSidecar(path); err == nil {
+				sst.bloom.Store(bf)
+			}
+		}
+		return sst, nil
+	}
+
+	if keys != nil {
+		return nil, fmt.Errorf("%w: SSTable %s is not encrypted", pagecrypt.ErrNotEncrypted, path)
+	}
+	// 블록 이전의 flat 포맷: 엔트리들 + 체크섬 4바이트. 마지막 4바이트는 체크섬임.
 	dataEnd := fileSize - 4
 
 	index := make(map[string]int64)
@@ -128,16 +163,19 @@ func OpenSSTable(path string, useBloom bool) (*SSTable, error) {
 		}
 
 		var keyLen uint16
-		if err := binary.Read(file, binary.BigEndian, &keyLen); err != nil {
+		if err := binary.Read(file, byteOrder, &keyLen); err != nil {
 			return nil, err
 		}
-		keyBytes := make([]byte, keyLen)
+		keyBytes := make([]byte, keyLen&^keydict.EncodedFlag)
 		if _, err := io.ReadFull(file, keyBytes); err != nil {
 			return nil, err
 		}
-		key := string(keyBytes)
+		key, err := decodeKey(dict, keyLen, keyBytes)
+		if err != nil {
+			return nil, err
+		}
 		var valLen uint16
-		if err := binary.Read(file, binary.BigEndian, &valLen); err != nil {
+		if err := binary.Read(file, byteOrder, &valLen); err != nil {
 			return nil, err
 		}
 		valBytes := make([]byte, valLen)
@@ -147,9 +185,9 @@ func OpenSSTable(path string, useBloom bool) (*SSTable, error) {
 
 		// 체크섬 계산을 위한 버퍼 업데이트.
 		buf := new(bytes.Buffer)
-		binary.Write(buf, binary.BigEndian, keyLen)
+		binary.Write(buf, byteOrder, keyLen)
 		buf.Write(keyBytes)
-		binary.Write(buf, binary.BigEndian, valLen)
+		binary.Write(buf, byteOrder, valLen)
 		buf.Write(valBytes)
 		entryBytes := buf.Bytes()
 		hasher.Write(entryBytes)
@@ -164,7 +202,7 @@ func OpenSSTable(path string, useBloom bool) (*SSTable, error) {
 
 	// 체크섬 읽기.
 	var fileChecksum uint32
-	if err := binary.Read(file, binary.BigEndian, &fileChecksum); err != nil {
+	if err := binary.Read(file, byteOrder, &fileChecksum); err != nil {
 		return nil, err
 	}
 	computedChecksum := hasher.Sum32()
@@ -172,54 +210,139 @@ func OpenSSTable(path string, useBloom bool) (*SSTable, error) {
 		return nil, ErrSSTableCorrupted
 	}
 
-	sst := &SSTable{
+	sst = &SSTable{
 		filePath: path,
 		minKey:   minKey,
 		maxKey:   maxKey,
 		size:     offset,
+		seq:      sstableSeq(path),
 		index:    index,
 		checksum: fileChecksum,
+		dict:     dict,
 	}
 	if useBloom {
-		bf := NewBloomFilter(1000)
+		bf := NewBloomFilter(bloomSize(len(index)))
 		for k := range index {
 			bf.Add(k)
 		}
-		sst.Bloom = bf
+		sst.bloom.Store(bf)
 	}
 	return sst, nil
 }
 
 // Get retrieves the value associated with the given key from the SSTable.
 func (s *SSTable) Get(key string) (string, bool) {
+	var cost ports.ReadCost
+	val, found, err := s.lookup(key, &cost)
+	if err != nil {
+		return "", false
+	}
+	return val, found
+}
+
+// lookup reads the entry for key and verifies that the stored key matches, so a
+// damaged block is reported as ErrSSTableCorrupted instead of returning a wrong value.
+// The entries or blocks read from the file are counted into cost.
+func (s *SSTable) lookup(key string, cost *ports.ReadCost) (string, bool, error) {
+	if s.quarantined.Load() {
+		return "", false, ErrSSTableCorrupted
+	}
+	if s.index == nil {
+		return s.lookupBlock(key, cost)
+	}
 	pos, exists := s.index[key]
 	if !exists {
-		return "", false
+		return "", false, nil
 	}
+	cost.Blocks++
 	file, err := os.Open(s.filePath)
 	if err != nil {
-		return "", false
+		return "", false, err
 	}
 	defer file.Close()
 	// Seek to the key's position.
 	if _, err := file.Seek(pos, io.SeekStart); err != nil {
-		return "", false
+		return "", false, err
 	}
 	var keyLen uint16
-	if err := binary.Read(file, binary.BigEndian, &keyLen); err != nil {
-		return "", false
+	if err := binary.Read(file, byteOrder, &keyLen); err != nil {
+		return "", false, ErrSSTableCorrupted
 	}
-	keyBytes := make([]byte, keyLen)
+	keyBytes := make([]byte, keyLen&^keydict.EncodedFlag)
 	if _, err := io.ReadFull(file, keyBytes); err != nil {
-		return "", false
+		return "", false, ErrSSTableCorrupted
+	}
+	if stored, err := decodeKey(s.dict, keyLen, keyBytes); err != nil || stored != key {
+		return "", false, ErrSSTableCorrupted
 	}
 	var valLen uint16
-	if err := binary.Read(file, binary.BigEndian, &valLen); err != nil {
-		return "", false
+	if err := binary.Read(file, byteOrder, &valLen); err != nil {
+		return "", false, ErrSSTableCorrupted
 	}
 	valBytes := make([]byte, valLen)
 	if _, err := io.ReadFull(file, valBytes); err != nil {
-		return "", false
+		return "", false, ErrSSTableCorrupted
+	}
+	return string(valBytes), true, nil
+}
+
+// entries reads every key-value pair of the SSTable.
+func (s *SSTable) entries() (map[string]string, error) {
+	if s.index == nil {
+		return s.blockEntries()
+	}
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, ErrSSTableCorrupted
+	}
+	result := make(map[string]string, len(s.index))
+	err = parseSSTEntries(data[:len(data)-4], s.dict, func(key string, value []byte) bool { // 체크섬 제외
+		result[key] = string(value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sstableSeq returns the creation order recorded in the file name of an SSTable.
+func sstableSeq(path string) int64 {
+	seq, _, _ := parseSSTableName(filepath.Base(path))
+	return seq
+}
+
+// HasBloom reports whether the table has a bloom filter loaded.
+func (s *SSTable) HasBloom() bool {
+	return s.bloom.Load() != nil
+}
+
+// mayContain reports whether key may be in the table; without a filter it is always true.
+func (s *SSTable) mayContain(key string) bool {
+	bf := s.bloom.Load()
+	return bf == nil || bf.MightContain(key)
+}
+
+// Quarantine marks the SSTable as corrupt; subsequent lookups skip it.
+func (s *SSTable) Quarantine() {
+	s.quarantined.Store(true)
+}
+
+// Quarantined reports whether the SSTable was marked corrupt.
+func (s *SSTable) Quarantined() bool {
+	return s.quarantined.Load()
+}
+
+// decodeKey restores a stored key, expanding its dictionary prefix when keyLen carries the flag.
+func decodeKey(dict *keydict.Dictionary, keyLen uint16, keyBytes []byte) (string, error) {
+	if keyLen&keydict.EncodedFlag == 0 {
+		return string(keyBytes), nil
+	}
+	if dict == nil {
+		return "", fmt.Errorf("sstable uses a key dictionary but none is configured")
 	}
-	return string(valBytes), true
+	return dict.Decode(string(keyBytes))
 }
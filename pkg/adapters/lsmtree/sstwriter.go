@@ -0,0 +1,121 @@
+package lsmtree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sukryu/GoLite/pkg/keydict"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// SSTableWriterOptions configures an SSTableWriter.
+type SSTableWriterOptions struct {
+	// CompressionType is the block compression: "none", "snappy" (default) or "zstd".
+	CompressionType string
+	// KeyDict stores keys with a known prefix as (dictID, suffix). The tree ingesting the
+	// file must be opened with the same dictionary.
+	KeyDict *keydict.Dictionary
+}
+
+// SSTableWriter builds an SSTable file from entries added in strictly ascending key order,
+// without holding them in memory, for tools that produce data outside the tree (see
+// LSMTree.IngestExternalFile). The file appears at its path only once Finish succeeds.
+type SSTableWriter struct {
+	path  string
+	file  *os.File
+	bw    *blockWriter
+	count int
+}
+
+// NewSSTableWriter starts an SSTable at path.
+func NewSSTableWriter(path string, opts SSTableWriterOptions) (*SSTableWriter, error) {
+	switch opts.CompressionType {
+	case "":
+		opts.CompressionType = "snappy"
+	case "none", "snappy", "zstd":
+	default:
+		return nil, fmt.Errorf("unknown compression type %q", opts.CompressionType)
+	}
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &SSTableWriter{
+		path: path,
+		file: file,
+		bw:   newBlockWriter(file, compressionCodec(opts.CompressionType), opts.KeyDict, nil),
+	}, nil
+}
+
+// Add appends key with value. Keys must be added in strictly ascending order.
+func (w *SSTableWriter) Add(key, value string) error {
+	if value == tombstone || isMergeRecord(value) || len(value) > maxSSTValue {
+		return ErrInvalidValue
+	}
+	return w.add(key, value)
+}
+
+// Delete appends a tombstone for key, hiding its older values once the file is ingested.
+func (w *SSTableWriter) Delete(key string) error {
+	return w.add(key, tombstone)
+}
+
+func (w *SSTableWriter) add(key, value string) error {
+	if w.file == nil {
+		return ErrInvalidOperation
+	}
+	if w.count > 0 && key <= w.bw.last {
+		return fmt.Errorf("%w: %q added after %q", ErrInvalidKey, key, w.bw.last)
+	}
+	if err := w.bw.add(key, value); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Count returns the number of entries added so far.
+func (w *SSTableWriter) Count() int {
+	return w.count
+}
+
+// Finish writes the block index and footer, syncs the file, moves it to its path and syncs
+// the directory.
+func (w *SSTableWriter) Finish() error {
+	if w.file == nil {
+		return ErrInvalidOperation
+	}
+	file := w.file
+	w.file = nil
+	tmp := file.Name()
+	if _, _, _, err := w.bw.finish(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return utils.SyncDir(filepath.Dir(w.path))
+}
+
+// Abort discards the file being written.
+func (w *SSTableWriter) Abort() {
+	if w.file == nil {
+		return
+	}
+	w.file.Close()
+	os.Remove(w.file.Name())
+	w.file = nil
+}
@@ -0,0 +1,55 @@
+package lsmtree
+
+import "time"
+
+// 쓰기 지연: leveling에서 level0 테이블은 키 범위가 겹쳐 Get이 모두 확인해야 하므로, 컴팩션이 쓰기를
+// 따라가지 못해 level0이 늘어나면 읽기가 느려집니다. level0 테이블 수가 Level0SlowdownTrigger에
+// 이르면 쓰기마다 writeSlowdownDelay만큼 쉬어 컴팩션에 시간을 주고, Level0StopTrigger에 이르면
+// 컴팩션이 level0을 그 아래로 줄일 때까지 쓰기를 막습니다. 두 경우 모두 다음 컴팩션 주기를 기다리지
+// 않고 컴팩터를 깨우며, 컴팩터는 level0이 slowdown 수에 이르면 Level0CompactionTrigger와 관계없이
+// 병합합니다. 막힌 쓰기는 Close하면 ErrDBClosed로 끝납니다.
+//
+// 늦추거나 막은 쓰기의 수와 기다린 누적 시간은 Stats의 "write_stalls"로 보고합니다. size-tiered
+// 전략은 모든 테이블을 level0에 두므로 적용하지 않습니다.
+
+const (
+	writeSlowdownDelay = time.Millisecond
+	writeStallPoll     = 5 * time.Millisecond // 막힌 쓰기가 level0을 다시 확인하는 간격
+)
+
+// stallWrite delays a write while level0 has reached the slowdown trigger and blocks it
+// while level0 is at the stop trigger, until compaction catches up or the tree is closed.
+func (l *LSMTree) stallWrite() error {
+	if l.config.CompactionStrategy == "sizing" {
+		return nil
+	}
+	n := l.level0Count()
+	if n < l.config.level0SlowdownTrigger() {
+		return nil
+	}
+	start := time.Now()
+	l.compactor.wakeUp()
+	if n < l.config.level0StopTrigger() {
+		l.metrics.IncWriteSlowdowns()
+		time.Sleep(writeSlowdownDelay)
+	} else {
+		l.metrics.IncWriteStops()
+		ticker := time.NewTicker(writeStallPoll)
+		defer ticker.Stop()
+		for l.level0Count() >= l.config.level0StopTrigger() {
+			select {
+			case <-l.stopCh:
+				return ErrDBClosed
+			case <-ticker.C:
+			}
+		}
+	}
+	l.metrics.AddWriteStall(time.Since(start))
+	return nil
+}
+
+func (l *LSMTree) level0Count() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.levels[0])
+}
@@ -4,39 +4,121 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
 )
 
 var ErrWALFull = errors.New("WAL channel is full")
 
-var entryPool = sync.Pool{
-	New: func() interface{} { return new(bytes.Buffer) },
+// ErrWALClosed is returned by appends after Close.
+var ErrWALClosed = errors.New("WAL is closed")
+
+// 그룹 커밋: 워커는 채널에 쌓인 엔트리를 모아 한 번의 write와 fsync로 기록합니다.
+// 배치는 GroupCommit.MaxEntries개가 모이거나 첫 엔트리 이후 MaxDelay가 지나면 커밋되며,
+// 대기는 fsync가 필요한 배치(SyncWrites 또는 AppendSync 요청 포함)에서만 합니다.
+// AppendSync는 자신의 엔트리가 포함된 배치의 fsync가 끝날 때까지 반환하지 않습니다.
+
+// GroupCommit configures how many WAL entries share one fsync.
+type GroupCommit struct {
+	MaxEntries int           // 배치당 최대 엔트리 수 (0이면 기본값 256)
+	MaxDelay   time.Duration // 배치를 채우기 위해 기다리는 최대 시간 (0이면 기다리지 않음)
 }
 
+const defaultGroupCommitEntries = 256
+
+// 세그먼트: WAL은 고정 크기의 세그먼트 파일로 나뉩니다. 첫 세그먼트는 WAL 경로 그대로이고 이후의
+// 세그먼트는 경로에 번호를 붙입니다(db.wal, db.wal.000001, ...). 배치가 세그먼트를 segmentSize 넘게
+// 키울 때와 Rotate를 부를 때 다음 세그먼트로 넘어가며, 열 때도 엔트리가 있는 마지막 세그먼트 뒤에
+// 새로 시작하므로 잘린 끝 뒤에 이어 쓰지 않습니다. 암호화된 WAL은 세그먼트마다 magic으로 시작하고
+// 프레임의 AAD는 세그먼트 안의 오프셋입니다.
+//
+// LSMTree는 memTable을 바꾸는 flush에서 Rotate하고, 새 SSTable을 쓴 뒤 RemoveSegmentsBefore로 그
+// 앞의 세그먼트를 지웁니다. 남은 세그먼트는 아직 flush되지 않은 쓰기뿐이므로 복구 시간과 디스크
+// 사용량이 memTable 크기에 묶입니다. 복구는 모든 세그먼트를 번호 순으로 재생합니다.
+
+const defaultSegmentSize = 4 * 1024 * 1024
+
 // WAL represents the Write-Ahead Log with asynchronous writes.
 type WAL struct {
 	file       *os.File
 	mu         sync.Mutex
 	syncWrites bool
-	walCh      chan WalEntry
+	group      GroupCommit
+	walCh      chan walRequest
 	wg         sync.WaitGroup
-	// Atomic counter for appended entries.
-	entryCount int64
+	closeMu    sync.RWMutex // Close와 전송 중인 Append 사이의 채널 닫힘 경합 방지
+	closed     bool
+	entryCount atomic.Int64       // 추가된 엔트리 수 (32비트에서도 정렬되도록 atomic 타입 사용)
+	syncs      atomic.Int64       // 수행한 fsync 횟수
+	batches    atomic.Int64       // 기록한 배치 수
+	keys       *pagecrypt.Keyring // 배치를 봉인하는 키 (nil이면 평문, mu로 보호)
+
+	path        string // 첫 세그먼트의 경로
+	segment     int64  // 현재 세그먼트 번호 (mu로 보호)
+	segmentSize int64
+	written     int64  // 현재 세그먼트의 크기 (mu로 보호)
+	seq         uint64 // 마지막으로 기록한 엔트리의 순번 (mu로 보호, recovery.go 참고)
+	rotations   atomic.Int64
+}
+
+// walRequest is an entry queued for the worker. done, if set, receives the result once the
+// entry is durable; a request without an entry is a barrier used by Flush.
+type walRequest struct {
+	entry   WalEntry
+	barrier bool
+	done    chan error
 }
 
-// NewWAL opens or creates a WAL file.
+// NewWAL opens or creates a WAL with the default group commit settings.
 func NewWAL(path string, syncWrites bool) (*WAL, error) {
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	return NewWALWithGroupCommit(path, syncWrites, GroupCommit{})
+}
+
+// NewWALWithGroupCommit opens or creates a WAL whose entries are fsynced in groups.
+func NewWALWithGroupCommit(path string, syncWrites bool, group GroupCommit) (*WAL, error) {
+	return newWAL(path, syncWrites, group, nil, defaultSegmentSize)
+}
+
+// newWAL opens or creates a WAL of segments of segmentSize bytes whose batches are sealed
+// with keys if not nil. Appends go to a new segment after the last one holding entries.
+func newWAL(path string, syncWrites bool, group GroupCommit, keys *pagecrypt.Keyring, segmentSize int64) (*WAL, error) {
+	segments, err := walSegments(path)
 	if err != nil {
 		return nil, err
 	}
+	var segment int64
+	if len(segments) > 0 {
+		segment = segments[len(segments)-1]
+	}
+	if group.MaxEntries <= 0 {
+		group.MaxEntries = defaultGroupCommitEntries
+	}
 	w := &WAL{
-		file:       file,
-		syncWrites: syncWrites,
-		walCh:      make(chan WalEntry, 30000),
+		path:        path,
+		segment:     segment,
+		segmentSize: segmentSize,
+		syncWrites:  syncWrites,
+		group:       group,
+		walCh:       make(chan walRequest, 30000),
+		keys:        keys,
+	}
+	if err := w.openSegment(os.O_APPEND); err != nil {
+		return nil, err
+	}
+	if w.written > w.headerSize() {
+		if err := w.rotate(); err != nil {
+			w.file.Close()
+			return nil, err
+		}
 	}
 	w.wg.Add(1)
 	go w.worker()
@@ -45,74 +127,356 @@ func NewWAL(path string, syncWrites bool) (*WAL, error) {
 
 // WalEntry represents a record in the WAL.
 type WalEntry struct {
-	Op    byte // 0x00 for insert, 0x01 for delete
+	Op    byte // 0x00 for insert, 0x01 for delete, walOpMerge for a merge operand
 	Key   string
 	Value string
 }
 
-// Append writes a WAL entry asynchronously.
+// walOpMerge is the Op of a WAL entry holding a merge operand (see merge.go).
+const walOpMerge byte = 0x02
+
+// Append writes a WAL entry asynchronously. With SyncWrites the entry is fsynced together
+// with the rest of its batch, but Append does not wait for it.
 func (w *WAL) Append(entry WalEntry) error {
-	// 원자적 카운터 증가
-	atomic.AddInt64(&w.entryCount, 1)
-	w.walCh <- entry
+	return w.send(walRequest{entry: entry})
+}
+
+// AppendSync writes a WAL entry and blocks until it is durable on disk, regardless of
+// SyncWrites. Concurrent callers share fsyncs.
+func (w *WAL) AppendSync(entry WalEntry) error {
+	done := make(chan error, 1)
+	if err := w.send(walRequest{entry: entry, done: done}); err != nil {
+		return err
+	}
+	return <-done
+}
+
+func (w *WAL) send(req walRequest) error {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return ErrWALClosed
+	}
+	if !req.barrier {
+		// 원자적 카운터 증가
+		w.entryCount.Add(1)
+	}
+	w.walCh <- req
 	return nil
 }
 
-// worker processes WAL entries from the channel.
+// worker collects queued entries into batches and commits them.
 func (w *WAL) worker() {
 	defer w.wg.Done()
-	for entry := range w.walCh {
-		buf := entryPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		buf.WriteByte(entry.Op)
-		binary.Write(buf, binary.BigEndian, uint16(len(entry.Key)))
-		buf.Write([]byte(entry.Key))
-		binary.Write(buf, binary.BigEndian, uint16(len(entry.Value)))
-		buf.Write([]byte(entry.Value))
+	batch := make([]walRequest, 0, w.group.MaxEntries)
+	for req := range w.walCh {
+		batch = append(batch[:0], req)
+		batch = w.collect(batch)
+		w.commit(batch)
+	}
+}
 
-		w.mu.Lock()
-		w.file.Write(buf.Bytes())
-		if w.syncWrites {
-			w.file.Sync()
+// collect adds queued requests to batch until it is full, the queue is empty and no fsync
+// is pending, or MaxDelay has passed since the first entry.
+func (w *WAL) collect(batch []walRequest) []walRequest {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
 		}
-		w.mu.Unlock()
+	}()
+	for len(batch) < w.group.MaxEntries {
+		select {
+		case req, ok := <-w.walCh:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, req)
+			continue
+		default:
+		}
+		if w.group.MaxDelay <= 0 || !w.needsSync(batch) || hasBarrier(batch) {
+			return batch
+		}
+		if timer == nil {
+			timer = time.NewTimer(w.group.MaxDelay)
+		}
+		select {
+		case req, ok := <-w.walCh:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, req)
+		case <-timer.C:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (w *WAL) needsSync(batch []walRequest) bool {
+	if w.syncWrites {
+		return true
+	}
+	for _, req := range batch {
+		if req.done != nil && !req.barrier {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBarrier(batch []walRequest) bool {
+	for _, req := range batch {
+		if req.barrier {
+			return true
+		}
+	}
+	return false
+}
+
+// commit writes the batch with a single write call, fsyncs it if needed and reports the
+// result to every waiter.
+func (w *WAL) commit(batch []walRequest) {
+	buf := entryPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	var err error
+	w.mu.Lock()
+	for _, req := range batch {
+		if req.barrier {
+			continue
+		}
+		if w.keys != nil {
+			encodeWalEntry(buf, req.entry) // 프레임은 봉인으로 인증됨
+		} else {
+			w.seq++
+			encodeCheckedEntry(buf, w.seq, req.entry)
+		}
+	}
+	if buf.Len() > 0 {
+		data := buf.Bytes()
+		if w.written+int64(len(data)) > w.segmentSize {
+			err = w.rotate()
+		}
+		if err == nil && w.keys != nil {
+			data, err = w.sealFrame(data)
+		}
+		if err == nil {
+			_, err = w.file.Write(data)
+			w.written += int64(len(data))
+		}
+		if err == nil && w.needsSync(batch) {
+			err = w.file.Sync()
+			w.syncs.Add(1)
+		}
+		w.batches.Add(1)
+	}
+	w.mu.Unlock()
+	entryPool.Put(buf)
 
-		entryPool.Put(buf)
+	for _, req := range batch {
+		if req.done != nil {
+			req.done <- err
+		}
 	}
 }
 
-// Reset truncates and resets the WAL file.
+var entryPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// encodeWalEntry appends entry in the format of a checked record body or a sealed frame.
+func encodeWalEntry(buf *bytes.Buffer, entry WalEntry) {
+	buf.WriteByte(entry.Op)
+	binary.Write(buf, byteOrder, uint16(len(entry.Key)))
+	buf.WriteString(entry.Key)
+	binary.Write(buf, byteOrder, uint16(len(entry.Value)))
+	buf.WriteString(entry.Value)
+}
+
+// Reset removes every segment and starts the WAL over in an empty segment.
 func (w *WAL) Reset() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	if err := w.file.Close(); err != nil {
 		return err
 	}
-	file, err := os.OpenFile(w.file.Name(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
+	if err := w.removeSegments(w.segment + 1); err != nil {
 		return err
 	}
-	w.file = file
 	// 리셋 후 카운터도 초기화.
-	atomic.StoreInt64(&w.entryCount, 0)
+	w.entryCount.Store(0)
+	return w.openSegment(os.O_TRUNC)
+}
+
+// Rotate writes the entries appended before the call and moves later appends to a new
+// segment, unless the current one is still empty. It returns the number of the segment
+// later appends go to; the segments before it can be removed once their entries are
+// persisted elsewhere.
+func (w *WAL) Rotate() (int64, error) {
+	w.Flush()
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return 0, ErrWALClosed
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.rotate(); err != nil {
+		return 0, err
+	}
+	return w.segment, nil
+}
+
+// RemoveSegmentsBefore removes the segments numbered below segment, as returned by Rotate.
+func (w *WAL) RemoveSegmentsBefore(segment int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.removeSegments(min(segment, w.segment))
+}
+
+// rotate closes the current segment and opens the next one, unless the current one holds no
+// entries yet. Caller holds w.mu.
+func (w *WAL) rotate() error {
+	if w.written <= w.headerSize() {
+		return nil
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.segment++
+	w.rotations.Add(1)
+	return w.openSegment(os.O_TRUNC)
+}
+
+// openSegment opens the current segment with flag added to the create flags, checks or
+// writes its magic and sets w.written. Caller holds w.mu or owns w.
+func (w *WAL) openSegment(flag int) error {
+	file, err := os.OpenFile(segmentPath(w.path, w.segment), os.O_RDWR|os.O_CREATE|os.O_APPEND|flag, 0666)
+	if err != nil {
+		return err
+	}
+	if err := checkWALMagic(file, w.keys); err != nil {
+		file.Close()
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.written = file, fi.Size()
+	return nil
+}
+
+// headerSize returns the size of a segment without entries.
+func (w *WAL) headerSize() int64 {
+	if w.keys != nil {
+		return int64(len(walSealedMagic))
+	}
+	return int64(len(walCheckedMagic))
+}
+
+// removeSegments removes the segments numbered below segment. Caller holds w.mu.
+func (w *WAL) removeSegments(segment int64) error {
+	segments, err := walSegments(w.path)
+	if err != nil {
+		return err
+	}
+	for _, n := range segments {
+		if n >= segment {
+			break
+		}
+		if err := os.Remove(segmentPath(w.path, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	return nil
 }
 
+// segmentPath returns the path of segment n of the WAL at path.
+func segmentPath(path string, n int64) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%06d", path, n)
+}
+
+// walSegments returns the numbers of the existing segments of the WAL at path, in order.
+func walSegments(path string) ([]int64, error) {
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(path)
+	var segments []int64
+	for _, file := range files {
+		name := file.Name()
+		if name == base {
+			segments = append(segments, 0)
+			continue
+		}
+		suffix, ok := strings.CutPrefix(name, base+".")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(suffix, 10, 64); err == nil && n > 0 {
+			segments = append(segments, n)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
 // Close shuts down the WAL gracefully.
 func (w *WAL) Close() error {
+	w.closeMu.Lock()
+	w.closed = true
 	close(w.walCh)
+	w.closeMu.Unlock()
 	w.wg.Wait()
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	return w.file.Close()
 }
 
-// Flush waits until the WAL channel is empty.
+// Flush waits until every entry appended before the call has been written.
 func (w *WAL) Flush() {
-	for {
-		if len(w.walCh) == 0 {
-			break
+	done := make(chan error, 1)
+	if err := w.send(walRequest{barrier: true, done: done}); err != nil {
+		return
+	}
+	<-done
+}
+
+// Size returns the total size of the WAL segments (unreadable segments count as 0).
+func (w *WAL) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	segments, err := walSegments(w.path)
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, n := range segments {
+		if fi, err := os.Stat(segmentPath(w.path, n)); err == nil {
+			size += fi.Size()
 		}
-		time.Sleep(1 * time.Millisecond)
+	}
+	return size
+}
+
+// Stats returns the number of appended entries, written batches and fsyncs, and the
+// current segment and the number of rotations.
+func (w *WAL) Stats() map[string]interface{} {
+	w.mu.Lock()
+	segment := w.segment
+	w.mu.Unlock()
+	return map[string]interface{}{
+		"entries":   w.entryCount.Load(),
+		"batches":   w.batches.Load(),
+		"syncs":     w.syncs.Load(),
+		"segment":   segment,
+		"rotations": w.rotations.Load(),
 	}
 }
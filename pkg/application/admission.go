@@ -0,0 +1,185 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// 수락 제어: Admission은 명령과 질의를 실행하기 전에 토큰 버킷으로 전역 및 클라이언트별 속도(초당 연산 수,
+// 초당 바이트 수)를 검사하고, 넘으면 기다리지 않고 ErrThrottled로 거절합니다. SetWriteRateLimit이
+// 명령을 늦추는 것과 달리, 폭주하는 쓰기 클라이언트가 임베디드 프로세스의 다른 작업을 밀어내지 못하게
+// 바로 돌려보냅니다. 바이트는 명령이 쓰는 키와 값의 길이이며, 질의는 연산 수에만 포함됩니다.
+//
+// 클라이언트는 WithClient로 컨텍스트에 붙인 ID로 구분하며, ID가 없는 연산은 전역 한도만 적용됩니다.
+// 버킷은 1초 분량까지 모아 둘 수 있어 잠깐의 폭주는 허용합니다. 한 번에 버킷보다 큰 명령은 버킷이
+// 가득 찼을 때 받아들이고 그만큼 빚을 지므로 영원히 거절되지는 않습니다. 한동안 쓰이지 않은
+// 클라이언트의 버킷은 정리됩니다.
+
+// ErrThrottled is returned when an operation exceeds a rate limit of the handler's Admission.
+var ErrThrottled = errors.New("rate limit exceeded")
+
+// AdmissionLimits configures an Admission. A limit of 0 or less is unlimited.
+type AdmissionLimits struct {
+	OpsPerSecond         float64 // All operations together
+	BytesPerSecond       float64 // Bytes written by all commands together
+	ClientOpsPerSecond   float64 // Operations of each client (see WithClient)
+	ClientBytesPerSecond float64 // Bytes written by the commands of each client
+}
+
+// clientIdleTimeout is how long the buckets of a client are kept after its last operation.
+const clientIdleTimeout = time.Minute
+
+// Admission rejects operations over their rate limits. One Admission can be shared by a
+// CommandHandler and a QueryHandler so that they count against the same limits.
+type Admission struct {
+	limits atomic.Pointer[admissionBuckets]
+}
+
+// admissionBuckets holds the buckets of one set of limits; SetLimits replaces it whole.
+type admissionBuckets struct {
+	limits  AdmissionLimits
+	ops     *utils.RateLimiter
+	bytes   *utils.RateLimiter
+	mu      sync.Mutex
+	clients map[string]*clientBuckets
+	swept   time.Time
+}
+
+type clientBuckets struct {
+	ops, bytes *utils.RateLimiter
+	last       time.Time
+}
+
+// NewAdmission creates an Admission enforcing limits.
+func NewAdmission(limits AdmissionLimits) *Admission {
+	a := &Admission{}
+	a.SetLimits(limits)
+	return a
+}
+
+// SetLimits replaces the limits, e.g. on a configuration reload. Buckets start full.
+func (a *Admission) SetLimits(limits AdmissionLimits) {
+	a.limits.Store(&admissionBuckets{
+		limits:  limits,
+		ops:     newBucket(limits.OpsPerSecond),
+		bytes:   newBucket(limits.BytesPerSecond),
+		clients: make(map[string]*clientBuckets),
+		swept:   time.Now(),
+	})
+}
+
+// Limits returns the current limits.
+func (a *Admission) Limits() AdmissionLimits {
+	return a.limits.Load().limits
+}
+
+// newBucket returns a limiter holding up to one second of rate.
+func newBucket(rate float64) *utils.RateLimiter {
+	return utils.NewRateLimiter(rate, int(math.Min(math.Ceil(rate), math.MaxInt32)))
+}
+
+// Admit takes one operation writing n bytes from the buckets of the client of ctx and the
+// global buckets, or returns an error wrapping ErrThrottled.
+func (a *Admission) Admit(ctx context.Context, n int) error {
+	b := a.limits.Load()
+	if client := ClientFrom(ctx); client != "" && (b.limits.ClientOpsPerSecond > 0 || b.limits.ClientBytesPerSecond > 0) {
+		c := b.client(client)
+		if !c.ops.AllowN(1) {
+			return fmt.Errorf("%w: client %s over %v operations per second", ErrThrottled, client, b.limits.ClientOpsPerSecond)
+		}
+		if n > 0 && !c.bytes.AllowN(float64(n)) {
+			return fmt.Errorf("%w: client %s over %v bytes per second", ErrThrottled, client, b.limits.ClientBytesPerSecond)
+		}
+	}
+	if !b.ops.AllowN(1) {
+		return fmt.Errorf("%w: over %v operations per second", ErrThrottled, b.limits.OpsPerSecond)
+	}
+	if n > 0 && !b.bytes.AllowN(float64(n)) {
+		return fmt.Errorf("%w: over %v bytes per second", ErrThrottled, b.limits.BytesPerSecond)
+	}
+	return nil
+}
+
+// client returns the buckets of client, creating them and dropping idle clients as needed.
+func (b *admissionBuckets) client(client string) *clientBuckets {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Sub(b.swept) > clientIdleTimeout {
+		for id, c := range b.clients {
+			if now.Sub(c.last) > clientIdleTimeout {
+				delete(b.clients, id)
+			}
+		}
+		b.swept = now
+	}
+	c := b.clients[client]
+	if c == nil {
+		c = &clientBuckets{
+			ops:   newBucket(b.limits.ClientOpsPerSecond),
+			bytes: newBucket(b.limits.ClientBytesPerSecond),
+		}
+		b.clients[client] = c
+	}
+	c.last = now
+	return c
+}
+
+type clientKey struct{}
+
+// WithClient returns a context whose operations count against the per-client limits of client.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFrom returns the client of ctx, or "" if it has none.
+func ClientFrom(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
+
+// SetAdmission rejects commands over the limits of a with ErrThrottled (nil disables it).
+func (h *CommandHandler) SetAdmission(a *Admission) {
+	h.admission.Store(a)
+}
+
+// SetAdmission rejects queries over the limits of a with ErrThrottled (nil disables it).
+func (h *QueryHandler) SetAdmission(a *Admission) {
+	h.admission.Store(a)
+}
+
+// admit checks op against the admission a, if any.
+func admit(ctx context.Context, a *Admission, op interface{}) error {
+	if a == nil || admitted(ctx) {
+		return nil
+	}
+	return a.Admit(ctx, opBytes(op))
+}
+
+// opBytes returns the bytes a command writes: its keys and values.
+func opBytes(op interface{}) int {
+	switch op := op.(type) {
+	case *InsertCommand:
+		return len(op.Key) + len(op.Value)
+	case *DeleteCommand:
+		return len(op.Key)
+	case *CompareAndSwapCommand:
+		return len(op.Key) + len(op.NewValue)
+	case *DeleteIfCommand:
+		return len(op.Key)
+	case *DeleteRangeCommand:
+		return len(op.StartKey) + len(op.EndKey)
+	case *InsertIfAbsentCommand:
+		return len(op.Key) + len(op.Value)
+	case *IncrementCommand:
+		return len(op.Key) + 8
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"errors"
+)
+
+// 접근 제어: SetAuthorizer로 Authorizer를 주면 CommandHandler와 QueryHandler는 명령과 질의를 실행하기
+// 전에 대상 테이블과 필요한 권한(읽기/쓰기)으로 Authorize를 호출하고, 실패하면 실행하지 않고 그 오류를
+// 반환합니다. 테이블은 호출자가 준 이름(테넌트 네임스페이스를 붙이기 전)이며, 특정 테이블이 없는
+// 연산(SyncCommand, CompactCommand, ChangesQuery, GetStatusQuery, GetSpecQuery)은 빈 이름으로
+// 데이터베이스 전체에 대한 권한을 묻습니다. 토큰 기반 구현은 server.ACL입니다.
+//
+// 아웃박스에 기록되는 비동기 명령은 EnqueueCommand에서 검사(수락 제어 포함)하므로, 재시작 뒤
+// 재실행될 때는 다시 검사하지 않습니다 (그 시점에는 요청한 쪽의 자격 증명이 없음).
+
+// Permission is the access an operation needs on a table.
+type Permission uint8
+
+const (
+	// PermRead allows queries on a table.
+	PermRead Permission = 1 << iota
+	// PermWrite allows commands on a table, including creating and dropping it.
+	PermWrite
+)
+
+// String returns "read", "write", "read,write" or "none".
+func (p Permission) String() string {
+	switch p {
+	case PermRead:
+		return "read"
+	case PermWrite:
+		return "write"
+	case PermRead | PermWrite:
+		return "read,write"
+	}
+	return "none"
+}
+
+var (
+	// ErrUnauthenticated is returned when an operation carries no valid credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the credentials of an operation don't grant the permission
+	// it needs on its table.
+	ErrForbidden = errors.New("permission denied")
+)
+
+// Authorizer decides whether the caller of ctx may access a table. table is "" for
+// operations on the whole database. It returns nil to allow the operation, or an error
+// wrapping ErrUnauthenticated or ErrForbidden.
+type Authorizer interface {
+	Authorize(ctx context.Context, table string, perm Permission) error
+}
+
+// SetAuthorizer checks every command with a before it is executed (nil disables the checks).
+func (h *CommandHandler) SetAuthorizer(a Authorizer) {
+	if a == nil {
+		h.authorizer.Store(nil)
+		return
+	}
+	h.authorizer.Store(&a)
+}
+
+// SetAuthorizer checks every query with a before it is executed (nil disables the checks).
+func (h *QueryHandler) SetAuthorizer(a Authorizer) {
+	if a == nil {
+		h.authorizer.Store(nil)
+		return
+	}
+	h.authorizer.Store(&a)
+}
+
+type admittedKey struct{}
+
+// withAdmitted marks ctx as already authorized and admitted, for commands replayed from the
+// outbox.
+func withAdmitted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, admittedKey{}, true)
+}
+
+// admitted reports whether ctx was marked by withAdmitted.
+func admitted(ctx context.Context) bool {
+	ok, _ := ctx.Value(admittedKey{}).(bool)
+	return ok
+}
+
+// authorize checks op against the authorizer a, if any.
+func authorize(ctx context.Context, a *Authorizer, op interface{}) error {
+	if a == nil || admitted(ctx) {
+		return nil
+	}
+	table, perm := accessOf(op)
+	return (*a).Authorize(ctx, table, perm)
+}
+
+// accessOf returns the table a command or query accesses and the permission it needs.
+func accessOf(op interface{}) (string, Permission) {
+	switch op := op.(type) {
+	case *CreateTableCommand:
+		return op.TableName, PermWrite
+	case *DropTableCommand:
+		return op.TableName, PermWrite
+	case *InsertCommand:
+		return op.TableName, PermWrite
+	case *DeleteCommand:
+		return op.TableName, PermWrite
+	case *CompareAndSwapCommand:
+		return op.TableName, PermWrite
+	case *DeleteIfCommand:
+		return op.TableName, PermWrite
+	case *DeleteRangeCommand:
+		return op.TableName, PermWrite
+	case *InsertIfAbsentCommand:
+		return op.TableName, PermWrite
+	case *IncrementCommand:
+		return op.TableName, PermWrite
+	case *GetValueQuery:
+		return op.TableName, PermRead
+	case *SearchQuery:
+		return op.TableName, PermRead
+	case *ScanQuery:
+		return op.TableName, PermRead
+	case *ScanPageQuery:
+		return op.TableName, PermRead
+	case *WatchQuery:
+		return op.TableName, PermRead
+	case *SubscribeQuery:
+		return op.TableName, PermRead
+	case *ExplainQuery:
+		return op.TableName, PermRead
+	case Command:
+		return "", PermWrite // SyncCommand, CompactCommand, 그 밖의 명령
+	}
+	return "", PermRead
+}
@@ -2,28 +2,62 @@ package application
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sukryu/GoLite/pkg/domain"
 	"github.com/sukryu/GoLite/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CommandHandler handles execution of commands against the database.
 type CommandHandler struct {
-	db     *domain.Database
-	logger utils.Logger
-	wg     sync.WaitGroup // For async command execution tracking
+	db       *domain.Database
+	logger   utils.Logger
+	wg       sync.WaitGroup // For async command execution tracking
+	proposer Proposer       // Optional consensus log; nil applies commands directly
+	limiter  *utils.RateLimiter
+	tracer   trace.Tracer
+	outbox   atomic.Pointer[outbox] // Optional durable queue of async commands (see outbox.go)
+
+	authorizer atomic.Pointer[Authorizer] // Optional access control (see auth.go)
+	admission  atomic.Pointer[Admission]  // Optional rate limits rejecting commands (see admission.go)
+}
+
+// Proposer replicates a change through a consensus log (e.g. replication.RaftNode).
+// Propose returns once the change is committed and applied to the local database.
+type Proposer interface {
+	Propose(ctx context.Context, m domain.Mutation) error
 }
 
 // NewCommandHandler creates a new CommandHandler instance.
 func NewCommandHandler(db *domain.Database, logger utils.Logger) *CommandHandler {
 	return &CommandHandler{
-		db:     db,
-		logger: logger,
+		db:      db,
+		logger:  logger,
+		limiter: utils.NewRateLimiter(0, 1),
+		tracer:  newTracer(db.TracerProvider()),
 	}
 }
 
+// SetProposer routes all commands through p instead of applying them to the database directly.
+// In this mode per-write ack levels are ignored: a committed entry is already on a majority.
+func (h *CommandHandler) SetProposer(p Proposer) {
+	h.proposer = p
+}
+
+// SetTracerProvider sends the spans of commands to tp instead of the database's provider.
+func (h *CommandHandler) SetTracerProvider(tp trace.TracerProvider) {
+	h.tracer = newTracer(tp)
+}
+
+// SetWriteRateLimit limits commands to perSecond per second (0 = unlimited).
+// It can be changed while commands are running, e.g. on a configuration reload.
+func (h *CommandHandler) SetWriteRateLimit(perSecond float64) {
+	h.limiter.SetRate(perSecond)
+}
+
 // Command defines the interface for all commands.
 type Command interface {
 	Execute(ctx context.Context, handler *CommandHandler) error
@@ -36,10 +70,18 @@ type CreateTableCommand struct {
 
 // Execute executes the CreateTableCommand.
 func (c *CreateTableCommand) Execute(ctx context.Context, handler *CommandHandler) error {
-	handler.logger.Info(fmt.Sprintf("Executing CreateTableCommand for table %s", c.TableName))
-	err := handler.db.CreateTable(c.TableName)
+	handler.logger.Info("Executing CreateTableCommand", "table", c.TableName)
+	table, err := domain.ResolveTable(ctx, c.TableName)
 	if err != nil {
-		handler.logger.Error(fmt.Sprintf("Failed to create table %s: %v", c.TableName, err))
+		return err
+	}
+	if handler.proposer != nil {
+		err = handler.proposer.Propose(ctx, domain.Mutation{Op: domain.MutationCreateTable, Table: table})
+	} else {
+		err = handler.db.CreateTable(table)
+	}
+	if err != nil {
+		handler.logger.Error("Failed to create table", "table", c.TableName, "error", err)
 		return err
 	}
 	return nil
@@ -52,10 +94,18 @@ type DropTableCommand struct {
 
 // Execute executes the DropTableCommand.
 func (c *DropTableCommand) Execute(ctx context.Context, handler *CommandHandler) error {
-	handler.logger.Info(fmt.Sprintf("Executing DropTableCommand for table %s", c.TableName))
-	err := handler.db.DropTable(c.TableName)
+	handler.logger.Info("Executing DropTableCommand", "table", c.TableName)
+	table, err := domain.ResolveTable(ctx, c.TableName)
 	if err != nil {
-		handler.logger.Error(fmt.Sprintf("Failed to drop table %s: %v", c.TableName, err))
+		return err
+	}
+	if handler.proposer != nil {
+		err = handler.proposer.Propose(ctx, domain.Mutation{Op: domain.MutationDropTable, Table: table})
+	} else {
+		err = handler.db.DropTable(table)
+	}
+	if err != nil {
+		handler.logger.Error("Failed to drop table", "table", c.TableName, "error", err)
 		return err
 	}
 	return nil
@@ -66,14 +116,23 @@ type InsertCommand struct {
 	TableName string
 	Key       string
 	Value     string
+	Ack       domain.WriteAck // Optional per-write ack level (zero uses the database default)
 }
 
 // Execute executes the InsertCommand.
 func (c *InsertCommand) Execute(ctx context.Context, handler *CommandHandler) error {
-	handler.logger.Info(fmt.Sprintf("Executing InsertCommand for key %s in table %s", c.Key, c.TableName))
-	err := handler.db.Insert(c.TableName, c.Key, c.Value)
+	handler.logger.Info("Executing InsertCommand", "table", c.TableName, "key", c.Key)
+	var err error
+	if handler.proposer != nil {
+		var table string
+		if table, err = domain.ResolveTable(ctx, c.TableName); err == nil {
+			err = handler.proposer.Propose(ctx, domain.Mutation{Op: domain.MutationInsert, Table: table, Key: c.Key, Value: c.Value})
+		}
+	} else {
+		err = handler.db.InsertContext(ctx, c.TableName, c.Key, c.Value, c.Ack)
+	}
 	if err != nil {
-		handler.logger.Error(fmt.Sprintf("Failed to insert key %s into table %s: %v", c.Key, c.TableName, err))
+		handler.logger.Error("Failed to insert key", "table", c.TableName, "key", c.Key, "error", err)
 		return err
 	}
 	return nil
@@ -83,33 +142,235 @@ func (c *InsertCommand) Execute(ctx context.Context, handler *CommandHandler) er
 type DeleteCommand struct {
 	TableName string
 	Key       string
+	Ack       domain.WriteAck // Optional per-write ack level (zero uses the database default)
 }
 
 // Execute executes the DeleteCommand.
 func (c *DeleteCommand) Execute(ctx context.Context, handler *CommandHandler) error {
-	handler.logger.Info(fmt.Sprintf("Executing DeleteCommand for key %s in table %s", c.Key, c.TableName))
-	err := handler.db.Delete(c.TableName, c.Key)
+	handler.logger.Info("Executing DeleteCommand", "table", c.TableName, "key", c.Key)
+	var err error
+	if handler.proposer != nil {
+		var table string
+		if table, err = domain.ResolveTable(ctx, c.TableName); err == nil {
+			err = handler.proposer.Propose(ctx, domain.Mutation{Op: domain.MutationDelete, Table: table, Key: c.Key})
+		}
+	} else {
+		err = handler.db.DeleteContext(ctx, c.TableName, c.Key, c.Ack)
+	}
+	if err != nil {
+		handler.logger.Error("Failed to delete key", "table", c.TableName, "key", c.Key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// errConditionalConsensus is returned by conditional, read-modify-write and range commands
+// when a Proposer is set: the current value or keys would be read from the local state, not
+// at the point the entry commits.
+var errConditionalConsensus = errors.New("conditional writes are not supported through a consensus log")
+
+// CompareAndSwapCommand sets a key only if its current value is ExpectedValue.
+// It fails with domain.ErrCompareFailed otherwise.
+type CompareAndSwapCommand struct {
+	TableName     string
+	Key           string
+	ExpectedValue string
+	NewValue      string
+	Ack           domain.WriteAck // Optional per-write ack level (zero uses the database default)
+}
+
+// Execute executes the CompareAndSwapCommand.
+func (c *CompareAndSwapCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing CompareAndSwapCommand", "table", c.TableName, "key", c.Key)
+	if handler.proposer != nil {
+		return errConditionalConsensus
+	}
+	table, err := domain.ResolveTable(ctx, c.TableName)
 	if err != nil {
-		handler.logger.Error(fmt.Sprintf("Failed to delete key %s from table %s: %v", c.Key, c.TableName, err))
+		return err
+	}
+	if err := handler.db.CompareAndSwapWithAck(table, c.Key, c.ExpectedValue, c.NewValue, c.Ack); err != nil {
+		handler.logger.Warn("Compare-and-swap not applied", "table", c.TableName, "key", c.Key, "error", err)
 		return err
 	}
 	return nil
 }
 
-// ExecuteCommand executes a command synchronously.
-func (h *CommandHandler) ExecuteCommand(ctx context.Context, cmd Command) error {
-	return cmd.Execute(ctx, h)
+// DeleteIfCommand deletes a key only if its current value is ExpectedValue.
+// It fails with domain.ErrCompareFailed otherwise.
+type DeleteIfCommand struct {
+	TableName     string
+	Key           string
+	ExpectedValue string
+	Ack           domain.WriteAck // Optional per-write ack level (zero uses the database default)
 }
 
-// ExecuteCommandAsync executes a command asynchronously.
+// Execute executes the DeleteIfCommand.
+func (c *DeleteIfCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing DeleteIfCommand", "table", c.TableName, "key", c.Key)
+	if handler.proposer != nil {
+		return errConditionalConsensus
+	}
+	table, err := domain.ResolveTable(ctx, c.TableName)
+	if err != nil {
+		return err
+	}
+	if err := handler.db.DeleteIfWithAck(table, c.Key, c.ExpectedValue, c.Ack); err != nil {
+		handler.logger.Warn("Delete-if not applied", "table", c.TableName, "key", c.Key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteRangeCommand deletes the keys of a table from StartKey up to, not including, EndKey
+// ("" = to the end of the table). Execute sets Deleted to the number of keys deleted.
+type DeleteRangeCommand struct {
+	TableName string
+	StartKey  string
+	EndKey    string
+	Ack       domain.WriteAck // Optional per-write ack level (zero uses the database default)
+	Deleted   int             // Keys deleted, set by Execute
+}
+
+// Execute executes the DeleteRangeCommand.
+func (c *DeleteRangeCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing DeleteRangeCommand", "table", c.TableName, "start", c.StartKey, "end", c.EndKey)
+	if handler.proposer != nil {
+		return errConditionalConsensus
+	}
+	table, err := domain.ResolveTable(ctx, c.TableName)
+	if err != nil {
+		return err
+	}
+	n, err := handler.db.DeleteRangeWithAck(table, c.StartKey, c.EndKey, c.Ack)
+	c.Deleted = n
+	if err != nil {
+		handler.logger.Error("Failed to delete key range", "table", c.TableName, "start", c.StartKey, "end", c.EndKey, "error", err)
+		return err
+	}
+	return nil
+}
+
+// InsertIfAbsentCommand inserts a key only if it does not exist yet.
+// It fails with domain.ErrKeyExists otherwise.
+type InsertIfAbsentCommand struct {
+	TableName string
+	Key       string
+	Value     string
+	Ack       domain.WriteAck // Optional per-write ack level (zero uses the database default)
+}
+
+// Execute executes the InsertIfAbsentCommand.
+func (c *InsertIfAbsentCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing InsertIfAbsentCommand", "table", c.TableName, "key", c.Key)
+	if handler.proposer != nil {
+		return errConditionalConsensus
+	}
+	table, err := domain.ResolveTable(ctx, c.TableName)
+	if err != nil {
+		return err
+	}
+	if err := handler.db.InsertIfAbsentWithAck(table, c.Key, c.Value, c.Ack); err != nil {
+		handler.logger.Warn("Insert-if-absent not applied", "table", c.TableName, "key", c.Key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// IncrementCommand atomically adds Delta to the integer stored under Key (0 if missing).
+// Execute sets Value to the new value.
+type IncrementCommand struct {
+	TableName string
+	Key       string
+	Delta     int64
+	Ack       domain.WriteAck // Optional per-write ack level (zero uses the database default)
+	Value     int64           // New value, set by Execute
+}
+
+// Execute executes the IncrementCommand.
+func (c *IncrementCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing IncrementCommand", "table", c.TableName, "key", c.Key)
+	if handler.proposer != nil {
+		return errConditionalConsensus
+	}
+	table, err := domain.ResolveTable(ctx, c.TableName)
+	if err != nil {
+		return err
+	}
+	value, err := handler.db.IncrementWithAck(table, c.Key, c.Delta, c.Ack)
+	if err != nil {
+		handler.logger.Error("Failed to increment key", "table", c.TableName, "key", c.Key, "error", err)
+		return err
+	}
+	c.Value = value
+	return nil
+}
+
+// SyncCommand makes every write committed before it durable, e.g. as the commit point
+// after a batch of messages was processed.
+type SyncCommand struct{}
+
+// Execute executes the SyncCommand.
+func (c *SyncCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing SyncCommand")
+	if err := handler.db.Sync(ctx); err != nil {
+		handler.logger.Error("Failed to sync database", "error", err)
+		return err
+	}
+	return nil
+}
+
+// CompactCommand represents a command to compact the storage adapter now. It stops before
+// the next compaction step once ctx is done.
+type CompactCommand struct{}
+
+// Execute executes the CompactCommand.
+func (c *CompactCommand) Execute(ctx context.Context, handler *CommandHandler) error {
+	handler.logger.Info("Executing CompactCommand")
+	if err := handler.db.Compact(ctx); err != nil {
+		handler.logger.Error("Failed to compact database", "error", err)
+		return err
+	}
+	return nil
+}
+
+// ExecuteCommand executes a command synchronously. The command must finish within the
+// database's WriteTimeout (CompactionTimeout for CompactCommand), or it fails with a
+// *domain.TimeoutError. With an Authorizer (see SetAuthorizer), a command the caller of ctx
+// may not run fails without being executed; with an Admission (see SetAdmission), so does a
+// command over its rate limits, with ErrThrottled.
+func (h *CommandHandler) ExecuteCommand(ctx context.Context, cmd Command) (err error) {
+	ctx, span := startSpan(ctx, h.tracer, "command", cmd)
+	defer func() { domain.EndSpan(span, err) }()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := authorize(ctx, h.authorizer.Load(), cmd); err != nil {
+		h.logger.Warn("Command not authorized", "command", opName(cmd), "error", err)
+		return err
+	}
+	if err := admit(ctx, h.admission.Load(), cmd); err != nil {
+		return err
+	}
+	class := domain.OperationWrite
+	if _, ok := cmd.(*CompactCommand); ok {
+		class = domain.OperationCompaction
+	}
+	ctx, cancel := h.db.WithTimeout(ctx, class, opName(cmd))
+	defer cancel()
+	if err := h.limiter.Wait(ctx); err != nil {
+		return domain.TimeoutErr(ctx, err)
+	}
+	return domain.TimeoutErr(ctx, cmd.Execute(ctx, h))
+}
+
+// ExecuteCommandAsync executes a command asynchronously. With an outbox (see EnableOutbox),
+// commands run in the order they were accepted and survive a crash; a command that cannot be
+// recorded is logged and dropped. Use EnqueueCommand to get that error.
 func (h *CommandHandler) ExecuteCommandAsync(ctx context.Context, cmd Command) {
-	h.wg.Add(1)
-	go func() {
-		defer h.wg.Done()
-		if err := cmd.Execute(ctx, h); err != nil {
-			h.logger.Error(fmt.Sprintf("Async command execution failed: %v", err))
-		}
-	}()
+	if err := h.EnqueueCommand(ctx, cmd); err != nil {
+		h.logger.Error("Failed to accept async command", "error", err)
+	}
 }
 
 func (h *CommandHandler) DB() *domain.Database {
@@ -0,0 +1,249 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 스캔 필터: ScanQuery와 ScanPageQuery는 Filter 식이나 Match 콜백(둘 다 주면 둘 다 만족해야 함)으로
+// 항목을 거릅니다. 필터는 스캔 콜백 안에서 평가되므로 맞지 않는 항목은 결과에 담기지 않고, Limit과
+// 페이지 크기도 맞는 항목만 셉니다. 임베디드 사용에는 Match를, HTTP/gRPC처럼 식을 문자열로 받아야
+// 하는 경우에는 Filter를 씁니다.
+//
+// 식의 문법 (키워드는 대소문자를 가리지 않음):
+//
+//	expr    = and { OR and }
+//	and     = not { AND not }
+//	not     = NOT not | "(" expr ")" | field op string
+//	field   = key | value
+//	op      = "=" | "!=" | "<" | "<=" | ">" | ">=" | CONTAINS | PREFIX | SUFFIX
+//	string  = Go 문법의 큰따옴표 문자열 ("a\"b")
+//
+// 비교는 바이트 순서이며, 예를 들면 `key >= "a" AND value CONTAINS "x"`처럼 씁니다.
+
+// ErrInvalidFilter is returned when a filter expression cannot be parsed.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterFunc reports whether a scanned entry belongs in the result.
+type FilterFunc func(key, value string) bool
+
+// ParseFilter compiles a filter expression (see the grammar above) into a FilterFunc.
+func ParseFilter(expr string) (FilterFunc, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidFilter, p.tokens[p.pos].text)
+	}
+	return f, nil
+}
+
+// scanFilter returns the filter of a scan query with expression expr and callback match,
+// or nil if it has neither.
+func scanFilter(expr string, match FilterFunc) (FilterFunc, error) {
+	if expr == "" {
+		return match, nil
+	}
+	f, err := ParseFilter(expr)
+	if err != nil || match == nil {
+		return f, err
+	}
+	return func(key, value string) bool { return f(key, value) && match(key, value) }, nil
+}
+
+// filterToken is a token of a filter expression. Strings hold their unquoted text.
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+// lexFilter splits expr into words, operators, parentheses and quoted strings.
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, filterToken{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidFilter)
+			}
+			s, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad string %s", ErrInvalidFilter, expr[i:end+1])
+			}
+			tokens = append(tokens, filterToken{text: s, quoted: true})
+			i = end + 1
+		case strings.ContainsRune("=!<>", rune(c)):
+			n := 1
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				n = 2
+			}
+			tokens = append(tokens, filterToken{text: expr[i : i+n]})
+			i += n
+		case isWordByte(c):
+			end := i
+			for end < len(expr) && isWordByte(expr[end]) {
+				end++
+			}
+			tokens = append(tokens, filterToken{text: strings.ToUpper(expr[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidFilter, c)
+		}
+	}
+	return tokens, nil
+}
+
+// isWordByte reports whether c belongs to a keyword or field name.
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// accept consumes the next token if it is the keyword or symbol text.
+func (p *filterParser) accept(text string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && p.tokens[p.pos].text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// next consumes and returns the next token.
+func (p *filterParser) next() (filterToken, error) {
+	if p.pos == len(p.tokens) {
+		return filterToken{}, fmt.Errorf("%w: unexpected end", ErrInvalidFilter)
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) or() (FilterFunc, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(key, value string) bool { return l(key, value) || right(key, value) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (FilterFunc, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(key, value string) bool { return l(key, value) && right(key, value) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) not() (FilterFunc, error) {
+	if p.accept("NOT") {
+		f, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(key, value string) bool { return !f(key, value) }, nil
+	}
+	if p.accept("(") {
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("%w: missing )", ErrInvalidFilter)
+		}
+		return f, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses `field op string`.
+func (p *filterParser) comparison() (FilterFunc, error) {
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	var pick func(key, value string) string
+	switch {
+	case !field.quoted && field.text == "KEY":
+		pick = func(key, _ string) string { return key }
+	case !field.quoted && field.text == "VALUE":
+		pick = func(_, value string) string { return value }
+	default:
+		return nil, fmt.Errorf("%w: expected key or value, got %q", ErrInvalidFilter, field.text)
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	operand, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.quoted || !operand.quoted {
+		return nil, fmt.Errorf("%w: expected %s <op> \"string\"", ErrInvalidFilter, strings.ToLower(field.text))
+	}
+	s := operand.text
+	var cmp func(v string) bool
+	switch op.text {
+	case "=":
+		cmp = func(v string) bool { return v == s }
+	case "!=":
+		cmp = func(v string) bool { return v != s }
+	case "<":
+		cmp = func(v string) bool { return v < s }
+	case "<=":
+		cmp = func(v string) bool { return v <= s }
+	case ">":
+		cmp = func(v string) bool { return v > s }
+	case ">=":
+		cmp = func(v string) bool { return v >= s }
+	case "CONTAINS":
+		cmp = func(v string) bool { return strings.Contains(v, s) }
+	case "PREFIX":
+		cmp = func(v string) bool { return strings.HasPrefix(v, s) }
+	case "SUFFIX":
+		cmp = func(v string) bool { return strings.HasSuffix(v, s) }
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, op.text)
+	}
+	return func(key, value string) bool { return cmp(pick(key, value)) }, nil
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// ManagerHandler routes commands and queries to the databases of a domain.Manager by the
+// database name of their context (see domain.WithDatabase). It keeps a CommandHandler and a
+// QueryHandler per database, created on first use; a database closed and opened again under
+// the same name gets new ones.
+type ManagerHandler struct {
+	manager *domain.Manager
+	logger  utils.Logger
+	mu      sync.Mutex
+	setup   func(*CommandHandler, *QueryHandler)
+	routes  map[string]*managedHandlers
+}
+
+type managedHandlers struct {
+	commands *CommandHandler
+	queries  *QueryHandler
+}
+
+// NewManagerHandler creates a handler routing to the databases of m.
+func NewManagerHandler(m *domain.Manager, logger utils.Logger) *ManagerHandler {
+	return &ManagerHandler{manager: m, logger: logger, routes: make(map[string]*managedHandlers)}
+}
+
+// SetHandlerSetup calls fn with the handlers of each database when they are created, e.g. to
+// set an Authorizer or an Admission shared by all databases. Handlers created before are kept.
+func (h *ManagerHandler) SetHandlerSetup(fn func(*CommandHandler, *QueryHandler)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.setup = fn
+}
+
+// Handlers returns the handlers of the database called name.
+func (h *ManagerHandler) Handlers(name string) (*CommandHandler, *QueryHandler, error) {
+	db, err := h.manager.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.routes[name]
+	if !ok || r.commands.DB() != db {
+		r = &managedHandlers{commands: NewCommandHandler(db, h.logger), queries: NewQueryHandler(db, h.logger)}
+		if h.setup != nil {
+			h.setup(r.commands, r.queries)
+		}
+		h.routes[name] = r
+	}
+	return r.commands, r.queries, nil
+}
+
+// route returns the handlers of the database of ctx.
+func (h *ManagerHandler) route(ctx context.Context) (*CommandHandler, *QueryHandler, error) {
+	name := domain.DatabaseFrom(ctx)
+	if name == "" {
+		return nil, nil, fmt.Errorf("%w: request names no database", domain.ErrDatabaseNotFound)
+	}
+	return h.Handlers(name)
+}
+
+// ExecuteCommand executes cmd on the database of ctx (see CommandHandler.ExecuteCommand).
+func (h *ManagerHandler) ExecuteCommand(ctx context.Context, cmd Command) error {
+	commands, _, err := h.route(ctx)
+	if err != nil {
+		return err
+	}
+	return commands.ExecuteCommand(ctx, cmd)
+}
+
+// ExecuteQuery executes query on the database of ctx (see QueryHandler.ExecuteQuery).
+func (h *ManagerHandler) ExecuteQuery(ctx context.Context, query Query) (interface{}, error) {
+	_, queries, err := h.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return queries.ExecuteQuery(ctx, query)
+}
+
+// Manager returns the manager whose databases h routes to, to open, list and close them.
+func (h *ManagerHandler) Manager() *domain.Manager {
+	return h.manager
+}
+
+// Wait waits for the asynchronous commands and queries of every database to complete.
+func (h *ManagerHandler) Wait() {
+	h.mu.Lock()
+	routes := make([]*managedHandlers, 0, len(h.routes))
+	for _, r := range h.routes {
+		routes = append(routes, r)
+	}
+	h.mu.Unlock()
+	for _, r := range routes {
+		r.commands.Wait()
+		r.queries.Wait()
+	}
+}
@@ -0,0 +1,359 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// 영속 아웃박스: EnableOutbox를 호출하면 ExecuteCommandAsync(와 EnqueueCommand)로 받은 명령을 실행
+// 전에 아웃박스 파일에 먼저 기록합니다. 아웃박스는 파일 어댑터(WAL + fsync-per-batch)를 그대로 쓰므로
+// 받은 명령은 fsync된 뒤에야 수락되고, 동시에 들어온 명령은 fsync 한 번에 함께 기록됩니다. 명령은
+// 단일 워커가 수락 순서대로 하나씩 실행하고 실행이 끝나면 아웃박스에서 지웁니다. 재시작 후
+// EnableOutbox는 남아 있던(수락됐지만 실행이 끝나지 않은) 명령을 새 명령보다 먼저 같은 순서로 다시
+// 실행합니다.
+//
+// 보장은 최소 한 번(at-least-once)입니다: 실행 직후 삭제 전에 장애가 나면 명령이 다시 실행되므로
+// Insert/Delete처럼 멱등인 명령이 적합합니다 (IncrementCommand는 두 번 더해질 수 있음). 실패한 명령은
+// 재시도하지 않고 로그를 남긴 뒤 지웁니다. 컨텍스트는 명령과 함께 저장되지 않으며 테넌트만 기록해
+// 실행 시 복원합니다 (우선순위, 마감 시각, 트레이스 부모는 버림). 기록하려면 명령 타입이 JSON으로
+// 직렬화되고 RegisterCommand로 등록되어 있어야 합니다; 이 패키지의 명령은 모두 등록되어 있습니다.
+
+// outboxRecord is a command as stored in the outbox.
+type outboxRecord struct {
+	Type    string          `json:"type"`
+	Tenant  string          `json:"tenant,omitempty"`
+	Command json.RawMessage `json:"command"`
+}
+
+// outboxEntry is an accepted command waiting for the worker. A nil cmd marks a sequence
+// number whose recording failed, so that the worker skips it.
+type outboxEntry struct {
+	seq    uint64
+	tenant string
+	cmd    Command
+}
+
+var (
+	commandTypesMu sync.RWMutex
+	commandTypes   = make(map[string]reflect.Type)
+)
+
+func init() {
+	for _, cmd := range []Command{
+		&CreateTableCommand{}, &DropTableCommand{}, &InsertCommand{}, &DeleteCommand{},
+		&CompareAndSwapCommand{}, &DeleteIfCommand{}, &DeleteRangeCommand{},
+		&InsertIfAbsentCommand{}, &IncrementCommand{},
+		&SyncCommand{}, &CompactCommand{},
+	} {
+		RegisterCommand(cmd)
+	}
+}
+
+// RegisterCommand allows commands of the type of cmd, a pointer to a struct that encodes to
+// JSON, to be recorded in the outbox.
+func RegisterCommand(cmd Command) {
+	t := reflect.TypeOf(cmd)
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("application: RegisterCommand of non-struct-pointer %T", cmd))
+	}
+	commandTypesMu.Lock()
+	defer commandTypesMu.Unlock()
+	commandTypes[t.Elem().String()] = t.Elem()
+}
+
+// commandType returns the registered name of cmd's type.
+func commandType(cmd Command) (string, error) {
+	t := reflect.TypeOf(cmd)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	commandTypesMu.RLock()
+	defer commandTypesMu.RUnlock()
+	if commandTypes[t.String()] != t {
+		return "", fmt.Errorf("command type %T is not registered for the outbox", cmd)
+	}
+	return t.String(), nil
+}
+
+// decodeCommand rebuilds the command of rec.
+func decodeCommand(rec outboxRecord) (Command, error) {
+	commandTypesMu.RLock()
+	t, ok := commandTypes[rec.Type]
+	commandTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown command type %q", rec.Type)
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(rec.Command, v.Interface()); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", rec.Type, err)
+	}
+	cmd, ok := v.Interface().(Command)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a command", rec.Type)
+	}
+	return cmd, nil
+}
+
+// outbox is the durable queue of async commands.
+type outbox struct {
+	store  *file.File
+	logger utils.Logger
+
+	mu     sync.Mutex
+	replay []outboxEntry // Commands left by a previous run, in order
+	queue  []outboxEntry // Commands accepted since, by sequence number
+	next   uint64        // Sequence number of the next accepted command
+	run    uint64        // Sequence number the worker runs next
+	active int           // Commands being run (0 or 1)
+	closed bool
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func outboxKey(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
+
+// EnableOutbox records async commands in the outbox at path before running them, in order, on
+// a single worker (see outbox.go). Commands left in the outbox by a previous run are queued
+// first; their number is returned.
+func (h *CommandHandler) EnableOutbox(path string) (int, error) {
+	if h.outbox.Load() != nil {
+		return 0, errors.New("command outbox is already enabled")
+	}
+	logger := utils.With(h.logger, "outbox", path)
+	store, err := file.NewFile(file.FileConfig{
+		FilePath:   path,
+		Durability: ports.DurabilityFsyncPerBatch,
+		Logger:     logger,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to open command outbox %s: %v", path, err)
+	}
+	o := &outbox{
+		store:  store,
+		logger: logger,
+		next:   1,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	// 키가 0으로 채운 시퀀스라 키 순서가 수락 순서
+	err = store.Scan("", func(key string, value interface{}) bool {
+		seq, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			// 시퀀스가 아닌 키는 실행 후 seq로 지울 수 없으므로 읽을 수 없는 기록처럼 지움
+			logger.Error("Dropping outbox command with invalid key", "key", key, "error", err)
+			store.Delete(key)
+			return true
+		}
+		if seq >= o.next {
+			o.next = seq + 1
+		}
+		s, _ := ports.ValueString(value)
+		var rec outboxRecord
+		cmd, err := func() (Command, error) {
+			if err := json.Unmarshal([]byte(s), &rec); err != nil {
+				return nil, err
+			}
+			return decodeCommand(rec)
+		}()
+		if err != nil {
+			// 실행할 수 없는 기록은 큐를 막지 않도록 지움
+			logger.Error("Dropping unreadable outbox command", "key", key, "error", err)
+			store.Delete(key)
+			return true
+		}
+		o.replay = append(o.replay, outboxEntry{seq: seq, tenant: rec.Tenant, cmd: cmd})
+		return true
+	})
+	if err != nil {
+		store.Close()
+		return 0, fmt.Errorf("failed to read command outbox %s: %v", path, err)
+	}
+	o.run = o.next
+	pending := len(o.replay)
+	if pending > 0 {
+		logger.Info("Re-executing commands left in outbox", "commands", pending)
+		o.signal()
+	}
+	h.wg.Add(pending)
+	h.outbox.Store(o)
+	go h.runOutbox(o)
+	return pending, nil
+}
+
+// EnqueueCommand accepts cmd for asynchronous execution. With an outbox, it returns once cmd is
+// durably recorded and runs it after every command accepted before it; without one, it starts
+// cmd at once (see ExecuteCommandAsync).
+func (h *CommandHandler) EnqueueCommand(ctx context.Context, cmd Command) error {
+	o := h.outbox.Load()
+	if o == nil {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			if err := h.ExecuteCommand(ctx, cmd); err != nil {
+				h.logger.Error("Async command execution failed", "error", err)
+			}
+		}()
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := authorize(ctx, h.authorizer.Load(), cmd); err != nil {
+		return err
+	}
+	if err := admit(ctx, h.admission.Load(), cmd); err != nil {
+		return err
+	}
+	typ, err := commandType(cmd)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", typ, err)
+	}
+	tenant := domain.TenantFrom(ctx)
+	record, _ := json.Marshal(outboxRecord{Type: typ, Tenant: tenant, Command: data})
+
+	// 시퀀스 번호는 잠금 안에서 정하고 fsync는 잠금 밖에서 함께 기다림. 기록이 먼저 끝난 뒤
+	// 번호도 워커는 앞 번호가 들어올 때까지 기다리므로 실행 순서는 번호 순서
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return errors.New("command outbox is closed")
+	}
+	seq := o.next
+	o.next++
+	h.wg.Add(1)
+	o.mu.Unlock()
+	if err := o.store.Insert(outboxKey(seq), string(record)); err != nil {
+		o.push(outboxEntry{seq: seq})
+		return fmt.Errorf("failed to record %s in outbox: %v", typ, err)
+	}
+	o.push(outboxEntry{seq: seq, tenant: tenant, cmd: cmd})
+	return nil
+}
+
+// push queues e by sequence number; concurrent enqueuers may finish their fsync out of order.
+func (o *outbox) push(e outboxEntry) {
+	o.mu.Lock()
+	i := len(o.queue)
+	for i > 0 && o.queue[i-1].seq > e.seq {
+		i--
+	}
+	o.queue = append(o.queue, outboxEntry{})
+	copy(o.queue[i+1:], o.queue[i:])
+	o.queue[i] = e
+	o.mu.Unlock()
+	o.signal()
+}
+
+func (o *outbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next command to run, if it was recorded.
+func (o *outbox) pop() (outboxEntry, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var e outboxEntry
+	switch {
+	case len(o.replay) > 0:
+		e, o.replay = o.replay[0], o.replay[1:]
+	case len(o.queue) > 0 && o.queue[0].seq == o.run:
+		e, o.queue = o.queue[0], o.queue[1:]
+		o.run++
+	default:
+		return outboxEntry{}, false
+	}
+	if e.cmd != nil {
+		o.active = 1
+	}
+	return e, true
+}
+
+// runOutbox runs the queued commands in order until the outbox is closed.
+func (h *CommandHandler) runOutbox(o *outbox) {
+	defer close(o.done)
+	for {
+		for {
+			e, ok := o.pop()
+			if !ok {
+				break
+			}
+			h.runOutboxEntry(o, e)
+		}
+		select {
+		case <-o.wake:
+		case <-o.stop:
+			return
+		}
+	}
+}
+
+func (h *CommandHandler) runOutboxEntry(o *outbox, e outboxEntry) {
+	defer h.wg.Done()
+	if e.cmd == nil {
+		return // 기록에 실패해 수락되지 않은 번호
+	}
+	defer func() {
+		o.mu.Lock()
+		o.active = 0
+		o.mu.Unlock()
+	}()
+	ctx := withAdmitted(context.Background()) // EnqueueCommand에서 검사함
+	if e.tenant != "" {
+		ctx = domain.WithTenant(ctx, e.tenant)
+	}
+	if err := h.ExecuteCommand(ctx, e.cmd); err != nil {
+		o.logger.Error("Outbox command failed", "seq", e.seq, "command", fmt.Sprintf("%T", e.cmd), "error", err)
+	}
+	if err := o.store.Delete(outboxKey(e.seq)); err != nil {
+		o.logger.Error("Failed to remove command from outbox", "seq", e.seq, "error", err)
+	}
+}
+
+// OutboxPending returns the number of accepted commands not yet finished (0 without an outbox).
+func (h *CommandHandler) OutboxPending() int {
+	o := h.outbox.Load()
+	if o == nil {
+		return 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.replay) + len(o.queue) + o.active
+}
+
+// CloseOutbox runs the commands already accepted, then stops the worker and closes the outbox.
+// Later async commands start at once, as without an outbox.
+func (h *CommandHandler) CloseOutbox() error {
+	o := h.outbox.Load()
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	o.closed = true
+	o.mu.Unlock()
+	h.wg.Wait()
+	close(o.stop)
+	<-o.done
+	h.outbox.Store(nil)
+	return o.store.Close()
+}
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// 스캔 페이지네이션: ScanPageQuery는 한 번에 Limit개까지의 항목과, 더 남아 있으면 마지막 키를 담은
+// 불투명 토큰 NextToken을 돌려줍니다. 클라이언트가 그 토큰을 다음 질의의 Token으로 넘기면 그 키 다음부터
+// 이어 읽으므로, HTTP/gRPC 서버는 커서 상태를 들고 있지 않아도 됩니다. 페이지 사이의 쓰기는 보일 수도
+// 안 보일 수도 있지만, 키 순서로 이어 가므로 같은 키를 두 번 돌려주지는 않습니다.
+//
+// 토큰은 버전, 테이블과 접두어(와 필터 식)의 체크섬, 마지막 키를 base64url로 인코딩한 것으로, 형식이
+// 잘못되었거나 다른 테이블이나 접두어, 필터의 질의에 쓰면 ErrInvalidPageToken으로 거절합니다. 비밀이
+// 아니므로 키 이름을 숨겨야 하면 토큰을 그대로 노출하지 않습니다. 저장소에 탐색(seek) 연산이 없어 각
+// 페이지는 접두어의 처음부터 토큰의 키까지 건너뛰며 읽습니다.
+
+// ErrInvalidPageToken is returned when a page token is malformed or was issued for another
+// table or prefix.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// pageTokenVersion is the first byte of an encoded page token.
+const pageTokenVersion byte = 1
+
+// DefaultPageSize is the Limit of a ScanPageQuery that sets none.
+const DefaultPageSize = 100
+
+// ScanPageQuery represents a query for one page of the entries of a table whose key starts
+// with Prefix, in key order, dropping the entries Filter or Match rejects (see filter.go).
+// Token is the NextToken of the previous page ("" for the first). The result is a *ScanPage.
+type ScanPageQuery struct {
+	TableName string
+	Prefix    string
+	Limit     int // Entries per page (DefaultPageSize if <= 0)
+	Token     string
+	Filter    string     // Filter expression ("" = none); a token is only valid with the same Filter
+	Match     FilterFunc // Filter callback for embedded use (nil = none)
+}
+
+// ScanPage is a page of entries returned by ScanPageQuery.
+type ScanPage struct {
+	Entries   []KeyValue
+	NextToken string // Token of the next page ("" if this is the last page)
+}
+
+// Execute executes the ScanPageQuery.
+func (q *ScanPageQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing ScanPageQuery", "table", q.TableName, "prefix", q.Prefix, "filter", q.Filter)
+	match, err := scanFilter(q.Filter, q.Match)
+	if err != nil {
+		return nil, err
+	}
+	var after string
+	resume := q.Token != ""
+	if resume {
+		if after, err = decodePageToken(q.Token, q.TableName, q.Prefix, q.Filter); err != nil {
+			return nil, err
+		}
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	page := &ScanPage{}
+	more := false
+	err = handler.db.ScanContext(ctx, q.TableName, q.Prefix, func(key, value string) bool {
+		if resume && key <= after || match != nil && !match(key, value) {
+			return true
+		}
+		if len(page.Entries) == limit {
+			more = true
+			return false
+		}
+		page.Entries = append(page.Entries, KeyValue{Key: key, Value: value})
+		return true
+	})
+	if err != nil {
+		handler.logger.Warn("Failed to scan table", "table", q.TableName, "prefix", q.Prefix, "error", err)
+		return nil, err
+	}
+	if more {
+		page.NextToken = encodePageToken(q.TableName, q.Prefix, q.Filter, page.Entries[len(page.Entries)-1].Key)
+	}
+	return page, nil
+}
+
+// pageScope is the checksum binding a token to the table, prefix and filter expression of its
+// query. Without a filter it is the checksum of earlier tokens, which stay valid.
+func pageScope(table, prefix, filter string) uint32 {
+	h := crc32.NewIEEE()
+	h.Write([]byte(table))
+	h.Write([]byte{0})
+	h.Write([]byte(prefix))
+	if filter != "" {
+		h.Write([]byte{0})
+		h.Write([]byte(filter))
+	}
+	return h.Sum32()
+}
+
+// encodePageToken returns the token resuming a scan of table, prefix and filter after the
+// key last.
+func encodePageToken(table, prefix, filter, last string) string {
+	buf := make([]byte, 0, 5+len(last))
+	buf = append(buf, pageTokenVersion)
+	buf = binary.BigEndian.AppendUint32(buf, pageScope(table, prefix, filter))
+	buf = append(buf, last...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// decodePageToken returns the last key of a token issued for table, prefix and filter.
+func decodePageToken(token, table, prefix, filter string) (string, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) < 5 || buf[0] != pageTokenVersion {
+		return "", fmt.Errorf("%w: malformed", ErrInvalidPageToken)
+	}
+	if binary.BigEndian.Uint32(buf[1:5]) != pageScope(table, prefix, filter) {
+		return "", fmt.Errorf("%w: issued for another table, prefix or filter", ErrInvalidPageToken)
+	}
+	return string(buf[5:]), nil
+}
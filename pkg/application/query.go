@@ -2,11 +2,14 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sukryu/GoLite/pkg/domain"
 	"github.com/sukryu/GoLite/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // QueryHandler handles execution of queries against the database.
@@ -14,6 +17,10 @@ type QueryHandler struct {
 	db     *domain.Database
 	logger utils.Logger
 	wg     sync.WaitGroup // For async query execution tracking
+	tracer trace.Tracer
+
+	authorizer atomic.Pointer[Authorizer] // Optional access control (see auth.go)
+	admission  atomic.Pointer[Admission]  // Optional rate limits rejecting queries (see admission.go)
 }
 
 // NewQueryHandler creates a new QueryHandler instance.
@@ -21,9 +28,15 @@ func NewQueryHandler(db *domain.Database, logger utils.Logger) *QueryHandler {
 	return &QueryHandler{
 		db:     db,
 		logger: logger,
+		tracer: newTracer(db.TracerProvider()),
 	}
 }
 
+// SetTracerProvider sends the spans of queries to tp instead of the database's provider.
+func (h *QueryHandler) SetTracerProvider(tp trace.TracerProvider) {
+	h.tracer = newTracer(tp)
+}
+
 // Query defines the interface for all queries.
 type Query interface {
 	Execute(ctx context.Context, handler *QueryHandler) (interface{}, error)
@@ -37,21 +50,187 @@ type GetValueQuery struct {
 
 // Execute executes the GetValueQuery.
 func (q *GetValueQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
-	handler.logger.Info(fmt.Sprintf("Executing GetValueQuery for key %s in table %s", q.Key, q.TableName))
-	value, err := handler.db.Get(q.TableName, q.Key)
+	handler.logger.Info("Executing GetValueQuery", "table", q.TableName, "key", q.Key)
+	value, err := handler.db.GetContext(ctx, q.TableName, q.Key)
 	if err != nil {
-		handler.logger.Warn(fmt.Sprintf("Failed to get key %s from table %s: %v", q.Key, q.TableName, err))
+		handler.logger.Warn("Failed to get key", "table", q.TableName, "key", q.Key, "error", err)
 		return nil, err
 	}
 	return value, nil
 }
 
-// GetStatusQuery represents a query to retrieve the database status.
-type GetStatusQuery struct{}
+// SearchQuery represents a query for the keys of a table whose value contains a term
+// (see domain.Database.CreateSearchIndex). The result is a []string of keys.
+type SearchQuery struct {
+	TableName string
+	Term      string
+}
+
+// Execute executes the SearchQuery.
+func (q *SearchQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing SearchQuery", "table", q.TableName, "term", q.Term)
+	table, err := domain.ResolveTable(ctx, q.TableName)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := handler.db.Search(table, q.Term)
+	if err != nil {
+		handler.logger.Warn("Failed to search table", "table", q.TableName, "term", q.Term, "error", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ScanQuery represents a query for the entries of a table whose key starts with Prefix, in
+// key order. Filter and Match drop the entries that don't match (see filter.go). Limit caps
+// the entries returned (0 = all). The scan stops with the error of ctx once ctx is done. The
+// result is a []KeyValue.
+type ScanQuery struct {
+	TableName string
+	Prefix    string
+	Limit     int
+	Filter    string     // Filter expression, e.g. `value CONTAINS "x"` ("" = none)
+	Match     FilterFunc // Filter callback for embedded use (nil = none)
+}
+
+// KeyValue is an entry returned by ScanQuery.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Execute executes the ScanQuery.
+func (q *ScanQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing ScanQuery", "table", q.TableName, "prefix", q.Prefix, "filter", q.Filter)
+	match, err := scanFilter(q.Filter, q.Match)
+	if err != nil {
+		return nil, err
+	}
+	var entries []KeyValue
+	err = handler.db.ScanContext(ctx, q.TableName, q.Prefix, func(key, value string) bool {
+		if match != nil && !match(key, value) {
+			return true
+		}
+		entries = append(entries, KeyValue{Key: key, Value: value})
+		return q.Limit <= 0 || len(entries) < q.Limit
+	})
+	if err != nil {
+		handler.logger.Warn("Failed to scan table", "table", q.TableName, "prefix", q.Prefix, "error", err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WatchQuery streams the changes of the keys of a table starting with Prefix. The result is
+// a *domain.Watcher whose channel C receives the changes; it is closed when ctx ends or
+// the caller calls Close.
+type WatchQuery struct {
+	TableName string
+	Prefix    string
+	Buffer    int // Channel capacity (domain.DefaultWatchBuffer if <= 0)
+}
+
+// Execute executes the WatchQuery.
+func (q *WatchQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing WatchQuery", "table", q.TableName, "prefix", q.Prefix)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	table, err := domain.ResolveTable(ctx, q.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := handler.db.GetSpec().Tables[table]; !ok {
+		return nil, fmt.Errorf("table %s not found", q.TableName)
+	}
+	w := handler.db.Watch(table, q.Prefix, q.Buffer)
+	context.AfterFunc(ctx, w.Close)
+	return w, nil
+}
+
+// SubscribeQuery streams the existing keys of a table starting with Prefix, then their live
+// changes. The result is a *domain.Subscription whose channel C receives them; it is closed
+// when ctx ends or the caller calls Close.
+type SubscribeQuery struct {
+	TableName string
+	Prefix    string
+	Buffer    int // Queued live changes before the subscription fails (domain.DefaultSubscriptionBuffer if <= 0)
+}
+
+// Execute executes the SubscribeQuery.
+func (q *SubscribeQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing SubscribeQuery", "table", q.TableName, "prefix", q.Prefix)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	table, err := domain.ResolveTable(ctx, q.TableName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := handler.db.SubscribeFrom(table, q.Prefix, q.Buffer)
+	if err != nil {
+		return nil, err
+	}
+	context.AfterFunc(ctx, s.Close)
+	return s, nil
+}
+
+// ChangesQuery reads the changefeed records with a sequence number above After. The result
+// is a []domain.ChangeRecord; pass the Seq of its last record as After to continue.
+type ChangesQuery struct {
+	After uint64
+	Limit int // Maximum records returned (all if <= 0)
+}
+
+// Execute executes the ChangesQuery.
+func (q *ChangesQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing ChangesQuery", "after", q.After)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return handler.db.ChangesSince(q.After, q.Limit)
+}
+
+// ExplainQuery runs a read and reports its cost. It reads Key, or scans the keys starting
+// with Prefix if Scan is set. The result is a domain.ReadExplain.
+type ExplainQuery struct {
+	TableName string
+	Key       string
+	Prefix    string
+	Scan      bool
+}
+
+// Execute executes the ExplainQuery.
+func (q *ExplainQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
+	handler.logger.Info("Executing ExplainQuery", "table", q.TableName, "scan", q.Scan)
+	table, err := domain.ResolveTable(ctx, q.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if q.Scan {
+		return handler.db.ExplainScan(table, q.Prefix)
+	}
+	return handler.db.ExplainGet(table, q.Key)
+}
+
+// GetStatusQuery represents a query to retrieve the database status. With CheckHealth, it
+// runs domain.Database.HealthCheck first; the report is in the status's Health, and a failed
+// check is not an error of the query.
+type GetStatusQuery struct {
+	CheckHealth bool
+}
 
 // Execute executes the GetStatusQuery.
 func (q *GetStatusQuery) Execute(ctx context.Context, handler *QueryHandler) (interface{}, error) {
-	handler.logger.Info("Executing GetStatusQuery")
+	handler.logger.Info("Executing GetStatusQuery", "check_health", q.CheckHealth)
+	if q.CheckHealth {
+		if _, err := handler.db.HealthCheck(ctx); err != nil {
+			if !errors.Is(err, domain.ErrUnhealthy) {
+				return nil, err
+			}
+			handler.logger.Warn("Health check failed", "error", err)
+		}
+	}
 	status := handler.db.GetStatus()
 	return status, nil
 }
@@ -66,9 +245,33 @@ func (q *GetSpecQuery) Execute(ctx context.Context, handler *QueryHandler) (inte
 	return spec, nil
 }
 
-// ExecuteQuery executes a query synchronously and returns the result.
-func (h *QueryHandler) ExecuteQuery(ctx context.Context, query Query) (interface{}, error) {
-	return query.Execute(ctx, h)
+// ExecuteQuery executes a query synchronously and returns the result. The query must finish
+// within the database's ReadTimeout, or it fails with a *domain.TimeoutError; the streams of
+// WatchQuery and SubscribeQuery are not bounded by it. With an Authorizer (see SetAuthorizer),
+// a query the caller of ctx may not run fails without being executed; with an Admission (see
+// SetAdmission), so does a query over its rate limits, with ErrThrottled.
+func (h *QueryHandler) ExecuteQuery(ctx context.Context, query Query) (result interface{}, err error) {
+	ctx, span := startSpan(ctx, h.tracer, "query", query)
+	defer func() { domain.EndSpan(span, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, h.authorizer.Load(), query); err != nil {
+		h.logger.Warn("Query not authorized", "query", opName(query), "error", err)
+		return nil, err
+	}
+	if err := admit(ctx, h.admission.Load(), query); err != nil {
+		return nil, err
+	}
+	switch query.(type) {
+	case *WatchQuery, *SubscribeQuery:
+		// 결과 스트림은 ctx가 끝날 때 닫히므로 제한 시간을 걸지 않음
+		return query.Execute(ctx, h)
+	}
+	ctx, cancel := h.db.WithTimeout(ctx, domain.OperationRead, opName(query))
+	defer cancel()
+	result, err = query.Execute(ctx, h)
+	return result, domain.TimeoutErr(ctx, err)
 }
 
 // ExecuteQueryAsync executes a query asynchronously and returns a channel for the result.
@@ -77,7 +280,7 @@ func (h *QueryHandler) ExecuteQueryAsync(ctx context.Context, query Query) <-cha
 	h.wg.Add(1)
 	go func() {
 		defer h.wg.Done()
-		result, err := query.Execute(ctx, h)
+		result, err := h.ExecuteQuery(ctx, query)
 		resultChan <- QueryResult{Result: result, Err: err}
 		close(resultChan)
 	}()
@@ -0,0 +1,39 @@
+package application
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 명령과 질의마다 OpenTelemetry 스팬(golite.command.<타입>, golite.query.<타입>)을 만들고, 그
+// 컨텍스트를 Execute에 넘겨 저장소 연산 스팬(domain/tracing.go)이 자식으로 붙게 합니다. 핸들러는
+// 기본으로 데이터베이스의 TracerProvider를 쓰며, SetTracerProvider로 바꿀 수 있습니다.
+
+// startSpan starts the span of executing op, named after its type.
+func startSpan(ctx context.Context, tracer trace.Tracer, kind string, op interface{}) (context.Context, trace.Span) {
+	name := opName(op)
+	return tracer.Start(ctx, "golite."+kind+"."+name, trace.WithAttributes(
+		attribute.String("db.system", "golite"),
+		attribute.String("golite."+kind, name),
+	))
+}
+
+// opName returns the name of the type of a command or query.
+func opName(op interface{}) string {
+	t := reflect.TypeOf(op)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "unknown"
+	}
+	return t.Name()
+}
+
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	return tp.Tracer(domain.TracerName)
+}
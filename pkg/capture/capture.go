@@ -0,0 +1,133 @@
+// Package capture는 커밋된 변경 스트림(domain.Mutation)을 시각과 함께 파일로 기록하고, 이를 다른
+// 인스턴스에 원래 속도(또는 최대 속도)로 재생합니다. 운영 환경의 워크로드 형태를 테스트 인스턴스에서
+// 재현해 튜닝이나 회귀 테스트에 사용합니다.
+//
+// 파일 포맷: magic "GLCP" 뒤에 gob으로 인코딩된 Record가 이어집니다. 마지막 레코드가 잘려 있으면
+// (기록 중 종료) 그 앞까지만 읽습니다.
+package capture
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+var magic = []byte("GLCP")
+
+// Record is one captured mutation with its commit time.
+type Record struct {
+	Time     time.Time
+	Mutation domain.Mutation
+}
+
+// Recorder appends every mutation committed by a database to a capture file.
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	buf     *bufio.Writer
+	enc     *gob.Encoder
+	remove  func()
+	records int64
+	err     error // First write error; recording stops after it
+}
+
+// Start creates (or truncates) the capture file at path and records db's mutations until Close.
+func Start(db *domain.Database, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create capture file: %v", err)
+	}
+	buf := bufio.NewWriter(file)
+	if _, err := buf.Write(magic); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write capture header: %v", err)
+	}
+	r := &Recorder{file: file, buf: buf, enc: gob.NewEncoder(buf)}
+	r.remove = db.AddMutationListener(r.record)
+	return r, nil
+}
+
+// record runs under the database write lock, so it only encodes into the buffer.
+func (r *Recorder) record(m domain.Mutation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.enc == nil {
+		return
+	}
+	if err := r.enc.Encode(Record{Time: time.Now(), Mutation: m}); err != nil {
+		r.err = err
+		return
+	}
+	r.records++
+}
+
+// Records returns the number of mutations recorded so far.
+func (r *Recorder) Records() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.records
+}
+
+// Close stops recording and flushes the capture file.
+func (r *Recorder) Close() error {
+	r.remove()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enc == nil {
+		return nil
+	}
+	r.enc = nil
+	err := r.buf.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	if r.err != nil {
+		return fmt.Errorf("capture stopped after a write error: %v", r.err)
+	}
+	return err
+}
+
+// Reader reads the records of a capture file in order.
+type Reader struct {
+	file *os.File
+	dec  *gob.Decoder
+}
+
+// Open opens a capture file for reading.
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %v", err)
+	}
+	buf := bufio.NewReader(file)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(buf, header); err != nil || string(header) != string(magic) {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a capture file", path)
+	}
+	return &Reader{file: file, dec: gob.NewDecoder(buf)}, nil
+}
+
+// Next returns the next record, or io.EOF after the last complete one.
+func (r *Reader) Next() (Record, error) {
+	var rec Record
+	if err := r.dec.Decode(&rec); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return Record{}, io.EOF // 잘린 마지막 레코드
+		}
+		return Record{}, err
+	}
+	return rec, nil
+}
+
+// Close closes the capture file.
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,134 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Speed scales the captured inter-arrival times: 1 reproduces the original pacing, 2 runs
+	// twice as fast. 0 (or negative) applies mutations as fast as possible.
+	Speed float64
+	// Progress, if set, is called after every applied mutation.
+	Progress func(ReplayStats)
+}
+
+// ReplayStats summarizes a replay.
+type ReplayStats struct {
+	Applied  int64         // Mutations applied
+	Failed   int64         // Mutations the target rejected
+	Elapsed  time.Duration // Wall time of the replay
+	Captured time.Duration // Time span of the applied records in the capture
+	MaxLag   time.Duration // Largest delay behind the paced schedule (paced replays only)
+}
+
+// Rate returns the applied mutations per second.
+func (s ReplayStats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Applied) / s.Elapsed.Seconds()
+}
+
+// ParseSpeed parses "max", "1x", "2.5x" or a plain number into ReplayOptions.Speed.
+func ParseSpeed(s string) (float64, error) {
+	if s == "max" {
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed %q (want max or a positive multiplier such as 1x)", s)
+	}
+	return speed, nil
+}
+
+// Replay applies the records of r to db through the regular write path, so the target's
+// durability and acknowledgement settings apply. Rejected mutations are counted and skipped.
+// It stops at the end of the capture or when ctx is done.
+func Replay(ctx context.Context, r *Reader, db *domain.Database, opts ReplayOptions) (ReplayStats, error) {
+	var stats ReplayStats
+	var first time.Time
+	start := time.Now()
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			stats.Elapsed = time.Since(start)
+			return stats, fmt.Errorf("failed to read capture: %v", err)
+		}
+		if first.IsZero() {
+			first = rec.Time
+		}
+		offset := rec.Time.Sub(first)
+		if opts.Speed > 0 {
+			due := start.Add(time.Duration(float64(offset) / opts.Speed))
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-ctx.Done():
+					stats.Elapsed = time.Since(start)
+					return stats, ctx.Err()
+				case <-time.After(wait):
+				}
+			} else if -wait > stats.MaxLag {
+				stats.MaxLag = -wait
+			}
+		} else if ctx.Err() != nil {
+			stats.Elapsed = time.Since(start)
+			return stats, ctx.Err()
+		}
+
+		if err := apply(db, rec.Mutation); err != nil {
+			stats.Failed++
+		} else {
+			stats.Applied++
+		}
+		stats.Captured = offset
+		stats.Elapsed = time.Since(start)
+		if opts.Progress != nil {
+			opts.Progress(stats)
+		}
+	}
+	stats.Elapsed = time.Since(start)
+	return stats, nil
+}
+
+// apply performs m with the public write API. Creating an existing table and deleting a
+// missing key or table are not errors, so a capture can be replayed onto a non-empty target.
+func apply(db *domain.Database, m domain.Mutation) error {
+	switch m.Op {
+	case domain.MutationInsert:
+		return db.Insert(m.Table, m.Key, m.Value)
+	case domain.MutationDelete:
+		if err := db.Delete(m.Table, m.Key); err != nil && !errors.Is(err, ports.ErrKeyNotFound) {
+			return err
+		}
+		return nil
+	case domain.MutationCreateTable:
+		if err := db.CreateTableWithOptions(m.Table, domain.ParseTableOptions(m.Value)); err != nil && !hasTable(db, m.Table) {
+			return err
+		}
+		return nil
+	case domain.MutationDropTable:
+		if err := db.DropTable(m.Table); err != nil && hasTable(db, m.Table) {
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown mutation op: %v", m.Op)
+}
+
+func hasTable(db *domain.Database, name string) bool {
+	_, ok := db.GetSpec().Tables[name]
+	return ok
+}
@@ -0,0 +1,67 @@
+// Package codec은 ports.Codec 구현(JSON, Gob, Msgpack)을 제공합니다.
+// domain.Database.InsertValue/GetValue로 임의의 Go 구조체를 저장할 때 사용합니다.
+//
+//	db.InsertValue("users", "u1", User{Name: "Alice"})
+//	var u User
+//	err := db.GetValue("users", "u1", &u)
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	// JSON은 encoding/json을 사용하며, 사람이 읽을 수 있고 다른 언어와 호환됩니다.
+	JSON ports.Codec = jsonCodec{}
+	// Gob은 encoding/gob을 사용하며, Go 타입을 그대로 보존합니다.
+	// 인터페이스 필드에 담긴 구체 타입은 gob.Register로 미리 등록해야 합니다.
+	Gob ports.Codec = gobCodec{}
+	// Msgpack은 MessagePack을 사용하며, JSON보다 작고 빠릅니다.
+	Msgpack ports.Codec = msgpackCodec{}
+)
+
+// ByName returns the codec with the given name ("json", "gob" or "msgpack").
+func ByName(name string) (ports.Codec, error) {
+	for _, c := range []ports.Codec{JSON, Gob, Msgpack} {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown codec %q", name)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                                 { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)        { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, out interface{}) error { return json.Unmarshal(data, out) }
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, out interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(out)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                          { return "msgpack" }
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, out interface{}) error {
+	return msgpack.Unmarshal(data, out)
+}
@@ -0,0 +1,303 @@
+// Package config는 golite 서버 설정 파일(JSON)을 읽고, 실행 중 재적용 가능한 항목을 구분합니다.
+//
+// `reload:"true"` 태그가 붙은 필드만 SIGHUP 등으로 실행 중에 바꿀 수 있습니다. 나머지 필드는
+// 저장소 파일이나 복제 구성처럼 재시작이 필요한 항목이며, 재적용 시 변경되어 있으면 거부됩니다.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// Config holds the server settings. Field names in the file are the json tags.
+type Config struct {
+	StorageType string `json:"storage"`     // btree or file
+	FilePath    string `json:"file"`        // Database file path
+	ThreadSafe  bool   `json:"threadsafe"`  // Enable thread safety
+	ReplListen  string `json:"repl_listen"` // Address to accept replicas on (primary mode)
+	ReplicaOf   string `json:"replica_of"`  // Primary address to follow (replica mode)
+	RaftID      string `json:"raft_id"`     // Raft node ID (consensus mode)
+	RaftListen  string `json:"raft_listen"` // Address to serve Raft RPCs on
+	RaftPeers   string `json:"raft_peers"`  // Other members as id=host:port,id=host:port
+	KeyDict     bool   `json:"key_dict"`    // Compress hot key prefixes (btree storage)
+	Mmap        bool   `json:"mmap"`        // Read B-tree pages through a memory mapping
+	Durability  string `json:"durability"`  // none, async, fsync-per-commit or fsync-per-batch
+	Capture     string `json:"capture"`     // Record committed mutations here for `golite replay`
+
+	// 암호화 테이블의 데이터 키를 감싸는 마스터 키 파일 (hex로 인코딩된 16, 24 또는 32바이트)
+	MasterKeyFile string `json:"master_key_file"`
+
+	// 저장소 전체(페이지, WAL)를 암호화하는 키를 hex로 담은 환경 변수 이름. 교체 중에는 같은 이름에
+	// _PREVIOUS를 붙인 변수에 이전 키를 둡니다 (pagecrypt.EnvKey, rekey 유지보수 작업 참고)
+	StorageKeyEnv string `json:"storage_key_env"`
+
+	// 시작 시 재생한 WAL이 이 크기(바이트)나 시간을 넘으면 복구 직후 compaction (0 = 기본값)
+	RecoveryMaxWAL    int64    `json:"recovery_max_wal"`
+	RecoveryMaxReplay Duration `json:"recovery_max_replay"`
+
+	// 테이블 수, 헤더 크기, 키/값 크기가 하드 리밋의 이 비율에 이르면 경고 (0 = 0.8, 음수 = 끔)
+	SoftLimitRatio float64 `json:"soft_limit_ratio"`
+
+	// 조회/쓰기 p99 목표. 계속 넘으면 낮은 우선순위 요청부터 거절 (0 = 목표 없음)
+	SLOReadP99  Duration `json:"slo_read_p99"`
+	SLOWriteP99 Duration `json:"slo_write_p99"`
+
+	// 사용자 테이블의 커밋된 변경을 __changefeed 시스템 테이블에 기록하고 최근 레코드만 보존 (0 = 모두)
+	Changefeed          bool `json:"changefeed"`
+	ChangefeedRetention int  `json:"changefeed_retention"`
+
+	// 사용자 테이블 키마다 최근 버전 수와 버전 보존 기간 (둘 다 0이면 버전 관리 끔, 0 = 제한 없음)
+	VersionHistory   int      `json:"version_history"`
+	VersionRetention Duration `json:"version_retention"`
+
+	// 연산별 힙 할당과 읽고 쓴 바이트를 Stats에 누적 (golite_opstats 빌드 태그로도 켜짐)
+	OpStats bool `json:"op_stats"`
+
+	// 읽기마다 거친 페이지/SSTable/블록 수를 Stats에 누적
+	ReadAmplification bool `json:"read_amplification"`
+
+	// 데이터베이스가 주기적으로 실행하는 유지보수 작업 (vacuum, backup, verify)
+	Maintenance []MaintenanceTask `json:"maintenance"`
+
+	// 질의, 명령, 컴팩션 명령의 기본 제한 시간. 넘으면 ErrTimeout으로 실패 (0 = 없음)
+	ReadTimeout       Duration `json:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	CompactionTimeout Duration `json:"compaction_timeout"`
+
+	// 사용자 테이블 삽입을 거절하는 저장소 크기(바이트)와 테이블당 키 수 한도 (0 = 없음)
+	MaxDatabaseBytes int64 `json:"max_database_bytes"`
+	MaxKeysPerTable  int64 `json:"max_keys_per_table"`
+
+	// 비동기 명령을 실행 전에 기록하는 영속 아웃박스 파일 (빈 값 = 끔). 장애 후 남은 명령을 다시 실행
+	CommandOutbox string `json:"command_outbox"`
+
+	// /healthz, /readyz 프로브를 응답할 HTTP 주소 (빈 값 = 끔)
+	HealthListen string `json:"health_listen"`
+
+	// 종료 시 구성 요소를 닫는 데 기다리는 최대 시간 (0 = 끝까지 기다림)
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	// 로그 출력 형식: text(기본) 또는 json
+	LogFormat string `json:"log_format"`
+
+	// 실행 중 재적용 가능한 항목
+	LogLevel           string   `json:"log_level" reload:"true"`           // info, warn, error or silent
+	WriteRateLimit     float64  `json:"write_rate_limit" reload:"true"`    // Commands per second (0 = unlimited)
+	OpsLimit           float64  `json:"ops_limit" reload:"true"`           // Operations per second before ErrThrottled (0 = unlimited)
+	BytesLimit         float64  `json:"bytes_limit" reload:"true"`         // Written bytes per second before ErrThrottled (0 = unlimited)
+	ClientOpsLimit     float64  `json:"client_ops_limit" reload:"true"`    // Operations per second of each client (0 = unlimited)
+	ClientBytesLimit   float64  `json:"client_bytes_limit" reload:"true"`  // Written bytes per second of each client (0 = unlimited)
+	CacheSize          int      `json:"cache_size" reload:"true"`          // B-tree node cache capacity
+	CompactionInterval Duration `json:"compaction_interval" reload:"true"` // File adapter compaction period (0 = default)
+}
+
+// Defaults returns the settings used when neither the file nor a flag sets a field.
+func Defaults() Config {
+	return Config{
+		StorageType:     "btree",
+		FilePath:        "golite.db",
+		ThreadSafe:      true,
+		LogLevel:        "info",
+		CacheSize:       10,
+		ShutdownTimeout: Duration(30 * time.Second),
+	}
+}
+
+// Load reads the JSON file at path over base. Fields missing from the file keep base's values;
+// unknown fields are an error so that typos are not silently ignored.
+func Load(path string, base Config) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, fmt.Errorf("failed to read config %s: %v", path, err)
+	}
+	cfg := base
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return base, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks field values without touching any resource.
+func (c Config) Validate() error {
+	if c.StorageType != "btree" && c.StorageType != "file" {
+		return fmt.Errorf("invalid storage %q (want btree or file)", c.StorageType)
+	}
+	if c.FilePath == "" {
+		return fmt.Errorf("file is required")
+	}
+	if _, err := utils.ParseLogLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("invalid log_level: %v", err)
+	}
+	if _, err := utils.ParseLogFormat(c.LogFormat); err != nil {
+		return fmt.Errorf("invalid log_format: %v", err)
+	}
+	if c.WriteRateLimit < 0 {
+		return fmt.Errorf("write_rate_limit must be >= 0, got %v", c.WriteRateLimit)
+	}
+	for name, limit := range map[string]float64{
+		"ops_limit": c.OpsLimit, "bytes_limit": c.BytesLimit,
+		"client_ops_limit": c.ClientOpsLimit, "client_bytes_limit": c.ClientBytesLimit,
+	} {
+		if limit < 0 {
+			return fmt.Errorf("%s must be >= 0, got %v", name, limit)
+		}
+	}
+	if c.CacheSize < 0 {
+		return fmt.Errorf("cache_size must be >= 0, got %d", c.CacheSize)
+	}
+	if c.CompactionInterval < 0 {
+		return fmt.Errorf("compaction_interval must be >= 0, got %v", c.CompactionInterval)
+	}
+	if _, err := ports.ParseDurability(c.Durability); err != nil {
+		return err
+	}
+	if c.RecoveryMaxWAL < 0 || c.RecoveryMaxReplay < 0 {
+		return fmt.Errorf("recovery_max_wal and recovery_max_replay must be >= 0")
+	}
+	if c.SLOReadP99 < 0 || c.SLOWriteP99 < 0 {
+		return fmt.Errorf("slo_read_p99 and slo_write_p99 must be >= 0")
+	}
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown_timeout must be >= 0, got %v", c.ShutdownTimeout)
+	}
+	if c.MaxDatabaseBytes < 0 || c.MaxKeysPerTable < 0 {
+		return fmt.Errorf("max_database_bytes and max_keys_per_table must be >= 0")
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.CompactionTimeout < 0 {
+		return fmt.Errorf("read_timeout, write_timeout and compaction_timeout must be >= 0")
+	}
+	if c.ChangefeedRetention < 0 {
+		return fmt.Errorf("changefeed_retention must be >= 0, got %d", c.ChangefeedRetention)
+	}
+	if c.VersionHistory < 0 || c.VersionRetention < 0 {
+		return fmt.Errorf("version_history and version_retention must be >= 0")
+	}
+	if c.SoftLimitRatio > 1 {
+		return fmt.Errorf("soft_limit_ratio must be <= 1, got %v", c.SoftLimitRatio)
+	}
+	for i, t := range c.Maintenance {
+		switch t.Kind {
+		case "vacuum", "verify", "rekey", "purge":
+		case "backup":
+			if t.Target == "" {
+				return fmt.Errorf("maintenance[%d]: backup requires a target", i)
+			}
+		default:
+			return fmt.Errorf("maintenance[%d]: invalid kind %q (want vacuum, backup, verify, rekey or purge)", i, t.Kind)
+		}
+		if t.Every <= 0 || t.Jitter < 0 {
+			return fmt.Errorf("maintenance[%d]: every must be > 0 and jitter >= 0", i)
+		}
+	}
+	return nil
+}
+
+// MaintenanceTask is a scheduled upkeep job (see domain.MaintenanceTask), e.g.
+//
+//	{"kind": "backup", "every": "24h", "jitter": "30m", "target": "backups/golite-{time}.db"}
+type MaintenanceTask struct {
+	Name   string   `json:"name,omitempty"`
+	Kind   string   `json:"kind"` // vacuum, backup, verify, rekey or purge
+	Every  Duration `json:"every"`
+	Jitter Duration `json:"jitter,omitempty"`
+	Target string   `json:"target,omitempty"` // Backup path; "{time}" is replaced with the run time
+}
+
+// Change is one field that differs between two configurations.
+type Change struct {
+	Field      string      // json name of the field
+	Old        interface{} // Previous value
+	New        interface{} // New value
+	Reloadable bool        // Can be applied without a restart
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
+}
+
+// Diff returns the fields that differ between old and new, in declaration order.
+func Diff(old, new Config) []Change {
+	var changes []Change
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		o, n := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+		f := t.Field(i)
+		changes = append(changes, Change{
+			Field:      fieldName(f),
+			Old:        o,
+			New:        n,
+			Reloadable: f.Tag.Get("reload") == "true",
+		})
+	}
+	return changes
+}
+
+// Override copies the fields of src named in fields (json names) into dst. Unknown names are
+// ignored. Command-line flags use it to take precedence over the file.
+func Override(dst *Config, src Config, fields []string) {
+	dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	t := dv.Type()
+	for _, name := range fields {
+		for i := 0; i < t.NumField(); i++ {
+			if fieldName(t.Field(i)) == name {
+				dv.Field(i).Set(sv.Field(i))
+			}
+		}
+	}
+}
+
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Duration is a time.Duration written as a string such as "90s" in the file.
+// It also implements flag.Value.
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+// Set parses a duration string (flag.Value).
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a duration string or a number of seconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return d.Set(s)
+	}
+	var secs float64
+	if err := json.Unmarshal(data, &secs); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(secs * float64(time.Second))
+	return nil
+}
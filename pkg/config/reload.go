@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// Applier applies the reloadable settings of cfg to a running component.
+// It must not have side effects when it returns an error.
+type Applier func(cfg Config) error
+
+// Reloader re-reads the config file and applies the runtime-mutable subset.
+// Reload is all-or-nothing: a change to a field that needs a restart, an invalid value,
+// or a failing applier leaves the running configuration unchanged.
+type Reloader struct {
+	path     string
+	logger   utils.Logger
+	mu       sync.Mutex
+	current  Config
+	appliers []Applier
+	override func(*Config)
+}
+
+// NewReloader creates a reloader for the file at path, starting from the running configuration.
+func NewReloader(path string, current Config, logger utils.Logger) *Reloader {
+	return &Reloader{path: path, current: current, logger: logger}
+}
+
+// OnReload registers fn to be called with the new configuration on every reload that changes
+// a reloadable field. Appliers run in registration order.
+func (r *Reloader) OnReload(fn Applier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, fn)
+}
+
+// SetOverride registers fn to adjust the loaded file before it is compared, e.g. to keep
+// command-line flags taking precedence over the file.
+func (r *Reloader) SetOverride(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.override = fn
+}
+
+// Current returns the configuration last applied.
+func (r *Reloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload re-reads the file and applies it. It returns the changes that were applied.
+func (r *Reloader) Reload() ([]Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := Load(r.path, r.current)
+	if err != nil {
+		return nil, r.reject(err)
+	}
+	if r.override != nil {
+		r.override(&next)
+	}
+	changes := Diff(r.current, next)
+	if len(changes) == 0 {
+		r.logger.Info("Config reloaded: no changes", "path", r.path)
+		return nil, nil
+	}
+	var fixed []string
+	for _, c := range changes {
+		if !c.Reloadable {
+			fixed = append(fixed, c.String())
+		}
+	}
+	if len(fixed) > 0 {
+		return nil, r.reject(fmt.Errorf("restart required to change %s", strings.Join(fixed, ", ")))
+	}
+
+	for i, apply := range r.appliers {
+		if err := apply(next); err != nil {
+			// 이미 적용된 항목을 이전 값으로 되돌려 전체를 원자적으로 유지
+			for j := i - 1; j >= 0; j-- {
+				if rerr := r.appliers[j](r.current); rerr != nil {
+					r.logger.Error("Failed to roll back config", "error", rerr)
+				}
+			}
+			return nil, r.reject(err)
+		}
+	}
+	r.current = next
+	for _, c := range changes {
+		r.logger.Info("Config reloaded", "field", c.Field, "old", c.Old, "new", c.New)
+	}
+	return changes, nil
+}
+
+func (r *Reloader) reject(err error) error {
+	err = fmt.Errorf("config reload rejected, nothing applied: %v", err)
+	r.logger.Error("Config reload rejected", "path", r.path, "error", err)
+	return err
+}
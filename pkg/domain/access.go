@@ -0,0 +1,292 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 접근자: Database는 모든 연산을 제공하므로 이를 넘겨받은 컴포넌트는 무엇이든 바꿀 수 있습니다.
+// Reader는 조회만, Writer는 조회와 데이터/테이블 변경을 노출하는 얇은 래퍼로, 애플리케이션은
+// 읽기만 하는 컴포넌트에 Reader를 넘겨 변경을 컴파일 시점에 막을 수 있습니다. 두 타입 모두
+// 내부 Database를 외부에 돌려주지 않으며, 닫기나 스냅샷, 마이그레이션 같은 운영 작업은
+// Database를 가진 쪽에만 남습니다. 같은 Database의 접근자이므로 동시성 보장도 Database와 같습니다.
+
+// Reader is a read-only view of a Database.
+type Reader struct {
+	db *Database
+}
+
+// Writer is a view of a Database that can read and change data and tables, but not
+// manage the database itself (Close, Snapshot, Migrate, ...).
+type Writer struct {
+	Reader
+}
+
+// Reader returns a read-only accessor for db.
+func (db *Database) Reader() Reader {
+	return Reader{db: db}
+}
+
+// Writer returns a read-write accessor for db.
+func (db *Database) Writer() Writer {
+	return Writer{Reader{db: db}}
+}
+
+// Get retrieves a value by key from a table.
+func (r Reader) Get(tableName, key string) (string, error) { return r.db.Get(tableName, key) }
+
+// GetContext retrieves a value with the priority of ctx (see Database.GetContext).
+func (r Reader) GetContext(ctx context.Context, tableName, key string) (string, error) {
+	return r.db.GetContext(ctx, tableName, key)
+}
+
+// GetBytes retrieves a raw byte value from a table.
+func (r Reader) GetBytes(tableName, key string) ([]byte, error) {
+	return r.db.GetBytes(tableName, key)
+}
+
+// GetWithMeta retrieves a value with its metadata from a table created with metadata.
+func (r Reader) GetWithMeta(tableName, key string) (string, EntryMeta, error) {
+	return r.db.GetWithMeta(tableName, key)
+}
+
+// GetValue deserializes a value into out with the database codec.
+func (r Reader) GetValue(tableName, key string, out interface{}) error {
+	return r.db.GetValue(tableName, key, out)
+}
+
+// GetValueWithCodec deserializes a value into out with codec c.
+func (r Reader) GetValueWithCodec(tableName, key string, out interface{}, c ports.Codec) error {
+	return r.db.GetValueWithCodec(tableName, key, out, c)
+}
+
+// GetOrDefault returns def if the key does not exist.
+func (r Reader) GetOrDefault(tableName, key, def string) (string, error) {
+	return r.db.GetOrDefault(tableName, key, def)
+}
+
+// MultiGetOrDefault looks up several keys, using def for missing ones.
+func (r Reader) MultiGetOrDefault(tableName string, keys []string, def string) (map[string]string, error) {
+	return r.db.MultiGetOrDefault(tableName, keys, def)
+}
+
+// GetValueOrDefault deserializes a value into out and reports whether the key exists.
+func (r Reader) GetValueOrDefault(tableName, key string, out interface{}) (bool, error) {
+	return r.db.GetValueOrDefault(tableName, key, out)
+}
+
+// Scan calls fn for the entries of a table whose key starts with prefix.
+func (r Reader) Scan(tableName, prefix string, fn func(key, value string) bool) error {
+	return r.db.Scan(tableName, prefix, fn)
+}
+
+// ScanContext scans a table of the tenant of ctx (see Database.ScanContext).
+func (r Reader) ScanContext(ctx context.Context, tableName, prefix string, fn func(key, value string) bool) error {
+	return r.db.ScanContext(ctx, tableName, prefix, fn)
+}
+
+// ExplainGet reads a key and reports the cost of the lookup (see Database.ExplainGet).
+func (r Reader) ExplainGet(tableName, key string) (ReadExplain, error) {
+	return r.db.ExplainGet(tableName, key)
+}
+
+// ExplainScan scans a key range and reports the cost of the scan (see Database.ExplainScan).
+func (r Reader) ExplainScan(tableName, prefix string) (ReadExplain, error) {
+	return r.db.ExplainScan(tableName, prefix)
+}
+
+// Verify reads every table back from storage (see Database.Verify).
+func (r Reader) Verify(ctx context.Context) (VerifyReport, error) { return r.db.Verify(ctx) }
+
+// TenantStats returns the usage and quota of a tenant.
+func (r Reader) TenantStats(tenant string) (TenantStats, error) { return r.db.TenantStats(tenant) }
+
+// Tenants returns the tenants that have tables or a quota.
+func (r Reader) Tenants() []string { return r.db.Tenants() }
+
+// Search returns the keys of a table whose value contains term.
+func (r Reader) Search(tableName, term string) ([]string, error) { return r.db.Search(tableName, term) }
+
+// Count returns the number of live keys in a table.
+func (r Reader) Count(tableName string) (int64, error) { return r.db.Count(tableName) }
+
+// CountPrefix returns the maintained number of keys of a table starting with prefix.
+func (r Reader) CountPrefix(tableName, prefix string) (int64, error) {
+	return r.db.CountPrefix(tableName, prefix)
+}
+
+// ChangesSince returns the changefeed records after the sequence number after.
+func (r Reader) ChangesSince(after uint64, limit int) ([]ChangeRecord, error) {
+	return r.db.ChangesSince(after, limit)
+}
+
+// KeyStats returns the key counters of a table.
+func (r Reader) KeyStats(tableName string) (KeyStats, error) { return r.db.KeyStats(tableName) }
+
+// TableOptions returns the options a table was created with.
+func (r Reader) TableOptions(tableName string) (TableOptions, error) {
+	return r.db.TableOptions(tableName)
+}
+
+// GetSpec returns the database spec.
+func (r Reader) GetSpec() DatabaseSpec { return r.db.GetSpec() }
+
+// GetStatus returns the database status.
+func (r Reader) GetStatus() DatabaseStatus { return r.db.GetStatus() }
+
+// RecoveryInfo returns the recovery the storage ran when it was opened.
+func (r Reader) RecoveryInfo() (ports.RecoveryReport, bool) { return r.db.RecoveryInfo() }
+
+// Seq returns the sequence number of the last committed mutation.
+func (r Reader) Seq() uint64 { return r.db.Seq() }
+
+// Watch subscribes to the changes of the keys of a table starting with prefix.
+func (r Reader) Watch(tableName, prefix string, buffer int) *Watcher {
+	return r.db.Watch(tableName, prefix, buffer)
+}
+
+// SubscribeFrom streams the existing keys of a table starting with prefix, then their changes.
+func (r Reader) SubscribeFrom(tableName, prefix string, buffer int) (*Subscription, error) {
+	return r.db.SubscribeFrom(tableName, prefix, buffer)
+}
+
+// Subscribe subscribes to database events.
+func (r Reader) Subscribe(types ...events.Type) *events.Subscription {
+	return r.db.Subscribe(types...)
+}
+
+// Insert inserts or replaces a value in a table.
+func (w Writer) Insert(tableName, key, value string) error { return w.db.Insert(tableName, key, value) }
+
+// InsertWithAck inserts a value and waits for ack.
+func (w Writer) InsertWithAck(tableName, key, value string, ack WriteAck) error {
+	return w.db.InsertWithAck(tableName, key, value, ack)
+}
+
+// InsertContext inserts a value with the priority of ctx (see Database.InsertContext).
+func (w Writer) InsertContext(ctx context.Context, tableName, key, value string, ack WriteAck) error {
+	return w.db.InsertContext(ctx, tableName, key, value, ack)
+}
+
+// InsertWithFlags inserts a value with user flags into a table created with metadata.
+func (w Writer) InsertWithFlags(tableName, key, value string, flags byte) error {
+	return w.db.InsertWithFlags(tableName, key, value, flags)
+}
+
+// InsertBytes inserts a raw byte value.
+func (w Writer) InsertBytes(tableName, key string, value []byte) error {
+	return w.db.InsertBytes(tableName, key, value)
+}
+
+// InsertValue serializes v with the database codec and inserts it.
+func (w Writer) InsertValue(tableName, key string, v interface{}) error {
+	return w.db.InsertValue(tableName, key, v)
+}
+
+// InsertValueWithCodec serializes v with codec c and inserts it.
+func (w Writer) InsertValueWithCodec(tableName, key string, v interface{}, c ports.Codec) error {
+	return w.db.InsertValueWithCodec(tableName, key, v, c)
+}
+
+// CompareAndSwap sets a value only if the current one is expectedValue.
+func (w Writer) CompareAndSwap(tableName, key, expectedValue, newValue string) error {
+	return w.db.CompareAndSwap(tableName, key, expectedValue, newValue)
+}
+
+// InsertIfAbsent inserts a value only if the key is not stored yet.
+func (w Writer) InsertIfAbsent(tableName, key, value string) error {
+	return w.db.InsertIfAbsent(tableName, key, value)
+}
+
+// Increment adds delta to the integer stored under a key and returns the new value.
+func (w Writer) Increment(tableName, key string, delta int64) (int64, error) {
+	return w.db.Increment(tableName, key, delta)
+}
+
+// Delete deletes a key from a table.
+func (w Writer) Delete(tableName, key string) error { return w.db.Delete(tableName, key) }
+
+// DeleteWithAck deletes a key and waits for ack.
+func (w Writer) DeleteWithAck(tableName, key string, ack WriteAck) error {
+	return w.db.DeleteWithAck(tableName, key, ack)
+}
+
+// DeleteContext deletes a key with the priority of ctx (see Database.DeleteContext).
+func (w Writer) DeleteContext(ctx context.Context, tableName, key string, ack WriteAck) error {
+	return w.db.DeleteContext(ctx, tableName, key, ack)
+}
+
+// DeleteIf deletes a key only if its current value is expectedValue.
+func (w Writer) DeleteIf(tableName, key, expectedValue string) error {
+	return w.db.DeleteIf(tableName, key, expectedValue)
+}
+
+// DeleteRange deletes the keys in [startKey, endKey) and returns how many were deleted.
+func (w Writer) DeleteRange(tableName, startKey, endKey string) (int, error) {
+	return w.db.DeleteRange(tableName, startKey, endKey)
+}
+
+// Write applies a batch atomically.
+func (w Writer) Write(batch *WriteBatch) error { return w.db.Write(batch) }
+
+// WriteWithAck applies a batch atomically and waits for ack.
+func (w Writer) WriteWithAck(batch *WriteBatch, ack WriteAck) error {
+	return w.db.WriteWithAck(batch, ack)
+}
+
+// WriteContext applies a batch with the priority of ctx (see Database.WriteContext).
+func (w Writer) WriteContext(ctx context.Context, batch *WriteBatch, ack WriteAck) error {
+	return w.db.WriteContext(ctx, batch, ack)
+}
+
+// Sync makes every write committed before the call durable (see Database.Sync).
+func (w Writer) Sync(ctx context.Context) error { return w.db.Sync(ctx) }
+
+// Compact compacts the storage adapter now (see Database.Compact).
+func (w Writer) Compact(ctx context.Context) error { return w.db.Compact(ctx) }
+
+// RunMaintenance runs a maintenance task now (see Database.RunMaintenance).
+func (w Writer) RunMaintenance(ctx context.Context, name string) error {
+	return w.db.RunMaintenance(ctx, name)
+}
+
+// CreateTable creates a table.
+func (w Writer) CreateTable(name string) error { return w.db.CreateTable(name) }
+
+// CreateTableWithOptions creates a table with options.
+func (w Writer) CreateTableWithOptions(name string, opts TableOptions) error {
+	return w.db.CreateTableWithOptions(name, opts)
+}
+
+// DropTable drops a table and its data.
+func (w Writer) DropTable(name string) error { return w.db.DropTable(name) }
+
+// CreateTableContext creates a table of the tenant of ctx.
+func (w Writer) CreateTableContext(ctx context.Context, name string) error {
+	return w.db.CreateTableContext(ctx, name)
+}
+
+// DropTableContext drops a table of the tenant of ctx.
+func (w Writer) DropTableContext(ctx context.Context, name string) error {
+	return w.db.DropTableContext(ctx, name)
+}
+
+// SetTenantQuota sets the quota of a tenant.
+func (w Writer) SetTenantQuota(tenant string, quota TenantQuota) error {
+	return w.db.SetTenantQuota(tenant, quota)
+}
+
+// DropTenant drops every table of a tenant and its quota.
+func (w Writer) DropTenant(tenant string) error { return w.db.DropTenant(tenant) }
+
+// CreateCountView maintains the number of keys of a table starting with prefix.
+func (w Writer) CreateCountView(tableName, prefix string) error {
+	return w.db.CreateCountView(tableName, prefix)
+}
+
+// CreateSearchIndex creates or attaches the search index of a table.
+func (w Writer) CreateSearchIndex(tableName string, tokenize Tokenizer) error {
+	return w.db.CreateSearchIndex(tableName, tokenize)
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// AckLevel defines how durable a write must be before it is acknowledged to the caller.
+type AckLevel int
+
+const (
+	// AckDefault defers to DatabaseConfig.WriteAck (per-write override not set).
+	AckDefault AckLevel = iota
+	// AckLocal acknowledges once the write is applied to the local storage adapter.
+	AckLocal
+	// AckLeaderFsync acknowledges once the write is fsynced on the leader (this process).
+	AckLeaderFsync
+	// AckFollowers acknowledges once the write is fsynced locally and applied by N followers.
+	AckFollowers
+)
+
+// String returns a human-readable name for the ack level.
+func (l AckLevel) String() string {
+	switch l {
+	case AckDefault:
+		return "default"
+	case AckLocal:
+		return "local"
+	case AckLeaderFsync:
+		return "leader-fsync"
+	case AckFollowers:
+		return "followers"
+	default:
+		return fmt.Sprintf("AckLevel(%d)", int(l))
+	}
+}
+
+// DefaultAckTimeout is used when a WriteAck requires followers but sets no Timeout.
+const DefaultAckTimeout = 5 * time.Second
+
+// WriteAck selects the acknowledgement level for a write, globally or per operation.
+type WriteAck struct {
+	Level     AckLevel      // Required durability level
+	Followers int           // Number of follower acks required for AckFollowers
+	Timeout   time.Duration // Max time to wait for follower acks (0 = DefaultAckTimeout)
+}
+
+var (
+	// ErrAckTimeout is returned when the required follower acks did not arrive in time.
+	// The write is already applied locally; only its replication guarantee is unconfirmed.
+	ErrAckTimeout = errors.New("write acknowledgement timed out")
+
+	// ErrReplicationUnavailable is returned when AckFollowers is requested but no replicator is attached.
+	ErrReplicationUnavailable = errors.New("replication is not configured")
+)
+
+// ReplicaAcknowledger is implemented by the replication subsystem.
+// WaitForReplicas blocks until at least followers replicas applied the write with sequence seq.
+type ReplicaAcknowledger interface {
+	WaitForReplicas(ctx context.Context, seq uint64, followers int) error
+}
+
+// SetReplicaAcknowledger attaches the component used to confirm follower acks.
+func (db *Database) SetReplicaAcknowledger(acker ReplicaAcknowledger) {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	db.acker = acker
+}
+
+// resolveAck merges a per-write ack with the database-wide default.
+func (db *Database) resolveAck(ack WriteAck) WriteAck {
+	if ack.Level == AckDefault {
+		ack = db.config.WriteAck
+	}
+	if ack.Level == AckDefault {
+		ack.Level = AckLocal
+	}
+	return ack
+}
+
+// awaitAck blocks until the write with sequence seq satisfies the requested ack level, or
+// ctx is done (see cancel.go). It must be called without holding db.mu so that waiting on
+// followers doesn't stall other writers.
+func (db *Database) awaitAck(ctx context.Context, seq uint64, ack WriteAck) error {
+	ack = db.resolveAck(ack)
+	switch ack.Level {
+	case AckLocal:
+		if err := db.awaitDurable(ctx, seq, false); err != nil {
+			return ackDurableError(ctx, seq, err)
+		}
+		return nil
+	case AckLeaderFsync, AckFollowers:
+		if err := db.awaitDurable(ctx, seq, true); err != nil {
+			return ackDurableError(ctx, seq, err)
+		}
+		if ack.Level == AckLeaderFsync {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown ack level: %v", ack.Level)
+	}
+
+	if ack.Followers <= 0 {
+		return nil
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+	}
+	acker := db.acker
+	if db.config.ThreadSafe {
+		db.mu.RUnlock()
+	}
+	if acker == nil {
+		return ErrReplicationUnavailable
+	}
+	timeout := ack.Timeout
+	if timeout <= 0 {
+		timeout = DefaultAckTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := acker.WaitForReplicas(waitCtx, seq, ack.Followers); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("write %d applied but not acknowledged by followers: %w", seq, ctx.Err())
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: seq %d, %d followers", ErrAckTimeout, seq, ack.Followers)
+		}
+		return err
+	}
+	return nil
+}
+
+// ackDurableError wraps a failure to make write seq durable; ctx errors are kept as they are.
+func ackDurableError(ctx context.Context, seq uint64, err error) error {
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("failed to fsync write %d: %v", seq, err)
+}
+
+// syncStorage forces the storage adapter and database file to durable storage.
+func (db *Database) syncStorage() error {
+	if s, ok := db.storage.(ports.ManagedStorage); ok {
+		if err := s.Sync(); err != nil {
+			return err
+		}
+	}
+	if db.file != nil {
+		return db.file.Sync()
+	}
+	return nil
+}
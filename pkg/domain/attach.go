@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 데이터베이스 연결(ATTACH): SQLite의 `ATTACH 'other.db' AS archive`처럼 Attach로 다른 GoLite 파일을
+// 별칭 아래에 열면 "archive.users"처럼 "별칭.테이블" 이름으로 그 파일의 테이블을 다룰 수 있습니다.
+// 연결된 파일은 독립된 Database로, 부모의 B-tree 설정, 스레드 안전성, 코덱, 내구성과 암호화 키를 물려받고
+// 자신의 잠금과 헤더를 가집니다. 따라서 두 파일에 걸친 쓰기는 원자적이지 않습니다.
+//
+// 별칭으로 라우팅되는 연산은 Get, Insert, Delete(WithAck와 Context 변형 포함), Scan, CreateTable,
+// DropTable, Count, KeyStats와 GetBytes이며, 그 위에 만들어진 GetValue/InsertValue 같은 도우미도 따라갑니다.
+// CAS, 배치, 검색 색인, 워치와 변경 피드는 부모 파일의 테이블만 다룹니다. 테넌트 컨텍스트의 테이블은
+// 테넌트 네임스페이스가 붙으므로 연결된 파일로 가지 않습니다. 이름에 "."이 든 부모의 테이블은 그
+// 앞부분과 같은 별칭이 연결되어 있지 않은 동안에만 그대로 쓸 수 있습니다. Close는 연결된 파일도
+// 닫습니다.
+
+var (
+	// ErrAliasNotFound is returned when detaching an alias that isn't attached.
+	ErrAliasNotFound = errors.New("attached database not found")
+	// ErrAliasExists is returned when attaching under an alias or a file already attached.
+	ErrAliasExists = errors.New("database already attached")
+)
+
+// Attach opens the GoLite file at path under alias, so that its tables are addressed as
+// "alias.table". The file is created if it doesn't exist.
+func (db *Database) Attach(alias, path string) error {
+	if alias == "" || strings.ContainsAny(alias, "./@") {
+		return fmt.Errorf("invalid alias %q", alias)
+	}
+	if path == "" {
+		return fmt.Errorf("file path is required")
+	}
+	db.attachMu.Lock()
+	defer db.attachMu.Unlock()
+	if _, ok := db.attached[alias]; ok {
+		return fmt.Errorf("%w: %s", ErrAliasExists, alias)
+	}
+	abs := cleanPath(path)
+	if abs == cleanPath(db.config.FilePath) {
+		return fmt.Errorf("%w: file %s is the main database", ErrAliasExists, path)
+	}
+	for name, other := range db.attached {
+		if cleanPath(other.config.FilePath) == abs {
+			return fmt.Errorf("%w: file %s is attached as %s", ErrAliasExists, path, name)
+		}
+	}
+	other, err := NewDatabase(DatabaseConfig{
+		Name:        alias,
+		FilePath:    path,
+		BtConfig:    db.config.BtConfig,
+		MaxTables:   db.config.MaxTables,
+		ThreadSafe:  db.config.ThreadSafe,
+		Events:      db.config.Events,
+		Codec:       db.config.Codec,
+		Durability:  db.config.Durability,
+		MasterKey:   db.config.MasterKey,
+		KeyProvider: db.config.KeyProvider,
+	}, db.logger)
+	if err != nil {
+		return fmt.Errorf("failed to attach %s as %s: %v", path, alias, err)
+	}
+	if db.attached == nil {
+		db.attached = make(map[string]*Database)
+	}
+	db.attached[alias] = other
+	db.logger.Info("Database attached", "database", db.config.Name, "alias", alias, "path", path)
+	return nil
+}
+
+// Detach closes the database attached under alias. It is detached even if closing fails.
+func (db *Database) Detach(alias string) error {
+	db.attachMu.Lock()
+	other, ok := db.attached[alias]
+	delete(db.attached, alias)
+	db.attachMu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAliasNotFound, alias)
+	}
+	if err := other.Close(); err != nil {
+		return fmt.Errorf("failed to detach %s: %v", alias, err)
+	}
+	db.logger.Info("Database detached", "database", db.config.Name, "alias", alias)
+	return nil
+}
+
+// Attached returns the aliases of the attached databases, sorted.
+func (db *Database) Attached() []string {
+	db.attachMu.RLock()
+	defer db.attachMu.RUnlock()
+	aliases := make([]string, 0, len(db.attached))
+	for alias := range db.attached {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// attachedTable returns the attached database and its table named by "alias.table", or
+// false if tableName has no attached alias.
+func (db *Database) attachedTable(tableName string) (*Database, string, bool) {
+	i := strings.IndexByte(tableName, '.')
+	if i <= 0 {
+		return nil, "", false
+	}
+	db.attachMu.RLock()
+	other, ok := db.attached[tableName[:i]]
+	db.attachMu.RUnlock()
+	if !ok {
+		return nil, "", false
+	}
+	return other, tableName[i+1:], true
+}
+
+// detachAll closes every attached database. It returns the first error after trying all
+// of them.
+func (db *Database) detachAll() error {
+	db.attachMu.Lock()
+	attached := db.attached
+	db.attached = nil
+	db.attachMu.Unlock()
+	var first error
+	for alias, other := range attached {
+		if err := other.Close(); err != nil && first == nil {
+			first = fmt.Errorf("failed to detach %s: %v", alias, err)
+		}
+	}
+	return first
+}
@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// ErrScanUnsupported is returned by Scan when the storage adapter can't iterate keys.
+var ErrScanUnsupported = errors.New("storage does not support scans")
+
+// BatchOp is a single write in a WriteBatch.
+type BatchOp struct {
+	Op    MutationOp // MutationInsert or MutationDelete
+	Table string
+	Key   string
+	Value string
+}
+
+// WriteBatch groups writes that are applied together under one database lock,
+// so readers never observe a partially applied batch.
+type WriteBatch struct {
+	ops []BatchOp
+}
+
+// NewWriteBatch creates an empty batch.
+func NewWriteBatch() *WriteBatch {
+	return &WriteBatch{}
+}
+
+// Insert adds an insert to the batch.
+func (b *WriteBatch) Insert(tableName, key, value string) *WriteBatch {
+	b.ops = append(b.ops, BatchOp{Op: MutationInsert, Table: tableName, Key: key, Value: value})
+	return b
+}
+
+// Delete adds a delete to the batch.
+func (b *WriteBatch) Delete(tableName, key string) *WriteBatch {
+	b.ops = append(b.ops, BatchOp{Op: MutationDelete, Table: tableName, Key: key})
+	return b
+}
+
+// Len returns the number of operations in the batch.
+func (b *WriteBatch) Len() int {
+	return len(b.ops)
+}
+
+// Ops returns the operations in the order they will be applied.
+func (b *WriteBatch) Ops() []BatchOp {
+	return append([]BatchOp(nil), b.ops...)
+}
+
+// Write applies the batch in order. All tables are checked before anything is written;
+// a storage error stops the batch at the failing operation (earlier operations stay applied).
+// Deleting a missing key inside a batch is not an error. Several operations on the same key
+// are coalesced into the last one, so only the final outcome reaches storage and listeners.
+func (db *Database) Write(batch *WriteBatch) error {
+	return db.WriteWithAck(batch, WriteAck{})
+}
+
+// WriteWithAck applies the batch and waits until its last write satisfies ack.
+func (db *Database) WriteWithAck(batch *WriteBatch, ack WriteAck) error {
+	return db.writeWithAck(context.Background(), batch, ack)
+}
+
+func (db *Database) writeWithAck(ctx context.Context, batch *WriteBatch, ack WriteAck) error {
+	if batch == nil || len(batch.ops) == 0 {
+		return nil
+	}
+	defer db.slo.observe(sloWrite, time.Now())
+	op := db.ops.begin(opBatch)
+	defer db.ops.end(&op)
+	seq, err := db.write(batch.ops)
+	if err != nil {
+		return err
+	}
+	for _, o := range batch.ops {
+		op.written += len(o.Key) + len(o.Value)
+	}
+	return db.awaitAck(ctx, seq, ack)
+}
+
+func (db *Database) write(ops []BatchOp) (uint64, error) {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	for i, op := range ops {
+		if op.Op != MutationInsert && op.Op != MutationDelete {
+			return 0, fmt.Errorf("batch op %d: unsupported op %v", i, op.Op)
+		}
+		if _, exists := db.spec.Tables[op.Table]; !exists {
+			return 0, fmt.Errorf("batch op %d: table %s not found", i, op.Table)
+		}
+	}
+	var seq uint64
+	for _, i := range coalesceOps(ops) {
+		op := ops[i]
+		var err error
+		var opSeq uint64
+		if op.Op == MutationInsert {
+			opSeq, err = db.insertLocked(op.Table, op.Key, op.Value)
+		} else {
+			opSeq, err = db.deleteLocked(op.Table, op.Key)
+			if errors.Is(err, ports.ErrKeyNotFound) {
+				err = nil
+				opSeq = seq
+			}
+		}
+		if err != nil {
+			return seq, fmt.Errorf("batch op %d: %w", i, err)
+		}
+		seq = opSeq
+	}
+	return seq, nil
+}
+
+// coalesceOps returns the indexes of the last operation on each key, in batch order.
+// Earlier operations on a key are overwritten by the last one (last write wins).
+func coalesceOps(ops []BatchOp) []int {
+	type tableKey struct{ table, key string }
+	last := make(map[tableKey]int, len(ops))
+	for i, op := range ops {
+		last[tableKey{op.Table, op.Key}] = i
+	}
+	idx := make([]int, 0, len(last))
+	for i, op := range ops {
+		if last[tableKey{op.Table, op.Key}] == i {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// Scan calls fn for every key in the table starting with prefix, in ascending key order,
+// until fn returns false. Writes are blocked while the scan runs, so fn must not write.
+func (db *Database) Scan(tableName, prefix string, fn func(key, value string) bool) error {
+	return db.scan(context.Background(), tableName, prefix, fn)
+}
+
+// scan is Scan stopping with ctx.Err() once ctx is done (see cancel.go).
+func (db *Database) scan(ctx context.Context, tableName, prefix string, fn func(key, value string) bool) error {
+	if other, table, ok := db.attachedTable(tableName); ok {
+		return other.scan(ctx, table, prefix, fn)
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	cost := db.reads.cost()
+	if db.ops == nil && cost == nil {
+		return db.scanCost(ctx, tableName, prefix, fn, nil)
+	}
+	op := db.ops.begin(opScan)
+	defer db.ops.end(&op)
+	keys := 0
+	err := db.scanCost(ctx, tableName, prefix, func(key, value string) bool {
+		op.read += len(key) + len(value)
+		keys++
+		return fn(key, value)
+	}, cost)
+	db.reads.add(readScan, cost, keys)
+	return err
+}
+
+// scanLocked performs the scan. Caller holds db.mu.
+func (db *Database) scanLocked(tableName, prefix string, fn func(key, value string) bool) error {
+	return db.scanCost(context.Background(), tableName, prefix, fn, nil)
+}
+
+// scanCost is scanLocked counting the structures touched into cost, if not nil (see readamp.go),
+// and stopping once ctx is done.
+func (db *Database) scanCost(ctx context.Context, tableName, prefix string, fn func(key, value string) bool, cost *ports.ReadCost) error {
+	spec, exists := db.spec.Tables[tableName]
+	if !exists {
+		return fmt.Errorf("table %s not found", tableName)
+	}
+	storage, tablePrefix := db.tableStorage(tableName)
+	scanner, ok := storage.(ports.ScannablePort)
+	if !ok {
+		return ErrScanUnsupported
+	}
+	scan := scanner.Scan
+	if rc, ok := storage.(ports.ReadCostPort); ok && cost != nil {
+		scan = func(prefix string, fn func(key string, value interface{}) bool) error {
+			return rc.ScanCost(prefix, fn, cost)
+		}
+	} else if cs, ok := storage.(ports.ContextScannablePort); ok && ctx.Done() != nil {
+		scan = func(prefix string, fn func(key string, value interface{}) bool) error {
+			return cs.ScanContext(ctx, prefix, fn)
+		}
+	}
+	var scanErr error
+	err := scan(tablePrefix+prefix, func(key string, value interface{}) bool {
+		if scanErr = ctx.Err(); scanErr != nil {
+			return false
+		}
+		key = strings.TrimPrefix(key, tablePrefix)
+		s, _ := ports.ValueString(value)
+		if spec.Encrypted {
+			if s, scanErr = db.decryptValue(tableName, key, s); scanErr != nil {
+				return false
+			}
+		}
+		if spec.Metadata {
+			_, s, _ = decodeEntry(s)
+		}
+		return fn(key, s)
+	})
+	if err != nil {
+		return err
+	}
+	return scanErr
+}
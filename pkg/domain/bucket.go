@@ -0,0 +1,282 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 중첩 버킷: 버킷은 "users/settings"처럼 '/'로 구분한 경로를 가진 네임스페이스이며, 각 버킷은
+// "#<경로>" 이름의 일반 테이블에 저장됩니다. 그래서 저장소가 ports.SubtreePort를 구현하면 버킷마다
+// 독립된 subtree를 쓰고, 아니면 테이블 이름을 붙인 키로 메인 저장소에 들어갑니다. 복제, 캡처,
+// 체인지피드, 스냅샷, 배치(BucketTable로 이름을 얻어 씀)도 테이블과 똑같이 적용됩니다.
+//
+// 버킷의 부모 관계는 경로에만 있습니다. CreateBucket은 없는 상위 버킷을 함께 만들고, DeleteBucket은
+// 하위 버킷을 모두 지웁니다. 버킷 하나가 테이블 하나이므로 버킷 수는 MaxTables에 포함됩니다.
+//
+// Cursor는 만든 시점의 버킷 내용을 키 순서로 담아 두고 앞뒤로 이동합니다. 이후의 쓰기는 보이지
+// 않으므로 순회하면서 같은 버킷에 써도 안전합니다.
+
+// bucketTablePrefix starts the name of every bucket table.
+const bucketTablePrefix = "#"
+
+var (
+	// ErrBucketExists is returned when creating a bucket that already exists.
+	ErrBucketExists = errors.New("bucket already exists")
+	// ErrBucketNotFound is returned when a bucket does not exist.
+	ErrBucketNotFound = errors.New("bucket not found")
+)
+
+// BucketTable returns the name of the table holding the bucket at path.
+func BucketTable(path string) string {
+	return bucketTablePrefix + path
+}
+
+// validateBucketPath checks that path is one or more non-empty names separated by '/'.
+func validateBucketPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("invalid bucket path %q", path)
+	}
+	for _, name := range strings.Split(path, "/") {
+		if name == "" || strings.Contains(name, "$") {
+			return fmt.Errorf("invalid bucket path %q", path)
+		}
+	}
+	return nil
+}
+
+// Bucket is a handle on a bucket. It stays valid while the bucket exists; operations on a
+// deleted bucket fail.
+type Bucket struct {
+	db   *Database
+	path string
+}
+
+// CreateBucket creates the bucket at path with any missing parent buckets. It fails with
+// ErrBucketExists if the bucket already exists.
+func (db *Database) CreateBucket(path string) (*Bucket, error) {
+	return db.createBucket(path, false)
+}
+
+// CreateBucketIfNotExists is CreateBucket returning the existing bucket instead of failing.
+func (db *Database) CreateBucketIfNotExists(path string) (*Bucket, error) {
+	return db.createBucket(path, true)
+}
+
+func (db *Database) createBucket(path string, mayExist bool) (*Bucket, error) {
+	if err := validateBucketPath(path); err != nil {
+		return nil, err
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, exists := db.spec.Tables[BucketTable(path)]; exists {
+		if !mayExist {
+			return nil, fmt.Errorf("%w: %s", ErrBucketExists, path)
+		}
+		return &Bucket{db: db, path: path}, nil
+	}
+	// 상위 버킷부터 만듦
+	names := strings.Split(path, "/")
+	for i := range names {
+		table := BucketTable(strings.Join(names[:i+1], "/"))
+		if _, exists := db.spec.Tables[table]; exists {
+			continue
+		}
+		if err := db.createTableLocked(table, TableOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	return &Bucket{db: db, path: path}, nil
+}
+
+// Bucket returns the bucket at path, or ErrBucketNotFound.
+func (db *Database) Bucket(path string) (*Bucket, error) {
+	if err := validateBucketPath(path); err != nil {
+		return nil, err
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if _, exists := db.spec.Tables[BucketTable(path)]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, path)
+	}
+	return &Bucket{db: db, path: path}, nil
+}
+
+// DeleteBucket deletes the bucket at path with all its keys and nested buckets.
+func (db *Database) DeleteBucket(path string) error {
+	if err := validateBucketPath(path); err != nil {
+		return err
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	table := BucketTable(path)
+	if _, exists := db.spec.Tables[table]; !exists {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, path)
+	}
+	tables := []string{table}
+	for name := range db.spec.Tables {
+		if strings.HasPrefix(name, table+"/") && !isAuxiliaryTable(name) {
+			tables = append(tables, name)
+		}
+	}
+	// 하위 버킷부터 지움
+	sort.Sort(sort.Reverse(sort.StringSlice(tables)))
+	for _, name := range tables {
+		if err := db.dropTableWithAuxLocked(name); err != nil {
+			return fmt.Errorf("failed to delete bucket %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// Buckets returns the names of the buckets directly under parent, sorted. An empty parent
+// lists the top-level buckets.
+func (db *Database) Buckets(parent string) ([]string, error) {
+	prefix := bucketTablePrefix
+	if parent != "" {
+		if err := validateBucketPath(parent); err != nil {
+			return nil, err
+		}
+		prefix = BucketTable(parent) + "/"
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if parent != "" {
+		if _, exists := db.spec.Tables[BucketTable(parent)]; !exists {
+			return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, parent)
+		}
+	}
+	names := []string{}
+	for name := range db.spec.Tables {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok || strings.Contains(rest, "/") || isAuxiliaryTable(name) {
+			continue
+		}
+		names = append(names, rest)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Path returns the path of the bucket.
+func (b *Bucket) Path() string {
+	return b.path
+}
+
+// Table returns the name of the table holding the bucket, for use with the table APIs
+// (batches, scans, watches).
+func (b *Bucket) Table() string {
+	return BucketTable(b.path)
+}
+
+// CreateBucket creates the bucket name nested in b. name may itself be a path.
+func (b *Bucket) CreateBucket(name string) (*Bucket, error) {
+	return b.db.CreateBucket(b.path + "/" + name)
+}
+
+// CreateBucketIfNotExists creates the bucket name nested in b unless it exists.
+func (b *Bucket) CreateBucketIfNotExists(name string) (*Bucket, error) {
+	return b.db.CreateBucketIfNotExists(b.path + "/" + name)
+}
+
+// Bucket returns the bucket name nested in b, or ErrBucketNotFound.
+func (b *Bucket) Bucket(name string) (*Bucket, error) {
+	return b.db.Bucket(b.path + "/" + name)
+}
+
+// DeleteBucket deletes the bucket name nested in b with everything under it.
+func (b *Bucket) DeleteBucket(name string) error {
+	return b.db.DeleteBucket(b.path + "/" + name)
+}
+
+// Buckets returns the names of the buckets directly under b, sorted.
+func (b *Bucket) Buckets() ([]string, error) {
+	return b.db.Buckets(b.path)
+}
+
+// Put stores value under key in the bucket.
+func (b *Bucket) Put(key, value string) error {
+	return b.db.Insert(b.Table(), key, value)
+}
+
+// Get returns the value of key in the bucket.
+func (b *Bucket) Get(key string) (string, error) {
+	return b.db.Get(b.Table(), key)
+}
+
+// Delete removes key from the bucket.
+func (b *Bucket) Delete(key string) error {
+	return b.db.Delete(b.Table(), key)
+}
+
+// Cursor returns a cursor over the keys of the bucket as they are now. Nested buckets are
+// not part of it; list them with Buckets.
+func (b *Bucket) Cursor() (*Cursor, error) {
+	c := &Cursor{}
+	err := b.db.Scan(b.Table(), "", func(key, value string) bool {
+		c.keys = append(c.keys, key)
+		c.values = append(c.values, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.pos = -1
+	return c, nil
+}
+
+// Cursor moves over the entries of a bucket in key order. ok is false once it moves past
+// either end. A Cursor is not safe for concurrent use.
+type Cursor struct {
+	keys   []string
+	values []string
+	pos    int
+}
+
+// First moves to the first entry.
+func (c *Cursor) First() (key, value string, ok bool) {
+	return c.at(0)
+}
+
+// Last moves to the last entry.
+func (c *Cursor) Last() (key, value string, ok bool) {
+	return c.at(len(c.keys) - 1)
+}
+
+// Next moves to the next entry.
+func (c *Cursor) Next() (key, value string, ok bool) {
+	return c.at(c.pos + 1)
+}
+
+// Prev moves to the previous entry.
+func (c *Cursor) Prev() (key, value string, ok bool) {
+	return c.at(c.pos - 1)
+}
+
+// Seek moves to the first entry whose key is at or after seek.
+func (c *Cursor) Seek(seek string) (key, value string, ok bool) {
+	return c.at(sort.SearchStrings(c.keys, seek))
+}
+
+// at moves to entry i, clamping the position just outside the entries.
+func (c *Cursor) at(i int) (string, string, bool) {
+	if i < 0 {
+		c.pos = -1
+		return "", "", false
+	}
+	if i >= len(c.keys) {
+		c.pos = len(c.keys)
+		return "", "", false
+	}
+	c.pos = i
+	return c.keys[i], c.values[i], true
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 취소: 컨텍스트를 받는 진입점(GetContext, InsertContext, DeleteContext, WriteContext, ScanContext,
+// CreateTableContext, DropTableContext)은 이미 끝난 컨텍스트를 잠금을 잡기 전에 ctx.Err()로
+// 거절합니다. 쓰기는 잠금 안에서 한 번 적용되기 시작하면 끝까지 적용되며, 취소는 그 뒤의 확인
+// 대기(fsync, 팔로워 ack)에만 영향을 줍니다. 이때 쓰기는 이미 적용된 상태이므로 ErrAckTimeout과
+// 마찬가지로 내구성·복제 보장만 확인되지 않은 것입니다. 시작된 fsync는 백그라운드에서 끝까지 진행됩니다.
+//
+// ScanContext는 키마다 ctx를 확인해 끝나면 순회를 멈추고 ctx.Err()를 반환합니다. 저장소가
+// ports.ContextScannablePort를 구현하면 컨텍스트를 그대로 넘겨 어댑터 내부의 준비 작업(파일
+// 어댑터의 인덱스 수집 등)도 중단됩니다. Compact는 ports.ContextCompactor를 구현하는 저장소의
+// 컴팩션을 요청하며 ctx가 끝나면 다음 단계로 넘어가지 않습니다.
+
+// ErrCompactUnsupported is returned by Compact when the storage adapter can't compact on request.
+var ErrCompactUnsupported = errors.New("storage does not support compaction")
+
+// admit rejects a request whose ctx is already done, or whose priority is being shed.
+func (db *Database) admit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.slo.admit(ctx)
+}
+
+// awaitDurable is makeDurable that stops waiting once ctx is done. The fsync keeps running.
+func (db *Database) awaitDurable(ctx context.Context, seq uint64, force bool) error {
+	d := db.config.Durability
+	if ctx.Done() == nil || (!force && d != ports.DurabilityFsyncPerBatch && d != ports.DurabilityFsyncPerCommit) {
+		return db.makeDurable(seq, force) // 기다릴 fsync가 없음
+	}
+	done := make(chan error, 1)
+	go func() { done <- db.makeDurable(seq, force) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("write %d applied but not yet durable: %w", seq, ctx.Err())
+	}
+}
+
+// Compact compacts the storage adapter now. If ctx ends first, the steps already done are
+// kept and ctx.Err() is returned.
+func (db *Database) Compact(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	compactor, ok := db.storage.(ports.ContextCompactor)
+	if !ok {
+		return ErrCompactUnsupported
+	}
+	if err := compactor.CompactContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("failed to compact database %s: %v", db.config.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 낙관적 동시성: CompareAndSwap과 InsertIfAbsent는 현재 값 확인과 쓰기를 같은 쓰기 잠금(db.mu)
+// 안에서 수행하므로, 여러 클라이언트가 외부 잠금 없이 같은 키를 조정할 수 있습니다. 조건이 맞지
+// 않으면 아무것도 쓰지 않고 ErrCompareFailed 또는 ErrKeyExists를 돌려주며, 호출자는 값을 다시
+// 읽어 재시도합니다. Increment도 같은 잠금 안에서 10진수 정수 값을 읽고 더해 다시 쓰므로 재시도가
+// 필요 없습니다. DeleteIf는 같은 방식으로 값이 기대한 값일 때만 키를 지웁니다. 성공한 쓰기는 보통의
+// Insert나 Delete와 같은 변경으로 커밋되므로 복제와 캡처에는 조건 없는 쓰기로 전달됩니다. 메타데이터
+// 테이블에서는 메타데이터를 뺀 값끼리 비교합니다.
+
+var (
+	// ErrNotInteger is returned by Increment when the stored value is not a decimal integer
+	// or the result would overflow.
+	ErrNotInteger = errors.New("value is not an integer")
+
+	// ErrCompareFailed is returned by CompareAndSwap and DeleteIf when the key is missing or
+	// its value is not the expected one. Nothing is written.
+	ErrCompareFailed = errors.New("compare-and-swap failed: value changed")
+
+	// ErrKeyExists is returned by InsertIfAbsent when the key is already stored.
+	ErrKeyExists = errors.New("key already exists")
+)
+
+// CompareAndSwap sets key to newValue only if its current value is expectedValue.
+func (db *Database) CompareAndSwap(tableName, key, expectedValue, newValue string) error {
+	return db.CompareAndSwapWithAck(tableName, key, expectedValue, newValue, WriteAck{})
+}
+
+// CompareAndSwapWithAck is CompareAndSwap that waits until the write satisfies ack.
+func (db *Database) CompareAndSwapWithAck(tableName, key, expectedValue, newValue string, ack WriteAck) error {
+	defer db.slo.observe(sloWrite, time.Now())
+	seq, err := db.update(tableName, key, func(current string, found bool) (string, error) {
+		if !found {
+			return "", fmt.Errorf("%w: key %s not found in table %s", ErrCompareFailed, key, tableName)
+		}
+		if current != expectedValue {
+			return "", fmt.Errorf("%w: key %s in table %s", ErrCompareFailed, key, tableName)
+		}
+		return newValue, nil
+	})
+	if err != nil {
+		return err
+	}
+	return db.awaitAck(context.Background(), seq, ack)
+}
+
+// DeleteIf deletes key only if its current value is expectedValue.
+func (db *Database) DeleteIf(tableName, key, expectedValue string) error {
+	return db.DeleteIfWithAck(tableName, key, expectedValue, WriteAck{})
+}
+
+// DeleteIfWithAck is DeleteIf that waits until the write satisfies ack.
+func (db *Database) DeleteIfWithAck(tableName, key, expectedValue string, ack WriteAck) error {
+	defer db.slo.observe(sloWrite, time.Now())
+	op := db.ops.begin(opDelete)
+	defer db.ops.end(&op)
+	seq, err := db.deleteIf(tableName, key, expectedValue)
+	if err != nil {
+		return err
+	}
+	op.written = len(key)
+	return db.awaitAck(context.Background(), seq, ack)
+}
+
+// deleteIf checks the current value of key and deletes it under db.mu.
+func (db *Database) deleteIf(tableName, key, expectedValue string) (uint64, error) {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return 0, fmt.Errorf("table %s not found", tableName)
+	}
+	current, err := db.get(tableName, key)
+	if errors.Is(err, ports.ErrKeyNotFound) {
+		return 0, fmt.Errorf("%w: key %s not found in table %s", ErrCompareFailed, key, tableName)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if current != expectedValue {
+		return 0, fmt.Errorf("%w: key %s in table %s", ErrCompareFailed, key, tableName)
+	}
+	return db.deleteLocked(tableName, key)
+}
+
+// InsertIfAbsent inserts key only if it is not stored yet.
+func (db *Database) InsertIfAbsent(tableName, key, value string) error {
+	return db.InsertIfAbsentWithAck(tableName, key, value, WriteAck{})
+}
+
+// InsertIfAbsentWithAck is InsertIfAbsent that waits until the write satisfies ack.
+func (db *Database) InsertIfAbsentWithAck(tableName, key, value string, ack WriteAck) error {
+	defer db.slo.observe(sloWrite, time.Now())
+	seq, err := db.update(tableName, key, func(_ string, found bool) (string, error) {
+		if found {
+			return "", fmt.Errorf("%w: key %s in table %s", ErrKeyExists, key, tableName)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return err
+	}
+	return db.awaitAck(context.Background(), seq, ack)
+}
+
+// Increment adds delta to the decimal integer stored under key and returns the new value.
+// A missing key counts as 0.
+func (db *Database) Increment(tableName, key string, delta int64) (int64, error) {
+	return db.IncrementWithAck(tableName, key, delta, WriteAck{})
+}
+
+// IncrementWithAck is Increment that waits until the write satisfies ack.
+func (db *Database) IncrementWithAck(tableName, key string, delta int64, ack WriteAck) (int64, error) {
+	defer db.slo.observe(sloWrite, time.Now())
+	var next int64
+	seq, err := db.update(tableName, key, func(current string, found bool) (string, error) {
+		var n int64
+		if found {
+			var err error
+			if n, err = strconv.ParseInt(current, 10, 64); err != nil {
+				return "", fmt.Errorf("%w: key %s in table %s holds %q", ErrNotInteger, key, tableName, current)
+			}
+		}
+		next = n + delta
+		if (delta > 0 && next < n) || (delta < 0 && next > n) {
+			return "", fmt.Errorf("%w: key %s in table %s overflows", ErrNotInteger, key, tableName)
+		}
+		return strconv.FormatInt(next, 10), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return next, db.awaitAck(context.Background(), seq, ack)
+}
+
+// update replaces the value of key with the one fn computes from the current value, all
+// under db.mu. Nothing is written if fn fails.
+func (db *Database) update(tableName, key string, fn func(current string, found bool) (string, error)) (uint64, error) {
+	op := db.ops.begin(opUpdate)
+	defer db.ops.end(&op)
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return 0, fmt.Errorf("table %s not found", tableName)
+	}
+	current, err := db.get(tableName, key)
+	found := err == nil
+	if err != nil && !errors.Is(err, ports.ErrKeyNotFound) {
+		return 0, err
+	}
+	op.read = len(current)
+	value, err := fn(current, found)
+	if err != nil {
+		return 0, err
+	}
+	op.written = len(key) + len(value)
+	return db.insertWithMeta(tableName, key, value, nil)
+}
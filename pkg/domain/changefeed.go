@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 체인지피드: DatabaseConfig.Changefeed를 켜면 사용자 테이블의 커밋된 변경마다 (op, table, key,
+// 시각, 시퀀스) 레코드를 시스템 테이블 ChangefeedTable에 시퀀스 순서로 남깁니다. 레코드는 변경과
+// 같은 쓰기 잠금 안에서 기록되므로 순서가 커밋 순서와 같고, 변경과 함께 내구화되며 재시작 후에도
+// 남습니다. 클라이언트는 마지막으로 처리한 시퀀스를 저장해 두고 ChangesSince로 그 뒤부터 이어
+// 읽습니다 (값은 레코드에 없으므로 필요하면 Get으로 읽습니다). 인메모리 Watch와 달리 클라이언트가
+// 꺼져 있던 동안의 변경도 놓치지 않습니다. 레코드의 시퀀스는 체인지피드 자체의 번호로, 데이터베이스의
+// Seq(열 때마다 0부터 시작)와 달리 재시작해도 이어집니다.
+//
+// 보조 테이블($search, $counts)과 체인지피드 자체의 변경은 기록하지 않습니다. ChangefeedRetention이
+// 있으면 가장 오래된 레코드부터 지우고, 지운 마지막 시퀀스를 남겨 두어 그보다 뒤처진 클라이언트에게
+// ErrChangefeedTruncated를 돌려줍니다 (전체를 다시 읽어야 함). ChangesSince는 피드를 앞에서부터
+// 스캔하므로 보존 개수로 피드 크기를 제한하는 것이 좋습니다. 체인지피드 테이블은 일반 테이블처럼
+// 복제되므로 복제본에서는 Changefeed를 켜지 않고 복제된 피드를 읽습니다.
+
+// ChangefeedTable is the system table holding the changefeed.
+const ChangefeedTable = "__changefeed"
+
+const (
+	changefeedRecordPrefix = "seq:"    // Records, keyed by zero-padded sequence number
+	changefeedTrimmedKey   = "trimmed" // Sequence number of the last trimmed record
+)
+
+// ErrChangefeedTruncated is returned by ChangesSince when records after the requested
+// sequence number were already trimmed by the retention limit.
+var ErrChangefeedTruncated = errors.New("changefeed truncated")
+
+// ChangeRecord is one committed change recorded in the changefeed.
+type ChangeRecord struct {
+	Seq   uint64    `json:"seq"` // Changefeed sequence number, increasing across restarts
+	Op    string    `json:"op"`  // MutationOp name: insert, delete, create_table, drop_table
+	Table string    `json:"table"`
+	Key   string    `json:"key,omitempty"`
+	Time  time.Time `json:"ts"`
+}
+
+// changefeed tracks the recorded changefeed. Guarded by db.mu.
+type changefeed struct {
+	retention int    // Records kept (0 = all)
+	entries   int    // Records currently stored
+	last      uint64 // Sequence number of the last record
+}
+
+func changefeedKey(seq uint64) string {
+	return fmt.Sprintf("%s%020d", changefeedRecordPrefix, seq)
+}
+
+func changefeedSeq(key string) uint64 {
+	seq, _ := strconv.ParseUint(strings.TrimPrefix(key, changefeedRecordPrefix), 10, 64)
+	return seq
+}
+
+// changefeedTrimmed returns the sequence number of the last trimmed record (0 if none).
+// Caller holds db.mu.
+func (db *Database) changefeedTrimmed() (uint64, error) {
+	s, err := db.get(ChangefeedTable, changefeedTrimmedKey)
+	if err != nil {
+		return 0, nil
+	}
+	trimmed, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid changefeed trim mark %q", s)
+	}
+	return trimmed, nil
+}
+
+// changefeedSkips reports whether changes of tableName are left out of the changefeed.
+func changefeedSkips(tableName string) bool {
+	return tableName == ChangefeedTable || tableName == HealthTable ||
+		strings.HasSuffix(tableName, searchIndexSuffix) ||
+		strings.HasSuffix(tableName, countViewSuffix) ||
+		strings.HasSuffix(tableName, trashSuffix)
+}
+
+// loadChangefeed creates ChangefeedTable if needed, counts its records and finds the last
+// sequence number. Called at open.
+func (db *Database) loadChangefeed() error {
+	if _, exists := db.spec.Tables[ChangefeedTable]; !exists {
+		if err := db.createTableLocked(ChangefeedTable, TableOptions{}); err != nil {
+			return fmt.Errorf("failed to create %s: %v", ChangefeedTable, err)
+		}
+	}
+	feed := &changefeed{retention: db.config.ChangefeedRetention}
+	if err := db.scanLocked(ChangefeedTable, changefeedRecordPrefix, func(key, _ string) bool {
+		feed.entries++
+		feed.last = changefeedSeq(key)
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to read %s: %v", ChangefeedTable, err)
+	}
+	if feed.entries == 0 {
+		feed.last, _ = db.changefeedTrimmed()
+	}
+	db.feed = feed
+	return db.trimChangefeed()
+}
+
+// recordChange appends m to the changefeed. Called by commit; caller holds db.mu.
+func (db *Database) recordChange(m Mutation) {
+	if changefeedSkips(m.Table) {
+		return
+	}
+	rec := ChangeRecord{Seq: db.feed.last + 1, Op: m.Op.String(), Table: m.Table, Key: m.Key, Time: db.now()}
+	data, _ := json.Marshal(rec)
+	err := func() error {
+		if _, err := db.insertLocked(ChangefeedTable, changefeedKey(rec.Seq), string(data)); err != nil {
+			return err
+		}
+		db.feed.last = rec.Seq
+		db.feed.entries++
+		return db.trimChangefeed()
+	}()
+	if err != nil {
+		db.logger.Error("Failed to record change in changefeed", "table", m.Table, "op", m.Op.String(), "error", err)
+		db.status.Error = fmt.Sprintf("failed to record %s of table %s in changefeed: %v", m.Op, m.Table, err)
+	}
+}
+
+// trimChangefeed deletes the oldest records beyond the retention limit. Caller holds db.mu.
+func (db *Database) trimChangefeed() error {
+	excess := db.feed.entries - db.feed.retention
+	if db.feed.retention <= 0 || excess <= 0 {
+		return nil
+	}
+	var oldest []string
+	if err := db.scanLocked(ChangefeedTable, changefeedRecordPrefix, func(key, _ string) bool {
+		oldest = append(oldest, key)
+		return len(oldest) < excess
+	}); err != nil {
+		return err
+	}
+	for _, key := range oldest {
+		if _, err := db.deleteLocked(ChangefeedTable, key); err != nil {
+			return err
+		}
+		db.feed.entries--
+	}
+	if len(oldest) == 0 {
+		return nil
+	}
+	trimmed := changefeedSeq(oldest[len(oldest)-1])
+	_, err := db.insertLocked(ChangefeedTable, changefeedTrimmedKey, strconv.FormatUint(trimmed, 10))
+	return err
+}
+
+// ChangesSince returns up to limit changefeed records with a sequence number above after,
+// in sequence order (all of them if limit <= 0). Pass the Seq of the last processed record
+// to continue tailing, or 0 to start from the beginning.
+func (db *Database) ChangesSince(after uint64, limit int) ([]ChangeRecord, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if _, exists := db.spec.Tables[ChangefeedTable]; !exists {
+		return nil, fmt.Errorf("changefeed is not enabled in database %s", db.config.Name)
+	}
+	trimmed, err := db.changefeedTrimmed()
+	if err != nil {
+		return nil, err
+	}
+	if after < trimmed {
+		return nil, fmt.Errorf("%w: records up to %d were trimmed", ErrChangefeedTruncated, trimmed)
+	}
+	var records []ChangeRecord
+	var decodeErr error
+	from := changefeedKey(after)
+	err = db.scanLocked(ChangefeedTable, changefeedRecordPrefix, func(key, value string) bool {
+		if key <= from {
+			return true
+		}
+		var rec ChangeRecord
+		if err := json.Unmarshal([]byte(value), &rec); err != nil {
+			decodeErr = fmt.Errorf("invalid changefeed record %s: %v", key, err)
+			return false
+		}
+		records = append(records, rec)
+		return limit <= 0 || len(records) < limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", ChangefeedTable, err)
+	}
+	return records, decodeErr
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 카운트 뷰: CreateCountView로 등록한 접두어마다 그 접두어로 시작하는 키의 개수를 유지합니다.
+// 개수는 메모리에 두고 보조 테이블("<table>$counts")에 "접두어 -> 10진수 개수"로 기록하며,
+// 새 키의 Insert와 Delete가 같은 잠금 안에서 함께 갱신하므로 CountPrefix는 스캔 없이 O(1)입니다.
+// 보조 테이블은 일반 테이블이므로 복제, 캡처, 스냅샷에 그대로 포함되고, 데이터베이스를 열 때
+// 다시 읽어 뷰를 이어서 유지합니다. 복제본은 복제된 보조 테이블의 값을 그대로 돌려줍니다.
+//
+// 키 카운터와 마찬가지로 비정상 종료 직전의 쓰기는 개수에 반영되지 않았을 수 있습니다.
+// 같은 접두어로 CreateCountView를 다시 호출하면 스캔으로 다시 셉니다.
+
+// countViewSuffix is appended to a table name to form the name of its count view table.
+const countViewSuffix = "$counts"
+
+// countViews holds the maintained prefix counts of one table. Guarded by db.mu.
+type countViews struct {
+	table  string           // Count view table
+	counts map[string]int64 // Live keys by registered prefix
+}
+
+// CountViewTable returns the name of the table holding the count views of tableName.
+func CountViewTable(tableName string) string {
+	return tableName + countViewSuffix
+}
+
+// CreateCountView maintains the number of keys of tableName starting with prefix, counting
+// the existing keys once with a scan. Calling it again for a registered prefix recounts it.
+func (db *Database) CreateCountView(tableName, prefix string) error {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, ok := db.spec.Tables[tableName]; !ok {
+		return fmt.Errorf("table %s not found", tableName)
+	}
+	if strings.HasSuffix(tableName, countViewSuffix) || strings.HasSuffix(tableName, searchIndexSuffix) {
+		return fmt.Errorf("table %s is an auxiliary table", tableName)
+	}
+	if prefix == "" {
+		return fmt.Errorf("empty prefix: use Count for the whole table")
+	}
+	var n int64
+	if err := db.scanLocked(tableName, prefix, func(string, string) bool {
+		n++
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to count prefix %q of table %s: %v", prefix, tableName, err)
+	}
+	views, ok := db.counts[tableName]
+	if !ok {
+		views = &countViews{table: CountViewTable(tableName), counts: make(map[string]int64)}
+		if _, exists := db.spec.Tables[views.table]; !exists {
+			if err := db.createTableLocked(views.table, TableOptions{}); err != nil {
+				return err
+			}
+		}
+		db.counts[tableName] = views
+	}
+	views.counts[prefix] = n
+	if _, err := db.insertLocked(views.table, prefix, strconv.FormatInt(n, 10)); err != nil {
+		return fmt.Errorf("failed to save count view of table %s: %v", tableName, err)
+	}
+	db.logger.Info("Created count view", "table", tableName, "prefix", prefix, "keys", n)
+	return nil
+}
+
+// DropCountView stops maintaining the count of prefix in tableName.
+func (db *Database) DropCountView(tableName, prefix string) error {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	views, ok := db.counts[tableName]
+	if !ok {
+		return fmt.Errorf("table %s has no count view %q", tableName, prefix)
+	}
+	if _, ok := views.counts[prefix]; !ok {
+		return fmt.Errorf("table %s has no count view %q", tableName, prefix)
+	}
+	delete(views.counts, prefix)
+	if _, err := db.deleteLocked(views.table, prefix); err != nil {
+		return fmt.Errorf("failed to drop count view of table %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// CountPrefix returns the number of keys of tableName starting with prefix, which must have
+// been registered with CreateCountView. On a replica the replicated count is returned.
+func (db *Database) CountPrefix(tableName, prefix string) (int64, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if views, ok := db.counts[tableName]; ok {
+		if n, ok := views.counts[prefix]; ok {
+			return n, nil
+		}
+	}
+	viewTable := CountViewTable(tableName)
+	if _, ok := db.spec.Tables[viewTable]; ok {
+		if s, err := db.get(viewTable, prefix); err == nil {
+			return strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("table %s has no count view %q", tableName, prefix)
+}
+
+// updateCountViews adds delta to the views of tableName matching key. Caller holds db.mu.
+func (db *Database) updateCountViews(tableName, key string, delta int64) error {
+	views, ok := db.counts[tableName]
+	if !ok {
+		return nil
+	}
+	for prefix, n := range views.counts {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		views.counts[prefix] = n + delta
+		if _, err := db.insertLocked(views.table, prefix, strconv.FormatInt(n+delta, 10)); err != nil {
+			return fmt.Errorf("failed to update count view of table %s: %v", tableName, err)
+		}
+	}
+	return nil
+}
+
+// loadCountViews restores the count views saved in the count view tables. Called at open.
+func (db *Database) loadCountViews() {
+	for name := range db.spec.Tables {
+		tableName, ok := strings.CutSuffix(name, countViewSuffix)
+		if !ok || db.spec.Tables[tableName] == nil {
+			continue
+		}
+		views := &countViews{table: name, counts: make(map[string]int64)}
+		err := db.scanLocked(name, "", func(prefix, value string) bool {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				views.counts[prefix] = n
+			}
+			return true
+		})
+		if err != nil {
+			db.logger.Warn("Failed to load count views", "table", tableName, "error", err)
+			continue
+		}
+		db.counts[tableName] = views
+	}
+}
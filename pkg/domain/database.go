@@ -2,24 +2,72 @@ package domain
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/codec"
+	"github.com/sukryu/GoLite/pkg/events"
 	"github.com/sukryu/GoLite/pkg/ports"
 	"github.com/sukryu/GoLite/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DatabaseConfig defines the configuration for a Database, inspired by K8s resource spec.
 type DatabaseConfig struct {
-	Name       string         // Database name (like K8s resource name)
-	FilePath   string         // File path for persistence
-	BtConfig   btree.BtConfig // B-tree specific config, optional for other adapters
-	MaxTables  int            // Maximum number of tables (resource limit)
-	ThreadSafe bool           // Enable thread safety
-	UsePages   bool           // Flag to indicate if page-based storage is used
+	Name       string           // Database name (like K8s resource name)
+	FilePath   string           // File path for persistence
+	BtConfig   btree.BtConfig   // B-tree specific config, optional for other adapters
+	MaxTables  int              // Maximum number of tables (resource limit)
+	ThreadSafe bool             // Enable thread safety
+	UsePages   bool             // Flag to indicate if page-based storage is used
+	WriteAck   WriteAck         // Default write acknowledgement level (local if unset)
+	Events     *events.Bus      // Lifecycle event bus, shared with adapters (created if nil)
+	Codec      ports.Codec      // Codec for InsertValue/GetValue (codec.JSON if nil)
+	Durability ports.Durability // Baseline fsync behaviour of writes (async if unset, see durability.go)
+	SoftLimits SoftLimits       // Warning thresholds below the hard limits (see limits.go)
+	MasterKey  []byte           // AES key (16, 24 or 32 bytes) wrapping the data keys of encrypted tables (see encryption.go)
+	// KeyProvider supplies the at-rest encryption key of the storage and its rotations
+	// (see storagekey.go).
+	KeyProvider ports.KeyProvider
+	LatencySLO  LatencySLO // p99 targets enforced by shedding low-priority requests (see slo.go)
+	OpStats     bool       // Account allocations and bytes per operation in Stats (see opstats.go)
+	// ReadAmplification accumulates the storage structures touched by Get and Scan in Stats
+	// (see readamp.go).
+	ReadAmplification bool
+	// TracerProvider receives the spans of the context entry points (the otel global
+	// provider if nil, see tracing.go).
+	TracerProvider trace.TracerProvider
+	// Changefeed records every committed change of the user tables in ChangefeedTable,
+	// keeping the last ChangefeedRetention records (all if 0, see changefeed.go).
+	Changefeed          bool
+	ChangefeedRetention int
+	// Migrations run once per database when it is opened (see migrate.go).
+	Migrations []Migration
+	// MigrationDryRun only logs the pending migrations at open instead of applying them.
+	MigrationDryRun bool
+	// Maintenance lists the upkeep tasks run by the database's scheduler (see maintenance.go).
+	Maintenance []MaintenanceTask
+	// ReadTimeout, WriteTimeout and CompactionTimeout bound the queries, commands and
+	// compactions run through the application layer (0 = none, see timeout.go).
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	CompactionTimeout time.Duration
+	// MaxDatabaseBytes and MaxKeysPerTable fail inserts into user tables once the storage
+	// size or a table's live keys reach them (0 = unlimited, see quota.go).
+	MaxDatabaseBytes int64
+	MaxKeysPerTable  int64
+	// VersionHistory keeps the last VersionHistory versions of each key of the user tables,
+	// and VersionRetention the versions younger than it, for GetVersions (0 = no limit;
+	// versioning is on if either is set, see versions.go).
+	VersionHistory   int
+	VersionRetention time.Duration
 }
 
 // DatabaseSpec defines the desired state of a Database, K8s-style.
@@ -32,6 +80,28 @@ type DatabaseStatus struct {
 	TableCount int    // Number of tables
 	Ready      bool   // Database readiness
 	Error      string // Last error, if any
+	// RecoveryDebt is the WAL backlog: the WAL size in bytes replayed on the next restart
+	// (0 if the storage has no WAL, see ports.RecoveryReporter).
+	RecoveryDebt int64
+	// Maintenance reports the runs of the scheduled maintenance tasks (see maintenance.go).
+	Maintenance []MaintenanceStatus
+	// Health is the report of the last HealthCheck (nil before the first, see health.go).
+	Health *HealthReport
+	// Quota reports the storage size and the keys of each table against the quotas (see quota.go).
+	Quota QuotaUsage
+	// Storage reports the state of the storage adapter.
+	Storage StorageStatus
+}
+
+// StorageStatus reports the state of the storage adapter, as far as it reports it (see
+// ports.SizeReporter and ports.StatusReporter).
+type StorageStatus struct {
+	DiskBytes      int64            // Size of the storage files
+	TableKeys      map[string]int64 // Live keys of every table, system tables included
+	CacheHits      int64            // Reads answered from the storage cache
+	CacheMisses    int64            // Reads that missed it
+	CacheHitRatio  float64          // CacheHits over all cached reads (0 without any)
+	LastCompaction time.Time        // End of the last compaction since open (zero if none)
 }
 
 // Database is the aggregate root for managing tables, inspired by SQLite's struct sqlite.
@@ -40,14 +110,46 @@ type Database struct {
 	spec    DatabaseSpec
 	status  DatabaseStatus
 	file    *os.File
-	storage ports.StoragePort // B-tree adapter
-	mu      sync.RWMutex      // Thread safety
-	logger  utils.Logger      // Logging for production readiness
+	storage ports.StoragePort     // B-tree adapter
+	trees   map[string]*tableTree // Tables with their own subtree (see tabletree.go)
+	mu      sync.RWMutex          // Thread safety
+	logger  utils.Logger          // Logging for production readiness
+	seq     uint64                // Sequence number of the last successful write
+	acker   ReplicaAcknowledger
+	events  *events.Bus // Lifecycle event bus
+
+	listeners mutationListeners // Committed change listeners (replication, watch)
+
+	keys      map[string]*tableKeyStats // Per-table live key counters
+	keysExact bool                      // Counters were restored from a clean header
+	keysDirty bool                      // Counters changed since the header was last saved clean
+
+	syncs    *groupSync               // Shared fsyncs for DurabilityFsyncPerBatch
+	limits   softLimitState           // Crossed soft limits (see limits.go)
+	dataKeys map[string]*dataKey      // Data keys of encrypted tables (see encryption.go)
+	search   map[string]*searchIndex  // Search indexes by indexed table (see search.go)
+	counts   map[string]*countViews   // Prefix count views by table (see countview.go)
+	slo      *sloGuard                // Latency histograms and load shedding (nil without targets)
+	ops      *opProfile               // Per-operation accounting (nil unless enabled)
+	reads    *readProfile             // Read amplification (nil unless enabled)
+	quotas   map[string]TenantQuota   // Tenant quotas by tenant (see tenant.go)
+	feed     *changefeed              // Changefeed state (nil unless DatabaseConfig.Changefeed)
+	versions *versioning              // Version retention (nil unless versioning is on)
+	trash    map[string]time.Duration // Soft-delete grace periods by table (see softdelete.go)
+	tracer   trace.Tracer             // Spans of the context entry points (see tracing.go)
+	maint    *maintenance             // Maintenance scheduler (nil without tasks)
+	health   *HealthReport            // Last HealthCheck report
+
+	attachMu sync.RWMutex         // Guards attached
+	attached map[string]*Database // Attached databases by alias (see attach.go)
 }
 
 // TableSpec defines the desired state of a Table, K8s-style.
 type TableSpec struct {
-	Name string // Table name
+	Name      string // Table name
+	Metadata  bool   // Entries carry EntryMeta (see meta.go)
+	Encrypted bool   // Values are encrypted with the table's data key (see encryption.go)
+	KeyID     string // ID of the table's data key, if encrypted
 }
 
 // NewDatabaseWithStorage creates a new Database instance with a custom storage adapter.
@@ -58,15 +160,58 @@ func NewDatabaseWithStorage(config DatabaseConfig, storage ports.StoragePort, fi
 	if config.MaxTables <= 0 {
 		config.MaxTables = 100
 	}
+	if config.Events == nil {
+		config.Events = events.NewBus()
+	}
+	if config.Codec == nil {
+		config.Codec = codec.JSON
+	}
+	durability, err := ports.ParseDurability(string(config.Durability))
+	if err != nil {
+		return nil, err
+	}
+	config.Durability = durability
+	if len(config.MasterKey) > 0 {
+		if _, err := newAEAD(config.MasterKey); err != nil {
+			return nil, fmt.Errorf("invalid master key: %v", err)
+		}
+	}
+	if config.Maintenance, err = validateMaintenance(config.Maintenance); err != nil {
+		return nil, err
+	}
+	if config.ReadTimeout < 0 || config.WriteTimeout < 0 || config.CompactionTimeout < 0 {
+		return nil, fmt.Errorf("read, write and compaction timeouts must be >= 0")
+	}
+	if config.MaxDatabaseBytes < 0 || config.MaxKeysPerTable < 0 {
+		return nil, fmt.Errorf("database size and table key quotas must be >= 0")
+	}
+	if config.VersionHistory < 0 || config.VersionRetention < 0 {
+		return nil, fmt.Errorf("version history and retention must be >= 0")
+	}
 
 	db := &Database{
-		config:  config,
-		spec:    DatabaseSpec{Tables: make(map[string]*TableSpec)},
-		status:  DatabaseStatus{Ready: true},
-		file:    file,
-		storage: storage,
-		logger:  logger,
-	}
+		config:    config,
+		spec:      DatabaseSpec{Tables: make(map[string]*TableSpec)},
+		status:    DatabaseStatus{Ready: true},
+		file:      file,
+		storage:   storage,
+		logger:    logger,
+		events:    config.Events,
+		trees:     make(map[string]*tableTree),
+		keys:      make(map[string]*tableKeyStats),
+		keysExact: true,
+		syncs:     newGroupSync(),
+		limits:    softLimitState{crossed: make(map[string]bool)},
+		dataKeys:  make(map[string]*dataKey),
+		search:    make(map[string]*searchIndex),
+		counts:    make(map[string]*countViews),
+		trash:     make(map[string]time.Duration),
+		slo:       newSLOGuard(config.LatencySLO, config.Name, config.Events, logger),
+		ops:       newOpProfile(config.OpStats),
+		reads:     newReadProfile(config.ReadAmplification),
+		quotas:    make(map[string]TenantQuota),
+	}
+	db.tracer = newTracer(&db.config)
 
 	if config.UsePages {
 		// Ensure file is at least 2 pages long for page-based storage
@@ -79,29 +224,71 @@ func NewDatabaseWithStorage(config DatabaseConfig, storage ports.StoragePort, fi
 	}
 
 	if err := db.loadHeader(); err != nil {
-		db.logger.Warn(fmt.Sprintf("failed to load header, initializing new: %v", err))
+		db.logger.Warn("Failed to load header, initializing new", "error", err)
 		if err := db.saveHeader(); err != nil {
 			return nil, err
 		}
 	}
+	db.loadCountViews()
+	db.loadSoftDelete()
+	db.loadTenantQuotas()
+	if config.Changefeed {
+		if err := db.loadChangefeed(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if config.VersionHistory > 0 || config.VersionRetention > 0 {
+		if err := db.loadVersions(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if _, err := db.Migrate(config.MigrationDryRun); err != nil {
+		db.Close()
+		return nil, err
+	}
+	db.maint = startMaintenance(db, db.config.Maintenance)
 	return db, nil
 }
 
-// NewDatabase creates a new Database instance with the default B-tree storage.
+// NewDatabase creates a new Database instance with the default B-tree storage. It fails with
+// utils.ErrLocked while another process has the database file open.
 func NewDatabase(config DatabaseConfig, logger utils.Logger) (*Database, error) {
 	config.UsePages = true // B-tree uses pages by default
 	file, err := os.OpenFile(config.FilePath, os.O_RDWR|os.O_CREATE, 0666)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database file: %v", err)
 	}
-	storage := btree.NewBtree(file, config.BtConfig)
-	return NewDatabaseWithStorage(config, storage, file, logger)
+	config.BtConfig.ReservedPages = 1 // Page 1 holds the table header
+	if err := storageKeys(&config); err != nil {
+		file.Close()
+		return nil, err
+	}
+	storage, err := btree.OpenBtree(file, config.BtConfig)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open database file: %w", err)
+	}
+	db, err := NewDatabaseWithStorage(config, storage, file, logger)
+	if err != nil {
+		// 잠금을 풀도록 파일을 닫음 (이미 닫혔으면 무시)
+		storage.Close()
+		file.Close()
+		return nil, err
+	}
+	return db, nil
 }
 
+// byteOrder is the byte order of every header the database stores (table list, table roots,
+// metadata, key statistics, table keys), the same as the B-tree pages holding them.
+var byteOrder = binary.LittleEndian
+
 // loadHeader reads table metadata from page 1 (B-tree uses page 0).
 func (db *Database) loadHeader() error {
 	if !db.config.UsePages {
-		return nil // No header for non-page-based storage
+		db.keysExact = false // Existing keys can't be counted without a header
+		return nil           // No header for non-page-based storage
 	}
 	data := make([]byte, db.config.BtConfig.PageSize)
 	n, err := db.file.ReadAt(data, int64(db.config.BtConfig.PageSize))
@@ -115,44 +302,66 @@ func (db *Database) loadHeader() error {
 
 	buf := bytes.NewReader(data)
 	var tableCount uint32
-	if err := binary.Read(buf, binary.LittleEndian, &tableCount); err != nil {
-		db.logger.Warn(fmt.Sprintf("Failed to read table count: %v, assuming empty", err))
+	if err := binary.Read(buf, byteOrder, &tableCount); err != nil {
+		db.logger.Warn("Failed to read table count, assuming empty", "error", err)
 		return nil
 	}
 
+	names := make([]string, 0, tableCount)
 	for i := uint32(0); i < tableCount; i++ {
 		var nameLen uint16
-		if err := binary.Read(buf, binary.LittleEndian, &nameLen); err != nil {
-			db.logger.Warn(fmt.Sprintf("Failed to read table name length at index %d: %v", i, err))
+		if err := binary.Read(buf, byteOrder, &nameLen); err != nil {
+			db.logger.Warn("Failed to read table name length", "index", i, "error", err)
 			break
 		}
 		nameBytes := make([]byte, nameLen)
 		if _, err := buf.Read(nameBytes); err != nil {
-			db.logger.Warn(fmt.Sprintf("Failed to read table name at index %d: %v", i, err))
+			db.logger.Warn("Failed to read table name", "index", i, "error", err)
 			break
 		}
 		name := string(nameBytes)
 		db.spec.Tables[name] = &TableSpec{Name: name}
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		db.decodeTableRoots(buf, names)
+		db.decodeTableMeta(buf, names)
+		db.decodeTableKeys(buf, names)
+		db.decodeKeyStats(buf, names)
 	}
 
 	db.status.TableCount = len(db.spec.Tables)
-	db.logger.Info(fmt.Sprintf("Loaded %d tables from header", db.status.TableCount))
+	db.logger.Info("Loaded tables from header", "tables", db.status.TableCount)
 	return nil
 }
 
-// saveHeader writes table metadata to page 1.
+// saveHeader writes table metadata to page 1 and syncs the file.
 func (db *Database) saveHeader() error {
 	if !db.config.UsePages {
 		return nil // No header for non-page-based storage
 	}
+	if err := db.writeHeader(); err != nil {
+		return err
+	}
+	if err := db.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync header: %v", err)
+	}
+	db.logger.Info("Saved header with table metadata")
+	return nil
+}
+
+// writeHeader writes page 1 without syncing. Subtrees call it on every root or length
+// change, just like the main tree rewrites page 0. Caller holds db.mu.
+func (db *Database) writeHeader() error {
 	buf := bytes.NewBuffer(make([]byte, 0, db.config.BtConfig.PageSize))
 
-	if err := binary.Write(buf, binary.LittleEndian, uint32(len(db.spec.Tables))); err != nil {
+	if err := binary.Write(buf, byteOrder, uint32(len(db.spec.Tables))); err != nil {
 		return fmt.Errorf("failed to write table count: %v", err)
 	}
-	for name := range db.spec.Tables {
+	names := db.sortedTableNames()
+	for _, name := range names {
 		nameLen := uint16(len(name))
-		if err := binary.Write(buf, binary.LittleEndian, nameLen); err != nil {
+		if err := binary.Write(buf, byteOrder, nameLen); err != nil {
 			return fmt.Errorf("failed to write table name length: %v", err)
 		}
 		if _, err := buf.WriteString(name); err != nil {
@@ -160,6 +369,14 @@ func (db *Database) saveHeader() error {
 		}
 	}
 
+	db.encodeTableRoots(buf, names)
+	db.encodeTableMeta(buf, names)
+	db.encodeTableKeys(buf, names)
+	fixed := buf.Len()
+	db.encodeKeyStats(buf, names, db.config.BtConfig.PageSize-buf.Len())
+
+	// 키 카운터는 남은 공간에 맞춰 줄어들므로 그 앞부분만 헤더 크기 한도에 포함.
+	db.checkSoftLimit(LimitHeaderBytes, "", fixed, db.config.BtConfig.PageSize, true)
 	data := buf.Bytes()
 	if len(data) > db.config.BtConfig.PageSize {
 		return fmt.Errorf("header exceeds page size: %d > %d", len(data), db.config.BtConfig.PageSize)
@@ -170,83 +387,297 @@ func (db *Database) saveHeader() error {
 	if err != nil {
 		return fmt.Errorf("failed to write header: %v", err)
 	}
-	if err := db.file.Sync(); err != nil {
-		return fmt.Errorf("failed to sync header: %v", err)
-	}
-	db.logger.Info("Saved header with table metadata")
 	return nil
 }
 
+// ErrTableExists is returned when creating a table that already exists.
+var ErrTableExists = errors.New("table already exists")
+
+// CreateTable creates a table with default options.
 func (db *Database) CreateTable(name string) error {
+	return db.createTable(name, TableOptions{})
+}
+
+func (db *Database) createTable(name string, opts TableOptions) error {
+	if other, table, ok := db.attachedTable(name); ok {
+		return other.createTable(table, opts)
+	}
 	if db.config.ThreadSafe {
 		db.mu.Lock()
 		defer db.mu.Unlock()
 	}
+	return db.createTableLocked(name, opts)
+}
+
+// createTableLocked creates the table. Caller holds db.mu.
+func (db *Database) createTableLocked(name string, opts TableOptions) error {
 	if db.status.TableCount >= db.config.MaxTables {
 		err := fmt.Errorf("max tables limit reached: %d", db.config.MaxTables)
 		db.status.Error = err.Error()
-		db.logger.Error(err.Error())
+		db.logger.Error("Failed to create table", "table", name, "error", err)
 		return err
 	}
 	if _, exists := db.spec.Tables[name]; exists {
-		return fmt.Errorf("table %s already exists", name)
+		return fmt.Errorf("%w: %s", ErrTableExists, name)
+	}
+	if err := db.checkTenantTableQuota(name); err != nil {
+		return err
+	}
+	if opts.SoftDelete < 0 || (opts.SoftDelete > 0 && opts.Encrypted) {
+		return fmt.Errorf("table %s: soft delete needs a positive grace period and an unencrypted table", name)
+	}
+	spec := &TableSpec{Name: name, Metadata: opts.Metadata, Encrypted: opts.Encrypted}
+	if opts.Encrypted {
+		if !db.config.UsePages {
+			return fmt.Errorf("table %s: encrypted tables require page-based storage", name)
+		}
+		dk, err := db.newDataKey()
+		if err != nil {
+			return fmt.Errorf("table %s: %w", name, err)
+		}
+		db.dataKeys[name] = dk
+		spec.KeyID = dk.id
 	}
-	db.spec.Tables[name] = &TableSpec{Name: name}
+	db.spec.Tables[name] = spec
+	db.keys[name] = newTableKeyStats()
+	db.openTableTree(name, 0, 0)
 	db.status.TableCount++
 	if err := db.saveHeader(); err != nil {
 		return err
 	}
-	db.logger.Info(fmt.Sprintf("Table %s created in database %s", name, db.config.Name))
+	db.checkSoftLimit(LimitTables, "", db.status.TableCount, db.config.MaxTables, true)
+	db.commit(Mutation{Op: MutationCreateTable, Table: name, Value: opts.String()})
+	db.events.Publish(events.Event{Type: events.TableCreated, Source: db.config.Name, Table: name})
+	db.logger.Info("Table created", "database", db.config.Name, "table", name)
+	if opts.SoftDelete > 0 {
+		return db.setSoftDeleteLocked(name, opts.SoftDelete)
+	}
 	return nil
 }
 
 func (db *Database) DropTable(name string) error {
+	if other, table, ok := db.attachedTable(name); ok {
+		return other.DropTable(table)
+	}
 	if db.config.ThreadSafe {
 		db.mu.Lock()
 		defer db.mu.Unlock()
 	}
+	return db.dropTableWithAuxLocked(name)
+}
+
+// dropTableWithAuxLocked drops the table with its search index, count views and
+// soft-deleted keys.
+// Caller holds db.mu.
+func (db *Database) dropTableWithAuxLocked(name string) error {
+	if _, exists := db.spec.Tables[name]; exists {
+		if _, indexed := db.search[name]; indexed {
+			delete(db.search, name)
+			if err := db.dropTableLocked(SearchIndexTable(name)); err != nil {
+				return err
+			}
+		}
+		if _, counted := db.counts[name]; counted {
+			delete(db.counts, name)
+			if err := db.dropTableLocked(CountViewTable(name)); err != nil {
+				return err
+			}
+		}
+		if _, soft := db.trash[name]; soft {
+			delete(db.trash, name)
+			if err := db.dropTableLocked(TrashTable(name)); err != nil {
+				return err
+			}
+		}
+	}
+	return db.dropTableLocked(name)
+}
+
+// dropTableLocked drops the table. Caller holds db.mu.
+func (db *Database) dropTableLocked(name string) error {
 	if _, exists := db.spec.Tables[name]; !exists {
 		err := fmt.Errorf("table %s not found", name)
 		db.status.Error = err.Error()
-		db.logger.Error(err.Error())
+		db.logger.Error("Failed to drop table", "table", name, "error", err)
+		return err
+	}
+	var dropped int64
+	if s, ok := db.keys[name]; ok {
+		dropped = s.count
+	}
+	if err := db.dropTableData(name); err != nil {
+		db.status.Error = err.Error()
+		db.logger.Error("Failed to drop table data", "table", name, "error", err)
 		return err
 	}
 	delete(db.spec.Tables, name)
+	delete(db.keys, name)
+	delete(db.dataKeys, name)
+	delete(db.search, strings.TrimSuffix(name, searchIndexSuffix))
+	delete(db.counts, strings.TrimSuffix(name, countViewSuffix))
+	delete(db.trash, strings.TrimSuffix(name, trashSuffix))
+	delete(db.trees, name) // 서브트리 테이블은 root 기록만 지우면 데이터가 사라짐
 	db.status.TableCount--
 	if err := db.saveHeader(); err != nil {
 		return err
 	}
-	db.logger.Info(fmt.Sprintf("Table %s dropped from database %s", name, db.config.Name))
+	db.checkSoftLimit(LimitTables, "", db.status.TableCount, db.config.MaxTables, true)
+	db.commit(Mutation{Op: MutationDropTable, Table: name})
+	db.events.Publish(events.Event{
+		Type:    events.TableDropped,
+		Source:  db.config.Name,
+		Table:   name,
+		Details: map[string]interface{}{"keys": dropped},
+	})
+	db.logger.Info("Table dropped", "database", db.config.Name, "table", name)
+	return nil
+}
+
+// dropTableData removes the keys of a table from storage. Tables with their own subtree
+// only need their root forgotten; prefixed tables are range-deleted if the storage supports it.
+// Caller holds db.mu.
+func (db *Database) dropTableData(name string) error {
+	storage, prefix := db.tableStorage(name)
+	if prefix == "" {
+		return nil
+	}
+	deleter, ok := storage.(ports.PrefixDeleter)
+	if !ok {
+		db.logger.Warn("Storage cannot delete by prefix; table data is left in place", "table", name)
+		return nil
+	}
+	n, err := deleter.DeletePrefix(prefix)
+	if err != nil {
+		return fmt.Errorf("failed to delete data of table %s: %v", name, err)
+	}
+	db.logger.Info("Deleted table keys", "table", name, "keys", n)
 	return nil
 }
 
 // Insert inserts a key-value pair into a table.
 func (db *Database) Insert(tableName, key, value string) error {
+	return db.InsertWithAck(tableName, key, value, WriteAck{})
+}
+
+// InsertWithAck inserts a key-value pair and waits until the write satisfies ack.
+// A zero WriteAck uses DatabaseConfig.WriteAck.
+func (db *Database) InsertWithAck(tableName, key, value string, ack WriteAck) error {
+	return db.insertWithAck(context.Background(), tableName, key, value, ack)
+}
+
+func (db *Database) insertWithAck(ctx context.Context, tableName, key, value string, ack WriteAck) error {
+	if other, table, ok := db.attachedTable(tableName); ok {
+		return other.insertWithAck(ctx, table, key, value, ack)
+	}
+	defer db.slo.observe(sloWrite, time.Now())
+	op := db.ops.begin(opInsert)
+	defer db.ops.end(&op)
+	seq, err := db.insert(tableName, key, value, nil)
+	if err != nil {
+		return err
+	}
+	op.written = len(key) + len(value)
+	return db.awaitAck(ctx, seq, ack)
+}
+
+func (db *Database) insert(tableName, key, value string, metaFn entryMetaFunc) (uint64, error) {
 	if db.config.ThreadSafe {
 		db.mu.Lock()
 		defer db.mu.Unlock()
 	}
+	return db.insertWithMeta(tableName, key, value, metaFn)
+}
 
-	if _, exists := db.spec.Tables[tableName]; !exists {
-		return fmt.Errorf("table %s not found", tableName)
+// insertLocked performs the insert. Caller holds db.mu.
+func (db *Database) insertLocked(tableName, key, value string) (uint64, error) {
+	return db.insertWithMeta(tableName, key, value, nil)
+}
+
+// insertWithMeta performs the insert. In metadata tables metaFn computes the entry's
+// metadata from the existing one; nil uses touchMeta. Caller holds db.mu.
+func (db *Database) insertWithMeta(tableName, key, value string, metaFn entryMetaFunc) (uint64, error) {
+	spec, exists := db.spec.Tables[tableName]
+	if !exists {
+		return 0, fmt.Errorf("table %s not found", tableName)
 	}
 
-	// Prefix key with table name for B-tree storage
-	prefixedKey := fmt.Sprintf("%s:%s", tableName, key)
-	err := db.storage.Insert(prefixedKey, value)
+	storage, prefix := db.tableStorage(tableName)
+	old, lookupErr := storage.Get(prefix + key)
+	isNew := errors.Is(lookupErr, ports.ErrKeyNotFound)
+	if lookupErr != nil && !isNew {
+		return 0, lookupErr
+	}
+	if isNew {
+		if err := db.checkTenantKeyQuota(tableName); err != nil {
+			return 0, err
+		}
+	}
+	if err := db.checkQuotas(tableName, isNew); err != nil {
+		return 0, err
+	}
+	var oldValue string
+	if _, indexed := db.search[tableName]; indexed && !isNew {
+		oldValue, _ = db.storedValue(tableName, key, old)
+	}
+	stored := value
+	var meta *EntryMeta
+	if spec.Metadata {
+		if metaFn == nil {
+			metaFn = db.touchMeta
+		}
+		var oldMeta *EntryMeta
+		if !isNew {
+			oldMeta = db.oldEntryMeta(tableName, key, old)
+		}
+		m := metaFn(oldMeta)
+		meta = &m
+		stored = encodeEntry(m, value)
+	}
+	stored, err := db.encryptValue(tableName, key, stored)
+	if err != nil {
+		return 0, err
+	}
+	db.checkEntryLimits(tableName, key, stored)
+	db.markKeysDirty()
+	err = storage.Insert(prefix+key, stored)
 	if err != nil {
 		db.status.Error = err.Error()
-		db.logger.Error(fmt.Sprintf("Failed to insert into %s: %v", tableName, err))
-		return err
+		db.logger.Error("Failed to insert", "table", tableName, "key", key, "error", err)
+		return 0, err
+	}
+	if isNew {
+		db.keyStatsFor(tableName).add(key, 1)
+	}
+	indexErr := db.updateSearchIndex(tableName, key, oldValue, value, !isNew)
+	if isNew {
+		if err := db.updateCountViews(tableName, key, 1); err != nil && indexErr == nil {
+			indexErr = err
+		}
+		if _, soft := db.trash[tableName]; soft {
+			if err := db.unburyLocked(tableName, key); err != nil && indexErr == nil {
+				indexErr = err
+			}
+		}
+	}
+	seq := db.commit(Mutation{Op: MutationInsert, Table: tableName, Key: key, Value: value, Meta: meta})
+	if indexErr != nil {
+		db.status.Error = indexErr.Error()
+		db.logger.Error("Failed to update auxiliary tables", "table", tableName, "key", key, "error", indexErr)
+		return seq, indexErr
 	}
 
-	// TODO: Emit InsertEvent (for event-driven architecture)
-	db.logger.Info(fmt.Sprintf("Inserted key %s into table %s", key, tableName))
-	return nil
+	db.logger.Info("Inserted key", "table", tableName, "key", key)
+	return seq, nil
 }
 
 // Get retrieves a value from a table by key.
 func (db *Database) Get(tableName, key string) (string, error) {
+	if other, table, ok := db.attachedTable(tableName); ok {
+		return other.Get(table, key)
+	}
+	defer db.slo.observe(sloRead, time.Now())
+	op := db.ops.begin(opGet)
+	defer db.ops.end(&op)
 	if db.config.ThreadSafe {
 		db.mu.RLock()
 		defer db.mu.RUnlock()
@@ -255,55 +686,216 @@ func (db *Database) Get(tableName, key string) (string, error) {
 	if _, exists := db.spec.Tables[tableName]; !exists {
 		return "", fmt.Errorf("table %s not found", tableName)
 	}
+	cost := db.reads.cost()
+	value, err := db.getCost(tableName, key, cost)
+	if err != nil {
+		db.logger.Warn("Key not found", "table", tableName, "key", key, "error", err)
+	}
+	op.read = len(value)
+	found := 0
+	if err == nil {
+		found = 1
+	}
+	db.reads.add(readGet, cost, found)
+	return value, err
+}
+
+// get returns the value of key in an existing table without entry metadata. Caller holds db.mu.
+func (db *Database) get(tableName, key string) (string, error) {
+	return db.getCost(tableName, key, nil)
+}
 
-	prefixedKey := fmt.Sprintf("%s:%s", tableName, key)
-	value, err := db.storage.Get(prefixedKey)
+// getCost is get counting the structures touched into cost, if not nil (see readamp.go).
+func (db *Database) getCost(tableName, key string, cost *ports.ReadCost) (string, error) {
+	value, err := db.getRawCost(tableName, key, cost)
 	if err != nil {
-		db.logger.Warn(fmt.Sprintf("Key %s not found in table %s: %v", key, tableName, err))
 		return "", err
 	}
+	if db.spec.Tables[tableName].Metadata {
+		_, value, err = decodeEntry(value)
+	}
+	return value, err
+}
+
+// getRaw returns the value as stored, including entry metadata, decrypted if the table is
+// encrypted. Caller holds db.mu.
+func (db *Database) getRaw(tableName, key string) (string, error) {
+	return db.getRawCost(tableName, key, nil)
+}
 
-	return value.(string), nil
+func (db *Database) getRawCost(tableName, key string, cost *ports.ReadCost) (string, error) {
+	storage, prefix := db.tableStorage(tableName)
+	value, err := storageGet(storage, prefix+key, cost)
+	if err != nil {
+		return "", err
+	}
+	s, err := ports.ValueString(value)
+	if err != nil {
+		return "", err
+	}
+	return db.decryptValue(tableName, key, s)
 }
 
 // Delete removes a key-value pair from a table.
 func (db *Database) Delete(tableName, key string) error {
+	return db.DeleteWithAck(tableName, key, WriteAck{})
+}
+
+// DeleteWithAck removes a key-value pair and waits until the delete satisfies ack.
+// A zero WriteAck uses DatabaseConfig.WriteAck.
+func (db *Database) DeleteWithAck(tableName, key string, ack WriteAck) error {
+	return db.deleteWithAck(context.Background(), tableName, key, ack)
+}
+
+func (db *Database) deleteWithAck(ctx context.Context, tableName, key string, ack WriteAck) error {
+	if other, table, ok := db.attachedTable(tableName); ok {
+		return other.deleteWithAck(ctx, table, key, ack)
+	}
+	defer db.slo.observe(sloWrite, time.Now())
+	op := db.ops.begin(opDelete)
+	defer db.ops.end(&op)
+	seq, err := db.delete(tableName, key)
+	if err != nil {
+		return err
+	}
+	op.written = len(key)
+	return db.awaitAck(ctx, seq, ack)
+}
+
+func (db *Database) delete(tableName, key string) (uint64, error) {
 	if db.config.ThreadSafe {
 		db.mu.Lock()
 		defer db.mu.Unlock()
 	}
+	return db.deleteLocked(tableName, key)
+}
 
+// deleteLocked performs the delete. Caller holds db.mu.
+func (db *Database) deleteLocked(tableName, key string) (uint64, error) {
 	if _, exists := db.spec.Tables[tableName]; !exists {
-		return fmt.Errorf("table %s not found", tableName)
+		return 0, fmt.Errorf("table %s not found", tableName)
 	}
 
-	prefixedKey := fmt.Sprintf("%s:%s", tableName, key)
-	err := db.storage.Delete(prefixedKey)
+	storage, prefix := db.tableStorage(tableName)
+	var oldValue string
+	var hadOld bool
+	if _, indexed := db.search[tableName]; indexed {
+		if old, err := storage.Get(prefix + key); err == nil {
+			oldValue, _ = db.storedValue(tableName, key, old)
+			hadOld = true
+		}
+	}
+	var buried interface{}
+	if _, soft := db.trash[tableName]; soft {
+		buried, _ = storage.Get(prefix + key)
+	}
+	db.markKeysDirty()
+	err := storage.Delete(prefix + key)
 	if err != nil {
 		db.status.Error = err.Error()
-		db.logger.Error(fmt.Sprintf("Failed to delete key %s from %s: %v", key, tableName, err))
-		return err
+		db.logger.Error("Failed to delete key", "table", tableName, "key", key, "error", err)
+		return 0, err
+	}
+	db.keyStatsFor(tableName).add(key, -1)
+	indexErr := db.updateSearchIndex(tableName, key, oldValue, "", hadOld)
+	if err := db.updateCountViews(tableName, key, -1); err != nil && indexErr == nil {
+		indexErr = err
+	}
+	if buried != nil {
+		if err := db.buryLocked(tableName, key, buried); err != nil && indexErr == nil {
+			indexErr = err
+		}
+	}
+	seq := db.commit(Mutation{Op: MutationDelete, Table: tableName, Key: key})
+	if indexErr != nil {
+		db.status.Error = indexErr.Error()
+		db.logger.Error("Failed to update auxiliary tables", "table", tableName, "key", key, "error", indexErr)
+		return seq, indexErr
 	}
 
-	// TODO: Emit DeleteEvent (for event-driven architecture)
-	db.logger.Info(fmt.Sprintf("Deleted key %s from table %s", key, tableName))
-	return nil
+	db.logger.Info("Deleted key", "table", tableName, "key", key)
+	return seq, nil
 }
 
 // Close gracefully shuts down the database.
 func (db *Database) Close() error {
+	db.maint.stop() // 진행 중인 유지보수 작업이 잠금을 잡고 있을 수 있으므로 먼저 멈춤
+	if err := db.detachAll(); err != nil {
+		db.logger.Warn("Failed to close attached databases", "database", db.config.Name, "error", err)
+	}
 	if db.config.ThreadSafe {
 		db.mu.Lock()
 		defer db.mu.Unlock()
 	}
 
+	if db.status.Ready && db.keysDirty {
+		// 정상 종료: 카운터를 clean 상태로 저장해 다음 오픈 시 그대로 신뢰할 수 있게 함.
+		db.keysDirty = false
+		if err := db.saveHeader(); err != nil {
+			db.logger.Warn("Failed to save key counters", "error", err)
+		}
+	}
+	// 저장소 어댑터의 백그라운드 작업(WAL 워커 등)을 먼저 멈춘 뒤 파일을 닫음.
+	if s, ok := db.storage.(ports.ManagedStorage); ok {
+		if err := s.Close(); err != nil {
+			db.logger.Error("Failed to close storage", "database", db.config.Name, "error", err)
+			return err
+		}
+	}
 	err := db.file.Close()
 	if err != nil {
-		db.logger.Error(fmt.Sprintf("Failed to close database %s: %v", db.config.Name, err))
+		db.logger.Error("Failed to close database", "database", db.config.Name, "error", err)
 		return err
 	}
 	db.status.Ready = false
-	db.logger.Info(fmt.Sprintf("Database %s closed", db.config.Name))
+	db.logger.Info("Database closed", "database", db.config.Name)
+	return nil
+}
+
+// Stats returns database-level counters merged with the storage adapter's statistics
+// (under "storage") when the adapter implements ports.ManagedStorage.
+func (db *Database) Stats() map[string]interface{} {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	stats := map[string]interface{}{
+		"name":                db.config.Name,
+		"tables":              len(db.spec.Tables),
+		"ready":               db.status.Ready,
+		"seq":                 db.seq,
+		"durability":          string(db.config.Durability),
+		"soft_limit_warnings": db.limits.warnings,
+	}
+	if db.config.Durability == ports.DurabilityFsyncPerBatch {
+		stats["group_fsyncs"] = db.syncs.count()
+	}
+	if db.slo != nil {
+		stats["latency_slo"] = db.slo.stats()
+	}
+	if db.feed != nil {
+		stats["changefeed_records"] = db.feed.entries
+	}
+	if db.ops != nil {
+		stats["ops"] = db.ops.stats()
+	}
+	if db.reads != nil {
+		stats["read_amplification"] = db.reads.stats()
+	}
+	if s, ok := db.storage.(ports.ManagedStorage); ok {
+		stats["storage"] = s.Stats()
+	}
+	return stats
+}
+
+// SetCacheSize resizes the storage cache at runtime. It returns an error if the storage
+// adapter has no resizable cache.
+func (db *Database) SetCacheSize(n int) error {
+	r, ok := db.storage.(ports.CacheResizer)
+	if !ok {
+		return fmt.Errorf("storage of database %s does not support cache resizing", db.config.Name)
+	}
+	r.SetCacheSize(n)
 	return nil
 }
 
@@ -313,7 +905,47 @@ func (db *Database) GetStatus() DatabaseStatus {
 		db.mu.RLock()
 		defer db.mu.RUnlock()
 	}
-	return db.status
+	status := db.status
+	if r, ok := db.storage.(ports.RecoveryReporter); ok {
+		status.RecoveryDebt = r.RecoveryDebt()
+	}
+	status.Maintenance = db.maint.statuses()
+	status.Health = db.health
+	status.Quota = db.quotaUsage()
+	status.Storage = db.storageStatus()
+	return status
+}
+
+// RecoveryInfo describes the recovery the storage ran when it was opened: the WAL records
+// replayed, the corrupted records that replay stopped at or skipped and the SSTables
+// quarantined since, so that lost data doesn't go unnoticed. It returns false if the storage
+// doesn't report its recovery (see ports.RecoveryReporter).
+func (db *Database) RecoveryInfo() (ports.RecoveryReport, bool) {
+	r, ok := db.storage.(ports.RecoveryReporter)
+	if !ok {
+		return ports.RecoveryReport{}, false
+	}
+	return r.RecoveryReport(), true
+}
+
+// storageStatus collects the state of the storage adapter. Caller holds db.mu.
+func (db *Database) storageStatus() StorageStatus {
+	status := StorageStatus{DiskBytes: db.storageBytes(), TableKeys: make(map[string]int64, len(db.spec.Tables))}
+	for name := range db.spec.Tables {
+		var keys int64
+		if s, ok := db.keys[name]; ok {
+			keys = s.count
+		}
+		status.TableKeys[name] = keys
+	}
+	if r, ok := db.storage.(ports.StatusReporter); ok {
+		s := r.StorageStatus()
+		status.CacheHits, status.CacheMisses, status.LastCompaction = s.CacheHits, s.CacheMisses, s.LastCompaction
+		if lookups := s.CacheHits + s.CacheMisses; lookups > 0 {
+			status.CacheHitRatio = float64(s.CacheHits) / float64(lookups)
+		}
+	}
+	return status
 }
 
 // GetSpec returns the current spec of the database.
@@ -324,3 +956,14 @@ func (db *Database) GetSpec() DatabaseSpec {
 	}
 	return db.spec
 }
+
+// Events returns the lifecycle event bus used by the database.
+func (db *Database) Events() *events.Bus {
+	return db.events
+}
+
+// Subscribe registers for lifecycle events of the given types (all types if none given).
+// Call Close on the returned subscription when done.
+func (db *Database) Subscribe(types ...events.Type) *events.Subscription {
+	return db.events.Subscribe(events.DefaultBufferSize, types...)
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 범위 삭제: DeleteRange는 테이블에서 [startKey, endKey) 범위의 키를 한 번의 쓰기 잠금 안에서 지우므로,
+// 대량 정리를 키마다 왕복하지 않고 끝낼 수 있습니다. 지운 키마다 보통의 Delete와 같은 변경
+// (MutationDelete)을 커밋하므로 복제, 캡처, 체인지피드와 버전 기록에는 개별 삭제로 전달됩니다.
+//
+// 저장소가 ports.RangeDeleter를 구현하고 테이블에 키마다 갱신할 보조 테이블(검색 색인, 카운트 뷰,
+// 소프트 삭제)이 없으면 저장소에서 범위를 한 번에 지웁니다. 그렇지 않으면 키마다 Delete와 같은 경로로
+// 지워 보조 테이블을 함께 갱신합니다.
+
+// DeleteRange deletes the keys of tableName from startKey up to, not including, endKey
+// ("" = to the end of the table) and returns how many were deleted.
+func (db *Database) DeleteRange(tableName, startKey, endKey string) (int, error) {
+	return db.DeleteRangeWithAck(tableName, startKey, endKey, WriteAck{})
+}
+
+// DeleteRangeWithAck is DeleteRange that waits until the deletes satisfy ack.
+func (db *Database) DeleteRangeWithAck(tableName, startKey, endKey string, ack WriteAck) (int, error) {
+	defer db.slo.observe(sloWrite, time.Now())
+	op := db.ops.begin(opDelete)
+	defer db.ops.end(&op)
+	keys, seq, err := db.deleteRange(tableName, startKey, endKey)
+	for _, key := range keys {
+		op.written += len(key)
+	}
+	if err != nil || len(keys) == 0 {
+		return len(keys), err
+	}
+	return len(keys), db.awaitAck(context.Background(), seq, ack)
+}
+
+// deleteRange deletes the keys in the range under db.mu and returns them with the sequence
+// number of the last delete.
+func (db *Database) deleteRange(tableName, startKey, endKey string) ([]string, uint64, error) {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return nil, 0, fmt.Errorf("table %s not found", tableName)
+	}
+	if endKey != "" && endKey <= startKey {
+		return nil, 0, nil
+	}
+	var keys []string
+	if err := db.scanLocked(tableName, rangePrefix(startKey, endKey), func(key, _ string) bool {
+		if endKey != "" && key >= endKey {
+			return false
+		}
+		if key >= startKey {
+			keys = append(keys, key)
+		}
+		return true
+	}); err != nil {
+		return nil, 0, err
+	}
+	if len(keys) == 0 {
+		return nil, 0, nil
+	}
+
+	storage, prefix := db.tableStorage(tableName)
+	deleter, ok := storage.(ports.RangeDeleter)
+	_, indexed := db.search[tableName]
+	_, counted := db.counts[tableName]
+	_, soft := db.trash[tableName]
+	if !ok || indexed || counted || soft {
+		var seq uint64
+		for i, key := range keys {
+			s, err := db.deleteLocked(tableName, key)
+			if err != nil {
+				return keys[:i], seq, err
+			}
+			seq = s
+		}
+		db.logger.Info("Deleted key range", "table", tableName, "start", startKey, "end", endKey, "keys", len(keys))
+		return keys, seq, nil
+	}
+
+	end := ""
+	if endKey != "" {
+		end = prefix + endKey
+	} else if prefix != "" {
+		end = prefixEnd(prefix)
+	}
+	db.markKeysDirty()
+	if _, err := deleter.DeleteRange(prefix+startKey, end); err != nil {
+		db.status.Error = err.Error()
+		db.logger.Error("Failed to delete key range", "table", tableName, "start", startKey, "end", endKey, "error", err)
+		return nil, 0, err
+	}
+	var seq uint64
+	stats := db.keyStatsFor(tableName)
+	for _, key := range keys {
+		stats.add(key, -1)
+		seq = db.commit(Mutation{Op: MutationDelete, Table: tableName, Key: key})
+	}
+	db.logger.Info("Deleted key range", "table", tableName, "start", startKey, "end", endKey, "keys", len(keys))
+	return keys, seq, nil
+}
+
+// rangePrefix returns the longest common prefix of startKey and endKey, which every key of
+// the range starts with ("" if endKey is "").
+func rangePrefix(startKey, endKey string) string {
+	if endKey == "" {
+		return ""
+	}
+	i := 0
+	for i < len(startKey) && i < len(endKey) && startKey[i] == endKey[i] {
+		i++
+	}
+	return startKey[:i]
+}
+
+// prefixEnd returns the smallest key greater than every key starting with prefix, or "" if
+// there is none.
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// 덤프 스트림은 저장소 형식과 무관한 정규(canonical) 형식입니다:
+//
+//	"GLD1" 레코드...
+//	레코드 = u32 본문 길이 | 본문 | u32 CRC32(본문)   (리틀 엔디안)
+//	본문   = 종류 1바이트 + uvarint 길이가 붙은 문자열들
+//	  table: 이름, 옵션 (TableOptions.String)
+//	  entry: 테이블, 키, 값
+//	  end:   uvarint 레코드 수 (end 제외)
+//
+// 테이블은 이름순, 키는 오름차순으로 기록되므로 같은 내용의 데이터베이스는 바이트 단위로 같은
+// 덤프를 만듭니다. 값은 사용자에게 보이는 값입니다: 암호화 테이블은 복호화되고, 메타데이터
+// 테이블의 EntryMeta는 기록되지 않습니다 (LoadFrom이 새 시각으로 다시 만듭니다).
+// end 레코드가 없으면 잘린 스트림으로 보고 ErrDumpCorrupt를 반환합니다.
+
+// dumpMagic starts every dump stream.
+const dumpMagic = "GLD1"
+
+// Dump record kinds.
+const (
+	dumpEnd   byte = 0
+	dumpTable byte = 1
+	dumpEntry byte = 2
+)
+
+// maxDumpRecord bounds the body length read from a stream, so a corrupt length can't make
+// LoadFrom allocate unbounded memory.
+const maxDumpRecord = 256 << 20
+
+// dumpLoadBatch is the number of entries LoadFrom writes per batch.
+const dumpLoadBatch = 1000
+
+// ErrDumpCorrupt is returned by LoadFrom when the stream is not a dump, fails a checksum
+// or is cut short.
+var ErrDumpCorrupt = errors.New("corrupt dump stream")
+
+// DumpTo writes every table of the database to w in the canonical dump format, for backups
+// and for bootstrapping a replica with LoadFrom. Writers are blocked until the dump is written,
+// so it is a consistent point-in-time copy.
+func (db *Database) DumpTo(w io.Writer) error {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if !db.status.Ready {
+		return fmt.Errorf("database %s is closed", db.config.Name)
+	}
+	names := make([]string, 0, len(db.spec.Tables))
+	for name := range db.spec.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	dw := dumpWriter{w: bw}
+	if _, err := bw.WriteString(dumpMagic); err != nil {
+		return fmt.Errorf("failed to write dump: %v", err)
+	}
+	for _, name := range names {
+		spec := db.spec.Tables[name]
+		opts := TableOptions{Metadata: spec.Metadata, Encrypted: spec.Encrypted}
+		if err := dw.record(dumpTable, name, opts.String()); err != nil {
+			return err
+		}
+		err := db.scanLocked(name, "", func(key, value string) bool {
+			return dw.record(dumpEntry, name, key, value) == nil
+		})
+		if dw.err != nil {
+			return dw.err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to dump table %s: %v", name, err)
+		}
+	}
+	if err := dw.end(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write dump: %v", err)
+	}
+	db.logger.Info("Dump written", "database", db.config.Name, "tables", len(names), "records", dw.count)
+	return nil
+}
+
+// dumpWriter frames dump records, keeping the first write error.
+type dumpWriter struct {
+	w     *bufio.Writer
+	buf   []byte
+	count uint64
+	err   error
+}
+
+func (d *dumpWriter) record(kind byte, fields ...string) error {
+	body := append(d.buf[:0], kind)
+	for _, f := range fields {
+		body = binary.AppendUvarint(body, uint64(len(f)))
+		body = append(body, f...)
+	}
+	d.count++
+	return d.write(body)
+}
+
+func (d *dumpWriter) end() error {
+	return d.write(binary.AppendUvarint(append(d.buf[:0], dumpEnd), d.count))
+}
+
+func (d *dumpWriter) write(body []byte) error {
+	d.buf = body
+	if d.err != nil {
+		return d.err
+	}
+	frame := binary.LittleEndian.AppendUint32(nil, uint32(len(body)))
+	if _, err := d.w.Write(frame); err != nil {
+		d.err = fmt.Errorf("failed to write dump: %v", err)
+		return d.err
+	}
+	if _, err := d.w.Write(body); err != nil {
+		d.err = fmt.Errorf("failed to write dump: %v", err)
+		return d.err
+	}
+	if _, err := d.w.Write(binary.LittleEndian.AppendUint32(frame[:0], crc32.ChecksumIEEE(body))); err != nil {
+		d.err = fmt.Errorf("failed to write dump: %v", err)
+	}
+	return d.err
+}
+
+// LoadFrom reads a stream written by DumpTo and inserts its entries, creating the tables
+// that don't exist with their dumped options. Existing keys are overwritten; keys missing
+// from the dump are kept. Records are applied as they are read, so when LoadFrom fails
+// (ErrDumpCorrupt for a damaged or truncated stream) the records before the failure stay applied.
+func (db *Database) LoadFrom(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(dumpMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != dumpMagic {
+		return fmt.Errorf("%w: missing dump header", ErrDumpCorrupt)
+	}
+	batch := NewWriteBatch()
+	flush := func() error {
+		if err := db.Write(batch); err != nil {
+			return fmt.Errorf("failed to load dump: %v", err)
+		}
+		batch = NewWriteBatch()
+		return nil
+	}
+	var count uint64
+	for {
+		body, err := readDumpRecord(br)
+		if err != nil {
+			return err
+		}
+		if body[0] == dumpEnd {
+			n, k := binary.Uvarint(body[1:])
+			if k <= 0 || n != count {
+				return fmt.Errorf("%w: end record counts %d records, read %d", ErrDumpCorrupt, n, count)
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			db.logger.Info("Dump loaded", "database", db.config.Name, "records", count)
+			return nil
+		}
+		count++
+		fields, err := dumpFields(body[1:])
+		if err != nil {
+			return err
+		}
+		switch {
+		case body[0] == dumpTable && len(fields) == 2:
+			// 앞선 항목이 새 테이블보다 먼저 기록되도록 배치를 비움
+			if err := flush(); err != nil {
+				return err
+			}
+			err := db.createTable(fields[0], ParseTableOptions(fields[1]))
+			if err != nil && !errors.Is(err, ErrTableExists) {
+				return fmt.Errorf("failed to load table %s: %v", fields[0], err)
+			}
+		case body[0] == dumpEntry && len(fields) == 3:
+			batch.Insert(fields[0], fields[1], fields[2])
+			if batch.Len() >= dumpLoadBatch {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: invalid record of kind %d", ErrDumpCorrupt, body[0])
+		}
+	}
+}
+
+// readDumpRecord reads one framed record and verifies its checksum.
+func readDumpRecord(r io.Reader) ([]byte, error) {
+	var frame [4]byte
+	if _, err := io.ReadFull(r, frame[:]); err != nil {
+		return nil, fmt.Errorf("%w: stream ends without an end record", ErrDumpCorrupt)
+	}
+	n := binary.LittleEndian.Uint32(frame[:])
+	if n == 0 || n > maxDumpRecord {
+		return nil, fmt.Errorf("%w: invalid record length %d", ErrDumpCorrupt, n)
+	}
+	body := make([]byte, n+4)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("%w: record cut short", ErrDumpCorrupt)
+	}
+	sum := binary.LittleEndian.Uint32(body[n:])
+	body = body[:n]
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, fmt.Errorf("%w: record checksum mismatch", ErrDumpCorrupt)
+	}
+	return body, nil
+}
+
+// dumpFields splits a record body (after the kind byte) into its strings.
+func dumpFields(b []byte) ([]string, error) {
+	var fields []string
+	for len(b) > 0 {
+		n, k := binary.Uvarint(b)
+		if k <= 0 || n > uint64(len(b)-k) {
+			return nil, fmt.Errorf("%w: invalid field length", ErrDumpCorrupt)
+		}
+		fields = append(fields, string(b[k:k+int(n)]))
+		b = b[k+int(n):]
+	}
+	return fields, nil
+}
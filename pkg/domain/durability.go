@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// DatabaseConfig.Durability는 쓰기 확인(WriteAck)의 기본 바탕이 됩니다. AckLocal 쓰기도
+// fsync-per-commit이면 쓰기마다 저장소를 fsync하고, fsync-per-batch이면 동시에 대기 중인 쓰기가
+// 한 번의 fsync를 공유합니다. B-tree는 WAL이 없으므로 none과 async는 같게 동작합니다.
+//
+// Sync(ctx)는 수준과 관계없이 애플리케이션이 고른 시점(예: 메시지 배치 처리 후)을 커밋 지점으로
+// 삼게 해 줍니다. fsync-per-batch 쓰기와 같은 groupSync를 거치므로 동시에 대기 중인 쓰기나 다른
+// Sync 호출과 한 번의 fsync를 공유합니다.
+
+// groupSync coalesces concurrent fsyncs. A waiter is satisfied by an fsync that started after
+// its write was committed; writers arriving during an fsync share the next one.
+type groupSync struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	synced  uint64 // Highest sequence number known to be durable
+	running bool
+	syncs   uint64 // Number of fsyncs performed
+}
+
+func newGroupSync() *groupSync {
+	g := &groupSync{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// wait blocks until the write with sequence seq is durable. current returns the sequence
+// number of the last committed write and fsync makes every committed write durable.
+func (g *groupSync) wait(seq uint64, current func() uint64, fsync func() error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.synced < seq {
+		if g.running {
+			g.cond.Wait()
+			continue
+		}
+		g.running = true
+		target := current()
+		g.mu.Unlock()
+		err := fsync()
+		g.mu.Lock()
+		g.running = false
+		g.syncs++
+		g.cond.Broadcast()
+		if err != nil {
+			return err
+		}
+		if target > g.synced {
+			g.synced = target
+		}
+	}
+	return nil
+}
+
+func (g *groupSync) count() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.syncs
+}
+
+// makeDurable syncs the write with sequence seq as DatabaseConfig.Durability requires.
+// force syncs even if the durability level does not (AckLeaderFsync and stronger).
+func (db *Database) makeDurable(seq uint64, force bool) error {
+	switch d := db.config.Durability; {
+	case d == ports.DurabilityFsyncPerBatch:
+		return db.syncs.wait(seq, db.Seq, db.syncStorage)
+	case d == ports.DurabilityFsyncPerCommit || force:
+		return db.syncStorage()
+	}
+	return nil
+}
+
+// Sync makes every write committed before the call durable: the storage adapter is synced
+// (WAL, buffered pages and its header) and then the database file with the table headers.
+// If ctx ends first Sync returns ctx.Err(); the fsync it started still completes.
+func (db *Database) Sync(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	seq := db.Seq()
+	done := make(chan error, 1)
+	go func() { done <- db.syncs.wait(seq, db.Seq, db.syncStorage) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 선택적 테이블 암호화: TableOptions.Encrypted로 만든 테이블은 자신만의 데이터 키(AES-256)를 가지며,
+// 값은 AES-GCM으로 암호화되어 저장됩니다. 데이터 키는 DatabaseConfig.MasterKey로 감싸(wrap) page 1
+// 헤더의 "KEYS" 섹션에 키 ID와 함께 기록되므로, 마스터 키 없이 파일만으로는 값을 읽을 수 없습니다.
+// 암호화하지 않은 테이블은 그대로 평문으로 저장되어 추가 비용이 없습니다.
+//
+// 키는 정렬과 prefix 스캔을 위해 평문으로 남습니다. 암호문은 테이블 이름과 키를 AAD로 묶으므로
+// 다른 키나 테이블로 옮겨진 값은 복호화에 실패합니다. 저장 형식: nonce(12) + 암호문 + tag(16).
+// 커밋 리스너(복제, 캡처)에는 평문 값이 전달됩니다.
+
+// tableKeysMagic marks the data key section that follows the table options in page 1.
+const tableKeysMagic uint32 = 0x5359454B // "KEYS"
+
+// dataKeySize is the size of a table data key (AES-256).
+const dataKeySize = 32
+
+// ErrMasterKeyRequired is returned when an encrypted table is created or used without the
+// master key its data key was wrapped with.
+var ErrMasterKeyRequired = errors.New("encrypted table requires the master key")
+
+// dataKey is the unwrapped data key of an encrypted table.
+type dataKey struct {
+	id      string      // Random key ID recorded in the table header (TableSpec.KeyID)
+	wrapped []byte      // Data key sealed with the master key
+	aead    cipher.AEAD // nil if the master key is missing or wrong
+}
+
+// newAEAD returns AES-GCM for key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with aead, prefixing a random nonce.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	out := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, out, plaintext, aad), nil
+}
+
+// open decrypts data produced by seal.
+func open(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], aad)
+}
+
+// newDataKey generates and wraps a data key for a new encrypted table.
+func (db *Database) newDataKey() (*dataKey, error) {
+	master, err := db.masterAEAD()
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 8)
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	dk := &dataKey{id: hex.EncodeToString(id)}
+	if dk.wrapped, err = seal(master, key, []byte(dk.id)); err != nil {
+		return nil, err
+	}
+	if dk.aead, err = newAEAD(key); err != nil {
+		return nil, err
+	}
+	return dk, nil
+}
+
+// unwrapDataKey restores the cipher of a data key read from the table header. Without the right
+// master key the table stays listed but its values can't be read or written.
+func (db *Database) unwrapDataKey(table string, dk *dataKey) {
+	master, err := db.masterAEAD()
+	if err != nil {
+		db.logger.Warn("Table is encrypted but no master key is configured", "table", table)
+		return
+	}
+	key, err := open(master, dk.wrapped, []byte(dk.id))
+	if err != nil {
+		db.logger.Warn("Failed to unwrap data key: wrong master key?", "table", table, "key_id", dk.id)
+		return
+	}
+	dk.aead, _ = newAEAD(key)
+}
+
+func (db *Database) masterAEAD() (cipher.AEAD, error) {
+	if len(db.config.MasterKey) == 0 {
+		return nil, ErrMasterKeyRequired
+	}
+	aead, err := newAEAD(db.config.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %v", err)
+	}
+	return aead, nil
+}
+
+// tableCipher returns the cipher of an encrypted table, or nil for plaintext tables.
+// Caller holds db.mu.
+func (db *Database) tableCipher(table string) (cipher.AEAD, error) {
+	dk, ok := db.dataKeys[table]
+	if !ok {
+		return nil, nil
+	}
+	if dk.aead == nil {
+		return nil, fmt.Errorf("table %s: %w", table, ErrMasterKeyRequired)
+	}
+	return dk.aead, nil
+}
+
+// encryptValue encrypts a stored value of an encrypted table; other tables pass through.
+// Caller holds db.mu.
+func (db *Database) encryptValue(table, key, stored string) (string, error) {
+	aead, err := db.tableCipher(table)
+	if aead == nil {
+		return stored, err
+	}
+	sealed, err := seal(aead, []byte(stored), valueAAD(table, key))
+	if err != nil {
+		return "", err
+	}
+	return string(sealed), nil
+}
+
+// decryptValue reverses encryptValue. Caller holds db.mu.
+func (db *Database) decryptValue(table, key, stored string) (string, error) {
+	aead, err := db.tableCipher(table)
+	if aead == nil {
+		return stored, err
+	}
+	plain, err := open(aead, []byte(stored), valueAAD(table, key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt key %s in table %s: %v", key, table, err)
+	}
+	return string(plain), nil
+}
+
+func valueAAD(table, key string) []byte {
+	return []byte(table + "\x00" + key)
+}
+
+// encodeTableKeys appends the data key section if any table is encrypted. Caller holds db.mu.
+func (db *Database) encodeTableKeys(buf *bytes.Buffer, names []string) {
+	if len(db.dataKeys) == 0 {
+		return
+	}
+	binary.Write(buf, byteOrder, tableKeysMagic)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	for _, name := range names {
+		dk, ok := db.dataKeys[name]
+		if !ok {
+			buf.WriteByte(0)
+			continue
+		}
+		buf.WriteByte(1)
+		for _, field := range [][]byte{[]byte(dk.id), dk.wrapped} {
+			buf.Write(tmp[:binary.PutUvarint(tmp, uint64(len(field)))])
+			buf.Write(field)
+		}
+	}
+}
+
+// decodeTableKeys reads the data key section if present; r is left untouched otherwise.
+func (db *Database) decodeTableKeys(r *bytes.Reader, names []string) {
+	start, _ := r.Seek(0, io.SeekCurrent)
+	var magic uint32
+	if err := binary.Read(r, byteOrder, &magic); err != nil || magic != tableKeysMagic {
+		r.Seek(start, io.SeekStart)
+		return
+	}
+	readField := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n > uint64(r.Len()) {
+			return nil, fmt.Errorf("invalid data key section")
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	}
+	for _, name := range names {
+		encrypted, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		if encrypted == 0 {
+			continue
+		}
+		id, err := readField()
+		if err != nil {
+			return
+		}
+		wrapped, err := readField()
+		if err != nil {
+			return
+		}
+		dk := &dataKey{id: string(id), wrapped: wrapped}
+		db.unwrapDataKey(name, dk)
+		db.dataKeys[name] = dk
+		db.spec.Tables[name].Encrypted = true
+		db.spec.Tables[name].KeyID = dk.id
+	}
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// 헤더 검사: ReadHeader는 데이터베이스를 열지 않고 page 1 헤더(loadHeader 참조)를 해석합니다.
+// golite fsck(pkg/repair)가 테이블 목록과 테이블별 서브트리 root를 얻는 데 사용합니다. loadHeader는
+// 잘린 섹션을 만나면 남은 부분을 무시하고 열지만, ReadHeader는 그 자리에서 오류를 반환합니다.
+// 섹션 순서는 테이블 이름, root(ROOT), 테이블 옵션(META), 데이터 키(KEYS), 키 통계(KCNT)이며
+// 이름 외의 섹션은 모두 magic으로 시작하고 생략될 수 있습니다.
+
+// HeaderTable is a table recorded in the page 1 header.
+type HeaderTable struct {
+	Name      string
+	Isolated  bool  // Stored in its own subtree; otherwise as "Name:key" in the main tree
+	Root      int64 // Subtree root offset (0 = empty subtree)
+	Length    int   // Subtree item count
+	Metadata  bool  // Entries carry EntryMeta (TableOptions.Metadata)
+	Encrypted bool  // Values are encrypted with a table data key
+}
+
+// Header is a parsed page 1 header.
+type Header struct {
+	Tables []HeaderTable
+
+	page       []byte
+	rootsStart int // Offset of the root section (-1 if none)
+	rootsEnd   int
+	statsFlags int // Offset of the key statistics flags (-1 if none)
+}
+
+// ReadHeader parses a page 1 header. An all-zero page is a database without tables.
+func ReadHeader(page []byte) (*Header, error) {
+	h := &Header{page: page, rootsStart: -1, statsFlags: -1}
+	r := bytes.NewReader(page)
+	pos := func() int { return len(page) - r.Len() }
+	truncated := func(section string, err error) error {
+		return fmt.Errorf("truncated %s section at offset %d: %v", section, pos(), err)
+	}
+
+	var tableCount uint32
+	if err := binary.Read(r, byteOrder, &tableCount); err != nil {
+		return nil, truncated("table", err)
+	}
+	for i := uint32(0); i < tableCount; i++ {
+		var nameLen uint16
+		if err := binary.Read(r, byteOrder, &nameLen); err != nil {
+			return nil, truncated("table", err)
+		}
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return nil, truncated("table", err)
+		}
+		h.Tables = append(h.Tables, HeaderTable{Name: string(name)})
+	}
+	if len(h.Tables) == 0 {
+		return h, nil
+	}
+
+	section := func(magic uint32) bool {
+		start := pos()
+		var m uint32
+		if err := binary.Read(r, byteOrder, &m); err != nil || m != magic {
+			r.Seek(int64(start), io.SeekStart)
+			return false
+		}
+		return true
+	}
+	if start := pos(); section(tableRootsMagic) {
+		h.rootsStart = start
+		for i := range h.Tables {
+			t := &h.Tables[i]
+			isolated, err := r.ReadByte()
+			if err != nil {
+				return nil, truncated("root", err)
+			}
+			if isolated == 0 {
+				continue
+			}
+			root, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, truncated("root", err)
+			}
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, truncated("root", err)
+			}
+			t.Isolated, t.Root, t.Length = true, int64(root), int(length)
+		}
+		h.rootsEnd = pos()
+	}
+	if section(tableMetaMagic) {
+		for i := range h.Tables {
+			opts, err := r.ReadByte()
+			if err != nil {
+				return nil, truncated("table options", err)
+			}
+			h.Tables[i].Metadata = opts&1 != 0
+		}
+	}
+	if section(tableKeysMagic) {
+		for i := range h.Tables {
+			encrypted, err := r.ReadByte()
+			if err != nil {
+				return nil, truncated("data key", err)
+			}
+			if encrypted == 0 {
+				continue
+			}
+			for range 2 { // key ID, wrapped key
+				n, err := binary.ReadUvarint(r)
+				if err == nil && n > uint64(r.Len()) {
+					err = io.ErrUnexpectedEOF
+				}
+				if err != nil {
+					return nil, truncated("data key", err)
+				}
+				r.Seek(int64(n), io.SeekCurrent)
+			}
+			h.Tables[i].Encrypted = true
+		}
+	}
+	if section(keyStatsMagic) && r.Len() > 0 {
+		h.statsFlags = pos()
+	}
+	return h, nil
+}
+
+// WithRoots returns a copy of the header page with the subtree roots and lengths of tables,
+// which must list the same tables in the same order as h.Tables. The key counters are marked
+// as not exact, since the trees may no longer hold the keys they counted.
+func (h *Header) WithRoots(tables []HeaderTable) ([]byte, error) {
+	if len(tables) != len(h.Tables) {
+		return nil, fmt.Errorf("header has %d tables, got %d", len(h.Tables), len(tables))
+	}
+	for i, t := range tables {
+		if t.Name != h.Tables[i].Name || t.Isolated != h.Tables[i].Isolated {
+			return nil, fmt.Errorf("table %d is %q, got %q", i, h.Tables[i].Name, t.Name)
+		}
+	}
+	page := bytes.Clone(h.page)
+	statsFlags := h.statsFlags
+	if h.rootsStart >= 0 {
+		var roots bytes.Buffer
+		appendTableRoots(&roots, tables)
+		rest := h.page[h.rootsEnd:]
+		// 남는 공간은 0으로 채워져 있으므로 root 섹션이 길어진 만큼 끝에서 잘라 냄
+		grow := roots.Len() - (h.rootsEnd - h.rootsStart)
+		if grow > 0 {
+			if len(bytes.TrimRight(rest, "\x00")) > len(rest)-grow {
+				return nil, fmt.Errorf("header exceeds page size by %d bytes", grow)
+			}
+			rest = rest[:len(rest)-grow]
+		}
+		page = append(append(page[:h.rootsStart], roots.Bytes()...), rest...)
+		page = append(page, make([]byte, len(h.page)-len(page))...)
+		if statsFlags >= 0 {
+			statsFlags += roots.Len() - (h.rootsEnd - h.rootsStart)
+		}
+	}
+	if statsFlags >= 0 {
+		page[statsFlags] &^= keyStatsClean
+	}
+	return page, nil
+}
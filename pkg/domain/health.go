@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 상태 점검: HealthCheck는 쿠버네티스 liveness/readiness 프로브처럼 자주 불려도 되는 가벼운 점검을
+// 차례로 실행합니다.
+//
+//   - ready: 데이터베이스가 닫히지 않았는지 확인합니다.
+//   - round_trip: 시스템 테이블 HealthTable의 키 하나에 현재 시각을 쓰고 바로 읽어 같은 값인지
+//     확인합니다. 저장소가 쓰기를 받아들이고 읽을 수 있는지를 실제 경로로 확인하며, 쓰기는
+//     DatabaseConfig.WriteAck와 관계없이 로컬 적용(AckLocal)까지만 기다립니다. 키 하나를 계속
+//     덮어쓰므로 데이터는 늘지 않으며, 변경 피드에는 남지 않습니다 (복제와 캡처에는 전달됨).
+//   - storage: 저장소가 ports.HealthChecker를 구현하면 그 점검(파일 어댑터는 WAL 상태)을 실행합니다.
+//
+// 결과는 HealthReport로 반환되고 마지막 결과는 GetStatus의 Health에 남습니다. 점검 하나라도 실패하면
+// 보고서의 Healthy가 false이고 첫 실패가 오류로 반환됩니다. 로드 셰딩(slo.go)은 적용되지 않습니다.
+
+// HealthTable is the system table written by HealthCheck.
+const HealthTable = "__health"
+
+const healthProbeKey = "probe"
+
+// ErrUnhealthy is returned by HealthCheck when a check fails.
+var ErrUnhealthy = errors.New("database is unhealthy")
+
+// HealthCheckResult is the outcome of one check of HealthCheck.
+type HealthCheckResult struct {
+	Name     string
+	Error    string // "" if the check passed
+	Duration time.Duration
+}
+
+// HealthReport is the result of HealthCheck.
+type HealthReport struct {
+	Healthy   bool
+	CheckedAt time.Time
+	Checks    []HealthCheckResult
+}
+
+// HealthCheck runs the checks described in health.go and returns their report. It fails
+// with ErrUnhealthy if a check fails, or with ctx.Err() if ctx ends first.
+func (db *Database) HealthCheck(ctx context.Context) (HealthReport, error) {
+	report := HealthReport{Healthy: true, CheckedAt: time.Now()}
+	var failed error
+	for _, check := range []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{"ready", db.checkReady},
+		{"round_trip", db.checkRoundTrip},
+		{"storage", db.checkStorage},
+	} {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		start := time.Now()
+		err := check.run(ctx)
+		result := HealthCheckResult{Name: check.name, Duration: time.Since(start)}
+		if err != nil {
+			result.Error = err.Error()
+			report.Healthy = false
+			if failed == nil {
+				failed = fmt.Errorf("%w: %s: %v", ErrUnhealthy, check.name, err)
+			}
+		}
+		report.Checks = append(report.Checks, result)
+		if err != nil && check.name == "ready" {
+			break // 닫힌 저장소는 더 점검하지 않음
+		}
+	}
+
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	db.health = &report
+	return report, failed
+}
+
+func (db *Database) checkReady(context.Context) error {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if !db.status.Ready {
+		return errors.New("database is closed")
+	}
+	return nil
+}
+
+// checkRoundTrip writes the current time to HealthTable and reads it back.
+func (db *Database) checkRoundTrip(ctx context.Context) error {
+	want := strconv.FormatInt(time.Now().UnixNano(), 10)
+	seq, got, err := func() (uint64, string, error) {
+		if db.config.ThreadSafe {
+			db.mu.Lock()
+			defer db.mu.Unlock()
+		}
+		if _, exists := db.spec.Tables[HealthTable]; !exists {
+			if err := db.createTableLocked(HealthTable, TableOptions{}); err != nil {
+				return 0, "", fmt.Errorf("failed to create %s: %v", HealthTable, err)
+			}
+		}
+		// 동시에 실행된 점검이 값을 덮어쓰지 않도록 쓰기와 읽기를 같은 잠금 안에서 함
+		seq, err := db.insertLocked(HealthTable, healthProbeKey, want)
+		if err != nil {
+			return 0, "", fmt.Errorf("write failed: %v", err)
+		}
+		got, err := db.get(HealthTable, healthProbeKey)
+		if err != nil {
+			return 0, "", fmt.Errorf("read failed: %v", err)
+		}
+		return seq, got, nil
+	}()
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("read %q after writing %q", got, want)
+	}
+	return db.awaitAck(ctx, seq, WriteAck{Level: AckLocal})
+}
+
+func (db *Database) checkStorage(context.Context) error {
+	if checker, ok := db.storage.(ports.HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// 테이블별 키 개수를 스캔 없이 추적합니다. 전체 개수와 함께 키의 첫 바이트로 분할된
+// 개수(partition)를 유지하므로 DropTable, Count, 쿼터 검사 등에서 전체 스캔이 필요 없습니다.
+// 카운터는 page 1 헤더에 함께 저장되며, 정상 종료(Close) 시에만 "clean"으로 표시됩니다.
+
+// keyStatsMagic marks the key statistics section that follows the table names in page 1.
+const keyStatsMagic uint32 = 0x544E434B // "KCNT"
+
+const (
+	keyStatsClean      uint8 = 1 << iota // Counters were saved by a clean Close
+	keyStatsPartitions                   // Per-first-byte partitions are included
+)
+
+// KeyStats summarizes the live keys of a table.
+type KeyStats struct {
+	Count       int64          // Number of live keys
+	ByFirstByte map[byte]int64 // Live keys partitioned by the first byte of the key
+	// Exact is false when the counters could not be trusted after reopening, e.g. after
+	// an unclean shutdown or with storage that keeps no page header.
+	Exact bool
+}
+
+// tableKeyStats is the in-memory counter set of one table.
+type tableKeyStats struct {
+	count      int64
+	partitions map[byte]int64
+}
+
+func newTableKeyStats() *tableKeyStats {
+	return &tableKeyStats{partitions: make(map[byte]int64)}
+}
+
+func (s *tableKeyStats) add(key string, delta int64) {
+	s.count += delta
+	if key == "" {
+		return
+	}
+	s.partitions[key[0]] += delta
+	if s.partitions[key[0]] == 0 {
+		delete(s.partitions, key[0])
+	}
+}
+
+// Count returns the number of live keys in a table without scanning storage.
+func (db *Database) Count(tableName string) (int64, error) {
+	stats, err := db.KeyStats(tableName)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Count, nil
+}
+
+// KeyStats returns the key counters of a table.
+func (db *Database) KeyStats(tableName string) (KeyStats, error) {
+	if other, table, ok := db.attachedTable(tableName); ok {
+		return other.KeyStats(table)
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return KeyStats{}, fmt.Errorf("table %s not found", tableName)
+	}
+	out := KeyStats{ByFirstByte: make(map[byte]int64), Exact: db.keysExact}
+	if s, ok := db.keys[tableName]; ok {
+		out.Count = s.count
+		for b, n := range s.partitions {
+			out.ByFirstByte[b] = n
+		}
+	}
+	return out, nil
+}
+
+// keyStatsFor returns the counters of a table, creating them if needed. Caller holds db.mu.
+func (db *Database) keyStatsFor(tableName string) *tableKeyStats {
+	s, ok := db.keys[tableName]
+	if !ok {
+		s = newTableKeyStats()
+		db.keys[tableName] = s
+	}
+	return s
+}
+
+// markKeysDirty records on disk that counters are about to diverge from the saved header,
+// so an unclean shutdown is detected on reopen. Only the first write after a save pays for it.
+// Caller holds db.mu.
+func (db *Database) markKeysDirty() {
+	if db.keysDirty {
+		return
+	}
+	db.keysDirty = true
+	if err := db.saveHeader(); err != nil {
+		db.logger.Warn("Failed to mark key counters dirty", "error", err)
+	}
+}
+
+// sortedTableNames returns table names in a stable order for header encoding. Caller holds db.mu.
+func (db *Database) sortedTableNames() []string {
+	names := make([]string, 0, len(db.spec.Tables))
+	for name := range db.spec.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encodeKeyStats appends the key statistics section for names to buf. Partitions are
+// omitted if they would not fit in room bytes; totals are always written.
+func (db *Database) encodeKeyStats(buf *bytes.Buffer, names []string, room int) {
+	flags := uint8(0)
+	if !db.keysDirty && db.keysExact { // Inexact counters stay inexact once persisted
+		flags |= keyStatsClean
+	}
+	encode := func(withPartitions bool) []byte {
+		var out bytes.Buffer
+		f := flags
+		if withPartitions {
+			f |= keyStatsPartitions
+		}
+		binary.Write(&out, byteOrder, keyStatsMagic)
+		out.WriteByte(f)
+		tmp := make([]byte, binary.MaxVarintLen64)
+		for _, name := range names {
+			s := db.keyStatsFor(name)
+			out.Write(tmp[:binary.PutUvarint(tmp, uint64(max(s.count, 0)))])
+			if !withPartitions {
+				continue
+			}
+			firsts := make([]int, 0, len(s.partitions))
+			for b := range s.partitions {
+				firsts = append(firsts, int(b))
+			}
+			sort.Ints(firsts)
+			out.Write(tmp[:binary.PutUvarint(tmp, uint64(len(firsts)))])
+			for _, b := range firsts {
+				out.WriteByte(byte(b))
+				out.Write(tmp[:binary.PutUvarint(tmp, uint64(max(s.partitions[byte(b)], 0)))])
+			}
+		}
+		return out.Bytes()
+	}
+	section := encode(true)
+	if len(section) > room {
+		section = encode(false)
+	}
+	buf.Write(section)
+}
+
+// decodeKeyStats reads the key statistics section following the table names.
+// Headers written before counters existed have no section; their counts are inexact.
+func (db *Database) decodeKeyStats(r *bytes.Reader, names []string) {
+	db.keysExact = false
+	var magic uint32
+	if err := binary.Read(r, byteOrder, &magic); err != nil || magic != keyStatsMagic {
+		return
+	}
+	flags, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	loaded := make(map[string]*tableKeyStats, len(names))
+	for _, name := range names {
+		s := newTableKeyStats()
+		count, err := binary.ReadUvarint(r)
+		if err != nil {
+			return
+		}
+		s.count = int64(count)
+		if flags&keyStatsPartitions != 0 {
+			n, err := binary.ReadUvarint(r)
+			if err != nil {
+				return
+			}
+			for i := uint64(0); i < n; i++ {
+				b, err := r.ReadByte()
+				if err != nil {
+					return
+				}
+				c, err := binary.ReadUvarint(r)
+				if err != nil {
+					return
+				}
+				s.partitions[b] = int64(c)
+			}
+		}
+		loaded[name] = s
+	}
+	for name, s := range loaded {
+		db.keys[name] = s
+	}
+	db.keysExact = flags&keyStatsClean != 0 && flags&keyStatsPartitions != 0
+}
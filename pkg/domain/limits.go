@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 소프트 리밋: 하드 리밋(MaxTables, page 1 헤더 크기, 저장소의 키/값 크기 상한)마다 그 일정 비율에
+// 경고 임계값을 두고, 사용량이 임계값을 넘으면 쓰기가 실패하기 전에 로그(Warn), 통계
+// ("soft_limit_warnings"), events.LimitWarning 이벤트로 알립니다.
+//
+// 테이블 수와 헤더 크기는 임계값을 넘을 때 한 번 경고하고, 다시 아래로 내려가면 재무장합니다.
+// 엔트리 크기는 테이블과 한도(key/value/entry)마다 처음 넘었을 때 한 번만 경고합니다.
+
+// Soft limit names reported in LimitWarning events (Details["limit"]).
+const (
+	LimitTables      = "tables"       // Tables vs DatabaseConfig.MaxTables
+	LimitHeaderBytes = "header_bytes" // Table header vs the page it is stored in
+	LimitKeySize     = "key_size"     // Key vs ports.EntryLimits.MaxKey
+	LimitValueSize   = "value_size"   // Value vs ports.EntryLimits.MaxValue
+	LimitEntrySize   = "entry_size"   // Key and value vs ports.EntryLimits.MaxEntry
+)
+
+// defaultSoftLimitRatio is the fraction of a hard limit at which warnings start.
+const defaultSoftLimitRatio = 0.8
+
+// SoftLimits sets the fraction (0-1) of each hard limit at which a warning is emitted.
+// Zero uses 0.8; a negative value disables the warning.
+type SoftLimits struct {
+	Tables float64 // Fraction of MaxTables
+	Header float64 // Fraction of the page holding the table header
+	Entry  float64 // Fraction of the storage's key, value and entry size limits
+}
+
+func (l SoftLimits) ratio(name string) float64 {
+	var r float64
+	switch name {
+	case LimitTables:
+		r = l.Tables
+	case LimitHeaderBytes:
+		r = l.Header
+	default:
+		r = l.Entry
+	}
+	if r == 0 {
+		return defaultSoftLimitRatio
+	}
+	return r
+}
+
+// softLimitState tracks which soft limits are currently crossed. Guarded by db.mu.
+type softLimitState struct {
+	crossed  map[string]bool
+	warnings int64
+}
+
+// checkSoftLimit warns once when used reaches the soft threshold of limit. Level limits
+// re-arm when usage drops below the threshold again. Caller holds db.mu.
+func (db *Database) checkSoftLimit(name, table string, used, limit int, rearm bool) {
+	ratio := db.config.SoftLimits.ratio(name)
+	if limit <= 0 || ratio < 0 {
+		return
+	}
+	id := name + "/" + table
+	if float64(used) < ratio*float64(limit) {
+		if rearm {
+			delete(db.limits.crossed, id)
+		}
+		return
+	}
+	if db.limits.crossed[id] {
+		return
+	}
+	db.limits.crossed[id] = true
+	db.limits.warnings++
+
+	where := "database " + db.config.Name
+	if table != "" {
+		where = "table " + table
+	}
+	db.logger.Warn("Soft limit reached", "limit", name, "where", where, "used", used, "max", limit, "percent", int(100*float64(used)/float64(limit)))
+	db.events.Publish(events.Event{
+		Type:   events.LimitWarning,
+		Source: db.config.Name,
+		Table:  table,
+		Details: map[string]interface{}{
+			"limit": name,
+			"used":  used,
+			"max":   limit,
+			"ratio": ratio,
+		},
+	})
+}
+
+// checkEntryLimits warns when an entry approaches the storage's size limits. Caller holds db.mu.
+func (db *Database) checkEntryLimits(table, key, stored string) {
+	l, ok := db.storage.(ports.EntryLimiter)
+	if !ok {
+		return
+	}
+	limits := l.EntryLimits()
+	_, prefix := db.tableStorage(table)
+	db.checkSoftLimit(LimitKeySize, table, len(prefix)+len(key), limits.MaxKey, false)
+	db.checkSoftLimit(LimitValueSize, table, len(stored), limits.MaxValue, false)
+	db.checkSoftLimit(LimitEntrySize, table, len(prefix)+len(key)+len(stored), limits.MaxEntry, false)
+}
@@ -0,0 +1,312 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 유지보수 스케줄러: DatabaseConfig.Maintenance에 작업(vacuum, backup, verify, rekey, purge)을 적어 두면
+// 데이터베이스가 연 시점부터 백그라운드 고루틴 하나로 각 작업을 Every마다 실행합니다. 실행 시각마다 0~Jitter의 무작위
+// 지연을 더해 여러 인스턴스의 작업이 같은 시각에 몰리지 않게 합니다. 작업은 한 번에 하나씩 실행되며,
+// 결과(실행 횟수, 마지막 실행 시각·소요 시간·오류, 다음 실행 시각)는 GetStatus의 Maintenance에
+// 남고 로그로도 기록됩니다. 외부 cron 없이 임베드한 애플리케이션이 정기 점검을 맡길 수 있습니다.
+//
+//   - vacuum: Compact로 저장소를 컴팩션합니다 (ports.ContextCompactor를 구현하는 어댑터만).
+//     CompactionTimeout이 있으면 그 시간 안에 끝나야 합니다.
+//   - backup: Snapshot으로 Target 경로에 복사본을 만듭니다. Target의 "{time}"은 실행 시각
+//     (UTC, 20060102-150405)으로 바뀌므로 매번 새 파일을 남길 수 있습니다.
+//   - verify: Verify로 모든 테이블을 끝까지 읽어 페이지 체크섬, 복호화, 키 카운터를 확인합니다.
+//   - rekey: RotateStorageKey로 KeyProvider가 새 키를 내주면 저장소를 그 키로 다시 암호화합니다.
+//   - purge: PurgeDeleted로 유예 기간이 지난 소프트 삭제 키를 지웁니다.
+//
+// 예약 실행이 아닌 즉시 실행은 RunMaintenance로 합니다. Close는 진행 중인 작업을 취소하고
+// (vacuum/verify는 다음 단계에서 멈춤) 스케줄러가 끝나기를 기다립니다.
+
+// MaintenanceKind is the job a MaintenanceTask runs.
+type MaintenanceKind string
+
+const (
+	MaintenanceVacuum MaintenanceKind = "vacuum" // Compact the storage (see Database.Compact)
+	MaintenanceBackup MaintenanceKind = "backup" // Snapshot the database to Target
+	MaintenanceVerify MaintenanceKind = "verify" // Read every table back (see Database.Verify)
+	MaintenanceRekey  MaintenanceKind = "rekey"  // Rotate to the key provider's key (see Database.RotateStorageKey)
+	MaintenancePurge  MaintenanceKind = "purge"  // Drop expired soft-deleted keys (see Database.PurgeDeleted)
+)
+
+// MaintenanceTask is a routine upkeep job run by the database's scheduler.
+type MaintenanceTask struct {
+	Name   string          // Unique name, shown in the status (the kind if empty)
+	Kind   MaintenanceKind // Job to run
+	Every  time.Duration   // Interval between runs
+	Jitter time.Duration   // Upper bound of a random delay added to each run (0 = none)
+	Target string          // Backup path; "{time}" is replaced with the run time
+}
+
+// MaintenanceStatus reports the runs of a MaintenanceTask.
+type MaintenanceStatus struct {
+	Name         string
+	Kind         MaintenanceKind
+	Runs         int64
+	LastRun      time.Time // Zero before the first run
+	LastDuration time.Duration
+	LastError    string // Error of the last run ("" if it succeeded)
+	NextRun      time.Time
+}
+
+// VerifyReport is the result of Verify.
+type VerifyReport struct {
+	Tables   int      // Tables read
+	Keys     int64    // Entries read
+	Problems []string // Tables that could not be read back or whose key counter is off
+}
+
+// maintenanceTimeLayout formats the run time replacing "{time}" in a backup target.
+const maintenanceTimeLayout = "20060102-150405"
+
+// validateMaintenance checks tasks and fills in the default names.
+func validateMaintenance(tasks []MaintenanceTask) ([]MaintenanceTask, error) {
+	tasks = append([]MaintenanceTask(nil), tasks...)
+	seen := make(map[string]bool)
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Name == "" {
+			t.Name = string(t.Kind)
+		}
+		switch t.Kind {
+		case MaintenanceVacuum, MaintenanceVerify, MaintenanceRekey, MaintenancePurge:
+		case MaintenanceBackup:
+			if t.Target == "" {
+				return nil, fmt.Errorf("maintenance task %s: backup requires a target", t.Name)
+			}
+		default:
+			return nil, fmt.Errorf("maintenance task %s: unknown kind %q (want vacuum, backup, verify, rekey or purge)", t.Name, t.Kind)
+		}
+		if t.Every <= 0 || t.Jitter < 0 {
+			return nil, fmt.Errorf("maintenance task %s: every must be > 0 and jitter >= 0", t.Name)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate maintenance task %s", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return tasks, nil
+}
+
+// maintenance schedules the maintenance tasks of a database. A nil scheduler has no tasks.
+type maintenance struct {
+	db     *Database
+	tasks  []MaintenanceTask
+	run    sync.Mutex // One task at a time
+	mu     sync.Mutex // Guards status
+	status []MaintenanceStatus
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// startMaintenance starts the scheduler of tasks, or returns nil if there are none.
+func startMaintenance(db *Database, tasks []MaintenanceTask) *maintenance {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &maintenance{db: db, tasks: tasks, cancel: cancel, done: make(chan struct{})}
+	now := time.Now()
+	for _, t := range tasks {
+		m.status = append(m.status, MaintenanceStatus{Name: t.Name, Kind: t.Kind, NextRun: nextMaintenanceRun(t, now)})
+	}
+	go m.loop(ctx)
+	return m
+}
+
+func nextMaintenanceRun(t MaintenanceTask, from time.Time) time.Time {
+	next := from.Add(t.Every)
+	if t.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int64N(int64(t.Jitter) + 1)))
+	}
+	return next
+}
+
+// loop runs the task that is due next until the scheduler is stopped.
+func (m *maintenance) loop(ctx context.Context) {
+	defer close(m.done)
+	for {
+		i, next := m.nextDue()
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		// RunMaintenance가 그사이 작업을 다시 예약했을 수 있음
+		if i, next = m.nextDue(); time.Now().Before(next) {
+			continue
+		}
+		m.runTask(ctx, i)
+	}
+}
+
+// nextDue returns the task with the earliest next run.
+func (m *maintenance) nextDue() (int, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := 0
+	for i, s := range m.status {
+		if s.NextRun.Before(m.status[due].NextRun) {
+			due = i
+		}
+	}
+	return due, m.status[due].NextRun
+}
+
+// runTask runs task i, records the run and schedules the next one.
+func (m *maintenance) runTask(ctx context.Context, i int) error {
+	m.run.Lock()
+	defer m.run.Unlock()
+	t := m.tasks[i]
+	start := time.Now()
+	err := m.db.runMaintenance(ctx, t, start)
+	elapsed := time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		return err // 종료 중 취소: 다음 실행에 맡김
+	}
+
+	m.mu.Lock()
+	s := &m.status[i]
+	s.Runs++
+	s.LastRun, s.LastDuration, s.LastError = start, elapsed, ""
+	if err != nil {
+		s.LastError = err.Error()
+	}
+	s.NextRun = nextMaintenanceRun(t, start)
+	m.mu.Unlock()
+
+	if err != nil {
+		m.db.logger.Error("Maintenance task failed", "task", t.Name, "kind", string(t.Kind), "duration", elapsed, "error", err)
+	} else {
+		m.db.logger.Info("Maintenance task finished", "task", t.Name, "kind", string(t.Kind), "duration", elapsed)
+	}
+	return err
+}
+
+// statuses returns a copy of the task statuses.
+func (m *maintenance) statuses() []MaintenanceStatus {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MaintenanceStatus(nil), m.status...)
+}
+
+// stop cancels the running task and waits for the scheduler to end.
+func (m *maintenance) stop() {
+	if m == nil {
+		return
+	}
+	m.once.Do(func() {
+		m.cancel()
+		<-m.done
+	})
+}
+
+// runMaintenance runs the job of t started at start.
+func (db *Database) runMaintenance(ctx context.Context, t MaintenanceTask, start time.Time) error {
+	switch t.Kind {
+	case MaintenanceVacuum:
+		ctx, cancel := db.WithTimeout(ctx, OperationCompaction, t.Name)
+		defer cancel()
+		return TimeoutErr(ctx, db.Compact(ctx))
+	case MaintenanceBackup:
+		return db.Snapshot(strings.ReplaceAll(t.Target, "{time}", start.UTC().Format(maintenanceTimeLayout)))
+	case MaintenanceVerify:
+		report, err := db.Verify(ctx)
+		if err != nil {
+			return err
+		}
+		if len(report.Problems) > 0 {
+			return fmt.Errorf("verify found %d problems: %s", len(report.Problems), strings.Join(report.Problems, "; "))
+		}
+		return nil
+	case MaintenanceRekey:
+		return db.RotateStorageKey(ctx)
+	case MaintenancePurge:
+		_, err := db.PurgeDeleted(ctx)
+		return err
+	}
+	return fmt.Errorf("unknown maintenance kind %q", t.Kind)
+}
+
+// RunMaintenance runs the maintenance task named name now, records the run in the status and
+// reschedules the task from now.
+func (db *Database) RunMaintenance(ctx context.Context, name string) error {
+	if db.maint != nil {
+		for i, t := range db.maint.tasks {
+			if t.Name == name {
+				return db.maint.runTask(ctx, i)
+			}
+		}
+	}
+	return fmt.Errorf("maintenance task %s not found", name)
+}
+
+// Verify reads every entry of every table back from storage, which checks the page checksums
+// and decrypts encrypted values, and compares the entries with the table's key counter when
+// the counters are exact. Each table is read under its own read lock. Problems are reported
+// table by table; Verify itself fails only if ctx ends or the storage can't scan.
+func (db *Database) Verify(ctx context.Context) (VerifyReport, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+	}
+	names := db.sortedTableNames()
+	if db.config.ThreadSafe {
+		db.mu.RUnlock()
+	}
+	var report VerifyReport
+	for _, name := range names {
+		keys, err := db.verifyTable(ctx, name)
+		switch {
+		case errors.Is(err, errTableGone):
+			continue // 확인 도중 삭제된 테이블
+		case ctx.Err() != nil:
+			return report, ctx.Err()
+		case errors.Is(err, ErrScanUnsupported):
+			return report, err
+		case err != nil:
+			report.Problems = append(report.Problems, fmt.Sprintf("table %s: %v", name, err))
+		}
+		report.Tables++
+		report.Keys += keys
+	}
+	return report, nil
+}
+
+var errTableGone = errors.New("table dropped")
+
+// verifyTable reads the entries of tableName back and checks its key counter.
+func (db *Database) verifyTable(ctx context.Context, tableName string) (int64, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return 0, errTableGone
+	}
+	var keys int64
+	if err := db.scanCost(ctx, tableName, "", func(_, _ string) bool {
+		keys++
+		return true
+	}, nil); err != nil {
+		return keys, err
+	}
+	if s, ok := db.keys[tableName]; ok && db.keysExact && s.count != keys {
+		return keys, fmt.Errorf("key counter is %d but %d entries were read", s.count, keys)
+	}
+	return keys, nil
+}
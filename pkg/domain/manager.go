@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// 다중 데이터베이스: Manager는 한 프로세스에서 이름이 다른 여러 Database(서로 다른 파일)를 열고,
+// 이름으로 찾고, 닫습니다. 테넌트마다 데이터베이스 파일을 따로 두는 임베더를 위한 것으로, 같은 파일을
+// 두 이름으로 열 수는 없습니다. 요청은 WithDatabase로 컨텍스트에 데이터베이스 이름을 붙여
+// application.ManagerHandler로 보냅니다. Manager를 닫으면 열린 데이터베이스를 모두 닫고, 이후의
+// Open은 실패합니다.
+
+var (
+	// ErrDatabaseNotFound is returned when no open database has the requested name.
+	ErrDatabaseNotFound = errors.New("database not found")
+	// ErrDatabaseExists is returned when opening a database under a name or file already open.
+	ErrDatabaseExists = errors.New("database already open")
+)
+
+// Manager opens and routes to several named databases.
+type Manager struct {
+	mu     sync.RWMutex
+	dbs    map[string]*Database
+	logger utils.Logger
+	closed bool
+}
+
+// NewManager creates a manager without databases.
+func NewManager(logger utils.Logger) *Manager {
+	return &Manager{dbs: make(map[string]*Database), logger: logger}
+}
+
+// Open opens the database of config with the default B-tree storage (see NewDatabase) under
+// config.Name.
+func (m *Manager) Open(config DatabaseConfig) (*Database, error) {
+	return m.open(config, func() (*Database, error) { return NewDatabase(config, m.logger) })
+}
+
+// Add puts a database opened by the caller (e.g. with NewDatabaseWithStorage) under its name.
+// The manager closes it from then on.
+func (m *Manager) Add(db *Database) error {
+	_, err := m.open(db.config, func() (*Database, error) { return db, nil })
+	return err
+}
+
+// open checks config against the open databases and registers the database opened by fn.
+func (m *Manager) open(config DatabaseConfig, fn func() (*Database, error)) (*Database, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("database name is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, fmt.Errorf("database manager is closed")
+	}
+	if _, ok := m.dbs[config.Name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseExists, config.Name)
+	}
+	path := cleanPath(config.FilePath)
+	for name, db := range m.dbs {
+		if cleanPath(db.config.FilePath) == path {
+			return nil, fmt.Errorf("%w: file %s is open as %s", ErrDatabaseExists, config.FilePath, name)
+		}
+	}
+	db, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	m.dbs[config.Name] = db
+	m.logger.Info("Database opened in manager", "database", config.Name, "path", config.FilePath)
+	return db, nil
+}
+
+func cleanPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// Get returns the open database called name.
+func (m *Manager) Get(name string) (*Database, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	db, ok := m.dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseNotFound, name)
+	}
+	return db, nil
+}
+
+// List returns the names of the open databases, sorted.
+func (m *Manager) List() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.dbs))
+	for name := range m.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes the database called name and removes it from the manager, even if closing
+// it fails.
+func (m *Manager) Close(name string) error {
+	m.mu.Lock()
+	db, ok := m.dbs[name]
+	delete(m.dbs, name)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrDatabaseNotFound, name)
+	}
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("failed to close database %s: %v", name, err)
+	}
+	return nil
+}
+
+// CloseAll closes every database and the manager. It returns the first error after trying
+// all of them.
+func (m *Manager) CloseAll() error {
+	m.mu.Lock()
+	dbs := m.dbs
+	m.dbs = make(map[string]*Database)
+	m.closed = true
+	m.mu.Unlock()
+	var first error
+	for name, db := range dbs {
+		if err := db.Close(); err != nil && first == nil {
+			first = fmt.Errorf("failed to close database %s: %v", name, err)
+		}
+	}
+	return first
+}
+
+type databaseKey struct{}
+
+// WithDatabase returns a context whose requests are routed to the database called name.
+func WithDatabase(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, databaseKey{}, name)
+}
+
+// DatabaseFrom returns the database name of ctx, or "" if it has none.
+func DatabaseFrom(ctx context.Context) string {
+	name, _ := ctx.Value(databaseKey{}).(string)
+	return name
+}
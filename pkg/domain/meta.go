@@ -0,0 +1,238 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// TableOptions.Metadata로 만든 테이블은 항목마다 생성/수정 시각과 사용자 플래그 1바이트를 값 앞에
+// 함께 저장합니다 (버전 1바이트 + 플래그 1바이트 + varint 시각 두 개, 보통 12바이트 내외).
+// Get/Scan은 값만 돌려주고, GetWithMeta가 메타데이터를 함께 반환합니다.
+// 테이블 옵션은 page 1 헤더의 "META" 섹션에 기록됩니다.
+
+// tableMetaMagic marks the table options section that follows the table roots in page 1.
+const tableMetaMagic uint32 = 0x4154454D // "META"
+
+// entryMetaVersion is the first byte of every entry stored in a metadata table.
+const entryMetaVersion byte = 1
+
+// TableOptions configures a table at creation time.
+type TableOptions struct {
+	// Metadata keeps EntryMeta (timestamps and user flags) with every entry of the table.
+	Metadata bool
+	// Encrypted encrypts the values of the table with its own data key, wrapped by
+	// DatabaseConfig.MasterKey (see encryption.go).
+	Encrypted bool
+	// SoftDelete keeps deleted keys for this grace period so that Undelete can restore
+	// them (0 = deletes are final, see softdelete.go). It can be changed with SetSoftDelete.
+	SoftDelete time.Duration
+}
+
+// EntryMeta is the metadata kept with an entry of a table created with TableOptions.Metadata.
+type EntryMeta struct {
+	Created time.Time // First insert of the key
+	Updated time.Time // Last insert of the key
+	Flags   byte      // Application-defined flags
+}
+
+// encodeEntry prefixes value with m.
+func encodeEntry(m EntryMeta, value string) string {
+	created := m.Created.UnixNano()
+	updated := m.Updated.UnixNano() - created
+	buf := make([]byte, 0, 2+2*binary.MaxVarintLen64+len(value))
+	buf = append(buf, entryMetaVersion, m.Flags)
+	buf = binary.AppendVarint(buf, created)
+	buf = binary.AppendVarint(buf, updated) // 수정 시각은 생성 시각과의 차이로 저장
+	return string(append(buf, value...))
+}
+
+// decodeEntry splits a stored entry into its metadata and value.
+func decodeEntry(raw string) (EntryMeta, string, error) {
+	if len(raw) < 2 || raw[0] != entryMetaVersion {
+		return EntryMeta{}, "", fmt.Errorf("invalid entry metadata")
+	}
+	r := bytes.NewReader([]byte(raw[2:]))
+	created, err := binary.ReadVarint(r)
+	if err != nil {
+		return EntryMeta{}, "", fmt.Errorf("invalid entry metadata: %v", err)
+	}
+	updated, err := binary.ReadVarint(r)
+	if err != nil {
+		return EntryMeta{}, "", fmt.Errorf("invalid entry metadata: %v", err)
+	}
+	value := raw[len(raw)-r.Len():]
+	return EntryMeta{
+		Created: time.Unix(0, created),
+		Updated: time.Unix(0, created+updated),
+		Flags:   raw[1],
+	}, value, nil
+}
+
+// CreateTableWithOptions creates a table configured by opts.
+func (db *Database) CreateTableWithOptions(name string, opts TableOptions) error {
+	return db.createTable(name, opts)
+}
+
+// TableOptions returns the options a table was created with.
+func (db *Database) TableOptions(tableName string) (TableOptions, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	spec, ok := db.spec.Tables[tableName]
+	if !ok {
+		return TableOptions{}, fmt.Errorf("table %s not found", tableName)
+	}
+	return TableOptions{Metadata: spec.Metadata, Encrypted: spec.Encrypted, SoftDelete: db.trash[tableName]}, nil
+}
+
+// InsertWithFlags inserts a key-value pair into a metadata table and sets its user flags.
+// Insert keeps the flags of an existing entry.
+func (db *Database) InsertWithFlags(tableName, key, value string, flags byte) error {
+	if opts, err := db.TableOptions(tableName); err != nil {
+		return err
+	} else if !opts.Metadata {
+		return fmt.Errorf("table %s was not created with metadata", tableName)
+	}
+	seq, err := db.insert(tableName, key, value, func(old *EntryMeta) EntryMeta {
+		m := db.touchMeta(old)
+		m.Flags = flags
+		return m
+	})
+	if err != nil {
+		return err
+	}
+	return db.awaitAck(context.Background(), seq, WriteAck{})
+}
+
+// entryMetaFunc computes the metadata of an entry being written from its current
+// metadata (nil if the key is new).
+type entryMetaFunc func(old *EntryMeta) EntryMeta
+
+// touchMeta refreshes Updated and keeps Created and Flags of an existing entry.
+func (db *Database) touchMeta(old *EntryMeta) EntryMeta {
+	now := db.now()
+	if old == nil {
+		return EntryMeta{Created: now, Updated: now}
+	}
+	return EntryMeta{Created: old.Created, Updated: now, Flags: old.Flags}
+}
+
+// oldEntryMeta decodes the metadata of the value currently stored. Caller holds db.mu.
+func (db *Database) oldEntryMeta(tableName, key string, stored interface{}) *EntryMeta {
+	s, err := ports.ValueString(stored)
+	if err == nil {
+		s, err = db.decryptValue(tableName, key, s)
+	}
+	if err != nil {
+		return nil
+	}
+	m, _, err := decodeEntry(s)
+	if err != nil {
+		return nil
+	}
+	return &m
+}
+
+// GetWithMeta retrieves a value and its metadata. Tables created without metadata return
+// a zero EntryMeta.
+func (db *Database) GetWithMeta(tableName, key string) (string, EntryMeta, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	spec, ok := db.spec.Tables[tableName]
+	if !ok {
+		return "", EntryMeta{}, fmt.Errorf("table %s not found", tableName)
+	}
+	raw, err := db.getRaw(tableName, key)
+	if err != nil {
+		return "", EntryMeta{}, err
+	}
+	if !spec.Metadata {
+		return raw, EntryMeta{}, nil
+	}
+	m, value, err := decodeEntry(raw)
+	if err != nil {
+		return "", EntryMeta{}, fmt.Errorf("key %s in table %s: %v", key, tableName, err)
+	}
+	return value, m, nil
+}
+
+// String encodes the options for replication of table creation as a comma separated
+// list, e.g. "metadata,encrypted" ("" for defaults).
+func (o TableOptions) String() string {
+	var opts []string
+	if o.Metadata {
+		opts = append(opts, "metadata")
+	}
+	if o.Encrypted {
+		opts = append(opts, "encrypted")
+	}
+	return strings.Join(opts, ",")
+}
+
+// ParseTableOptions decodes TableOptions.String, e.g. the Value of a MutationCreateTable.
+// Unknown options are ignored.
+func ParseTableOptions(s string) TableOptions {
+	var o TableOptions
+	for _, opt := range strings.Split(s, ",") {
+		switch opt {
+		case "metadata":
+			o.Metadata = true
+		case "encrypted":
+			o.Encrypted = true
+		}
+	}
+	return o
+}
+
+// now returns the timestamp recorded for writes; monotonic readings are stripped so that
+// stored and returned times compare equal.
+func (db *Database) now() time.Time {
+	return time.Now().Round(0)
+}
+
+// encodeTableMeta appends the table options section if any table uses an option, so headers
+// of databases without options are unchanged. Caller holds db.mu.
+func (db *Database) encodeTableMeta(buf *bytes.Buffer, names []string) {
+	used := false
+	for _, name := range names {
+		used = used || db.spec.Tables[name].Metadata
+	}
+	if !used {
+		return
+	}
+	binary.Write(buf, byteOrder, tableMetaMagic)
+	for _, name := range names {
+		var opts byte
+		if db.spec.Tables[name].Metadata {
+			opts |= 1
+		}
+		buf.WriteByte(opts)
+	}
+}
+
+// decodeTableMeta reads the table options section if present; r is left untouched otherwise.
+func (db *Database) decodeTableMeta(r *bytes.Reader, names []string) {
+	start, _ := r.Seek(0, io.SeekCurrent)
+	var magic uint32
+	if err := binary.Read(r, byteOrder, &magic); err != nil || magic != tableMetaMagic {
+		r.Seek(start, io.SeekStart)
+		return
+	}
+	for _, name := range names {
+		opts, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		db.spec.Tables[name].Metadata = opts&1 != 0
+	}
+}
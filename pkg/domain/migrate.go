@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/events"
+)
+
+// 마이그레이션: 애플리케이션은 DatabaseConfig.Migrations에 버전 순서가 있는 마이그레이션 함수를
+// 등록하고, 데이터베이스를 열 때 아직 적용되지 않은 것만 버전 순으로 한 번씩 실행됩니다.
+// 적용 기록은 시스템 테이블 MigrationsTable에 버전별로 남습니다 (키: 0으로 채운 버전, 값: JSON).
+//
+// 마이그레이션은 기록 전에 실행되므로, 실행 직후 기록 전에 프로세스가 죽으면 다음 오픈에서 다시
+// 실행됩니다. 마이그레이션은 다시 실행되어도 안전하게 작성하는 것이 좋습니다 (예: 테이블이 이미
+// 있으면 건너뛰기). 실패한 마이그레이션이 있으면 이후 버전은 실행되지 않고 오픈이 실패합니다.
+
+// MigrationsTable is the system table recording applied migrations.
+const MigrationsTable = "__migrations"
+
+// Migration is one ordered change to the schema or data of a database.
+type Migration struct {
+	Version int    // Unique, positive; migrations run in ascending order
+	Name    string // Human-readable description
+	Up      func(db *Database) error
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time // Zero if not applied
+	// Registered is false for versions recorded in the database but missing from
+	// DatabaseConfig.Migrations, e.g. after a downgrade of the application.
+	Registered bool
+}
+
+// migrationRecord is the value stored in MigrationsTable.
+type migrationRecord struct {
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func migrationKey(version int) string {
+	return fmt.Sprintf("%020d", version)
+}
+
+// sortedMigrations validates and sorts the registered migrations.
+func sortedMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q: version must be positive", m.Name)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) has no Up function", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+	return sorted, nil
+}
+
+// MigrationStatus lists the registered migrations and any recorded ones, by version.
+func (db *Database) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := sortedMigrations(db.config.Migrations)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var status []MigrationStatus
+	registered := make(map[int]bool)
+	for _, m := range migrations {
+		rec, ok := applied[m.Version]
+		status = append(status, MigrationStatus{Version: m.Version, Name: m.Name, Applied: ok, AppliedAt: rec.AppliedAt, Registered: true})
+		registered[m.Version] = true
+	}
+	for version, rec := range applied {
+		if !registered[version] {
+			status = append(status, MigrationStatus{Version: version, Name: rec.Name, Applied: true, AppliedAt: rec.AppliedAt})
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+// Migrate applies the pending migrations of DatabaseConfig.Migrations in version order and
+// returns those it applied. With dryRun it only returns the pending migrations.
+// NewDatabaseWithStorage calls it with DatabaseConfig.MigrationDryRun.
+func (db *Database) Migrate(dryRun bool) ([]MigrationStatus, error) {
+	migrations, err := sortedMigrations(db.config.Migrations)
+	if err != nil || len(migrations) == 0 {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var done []MigrationStatus
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if dryRun {
+			db.logger.Info("Pending migration", "version", m.Version, "name", m.Name)
+			done = append(done, MigrationStatus{Version: m.Version, Name: m.Name, Registered: true})
+			continue
+		}
+		if err := db.ensureMigrationsTable(); err != nil {
+			return done, err
+		}
+		db.logger.Info("Applying migration", "version", m.Version, "name", m.Name)
+		if err := m.Up(db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		rec := migrationRecord{Name: m.Name, AppliedAt: db.now()}
+		data, _ := json.Marshal(rec)
+		if err := db.Insert(MigrationsTable, migrationKey(m.Version), string(data)); err != nil {
+			return done, fmt.Errorf("failed to record migration %d: %v", m.Version, err)
+		}
+		done = append(done, MigrationStatus{Version: m.Version, Name: m.Name, Applied: true, AppliedAt: rec.AppliedAt, Registered: true})
+		db.events.Publish(events.Event{
+			Type:    events.MigrationApplied,
+			Source:  db.config.Name,
+			Details: map[string]interface{}{"version": m.Version, "name": m.Name},
+		})
+	}
+	return done, nil
+}
+
+// appliedMigrations reads MigrationsTable; it is empty if the table does not exist yet.
+func (db *Database) appliedMigrations() (map[int]migrationRecord, error) {
+	applied := make(map[int]migrationRecord)
+	if !db.hasTable(MigrationsTable) {
+		return applied, nil
+	}
+	var decodeErr error
+	err := db.Scan(MigrationsTable, "", func(key, value string) bool {
+		var version int
+		var rec migrationRecord
+		if _, err := fmt.Sscanf(key, "%d", &version); err != nil {
+			decodeErr = fmt.Errorf("invalid migration record %q", key)
+			return false
+		}
+		if err := json.Unmarshal([]byte(value), &rec); err != nil {
+			decodeErr = fmt.Errorf("invalid migration record %d: %v", version, err)
+			return false
+		}
+		applied[version] = rec
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", MigrationsTable, err)
+	}
+	return applied, decodeErr
+}
+
+func (db *Database) ensureMigrationsTable() error {
+	if db.hasTable(MigrationsTable) {
+		return nil
+	}
+	return db.CreateTable(MigrationsTable)
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// MutationOp identifies the kind of committed change.
+type MutationOp uint8
+
+const (
+	MutationInsert MutationOp = iota + 1
+	MutationDelete
+	MutationCreateTable
+	MutationDropTable
+)
+
+// String returns a human-readable name for the mutation op.
+func (op MutationOp) String() string {
+	switch op {
+	case MutationInsert:
+		return "insert"
+	case MutationDelete:
+		return "delete"
+	case MutationCreateTable:
+		return "create_table"
+	case MutationDropTable:
+		return "drop_table"
+	default:
+		return fmt.Sprintf("MutationOp(%d)", uint8(op))
+	}
+}
+
+// Mutation is a committed change with its database-wide sequence number.
+// Mutations are delivered to listeners in sequence order.
+type Mutation struct {
+	Seq   uint64
+	Op    MutationOp
+	Table string
+	Key   string
+	Value string     // Inserted value; encoded TableOptions for MutationCreateTable
+	Meta  *EntryMeta // Entry metadata of inserts into metadata tables (nil otherwise)
+}
+
+// MutationListener is called synchronously, under the database write lock, after each
+// committed change. Implementations must not block or call back into the Database.
+type MutationListener func(Mutation)
+
+type mutationListeners struct {
+	mu     sync.RWMutex
+	nextID int
+	fns    map[int]MutationListener
+}
+
+// AddMutationListener registers fn for committed changes and returns a function that removes it.
+func (db *Database) AddMutationListener(fn MutationListener) (remove func()) {
+	l := &db.listeners
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fns == nil {
+		l.fns = make(map[int]MutationListener)
+	}
+	l.nextID++
+	id := l.nextID
+	l.fns[id] = fn
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.fns, id)
+	}
+}
+
+// commit assigns the next sequence number to m and notifies listeners. Caller holds db.mu.
+func (db *Database) commit(m Mutation) uint64 {
+	db.seq++
+	m.Seq = db.seq
+	db.listeners.mu.RLock()
+	for _, fn := range db.listeners.fns {
+		fn(m)
+	}
+	db.listeners.mu.RUnlock()
+	if db.feed != nil {
+		db.recordChange(m)
+	}
+	if db.versions != nil {
+		db.recordVersion(m)
+	}
+	return m.Seq
+}
+
+// Seq returns the sequence number of the last committed change.
+func (db *Database) Seq() uint64 {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	return db.seq
+}
+
+// ApplyMutation applies a change received from another node (e.g. a replication primary)
+// and adopts its sequence number. Table creation is idempotent so replays are safe.
+func (db *Database) ApplyMutation(m Mutation) error {
+	switch m.Op {
+	case MutationInsert:
+		var metaFn entryMetaFunc
+		if m.Meta != nil {
+			meta := *m.Meta // 원본 노드의 시각과 플래그를 그대로 사용
+			metaFn = func(*EntryMeta) EntryMeta { return meta }
+		}
+		_, err := db.insert(m.Table, m.Key, m.Value, metaFn)
+		if err != nil {
+			return err
+		}
+	case MutationDelete:
+		_, err := db.delete(m.Table, m.Key)
+		if err != nil && !errors.Is(err, ports.ErrKeyNotFound) {
+			return err
+		}
+	case MutationCreateTable:
+		if err := db.createTable(m.Table, ParseTableOptions(m.Value)); err != nil && !db.hasTable(m.Table) {
+			return err
+		}
+	case MutationDropTable:
+		if err := db.DropTable(m.Table); err != nil && db.hasTable(m.Table) {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown mutation op: %v", m.Op)
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if m.Seq > db.seq {
+		db.seq = m.Seq
+	}
+	return nil
+}
+
+// hasTable reports whether the table exists.
+func (db *Database) hasTable(name string) bool {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	_, ok := db.spec.Tables[name]
+	return ok
+}
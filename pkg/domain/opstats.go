@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"runtime/metrics"
+	"sync"
+)
+
+// 연산별 계측: DatabaseConfig.OpStats를 켜거나 golite_opstats 빌드 태그로 빌드하면 공개 연산
+// (Get, Scan, Insert, Delete, Write, 조건부 쓰기)마다 호출 수, 힙 할당 바이트/객체 수, 읽고 쓴
+// 키+값 바이트를 누적해 Stats의 "ops"로 보여 줍니다. 외부 pprof 없이 할당이 많은 연산을 찾는
+// 용도입니다.
+//
+// 할당량은 연산 전후의 runtime/metrics 힙 할당 누계 차이이므로 (세계를 멈추지 않음) 같은 시각에
+// 다른 고루틴이 한 할당도 섞입니다. 정확한 연산당 값은 단일 고루틴 벤치마크에서 읽어야 하며, 동시
+// 부하에서는 연산 간 비교용 근사치로 봅니다. 꺼져 있으면 계측 비용은 nil 검사뿐입니다.
+//
+//	go test -tags golite_opstats ./...
+
+// OpStats is the accounting of one kind of operation.
+type OpStats struct {
+	Count        int64  `json:"count"`
+	AllocBytes   uint64 `json:"alloc_bytes"`   // Heap bytes allocated during the operations
+	AllocObjects uint64 `json:"alloc_objects"` // Heap objects allocated during the operations
+	BytesRead    int64  `json:"bytes_read"`    // Value bytes read (and keys, for scans)
+	BytesWritten int64  `json:"bytes_written"` // Key and value bytes written
+}
+
+// AllocBytesPerOp returns the average heap bytes allocated per operation.
+func (s OpStats) AllocBytesPerOp() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.AllocBytes) / float64(s.Count)
+}
+
+type opKind int
+
+const (
+	opGet opKind = iota
+	opScan
+	opInsert
+	opDelete
+	opBatch
+	opUpdate // CompareAndSwap, InsertIfAbsent, Increment
+	numOpKinds
+)
+
+var opKindNames = [numOpKinds]string{"get", "scan", "insert", "delete", "write_batch", "update"}
+
+// opProfile accumulates OpStats by operation kind. A nil profile records nothing.
+type opProfile struct {
+	mu  sync.Mutex
+	ops [numOpKinds]OpStats
+}
+
+// opSample is an operation in progress. The operation adds its key and value bytes.
+type opSample struct {
+	kind    opKind
+	bytes   uint64
+	objects uint64
+	read    int
+	written int
+}
+
+var allocMetrics = []string{"/gc/heap/allocs:bytes", "/gc/heap/allocs:objects"}
+
+func newOpProfile(enabled bool) *opProfile {
+	if !enabled && !opStatsBuildTag {
+		return nil
+	}
+	return &opProfile{}
+}
+
+// readAllocs returns the heap bytes and objects allocated by the process so far.
+func readAllocs() (bytes, objects uint64) {
+	var samples [2]metrics.Sample
+	samples[0].Name, samples[1].Name = allocMetrics[0], allocMetrics[1]
+	metrics.Read(samples[:])
+	return samples[0].Value.Uint64(), samples[1].Value.Uint64()
+}
+
+// begin starts measuring an operation.
+func (p *opProfile) begin(kind opKind) opSample {
+	if p == nil {
+		return opSample{}
+	}
+	s := opSample{kind: kind}
+	s.bytes, s.objects = readAllocs()
+	return s
+}
+
+// end adds the measured operation to the profile.
+func (p *opProfile) end(s *opSample) {
+	if p == nil {
+		return
+	}
+	bytes, objects := readAllocs()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	op := &p.ops[s.kind]
+	op.Count++
+	op.AllocBytes += bytes - s.bytes
+	op.AllocObjects += objects - s.objects
+	op.BytesRead += int64(s.read)
+	op.BytesWritten += int64(s.written)
+}
+
+// stats returns the accounting of the operations that ran at least once.
+func (p *opProfile) stats() map[string]OpStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make(map[string]OpStats)
+	for kind, op := range p.ops {
+		if op.Count > 0 {
+			stats[opKindNames[kind]] = op
+		}
+	}
+	return stats
+}
@@ -0,0 +1,6 @@
+//go:build !golite_opstats
+
+package domain
+
+// opStatsBuildTag enables per-operation accounting regardless of DatabaseConfig.OpStats.
+const opStatsBuildTag = false
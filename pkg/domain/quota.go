@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 저장소 쿼터: DatabaseConfig.MaxDatabaseBytes와 MaxKeysPerTable은 삽입 시점에 검사하는 하드 리밋입니다.
+//
+//   - MaxDatabaseBytes: 저장소의 디스크 크기(ports.SizeReporter, 없으면 데이터베이스 파일 크기)가
+//     한도에 이르면 사용자 테이블의 모든 삽입(기존 키 덮어쓰기 포함)이 실패합니다. 삭제는 계속 되지만
+//     B-tree는 페이지를 돌려주지 않으므로 크기를 줄이려면 vacuum이나 compaction이 필요합니다.
+//   - MaxKeysPerTable: 테이블의 살아 있는 키 수(keycount.go)가 한도에 이르면 새 키의 삽입이 실패하고,
+//     기존 키의 갱신은 그대로 됩니다. 카운터가 정확하지 않으면(KeyStats.Exact가 false, 예: 비정상
+//     종료 뒤나 페이지 헤더가 없는 저장소) 그 카운터로 검사하므로 실제 키 수보다 느슨할 수 있습니다.
+//
+// 시스템 테이블("__"로 시작)과 검색 색인, 카운트 뷰 같은 보조 테이블은 검사하지 않으므로 헬스 체크와
+// 마이그레이션 기록, 이미 받아들인 쓰기의 색인 갱신은 한도에 걸려도 실패하지 않습니다. 실패한 삽입은
+// *QuotaError를 반환하며, 현재 사용량은 DatabaseStatus.Quota에 있습니다.
+
+var (
+	// ErrDatabaseFull matches a *QuotaError for MaxDatabaseBytes with errors.Is.
+	ErrDatabaseFull = errors.New("database size quota exceeded")
+	// ErrTableFull matches a *QuotaError for MaxKeysPerTable with errors.Is.
+	ErrTableFull = errors.New("table key quota exceeded")
+)
+
+// Quota names reported in a QuotaError.
+const (
+	QuotaDatabaseBytes = "database_bytes" // Storage size vs DatabaseConfig.MaxDatabaseBytes
+	QuotaTableKeys     = "table_keys"     // Live keys of a table vs DatabaseConfig.MaxKeysPerTable
+)
+
+// QuotaError is returned when an insert would exceed a storage quota.
+type QuotaError struct {
+	Quota string // QuotaDatabaseBytes or QuotaTableKeys
+	Table string // Table of the insert
+	Used  int64  // Bytes or keys in use
+	Limit int64  // Configured limit
+}
+
+func (e *QuotaError) Error() string {
+	if e.Quota == QuotaTableKeys {
+		return fmt.Sprintf("table %s has %d of %d keys", e.Table, e.Used, e.Limit)
+	}
+	return fmt.Sprintf("database uses %d of %d bytes", e.Used, e.Limit)
+}
+
+// Is reports whether target is ErrDatabaseFull or ErrTableFull, matching the quota.
+func (e *QuotaError) Is(target error) bool {
+	switch e.Quota {
+	case QuotaDatabaseBytes:
+		return target == ErrDatabaseFull
+	case QuotaTableKeys:
+		return target == ErrTableFull
+	}
+	return false
+}
+
+// QuotaUsage reports the usage of the storage quotas.
+type QuotaUsage struct {
+	DatabaseBytes    int64            // Current storage size
+	MaxDatabaseBytes int64            // 0 = unlimited
+	TableKeys        map[string]int64 // Live keys of each user table
+	MaxKeysPerTable  int64            // 0 = unlimited
+}
+
+// quotaTable reports whether inserts into tableName are checked against the quotas.
+func quotaTable(tableName string) bool {
+	if strings.HasPrefix(tableName, "__") {
+		return false
+	}
+	return !strings.HasSuffix(tableName, searchIndexSuffix) && !strings.HasSuffix(tableName, countViewSuffix) &&
+		!strings.HasSuffix(tableName, trashSuffix)
+}
+
+// storageBytes returns the disk size of the storage. Caller holds db.mu.
+func (db *Database) storageBytes() int64 {
+	if r, ok := db.storage.(ports.SizeReporter); ok {
+		return r.DiskSize()
+	}
+	if db.file != nil {
+		if fi, err := db.file.Stat(); err == nil {
+			return fi.Size()
+		}
+	}
+	return 0
+}
+
+// checkQuotas fails if inserting into tableName (a new key if isNew) would exceed a storage
+// quota. Caller holds db.mu.
+func (db *Database) checkQuotas(tableName string, isNew bool) error {
+	if !quotaTable(tableName) {
+		return nil
+	}
+	if limit := db.config.MaxKeysPerTable; limit > 0 && isNew {
+		if s, ok := db.keys[tableName]; ok && s.count >= limit {
+			return &QuotaError{Quota: QuotaTableKeys, Table: tableName, Used: s.count, Limit: limit}
+		}
+	}
+	if limit := db.config.MaxDatabaseBytes; limit > 0 {
+		if used := db.storageBytes(); used >= limit {
+			return &QuotaError{Quota: QuotaDatabaseBytes, Table: tableName, Used: used, Limit: limit}
+		}
+	}
+	return nil
+}
+
+// quotaUsage returns the usage of the storage quotas. Caller holds db.mu.
+func (db *Database) quotaUsage() QuotaUsage {
+	usage := QuotaUsage{
+		DatabaseBytes:    db.storageBytes(),
+		MaxDatabaseBytes: db.config.MaxDatabaseBytes,
+		TableKeys:        make(map[string]int64),
+		MaxKeysPerTable:  db.config.MaxKeysPerTable,
+	}
+	for name := range db.spec.Tables {
+		if !quotaTable(name) {
+			continue
+		}
+		if s, ok := db.keys[name]; ok {
+			usage.TableKeys[name] = s.count
+		} else {
+			usage.TableKeys[name] = 0
+		}
+	}
+	return usage
+}
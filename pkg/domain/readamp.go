@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 읽기 증폭: 저장소 어댑터가 ports.ReadCostPort를 구현하면 Get과 Scan 한 번이 거친 구조(B-tree
+// 페이지와 그중 디스크에서 읽은 수, SSTable, 블룸 필터로 건너뛴 SSTable, 블록, 메모리 적중)를 셀
+// 수 있습니다. DatabaseConfig.ReadAmplification을 켜면 이를 읽기 종류별로 누적해 Stats의
+// "read_amplification"으로 보여 주므로, 블룸 필터·캐시·컴팩션 설정을 바꾼 전후의 읽기당 비용을
+// 비교할 수 있습니다. 꺼져 있으면 비용은 nil 검사뿐입니다.
+//
+// ExplainGet/ExplainScan은 설정과 관계없이 읽기 하나를 실제로 수행하고 그 읽기의 비용을 돌려줍니다
+// (누계에는 더하지 않음). 어댑터가 비용을 세지 못하면 Measured가 false이고 비용은 0입니다.
+
+// ReadAmplification is the aggregate cost of one kind of read.
+type ReadAmplification struct {
+	Reads int64          `json:"reads"`
+	Keys  int64          `json:"keys"` // Keys returned
+	Cost  ports.ReadCost `json:"cost"` // Structures touched by all the reads
+}
+
+// PerRead returns total averaged over the reads, e.g. r.PerRead(r.Cost.Pages).
+func (r ReadAmplification) PerRead(total int64) float64 {
+	if r.Reads == 0 {
+		return 0
+	}
+	return float64(total) / float64(r.Reads)
+}
+
+// ReadExplain reports how one read was served.
+type ReadExplain struct {
+	Op       string // "get" or "scan"
+	Table    string
+	Adapter  string // Storage adapter type serving the table
+	Measured bool   // The adapter counts read costs; Cost is zero otherwise
+	Keys     int    // Keys returned
+	Cost     ports.ReadCost
+	Duration time.Duration
+}
+
+type readKind int
+
+const (
+	readGet readKind = iota
+	readScan
+	numReadKinds
+)
+
+var readKindNames = [numReadKinds]string{"get", "scan"}
+
+// readProfile accumulates ReadAmplification by kind of read. A nil profile records nothing.
+type readProfile struct {
+	mu    sync.Mutex
+	reads [numReadKinds]ReadAmplification
+}
+
+func newReadProfile(enabled bool) *readProfile {
+	if !enabled {
+		return nil
+	}
+	return &readProfile{}
+}
+
+// cost returns the counter of a read, or nil if reads are not measured.
+func (p *readProfile) cost() *ports.ReadCost {
+	if p == nil {
+		return nil
+	}
+	return &ports.ReadCost{}
+}
+
+// add accumulates a read that returned keys.
+func (p *readProfile) add(kind readKind, cost *ports.ReadCost, keys int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r := &p.reads[kind]
+	r.Reads++
+	r.Keys += int64(keys)
+	r.Cost.Add(*cost)
+}
+
+// stats returns the reads that ran at least once.
+func (p *readProfile) stats() map[string]ReadAmplification {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make(map[string]ReadAmplification)
+	for kind, r := range p.reads {
+		if r.Reads > 0 {
+			stats[readKindNames[kind]] = r
+		}
+	}
+	return stats
+}
+
+// storageGet reads key, counting the structures touched into cost if it is not nil and the
+// storage reports them.
+func storageGet(storage ports.StoragePort, key string, cost *ports.ReadCost) (interface{}, error) {
+	if rc, ok := storage.(ports.ReadCostPort); ok && cost != nil {
+		return rc.GetCost(key, cost)
+	}
+	return storage.Get(key)
+}
+
+// ExplainGet reads key from tableName and reports the cost of the lookup. A missing key is
+// not an error; the result has no keys.
+func (db *Database) ExplainGet(tableName, key string) (ReadExplain, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return ReadExplain{}, fmt.Errorf("table %s not found", tableName)
+	}
+	ex := db.newExplain("get", tableName)
+	start := time.Now()
+	_, err := db.getCost(tableName, key, &ex.Cost)
+	ex.Duration = time.Since(start)
+	switch {
+	case err == nil:
+		ex.Keys = 1
+	case !errors.Is(err, ports.ErrKeyNotFound):
+		return ex, err
+	}
+	return ex, nil
+}
+
+// ExplainScan scans the keys of tableName starting with prefix and reports the cost of the scan.
+func (db *Database) ExplainScan(tableName, prefix string) (ReadExplain, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	ex := db.newExplain("scan", tableName)
+	start := time.Now()
+	err := db.scanCost(context.Background(), tableName, prefix, func(_, _ string) bool {
+		ex.Keys++
+		return true
+	}, &ex.Cost)
+	ex.Duration = time.Since(start)
+	return ex, err
+}
+
+// newExplain describes the storage serving tableName. Caller holds db.mu.
+func (db *Database) newExplain(op, tableName string) ReadExplain {
+	storage, _ := db.tableStorage(tableName)
+	_, measured := storage.(ports.ReadCostPort)
+	return ReadExplain{Op: op, Table: tableName, Adapter: fmt.Sprintf("%T", storage), Measured: measured}
+}
@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 검색 인덱스: CreateSearchIndex로 등록한 테이블은 값을 토큰으로 나눠 보조 테이블
+// ("<table>$search")에 "token\x00key" 형태의 키로 기록합니다. 인덱스는 Insert/Delete/배치와 같은
+// 잠금 안에서 함께 갱신되며, Search는 보조 테이블의 prefix 스캔으로 해당 토큰(또는 그 접두어)을
+// 포함한 키를 찾습니다. 보조 테이블은 일반 테이블이므로 복제, 캡처, 스냅샷에 그대로 포함됩니다.
+// 따라서 복제본에서는 CreateSearchIndex를 호출하지 않고 복제된 보조 테이블로 Search합니다.
+//
+// 토크나이저는 함수이므로 저장되지 않습니다. 데이터베이스를 열 때마다 같은 토크나이저로
+// CreateSearchIndex를 다시 호출해야 하며, 이미 있는 보조 테이블은 다시 만들지 않고 그대로 사용합니다.
+
+// searchIndexSuffix is appended to a table name to form the name of its index table.
+const searchIndexSuffix = "$search"
+
+// Tokenizer splits a value into the terms it is found by. Empty terms are ignored.
+type Tokenizer func(value string) []string
+
+// WhitespaceTokenizer lowercases the value and splits it on anything that is not a letter
+// or a digit.
+func WhitespaceTokenizer(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchIndex is the inverted index of one table. Guarded by db.mu.
+type searchIndex struct {
+	table    string // Index table
+	tokenize Tokenizer
+}
+
+// SearchIndexTable returns the name of the table holding the search index of tableName.
+func SearchIndexTable(tableName string) string {
+	return tableName + searchIndexSuffix
+}
+
+// CreateSearchIndex maintains an inverted index of the values of tableName, split into terms
+// by tokenize (WhitespaceTokenizer if nil). The index table is created and filled from the
+// existing rows on first use; later calls, e.g. after reopening, attach to it.
+func (db *Database) CreateSearchIndex(tableName string, tokenize Tokenizer) error {
+	if tokenize == nil {
+		tokenize = WhitespaceTokenizer
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, ok := db.spec.Tables[tableName]; !ok {
+		return fmt.Errorf("table %s not found", tableName)
+	}
+	if strings.HasSuffix(tableName, searchIndexSuffix) {
+		return fmt.Errorf("table %s is a search index", tableName)
+	}
+	if _, ok := db.search[tableName]; ok {
+		return fmt.Errorf("table %s already has a search index", tableName)
+	}
+	idx := &searchIndex{table: SearchIndexTable(tableName), tokenize: tokenize}
+	if _, ok := db.spec.Tables[idx.table]; ok {
+		db.search[tableName] = idx
+		return nil
+	}
+
+	// 보조 테이블이 없으면 기존 행으로 채움. 스캔 중에는 쓰지 않도록 먼저 모아 둠.
+	rows := make(map[string]string)
+	if err := db.scanLocked(tableName, "", func(key, value string) bool {
+		rows[key] = value
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to index table %s: %v", tableName, err)
+	}
+	if err := db.createTableLocked(idx.table, TableOptions{}); err != nil {
+		return err
+	}
+	db.search[tableName] = idx
+	for key, value := range rows {
+		if err := db.updateSearchIndex(tableName, key, "", value, false); err != nil {
+			return err
+		}
+	}
+	db.logger.Info("Created search index", "table", tableName, "rows", len(rows))
+	return nil
+}
+
+// Search returns the keys of tableName whose value contains a term starting with term, in
+// ascending key order. The term is normalized with the table's tokenizer, so with
+// WhitespaceTokenizer the search is case-insensitive. Without CreateSearchIndex, e.g. on a
+// replica, the replicated index table is searched with term as given.
+func (db *Database) Search(tableName, term string) ([]string, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	indexTable := SearchIndexTable(tableName)
+	if _, ok := db.spec.Tables[indexTable]; !ok {
+		return nil, fmt.Errorf("table %s has no search index", tableName)
+	}
+	if idx, ok := db.search[tableName]; ok {
+		if terms := idx.tokenize(term); len(terms) == 1 {
+			term = terms[0]
+		}
+	}
+	if term == "" {
+		return nil, fmt.Errorf("empty search term")
+	}
+	seen := make(map[string]bool)
+	var keys []string
+	err := db.scanLocked(indexTable, term, func(entry, _ string) bool {
+		if i := strings.IndexByte(entry, 0); i >= 0 && !seen[entry[i+1:]] {
+			seen[entry[i+1:]] = true
+			keys = append(keys, entry[i+1:])
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// updateSearchIndex replaces the index entries of key for oldValue with those for newValue.
+// hadOld tells whether oldValue was stored. Caller holds db.mu.
+func (db *Database) updateSearchIndex(tableName, key, oldValue, newValue string, hadOld bool) error {
+	idx, ok := db.search[tableName]
+	if !ok {
+		return nil
+	}
+	oldTerms := make(map[string]bool)
+	if hadOld {
+		oldTerms = searchTerms(idx.tokenize(oldValue))
+	}
+	newTerms := searchTerms(idx.tokenize(newValue))
+	for term := range oldTerms {
+		entry := term + "\x00" + key
+		if newTerms[term] {
+			continue
+		}
+		// 인덱스에 없는 항목은 건너뜀 (다른 토크나이저로 만든 인덱스 등)
+		if _, err := db.getRaw(idx.table, entry); err != nil {
+			continue
+		}
+		if _, err := db.deleteLocked(idx.table, entry); err != nil {
+			return fmt.Errorf("failed to update search index of table %s: %v", tableName, err)
+		}
+	}
+	for term := range newTerms {
+		if !oldTerms[term] {
+			if _, err := db.insertLocked(idx.table, term+"\x00"+key, ""); err != nil {
+				return fmt.Errorf("failed to update search index of table %s: %v", tableName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// searchTerms returns the distinct usable terms of tokens.
+func searchTerms(tokens []string) map[string]bool {
+	terms := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if t != "" && !strings.ContainsRune(t, 0) {
+			terms[t] = true
+		}
+	}
+	return terms
+}
+
+// storedValue returns the plain value of a raw stored entry of tableName. Caller holds db.mu.
+func (db *Database) storedValue(tableName, key string, raw interface{}) (string, error) {
+	s, err := ports.ValueString(raw)
+	if err == nil {
+		s, err = db.decryptValue(tableName, key, s)
+	}
+	if err != nil {
+		return "", err
+	}
+	if spec := db.spec.Tables[tableName]; spec != nil && spec.Metadata {
+		_, s, err = decodeEntry(s)
+	}
+	return s, err
+}
@@ -0,0 +1,358 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 지연 SLO 가드레일: 조회(Get)와 쓰기(Insert, Delete, Write)의 지연을 데이터베이스가 직접
+// 히스토그램에 기록하고, 고정 길이 창(Window)마다 p99를 목표(ReadP99, WriteP99)와 비교합니다.
+// 목표를 넘는 창이 Sustain번 연속되면 부하 차단 단계를 하나 올려 가장 낮은 우선순위의 요청부터
+// ErrOverloaded로 거절하고, 목표 안의 창이 Sustain번 연속되면 단계를 하나 내립니다. 요청이 없어
+// 지나간 창과 표본이 MinSamples보다 적은 창은 목표 안으로 봅니다.
+//
+//   - 우선순위는 WithPriority로 컨텍스트에 싣고, 컨텍스트를 받는 진입점(GetContext, InsertContext,
+//     DeleteContext, WriteContext)만 거절합니다. 우선순위가 없는 컨텍스트는 PriorityNormal입니다.
+//   - PriorityCritical은 거절되지 않으므로 단계는 PriorityHigh까지 차단하는 데서 멈춥니다.
+//   - 단계가 바뀔 때마다 로그와 events.LoadShedding 이벤트로 알립니다.
+//
+// 히스토그램은 2의 거듭제곱 구간마다 4개의 버킷을 두므로 p99는 실제 값보다 최대 25% 큽니다.
+
+// ErrOverloaded is returned to requests rejected while the database sheds load.
+var ErrOverloaded = errors.New("database overloaded: request shed")
+
+// Priority orders requests for load shedding; lower priorities are shed first.
+type Priority int
+
+const (
+	PriorityBackground Priority = iota // Batch jobs, backfills
+	PriorityLow
+	PriorityNormal // Requests without a priority
+	PriorityHigh
+	PriorityCritical // Never shed
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context carrying the request priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority carried by ctx, PriorityNormal if none.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// LatencySLO sets latency targets. Zero targets disable the guardrail for that kind of request.
+type LatencySLO struct {
+	ReadP99    time.Duration // Target p99 of Get
+	WriteP99   time.Duration // Target p99 of Insert, Delete and Write
+	Window     time.Duration // Measurement window (1s if zero)
+	Sustain    int           // Consecutive windows to raise or lower the shedding level (3 if zero)
+	MinSamples int           // Windows with fewer samples never count as violations (20 if zero)
+}
+
+func (s LatencySLO) enabled() bool {
+	return s.ReadP99 > 0 || s.WriteP99 > 0
+}
+
+// GetContext is Get for a request with the priority of ctx. It fails with ErrOverloaded while
+// that priority is being shed. Tables are resolved in the tenant namespace of ctx (see tenant.go).
+func (db *Database) GetContext(ctx context.Context, tableName, key string) (value string, err error) {
+	tableName, err = ResolveTable(ctx, tableName)
+	if err != nil {
+		return "", err
+	}
+	ctx, span := db.startSpan(ctx, "get", tableName)
+	defer func() { EndSpan(span, err) }()
+	if err := db.admit(ctx); err != nil {
+		return "", err
+	}
+	return db.Get(tableName, key)
+}
+
+// InsertContext is InsertWithAck for a request with the priority of ctx. It fails with
+// ErrOverloaded while that priority is being shed. Tables are resolved in the tenant
+// namespace of ctx (see tenant.go).
+func (db *Database) InsertContext(ctx context.Context, tableName, key, value string, ack WriteAck) (err error) {
+	tableName, err = ResolveTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	ctx, span := db.startSpan(ctx, "insert", tableName)
+	defer func() { EndSpan(span, err) }()
+	if err := db.admit(ctx); err != nil {
+		return err
+	}
+	return db.insertWithAck(ctx, tableName, key, value, ack)
+}
+
+// DeleteContext is DeleteWithAck for a request with the priority of ctx. It fails with
+// ErrOverloaded while that priority is being shed. Tables are resolved in the tenant
+// namespace of ctx (see tenant.go).
+func (db *Database) DeleteContext(ctx context.Context, tableName, key string, ack WriteAck) (err error) {
+	tableName, err = ResolveTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	ctx, span := db.startSpan(ctx, "delete", tableName)
+	defer func() { EndSpan(span, err) }()
+	if err := db.admit(ctx); err != nil {
+		return err
+	}
+	return db.deleteWithAck(ctx, tableName, key, ack)
+}
+
+// WriteContext is WriteWithAck for a request with the priority of ctx. It fails with
+// ErrOverloaded while that priority is being shed. Tables are resolved in the tenant
+// namespace of ctx (see tenant.go).
+func (db *Database) WriteContext(ctx context.Context, batch *WriteBatch, ack WriteAck) (err error) {
+	ctx, span := db.startSpan(ctx, "write_batch", "")
+	defer func() { EndSpan(span, err) }()
+	if batch != nil {
+		span.SetAttributes(attribute.Int("golite.batch_ops", len(batch.ops)))
+	}
+	if err := db.admit(ctx); err != nil {
+		return err
+	}
+	if TenantFrom(ctx) != "" && batch != nil {
+		resolved := &WriteBatch{ops: make([]BatchOp, len(batch.ops))}
+		for i, op := range batch.ops {
+			table, err := ResolveTable(ctx, op.Table)
+			if err != nil {
+				return err
+			}
+			op.Table = table
+			resolved.ops[i] = op
+		}
+		batch = resolved
+	}
+	return db.writeWithAck(ctx, batch, ack)
+}
+
+// sloOp is the kind of request a latency sample belongs to.
+type sloOp int
+
+const (
+	sloRead sloOp = iota
+	sloWrite
+)
+
+func (op sloOp) String() string {
+	if op == sloRead {
+		return "read"
+	}
+	return "write"
+}
+
+// histogramBuckets covers every time.Duration: 4 buckets below 4ns, then 4 per power of two.
+const histogramBuckets = 4 * 63
+
+// latencyHistogram counts latency samples in log-linear buckets.
+type latencyHistogram struct {
+	counts [histogramBuckets]int64
+	total  int64
+}
+
+func bucketOf(d time.Duration) int {
+	v := uint64(d)
+	if d < 4 {
+		return int(max(d, 0))
+	}
+	e := bits.Len64(v) - 1
+	return 4*(e-1) + int(v>>(e-2)&3)
+}
+
+// bucketUpper returns the largest duration counted in bucket i.
+func bucketUpper(i int) time.Duration {
+	if i < 4 {
+		return time.Duration(i)
+	}
+	e, sub := i/4+1, uint64(i%4)
+	return time.Duration((4+sub+1)<<(e-2) - 1)
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	h.counts[bucketOf(d)]++
+	h.total++
+}
+
+// quantile returns the upper bound of the bucket holding quantile q (0-1).
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(q*float64(h.total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, c := range h.counts {
+		if seen += c; seen >= rank {
+			return bucketUpper(i)
+		}
+	}
+	return bucketUpper(histogramBuckets - 1)
+}
+
+// sloGuard measures request latencies and decides which priorities are shed.
+// A nil guard (no targets configured) admits everything and records nothing.
+type sloGuard struct {
+	config LatencySLO
+	name   string
+	events *events.Bus
+	logger utils.Logger
+
+	mu         sync.Mutex
+	start      time.Time           // Start of the current window
+	current    [2]latencyHistogram // Samples of the current window by sloOp
+	lastP99    [2]time.Duration    // p99 of the last evaluated window by sloOp
+	violations int                 // Consecutive violating windows
+	healthy    int                 // Consecutive windows within the targets
+	level      int                 // Priorities below Priority(level) are shed
+	shed       int64               // Requests rejected with ErrOverloaded
+}
+
+func newSLOGuard(config LatencySLO, name string, bus *events.Bus, logger utils.Logger) *sloGuard {
+	if !config.enabled() {
+		return nil
+	}
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	if config.Sustain <= 0 {
+		config.Sustain = 3
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 20
+	}
+	return &sloGuard{config: config, name: name, events: bus, logger: logger, start: time.Now()}
+}
+
+// target returns the p99 target of op (0 = none).
+func (g *sloGuard) target(op sloOp) time.Duration {
+	if op == sloRead {
+		return g.config.ReadP99
+	}
+	return g.config.WriteP99
+}
+
+// admit rejects ctx with ErrOverloaded if its priority is being shed.
+func (g *sloGuard) admit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if g == nil {
+		return nil
+	}
+	p := PriorityFrom(ctx)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rotate(time.Now())
+	if p < Priority(g.level) && p < PriorityCritical {
+		g.shed++
+		return fmt.Errorf("%w (priority %d, shedding below %d)", ErrOverloaded, p, g.level)
+	}
+	return nil
+}
+
+// observe records the latency of a request of kind op that started at start.
+func (g *sloGuard) observe(op sloOp, start time.Time) {
+	if g == nil {
+		return
+	}
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rotate(now)
+	g.current[op].observe(now.Sub(start))
+}
+
+// rotate evaluates the current window once it is over. Caller holds g.mu.
+func (g *sloGuard) rotate(now time.Time) {
+	elapsed := now.Sub(g.start)
+	if elapsed < g.config.Window {
+		return
+	}
+	g.evaluate()
+	g.current = [2]latencyHistogram{}
+	// 요청 없이 지나간 창은 목표 안으로 셈 (단계를 모두 내릴 만큼만 반복)
+	idle := min(int64(elapsed/g.config.Window)-1, int64(g.level*g.config.Sustain))
+	for i := int64(0); i < idle; i++ {
+		g.evaluate()
+	}
+	g.start = now
+}
+
+// evaluate compares the current window with the targets and moves the shedding level.
+// Caller holds g.mu.
+func (g *sloGuard) evaluate() {
+	violated, worst := false, sloRead
+	for op := sloRead; op <= sloWrite; op++ {
+		h := &g.current[op]
+		g.lastP99[op] = h.quantile(0.99)
+		target := g.target(op)
+		if target > 0 && h.total >= int64(g.config.MinSamples) && g.lastP99[op] > target {
+			violated, worst = true, op
+		}
+	}
+	if violated {
+		g.healthy = 0
+		if g.violations++; g.violations >= g.config.Sustain && g.level < int(PriorityCritical) {
+			g.violations = 0
+			g.level++
+			g.changed(worst, true)
+		}
+		return
+	}
+	g.violations = 0
+	if g.healthy++; g.healthy >= g.config.Sustain && g.level > 0 {
+		g.healthy = 0
+		g.level--
+		g.changed(worst, false)
+	}
+}
+
+// changed reports a new shedding level; raised tells whether op's violations raised it.
+// Caller holds g.mu.
+func (g *sloGuard) changed(op sloOp, raised bool) {
+	details := map[string]interface{}{
+		"level":     g.level,
+		"read_p99":  g.lastP99[sloRead],
+		"write_p99": g.lastP99[sloWrite],
+	}
+	switch {
+	case raised:
+		details["op"], details["target"] = op.String(), g.target(op)
+		g.logger.Warn("Over latency SLO: shedding load", "database", g.name, "op", op.String(), "p99", g.lastP99[op], "target", g.target(op), "shed_below", g.level)
+	case g.level > 0:
+		g.logger.Warn("Latency recovering", "database", g.name, "shed_below", g.level)
+	default:
+		g.logger.Warn("Back within latency SLO: load shedding stopped", "database", g.name)
+	}
+	g.events.Publish(events.Event{Type: events.LoadShedding, Source: g.name, Details: details})
+}
+
+// stats returns the guard's counters for Database.Stats.
+func (g *sloGuard) stats() map[string]interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return map[string]interface{}{
+		"read_p99":      g.lastP99[sloRead],
+		"write_p99":     g.lastP99[sloWrite],
+		"shed_level":    g.level,
+		"shed_requests": g.shed,
+	}
+}
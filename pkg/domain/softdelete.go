@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 소프트 삭제: TableOptions.SoftDelete(또는 SetSoftDelete)로 유예 기간을 준 테이블에서 Delete는 키를
+// 테이블에서 지우는 대신 보조 테이블("<table>$trash")로 옮겨 삭제 시각과 함께 남깁니다(툼스톤).
+// 테이블에서는 사라지므로 Get, Scan, Count는 삭제된 것으로 보고, 유예 기간 안에는 Undelete로 되살릴
+// 수 있습니다. 메타데이터 테이블은 되살릴 때 원래의 Created와 Flags를 유지합니다. 삭제된 키에 다시
+// 삽입하면 툼스톤은 사라집니다.
+//
+// 유예 기간이 지난 툼스톤은 PurgeDeleted(유지보수 작업 "purge")가 지웁니다. 그 전까지는 보조 테이블이
+// 디스크를 차지하며, 지나간 툼스톤은 되살릴 수 없습니다. 유예 기간은 보조 테이블에 기록되어 다시 열 때
+// 이어지고, 보조 테이블은 일반 테이블처럼 복제되므로 복제본도 같은 키가 지워진 것으로 봅니다. 암호화
+// 테이블은 평문 값이 보조 테이블에 남지 않도록 소프트 삭제를 쓸 수 없습니다.
+
+// trashSuffix is appended to a table name to form the name of its soft-delete table.
+const trashSuffix = "$trash"
+
+const (
+	trashEntryPrefix = "d:"    // Tombstones, keyed by the deleted key
+	trashGraceKey    = "grace" // Grace period of the table
+)
+
+// TrashTable returns the name of the table holding the soft-deleted keys of tableName.
+func TrashTable(tableName string) string {
+	return tableName + trashSuffix
+}
+
+// SetSoftDelete keeps the keys deleted from tableName for grace, so that Undelete can
+// restore them. A grace of 0 turns soft delete off and drops the kept keys.
+func (db *Database) SetSoftDelete(tableName string, grace time.Duration) error {
+	if grace < 0 {
+		return fmt.Errorf("soft delete grace period must be >= 0, got %v", grace)
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if _, ok := db.spec.Tables[tableName]; !ok {
+		return fmt.Errorf("table %s not found", tableName)
+	}
+	if grace == 0 {
+		if _, ok := db.trash[tableName]; !ok {
+			return nil
+		}
+		delete(db.trash, tableName)
+		return db.dropTableLocked(TrashTable(tableName))
+	}
+	return db.setSoftDeleteLocked(tableName, grace)
+}
+
+// setSoftDeleteLocked creates the soft-delete table of tableName if needed and records
+// grace. Caller holds db.mu.
+func (db *Database) setSoftDeleteLocked(tableName string, grace time.Duration) error {
+	spec := db.spec.Tables[tableName]
+	if spec.Encrypted {
+		return fmt.Errorf("table %s: soft delete is not supported on encrypted tables", tableName)
+	}
+	if !quotaTable(tableName) {
+		return fmt.Errorf("table %s is a system or auxiliary table", tableName)
+	}
+	trash := TrashTable(tableName)
+	if _, exists := db.spec.Tables[trash]; !exists {
+		if err := db.createTableLocked(trash, TableOptions{}); err != nil {
+			return err
+		}
+	}
+	if _, err := db.insertLocked(trash, trashGraceKey, grace.String()); err != nil {
+		return fmt.Errorf("failed to save soft delete grace period of table %s: %v", tableName, err)
+	}
+	db.trash[tableName] = grace
+	return nil
+}
+
+// loadSoftDelete restores the grace periods of the soft-delete tables. Called at open.
+func (db *Database) loadSoftDelete() {
+	for name := range db.spec.Tables {
+		table, ok := strings.CutSuffix(name, trashSuffix)
+		if !ok || db.spec.Tables[table] == nil {
+			continue
+		}
+		s, err := db.get(name, trashGraceKey)
+		if err != nil {
+			continue
+		}
+		if grace, err := time.ParseDuration(s); err == nil && grace > 0 {
+			db.trash[table] = grace
+		} else {
+			db.logger.Warn("Invalid soft delete grace period", "table", table, "value", s)
+		}
+	}
+}
+
+// encodeTombstone stores the deletion time in front of the stored entry.
+func encodeTombstone(deleted time.Time, entry string) string {
+	return strconv.FormatInt(deleted.UnixNano(), 10) + ":" + entry
+}
+
+// decodeTombstone splits a tombstone into the deletion time and the stored entry.
+func decodeTombstone(s string) (time.Time, string, error) {
+	ts, entry, ok := strings.Cut(s, ":")
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if !ok || err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid tombstone")
+	}
+	return time.Unix(0, nanos), entry, nil
+}
+
+// buryLocked keeps the entry stored under key (old, as read from storage) in the
+// soft-delete table of tableName, if it has one. Caller holds db.mu.
+func (db *Database) buryLocked(tableName, key string, old interface{}) error {
+	entry, err := ports.ValueString(old)
+	if err != nil {
+		return err
+	}
+	_, err = db.insertLocked(TrashTable(tableName), trashEntryPrefix+key, encodeTombstone(db.now(), entry))
+	return err
+}
+
+// unburyLocked drops the tombstone of key of tableName after it was written again.
+// Caller holds db.mu.
+func (db *Database) unburyLocked(tableName, key string) error {
+	trash := TrashTable(tableName)
+	if _, err := db.get(trash, trashEntryPrefix+key); err != nil {
+		return nil
+	}
+	_, err := db.deleteLocked(trash, trashEntryPrefix+key)
+	return err
+}
+
+// Undelete restores a key deleted from a soft-delete table within its grace period.
+func (db *Database) Undelete(tableName, key string) error {
+	seq, err := db.undelete(tableName, key)
+	if err != nil {
+		return err
+	}
+	return db.awaitAck(context.Background(), seq, WriteAck{})
+}
+
+func (db *Database) undelete(tableName, key string) (uint64, error) {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	spec, ok := db.spec.Tables[tableName]
+	if !ok {
+		return 0, fmt.Errorf("table %s not found", tableName)
+	}
+	grace, ok := db.trash[tableName]
+	if !ok {
+		return 0, fmt.Errorf("table %s does not use soft delete", tableName)
+	}
+	s, err := db.get(TrashTable(tableName), trashEntryPrefix+key)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s was not deleted from table %s", ports.ErrKeyNotFound, key, tableName)
+	}
+	deleted, entry, err := decodeTombstone(s)
+	if err != nil {
+		return 0, fmt.Errorf("key %s in table %s: %v", key, tableName, err)
+	}
+	if db.now().Sub(deleted) > grace {
+		return 0, fmt.Errorf("%w: %s was deleted from table %s more than %v ago", ports.ErrKeyNotFound, key, tableName, grace)
+	}
+	value := entry
+	var metaFn entryMetaFunc
+	if spec.Metadata {
+		m, v, err := decodeEntry(entry)
+		if err != nil {
+			return 0, fmt.Errorf("key %s in table %s: %v", key, tableName, err)
+		}
+		value = v
+		metaFn = func(*EntryMeta) EntryMeta {
+			return EntryMeta{Created: m.Created, Updated: db.now(), Flags: m.Flags}
+		}
+	}
+	// 삽입이 툼스톤을 지움 (unburyLocked)
+	seq, err := db.insertWithMeta(tableName, key, value, metaFn)
+	if err != nil {
+		return 0, err
+	}
+	db.logger.Info("Undeleted key", "table", tableName, "key", key)
+	return seq, nil
+}
+
+// PurgeDeleted drops the tombstones of the soft-delete tables older than their grace
+// period and returns how many were dropped.
+func (db *Database) PurgeDeleted(ctx context.Context) (int, error) {
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	now := db.now()
+	purged := 0
+	for table, grace := range db.trash {
+		trash := TrashTable(table)
+		var expired []string
+		err := db.scanLocked(trash, trashEntryPrefix, func(key, value string) bool {
+			if deleted, _, err := decodeTombstone(value); err == nil && now.Sub(deleted) > grace {
+				expired = append(expired, key)
+			}
+			return true
+		})
+		if err != nil {
+			return purged, fmt.Errorf("failed to read %s: %v", trash, err)
+		}
+		for _, key := range expired {
+			if err := ctx.Err(); err != nil {
+				return purged, err
+			}
+			if _, err := db.deleteLocked(trash, key); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	if purged > 0 {
+		db.logger.Info("Purged soft-deleted keys", "database", db.config.Name, "keys", purged)
+	}
+	return purged, nil
+}
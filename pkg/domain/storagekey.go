@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 저장 데이터 암호화 키 관리: DatabaseConfig.KeyProvider를 주면 NewDatabase가 열 때 공급자에게서 B-tree
+// 파일의 키(와 중단된 교체를 마칠 이전 키)를 받습니다. 파일 어댑터처럼 직접 만든 저장소는 만들 때
+// 같은 공급자에서 키를 받아 넘기면 됩니다.
+//
+// 키 교체 정책은 공급자와 스케줄이 나눠 맡습니다. RotateStorageKey는 공급자의 현재 키로 저장소를
+// Rekey하며, 공급자가 아직 같은 키를 내주면 아무것도 하지 않습니다. MaintenanceRekey 작업으로 이를
+// 주기적으로 실행하면, 공급자(KMS의 새 키 버전, 환경 변수 등)가 새 키를 내주기 시작한 뒤 다음 실행에서
+// 저장소가 새 키로 바뀝니다. 테이블 단위 암호화의 MasterKey(encryption.go)와는 별개의 키입니다.
+
+// ErrRekeyUnsupported is returned by RotateStorageKey when the storage adapter is not
+// encrypted with a key it can change (ports.Rekeyer).
+var ErrRekeyUnsupported = errors.New("storage does not support encryption key rotation")
+
+// storageKeys fetches the B-tree encryption keys from the key provider of config, unless
+// they are set explicitly.
+func storageKeys(config *DatabaseConfig) error {
+	if config.KeyProvider == nil || len(config.BtConfig.EncryptionKey) > 0 {
+		return nil
+	}
+	current, previous, err := config.KeyProvider.Keys(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get storage encryption key: %v", err)
+	}
+	config.BtConfig.EncryptionKey, config.BtConfig.PreviousEncryptionKey = current, previous
+	return nil
+}
+
+// RotateStorageKey re-encrypts the storage with the current key of DatabaseConfig.KeyProvider.
+// It does nothing if the storage is already encrypted with that key, so it can be run on a
+// schedule (MaintenanceRekey) and rotates the key once the provider hands out a new one.
+func (db *Database) RotateStorageKey(ctx context.Context) error {
+	if db.config.KeyProvider == nil {
+		return fmt.Errorf("database %s has no key provider", db.config.Name)
+	}
+	rekeyer, ok := db.storage.(ports.Rekeyer)
+	if !ok {
+		return ErrRekeyUnsupported
+	}
+	current, _, err := db.config.KeyProvider.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get storage encryption key: %v", err)
+	}
+	err = rekeyer.Rekey(current)
+	if errors.Is(err, pagecrypt.ErrSameKey) {
+		return nil // 공급자가 아직 새 키를 내주지 않음
+	}
+	if errors.Is(err, pagecrypt.ErrNotEncrypted) {
+		return fmt.Errorf("%w: %v", ErrRekeyUnsupported, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rotate storage key of database %s: %v", db.config.Name, err)
+	}
+	db.logger.Info("Storage encryption key rotated", "database", db.config.Name, "key_id", fmt.Sprintf("%08x", pagecrypt.KeyID(current)))
+	return nil
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 캐치업 구독: SubscribeFrom은 접두어에 맞는 기존 항목을 먼저 흘려보낸 뒤(Change.Snapshot = true)
+// 이어서 실시간 변경으로 넘어갑니다. 스캔과 변경 리스너 등록을 같은 읽기 잠금 안에서 하므로 그 사이에
+// 커밋되는 쓰기가 없고, 스냅샷 이후의 변경은 빠짐도 중복도 없이 한 번씩 전달됩니다. 읽기 모델을
+// 만들 때는 스냅샷 항목으로 상태를 채우고 이후 변경을 적용하면 됩니다.
+//
+// Watch와 달리 변경을 버리지 않습니다. 구독자가 스냅샷을 읽는 동안 쌓인 변경은 큐에 모아 두고, 큐가
+// buffer를 넘으면 구독을 ErrSubscriptionOverflow로 끝냅니다 (C가 닫히고 Err가 오류를 돌려줌).
+// 이때 구독자는 SubscribeFrom으로 처음부터 다시 시작해야 합니다. 스냅샷은 메모리에 모아 두므로
+// 접두어 범위가 큰 경우 메모리 사용량에 주의합니다.
+
+// DefaultSubscriptionBuffer is the number of live changes a Subscription created with
+// buffer <= 0 queues for a slow consumer before it fails.
+const DefaultSubscriptionBuffer = 4096
+
+// ErrSubscriptionOverflow ends a Subscription whose consumer fell more than its buffer
+// behind the live changes.
+var ErrSubscriptionOverflow = errors.New("subscription overflow: consumer fell behind")
+
+// Subscription streams the existing entries of a key range followed by its live changes.
+type Subscription struct {
+	C      <-chan Change
+	out    chan Change
+	table  string
+	prefix string
+	buffer int
+	remove func()
+	wake   chan struct{} // Signals new queued changes or a failure
+	done   chan struct{} // Closed by Close
+	once   sync.Once
+
+	mu    sync.Mutex
+	queue []Change // Live changes not yet sent
+	err   error
+}
+
+var _ ports.StorageEventPort = (*Subscription)(nil)
+
+// SubscribeFrom streams the keys of tableName starting with prefix as of now, then their
+// changes. buffer bounds the queued live changes (DefaultSubscriptionBuffer if <= 0).
+func (db *Database) SubscribeFrom(tableName, prefix string, buffer int) (*Subscription, error) {
+	if buffer <= 0 {
+		buffer = DefaultSubscriptionBuffer
+	}
+	out := make(chan Change)
+	s := &Subscription{
+		C:      out,
+		out:    out,
+		table:  tableName,
+		prefix: prefix,
+		buffer: buffer,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	var snapshot []Change
+	err := db.scanLocked(tableName, prefix, func(key, value string) bool {
+		snapshot = append(snapshot, Change{Table: tableName, Key: key, Value: value, Snapshot: true})
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to table %s: %v", tableName, err)
+	}
+	// 읽기 잠금을 쥔 채 등록하므로 스냅샷과 첫 변경 사이에 커밋이 끼어들 수 없음
+	s.remove = db.AddStorageEventPort(tableName, s)
+	go s.run(snapshot)
+	return s, nil
+}
+
+// OnInsert implements ports.StorageEventPort.
+func (s *Subscription) OnInsert(key string, value interface{}) {
+	v, _ := ports.ValueString(value)
+	s.enqueue(Change{Table: s.table, Key: key, Value: v})
+}
+
+// OnDelete implements ports.StorageEventPort.
+func (s *Subscription) OnDelete(key string) {
+	s.enqueue(Change{Table: s.table, Key: key, Deleted: true})
+}
+
+// enqueue queues a live change. It runs under the database write lock, so it never blocks.
+func (s *Subscription) enqueue(c Change) {
+	if !strings.HasPrefix(c.Key, s.prefix) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	if len(s.queue) >= s.buffer {
+		s.err = ErrSubscriptionOverflow
+		s.queue = nil
+	} else {
+		s.queue = append(s.queue, c)
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the snapshot, then the queued changes, until Close or a failure.
+func (s *Subscription) run(snapshot []Change) {
+	defer close(s.out)
+	defer s.remove()
+	for _, c := range snapshot {
+		if !s.send(c) {
+			return
+		}
+	}
+	for {
+		s.mu.Lock()
+		queue, err := s.queue, s.err
+		s.queue = nil
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+		for _, c := range queue {
+			if !s.send(c) {
+				return
+			}
+		}
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Subscription) send(c Change) bool {
+	select {
+	case s.out <- c:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Err returns ErrSubscriptionOverflow if the subscription ended because its consumer fell
+// behind, nil otherwise.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription; C is closed shortly after. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() { close(s.done) })
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 저장소가 ports.SubtreePort를 구현하면 테이블마다 독립된 B-tree root를 사용합니다.
+// 키에 테이블 이름을 붙이지 않으므로 ':'를 포함한 키가 다른 테이블과 충돌하지 않고,
+// DropTable은 root 기록만 지우면 됩니다. root 위치는 page 1 헤더의 테이블 이름 뒤에 기록됩니다.
+// 이 섹션이 없는 이전 포맷의 테이블은 기존처럼 "table:key" 형태로 메인 트리에 남습니다.
+
+// tableRootsMagic marks the per-table root section that follows the table names in page 1.
+const tableRootsMagic uint32 = 0x544F4F52 // "ROOT"
+
+// tableTree is the isolated storage of one table.
+type tableTree struct {
+	root   int64
+	length int
+	store  ports.StoragePort
+}
+
+// tableStorage returns the storage holding a table's keys and the prefix its keys carry there.
+// Tables with their own subtree store keys verbatim; legacy tables share the main storage
+// with "table:" prefixed keys. Caller holds db.mu.
+func (db *Database) tableStorage(tableName string) (ports.StoragePort, string) {
+	if t, ok := db.trees[tableName]; ok {
+		return t.store, ""
+	}
+	return db.storage, tableName + ":"
+}
+
+// openTableTree gives a table its own subtree if the storage supports it. Caller holds db.mu.
+func (db *Database) openTableTree(tableName string, root int64, length int) {
+	sub, ok := db.storage.(ports.SubtreePort)
+	if !ok || !db.config.UsePages {
+		return
+	}
+	t := &tableTree{root: root, length: length}
+	t.store = sub.Subtree(root, length, func(root int64, length int) error {
+		t.root, t.length = root, length
+		return db.writeHeader()
+	})
+	db.trees[tableName] = t
+}
+
+// encodeTableRoots appends the per-table root section for names to buf. Caller holds db.mu.
+func (db *Database) encodeTableRoots(buf *bytes.Buffer, names []string) {
+	tables := make([]HeaderTable, len(names))
+	for i, name := range names {
+		tables[i].Name = name
+		if t, ok := db.trees[name]; ok {
+			tables[i].Isolated, tables[i].Root, tables[i].Length = true, t.root, t.length
+		}
+	}
+	appendTableRoots(buf, tables)
+}
+
+// appendTableRoots appends the per-table root section for tables to buf.
+func appendTableRoots(buf *bytes.Buffer, tables []HeaderTable) {
+	binary.Write(buf, byteOrder, tableRootsMagic)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	for _, t := range tables {
+		if !t.Isolated {
+			buf.WriteByte(0) // Legacy table stored in the main tree
+			continue
+		}
+		buf.WriteByte(1)
+		buf.Write(tmp[:binary.PutUvarint(tmp, uint64(t.Root))])
+		buf.Write(tmp[:binary.PutUvarint(tmp, uint64(t.Length))])
+	}
+}
+
+// decodeTableRoots reads the per-table root section if present and opens the subtrees.
+// Headers written before tables had their own roots have no section; r is left untouched then.
+func (db *Database) decodeTableRoots(r *bytes.Reader, names []string) {
+	start, _ := r.Seek(0, io.SeekCurrent)
+	var magic uint32
+	if err := binary.Read(r, byteOrder, &magic); err != nil || magic != tableRootsMagic {
+		r.Seek(start, io.SeekStart)
+		return
+	}
+	for _, name := range names {
+		isolated, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		if isolated == 0 {
+			continue
+		}
+		root, err := binary.ReadUvarint(r)
+		if err != nil {
+			return
+		}
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			return
+		}
+		db.openTableTree(name, int64(root), int(length))
+	}
+}
@@ -0,0 +1,320 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 멀티 테넌시: WithTenant로 컨텍스트에 테넌트 ID를 실으면 컨텍스트를 받는 진입점(GetContext,
+// InsertContext, DeleteContext, WriteContext, ScanContext, CreateTableContext, DropTableContext)이
+// 테이블 이름 앞에 테넌트 네임스페이스를 붙여 "@<tenant>/<table>" 테이블에 접근합니다. 테넌트
+// 테이블은 일반 테이블이므로 복제, 캡처, 체인지피드, 스냅샷에 그대로 포함됩니다. 테넌트가 없는
+// 컨텍스트는 테이블 이름을 그대로 씁니다.
+//
+// 쿼터(TenantQuota)는 시스템 테이블 TenantsTable에 테넌트별 JSON으로 저장되며, 테이블 생성과 새 키
+// 삽입 때 쓰기 잠금 안에서 검사하므로 동시 요청이 쿼터를 넘을 수 없습니다. 키 개수는 테이블별 키
+// 카운터(keycount.go)의 합이라 스캔이 필요 없습니다. 보조 테이블($search, $counts)은 테이블 수와 키
+// 수에 넣지 않습니다. 배치는 쿼터를 넘는 연산에서 멈추며 앞선 연산은 적용된 채로 남습니다.
+// DropTenant는 테넌트의 모든 테이블과 쿼터를 한 번에 지웁니다.
+
+// TenantsTable is the system table holding the tenant quotas.
+const TenantsTable = "__tenants"
+
+// tenantTablePrefix starts the name of every tenant table.
+const tenantTablePrefix = "@"
+
+// ErrQuotaExceeded is returned when a write or table creation would exceed a tenant quota.
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// TenantQuota limits the resources of a tenant. Zero fields are unlimited.
+type TenantQuota struct {
+	MaxTables int   `json:"max_tables"`
+	MaxKeys   int64 `json:"max_keys"`
+}
+
+// TenantStats reports the usage of a tenant.
+type TenantStats struct {
+	Tenant string
+	Tables []string // Table names without the tenant namespace, sorted
+	Keys   int64    // Live keys over all tables
+	Quota  TenantQuota
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose requests access the tables of tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant of ctx, or "" if it has none.
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// TenantTable returns the name of the table holding tableName for tenant.
+func TenantTable(tenant, tableName string) string {
+	return tenantTablePrefix + tenant + "/" + tableName
+}
+
+// ResolveTable returns the table tableName refers to for the tenant of ctx.
+func ResolveTable(ctx context.Context, tableName string) (string, error) {
+	tenant := TenantFrom(ctx)
+	if tenant == "" {
+		return tableName, nil
+	}
+	if err := validateTenant(tenant); err != nil {
+		return "", err
+	}
+	return TenantTable(tenant, tableName), nil
+}
+
+func validateTenant(tenant string) error {
+	if tenant == "" || strings.Contains(tenant, "/") {
+		return fmt.Errorf("invalid tenant ID %q", tenant)
+	}
+	return nil
+}
+
+// isAuxiliaryTable reports whether name is the search index or count view of another table.
+func isAuxiliaryTable(name string) bool {
+	return strings.HasSuffix(name, searchIndexSuffix) || strings.HasSuffix(name, countViewSuffix)
+}
+
+// tenantOf returns the tenant owning tableName and whether it counts toward the tenant's
+// quota (auxiliary tables don't).
+func tenantOf(tableName string) (tenant string, counted bool) {
+	rest, ok := strings.CutPrefix(tableName, tenantTablePrefix)
+	if !ok {
+		return "", false
+	}
+	tenant, _, ok = strings.Cut(rest, "/")
+	if !ok {
+		return "", false
+	}
+	return tenant, !isAuxiliaryTable(tableName)
+}
+
+// tenantUsage returns the counted tables and live keys of tenant. Caller holds db.mu.
+func (db *Database) tenantUsage(tenant string) (tables []string, keys int64) {
+	for name := range db.spec.Tables {
+		if t, counted := tenantOf(name); counted && t == tenant {
+			tables = append(tables, name)
+			if s, ok := db.keys[name]; ok {
+				keys += s.count
+			}
+		}
+	}
+	sort.Strings(tables)
+	return tables, keys
+}
+
+// checkTenantTableQuota fails if creating tableName would exceed its tenant's table quota.
+// Caller holds db.mu.
+func (db *Database) checkTenantTableQuota(tableName string) error {
+	tenant, counted := tenantOf(tableName)
+	quota := db.quotas[tenant]
+	if !counted || quota.MaxTables <= 0 {
+		return nil
+	}
+	if tables, _ := db.tenantUsage(tenant); len(tables) >= quota.MaxTables {
+		return fmt.Errorf("%w: tenant %s has %d of %d tables", ErrQuotaExceeded, tenant, len(tables), quota.MaxTables)
+	}
+	return nil
+}
+
+// checkTenantKeyQuota fails if adding a key to tableName would exceed its tenant's key quota.
+// Caller holds db.mu.
+func (db *Database) checkTenantKeyQuota(tableName string) error {
+	tenant, counted := tenantOf(tableName)
+	quota := db.quotas[tenant]
+	if !counted || quota.MaxKeys <= 0 {
+		return nil
+	}
+	if _, keys := db.tenantUsage(tenant); keys >= quota.MaxKeys {
+		return fmt.Errorf("%w: tenant %s has %d of %d keys", ErrQuotaExceeded, tenant, keys, quota.MaxKeys)
+	}
+	return nil
+}
+
+// SetTenantQuota sets the quota of tenant; a zero quota removes it. Existing usage above
+// the new quota is kept, but blocks further growth.
+func (db *Database) SetTenantQuota(tenant string, quota TenantQuota) error {
+	if err := validateTenant(tenant); err != nil {
+		return err
+	}
+	if quota.MaxTables < 0 || quota.MaxKeys < 0 {
+		return fmt.Errorf("tenant %s: quota limits must be >= 0", tenant)
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	if quota == (TenantQuota{}) {
+		return db.removeTenantQuota(tenant)
+	}
+	if _, exists := db.spec.Tables[TenantsTable]; !exists {
+		if err := db.createTableLocked(TenantsTable, TableOptions{}); err != nil {
+			return fmt.Errorf("failed to create %s: %v", TenantsTable, err)
+		}
+	}
+	data, _ := json.Marshal(quota)
+	if _, err := db.insertLocked(TenantsTable, tenant, string(data)); err != nil {
+		return fmt.Errorf("failed to save quota of tenant %s: %v", tenant, err)
+	}
+	db.quotas[tenant] = quota
+	return nil
+}
+
+// removeTenantQuota deletes the quota of tenant, if any. Caller holds db.mu.
+func (db *Database) removeTenantQuota(tenant string) error {
+	if _, ok := db.quotas[tenant]; !ok {
+		return nil
+	}
+	if _, err := db.deleteLocked(TenantsTable, tenant); err != nil {
+		return fmt.Errorf("failed to remove quota of tenant %s: %v", tenant, err)
+	}
+	delete(db.quotas, tenant)
+	return nil
+}
+
+// TenantStats returns the usage and quota of tenant.
+func (db *Database) TenantStats(tenant string) (TenantStats, error) {
+	if err := validateTenant(tenant); err != nil {
+		return TenantStats{}, err
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	tables, keys := db.tenantUsage(tenant)
+	stats := TenantStats{Tenant: tenant, Keys: keys, Quota: db.quotas[tenant]}
+	for _, name := range tables {
+		stats.Tables = append(stats.Tables, strings.TrimPrefix(name, TenantTable(tenant, "")))
+	}
+	return stats, nil
+}
+
+// Tenants returns the tenants that have tables or a quota, sorted.
+func (db *Database) Tenants() []string {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	seen := make(map[string]bool)
+	for name := range db.spec.Tables {
+		if tenant, counted := tenantOf(name); counted {
+			seen[tenant] = true
+		}
+	}
+	for tenant := range db.quotas {
+		seen[tenant] = true
+	}
+	tenants := make([]string, 0, len(seen))
+	for tenant := range seen {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// DropTenant drops every table of tenant, with their auxiliary tables, and its quota.
+func (db *Database) DropTenant(tenant string) error {
+	if err := validateTenant(tenant); err != nil {
+		return err
+	}
+	if db.config.ThreadSafe {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+	}
+	// 검색 색인이 다시 연결되지 않은 보조 테이블도 남지 않도록 네임스페이스의 모든 테이블을 지움
+	var tables []string
+	for name := range db.spec.Tables {
+		if t, _ := tenantOf(name); t == tenant {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+	for _, name := range tables {
+		if _, exists := db.spec.Tables[name]; !exists {
+			continue // 앞에서 주 테이블과 함께 지워진 보조 테이블
+		}
+		if err := db.dropTableWithAuxLocked(name); err != nil {
+			return fmt.Errorf("failed to drop tenant %s: %v", tenant, err)
+		}
+	}
+	if err := db.removeTenantQuota(tenant); err != nil {
+		return err
+	}
+	db.logger.Info("Dropped tenant", "tenant", tenant, "tables", len(tables))
+	return nil
+}
+
+// loadTenantQuotas restores the quotas saved in TenantsTable. Called at open.
+func (db *Database) loadTenantQuotas() {
+	if _, exists := db.spec.Tables[TenantsTable]; !exists {
+		return
+	}
+	err := db.scanLocked(TenantsTable, "", func(tenant, value string) bool {
+		var quota TenantQuota
+		if err := json.Unmarshal([]byte(value), &quota); err != nil {
+			db.logger.Warn("Ignoring invalid tenant quota", "tenant", tenant, "error", err)
+			return true
+		}
+		db.quotas[tenant] = quota
+		return true
+	})
+	if err != nil {
+		db.logger.Warn("Failed to load tenant quotas", "error", err)
+	}
+}
+
+// CreateTableContext creates a table in the tenant namespace of ctx.
+func (db *Database) CreateTableContext(ctx context.Context, tableName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name, err := ResolveTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	return db.CreateTable(name)
+}
+
+// DropTableContext drops a table of the tenant namespace of ctx.
+func (db *Database) DropTableContext(ctx context.Context, tableName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name, err := ResolveTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	return db.DropTable(name)
+}
+
+// ScanContext is Scan on a table of the tenant namespace of ctx.
+func (db *Database) ScanContext(ctx context.Context, tableName, prefix string, fn func(key, value string) bool) (err error) {
+	name, err := ResolveTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	ctx, span := db.startSpan(ctx, "scan", name)
+	defer func() { EndSpan(span, err) }()
+	if err := db.admit(ctx); err != nil {
+		return err
+	}
+	keys := 0
+	defer func() { span.SetAttributes(attribute.Int("golite.keys", keys)) }()
+	return db.scan(ctx, name, prefix, func(key, value string) bool {
+		keys++
+		return fn(key, value)
+	})
+}
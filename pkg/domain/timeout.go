@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 기본 제한 시간: DatabaseConfig의 ReadTimeout, WriteTimeout, CompactionTimeout을 설정하면 애플리케이션
+// 계층(CommandHandler, QueryHandler)이 명령과 질의마다 WithTimeout으로 그 시간이 지나면 끝나는
+// 컨텍스트를 만들어 넘깁니다. 호출자의 컨텍스트에 더 이른 마감 시각이 있으면 그쪽이 우선합니다.
+// 기본 제한 시간 때문에 끝난 연산은 *TimeoutError를 반환하며 errors.Is(err, ErrTimeout)와
+// errors.Is(err, context.DeadlineExceeded)가 모두 참입니다. 호출자가 정한 마감 시각이 지난 경우는
+// 지금처럼 context.DeadlineExceeded만 반환합니다.
+//
+// 제한 시간은 컨텍스트를 통해서만 적용되므로 취소(cancel.go)와 같은 규칙을 따릅니다: 잠금 안에서
+// 적용되기 시작한 쓰기는 끝까지 적용되고, 시간이 지나면 확인 대기(fsync, 팔로워 ack)만 멈춥니다.
+
+// ErrTimeout matches every *TimeoutError with errors.Is.
+var ErrTimeout = errors.New("operation timed out")
+
+// OperationClass selects the default timeout of an operation.
+type OperationClass int
+
+const (
+	OperationRead       OperationClass = iota // ReadTimeout
+	OperationWrite                            // WriteTimeout
+	OperationCompaction                       // CompactionTimeout
+)
+
+func (c OperationClass) String() string {
+	switch c {
+	case OperationRead:
+		return "read"
+	case OperationWrite:
+		return "write"
+	case OperationCompaction:
+		return "compaction"
+	}
+	return fmt.Sprintf("OperationClass(%d)", int(c))
+}
+
+// TimeoutError is returned when an operation runs past its default timeout.
+type TimeoutError struct {
+	Op      string        // Operation that timed out
+	Timeout time.Duration // Default timeout that expired
+	Err     error         // Error returned by the operation
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s timed out after %v", e.Op, e.Timeout)
+	}
+	return fmt.Sprintf("%s timed out after %v: %v", e.Op, e.Timeout, e.Err)
+}
+
+// Is reports whether target is ErrTimeout.
+func (e *TimeoutError) Is(target error) bool { return target == ErrTimeout }
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout returns the default timeout of class (0 = none).
+func (db *Database) Timeout(class OperationClass) time.Duration {
+	switch class {
+	case OperationRead:
+		return db.config.ReadTimeout
+	case OperationWrite:
+		return db.config.WriteTimeout
+	case OperationCompaction:
+		return db.config.CompactionTimeout
+	}
+	return 0
+}
+
+// WithTimeout returns a context for the operation op of class that ends once the default
+// timeout of class has passed. Without a timeout it returns ctx unchanged. Pass the error of
+// the operation to TimeoutErr to report an expired timeout.
+func (db *Database) WithTimeout(ctx context.Context, class OperationClass, op string) (context.Context, context.CancelFunc) {
+	d := db.Timeout(class)
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, d, &TimeoutError{Op: op, Timeout: d})
+}
+
+// TimeoutErr returns err as a *TimeoutError if it was caused by the default timeout set on
+// ctx by WithTimeout, and err unchanged otherwise.
+func TimeoutErr(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	var cause *TimeoutError
+	if !errors.As(context.Cause(ctx), &cause) {
+		return err // 호출자가 정한 마감 시각
+	}
+	return &TimeoutError{Op: cause.Op, Timeout: cause.Timeout, Err: err}
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 트레이싱: 컨텍스트를 받는 진입점(GetContext, InsertContext, DeleteContext, WriteContext,
+// ScanContext)은 저장소 연산마다 OpenTelemetry 스팬(golite.storage.<op>)을 만들어 호출자의 스팬
+// 아래에 붙입니다. 스팬에는 데이터베이스 이름, 테이블, 어댑터 타입이 속성으로 남으며 키와 값은
+// 남기지 않습니다. 애플리케이션 계층의 CommandHandler/QueryHandler가 명령과 질의마다 만드는 스팬이
+// 부모가 되므로 요청 하나의 지연을 명령 → 저장소 연산 단위로 나눠 볼 수 있습니다.
+//
+// DatabaseConfig.TracerProvider가 없으면 otel 전역 프로바이더를 씁니다. 전역 프로바이더를 설정하지
+// 않은 기본 상태는 noop이므로 스팬 비용은 거의 없습니다. 없는 키는 정상적인 조회 결과로 보고 오류로
+// 표시하지 않습니다. 컨텍스트가 없는 진입점(Get, Insert 등)은 스팬을 만들지 않습니다.
+
+// TracerName is the instrumentation scope of the spans created by GoLite.
+const TracerName = "github.com/sukryu/GoLite"
+
+// TracerProvider returns the provider of the database's spans.
+func (db *Database) TracerProvider() trace.TracerProvider {
+	return db.config.TracerProvider
+}
+
+// newTracer resolves the tracer provider of config.
+func newTracer(config *DatabaseConfig) trace.Tracer {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	return config.TracerProvider.Tracer(TracerName)
+}
+
+// startSpan starts the span of a storage operation on tableName.
+func (db *Database) startSpan(ctx context.Context, op, tableName string) (context.Context, trace.Span) {
+	return db.tracer.Start(ctx, "golite.storage."+op, trace.WithAttributes(
+		attribute.String("db.system", "golite"),
+		attribute.String("db.name", db.config.Name),
+		attribute.String("golite.table", tableName),
+		attribute.String("golite.adapter", fmt.Sprintf("%T", db.storage)),
+	))
+}
+
+// EndSpan records err on span, unless it only reports a missing key, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ports.ErrKeyNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
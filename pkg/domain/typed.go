@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 값은 저장소에 바이트열로 저장됩니다. InsertBytes/GetBytes는 이를 그대로 다루며 NUL이나
+// 잘못된 UTF-8이 포함된 값도 그대로 보존합니다. InsertValue/GetValue는 DatabaseConfig.Codec
+// (기본 JSON)으로 Go 값을 직렬화하므로, 같은 키를 읽을 때는 쓸 때와 같은 코덱을 사용해야 합니다.
+
+// InsertBytes inserts a raw byte value into a table.
+func (db *Database) InsertBytes(tableName, key string, value []byte) error {
+	return db.Insert(tableName, key, string(value))
+}
+
+// GetBytes retrieves a raw byte value from a table. The returned slice is owned by the caller.
+func (db *Database) GetBytes(tableName, key string) ([]byte, error) {
+	if other, table, ok := db.attachedTable(tableName); ok {
+		return other.GetBytes(table, key)
+	}
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	spec, exists := db.spec.Tables[tableName]
+	if !exists {
+		return nil, fmt.Errorf("table %s not found", tableName)
+	}
+	if spec.Metadata || spec.Encrypted {
+		value, err := db.getRaw(tableName, key)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Metadata {
+			if _, value, err = decodeEntry(value); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(value), nil
+	}
+	storage, prefix := db.tableStorage(tableName)
+	value, err := ports.AsBytes(storage).GetBytes([]byte(prefix + key))
+	if err != nil {
+		return nil, err
+	}
+	// 어댑터가 캐시와 공유하는 슬라이스를 돌려줄 수 있으므로 복사해서 반환
+	return bytes.Clone(value), nil
+}
+
+// InsertValue serializes v with the database codec and inserts it into a table.
+func (db *Database) InsertValue(tableName, key string, v interface{}) error {
+	return db.InsertValueWithCodec(tableName, key, v, db.config.Codec)
+}
+
+// GetValue retrieves a value from a table and deserializes it into out (a pointer)
+// with the database codec.
+func (db *Database) GetValue(tableName, key string, out interface{}) error {
+	return db.GetValueWithCodec(tableName, key, out, db.config.Codec)
+}
+
+// InsertValueWithCodec serializes v with c and inserts it into a table.
+func (db *Database) InsertValueWithCodec(tableName, key string, v interface{}, c ports.Codec) error {
+	data, err := c.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode value for key %s with %s: %v", key, c.Name(), err)
+	}
+	return db.InsertBytes(tableName, key, data)
+}
+
+// GetValueWithCodec retrieves a value from a table and deserializes it into out with c.
+func (db *Database) GetValueWithCodec(tableName, key string, out interface{}, c ports.Codec) error {
+	data, err := db.GetBytes(tableName, key)
+	if err != nil {
+		return err
+	}
+	if err := c.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode value of key %s with %s: %v", key, c.Name(), err)
+	}
+	return nil
+}
+
+// GetOrDefault retrieves a value from a table, or def if the key does not exist. Other
+// errors, such as a missing table, are returned.
+func (db *Database) GetOrDefault(tableName, key, def string) (string, error) {
+	values, err := db.MultiGetOrDefault(tableName, []string{key}, def)
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+// MultiGetOrDefault retrieves the values of keys from a table in one read lock, using def
+// for keys that do not exist. It fails on the first other error.
+func (db *Database) MultiGetOrDefault(tableName string, keys []string, def string) (map[string]string, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return nil, fmt.Errorf("table %s not found", tableName)
+	}
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, err := db.get(tableName, key)
+		if errors.Is(err, ports.ErrKeyNotFound) {
+			value, err = def, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get key %s from table %s: %w", key, tableName, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// GetValueOrDefault is GetValue that leaves out unchanged if the key does not exist, so a
+// default stored in out beforehand is kept. It reports whether the key was found.
+func (db *Database) GetValueOrDefault(tableName, key string, out interface{}) (bool, error) {
+	err := db.GetValue(tableName, key, out)
+	if errors.Is(err, ports.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Codec returns the codec used by InsertValue and GetValue.
+func (db *Database) Codec() ports.Codec {
+	return db.config.Codec
+}
@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 레코드 버전 관리: DatabaseConfig.VersionHistory나 VersionRetention을 주면 사용자 테이블의 커밋된
+// 삽입과 삭제마다 그 키의 버전(값, 시각, 삭제 여부)을 시스템 테이블 VersionsTable에 남기고,
+// GetVersions로 최신 버전부터 돌려줍니다. 체인지피드처럼 변경과 같은 쓰기 잠금 안에서 기록되므로
+// 버전 순서는 커밋 순서와 같고 재시작 후에도 남습니다. 버전 번호는 키마다 1부터 증가합니다.
+//
+// 키마다 최신 VersionHistory개만 남기고(0 = 개수 제한 없음), VersionRetention보다 오래된 버전은
+// 지웁니다(0 = 기간 제한 없음). 정리는 그 키에 다시 쓸 때 하므로, 더 쓰이지 않는 키의 오래된 버전은
+// 남아 있지만 GetVersions는 보존 기간이 지난 버전을 돌려주지 않습니다. 테이블을 지우면 그 테이블의
+// 버전도 지웁니다. 시스템 테이블과 보조 테이블은 기록하지 않고, 암호화 테이블도 평문 값이 버전
+// 테이블에 남지 않도록 기록하지 않습니다. 버전 테이블은 일반 테이블처럼 복제되므로 복제본에서는
+// 버전 관리를 켜지 않고 복제된 버전을 읽습니다.
+
+// VersionsTable is the system table holding the versions of the keys.
+const VersionsTable = "__versions"
+
+// Version is one recorded version of a key.
+type Version struct {
+	Version uint64    `json:"v"`                 // Version number of the key, from 1
+	Value   string    `json:"value,omitempty"`   // Inserted value ("" for deletes)
+	Deleted bool      `json:"deleted,omitempty"` // The key was deleted
+	Time    time.Time `json:"ts"`                // Commit time
+}
+
+// versioning holds the retention of the recorded versions.
+type versioning struct {
+	history   int           // Versions kept per key (0 = all)
+	retention time.Duration // Age of the oldest version kept (0 = forever)
+}
+
+// versionTablePrefix is the prefix of the version keys of tableName. Lengths keep table and
+// key names containing separators unambiguous.
+func versionTablePrefix(tableName string) string {
+	return strconv.Itoa(len(tableName)) + ":" + tableName
+}
+
+// versionPrefix is the prefix of the version keys of key in tableName.
+func versionPrefix(tableName, key string) string {
+	return versionTablePrefix(tableName) + strconv.Itoa(len(key)) + ":" + key + "@"
+}
+
+// versionOf returns the version number of a version key with prefix, or false if the key
+// belongs to another key.
+func versionOf(prefix, versionKey string) (uint64, bool) {
+	suffix := strings.TrimPrefix(versionKey, prefix)
+	if len(suffix) != 20 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(suffix, 10, 64)
+	return v, err == nil
+}
+
+// loadVersions creates VersionsTable if needed. Called at open.
+func (db *Database) loadVersions() error {
+	if _, exists := db.spec.Tables[VersionsTable]; !exists {
+		if err := db.createTableLocked(VersionsTable, TableOptions{}); err != nil {
+			return fmt.Errorf("failed to create %s: %v", VersionsTable, err)
+		}
+	}
+	db.versions = &versioning{history: db.config.VersionHistory, retention: db.config.VersionRetention}
+	return nil
+}
+
+// versionSkips reports whether changes of tableName are left out of the versions.
+// Caller holds db.mu.
+func (db *Database) versionSkips(tableName string) bool {
+	if !quotaTable(tableName) {
+		return true
+	}
+	spec, ok := db.spec.Tables[tableName]
+	return ok && spec.Encrypted
+}
+
+// recordVersion records m as a new version of its key, or drops the versions of a dropped
+// table. Called by commit; caller holds db.mu.
+func (db *Database) recordVersion(m Mutation) {
+	var err error
+	switch m.Op {
+	case MutationInsert, MutationDelete:
+		if db.versionSkips(m.Table) {
+			return
+		}
+		err = db.appendVersion(m)
+	case MutationDropTable:
+		if strings.HasPrefix(m.Table, "__") {
+			return
+		}
+		err = db.deleteVersions(versionTablePrefix(m.Table))
+	default:
+		return
+	}
+	if err != nil {
+		db.logger.Error("Failed to record version", "table", m.Table, "key", m.Key, "error", err)
+		db.status.Error = fmt.Sprintf("failed to record %s of table %s in versions: %v", m.Op, m.Table, err)
+	}
+}
+
+// appendVersion stores the version of m and trims the versions of its key beyond the
+// retention. Caller holds db.mu.
+func (db *Database) appendVersion(m Mutation) error {
+	prefix := versionPrefix(m.Table, m.Key)
+	var keys []string
+	var last uint64
+	if err := db.scanLocked(VersionsTable, prefix, func(key, _ string) bool {
+		if v, ok := versionOf(prefix, key); ok {
+			keys = append(keys, key)
+			last = v
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	ver := Version{Version: last + 1, Deleted: m.Op == MutationDelete, Time: db.now()}
+	if !ver.Deleted {
+		ver.Value = m.Value
+	}
+	data, _ := json.Marshal(ver)
+	key := fmt.Sprintf("%s%020d", prefix, ver.Version)
+	if _, err := db.insertLocked(VersionsTable, key, string(data)); err != nil {
+		return err
+	}
+	keys = append(keys, key)
+
+	drop := 0
+	if h := db.versions.history; h > 0 && len(keys) > h {
+		drop = len(keys) - h
+	}
+	if db.versions.retention > 0 {
+		cutoff := ver.Time.Add(-db.versions.retention)
+		for drop < len(keys)-1 {
+			old, err := db.version(keys[drop])
+			if err != nil || !old.Time.Before(cutoff) {
+				break
+			}
+			drop++
+		}
+	}
+	for _, key := range keys[:drop] {
+		if _, err := db.deleteLocked(VersionsTable, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// version reads the version stored under versionKey. Caller holds db.mu.
+func (db *Database) version(versionKey string) (Version, error) {
+	var ver Version
+	data, err := db.get(VersionsTable, versionKey)
+	if err != nil {
+		return ver, err
+	}
+	if err := json.Unmarshal([]byte(data), &ver); err != nil {
+		return ver, fmt.Errorf("invalid version %s: %v", versionKey, err)
+	}
+	return ver, nil
+}
+
+// deleteVersions deletes the version keys with prefix. Caller holds db.mu.
+func (db *Database) deleteVersions(prefix string) error {
+	var keys []string
+	if err := db.scanLocked(VersionsTable, prefix, func(key, _ string) bool {
+		keys = append(keys, key)
+		return true
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, err := db.deleteLocked(VersionsTable, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetVersions returns the recorded versions of key in tableName, newest first, leaving
+// out versions older than DatabaseConfig.VersionRetention. It fails if versioning isn't
+// enabled.
+func (db *Database) GetVersions(tableName, key string) ([]Version, error) {
+	if db.config.ThreadSafe {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+	}
+	if db.versions == nil {
+		return nil, fmt.Errorf("versioning is not enabled in database %s", db.config.Name)
+	}
+	if _, exists := db.spec.Tables[tableName]; !exists {
+		return nil, fmt.Errorf("table %s not found", tableName)
+	}
+	var cutoff time.Time
+	if db.versions.retention > 0 {
+		cutoff = db.now().Add(-db.versions.retention)
+	}
+	prefix := versionPrefix(tableName, key)
+	var versions []Version
+	var decodeErr error
+	err := db.scanLocked(VersionsTable, prefix, func(k, value string) bool {
+		if _, ok := versionOf(prefix, k); !ok {
+			return true
+		}
+		var ver Version
+		if err := json.Unmarshal([]byte(value), &ver); err != nil {
+			decodeErr = fmt.Errorf("invalid version %s: %v", k, err)
+			return false
+		}
+		if ver.Time.Before(cutoff) {
+			return true
+		}
+		versions = append(versions, ver)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", VersionsTable, err)
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, decodeErr
+}
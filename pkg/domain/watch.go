@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// 키 변경 구독: AddStorageEventPort로 등록한 ports.StorageEventPort는 테이블의 커밋된 Insert와
+// Delete마다 OnInsert/OnDelete로 호출됩니다. 변경 리스너(AddMutationListener) 위에 있으므로
+// 호출은 쓰기 잠금 안에서 커밋 순서대로 일어나며, 구현은 막히거나 Database를 다시 호출하면 안 됩니다.
+//
+// Watch는 이를 채널로 바꾼 구현입니다. 접두어로 거른 변경을 버퍼 채널에 넣고, 버퍼가 가득 차면
+// 쓰기를 막지 않도록 변경을 버리고 Dropped로 셉니다(이벤트 버스와 같은 방식). 놓친 변경이 있으면
+// 구독자는 Scan으로 현재 상태를 다시 읽어야 합니다.
+
+// DefaultWatchBuffer is the channel capacity of a Watcher created with buffer <= 0.
+const DefaultWatchBuffer = 64
+
+// Change is a committed change delivered to a Watcher or Subscription.
+type Change struct {
+	Table    string
+	Key      string
+	Value    string // Inserted value (empty for deletes)
+	Deleted  bool
+	Snapshot bool // Existing entry sent by SubscribeFrom before the live changes
+}
+
+// AddStorageEventPort calls p after every committed insert into or delete from tableName,
+// with keys relative to the table. It returns a function that unregisters p.
+func (db *Database) AddStorageEventPort(tableName string, p ports.StorageEventPort) (remove func()) {
+	return db.AddMutationListener(func(m Mutation) {
+		if m.Table != tableName {
+			return
+		}
+		switch m.Op {
+		case MutationInsert:
+			p.OnInsert(m.Key, m.Value)
+		case MutationDelete:
+			p.OnDelete(m.Key)
+		}
+	})
+}
+
+// Watcher receives the changes of the keys of a table starting with a prefix on C until
+// Close is called.
+type Watcher struct {
+	C       <-chan Change
+	ch      chan Change
+	table   string
+	prefix  string
+	mu      sync.Mutex // Orders sends with Close
+	closed  bool
+	remove  func()
+	dropped atomic.Int64
+}
+
+var _ ports.StorageEventPort = (*Watcher)(nil)
+
+// Watch subscribes to the changes of the keys of tableName starting with prefix. buffer is
+// the channel capacity (DefaultWatchBuffer if <= 0); changes that do not fit are dropped.
+func (db *Database) Watch(tableName, prefix string, buffer int) *Watcher {
+	if buffer <= 0 {
+		buffer = DefaultWatchBuffer
+	}
+	ch := make(chan Change, buffer)
+	w := &Watcher{C: ch, ch: ch, table: tableName, prefix: prefix}
+	w.remove = db.AddStorageEventPort(tableName, w)
+	return w
+}
+
+// OnInsert implements ports.StorageEventPort.
+func (w *Watcher) OnInsert(key string, value interface{}) {
+	v, _ := ports.ValueString(value)
+	w.send(Change{Table: w.table, Key: key, Value: v})
+}
+
+// OnDelete implements ports.StorageEventPort.
+func (w *Watcher) OnDelete(key string) {
+	w.send(Change{Table: w.table, Key: key, Deleted: true})
+}
+
+func (w *Watcher) send(c Change) {
+	if !strings.HasPrefix(c.Key, w.prefix) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.ch <- c:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of changes dropped because C was full.
+func (w *Watcher) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close stops the watcher and closes C. It is safe to call more than once.
+func (w *Watcher) Close() {
+	w.remove()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.ch)
+}
@@ -0,0 +1,125 @@
+// Package events는 GoLite 내부의 라이프사이클 이벤트를 전달하는 인프로세스 pub/sub 버스를 제공합니다.
+// 도메인과 어댑터가 이벤트를 발행하고, 호스트 애플리케이션은 Subscribe로 구독합니다.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type identifies the kind of lifecycle event.
+type Type string
+
+const (
+	TableCreated       Type = "table.created"
+	TableDropped       Type = "table.dropped"
+	CompactionStarted  Type = "compaction.started"
+	CompactionFinished Type = "compaction.finished"
+	FlushCompleted     Type = "flush.completed"
+	BackupTaken        Type = "backup.taken"
+	LimitWarning       Type = "limit.warning"
+	MigrationApplied   Type = "migration.applied"
+	RebuildProgress    Type = "rebuild.progress"
+	LoadShedding       Type = "load.shedding"
+)
+
+// Event is a structured lifecycle notification.
+type Event struct {
+	Type    Type                   // Kind of event
+	Source  string                 // Emitting component (database name or adapter)
+	Table   string                 // Affected table, if any
+	Time    time.Time              // When the event occurred
+	Err     error                  // Failure cause for "finished" events, if any
+	Details map[string]interface{} // Event-specific attributes
+}
+
+// DefaultBufferSize is the per-subscriber channel capacity used when Subscribe gets buffer <= 0.
+const DefaultBufferSize = 64
+
+// Bus fans out published events to subscribers.
+// Publish never blocks: events for a subscriber whose buffer is full are dropped and counted.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[uint64]*Subscription
+	nextID  uint64
+	dropped atomic.Int64
+}
+
+// Subscription receives events matching its type filter on C until Close is called.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	id     uint64
+	types  map[Type]struct{}
+	bus    *Bus
+	closed bool
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[uint64]*Subscription)}
+}
+
+// Subscribe registers a subscriber for the given event types (all types if none given).
+func (b *Bus) Subscribe(buffer int, types ...Type) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBufferSize
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, bus: b}
+	if len(types) > 0 {
+		sub.types = make(map[Type]struct{}, len(types))
+		for _, t := range types {
+			sub.types[t] = struct{}{}
+		}
+	}
+	b.mu.Lock()
+	b.nextID++
+	sub.id = b.nextID
+	b.subs[sub.id] = sub
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish delivers the event to all matching subscribers without blocking.
+// A nil Bus is valid and discards events, so components can publish unconditionally.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subs {
+		if sub.types != nil {
+			if _, ok := sub.types[e.Type]; !ok {
+				continue
+			}
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because a subscriber was too slow.
+func (b *Bus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Close unregisters the subscription and closes its channel.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(s.bus.subs, s.id)
+	close(s.ch)
+}
@@ -0,0 +1,304 @@
+// Package keydict는 테이블과 저장소 어댑터가 공유하는 키 prefix 압축 사전을 제공합니다.
+//
+// "service:env:tenant:uuid"처럼 장황한 키 스킴은 대부분의 바이트가 반복되는 prefix입니다.
+// Dictionary는 런타임에 관찰한 키에서 자주 등장하는 구분자 단위 prefix(hot prefix)를 찾아
+// 최대 255개까지 ID를 부여하고, 키를 (dictID, suffix) 형태로 저장할 수 있게 합니다.
+// 사전은 추가만 가능(append-only)하므로 한 번 기록된 키는 항상 다시 해석할 수 있습니다.
+package keydict
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// EncodedFlag is set in a stored 16-bit key length when the key bytes are dictionary-encoded.
+// Raw keys must therefore be shorter than EncodedFlag bytes.
+const EncodedFlag uint16 = 0x8000
+
+// MaxEntries is the maximum number of prefixes a dictionary can hold (IDs 1..255).
+const MaxEntries = 255
+
+// ErrUnknownID is returned when an encoded key references a prefix the dictionary doesn't have.
+var ErrUnknownID = errors.New("unknown key dictionary id")
+
+var fileMagic = []byte("GLKD")
+
+// Config tunes hot-prefix detection.
+type Config struct {
+	Threshold     int    // Observations before a prefix is promoted (default 64)
+	MinPrefixLen  int    // Shortest prefix worth a dictionary entry (default 4)
+	MaxCandidates int    // Candidate prefixes tracked at once (default 1024)
+	SampleEvery   int    // Observe one in N keys (default 1 = every key)
+	Delimiters    string // Characters ending a prefix (default ":/.|")
+}
+
+// Dictionary maps hot key prefixes to one-byte IDs. It is safe for concurrent use.
+type Dictionary struct {
+	config Config
+	path   string // Persistence file ("" = in memory only)
+
+	mu       sync.RWMutex
+	prefixes []string         // ID-1 -> prefix
+	ids      map[string]uint8 // prefix -> ID
+
+	candMu     sync.Mutex
+	candidates map[string]int
+
+	observed   atomic.Uint64
+	encoded    atomic.Uint64
+	bytesSaved atomic.Uint64
+}
+
+// New creates an in-memory dictionary.
+func New(config Config) *Dictionary {
+	if config.Threshold <= 0 {
+		config.Threshold = 64
+	}
+	if config.MinPrefixLen <= 0 {
+		config.MinPrefixLen = 4
+	}
+	if config.MaxCandidates <= 0 {
+		config.MaxCandidates = 1024
+	}
+	if config.SampleEvery <= 0 {
+		config.SampleEvery = 1
+	}
+	if config.Delimiters == "" {
+		config.Delimiters = ":/.|"
+	}
+	return &Dictionary{
+		config:     config,
+		ids:        make(map[string]uint8),
+		candidates: make(map[string]int),
+	}
+}
+
+// Open loads the dictionary persisted at path, creating an empty one if the file doesn't exist.
+// New prefixes are written to path before they are used for encoding.
+func Open(path string, config Config) (*Dictionary, error) {
+	d := New(config)
+	d.path = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key dictionary: %v", err)
+	}
+	if err := d.decodeFile(data); err != nil {
+		return nil, fmt.Errorf("failed to load key dictionary %s: %v", path, err)
+	}
+	return d, nil
+}
+
+// Prefixes returns the dictionary entries in ID order (ID = index + 1).
+func (d *Dictionary) Prefixes() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]string(nil), d.prefixes...)
+}
+
+// Add registers prefix explicitly and returns its ID.
+func (d *Dictionary) Add(prefix string) (uint8, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if id, ok := d.ids[prefix]; ok {
+		return id, nil
+	}
+	if len(d.prefixes) >= MaxEntries {
+		return 0, fmt.Errorf("key dictionary is full (%d entries)", MaxEntries)
+	}
+	if len(prefix) < 2 || len(prefix) >= int(EncodedFlag) {
+		return 0, fmt.Errorf("invalid dictionary prefix length %d", len(prefix))
+	}
+	d.prefixes = append(d.prefixes, prefix)
+	// 사전을 먼저 영속화해야 이 ID를 참조하는 페이지가 디스크에 기록될 수 있음.
+	if err := d.save(); err != nil {
+		d.prefixes = d.prefixes[:len(d.prefixes)-1]
+		return 0, err
+	}
+	id := uint8(len(d.prefixes))
+	d.ids[prefix] = id
+	return id, nil
+}
+
+// Observe feeds a written key to hot-prefix detection. Prefixes ending at a delimiter that
+// were seen Threshold times are promoted to the dictionary.
+func (d *Dictionary) Observe(key string) {
+	if d == nil {
+		return
+	}
+	if n := d.observed.Add(1); n%uint64(d.config.SampleEvery) != 0 {
+		return
+	}
+	var promote []string
+	d.candMu.Lock()
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(d.config.Delimiters, key[i]) < 0 || i+1 < d.config.MinPrefixLen {
+			continue
+		}
+		prefix := key[:i+1]
+		if d.known(prefix) {
+			continue
+		}
+		if _, ok := d.candidates[prefix]; !ok && len(d.candidates) >= d.config.MaxCandidates {
+			d.decayCandidates()
+		}
+		d.candidates[prefix]++
+		if d.candidates[prefix] >= d.config.Threshold {
+			delete(d.candidates, prefix)
+			promote = append(promote, strings.Clone(prefix))
+		}
+	}
+	d.candMu.Unlock()
+	for _, p := range promote {
+		d.Add(p) // 가득 찬 경우 등은 무시: 압축은 최적화일 뿐
+	}
+}
+
+// decayCandidates halves all candidate counts and drops the ones reaching zero, keeping
+// memory bounded while favouring recently hot prefixes. Caller holds candMu.
+func (d *Dictionary) decayCandidates() {
+	for p, n := range d.candidates {
+		if n /= 2; n == 0 {
+			delete(d.candidates, p)
+		} else {
+			d.candidates[p] = n
+		}
+	}
+	if len(d.candidates) >= d.config.MaxCandidates {
+		for p := range d.candidates {
+			delete(d.candidates, p)
+			if len(d.candidates) < d.config.MaxCandidates/2 {
+				break
+			}
+		}
+	}
+}
+
+func (d *Dictionary) known(prefix string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.ids[prefix]
+	return ok
+}
+
+// Encode returns the stored form of key: the ID byte of its longest dictionary prefix followed
+// by the suffix. It reports false (and returns key unchanged) when no prefix applies.
+func (d *Dictionary) Encode(key string) (string, bool) {
+	if d == nil {
+		return key, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.ids) == 0 {
+		return key, false
+	}
+	// 사전의 모든 prefix는 구분자로 끝나므로 구분자 위치만 뒤에서부터 확인하면 됨.
+	for i := len(key) - 1; i >= 1; i-- {
+		if strings.IndexByte(d.config.Delimiters, key[i]) < 0 {
+			continue
+		}
+		if id, ok := d.ids[key[:i+1]]; ok {
+			d.encoded.Add(1)
+			d.bytesSaved.Add(uint64(i))
+			return string([]byte{id}) + key[i+1:], true
+		}
+	}
+	return key, false
+}
+
+// Decode restores a key stored by Encode.
+func (d *Dictionary) Decode(stored string) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("%w: no key dictionary configured", ErrUnknownID)
+	}
+	if stored == "" {
+		return "", fmt.Errorf("%w: empty encoded key", ErrUnknownID)
+	}
+	id := int(stored[0])
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if id == 0 || id > len(d.prefixes) {
+		return "", fmt.Errorf("%w: %d", ErrUnknownID, id)
+	}
+	return d.prefixes[id-1] + stored[1:], nil
+}
+
+// Stats returns dictionary statistics.
+func (d *Dictionary) Stats() map[string]interface{} {
+	d.mu.RLock()
+	entries := len(d.prefixes)
+	d.mu.RUnlock()
+	d.candMu.Lock()
+	candidates := len(d.candidates)
+	d.candMu.Unlock()
+	return map[string]interface{}{
+		"entries":      entries,
+		"candidates":   candidates,
+		"observed":     d.observed.Load(),
+		"encoded_keys": d.encoded.Load(),
+		"bytes_saved":  d.bytesSaved.Load(),
+	}
+}
+
+// byteOrder is the byte order of the length fields in a dictionary file.
+var byteOrder = binary.LittleEndian
+
+// save writes the dictionary atomically. Caller holds d.mu.
+func (d *Dictionary) save() error {
+	if d.path == "" {
+		return nil
+	}
+	buf := bytes.NewBuffer(nil)
+	buf.Write(fileMagic)
+	binary.Write(buf, byteOrder, uint16(len(d.prefixes)))
+	for _, p := range d.prefixes {
+		binary.Write(buf, byteOrder, uint16(len(p)))
+		buf.WriteString(p)
+	}
+	if err := utils.WriteFileAtomic(d.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to save key dictionary: %v", err)
+	}
+	return nil
+}
+
+func (d *Dictionary) decodeFile(data []byte) error {
+	r := bytes.NewReader(data)
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, fileMagic) {
+		return fmt.Errorf("bad magic")
+	}
+	var count uint16
+	if err := binary.Read(r, byteOrder, &count); err != nil {
+		return err
+	}
+	if count > MaxEntries {
+		return fmt.Errorf("too many entries: %d", count)
+	}
+	for i := uint16(0); i < count; i++ {
+		var n uint16
+		if err := binary.Read(r, byteOrder, &n); err != nil {
+			return err
+		}
+		p := make([]byte, n)
+		if _, err := io.ReadFull(r, p); err != nil {
+			return err
+		}
+		d.prefixes = append(d.prefixes, string(p))
+		d.ids[string(p)] = uint8(len(d.prefixes))
+	}
+	return nil
+}
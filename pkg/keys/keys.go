@@ -0,0 +1,232 @@
+// Package keys는 B-tree의 문자열(바이트) 순서가 값의 순서와 같아지도록 정수, 실수, 시각과 이들의
+// 튜플을 키로 인코딩합니다.
+//
+// 숫자를 strconv로 키에 넣으면 "10" < "9"처럼 사전 순으로 정렬되어 범위 스캔이 틀어집니다. 이 패키지의
+// 인코딩은 고정 길이 빅엔디언에 부호 비트를 뒤집어 음수가 양수 앞에 오게 하므로, 인코딩한 키의 바이트
+// 비교가 곧 값의 비교입니다. 인코딩은 사람이 읽을 수 없는 바이너리이며, Decode 함수로 되돌립니다.
+//
+// Tuple은 여러 값을 이어 붙여 ("user", 42, 시각)처럼 복합 키를 만듭니다. 각 원소는 타입 태그로
+// 시작하고 문자열은 0x00을 이스케이프한 뒤 종료 표시(0x00 0x01)로 끝나므로, 앞쪽 원소만 인코딩한 튜플은 그
+// 원소들로 시작하는 모든 키의 접두어가 되어 Scan의 prefix로 쓸 수 있습니다. 튜플은 원소별로 정렬되며
+// 서로 다른 타입의 원소는 태그 순서(문자열 < 부호 있는 정수 < 부호 없는 정수 < 실수 < 시각)로 정렬됩니다.
+package keys
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey is returned when decoding a key that wasn't produced by this package.
+var ErrInvalidKey = errors.New("invalid encoded key")
+
+// Type tags of tuple elements, in sort order.
+const (
+	tagString byte = 0x01
+	tagInt    byte = 0x02
+	tagUint   byte = 0x03
+	tagFloat  byte = 0x04
+	tagTime   byte = 0x05
+)
+
+// A 0x00 byte inside a string element is followed by stringEscape; the element ends with
+// 0x00 followed by stringEnd, which sorts before any escaped 0x00 or continuation. Unlike a
+// bare 0x00 terminator, no string's element is then a prefix of another string's element.
+const (
+	stringEnd    byte = 0x01
+	stringEscape byte = 0xff
+)
+
+// Int64 encodes v so that encodings sort like the integers.
+func Int64(v int64) string {
+	return Uint64(uint64(v) ^ 1<<63)
+}
+
+// DecodeInt64 decodes an Int64 encoding.
+func DecodeInt64(s string) (int64, error) {
+	u, err := DecodeUint64(s)
+	return int64(u ^ 1<<63), err
+}
+
+// Uint64 encodes v so that encodings sort like the integers.
+func Uint64(v uint64) string {
+	return string(binary.BigEndian.AppendUint64(nil, v))
+}
+
+// DecodeUint64 decodes a Uint64 encoding.
+func DecodeUint64(s string) (uint64, error) {
+	if len(s) != 8 {
+		return 0, fmt.Errorf("%w: want 8 bytes, got %d", ErrInvalidKey, len(s))
+	}
+	return binary.BigEndian.Uint64([]byte(s)), nil
+}
+
+// Float64 encodes v so that encodings sort like the numbers, with -Inf first and +Inf
+// last. -0 sorts before +0, and NaNs sort after +Inf (or before -Inf with the sign bit set).
+func Float64(v float64) string {
+	return Uint64(floatBits(v))
+}
+
+// DecodeFloat64 decodes a Float64 encoding.
+func DecodeFloat64(s string) (float64, error) {
+	u, err := DecodeUint64(s)
+	if err != nil {
+		return 0, err
+	}
+	return bitsFloat(u), nil
+}
+
+// floatBits flips the sign bit of positive numbers and every bit of negative ones, so that
+// the bits sort like the numbers.
+func floatBits(v float64) uint64 {
+	b := math.Float64bits(v)
+	if b&(1<<63) != 0 {
+		return ^b
+	}
+	return b | 1<<63
+}
+
+func bitsFloat(u uint64) float64 {
+	if u&(1<<63) != 0 {
+		return math.Float64frombits(u &^ (1 << 63))
+	}
+	return math.Float64frombits(^u)
+}
+
+// Time encodes t as its Unix time in nanoseconds (see Int64), so that encodings sort like
+// the instants. The location and monotonic reading of t are not kept, and t must lie
+// between the years 1678 and 2262.
+func Time(t time.Time) string {
+	return Int64(t.UnixNano())
+}
+
+// DecodeTime decodes a Time encoding into a time in the local location.
+func DecodeTime(s string) (time.Time, error) {
+	n, err := DecodeInt64(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n), nil
+}
+
+// Tuple encodes elems so that tuples sort element by element. Elements may be strings,
+// []byte, signed integers (int, int8 ... int64), unsigned integers (uint ... uint64),
+// float32, float64 and time.Time. The encoding of the first elements of a tuple is a
+// prefix of the encoding of the whole tuple.
+func Tuple(elems ...interface{}) (string, error) {
+	var b strings.Builder
+	for i, e := range elems {
+		switch v := e.(type) {
+		case string:
+			appendString(&b, v)
+		case []byte:
+			appendString(&b, string(v))
+		case int:
+			appendTagged(&b, tagInt, Int64(int64(v)))
+		case int8:
+			appendTagged(&b, tagInt, Int64(int64(v)))
+		case int16:
+			appendTagged(&b, tagInt, Int64(int64(v)))
+		case int32:
+			appendTagged(&b, tagInt, Int64(int64(v)))
+		case int64:
+			appendTagged(&b, tagInt, Int64(v))
+		case uint:
+			appendTagged(&b, tagUint, Uint64(uint64(v)))
+		case uint8:
+			appendTagged(&b, tagUint, Uint64(uint64(v)))
+		case uint16:
+			appendTagged(&b, tagUint, Uint64(uint64(v)))
+		case uint32:
+			appendTagged(&b, tagUint, Uint64(uint64(v)))
+		case uint64:
+			appendTagged(&b, tagUint, Uint64(v))
+		case float32:
+			appendTagged(&b, tagFloat, Float64(float64(v)))
+		case float64:
+			appendTagged(&b, tagFloat, Float64(v))
+		case time.Time:
+			appendTagged(&b, tagTime, Time(v))
+		default:
+			return "", fmt.Errorf("tuple element %d: unsupported type %T", i, e)
+		}
+	}
+	return b.String(), nil
+}
+
+func appendTagged(b *strings.Builder, tag byte, enc string) {
+	b.WriteByte(tag)
+	b.WriteString(enc)
+}
+
+// appendString writes a string element with its 0x00 bytes escaped and its terminator.
+func appendString(b *strings.Builder, s string) {
+	b.WriteByte(tagString)
+	for i := 0; i < len(s); i++ {
+		b.WriteByte(s[i])
+		if s[i] == 0 {
+			b.WriteByte(stringEscape)
+		}
+	}
+	b.WriteByte(0)
+	b.WriteByte(stringEnd)
+}
+
+// DecodeTuple decodes a Tuple encoding. Strings and []byte decode as string, signed
+// integers as int64, unsigned integers as uint64, floats as float64 and times as time.Time.
+func DecodeTuple(s string) ([]interface{}, error) {
+	var elems []interface{}
+	for len(s) > 0 {
+		tag := s[0]
+		s = s[1:]
+		switch tag {
+		case tagString:
+			var b strings.Builder
+			for {
+				i := strings.IndexByte(s, 0)
+				if i < 0 || i+1 == len(s) {
+					return nil, fmt.Errorf("%w: unterminated string in tuple", ErrInvalidKey)
+				}
+				b.WriteString(s[:i])
+				switch s[i+1] {
+				case stringEscape:
+					b.WriteByte(0)
+					s = s[i+2:]
+					continue
+				case stringEnd:
+					s = s[i+2:]
+				default:
+					return nil, fmt.Errorf("%w: invalid escape in tuple string", ErrInvalidKey)
+				}
+				break
+			}
+			elems = append(elems, b.String())
+		case tagInt, tagUint, tagFloat, tagTime:
+			if len(s) < 8 {
+				return nil, fmt.Errorf("%w: truncated tuple element", ErrInvalidKey)
+			}
+			enc := s[:8]
+			s = s[8:]
+			switch tag {
+			case tagInt:
+				v, _ := DecodeInt64(enc)
+				elems = append(elems, v)
+			case tagUint:
+				v, _ := DecodeUint64(enc)
+				elems = append(elems, v)
+			case tagFloat:
+				v, _ := DecodeFloat64(enc)
+				elems = append(elems, v)
+			case tagTime:
+				v, _ := DecodeTime(enc)
+				elems = append(elems, v)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown tuple tag 0x%02x", ErrInvalidKey, tag)
+		}
+	}
+	return elems, nil
+}
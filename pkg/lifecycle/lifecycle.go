@@ -0,0 +1,95 @@
+// Package lifecycle는 서버를 이루는 구성 요소(핸들러, 아웃박스, 복제, 캡처, 데이터베이스)를 의존
+// 순서대로 종료합니다. 구성 요소는 시작한 순서대로 Add로 등록하고, Shutdown은 역순으로 하나씩
+// 닫아 앞 단계가 끝난 뒤에야 그 단계가 쓰던 구성 요소를 닫습니다: 핸들러의 명령이 모두 끝난 뒤
+// 데이터베이스를 닫고, 데이터베이스는 Close 안에서 다시 저장소(WAL 워커 정지와 플러시) → 파일
+// 순서로 닫습니다.
+//
+// Shutdown의 ctx가 끝나면 진행 중인 단계를 더 기다리지 않고 남은 단계를 건너뛴 채 오류를
+// 반환합니다. 닫히지 않은 저장소는 비정상 종료와 같은 상태이며 다음 시작 때 WAL 재생으로
+// 복구되므로, 호출자는 그대로 프로세스를 끝내면 됩니다.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// stage is a registered component.
+type stage struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// Manager shuts down components in the reverse order of their registration.
+type Manager struct {
+	logger utils.Logger
+	mu     sync.Mutex
+	stages []stage
+	done   bool
+}
+
+// New creates a Manager that logs the shutdown to logger.
+func New(logger utils.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Add registers a component that depends on the ones registered before it; Shutdown calls
+// close before theirs. close should stop waiting once ctx is done.
+func (m *Manager) Add(name string, close func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages = append(m.stages, stage{name: name, close: close})
+}
+
+// AddCloser registers a component closed by c.Close.
+func (m *Manager) AddCloser(name string, c io.Closer) {
+	m.Add(name, func(context.Context) error { return c.Close() })
+}
+
+// Shutdown closes the registered components, last registered first, each once the previous
+// one has finished. A component that fails is logged and the next ones are still closed; the
+// errors are joined. If ctx ends while a component is closing, Shutdown returns without
+// closing the remaining ones. Later calls do nothing.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return nil
+	}
+	m.done = true
+	stages := m.stages
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(stages) - 1; i >= 0; i-- {
+		s := stages[i]
+		start := time.Now()
+		done := make(chan error, 1)
+		go func() { done <- s.close(ctx) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				m.logger.Error("Failed to shut down component", "component", s.name, "error", err)
+				errs = append(errs, fmt.Errorf("%s: %v", s.name, err))
+				continue
+			}
+			m.logger.Info("Component shut down", "component", s.name, "duration", time.Since(start))
+		case <-ctx.Done():
+			var skipped []string
+			for j := i - 1; j >= 0; j-- {
+				skipped = append(skipped, stages[j].name)
+			}
+			m.logger.Error("Shutdown timed out", "component", s.name, "skipped", strings.Join(skipped, ","))
+			errs = append(errs, fmt.Errorf("shutdown of %s did not finish: %w", s.name, ctx.Err()))
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// DefaultBatchSize is the number of rows Import writes per batch when none is given.
+const DefaultBatchSize = 1000
+
+// Rows calls fn with the key and JSON value of every row of t (see the package documentation),
+// in rowid order, until fn returns an error.
+func (db *DB) Rows(t Table, fn func(key string, value []byte) error) error {
+	if t.WithoutRowid {
+		return fmt.Errorf("%w: table %s is a WITHOUT ROWID table", ErrUnsupported, t.Name)
+	}
+	pk := -1
+	for i, c := range t.Columns {
+		if c == t.PrimaryKey {
+			pk = i
+		}
+	}
+	var buf bytes.Buffer
+	return db.walkTable(t.rootPage, func(rowid int64, payload []byte) error {
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return fmt.Errorf("table %s, row %d: %v", t.Name, rowid, err)
+		}
+		buf.Reset()
+		buf.WriteByte('{')
+		for i, col := range t.Columns {
+			var v interface{}
+			if i < len(values) {
+				v = values[i]
+			}
+			if col == t.RowidAlias {
+				v = rowid // rowid 별칭은 레코드에 NULL로 저장됨
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(col)
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("table %s, row %d, column %s: %v", t.Name, rowid, col, err)
+			}
+			buf.Write(name)
+			buf.WriteByte(':')
+			buf.Write(data)
+		}
+		buf.WriteByte('}')
+
+		key := strconv.FormatInt(rowid, 10)
+		if pk >= 0 && pk < len(values) && values[pk] != nil {
+			key = keyString(values[pk])
+		}
+		return fn(key, buf.Bytes())
+	})
+}
+
+// keyString formats a primary key value as a GoLite key.
+func keyString(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return string(v)
+	}
+	return v.(string)
+}
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	Tables    []string // SQLite tables to import (all if empty)
+	Prefix    string   // Prepended to the name of every GoLite table written
+	BatchSize int      // Rows per write batch (DefaultBatchSize if <= 0)
+}
+
+// TableResult reports the import of one SQLite table.
+type TableResult struct {
+	Table   string // SQLite table name
+	Target  string // GoLite table name
+	Rows    int    // Rows written
+	Skipped error  // Why the table was not imported (nil if it was)
+}
+
+// Import copies the tables of src into dst as described in the package documentation,
+// creating the GoLite tables that don't exist. Tables this reader can't import are reported
+// in their TableResult and skipped; other errors stop the import.
+func Import(ctx context.Context, src *DB, dst *domain.Database, opts ImportOptions) ([]TableResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	tables := src.Tables()
+	if len(opts.Tables) > 0 {
+		byName := make(map[string]Table, len(tables))
+		for _, t := range tables {
+			byName[t.Name] = t
+		}
+		tables = tables[:0]
+		for _, name := range opts.Tables {
+			t, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("SQLite table %s not found", name)
+			}
+			tables = append(tables, t)
+		}
+	}
+
+	var results []TableResult
+	for _, t := range tables {
+		result := TableResult{Table: t.Name, Target: opts.Prefix + t.Name}
+		if t.WithoutRowid {
+			result.Skipped = fmt.Errorf("%w: WITHOUT ROWID table", ErrUnsupported)
+			results = append(results, result)
+			continue
+		}
+		if err := dst.CreateTableContext(ctx, result.Target); err != nil && !errors.Is(err, domain.ErrTableExists) {
+			return results, fmt.Errorf("failed to create table %s: %v", result.Target, err)
+		}
+		batch := domain.NewWriteBatch()
+		flush := func() error {
+			if batch.Len() == 0 {
+				return nil
+			}
+			if err := dst.WriteContext(ctx, batch, domain.WriteAck{}); err != nil {
+				return fmt.Errorf("failed to import into table %s: %v", result.Target, err)
+			}
+			result.Rows += batch.Len()
+			batch = domain.NewWriteBatch()
+			return nil
+		}
+		err := src.Rows(t, func(key string, value []byte) error {
+			batch.Insert(result.Target, key, string(value))
+			if batch.Len() >= opts.BatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		results = append(results, result)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// readVarint decodes an SQLite varint (big-endian, 7 bits per byte, the ninth byte carrying 8
+// bits) and returns it with its length, or a length of 0 if b is too short.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
+
+// decodeRecord decodes a record into its values: nil, int64, float64, string (TEXT) or
+// []byte (BLOB).
+func decodeRecord(payload []byte) ([]interface{}, error) {
+	headerSize, k := readVarint(payload)
+	if k == 0 || headerSize > uint64(len(payload)) || headerSize < uint64(k) {
+		return nil, fmt.Errorf("invalid record header size")
+	}
+	header := payload[k:headerSize]
+	body := payload[headerSize:]
+	var values []interface{}
+	for len(header) > 0 {
+		serial, n := readVarint(header)
+		if n == 0 {
+			return nil, fmt.Errorf("invalid serial type in record header")
+		}
+		header = header[n:]
+		size := serialSize(serial)
+		if size > uint64(len(body)) {
+			return nil, fmt.Errorf("record value of %d bytes is cut short", size)
+		}
+		data := body[:size]
+		body = body[size:]
+		switch {
+		case serial == 0:
+			values = append(values, nil)
+		case serial >= 1 && serial <= 6:
+			// 부호 있는 빅 엔디안 정수: 최상위 바이트부터 부호 확장
+			v := int64(int8(data[0]))
+			for _, b := range data[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case serial == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(data)))
+		case serial == 8:
+			values = append(values, int64(0))
+		case serial == 9:
+			values = append(values, int64(1))
+		case serial >= 12 && serial%2 == 0:
+			values = append(values, append([]byte(nil), data...))
+		case serial >= 13:
+			values = append(values, string(data))
+		default:
+			return nil, fmt.Errorf("reserved serial type %d", serial)
+		}
+	}
+	return values, nil
+}
+
+// serialSize returns the size of a value of the given serial type.
+func serialSize(serial uint64) uint64 {
+	switch serial {
+	case 0, 8, 9, 10, 11:
+		return 0
+	case 1, 2, 3, 4:
+		return serial
+	case 5:
+		return 6
+	case 6, 7:
+		return 8
+	}
+	return (serial - 12) / 2
+}
@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Table is a table of the SQLite schema.
+type Table struct {
+	Name string
+	// Columns are the stored columns in declaration order. VIRTUAL generated columns have no
+	// value in the rows and are left out.
+	Columns []string
+	// PrimaryKey is the column whose value keys the imported rows; "" keys them by rowid.
+	PrimaryKey string
+	// RowidAlias is the INTEGER PRIMARY KEY column, whose value is the rowid ("" if none).
+	RowidAlias   string
+	WithoutRowid bool
+	rootPage     uint32
+}
+
+// readSchema reads the tables of sqlite_schema (the table B-tree on page 1).
+func (db *DB) readSchema() error {
+	return db.walkTable(1, func(rowid int64, payload []byte) error {
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return fmt.Errorf("schema row %d: %v", rowid, err)
+		}
+		if len(values) < 5 {
+			return fmt.Errorf("schema row %d has %d columns", rowid, len(values))
+		}
+		kind, _ := values[0].(string)
+		name, _ := values[1].(string)
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		// 내부 테이블(sqlite_sequence 등)과 root가 없는 가상 테이블은 가져오지 않음
+		if kind != "table" || strings.HasPrefix(name, "sqlite_") || root <= 0 {
+			return nil
+		}
+		t, err := parseCreateTable(sql)
+		if err != nil {
+			return fmt.Errorf("table %s: %v", name, err)
+		}
+		t.Name = name
+		t.rootPage = uint32(root)
+		db.tables = append(db.tables, t)
+		return nil
+	})
+}
+
+// parseCreateTable extracts the columns and primary key from a CREATE TABLE statement as
+// stored in sqlite_schema.
+func parseCreateTable(sql string) (Table, error) {
+	var t Table
+	tokens := tokenize(sql)
+	open := -1
+	for i, tok := range tokens {
+		if tok == "(" {
+			open = i
+			break
+		}
+	}
+	if open < 0 {
+		return t, fmt.Errorf("no column list in %q", sql)
+	}
+	// 최상위 괄호 안을 쉼표로 나눠 열 정의와 테이블 제약 조건으로 분리
+	var defs [][]string
+	depth, start, end := 0, open+1, -1
+	for i := open; i < len(tokens) && end < 0; i++ {
+		switch tokens[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				defs = append(defs, tokens[start:i])
+				end = i
+			}
+		case ",":
+			if depth == 1 {
+				defs = append(defs, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if end < 0 {
+		return t, fmt.Errorf("unbalanced parentheses in %q", sql)
+	}
+	for i := end + 1; i+1 < len(tokens); i++ {
+		if strings.EqualFold(tokens[i], "WITHOUT") && strings.EqualFold(tokens[i+1], "ROWID") {
+			t.WithoutRowid = true
+		}
+	}
+
+	types := make(map[string]string)
+	var pk []string
+	for _, def := range defs {
+		if len(def) == 0 {
+			continue
+		}
+		switch strings.ToUpper(def[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			if cols := tablePrimaryKey(def); cols != nil {
+				pk = cols
+			}
+			continue
+		}
+		name := unquote(def[0])
+		if isVirtualColumn(def) {
+			continue
+		}
+		t.Columns = append(t.Columns, name)
+		// 타입 이름은 이름 뒤의 식별자들 (제약 조건 키워드 전까지)
+		var typ []string
+		for _, tok := range def[1:] {
+			if isConstraintKeyword(tok) || tok == "(" {
+				break
+			}
+			typ = append(typ, strings.ToUpper(tok))
+		}
+		types[name] = strings.Join(typ, " ")
+		for i := 1; i+1 < len(def); i++ {
+			if strings.EqualFold(def[i], "PRIMARY") && strings.EqualFold(def[i+1], "KEY") {
+				pk = []string{name}
+			}
+		}
+	}
+	if len(pk) == 1 {
+		t.PrimaryKey = pk[0]
+		if types[pk[0]] == "INTEGER" && !t.WithoutRowid {
+			t.RowidAlias = pk[0]
+			t.PrimaryKey = "" // rowid 자체가 키
+		}
+	}
+	return t, nil
+}
+
+// tablePrimaryKey returns the columns of a PRIMARY KEY table constraint, or nil.
+func tablePrimaryKey(def []string) []string {
+	for i := 0; i+2 < len(def); i++ {
+		if !strings.EqualFold(def[i], "PRIMARY") || !strings.EqualFold(def[i+1], "KEY") || def[i+2] != "(" {
+			continue
+		}
+		var cols []string
+		expectName := true
+		for _, tok := range def[i+3:] {
+			if tok == ")" {
+				break
+			}
+			if tok == "," {
+				expectName = true
+				continue
+			}
+			if expectName { // COLLATE, ASC/DESC 등은 건너뜀
+				cols = append(cols, unquote(tok))
+				expectName = false
+			}
+		}
+		return cols
+	}
+	return nil
+}
+
+// isVirtualColumn reports whether a column definition is a VIRTUAL generated column
+// ("AS (expr)" without STORED), which has no value in the record.
+func isVirtualColumn(def []string) bool {
+	generated := false
+	for i := 1; i+1 < len(def); i++ {
+		if strings.EqualFold(def[i], "AS") && def[i+1] == "(" {
+			generated = true
+		}
+	}
+	return generated && !strings.EqualFold(def[len(def)-1], "STORED")
+}
+
+func isConstraintKeyword(tok string) bool {
+	switch strings.ToUpper(tok) {
+	case "CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK", "DEFAULT", "COLLATE",
+		"REFERENCES", "GENERATED", "AS":
+		return true
+	}
+	return false
+}
+
+// tokenize splits SQL into identifiers, quoted names, literals and single punctuation characters.
+func tokenize(sql string) []string {
+	var tokens []string
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == closing {
+					// 따옴표 두 개는 이스케이프된 따옴표
+					if closing != ']' && j+1 < len(sql) && sql[j+1] == closing {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, sql[i:min(j+1, len(sql))])
+			i = j + 1
+		case strings.IndexByte("(),;", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			j := i
+			for j < len(sql) && strings.IndexByte(" \t\n\r(),;\"'`[", sql[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, sql[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+// unquote strips the quotes of a quoted identifier.
+func unquote(name string) string {
+	if len(name) < 2 {
+		return name
+	}
+	switch q := name[0]; q {
+	case '"', '\'', '`':
+		if name[len(name)-1] == q {
+			return strings.ReplaceAll(name[1:len(name)-1], string([]byte{q, q}), string(q))
+		}
+	case '[':
+		if name[len(name)-1] == ']' {
+			return name[1 : len(name)-1]
+		}
+	}
+	return name
+}
@@ -0,0 +1,227 @@
+// Package sqlite는 SQLite 데이터베이스 파일을 직접 읽어 테이블의 행을 GoLite로 가져옵니다. SQLite
+// 라이브러리(cgo)에 의존하지 않고 파일 형식(https://www.sqlite.org/fileformat2.html)을 읽기 전용으로
+// 해석하므로, SQLite를 쓰던 애플리케이션이 데이터를 옮길 때 별도 도구가 필요 없습니다.
+//
+// 가져오는 방식 (Import):
+//
+//   - sqlite_schema의 일반 테이블마다 같은 이름의 GoLite 테이블에 행 하나를 키 하나로 저장합니다.
+//     sqlite_로 시작하는 내부 테이블, 가상 테이블, WITHOUT ROWID 테이블은 건너뜁니다.
+//   - 키는 선언된 단일 열 PRIMARY KEY의 값이고, 그런 열이 없거나(복합 키 포함) INTEGER PRIMARY KEY
+//     (rowid 별칭)이면 rowid를 10진수로 씁니다. GoLite 키는 바이트 순서로 정렬되므로 숫자 순서가
+//     아닙니다.
+//   - 값은 선언 순서대로 열 이름을 키로 하는 JSON 객체입니다. 정수와 실수는 숫자, TEXT는 문자열,
+//     BLOB은 base64 문자열, NULL은 null입니다. ALTER TABLE ADD COLUMN 이전에 기록된 행에 없는 열은
+//     기본값 대신 null이 됩니다.
+//
+// 읽기 전에 체크포인트되지 않은 WAL(-wal 파일)이 있으면 최신 내용이 아니므로 거부합니다. 텍스트
+// 인코딩은 UTF-8만 지원합니다.
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// headerMagic starts every SQLite database file.
+var headerMagic = []byte("SQLite format 3\x00")
+
+// Table B-tree page types.
+const (
+	pageInteriorTable = 0x05
+	pageLeafTable     = 0x0d
+)
+
+// ErrUnsupported is matched (errors.Is) by errors for SQLite features this reader can't import.
+var ErrUnsupported = errors.New("unsupported SQLite database")
+
+// DB is an SQLite database file opened for reading.
+type DB struct {
+	file     *os.File
+	pageSize int
+	usable   int // Page size minus the reserved bytes at the end of each page
+	pages    int // Database size in pages
+	tables   []Table
+}
+
+// Open opens the SQLite database at path and reads its schema.
+func Open(path string) (*DB, error) {
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() > 0 {
+		return nil, fmt.Errorf("%w: %s-wal is not checkpointed (run PRAGMA wal_checkpoint(TRUNCATE) first)", ErrUnsupported, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{file: f}
+	if err := db.readHeader(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open SQLite database %s: %w", path, err)
+	}
+	if err := db.readSchema(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read schema of %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Close closes the database file.
+func (db *DB) Close() error {
+	return db.file.Close()
+}
+
+// Tables returns the tables of the schema in the order they were created.
+func (db *DB) Tables() []Table {
+	return append([]Table(nil), db.tables...)
+}
+
+func (db *DB) readHeader() error {
+	header := make([]byte, 100)
+	if _, err := db.file.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("failed to read header: %v", err)
+	}
+	if !bytes.Equal(header[:16], headerMagic) {
+		return errors.New("not an SQLite database")
+	}
+	db.pageSize = int(binary.BigEndian.Uint16(header[16:18]))
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 {
+		return fmt.Errorf("invalid page size %d", db.pageSize)
+	}
+	db.usable = db.pageSize - int(header[20])
+	if encoding := binary.BigEndian.Uint32(header[56:60]); encoding > 1 {
+		return fmt.Errorf("%w: text encoding %d (only UTF-8 is supported)", ErrUnsupported, encoding)
+	}
+	// 헤더의 페이지 수는 변경 카운터가 일치할 때만 유효하며, 아니면 파일 크기로 계산
+	db.pages = int(binary.BigEndian.Uint32(header[28:32]))
+	if db.pages == 0 || binary.BigEndian.Uint32(header[24:28]) != binary.BigEndian.Uint32(header[92:96]) {
+		info, err := db.file.Stat()
+		if err != nil {
+			return err
+		}
+		db.pages = int(info.Size() / int64(db.pageSize))
+	}
+	return nil
+}
+
+// readPage reads page number n (1-based).
+func (db *DB) readPage(n uint32) ([]byte, error) {
+	if n == 0 || int(n) > db.pages {
+		return nil, fmt.Errorf("page %d is out of range (database has %d pages)", n, db.pages)
+	}
+	page := make([]byte, db.pageSize)
+	if _, err := db.file.ReadAt(page, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("failed to read page %d: %v", n, err)
+	}
+	return page, nil
+}
+
+// walkTable calls fn with the rowid and payload of every row of the table B-tree rooted at
+// root, in rowid order, until fn returns an error.
+func (db *DB) walkTable(root uint32, fn func(rowid int64, payload []byte) error) error {
+	return db.walkTablePage(root, 0, fn)
+}
+
+func (db *DB) walkTablePage(n uint32, depth int, fn func(rowid int64, payload []byte) error) error {
+	if depth > 64 {
+		return fmt.Errorf("B-tree at page %d is too deep (cycle?)", n)
+	}
+	page, err := db.readPage(n)
+	if err != nil {
+		return err
+	}
+	off := 0
+	if n == 1 {
+		off = 100 // 첫 페이지는 파일 헤더 뒤에서 시작
+	}
+	kind := page[off]
+	cells := int(binary.BigEndian.Uint16(page[off+3:]))
+	headerLen := 8
+	if kind == pageInteriorTable {
+		headerLen = 12
+	} else if kind != pageLeafTable {
+		return fmt.Errorf("page %d has type %#x, expected a table B-tree page", n, kind)
+	}
+	if off+headerLen+2*cells > len(page) {
+		return fmt.Errorf("page %d: %d cells do not fit", n, cells)
+	}
+	for i := 0; i < cells; i++ {
+		cell := int(binary.BigEndian.Uint16(page[off+headerLen+2*i:]))
+		if cell >= db.usable {
+			return fmt.Errorf("page %d: cell %d at invalid offset %d", n, i, cell)
+		}
+		if kind == pageInteriorTable {
+			if cell+4 > db.usable {
+				return fmt.Errorf("page %d: cell %d is cut short", n, i)
+			}
+			if err := db.walkTablePage(binary.BigEndian.Uint32(page[cell:]), depth+1, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		rowid, payload, err := db.leafCell(page, n, cell)
+		if err != nil {
+			return err
+		}
+		if err := fn(rowid, payload); err != nil {
+			return err
+		}
+	}
+	if kind == pageInteriorTable {
+		return db.walkTablePage(binary.BigEndian.Uint32(page[off+8:]), depth+1, fn)
+	}
+	return nil
+}
+
+// leafCell decodes the table leaf cell at offset cell of page n, following overflow pages.
+func (db *DB) leafCell(page []byte, n uint32, cell int) (int64, []byte, error) {
+	body := page[cell:db.usable]
+	size, k := readVarint(body)
+	if k == 0 {
+		return 0, nil, fmt.Errorf("page %d: invalid payload size at offset %d", n, cell)
+	}
+	rowid, k2 := readVarint(body[k:])
+	if k2 == 0 {
+		return 0, nil, fmt.Errorf("page %d: invalid rowid at offset %d", n, cell)
+	}
+	body = body[k+k2:]
+	total := int(size)
+	if size > uint64(db.pages)*uint64(db.usable) {
+		return 0, nil, fmt.Errorf("page %d: payload of %d bytes is larger than the database", n, size)
+	}
+
+	// 페이로드가 페이지에 다 들어가지 않으면 앞부분만 셀에 있고 나머지는 오버플로 페이지에 이어짐
+	local := total
+	if maxLocal := db.usable - 35; total > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if local > len(body) || (local < total && local+4 > len(body)) {
+		return 0, nil, fmt.Errorf("page %d: payload at offset %d is cut short", n, cell)
+	}
+	payload := make([]byte, 0, total)
+	payload = append(payload, body[:local]...)
+	next := uint32(0)
+	if local < total {
+		next = binary.BigEndian.Uint32(body[local:])
+	}
+	for hops := 0; len(payload) < total; hops++ {
+		if next == 0 || hops > db.pages {
+			return 0, nil, fmt.Errorf("page %d: overflow chain of row %d ends early", n, int64(rowid))
+		}
+		overflow, err := db.readPage(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		next = binary.BigEndian.Uint32(overflow)
+		payload = append(payload, overflow[4:min(db.usable, 4+total-len(payload))]...)
+	}
+	return int64(rowid), payload, nil
+}
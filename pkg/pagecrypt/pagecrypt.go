@@ -0,0 +1,189 @@
+// Package pagecrypt는 저장소 어댑터(B-tree, file, LSM)가 공유하는 저장 데이터 암호화를 제공합니다.
+//
+// 페이지, SSTable 블록, WAL 레코드는 AES-256-GCM으로 봉인(seal)됩니다. 봉인된 데이터는
+//
+//	keyID(u32, 빅 엔디안) | nonce(12) | 암호문 | tag(16)
+//
+// 형식이며, keyID는 키의 SHA-256에서 얻은 4바이트라 파일만 보고도 어떤 키로 썼는지 알 수 있습니다.
+// 어댑터는 위치(페이지 오프셋 등)를 AAD로 넘겨 봉인된 데이터를 다른 위치로 옮기면 열리지 않게 합니다.
+// nonce는 봉인할 때마다 무작위로 만들므로 한 키로 약 2^32번 넘게 봉인하기 전에 Rekey로 키를 바꿔야 합니다.
+//
+// Keyring은 현재 키와 이전 키 하나를 보관합니다. 새 데이터는 항상 현재 키로 봉인하고, 읽을 때는
+// keyID가 맞는 키를 고르므로 Rekey가 중간에 멈춘 저장소도 두 키를 모두 주면 열고 마저 바꿀 수 있습니다.
+//
+// 키를 어디서 가져올지는 ports.KeyProvider로 정합니다. StaticKey(고정 키), EnvKey(환경 변수),
+// KeyFunc(외부 KMS 호출 등)를 제공합니다.
+package pagecrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// KeySize is the size of an encryption key (AES-256).
+const KeySize = 32
+
+// Overhead is the number of bytes Seal adds to a plaintext: key ID, nonce and tag.
+const Overhead = 4 + 12 + 16
+
+var (
+	// ErrKeyRequired is returned when encrypted storage is opened without an encryption key.
+	ErrKeyRequired = errors.New("storage is encrypted: an encryption key is required")
+	// ErrWrongKey is returned when data was sealed with a key the keyring doesn't hold.
+	ErrWrongKey = errors.New("encryption key does not match")
+	// ErrNotEncrypted is returned when an encryption key is given for existing unencrypted
+	// storage, or Rekey is called on it.
+	ErrNotEncrypted = errors.New("storage is not encrypted")
+	// ErrRekeyIncomplete is returned when storage left by an interrupted Rekey is opened without
+	// the previous key.
+	ErrRekeyIncomplete = errors.New("an interrupted rekey needs the previous encryption key")
+	// ErrSameKey is returned by Rekey when the new key is the key the storage is encrypted with.
+	ErrSameKey = errors.New("new encryption key is the current key")
+)
+
+// IsKeyError reports whether err is one of the key errors above, which adapters return
+// instead of treating the storage as new or corrupt.
+func IsKeyError(err error) bool {
+	return errors.Is(err, ErrKeyRequired) || errors.Is(err, ErrWrongKey) ||
+		errors.Is(err, ErrNotEncrypted) || errors.Is(err, ErrRekeyIncomplete)
+}
+
+// KeyID returns the ID recorded with data sealed by key.
+func KeyID(key []byte) uint32 {
+	sum := sha256.Sum256(append([]byte("golite-pagecrypt-key-id:"), key...))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// Cipher seals data with one key.
+type Cipher struct {
+	id   uint32
+	aead cipher.AEAD
+}
+
+// NewCipher returns the cipher of a KeySize-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{id: KeyID(key), aead: aead}, nil
+}
+
+// ID returns the key ID of the cipher.
+func (c *Cipher) ID() uint32 {
+	return c.id
+}
+
+// Seal appends the sealed plaintext to dst and returns the result.
+func (c *Cipher) Seal(dst, plaintext, aad []byte) ([]byte, error) {
+	dst = binary.BigEndian.AppendUint32(dst, c.id)
+	start := len(dst)
+	dst = append(dst, make([]byte, c.aead.NonceSize())...)
+	if _, err := io.ReadFull(rand.Reader, dst[start:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return c.aead.Seal(dst, dst[start:], plaintext, aad), nil
+}
+
+// open decrypts data sealed by c, appending the plaintext to dst.
+func (c *Cipher) open(dst, data, aad []byte) ([]byte, error) {
+	nonce := data[4 : 4+c.aead.NonceSize()]
+	out, err := c.aead.Open(dst, nonce, data[4+c.aead.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %v", err)
+	}
+	return out, nil
+}
+
+// Keyring holds the current key and, while a rekey is unfinished, the previous one.
+// A nil *Keyring means the storage is not encrypted.
+type Keyring struct {
+	current  *Cipher
+	previous *Cipher // nil if none
+}
+
+// NewKeyring returns the keyring of key and, if not empty, previous. It returns nil without
+// error when key is empty.
+func NewKeyring(key, previous []byte) (*Keyring, error) {
+	if len(key) == 0 {
+		if len(previous) != 0 {
+			return nil, fmt.Errorf("a previous encryption key requires an encryption key")
+		}
+		return nil, nil
+	}
+	k := &Keyring{}
+	var err error
+	if k.current, err = NewCipher(key); err != nil {
+		return nil, err
+	}
+	if len(previous) != 0 {
+		if k.previous, err = NewCipher(previous); err != nil {
+			return nil, fmt.Errorf("previous %v", err)
+		}
+	}
+	return k, nil
+}
+
+// Current returns the cipher new data is sealed with.
+func (k *Keyring) Current() *Cipher {
+	return k.current
+}
+
+// Previous returns the cipher of the previous key, or nil.
+func (k *Keyring) Previous() *Cipher {
+	return k.previous
+}
+
+// Rotate returns a keyring whose current key is newKey and whose previous key is the current
+// key of k.
+func (k *Keyring) Rotate(newKey []byte) (*Keyring, error) {
+	c, err := NewCipher(newKey)
+	if err != nil {
+		return nil, err
+	}
+	if c.id == k.current.id {
+		return nil, ErrSameKey
+	}
+	return &Keyring{current: c, previous: k.current}, nil
+}
+
+// WithoutPrevious returns the keyring holding only the current key.
+func (k *Keyring) WithoutPrevious() *Keyring {
+	return &Keyring{current: k.current}
+}
+
+// SealedID returns the key ID data was sealed with.
+func SealedID(data []byte) (uint32, error) {
+	if len(data) < Overhead {
+		return 0, fmt.Errorf("sealed data too short: %d bytes", len(data))
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
+
+// Open decrypts data sealed with one of the keys of k, appending the plaintext to dst.
+func (k *Keyring) Open(dst, data, aad []byte) ([]byte, error) {
+	id, err := SealedID(data)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case id == k.current.id:
+		return k.current.open(dst, data, aad)
+	case k.previous != nil && id == k.previous.id:
+		return k.previous.open(dst, data, aad)
+	}
+	return nil, fmt.Errorf("%w: data sealed with key %08x", ErrWrongKey, id)
+}
@@ -0,0 +1,71 @@
+package pagecrypt
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+var (
+	_ ports.KeyProvider = StaticKey(nil)
+	_ ports.KeyProvider = EnvKey("")
+	_ ports.KeyProvider = KeyFunc(nil)
+)
+
+// StaticKey is a ports.KeyProvider that always returns the same key and no previous key.
+type StaticKey []byte
+
+// Keys implements ports.KeyProvider.
+func (k StaticKey) Keys(ctx context.Context) ([]byte, []byte, error) {
+	if len(k) == 0 {
+		return nil, nil, fmt.Errorf("static encryption key is empty")
+	}
+	return k, nil, nil
+}
+
+// EnvKey is a ports.KeyProvider reading a hex-encoded key from the environment variable it
+// names, and the previous key, if set, from the same name with the suffix "_PREVIOUS". The
+// variables are read on every call, so a key rotated in the environment is picked up by the
+// next rekey.
+type EnvKey string
+
+// Keys implements ports.KeyProvider.
+func (name EnvKey) Keys(ctx context.Context) ([]byte, []byte, error) {
+	current, err := envHexKey(string(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	if current == nil {
+		return nil, nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	previous, err := envHexKey(string(name) + "_PREVIOUS")
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, previous, nil
+}
+
+// envHexKey decodes the hex key in the environment variable name, or returns nil if it is unset.
+func envHexKey(name string) ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s must contain a hex-encoded key: %v", name, err)
+	}
+	return key, nil
+}
+
+// KeyFunc adapts a function, such as a call to an external KMS, to ports.KeyProvider.
+type KeyFunc func(ctx context.Context) (current, previous []byte, err error)
+
+// Keys implements ports.KeyProvider.
+func (f KeyFunc) Keys(ctx context.Context) ([]byte, []byte, error) {
+	return f(ctx)
+}
@@ -0,0 +1,45 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Codec은 Go 값을 저장소에 넣을 []byte로 변환하는 직렬화 방식입니다.
+// 구현은 pkg/codec에 있으며(JSON, Gob, Msgpack) domain.Database의 InsertValue/GetValue가 사용합니다.
+type Codec interface {
+	// Name은 코덱 이름을 반환합니다 (예: "json").
+	Name() string
+
+	// Marshal은 v를 직렬화합니다.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal은 data를 out(포인터)으로 역직렬화합니다.
+	Unmarshal(data []byte, out interface{}) error
+}
+
+// ErrInvalidValue는 저장소가 받을 수 없는 타입의 값이 주어졌을 때 반환되는 오류입니다.
+var ErrInvalidValue = errors.New("value must be string or []byte")
+
+// ValueString은 StoragePort.Insert에 전달된 값을 저장 형식(string)으로 바꿉니다.
+// 어댑터는 string과 []byte 값을 모두 받아야 하며, 그 외 타입은 Codec으로 먼저 직렬화해야 합니다.
+func ValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return "", fmt.Errorf("%w, got %T", ErrInvalidValue, value)
+}
+
+// ValueBytes는 ValueString과 같지만 []byte 값을 복사 없이 그대로 반환합니다.
+func ValueBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("%w, got %T", ErrInvalidValue, value)
+}
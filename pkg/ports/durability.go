@@ -0,0 +1,36 @@
+package ports
+
+import "fmt"
+
+// Durability는 쓰기가 반환되기 전에 어느 수준까지 디스크에 기록되어야 하는지를 정합니다.
+// domain.DatabaseConfig, file.FileConfig, lsmtree.Config가 같은 값을 받아 일관되게 적용합니다.
+type Durability string
+
+const (
+	// DurabilityNone은 WAL을 쓰지 않습니다. 쓰기는 flush/compaction/Close 때에만 디스크에 남으며
+	// 그 전에 장애가 나면 유실됩니다. B-tree처럼 WAL이 없는 저장소에서는 async와 같습니다.
+	DurabilityNone Durability = "none"
+	// DurabilityAsync는 WAL을 백그라운드에서 기록하고 fsync를 기다리지 않습니다 (기본값).
+	DurabilityAsync Durability = "async"
+	// DurabilityFsyncPerCommit은 쓰기(또는 배치)마다 개별 fsync가 끝난 뒤 반환합니다.
+	DurabilityFsyncPerCommit Durability = "fsync-per-commit"
+	// DurabilityFsyncPerBatch는 동시에 대기 중인 커밋을 모아 한 번의 fsync로 기록하고(그룹 커밋),
+	// 각 쓰기는 자신이 포함된 fsync가 끝난 뒤 반환합니다.
+	DurabilityFsyncPerBatch Durability = "fsync-per-batch"
+)
+
+// ParseDurability는 설정 문자열을 Durability로 바꿉니다. 빈 문자열은 DurabilityAsync입니다.
+func ParseDurability(s string) (Durability, error) {
+	switch d := Durability(s); d {
+	case "":
+		return DurabilityAsync, nil
+	case DurabilityNone, DurabilityAsync, DurabilityFsyncPerCommit, DurabilityFsyncPerBatch:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown durability %q (want none, async, fsync-per-commit or fsync-per-batch)", s)
+}
+
+// Fsync는 쓰기가 fsync를 기다려야 하는 수준인지 반환합니다.
+func (d Durability) Fsync() bool {
+	return d == DurabilityFsyncPerCommit || d == DurabilityFsyncPerBatch
+}
@@ -0,0 +1,13 @@
+package ports
+
+import "context"
+
+// KeyProvider는 저장 데이터 암호화(Rekeyer를 구현하는 어댑터의 EncryptionKey) 키를 공급합니다.
+// 고정 키, 환경 변수, 외부 KMS 호출 등 키를 어디서 가져올지와 언제 새 키를 내줄지는 구현이 정하며,
+// 데이터베이스는 열 때와 키 교체 작업(domain.MaintenanceRekey)을 실행할 때 Keys를 호출합니다.
+type KeyProvider interface {
+	// Keys는 데이터를 암호화할 현재 키와, 그 키로의 교체가 끝나지 않았을 수 있으면 이전 키를
+	// 반환합니다 (없으면 nil). 중단된 교체를 마치려면 이전 키가 필요하므로, 새 키를 내주기
+	// 시작한 뒤에도 교체가 끝났다고 알 수 있을 때까지 이전 키를 함께 반환해야 합니다.
+	Keys(ctx context.Context) (current, previous []byte, err error)
+}
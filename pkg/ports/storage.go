@@ -2,7 +2,11 @@
 // 이 패키지는 도메인 로직과 어댑터(B-트리, LSM 등)를 연결하는 포트 역할을 합니다.
 package ports
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // StoragePort는 GoLite의 저장소 동작을 정의하는 인터페이스입니다.
 // SQLite 1.0의 키-값 저장 방식을 기반으로 하며, 삽입, 조회, 삭제를 지원합니다.
@@ -20,6 +24,112 @@ type StoragePort interface {
 	Delete(key string) error
 }
 
+// BytesPort는 키와 값을 []byte로 다루는 저장소 인터페이스입니다.
+// NUL이나 잘못된 UTF-8이 포함된 바이너리 데이터도 그대로 보존되며, string 변환에 따른 복사를 피합니다.
+// 이를 직접 구현하지 않는 StoragePort는 AsBytes로 감싸 사용할 수 있습니다.
+type BytesPort interface {
+	// InsertBytes는 키-값 쌍을 삽입합니다. 구현은 value를 복사해야 하므로 호출자는 이후 value를 재사용할 수 있습니다.
+	InsertBytes(key, value []byte) error
+
+	// GetBytes는 키에 해당하는 값을 조회합니다. 반환된 슬라이스는 어댑터 내부와 공유될 수 있으므로 수정하면 안 됩니다.
+	// 키가 존재하지 않으면 ErrKeyNotFound 오류를 반환합니다.
+	GetBytes(key []byte) ([]byte, error)
+
+	// DeleteBytes는 키-값 쌍을 삭제합니다.
+	DeleteBytes(key []byte) error
+}
+
+// AsBytes는 s의 []byte API를 반환합니다. s가 BytesPort를 구현하지 않으면
+// string 기반 Insert/Get/Delete를 호출하는 호환 래퍼를 반환합니다.
+func AsBytes(s StoragePort) BytesPort {
+	if b, ok := s.(BytesPort); ok {
+		return b
+	}
+	return stringPort{s}
+}
+
+// stringPort adapts a string-only StoragePort to BytesPort.
+type stringPort struct{ s StoragePort }
+
+func (p stringPort) InsertBytes(key, value []byte) error {
+	return p.s.Insert(string(key), string(value))
+}
+
+func (p stringPort) GetBytes(key []byte) ([]byte, error) {
+	v, err := p.s.Get(string(key))
+	if err != nil {
+		return nil, err
+	}
+	return ValueBytes(v)
+}
+
+func (p stringPort) DeleteBytes(key []byte) error {
+	return p.s.Delete(string(key))
+}
+
+// ScannablePort는 키 순서대로 순회할 수 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+type ScannablePort interface {
+	// Scan은 prefix로 시작하는 모든 키에 대해 오름차순으로 fn을 호출합니다.
+	// fn이 false를 반환하면 순회를 중단합니다.
+	Scan(prefix string, fn func(key string, value interface{}) bool) error
+}
+
+// ContextScannablePort는 컨텍스트가 끝나면 순회를 멈출 수 있는 ScannablePort가 구현하는 선택적 인터페이스입니다.
+type ContextScannablePort interface {
+	// ScanContext는 Scan과 같지만 ctx가 끝나면 순회를 중단하고 ctx.Err()를 반환합니다.
+	ScanContext(ctx context.Context, prefix string, fn func(key string, value interface{}) bool) error
+}
+
+// ContextCompactor는 요청 시 컴팩션을 수행하고 컨텍스트로 이를 취소할 수 있는 저장소 어댑터가
+// 구현하는 선택적 인터페이스입니다.
+type ContextCompactor interface {
+	// CompactContext는 컴팩션을 수행합니다. ctx가 끝나면 다음 단계로 넘어가지 않고 ctx.Err()를
+	// 반환하며, 이미 끝난 단계의 결과는 유지되고 중단된 단계는 기존 파일을 그대로 둡니다.
+	CompactContext(ctx context.Context) error
+}
+
+// PrefixDeleter는 prefix로 시작하는 키를 한 번에 삭제할 수 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+type PrefixDeleter interface {
+	// DeletePrefix는 prefix로 시작하는 모든 키를 삭제하고 삭제한 키의 개수를 반환합니다.
+	DeletePrefix(prefix string) (int, error)
+}
+
+// RangeDeleter는 키 범위를 한 번에 삭제할 수 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+type RangeDeleter interface {
+	// DeleteRange는 start 이상 end 미만의 모든 키를 삭제하고 삭제한 키의 개수를 반환합니다.
+	// end가 ""이면 상한이 없습니다.
+	DeleteRange(start, end string) (int, error)
+}
+
+// InRange reports whether key lies in [start, end), where an end of "" has no upper bound.
+func InRange(key, start, end string) bool {
+	return key >= start && (end == "" || key < end)
+}
+
+// SubtreePort는 같은 파일 안에 독립된 키 공간(서브트리)을 만들 수 있는 저장소 어댑터가 구현하는
+// 선택적 인터페이스입니다. domain.Database는 이를 이용해 테이블마다 별도의 B-tree root를 둡니다.
+type SubtreePort interface {
+	// Subtree는 root와 length로 기록된 서브트리를 엽니다 (새 서브트리는 0, 0).
+	// root나 length가 바뀔 때마다 persist가 호출되므로 소유자가 이를 영속화해야 합니다.
+	Subtree(root int64, length int, persist func(root int64, length int) error) StoragePort
+}
+
+// ManagedStorage는 수명 주기와 상태 조회를 지원하는 저장소 어댑터가 구현하는 인터페이스입니다.
+// domain.Database는 저장소가 이 인터페이스를 구현하면 Sync/Close 시 함께 호출합니다.
+type ManagedStorage interface {
+	StoragePort
+
+	// Close는 백그라운드 작업(WAL 워커 등)을 멈추고 어댑터가 연 리소스를 해제합니다.
+	// 여러 번 호출해도 안전해야 합니다.
+	Close() error
+
+	// Sync는 지금까지 확인된 모든 쓰기를 디스크에 영속화합니다.
+	Sync() error
+
+	// Stats는 어댑터별 통계를 반환합니다.
+	Stats() map[string]interface{}
+}
+
 // Item은 저장소에 저장되는 아이템의 비교를 위한 인터페이스입니다.
 // B-트리와 같은 정렬 기반 자료구조에서 사용됩니다.
 type Item interface {
@@ -40,3 +150,104 @@ type StorageEventPort interface {
 	// OnDelete는 삭제 작업 후 호출되어 이벤트를 발생시킵니다.
 	OnDelete(key string)
 }
+
+// CacheResizer는 실행 중에 캐시 용량을 바꿀 수 있는 저장소 어댑터가 구현하는 인터페이스입니다.
+// 설정 재적용(SIGHUP) 시 사용됩니다.
+type CacheResizer interface {
+	// SetCacheSize는 캐시 용량을 n으로 바꾸고 초과분을 즉시 내보냅니다. 0은 캐시를 끕니다.
+	SetCacheSize(n int)
+}
+
+// RecoveryReport는 저장소 어댑터가 열 때 수행한 복구의 결과입니다. 해당하지 않는 항목은 0입니다.
+type RecoveryReport struct {
+	WALBytes    int64         `json:"wal_bytes"`   // 재생한 로그의 바이트 수
+	WALRecords  int64         `json:"wal_records"` // 재생해 적용한 로그 레코드 수
+	Corrupted   int64         `json:"corrupted"`   // 손상되어 재생을 멈추거나 건너뛴 레코드(암호화된 로그는 프레임) 수
+	Quarantined int64         `json:"quarantined"` // 손상으로 격리되어 읽지 않는 SSTable 수 (열린 뒤 발견한 것 포함)
+	Duration    time.Duration `json:"duration"`    // 복구에 걸린 시간
+}
+
+// RecoveryReporter는 시작 시 로그(WAL)를 재생하는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+type RecoveryReporter interface {
+	// RecoveryDebt는 지금 재시작하면 재생해야 하는 로그의 바이트 수를 반환합니다.
+	RecoveryDebt() int64
+
+	// RecoveryReport는 열 때 수행한 복구의 결과를 반환합니다.
+	RecoveryReport() RecoveryReport
+}
+
+// SizeReporter는 디스크 사용량을 싸게 알 수 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+// domain.Database는 이를 이용해 MaxDatabaseBytes 쿼터를 검사합니다.
+type SizeReporter interface {
+	// DiskSize는 저장소 파일(로그 포함)이 디스크에서 차지하는 바이트 수를 반환합니다.
+	DiskSize() int64
+}
+
+// StorageStatus는 저장소 어댑터가 보고하는 캐시와 compaction 상태입니다. 해당하지 않는 항목은 0입니다.
+type StorageStatus struct {
+	CacheHits      int64     // 캐시에서 답한 조회 수
+	CacheMisses    int64     // 캐시에 없어 파일에서 읽은 조회 수
+	LastCompaction time.Time // 연 뒤 마지막으로 끝난 compaction 시각 (없으면 zero)
+}
+
+// StatusReporter는 StorageStatus를 보고하는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+// domain.Database는 이를 DatabaseStatus.Storage에 담습니다.
+type StatusReporter interface {
+	StorageStatus() StorageStatus
+}
+
+// HealthChecker는 내부 상태(WAL 등)를 점검할 수 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+type HealthChecker interface {
+	// CheckHealth는 저장소가 쓰기를 받아 기록할 수 있는 상태가 아니면 그 이유를 반환합니다.
+	CheckHealth() error
+}
+
+// EntryLimits는 저장소가 받아들이는 엔트리 크기의 상한(바이트)입니다. 0은 제한이 없음을 뜻합니다.
+type EntryLimits struct {
+	MaxKey   int // 키 하나의 최대 크기
+	MaxValue int // 값 하나의 최대 크기
+	MaxEntry int // 키와 값을 합친 최대 크기
+}
+
+// EntryLimiter는 엔트리 크기에 고정된 상한이 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+// domain.Database는 이를 이용해 쓰기가 실패하기 전에 소프트 리밋 경고를 보냅니다.
+type EntryLimiter interface {
+	EntryLimits() EntryLimits
+}
+
+// ReadCost는 읽기가 거친 저장소 구조의 수(읽기 증폭)입니다. 어댑터는 자신에게 해당하는 항목만 채웁니다.
+type ReadCost struct {
+	Pages      int64 `json:"pages"`       // 방문한 B-tree 노드(페이지) 수
+	PageReads  int64 `json:"page_reads"`  // 그중 노드 캐시에 없어 디스크에서 읽은 수
+	SSTables   int64 `json:"sstables"`    // 조회하거나 병합한 SSTable 수
+	BloomSkips int64 `json:"bloom_skips"` // 키 범위는 맞지만 블룸 필터로 건너뛴 SSTable 수
+	Blocks     int64 `json:"blocks"`      // 파일에서 읽은 SSTable 블록(블록이 없는 테이블은 엔트리/파일) 수
+	CacheHits  int64 `json:"cache_hits"`  // 파일을 읽지 않고 메모리(memtable, 값 캐시)에서 답한 수
+}
+
+// Add는 o를 c에 더합니다.
+func (c *ReadCost) Add(o ReadCost) {
+	c.Pages += o.Pages
+	c.PageReads += o.PageReads
+	c.SSTables += o.SSTables
+	c.BloomSkips += o.BloomSkips
+	c.Blocks += o.Blocks
+	c.CacheHits += o.CacheHits
+}
+
+// ReadCostPort는 읽기마다 거친 구조를 셀 수 있는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+// domain.Database는 이를 이용해 읽기 증폭 통계와 Explain 결과를 만듭니다.
+// cost는 nil일 수 있으며, 이때는 세지 않고 Get/Scan과 똑같이 동작해야 합니다.
+type ReadCostPort interface {
+	// GetCost는 Get과 같으며 조회가 거친 구조를 cost에 더합니다.
+	GetCost(key string, cost *ReadCost) (interface{}, error)
+
+	// ScanCost는 Scan과 같으며 순회가 거친 구조를 cost에 더합니다.
+	ScanCost(prefix string, fn func(key string, value interface{}) bool, cost *ReadCost) error
+}
+
+// Rekeyer는 저장 데이터를 암호화하는 저장소 어댑터가 구현하는 선택적 인터페이스입니다.
+type Rekeyer interface {
+	// Rekey는 저장된 모든 데이터를 newKey로 다시 암호화하고 이후 쓰기에 newKey를 사용합니다.
+	Rekey(newKey []byte) error
+}
@@ -0,0 +1,379 @@
+// Package repair는 닫혀 있는 GoLite 데이터베이스 파일을 열지 않고 점검하고, 손상된 파일에서 읽을 수
+// 있는 레코드를 새 파일로 살려 냅니다 (golite fsck).
+//
+// 파일 형식은 첫 4바이트로 구분합니다: "GLB1"/"GLB2"/"GLB4"는 파일 어댑터, 그 외는 B-tree입니다.
+// 암호화된 저장소("GLE2" 파일 어댑터, 암호화 플래그가 있는 B-tree)는 키 없이 읽을 수 없으므로
+// pagecrypt.ErrKeyRequired로 거부합니다.
+//
+//   - B-tree: 메인 트리와 page 1 헤더(domain.ReadHeader)에 기록된 테이블별 서브트리를 모두
+//     btree.CheckTree로 검사합니다 (체크섬, 노드 불변식, 키 범위, 리프 깊이, 길이). 헤더의 테이블
+//     중 root가 파일 밖이거나 읽을 수 없는 페이지를 가리키는 것, 그리고 메인 트리에 남아 있지만
+//     헤더에 없는 테이블의 레코드는 매달린(dangling) 메타데이터로 보고합니다. 어느 트리에서도
+//     도달하지 않는 페이지는 삭제나 DropTable 뒤 회수되지 않은 공간이므로 문제가 아닌 정보입니다.
+//   - 파일 어댑터: 메인 파일과 WAL의 형식을 file.CheckFiles로 검사합니다.
+//
+// Salvage를 지정하면 점검하면서 읽은 레코드를 같은 형식의 새 데이터베이스에 저장된 바이트 그대로
+// 복사합니다 (메타데이터 테이블의 엔트리 헤더와 암호화된 값도 그대로이므로 같은 키로 열 수 있음).
+// B-tree 헤더는 새 root로 고쳐 쓰고, 키 개수 통계는 정확하지 않은 것으로 표시합니다.
+//
+// 점검은 다른 프로세스가 데이터베이스를 쓰지 않을 때만 의미가 있으므로, 사용 중인 파일은
+// utils.ErrLocked로 거부합니다.
+package repair
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// DefaultPageSize is the B-tree page size used when Options.PageSize is not set.
+const DefaultPageSize = 4096
+
+// Format names reported in Report.Format.
+const (
+	FormatBtree = "btree"
+	FormatFile  = "file"
+)
+
+// Options configures Check.
+type Options struct {
+	// PageSize is the page size the B-tree file was created with (DefaultPageSize if 0).
+	PageSize int
+	// Salvage is the path of a new database receiving the readable records; it must not exist.
+	Salvage string
+}
+
+// Report is the result of Check.
+type Report struct {
+	Path     string
+	Format   string // FormatBtree or FormatFile
+	Tables   int    // Tables in the B-tree header
+	Pages    int    // B-tree node pages reached from a tree
+	Orphaned int    // B-tree node pages not reached from any tree
+	Records  int    // Records that could be read
+	Salvaged int    // Records written to Options.Salvage
+	Problems []string
+	Warnings []string // Findings that do not lose data, e.g. a torn WAL tail
+}
+
+// OK reports whether no problem was found.
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *Report) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Check validates the database at path as described in the package documentation. Problems
+// found in the files are listed in the report; the error is for a database that could not be
+// checked at all, e.g. because it is missing or in use.
+func Check(path string, opts Options) (*Report, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.Salvage != "" {
+		for _, p := range []string{opts.Salvage, opts.Salvage + ".wal"} {
+			if _, err := os.Stat(p); err == nil {
+				return nil, fmt.Errorf("salvage target %s already exists", p)
+			}
+		}
+	}
+	magic := make([]byte, 4)
+	f, err := os.Open(path)
+	if err == nil {
+		_, err = f.ReadAt(magic, 0)
+		f.Close()
+	}
+	switch {
+	case err == nil && (string(magic) == "GLB1" || string(magic) == "GLB2" || string(magic) == "GLB4"):
+		return checkFile(path, opts)
+	case err == nil && string(magic) == "GLE2":
+		return nil, fmt.Errorf("%s: %w; encrypted file storage can't be checked offline", path, pagecrypt.ErrKeyRequired)
+	case err == nil:
+		return checkBtree(path, opts)
+	}
+	// 메인 파일이 없거나 비어 있어도 WAL만 있는 파일 어댑터는 점검할 수 있음
+	if _, werr := os.Stat(path + ".wal"); werr == nil {
+		return checkFile(path, opts)
+	}
+	return nil, fmt.Errorf("failed to read %s: %v", path, err)
+}
+
+func checkBtree(path string, opts Options) (*Report, error) {
+	report := &Report{Path: path, Format: FormatBtree}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	bt, err := btree.OpenBtree(f, btree.BtConfig{PageSize: opts.PageSize, ReservedPages: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer bt.Close()
+
+	header, err := readHeader(f, opts.PageSize)
+	if err != nil {
+		report.problem("table header: %v", err)
+	}
+	tables := make(map[string]domain.HeaderTable)
+	if header != nil {
+		report.Tables = len(header.Tables)
+		for _, t := range header.Tables {
+			tables[t.Name] = t
+		}
+	}
+
+	var out *salvager
+	if opts.Salvage != "" {
+		checksums, _ := bt.Stats()["checksums"].(bool)
+		if out, err = newSalvager(opts.Salvage, opts.PageSize, checksums); err != nil {
+			return nil, err
+		}
+		defer out.abort()
+	}
+
+	seen := make(map[int64]bool)
+	mainOut := out.mainTree()
+	orphans := make(map[string]int)
+	main := bt.CheckTree(bt.RootOffset, bt.Length, seen, func(key string, value []byte) {
+		report.Records++
+		if header != nil {
+			table, _, found := strings.Cut(key, ":")
+			if t, ok := tables[table]; !found || !ok || t.Isolated {
+				orphans[table]++
+			}
+		}
+		out.insert(mainOut, key, value)
+	})
+	report.treeProblems("main tree", main)
+
+	// 헤더에 없는 테이블의 레코드: 테이블 목록이 사라졌거나 DropTable이 중간에 멈춘 경우
+	names := make([]string, 0, len(orphans))
+	for name := range orphans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.problem("main tree holds %d records of table %q, which is not a table of the header", orphans[name], name)
+	}
+
+	var roots []domain.HeaderTable
+	if header != nil {
+		for _, t := range header.Tables {
+			if !t.Isolated {
+				roots = append(roots, t)
+				continue
+			}
+			sub := out.subtree()
+			check := bt.CheckTree(t.Root, t.Length, seen, func(key string, value []byte) {
+				report.Records++
+				out.insert(sub, key, value)
+			})
+			report.treeProblems(fmt.Sprintf("table %q", t.Name), check)
+			roots = append(roots, domain.HeaderTable{Name: t.Name, Isolated: true, Root: sub.root, Length: sub.length})
+		}
+	}
+
+	if stat, err := f.Stat(); err == nil {
+		first := int64(opts.PageSize) * 2 // 헤더와 테이블 헤더 다음부터 노드 페이지
+		size := stat.Size()
+		if partial := size % int64(opts.PageSize); partial != 0 {
+			report.warn("file ends with a partial page of %d bytes", partial)
+		}
+		for off := first; off+int64(opts.PageSize) <= size; off += int64(opts.PageSize) {
+			if !seen[off] {
+				report.Orphaned++
+			}
+		}
+	}
+	report.Pages = len(seen)
+
+	if out != nil {
+		if err := out.finish(header, roots); err != nil {
+			return nil, err
+		}
+		report.Salvaged = out.count
+		if out.failed > 0 {
+			report.warn("%d readable records could not be salvaged", out.failed)
+		}
+	}
+	return report, nil
+}
+
+// treeProblems adds the problems of a tree check to the report.
+func (r *Report) treeProblems(tree string, check btree.TreeCheck) {
+	for _, err := range check.Problems {
+		r.problem("%s: %v", tree, err)
+	}
+}
+
+// readHeader reads and parses the table header on page 1.
+func readHeader(f *os.File, pageSize int) (*domain.Header, error) {
+	page := make([]byte, pageSize)
+	// 읽은 바이트가 없으면 테이블을 만든 적 없는 데이터베이스 (빈 헤더)
+	if n, _ := f.ReadAt(page, int64(pageSize)); n > 0 && n < pageSize {
+		return nil, fmt.Errorf("page is cut short at %d of %d bytes", n, pageSize)
+	}
+	return domain.ReadHeader(page)
+}
+
+// salvager copies records into a new B-tree file. A nil salvager discards them.
+type salvager struct {
+	path     string
+	pageSize int
+	file     *os.File
+	tree     *btree.Btree
+	count    int
+	failed   int
+	done     bool
+}
+
+type salvageTree struct {
+	store  ports.BytesPort
+	root   int64
+	length int
+}
+
+func newSalvager(path string, pageSize int, checksums bool) (*salvager, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create salvage target: %v", err)
+	}
+	bt, err := btree.OpenBtree(f, btree.BtConfig{PageSize: pageSize, ReservedPages: 1, Checksums: checksums})
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to create salvage target: %v", err)
+	}
+	return &salvager{path: path, pageSize: pageSize, file: f, tree: bt}, nil
+}
+
+// mainTree returns the main tree of the salvage target.
+func (s *salvager) mainTree() *salvageTree {
+	if s == nil {
+		return nil
+	}
+	return &salvageTree{store: s.tree}
+}
+
+// subtree starts a new table tree in the salvage target.
+func (s *salvager) subtree() *salvageTree {
+	t := &salvageTree{}
+	if s == nil {
+		return t
+	}
+	t.store = s.tree.Subtree(0, 0, func(root int64, length int) error {
+		t.root, t.length = root, length
+		return nil
+	}).(ports.BytesPort)
+	return t
+}
+
+func (s *salvager) insert(t *salvageTree, key string, value []byte) {
+	if s == nil {
+		return
+	}
+	if err := t.store.InsertBytes([]byte(key), value); err != nil {
+		s.failed++
+		return
+	}
+	s.count++
+}
+
+// finish writes the table header with the new roots and closes the target.
+func (s *salvager) finish(header *domain.Header, roots []domain.HeaderTable) error {
+	s.done = true
+	var errs []error
+	if header != nil && len(header.Tables) > 0 {
+		page, err := header.WithRoots(roots)
+		if err == nil {
+			_, err = s.file.WriteAt(page, int64(s.pageSize))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to write table header: %v", err))
+		}
+	}
+	errs = append(errs, s.tree.Close(), s.file.Sync(), s.file.Close())
+	if err := errors.Join(errs...); err != nil {
+		os.Remove(s.path)
+		return fmt.Errorf("failed to write salvage target: %v", err)
+	}
+	return nil
+}
+
+// abort removes the target if finish was not reached.
+func (s *salvager) abort() {
+	if s == nil || s.done {
+		return
+	}
+	s.tree.Close()
+	s.file.Close()
+	os.Remove(s.path)
+}
+
+func checkFile(path string, opts Options) (*Report, error) {
+	report := &Report{Path: path, Format: FormatFile}
+	// 파일 어댑터는 WAL 파일을 잠그므로 같은 잠금으로 사용 중인 저장소를 거부
+	if wal, err := os.Open(path + ".wal"); err == nil {
+		defer wal.Close()
+		if err := utils.LockFile(wal); err != nil {
+			return nil, err
+		}
+		defer utils.UnlockFile(wal)
+	}
+
+	var records [][2]string
+	check := file.CheckFiles(path, func(key, value string) {
+		report.Records++
+		if opts.Salvage != "" {
+			records = append(records, [2]string{key, value})
+		}
+	})
+	for _, err := range check.Problems {
+		report.problem("%v", err)
+	}
+	if check.TornBytes > 0 {
+		report.warn("WAL ends with a torn record of %d bytes, which is dropped on open", check.TornBytes)
+	}
+	if opts.Salvage == "" {
+		return report, nil
+	}
+
+	out, err := file.NewFile(file.FileConfig{
+		FilePath: opts.Salvage,
+		Logger:   &utils.SilentLogger{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create salvage target: %v", err)
+	}
+	failed := 0
+	for _, rec := range records {
+		if err := out.Insert(rec[0], rec[1]); err != nil {
+			failed++
+			continue
+		}
+		report.Salvaged++
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write salvage target: %v", err)
+	}
+	if failed > 0 {
+		report.warn("%d readable records could not be salvaged", failed)
+	}
+	return report, nil
+}
@@ -0,0 +1,371 @@
+package replication
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+var _ domain.ReplicaAcknowledger = (*Primary)(nil)
+
+// PrimaryConfig configures a replication primary.
+type PrimaryConfig struct {
+	Addr        string // TCP listen address, e.g. ":7070"
+	Backlog     int    // Number of recent mutations kept for replica catch-up (default 10000)
+	SendBuffer  int    // Per-replica queue; a replica that overflows it is resynced (default 1024)
+	SnapshotDir string // Directory for temporary snapshot files (default os.TempDir())
+}
+
+// Primary streams committed mutations of a Database to connected replicas.
+type Primary struct {
+	config   PrimaryConfig
+	db       *domain.Database
+	logger   utils.Logger
+	runID    string
+	listener net.Listener
+	remove   func()
+
+	mu       sync.Mutex
+	backlog  []domain.Mutation // ring of the most recent mutations, in sequence order
+	lastSeq  uint64            // sequence of the newest committed mutation seen
+	sessions map[*session]struct{}
+	acked    map[*session]uint64
+	ackCond  *sync.Cond
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// session is the primary-side state of one connected replica.
+type session struct {
+	c     *conn
+	queue chan domain.Mutation
+	kick  chan struct{} // closed when the replica fell behind the send buffer
+	once  sync.Once
+}
+
+func (s *session) disconnect() {
+	s.once.Do(func() { close(s.kick) })
+}
+
+// NewPrimary creates a primary for db. Call Start to begin accepting replicas.
+func NewPrimary(db *domain.Database, config PrimaryConfig, logger utils.Logger) (*Primary, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+	if config.Addr == "" {
+		return nil, fmt.Errorf("listen address is required")
+	}
+	if config.Backlog <= 0 {
+		config.Backlog = 10000
+	}
+	if config.SendBuffer <= 0 {
+		config.SendBuffer = 1024
+	}
+	if config.SnapshotDir == "" {
+		config.SnapshotDir = os.TempDir()
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate run id: %v", err)
+	}
+	p := &Primary{
+		config:   config,
+		db:       db,
+		logger:   logger,
+		runID:    hex.EncodeToString(id),
+		sessions: make(map[*session]struct{}),
+		acked:    make(map[*session]uint64),
+	}
+	p.ackCond = sync.NewCond(&p.mu)
+	return p, nil
+}
+
+// Start listens for replicas and begins tailing the database's committed mutations.
+func (p *Primary) Start() error {
+	ln, err := net.Listen("tcp", p.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", p.config.Addr, err)
+	}
+	p.listener = ln
+	seq := p.db.Seq()
+	p.mu.Lock()
+	p.lastSeq = seq
+	p.mu.Unlock()
+	p.remove = p.db.AddMutationListener(p.onMutation)
+	p.db.SetReplicaAcknowledger(p)
+
+	p.wg.Add(1)
+	go p.acceptLoop()
+	p.logger.Info("Replication primary listening", "addr", ln.Addr().String(), "run", p.runID)
+	return nil
+}
+
+// Addr returns the address the primary is listening on.
+func (p *Primary) Addr() string {
+	if p.listener == nil {
+		return p.config.Addr
+	}
+	return p.listener.Addr().String()
+}
+
+// onMutation records a committed mutation and fans it out without blocking the writer.
+func (p *Primary) onMutation(m domain.Mutation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.backlog) >= p.config.Backlog {
+		copy(p.backlog, p.backlog[1:])
+		p.backlog = p.backlog[:len(p.backlog)-1]
+	}
+	p.backlog = append(p.backlog, m)
+	p.lastSeq = m.Seq
+	for s := range p.sessions {
+		select {
+		case s.queue <- m:
+		default:
+			// 너무 뒤처진 replica는 연결을 끊고 스냅샷부터 다시 따라잡게 합니다.
+			s.disconnect()
+		}
+	}
+}
+
+func (p *Primary) acceptLoop() {
+	defer p.wg.Done()
+	for {
+		raw, err := p.listener.Accept()
+		if err != nil {
+			p.mu.Lock()
+			closed := p.closed
+			p.mu.Unlock()
+			if !closed {
+				p.logger.Error("Replication accept failed", "error", err)
+			}
+			return
+		}
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.serve(newConn(raw))
+		}()
+	}
+}
+
+// serve runs one replica session: catch-up (backlog or snapshot), then live streaming.
+func (p *Primary) serve(c *conn) {
+	defer c.Close()
+	hello, err := c.recv()
+	if err != nil || hello.Kind != msgHello {
+		p.logger.Warn("Replication handshake failed", "error", err)
+		return
+	}
+
+	s := &session{c: c, queue: make(chan domain.Mutation, p.config.SendBuffer), kick: make(chan struct{})}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.sessions[s] = struct{}{}
+	p.acked[s] = 0
+	var catchUp []domain.Mutation
+	needSnapshot := hello.RunID != p.runID
+	if !needSnapshot {
+		catchUp, needSnapshot = p.backlogSince(hello.Seq)
+	}
+	p.mu.Unlock()
+	defer p.dropSession(s)
+
+	sent := hello.Seq
+	if needSnapshot {
+		seq, err := p.sendSnapshot(c)
+		if err != nil {
+			p.logger.Error("Failed to send snapshot to replica", "error", err)
+			return
+		}
+		sent = seq
+	} else {
+		for _, m := range catchUp {
+			if err := c.send(&message{Kind: msgMutation, Mutation: m}); err != nil {
+				return
+			}
+			sent = m.Seq
+		}
+	}
+
+	go p.readAcks(s)
+	for {
+		select {
+		case m := <-s.queue:
+			if m.Seq <= sent {
+				continue // 이미 스냅샷 또는 백로그로 전송됨
+			}
+			if err := c.send(&message{Kind: msgMutation, Mutation: m}); err != nil {
+				return
+			}
+			sent = m.Seq
+		case <-s.kick:
+			p.logger.Warn("Replica fell behind the send buffer, disconnecting for resync")
+			return
+		}
+	}
+}
+
+// backlogSince returns buffered mutations after seq, or needSnapshot if the backlog
+// no longer reaches back that far. Caller holds p.mu.
+func (p *Primary) backlogSince(seq uint64) ([]domain.Mutation, bool) {
+	if seq == p.lastSeq {
+		return nil, false
+	}
+	if seq > p.lastSeq || len(p.backlog) == 0 || p.backlog[0].Seq > seq+1 {
+		return nil, true
+	}
+	out := make([]domain.Mutation, 0, len(p.backlog))
+	for _, m := range p.backlog {
+		if m.Seq > seq {
+			out = append(out, m)
+		}
+	}
+	return out, false
+}
+
+// sendSnapshot takes a database snapshot and ships it, returning its sequence number.
+func (p *Primary) sendSnapshot(c *conn) (uint64, error) {
+	dir, err := os.MkdirTemp(p.config.SnapshotDir, "golite-repl-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.db")
+	seq, err := p.db.SnapshotWithSeq(path)
+	if err != nil {
+		return 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	wal, err := os.ReadFile(path + ".wal")
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	msg := &message{Kind: msgSnapshot, RunID: p.runID, Seq: seq, Snapshot: data, SnapshotWAL: wal}
+	if err := c.send(msg); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// readAcks records replica acknowledgements until the connection closes.
+func (p *Primary) readAcks(s *session) {
+	for {
+		m, err := s.c.recv()
+		if err != nil {
+			s.disconnect()
+			return
+		}
+		if m.Kind != msgAck {
+			continue
+		}
+		p.mu.Lock()
+		if _, ok := p.acked[s]; ok && m.Seq > p.acked[s] {
+			p.acked[s] = m.Seq
+			p.ackCond.Broadcast()
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *Primary) dropSession(s *session) {
+	p.mu.Lock()
+	delete(p.sessions, s)
+	delete(p.acked, s)
+	p.ackCond.Broadcast()
+	p.mu.Unlock()
+	s.disconnect()
+}
+
+// WaitForReplicas blocks until at least followers replicas acknowledged seq, or ctx ends.
+func (p *Primary) WaitForReplicas(ctx context.Context, seq uint64, followers int) error {
+	stop := context.AfterFunc(ctx, func() {
+		p.mu.Lock()
+		p.ackCond.Broadcast()
+		p.mu.Unlock()
+	})
+	defer stop()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.closed {
+			return ErrClosed
+		}
+		count := 0
+		for _, acked := range p.acked {
+			if acked >= seq {
+				count++
+			}
+		}
+		if count >= followers {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.ackCond.Wait()
+	}
+}
+
+// Replicas returns the number of connected replicas.
+func (p *Primary) Replicas() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+// Close stops accepting replicas, disconnects existing ones and detaches from the database.
+func (p *Primary) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	for s := range p.sessions {
+		s.disconnect()
+		s.c.Close()
+	}
+	p.ackCond.Broadcast()
+	p.mu.Unlock()
+
+	if p.remove != nil {
+		p.remove()
+	}
+	p.db.SetReplicaAcknowledger(nil)
+	var err error
+	if p.listener != nil {
+		err = p.listener.Close()
+	}
+	waitTimeout(&p.wg, 5*time.Second)
+	return err
+}
+
+// waitTimeout waits for wg but gives up after timeout.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
@@ -0,0 +1,61 @@
+// Package replication은 primary/replica 비동기 복제를 구현합니다.
+// Primary는 커밋된 변경(domain.Mutation)을 TCP로 스트리밍하고, Replica는 이를 순서대로 적용합니다.
+// 따라잡을 수 없을 만큼 뒤처진 Replica는 스냅샷으로부터 다시 시작합니다.
+package replication
+
+import (
+	"encoding/gob"
+	"errors"
+	"net"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// ErrClosed is returned when operating on a closed primary or replica.
+var ErrClosed = errors.New("replication is closed")
+
+type messageKind uint8
+
+const (
+	msgHello    messageKind = iota + 1 // replica -> primary: resume position
+	msgSnapshot                        // primary -> replica: full database image
+	msgMutation                        // primary -> replica: committed change
+	msgAck                             // replica -> primary: applied up to Seq
+)
+
+// message is the single frame type exchanged on a replication connection.
+type message struct {
+	Kind        messageKind
+	RunID       string // Identifies the primary's lifetime; a mismatch forces a snapshot
+	Seq         uint64
+	Mutation    domain.Mutation
+	Snapshot    []byte // Database file image
+	SnapshotWAL []byte // Optional WAL sidecar image
+}
+
+// conn wraps a TCP connection with gob framing.
+type conn struct {
+	raw net.Conn
+	enc *gob.Encoder
+	dec *gob.Decoder
+}
+
+func newConn(raw net.Conn) *conn {
+	return &conn{raw: raw, enc: gob.NewEncoder(raw), dec: gob.NewDecoder(raw)}
+}
+
+func (c *conn) send(m *message) error {
+	return c.enc.Encode(m)
+}
+
+func (c *conn) recv() (*message, error) {
+	var m message
+	if err := c.dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (c *conn) Close() error {
+	return c.raw.Close()
+}
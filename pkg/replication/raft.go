@@ -0,0 +1,699 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// Raft 합의 모드: 모든 변경은 리더의 로그에 추가되고, 과반수 노드에 복제(commit)된 뒤에만
+// 각 노드의 데이터베이스에 순서대로 적용됩니다. 로그 인덱스가 곧 domain.Mutation.Seq가 됩니다.
+
+// RaftRole is the role of a node in the cluster.
+type RaftRole int
+
+const (
+	RaftFollower RaftRole = iota
+	RaftCandidate
+	RaftLeader
+)
+
+// String returns a human-readable name for the role.
+func (r RaftRole) String() string {
+	switch r {
+	case RaftFollower:
+		return "follower"
+	case RaftCandidate:
+		return "candidate"
+	case RaftLeader:
+		return "leader"
+	default:
+		return fmt.Sprintf("RaftRole(%d)", int(r))
+	}
+}
+
+var (
+	// ErrNotLeader is matched (errors.Is) when a proposal is sent to a non-leader node.
+	ErrNotLeader = errors.New("not the raft leader")
+
+	// ErrLeadershipLost is returned when the proposed entry was replaced by another leader's log.
+	ErrLeadershipLost = errors.New("leadership lost before the entry committed")
+)
+
+// NotLeaderError carries the ID of the current leader, if known, so clients can redirect.
+type NotLeaderError struct {
+	Leader string
+}
+
+func (e *NotLeaderError) Error() string {
+	if e.Leader == "" {
+		return "not the raft leader (leader unknown)"
+	}
+	return fmt.Sprintf("not the raft leader (leader is %s)", e.Leader)
+}
+
+// Is reports whether target is ErrNotLeader.
+func (e *NotLeaderError) Is(target error) bool {
+	return target == ErrNotLeader
+}
+
+// LogEntry is one replicated log record. A zero Mutation.Op marks a leader no-op entry.
+type LogEntry struct {
+	Term     uint64
+	Index    uint64
+	Mutation domain.Mutation
+}
+
+// VoteRequest is the RequestVote RPC argument.
+type VoteRequest struct {
+	Term         uint64
+	CandidateID  string
+	LastLogIndex uint64
+	LastLogTerm  uint64
+}
+
+// VoteResponse is the RequestVote RPC result.
+type VoteResponse struct {
+	Term    uint64
+	Granted bool
+}
+
+// AppendRequest is the AppendEntries RPC argument (also used as heartbeat).
+type AppendRequest struct {
+	Term         uint64
+	LeaderID     string
+	PrevLogIndex uint64
+	PrevLogTerm  uint64
+	Entries      []LogEntry
+	LeaderCommit uint64
+}
+
+// AppendResponse is the AppendEntries RPC result. LastIndex is the follower's last log
+// index, used by the leader to skip back quickly after a consistency check failure.
+type AppendResponse struct {
+	Term      uint64
+	Success   bool
+	LastIndex uint64
+}
+
+// RaftTransport delivers RPCs to other cluster members identified by node ID.
+type RaftTransport interface {
+	RequestVote(ctx context.Context, peer string, req *VoteRequest) (*VoteResponse, error)
+	AppendEntries(ctx context.Context, peer string, req *AppendRequest) (*AppendResponse, error)
+}
+
+// RaftConfig configures a Raft node.
+type RaftConfig struct {
+	ID                string        // Unique node ID
+	Peers             []string      // IDs of the other cluster members
+	Transport         RaftTransport // RPC transport to peers
+	ElectionTimeout   time.Duration // Base election timeout, randomized in [t, 2t) (default 300ms)
+	HeartbeatInterval time.Duration // Leader heartbeat interval (default 50ms)
+	MaxAppendEntries  int           // Max entries per AppendEntries RPC (default 64)
+	StatePath         string        // Optional file persisting the current term and vote
+}
+
+// raftState is the persisted part of the node state.
+type raftState struct {
+	Term     uint64
+	VotedFor string
+}
+
+// raftWaiter is a pending Propose call waiting for its entry to be applied.
+type raftWaiter struct {
+	term uint64
+	ch   chan error
+}
+
+// RaftNode is a member of a Raft cluster that applies committed entries to a Database.
+//
+// 로그는 메모리에 유지됩니다. 재시작한 노드는 리더로부터 로그를 다시 받아 적용하며,
+// ApplyMutation이 멱등이므로 이미 반영된 변경의 재적용은 안전합니다.
+type RaftNode struct {
+	config RaftConfig
+	db     *domain.Database
+	logger utils.Logger
+
+	mu               sync.Mutex
+	role             RaftRole
+	term             uint64
+	votedFor         string
+	leaderID         string
+	log              []LogEntry // log[0] is a sentinel (Index 0, Term 0)
+	commitIndex      uint64
+	lastApplied      uint64
+	nextIndex        map[string]uint64
+	matchIndex       map[string]uint64
+	inflight         map[string]bool
+	waiters          map[uint64]raftWaiter
+	electionDeadline time.Time
+	lastHeartbeat    time.Time
+	closed           bool
+
+	applyCh     chan struct{}
+	replicateCh chan struct{}
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewRaftNode creates a node that applies committed entries to db. Call Start to join the cluster.
+func NewRaftNode(db *domain.Database, config RaftConfig, logger utils.Logger) (*RaftNode, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+	if config.ID == "" {
+		return nil, fmt.Errorf("node id is required")
+	}
+	if config.Transport == nil && len(config.Peers) > 0 {
+		return nil, fmt.Errorf("transport is required for a multi-node cluster")
+	}
+	if config.ElectionTimeout <= 0 {
+		config.ElectionTimeout = 300 * time.Millisecond
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 50 * time.Millisecond
+	}
+	if config.MaxAppendEntries <= 0 {
+		config.MaxAppendEntries = 64
+	}
+	n := &RaftNode{
+		config:      config,
+		db:          db,
+		logger:      logger,
+		log:         []LogEntry{{}},
+		nextIndex:   make(map[string]uint64),
+		matchIndex:  make(map[string]uint64),
+		inflight:    make(map[string]bool),
+		waiters:     make(map[uint64]raftWaiter),
+		applyCh:     make(chan struct{}, 1),
+		replicateCh: make(chan struct{}, 1),
+		stopCh:      make(chan struct{}),
+	}
+	if err := n.loadState(); err != nil {
+		return nil, fmt.Errorf("failed to load raft state: %v", err)
+	}
+	return n, nil
+}
+
+// Start begins the election timer and the apply loop.
+func (n *RaftNode) Start() {
+	n.mu.Lock()
+	n.resetElectionDeadline()
+	n.mu.Unlock()
+	n.wg.Add(2)
+	go n.run()
+	go n.applyLoop()
+	n.logger.Info("Raft node started", "node", n.config.ID, "peers", len(n.config.Peers), "term", n.Term())
+}
+
+// ID returns the node ID.
+func (n *RaftNode) ID() string {
+	return n.config.ID
+}
+
+// Role returns the node's current role.
+func (n *RaftNode) Role() RaftRole {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role
+}
+
+// IsLeader reports whether the node is currently the leader.
+func (n *RaftNode) IsLeader() bool {
+	return n.Role() == RaftLeader
+}
+
+// Leader returns the ID of the current leader, or "" if unknown.
+func (n *RaftNode) Leader() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.leaderID
+}
+
+// Term returns the node's current term.
+func (n *RaftNode) Term() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.term
+}
+
+// CommitIndex returns the index of the highest entry known to be committed.
+func (n *RaftNode) CommitIndex() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.commitIndex
+}
+
+// AppliedIndex returns the index of the last entry applied to the database.
+func (n *RaftNode) AppliedIndex() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.lastApplied
+}
+
+// Propose appends m to the replicated log and blocks until it is committed and applied
+// locally, returning the error from applying it. Only the leader accepts proposals.
+func (n *RaftNode) Propose(ctx context.Context, m domain.Mutation) error {
+	if m.Op == 0 {
+		return fmt.Errorf("mutation op is required")
+	}
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return ErrClosed
+	}
+	if n.role != RaftLeader {
+		leader := n.leaderID
+		n.mu.Unlock()
+		return &NotLeaderError{Leader: leader}
+	}
+	index := n.appendLocal(m)
+	ch := make(chan error, 1)
+	n.waiters[index] = raftWaiter{term: n.term, ch: ch}
+	n.advanceCommit()
+	n.mu.Unlock()
+	n.triggerReplicate()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		n.mu.Lock()
+		delete(n.waiters, index)
+		n.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// WaitForLeader blocks until a leader is known or ctx is done.
+func (n *RaftNode) WaitForLeader(ctx context.Context) (string, error) {
+	ticker := time.NewTicker(n.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		if leader := n.Leader(); leader != "" {
+			return leader, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// Close stops the node. Pending proposals fail with ErrClosed.
+func (n *RaftNode) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	for index, w := range n.waiters {
+		w.ch <- ErrClosed
+		delete(n.waiters, index)
+	}
+	n.mu.Unlock()
+	close(n.stopCh)
+	n.wg.Wait()
+	return nil
+}
+
+// HandleRequestVote processes a RequestVote RPC from a candidate.
+func (n *RaftNode) HandleRequestVote(req *VoteRequest) *VoteResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.Term < n.term {
+		return &VoteResponse{Term: n.term}
+	}
+	if req.Term > n.term {
+		n.stepDown(req.Term)
+	}
+	last := n.log[len(n.log)-1]
+	upToDate := req.LastLogTerm > last.Term || (req.LastLogTerm == last.Term && req.LastLogIndex >= last.Index)
+	if (n.votedFor == "" || n.votedFor == req.CandidateID) && upToDate {
+		n.votedFor = req.CandidateID
+		if err := n.saveState(); err != nil {
+			// 투표를 기록할 수 없으면 이중 투표 위험이 있으므로 거부.
+			n.votedFor = ""
+			return &VoteResponse{Term: n.term}
+		}
+		n.resetElectionDeadline()
+		return &VoteResponse{Term: n.term, Granted: true}
+	}
+	return &VoteResponse{Term: n.term}
+}
+
+// HandleAppendEntries processes an AppendEntries RPC from the leader.
+func (n *RaftNode) HandleAppendEntries(req *AppendRequest) *AppendResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.Term < n.term {
+		return &AppendResponse{Term: n.term, LastIndex: n.lastIndex()}
+	}
+	if req.Term > n.term || n.role != RaftFollower {
+		n.stepDown(req.Term)
+	}
+	n.leaderID = req.LeaderID
+	n.resetElectionDeadline()
+
+	if req.PrevLogIndex > n.lastIndex() {
+		return &AppendResponse{Term: n.term, LastIndex: n.lastIndex()}
+	}
+	if n.log[req.PrevLogIndex].Term != req.PrevLogTerm {
+		return &AppendResponse{Term: n.term, LastIndex: req.PrevLogIndex - 1}
+	}
+	for _, e := range req.Entries {
+		if e.Index <= n.lastIndex() {
+			if n.log[e.Index].Term == e.Term {
+				continue // already have it (duplicate or reordered RPC)
+			}
+			n.log = n.log[:e.Index]
+		}
+		n.log = append(n.log, e)
+	}
+	if req.LeaderCommit > n.commitIndex {
+		newLast := req.PrevLogIndex + uint64(len(req.Entries))
+		n.commitIndex = min(req.LeaderCommit, newLast)
+		n.signal(n.applyCh)
+	}
+	return &AppendResponse{Term: n.term, Success: true, LastIndex: n.lastIndex()}
+}
+
+// run drives election timeouts and leader heartbeats.
+func (n *RaftNode) run() {
+	defer n.wg.Done()
+	tick := n.config.HeartbeatInterval / 2
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-n.replicateCh:
+			n.broadcast()
+		case now := <-ticker.C:
+			n.mu.Lock()
+			role := n.role
+			heartbeat := now.Sub(n.lastHeartbeat) >= n.config.HeartbeatInterval
+			election := role != RaftLeader && now.After(n.electionDeadline)
+			n.mu.Unlock()
+			if role == RaftLeader && heartbeat {
+				n.broadcast()
+			} else if election {
+				n.startElection()
+			}
+		}
+	}
+}
+
+// startElection becomes a candidate for the next term and requests votes from all peers.
+func (n *RaftNode) startElection() {
+	n.mu.Lock()
+	n.role = RaftCandidate
+	n.term++
+	n.votedFor = n.config.ID
+	n.leaderID = ""
+	if err := n.saveState(); err != nil {
+		n.logger.Warn("Raft node failed to persist state", "node", n.config.ID, "error", err)
+	}
+	n.resetElectionDeadline()
+	last := n.log[len(n.log)-1]
+	req := &VoteRequest{Term: n.term, CandidateID: n.config.ID, LastLogIndex: last.Index, LastLogTerm: last.Term}
+	votes := 1
+	if votes >= n.quorum() {
+		n.becomeLeader()
+		n.mu.Unlock()
+		return
+	}
+	n.mu.Unlock()
+
+	for _, peer := range n.config.Peers {
+		go func(peer string) {
+			ctx, cancel := context.WithTimeout(context.Background(), n.config.ElectionTimeout)
+			defer cancel()
+			resp, err := n.config.Transport.RequestVote(ctx, peer, req)
+			if err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if resp.Term > n.term {
+				n.stepDown(resp.Term)
+				return
+			}
+			if n.role != RaftCandidate || n.term != req.Term || !resp.Granted {
+				return
+			}
+			votes++
+			if votes >= n.quorum() {
+				n.becomeLeader()
+			}
+		}(peer)
+	}
+}
+
+// becomeLeader switches to leader and appends a no-op so entries from earlier terms commit.
+// Caller holds n.mu.
+func (n *RaftNode) becomeLeader() {
+	n.role = RaftLeader
+	n.leaderID = n.config.ID
+	for _, peer := range n.config.Peers {
+		n.nextIndex[peer] = n.lastIndex() + 1
+		n.matchIndex[peer] = 0
+	}
+	n.appendLocal(domain.Mutation{})
+	n.advanceCommit()
+	n.lastHeartbeat = time.Time{}
+	n.signal(n.replicateCh)
+	n.logger.Info("Raft node became leader", "node", n.config.ID, "term", n.term)
+}
+
+// stepDown reverts to follower, adopting term if it is newer. Caller holds n.mu.
+func (n *RaftNode) stepDown(term uint64) {
+	if term > n.term {
+		n.term = term
+		n.votedFor = ""
+		n.leaderID = ""
+		if err := n.saveState(); err != nil {
+			n.logger.Warn("Raft node failed to persist state", "node", n.config.ID, "error", err)
+		}
+	}
+	if n.role != RaftFollower {
+		n.role = RaftFollower
+		n.resetElectionDeadline()
+	}
+}
+
+// broadcast sends AppendEntries (or a heartbeat) to every peer without a request in flight.
+func (n *RaftNode) broadcast() {
+	n.mu.Lock()
+	if n.role != RaftLeader {
+		n.mu.Unlock()
+		return
+	}
+	n.lastHeartbeat = time.Now()
+	n.mu.Unlock()
+	for _, peer := range n.config.Peers {
+		go n.replicateTo(peer)
+	}
+}
+
+// replicateTo sends one AppendEntries RPC to peer and processes the response.
+func (n *RaftNode) replicateTo(peer string) {
+	n.mu.Lock()
+	if n.role != RaftLeader || n.inflight[peer] || n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.inflight[peer] = true
+	next := n.nextIndex[peer]
+	end := min(n.lastIndex()+1, next+uint64(n.config.MaxAppendEntries))
+	req := &AppendRequest{
+		Term:         n.term,
+		LeaderID:     n.config.ID,
+		PrevLogIndex: next - 1,
+		PrevLogTerm:  n.log[next-1].Term,
+		Entries:      append([]LogEntry(nil), n.log[next:end]...),
+		LeaderCommit: n.commitIndex,
+	}
+	n.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.config.ElectionTimeout)
+	resp, err := n.config.Transport.AppendEntries(ctx, peer, req)
+	cancel()
+
+	n.mu.Lock()
+	n.inflight[peer] = false
+	if err != nil {
+		n.mu.Unlock()
+		return
+	}
+	if resp.Term > n.term {
+		n.stepDown(resp.Term)
+		n.mu.Unlock()
+		return
+	}
+	if n.role != RaftLeader || n.term != req.Term {
+		n.mu.Unlock()
+		return
+	}
+	if resp.Success {
+		match := req.PrevLogIndex + uint64(len(req.Entries))
+		if match > n.matchIndex[peer] {
+			n.matchIndex[peer] = match
+		}
+		n.nextIndex[peer] = max(n.nextIndex[peer], match+1)
+		n.advanceCommit()
+	} else {
+		// 일관성 검사 실패: 팔로워의 마지막 인덱스 힌트를 이용해 nextIndex를 되돌림.
+		n.nextIndex[peer] = max(1, min(next-1, resp.LastIndex+1))
+	}
+	more := n.nextIndex[peer] <= n.lastIndex()
+	n.mu.Unlock()
+	if more {
+		go n.replicateTo(peer)
+	}
+}
+
+// advanceCommit commits the highest entry of the current term stored on a majority.
+// Caller holds n.mu.
+func (n *RaftNode) advanceCommit() {
+	for index := n.lastIndex(); index > n.commitIndex; index-- {
+		if n.log[index].Term != n.term {
+			break // 이전 term의 엔트리는 직접 카운트하지 않음 (Raft §5.4.2).
+		}
+		count := 1
+		for _, peer := range n.config.Peers {
+			if n.matchIndex[peer] >= index {
+				count++
+			}
+		}
+		if count >= n.quorum() {
+			n.commitIndex = index
+			n.signal(n.applyCh)
+			return
+		}
+	}
+}
+
+// applyLoop applies committed entries to the database in log order.
+func (n *RaftNode) applyLoop() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-n.applyCh:
+		}
+		for {
+			n.mu.Lock()
+			if n.lastApplied >= n.commitIndex {
+				n.mu.Unlock()
+				break
+			}
+			entries := append([]LogEntry(nil), n.log[n.lastApplied+1:n.commitIndex+1]...)
+			n.mu.Unlock()
+
+			for _, e := range entries {
+				var err error
+				if e.Mutation.Op != 0 {
+					m := e.Mutation
+					m.Seq = e.Index
+					err = n.db.ApplyMutation(m)
+					if err != nil {
+						n.logger.Warn("Raft node failed to apply entry", "node", n.config.ID, "index", e.Index, "error", err)
+					}
+				}
+				n.mu.Lock()
+				n.lastApplied = e.Index
+				if w, ok := n.waiters[e.Index]; ok {
+					delete(n.waiters, e.Index)
+					if w.term != e.Term {
+						err = ErrLeadershipLost
+					}
+					w.ch <- err
+				}
+				n.mu.Unlock()
+			}
+		}
+	}
+}
+
+// appendLocal appends m to the leader's log and returns its index. Caller holds n.mu.
+func (n *RaftNode) appendLocal(m domain.Mutation) uint64 {
+	index := n.lastIndex() + 1
+	n.log = append(n.log, LogEntry{Term: n.term, Index: index, Mutation: m})
+	return index
+}
+
+func (n *RaftNode) lastIndex() uint64 {
+	return n.log[len(n.log)-1].Index
+}
+
+func (n *RaftNode) quorum() int {
+	return (len(n.config.Peers)+1)/2 + 1
+}
+
+// resetElectionDeadline picks a new randomized election timeout. Caller holds n.mu.
+func (n *RaftNode) resetElectionDeadline() {
+	timeout := n.config.ElectionTimeout
+	n.electionDeadline = time.Now().Add(timeout + time.Duration(rand.Int63n(int64(timeout))))
+}
+
+func (n *RaftNode) triggerReplicate() {
+	n.signal(n.replicateCh)
+}
+
+// signal performs a non-blocking send on a wake-up channel.
+func (n *RaftNode) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// saveState persists the current term and vote. Caller holds n.mu.
+func (n *RaftNode) saveState() error {
+	if n.config.StatePath == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(raftState{Term: n.term, VotedFor: n.votedFor}); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(n.config.StatePath, buf.Bytes())
+}
+
+// loadState restores the persisted term and vote, if any.
+func (n *RaftNode) loadState() error {
+	if n.config.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(n.config.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st raftState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&st); err != nil {
+		return err
+	}
+	n.term = st.Term
+	n.votedFor = st.VotedFor
+	return nil
+}
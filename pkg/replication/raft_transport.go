@@ -0,0 +1,225 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+// InmemNetwork connects Raft nodes running in the same process. It supports
+// disconnecting nodes to simulate network partitions.
+type InmemNetwork struct {
+	mu    sync.RWMutex
+	nodes map[string]*RaftNode
+	down  map[string]bool
+}
+
+// NewInmemNetwork creates an empty in-process network.
+func NewInmemNetwork() *InmemNetwork {
+	return &InmemNetwork{nodes: make(map[string]*RaftNode), down: make(map[string]bool)}
+}
+
+// Transport returns the endpoint used by the node with the given ID.
+func (nw *InmemNetwork) Transport(id string) RaftTransport {
+	return &inmemTransport{network: nw, from: id}
+}
+
+// Register makes node reachable under its ID.
+func (nw *InmemNetwork) Register(node *RaftNode) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	nw.nodes[node.ID()] = node
+}
+
+// Disconnect drops all traffic to and from id.
+func (nw *InmemNetwork) Disconnect(id string) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	nw.down[id] = true
+}
+
+// Reconnect restores traffic to and from id.
+func (nw *InmemNetwork) Reconnect(id string) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	delete(nw.down, id)
+}
+
+func (nw *InmemNetwork) route(from, to string) (*RaftNode, error) {
+	nw.mu.RLock()
+	defer nw.mu.RUnlock()
+	if nw.down[from] || nw.down[to] {
+		return nil, fmt.Errorf("raft peer %s unreachable from %s", to, from)
+	}
+	node, ok := nw.nodes[to]
+	if !ok {
+		return nil, fmt.Errorf("unknown raft peer %s", to)
+	}
+	return node, nil
+}
+
+type inmemTransport struct {
+	network *InmemNetwork
+	from    string
+}
+
+func (t *inmemTransport) RequestVote(ctx context.Context, peer string, req *VoteRequest) (*VoteResponse, error) {
+	node, err := t.network.route(t.from, peer)
+	if err != nil {
+		return nil, err
+	}
+	return node.HandleRequestVote(req), nil
+}
+
+func (t *inmemTransport) AppendEntries(ctx context.Context, peer string, req *AppendRequest) (*AppendResponse, error) {
+	node, err := t.network.route(t.from, peer)
+	if err != nil {
+		return nil, err
+	}
+	// 요청을 복사해 노드 간 슬라이스 공유를 방지.
+	cp := *req
+	cp.Entries = append([]LogEntry(nil), req.Entries...)
+	return node.HandleAppendEntries(&cp), nil
+}
+
+// RaftServer exposes a RaftNode to remote peers over TCP (net/rpc with gob encoding).
+type RaftServer struct {
+	listener net.Listener
+	server   *rpc.Server
+	wg       sync.WaitGroup
+}
+
+// raftRPC is the net/rpc service wrapping a node.
+type raftRPC struct {
+	node *RaftNode
+}
+
+func (r *raftRPC) RequestVote(req *VoteRequest, resp *VoteResponse) error {
+	*resp = *r.node.HandleRequestVote(req)
+	return nil
+}
+
+func (r *raftRPC) AppendEntries(req *AppendRequest, resp *AppendResponse) error {
+	*resp = *r.node.HandleAppendEntries(req)
+	return nil
+}
+
+// ServeRaft listens on addr and serves Raft RPCs for node.
+func ServeRaft(node *RaftNode, addr string) (*RaftServer, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Raft", &raftRPC{node: node}); err != nil {
+		return nil, fmt.Errorf("failed to register raft service: %v", err)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+	s := &RaftServer{listener: ln, server: server}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		server.Accept(ln)
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *RaftServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops accepting connections.
+func (s *RaftServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// TCPTransport sends Raft RPCs to peers served by ServeRaft.
+type TCPTransport struct {
+	addrs   map[string]string // peer ID -> address
+	mu      sync.Mutex
+	clients map[string]*rpc.Client
+}
+
+// NewTCPTransport creates a transport for the given peer ID -> address map.
+func NewTCPTransport(addrs map[string]string) *TCPTransport {
+	return &TCPTransport{addrs: addrs, clients: make(map[string]*rpc.Client)}
+}
+
+func (t *TCPTransport) RequestVote(ctx context.Context, peer string, req *VoteRequest) (*VoteResponse, error) {
+	resp := &VoteResponse{}
+	if err := t.call(ctx, peer, "Raft.RequestVote", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *TCPTransport) AppendEntries(ctx context.Context, peer string, req *AppendRequest) (*AppendResponse, error) {
+	resp := &AppendResponse{}
+	if err := t.call(ctx, peer, "Raft.AppendEntries", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// call invokes method on peer, dialing lazily and dropping the connection on failure.
+func (t *TCPTransport) call(ctx context.Context, peer, method string, args, reply interface{}) error {
+	client, err := t.client(ctx, peer)
+	if err != nil {
+		return err
+	}
+	call := client.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if call.Error != nil {
+			t.drop(peer, client)
+		}
+		return call.Error
+	case <-ctx.Done():
+		t.drop(peer, client)
+		return ctx.Err()
+	}
+}
+
+func (t *TCPTransport) client(ctx context.Context, peer string) (*rpc.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.clients[peer]; ok {
+		return c, nil
+	}
+	addr, ok := t.addrs[peer]
+	if !ok {
+		return nil, fmt.Errorf("unknown raft peer %s", peer)
+	}
+	var d net.Dialer
+	raw, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := rpc.NewClient(raw)
+	t.clients[peer] = c
+	return c, nil
+}
+
+func (t *TCPTransport) drop(peer string, c *rpc.Client) {
+	t.mu.Lock()
+	if t.clients[peer] == c {
+		delete(t.clients, peer)
+	}
+	t.mu.Unlock()
+	c.Close()
+}
+
+// Close closes all peer connections.
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for peer, c := range t.clients {
+		c.Close()
+		delete(t.clients, peer)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package replication
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// OpenFunc opens (or reopens) the replica's local database at filePath.
+type OpenFunc func(filePath string) (*domain.Database, error)
+
+// ReplicaConfig configures a replication replica.
+type ReplicaConfig struct {
+	PrimaryAddr   string        // Address of the primary to follow
+	FilePath      string        // Local database file the replica maintains
+	RetryInterval time.Duration // Delay between reconnect attempts (default 1s)
+}
+
+// Replica follows a primary and applies its mutations to a local database.
+// The local database should be treated as read-only by the application.
+type Replica struct {
+	config ReplicaConfig
+	open   OpenFunc
+	logger utils.Logger
+
+	mu      sync.RWMutex
+	db      *domain.Database
+	runID   string
+	lastSeq uint64
+	conn    *conn
+	closed  bool
+	err     error // 실패한 스냅샷 설치 뒤 이전 데이터베이스도 다시 열지 못한 오류 (설정되면 db는 nil)
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewReplica opens the local database through open and prepares to follow the primary.
+func NewReplica(config ReplicaConfig, open OpenFunc, logger utils.Logger) (*Replica, error) {
+	if config.PrimaryAddr == "" || config.FilePath == "" {
+		return nil, fmt.Errorf("primary address and file path are required")
+	}
+	if open == nil {
+		return nil, fmt.Errorf("open function is required")
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+	db, err := open(config.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica database: %v", err)
+	}
+	return &Replica{
+		config: config,
+		open:   open,
+		logger: logger,
+		db:     db,
+		stopCh: make(chan struct{}),
+	}, nil
+}
+
+// Start begins following the primary in the background, reconnecting on failure.
+func (r *Replica) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// DB returns the current local database. It changes after a snapshot install,
+// so callers should not cache the returned pointer across long periods.
+// It is nil once the replica has failed (see Err).
+func (r *Replica) DB() *domain.Database {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.db
+}
+
+// Err returns the error that stopped the replica, or nil while it can follow the primary.
+func (r *Replica) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// LastSeq returns the primary sequence number of the last applied mutation.
+func (r *Replica) LastSeq() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastSeq
+}
+
+func (r *Replica) run() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		default:
+		}
+		raw, err := net.DialTimeout("tcp", r.config.PrimaryAddr, r.config.RetryInterval)
+		if err == nil {
+			err = r.follow(newConn(raw))
+		}
+		if err != nil {
+			r.logger.Warn("Replication interrupted", "primary", r.config.PrimaryAddr, "error", err)
+		}
+		if err := r.Err(); err != nil {
+			r.logger.Error("Replica stopped", "primary", r.config.PrimaryAddr, "error", err)
+			return
+		}
+		select {
+		case <-r.stopCh:
+			return
+		case <-time.After(r.config.RetryInterval):
+		}
+	}
+}
+
+// follow runs one connection: handshake, then apply frames until an error occurs.
+func (r *Replica) follow(c *conn) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		c.Close()
+		return nil
+	}
+	r.conn = c
+	hello := &message{Kind: msgHello, RunID: r.runID, Seq: r.lastSeq}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.conn = nil
+		r.mu.Unlock()
+		c.Close()
+	}()
+
+	if err := c.send(hello); err != nil {
+		return err
+	}
+	for {
+		m, err := c.recv()
+		if err != nil {
+			return err
+		}
+		switch m.Kind {
+		case msgSnapshot:
+			if err := r.installSnapshot(m); err != nil {
+				return fmt.Errorf("failed to install snapshot: %v", err)
+			}
+		case msgMutation:
+			// 빠진 변경 위에 적용하면 primary와 어긋나므로 끊고 다시 연결해 그 위치부터 받음
+			if next := r.LastSeq() + 1; m.Mutation.Seq != next {
+				return fmt.Errorf("received mutation %d, expected %d", m.Mutation.Seq, next)
+			}
+			if err := r.DB().ApplyMutation(m.Mutation); err != nil {
+				return fmt.Errorf("failed to apply mutation %d: %v", m.Mutation.Seq, err)
+			}
+			r.mu.Lock()
+			r.lastSeq = m.Mutation.Seq
+			r.mu.Unlock()
+		default:
+			continue
+		}
+		if err := c.send(&message{Kind: msgAck, Seq: r.LastSeq()}); err != nil {
+			return err
+		}
+	}
+}
+
+// installSnapshot replaces the local database with the primary's snapshot and reopens it.
+// If the snapshot cannot be restored or opened, the previous database is reopened; if that
+// fails too, the replica stops (see Err).
+func (r *Replica) installSnapshot(m *message) error {
+	incoming := r.config.FilePath + ".incoming"
+	defer os.Remove(incoming)
+	defer os.Remove(incoming + ".wal")
+	if err := os.WriteFile(incoming, m.Snapshot, 0666); err != nil {
+		return err
+	}
+	if m.SnapshotWAL != nil {
+		if err := os.WriteFile(incoming+".wal", m.SnapshotWAL, 0666); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 파일이 잠겨 있어 새 데이터베이스를 열기 전에 닫아야 함. 이전 파일은 열릴 때까지 옆에 남겨 둠
+	if err := r.db.Close(); err != nil {
+		r.logger.Warn("Failed to close replica database before snapshot install", "error", err)
+	}
+	r.db = nil
+	db, err := r.swapSnapshot(incoming)
+	if err != nil {
+		old, reopenErr := r.open(r.config.FilePath)
+		if reopenErr != nil {
+			r.err = fmt.Errorf("failed to reopen replica database after snapshot install failed: %v", reopenErr)
+			return err
+		}
+		r.db = old
+		return err
+	}
+	r.db = db
+	r.runID = m.RunID
+	r.lastSeq = m.Seq
+	r.logger.Info("Installed snapshot", "seq", m.Seq, "primary", r.config.PrimaryAddr)
+	return nil
+}
+
+// swapSnapshot moves the closed database files aside, restores incoming in their place and
+// opens it. On failure the previous files are moved back. Caller holds r.mu.
+func (r *Replica) swapSnapshot(incoming string) (*domain.Database, error) {
+	path := r.config.FilePath
+	previous := path + ".previous"
+	removeDatabaseFiles(previous)
+	if err := moveDatabaseFiles(path, previous); err != nil {
+		moveDatabaseFiles(previous, path)
+		return nil, err
+	}
+	err := domain.RestoreFromSnapshot(incoming, path)
+	var db *domain.Database
+	if err == nil {
+		db, err = r.open(path)
+	}
+	if err != nil {
+		removeDatabaseFiles(path)
+		if moveErr := moveDatabaseFiles(previous, path); moveErr != nil {
+			r.logger.Warn("Failed to restore replica database files", "error", moveErr)
+		}
+		return nil, err
+	}
+	removeDatabaseFiles(previous)
+	return db, nil
+}
+
+// moveDatabaseFiles renames the database file at from and its WAL, if any, to to.
+func moveDatabaseFiles(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	if err := os.Rename(from+".wal", to+".wal"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeDatabaseFiles removes the database file at path and its WAL.
+func removeDatabaseFiles(path string) {
+	os.Remove(path)
+	os.Remove(path + ".wal")
+}
+
+// Close stops following the primary and closes the local database.
+func (r *Replica) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.stopCh)
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	r.mu.Unlock()
+	waitTimeout(&r.wg, 5*time.Second)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sukryu/GoLite/pkg/application"
+)
+
+// API 토큰 인증: ACL은 토큰마다 테이블별 읽기/쓰기 권한을 보관하는 application.Authorizer입니다.
+// CommandHandler.SetAuthorizer와 QueryHandler.SetAuthorizer에 넘기면 명령과 질의를 실행하기 전에
+// 컨텍스트의 토큰(WithToken)으로 권한을 검사합니다. 토큰은 원문 대신 SHA-256 해시로 보관합니다.
+//
+// 테이블 이름 AllTables("*")로 준 권한은 모든 테이블과 데이터베이스 전체 연산(Sync, Compact,
+// Changes, GetStatus, GetSpec)에 적용됩니다. 데이터베이스 전체 연산에는 AllTables 권한이 필요하므로,
+// 헬스 핸들러(NewHealthHandler)가 ACL을 건 QueryHandler를 쓰면 요청에 AllTables 읽기 권한이 있는
+// 토큰이 있어야 합니다. HTTP 요청의 토큰은 Authenticate 미들웨어가 Authorization: Bearer 헤더에서
+// 꺼내 컨텍스트에 넣습니다.
+
+// AllTables grants a permission on every table and on the whole database.
+const AllTables = "*"
+
+type tokenKey struct{}
+
+// WithToken returns a context whose operations are authorized with the API token token.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFrom returns the API token of ctx, or "" if it has none.
+func TokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// ACL maps API tokens to per-table permissions. It is safe for concurrent use, so tokens can
+// be granted and revoked while the handlers are serving.
+type ACL struct {
+	mu     sync.RWMutex
+	tokens map[[sha256.Size]byte]map[string]application.Permission
+}
+
+var _ application.Authorizer = (*ACL)(nil)
+
+// NewACL returns an ACL without tokens, which denies every operation.
+func NewACL() *ACL {
+	return &ACL{tokens: make(map[[sha256.Size]byte]map[string]application.Permission)}
+}
+
+// Grant adds perm on table (AllTables for every table) to the permissions of token.
+func (a *ACL) Grant(token, table string, perm application.Permission) error {
+	if token == "" {
+		return fmt.Errorf("API token must not be empty")
+	}
+	if table == "" {
+		return fmt.Errorf("table name must not be empty (use %q for every table)", AllTables)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	h := sha256.Sum256([]byte(token))
+	tables := a.tokens[h]
+	if tables == nil {
+		tables = make(map[string]application.Permission)
+		a.tokens[h] = tables
+	}
+	tables[table] |= perm
+	return nil
+}
+
+// Revoke removes token and all its permissions.
+func (a *ACL) Revoke(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.tokens, sha256.Sum256([]byte(token)))
+}
+
+// Authorize implements application.Authorizer with the token of ctx (see WithToken).
+func (a *ACL) Authorize(ctx context.Context, table string, perm application.Permission) error {
+	token := TokenFrom(ctx)
+	if token == "" {
+		return fmt.Errorf("%w: no API token", application.ErrUnauthenticated)
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	tables, ok := a.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		return fmt.Errorf("%w: unknown API token", application.ErrUnauthenticated)
+	}
+	granted := tables[AllTables]
+	if table != "" {
+		granted |= tables[table]
+	}
+	if granted&perm != perm {
+		if table == "" {
+			return fmt.Errorf("%w: %s access to the database", application.ErrForbidden, perm)
+		}
+		return fmt.Errorf("%w: %s access to table %s", application.ErrForbidden, perm, table)
+	}
+	return nil
+}
+
+// Authenticate returns a handler that puts the bearer token of each request's Authorization
+// header into its context (see WithToken) before calling next. Requests without one are
+// passed on unchanged and fail authorization in the handlers.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+			r = r.WithContext(WithToken(r.Context(), token))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
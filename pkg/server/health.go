@@ -0,0 +1,93 @@
+// Package server는 GoLite 프로세스를 운영 환경(쿠버네티스 등)에 노출하는 HTTP 엔드포인트를 제공합니다.
+//
+// 헬스 핸들러(NewHealthHandler)는 두 가지 프로브를 응답합니다.
+//
+//   - /healthz: GetStatusQuery{CheckHealth: true}로 domain.Database.HealthCheck(쓰기/읽기 왕복,
+//     저장소 WAL 점검)를 실행해 모두 통과하면 200, 아니면 503을 반환합니다. 본문은 점검별 결과가
+//     담긴 JSON입니다. liveness 프로브로 쓰면 저장소가 쓰기를 받지 못하는 프로세스를 재시작합니다.
+//   - /readyz: 데이터베이스가 열려 있는지만 확인하는 가벼운 프로브입니다 (200 또는 503). 종료가
+//     시작되어 데이터베이스가 닫히면 실패해 트래픽이 빠집니다.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// DefaultHealthTimeout bounds a /healthz check when NewHealthHandler is given no timeout.
+const DefaultHealthTimeout = 5 * time.Second
+
+// healthResponse is the JSON body of /healthz and /readyz.
+type healthResponse struct {
+	Status string        `json:"status"` // ok or unhealthy
+	Error  string        `json:"error,omitempty"`
+	Checks []healthCheck `json:"checks,omitempty"`
+}
+
+type healthCheck struct {
+	Name       string  `json:"name"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// NewHealthHandler returns a handler serving /healthz and /readyz from queries' database.
+// A /healthz check that takes longer than timeout fails (DefaultHealthTimeout if <= 0).
+func NewHealthHandler(queries *application.QueryHandler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		result, err := queries.ExecuteQuery(ctx, &application.GetStatusQuery{CheckHealth: true})
+		if err != nil {
+			writeHealth(w, healthResponse{Status: "unhealthy", Error: err.Error()})
+			return
+		}
+		report := result.(domain.DatabaseStatus).Health
+		resp := healthResponse{Status: "ok"}
+		if report == nil || !report.Healthy {
+			resp.Status = "unhealthy"
+		}
+		if report != nil {
+			for _, c := range report.Checks {
+				resp.Checks = append(resp.Checks, healthCheck{
+					Name:       c.Name,
+					Error:      c.Error,
+					DurationMS: float64(c.Duration.Microseconds()) / 1000,
+				})
+			}
+		}
+		writeHealth(w, resp)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		result, err := queries.ExecuteQuery(r.Context(), &application.GetStatusQuery{})
+		switch {
+		case err != nil:
+			writeHealth(w, healthResponse{Status: "unhealthy", Error: err.Error()})
+		case !result.(domain.DatabaseStatus).Ready:
+			writeHealth(w, healthResponse{Status: "unhealthy", Error: "database is closed"})
+		default:
+			writeHealth(w, healthResponse{Status: "ok"})
+		}
+	})
+	return mux
+}
+
+// writeHealth writes resp with 200 if it is ok and 503 otherwise.
+func writeHealth(w http.ResponseWriter, resp healthResponse) {
+	code := http.StatusOK
+	if resp.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
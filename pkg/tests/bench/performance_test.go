@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
 	"github.com/sukryu/GoLite/pkg/adapters/lockfree"
 	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
 )
@@ -254,3 +255,36 @@ func BenchmarkBatchOperations(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkBtreeGetParallel는 여러 고루틴이 캐시된 B-tree 노드를 동시에 읽는 경우를 측정합니다.
+// 캐시 세그먼트 수(shards)에 따른 잠금 경합 차이를 비교합니다.
+func BenchmarkBtreeGetParallel(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			dir := createTempDir(b)
+			defer removeTempDir(b, dir)
+			f, err := os.Create(dir + "/btree.db")
+			if err != nil {
+				b.Fatalf("failed to create file: %v", err)
+			}
+			defer f.Close()
+			bt := btree.NewBtree(f, btree.BtConfig{Degree: 32, PageSize: 4096, ThreadSafe: true, CacheSize: 4096, CacheShards: shards})
+			const numKeys = 10000
+			for i := 0; i < numKeys; i++ {
+				if err := bt.Insert("key_"+strconv.Itoa(i), "value"); err != nil {
+					b.Fatalf("failed to insert: %v", err)
+				}
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var i int
+				for pb.Next() {
+					if _, err := bt.Get("key_" + strconv.Itoa(i%numKeys)); err != nil {
+						b.Fatalf("failed to get: %v", err)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,331 @@
+// Package conformance는 모든 저장소 어댑터가 지켜야 하는 동작을 검사하는 재사용 가능한 테스트 모음입니다.
+//
+// 새 어댑터는 자신의 테스트에서 Run에 Subject를 넘기기만 하면 btree, file, lsm과 같은 기준으로
+// 검증됩니다. ports.StoragePort는 그대로, types.Storage는 FromStorage로 감싸 넘깁니다.
+//
+//   - basic: 삽입, 덮어쓰기, 조회와 없는 키의 ErrKeyNotFound.
+//   - delete: 지운 키는 ErrKeyNotFound이고, 다시 삽입하면 새 값이 보이며, 빈 값은 지운 키와 다릅니다.
+//     없는 키를 지우면 nil이나 ErrKeyNotFound를 반환합니다 (types.Storage는 tombstone만 남길 수 있음).
+//   - durability: 닫았다 다시 연 저장소가 덮어쓰기와 삭제를 포함한 마지막 상태를 돌려줍니다.
+//   - concurrent: 여러 goroutine이 섞어 쓰고 읽은 뒤의 상태가, 다시 연 뒤에도, 순서대로 적용한 결과와 같습니다.
+//   - large_values: 저장소가 받아들이는 가장 큰 값까지 손상 없이 저장하고 다시 연 뒤에도 돌려줍니다.
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/types"
+)
+
+// Store is a storage implementation under test.
+type Store interface {
+	ports.StoragePort
+	Close() error
+}
+
+// Subject describes the implementation Run checks.
+type Subject struct {
+	// Open opens the store kept in dir, creating an empty one if dir holds none. Every store
+	// Open returns is closed by the suite before the next one is opened on the same dir.
+	Open func(dir string) (Store, error)
+	// MaxValue caps the size of the values written by the large_values check. 0 means 1MB or,
+	// if the store implements ports.EntryLimiter, the largest entry it always accepts.
+	MaxValue int
+}
+
+// defaultMaxValue is the largest value written when neither the subject nor the store caps it.
+const defaultMaxValue = 1 << 20
+
+// Run checks s against every conformance case, each in its own subtest and directory.
+func Run(t *testing.T, s Subject) {
+	t.Run("basic", func(t *testing.T) { testBasic(t, s) })
+	t.Run("delete", func(t *testing.T) { testDelete(t, s) })
+	t.Run("durability", func(t *testing.T) { testDurability(t, s) })
+	t.Run("concurrent", func(t *testing.T) { testConcurrent(t, s) })
+	t.Run("large_values", func(t *testing.T) { testLargeValues(t, s) })
+}
+
+// FromStorage adapts a types.Storage to Store. notFound is the error its Get and Delete
+// return for a missing key; it is reported as ports.ErrKeyNotFound. Values must be strings.
+func FromStorage(s types.Storage, notFound error) Store {
+	return &storageStore{s: s, notFound: notFound}
+}
+
+type storageStore struct {
+	s        types.Storage
+	notFound error
+}
+
+func (s *storageStore) Insert(key string, value interface{}) error {
+	v, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("unsupported value type %T", value)
+	}
+	return s.s.Insert(key, v)
+}
+
+func (s *storageStore) Get(key string) (interface{}, error) {
+	v, err := s.s.Get(key)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	return v, nil
+}
+
+func (s *storageStore) Delete(key string) error {
+	return s.translate(s.s.Delete(key))
+}
+
+func (s *storageStore) Close() error {
+	return s.s.Close()
+}
+
+func (s *storageStore) translate(err error) error {
+	if s.notFound != nil && errors.Is(err, s.notFound) {
+		return ports.ErrKeyNotFound
+	}
+	return err
+}
+
+// open opens the store in dir, failing the test on error.
+func open(t *testing.T, s Subject, dir string) Store {
+	t.Helper()
+	store, err := s.Open(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return store
+}
+
+// reopen closes store and opens the one kept in dir again.
+func reopen(t *testing.T, s Subject, store Store, dir string) Store {
+	t.Helper()
+	if err := store.Close(); err != nil {
+		t.Fatalf("close store: %v", err)
+	}
+	return open(t, s, dir)
+}
+
+// valueString returns v, which adapters return as a string or []byte, as a string.
+func valueString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// check asserts that store holds exactly want for keys, where a key missing from want must
+// not be found.
+func check(t *testing.T, store Store, keys []string, want map[string]string) {
+	t.Helper()
+	for _, key := range keys {
+		v, err := store.Get(key)
+		w, ok := want[key]
+		if !ok {
+			assert.ErrorIs(t, err, ports.ErrKeyNotFound, "deleted key %q", key)
+			continue
+		}
+		if !assert.NoError(t, err, key) {
+			continue
+		}
+		got, isString := valueString(v)
+		assert.True(t, isString, "value of %q has type %T", key, v)
+		if len(w) > 64 { // 큰 값은 차이만 보고
+			assert.True(t, got == w, "value of %q differs (%d bytes, want %d)", key, len(got), len(w))
+			continue
+		}
+		assert.Equal(t, w, got, key)
+	}
+}
+
+func testBasic(t *testing.T, s Subject) {
+	store := open(t, s, t.TempDir())
+	defer store.Close()
+
+	_, err := store.Get("missing")
+	assert.ErrorIs(t, err, ports.ErrKeyNotFound)
+	assert.NoError(t, store.Insert("a", "1"))
+	assert.NoError(t, store.Insert("b", "2"))
+	assert.NoError(t, store.Insert("a", "3"))
+	check(t, store, []string{"a", "b", "c"}, map[string]string{"a": "3", "b": "2"})
+}
+
+func testDelete(t *testing.T, s Subject) {
+	dir := t.TempDir()
+	store := open(t, s, dir)
+	defer func() { store.Close() }()
+
+	assert.NoError(t, store.Insert("gone", "v"))
+	assert.NoError(t, store.Insert("empty", ""))
+	assert.NoError(t, store.Delete("gone"))
+	if err := store.Delete("never"); err != nil {
+		assert.ErrorIs(t, err, ports.ErrKeyNotFound)
+	}
+	keys := []string{"gone", "empty", "never"}
+	want := map[string]string{"empty": ""}
+	check(t, store, keys, want)
+
+	store = reopen(t, s, store, dir)
+	check(t, store, keys, want)
+	assert.NoError(t, store.Insert("gone", "back"))
+	want["gone"] = "back"
+	check(t, store, keys, want)
+
+	store = reopen(t, s, store, dir)
+	check(t, store, keys, want)
+}
+
+func testDurability(t *testing.T, s Subject) {
+	dir := t.TempDir()
+	store := open(t, s, dir)
+	defer func() { store.Close() }()
+
+	const n = 500
+	keys := make([]string, n)
+	want := make(map[string]string)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%04d", i)
+		assert.NoError(t, store.Insert(keys[i], fmt.Sprintf("v1-%d", i)))
+		want[keys[i]] = fmt.Sprintf("v1-%d", i)
+	}
+	for i := 0; i < n; i += 3 {
+		assert.NoError(t, store.Insert(keys[i], fmt.Sprintf("v2-%d", i)))
+		want[keys[i]] = fmt.Sprintf("v2-%d", i)
+	}
+	for i := 1; i < n; i += 5 {
+		assert.NoError(t, store.Delete(keys[i]))
+		delete(want, keys[i])
+	}
+
+	store = reopen(t, s, store, dir)
+	check(t, store, keys, want)
+	// 다시 연 저장소에 쓴 것도 다음에 열 때 남아야 함
+	assert.NoError(t, store.Insert(keys[1], "v3"))
+	want[keys[1]] = "v3"
+	store = reopen(t, s, store, dir)
+	check(t, store, keys, want)
+}
+
+func testConcurrent(t *testing.T, s Subject) {
+	dir := t.TempDir()
+	store := open(t, s, dir)
+	defer func() { store.Close() }()
+
+	// 각 writer는 자기 키만 쓰므로 최종 상태가 정해지고, reader는 모든 키를 읽음
+	const writers, readers, keysPerWriter, rounds = 4, 4, 50, 6
+	var keys []string
+	want := make(map[string]string)
+	for w := 0; w < writers; w++ {
+		for i := 0; i < keysPerWriter; i++ {
+			key := fmt.Sprintf("w%d-key%03d", w, i)
+			keys = append(keys, key)
+			for r := 0; r < rounds; r++ {
+				if (i+r)%4 == 3 {
+					delete(want, key)
+				} else {
+					want[key] = fmt.Sprintf("w%d-%d-%d", w, i, r)
+				}
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	var wg, readWG sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		readWG.Add(1)
+		go func() {
+			defer readWG.Done()
+			for {
+				for _, key := range keys {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					v, err := store.Get(key)
+					if err != nil {
+						assert.ErrorIs(t, err, ports.ErrKeyNotFound, key)
+						continue
+					}
+					got, _ := valueString(v)
+					assert.True(t, strings.HasPrefix(got, key[:strings.Index(key, "-")]), "%q read %q", key, got)
+				}
+			}
+		}()
+	}
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				for i := 0; i < keysPerWriter; i++ {
+					key := fmt.Sprintf("w%d-key%03d", w, i)
+					if (i+r)%4 == 3 {
+						if err := store.Delete(key); err != nil {
+							assert.ErrorIs(t, err, ports.ErrKeyNotFound, key)
+						}
+						continue
+					}
+					assert.NoError(t, store.Insert(key, fmt.Sprintf("w%d-%d-%d", w, i, r)), key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(done)
+	readWG.Wait()
+
+	check(t, store, keys, want)
+	store = reopen(t, s, store, dir)
+	check(t, store, keys, want)
+}
+
+func testLargeValues(t *testing.T, s Subject) {
+	dir := t.TempDir()
+	store := open(t, s, dir)
+	defer func() { store.Close() }()
+
+	max := defaultMaxValue
+	if limiter, ok := store.(ports.EntryLimiter); ok {
+		limits := limiter.EntryLimits()
+		if limits.MaxValue > 0 && limits.MaxValue < max {
+			max = limits.MaxValue
+		}
+		if limits.MaxEntry > 0 && limits.MaxEntry-len("large-00") < max {
+			max = limits.MaxEntry - len("large-00")
+		}
+	}
+	if s.MaxValue > 0 && s.MaxValue < max {
+		max = s.MaxValue
+	}
+
+	var keys []string
+	want := make(map[string]string)
+	for i, size := range []int{max / 8, max / 2, max - 1, max} {
+		key := fmt.Sprintf("large-%02d", i)
+		// 반복 주기가 블록 크기와 맞지 않는 내용으로 어긋난 조각을 드러냄
+		pattern := []byte(fmt.Sprintf("%d-0123456789abcdef", i))
+		value := string(bytes.Repeat(pattern, size/len(pattern)+1)[:size])
+		keys = append(keys, key)
+		want[key] = value
+		assert.NoError(t, store.Insert(key, value), "%d bytes", size)
+	}
+	// 큰 값을 작은 값으로 덮어써도 남은 조각이 보이면 안 됨
+	assert.NoError(t, store.Insert(keys[0], "small"))
+	want[keys[0]] = "small"
+	check(t, store, keys, want)
+
+	store = reopen(t, s, store, dir)
+	check(t, store, keys, want)
+}
@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+)
+
+// btreeStore closes the tree file that the tree itself leaves open.
+type btreeStore struct {
+	*btree.Btree
+	file *os.File
+}
+
+func (s *btreeStore) Close() error {
+	err := s.Btree.Close()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func TestBtree(t *testing.T) {
+	Run(t, Subject{Open: func(dir string) (Store, error) {
+		f, err := os.OpenFile(filepath.Join(dir, "tree.db"), os.O_RDWR|os.O_CREATE, 0666)
+		if err != nil {
+			return nil, err
+		}
+		bt, err := btree.OpenBtree(f, btree.BtConfig{Degree: 4, PageSize: 16384, ThreadSafe: true})
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &btreeStore{Btree: bt, file: f}, nil
+	}})
+}
+
+func TestFile(t *testing.T) {
+	Run(t, Subject{Open: func(dir string) (Store, error) {
+		return file.NewFile(file.FileConfig{FilePath: filepath.Join(dir, "data.db")})
+	}})
+}
+
+func TestLSM(t *testing.T) {
+	Run(t, Subject{
+		Open: func(dir string) (Store, error) {
+			config := lsmtree.DefaultConfig()
+			config.FilePath = dir
+			config.MemTableSize = 16 * 1024 // 여러 SSTable로 flush되도록 작게 설정
+			config.CompactionInterval = time.Hour
+			lsm, err := lsmtree.NewLSMTree(config)
+			if err != nil {
+				return nil, err
+			}
+			return FromStorage(lsm, lsmtree.ErrKeyNotFound), nil
+		},
+		MaxValue: 1<<16 - 2, // SSTable 값 길이는 16비트, 최댓값은 tombstone 표시
+	})
+}
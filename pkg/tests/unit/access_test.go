@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// reportCount는 Reader만 받으므로 데이터를 바꿀 수 없는 컴포넌트의 예입니다.
+func reportCount(r domain.Reader, table string) (int64, error) {
+	return r.Count(table)
+}
+
+func TestReaderWriterAccessors(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+
+	w := db.Writer()
+	assert.NoError(t, w.CreateTable("users"))
+	assert.NoError(t, w.Insert("users", "alice", "admin"))
+	assert.NoError(t, w.Write(domain.NewWriteBatch().Insert("users", "bob", "user").Insert("users", "carol", "user")))
+	assert.NoError(t, w.Delete("users", "carol"))
+
+	r := db.Reader()
+	value, err := r.Get("users", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", value)
+	n, err := reportCount(r, "users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = reportCount(w.Reader, "users") // Writer는 Reader로 낮춰 넘길 수 있음
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	var keys []string
+	assert.NoError(t, r.Scan("users", "", func(key, value string) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"alice", "bob"}, keys)
+	def, err := r.GetOrDefault("users", "carol", "none")
+	assert.NoError(t, err)
+	assert.Equal(t, "none", def)
+	assert.Equal(t, db.Seq(), r.Seq())
+}
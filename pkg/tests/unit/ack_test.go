@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// stubAcknowledger는 지정된 개수의 팔로워만 응답하는 테스트용 복제기입니다.
+type stubAcknowledger struct {
+	available int
+}
+
+func (s *stubAcknowledger) WaitForReplicas(ctx context.Context, seq uint64, followers int) error {
+	if followers <= s.available {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWriteAckLevels(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+
+	assert.NoError(t, db.InsertWithAck("users", "k1", "v1", domain.WriteAck{Level: domain.AckLocal}))
+	assert.NoError(t, db.InsertWithAck("users", "k2", "v2", domain.WriteAck{Level: domain.AckLeaderFsync}))
+
+	err := db.InsertWithAck("users", "k3", "v3", domain.WriteAck{Level: domain.AckFollowers, Followers: 1})
+	assert.True(t, errors.Is(err, domain.ErrReplicationUnavailable), "followers ack without replication should fail")
+	value, err := db.Get("users", "k3")
+	assert.NoError(t, err, "write should still be applied locally")
+	assert.Equal(t, "v3", value)
+
+	db.SetReplicaAcknowledger(&stubAcknowledger{available: 1})
+	assert.NoError(t, db.InsertWithAck("users", "k4", "v4", domain.WriteAck{Level: domain.AckFollowers, Followers: 1}))
+
+	err = db.DeleteWithAck("users", "k4", domain.WriteAck{Level: domain.AckFollowers, Followers: 2, Timeout: 20 * time.Millisecond})
+	assert.True(t, errors.Is(err, domain.ErrAckTimeout), "missing follower acks should time out")
+}
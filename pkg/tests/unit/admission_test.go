@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func TestRateLimiterAllowN(t *testing.T) {
+	limiter := utils.NewRateLimiter(1, 2)
+	assert.True(t, limiter.AllowN(1))
+	assert.True(t, limiter.AllowN(1))
+	assert.False(t, limiter.AllowN(1), "bucket is empty")
+
+	big := utils.NewRateLimiter(1, 2)
+	assert.True(t, big.AllowN(10), "an event larger than the burst passes on a full bucket")
+	assert.False(t, big.AllowN(1), "and leaves the bucket in debt")
+
+	assert.True(t, utils.NewRateLimiter(0, 1).AllowN(1e9), "rate 0 is unlimited")
+}
+
+func TestRateLimiterWaitN(t *testing.T) {
+	limiter := utils.NewRateLimiter(100, 10)
+	start := time.Now()
+	assert.NoError(t, limiter.WaitN(context.Background(), 10), "a full bucket passes at once")
+	assert.NoError(t, limiter.WaitN(context.Background(), 30), "larger than the burst once the bucket refilled")
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.WaitN(ctx, 10), context.DeadlineExceeded, "the bucket is in debt")
+	assert.NoError(t, utils.NewRateLimiter(0, 1).WaitN(context.Background(), 1e9), "rate 0 is unlimited")
+}
+
+func TestAdmissionThrottlesClientsAndGlobal(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "admission.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("t"))
+
+	admission := application.NewAdmission(application.AdmissionLimits{ClientOpsPerSecond: 2, OpsPerSecond: 3})
+	commands := application.NewCommandHandler(db, &mockLogger{})
+	queries := application.NewQueryHandler(db, &mockLogger{})
+	commands.SetAdmission(admission)
+	queries.SetAdmission(admission)
+
+	noisy := application.WithClient(context.Background(), "noisy")
+	insert := &application.InsertCommand{TableName: "t", Key: "k", Value: "v"}
+	assert.NoError(t, commands.ExecuteCommand(noisy, insert))
+	_, err = queries.ExecuteQuery(noisy, &application.GetValueQuery{TableName: "t", Key: "k"})
+	assert.NoError(t, err)
+	err = commands.ExecuteCommand(noisy, insert)
+	assert.ErrorIs(t, err, application.ErrThrottled)
+	assert.ErrorContains(t, err, "client noisy")
+
+	// 다른 클라이언트는 자기 한도가 남았지만 전역 한도(초당 3)에 걸림
+	other := application.WithClient(context.Background(), "other")
+	assert.NoError(t, commands.ExecuteCommand(other, insert))
+	err = commands.ExecuteCommand(other, insert)
+	assert.ErrorIs(t, err, application.ErrThrottled)
+	assert.NotContains(t, err.Error(), "client")
+
+	// 한도를 없애면 바로 통과
+	admission.SetLimits(application.AdmissionLimits{})
+	assert.NoError(t, commands.ExecuteCommand(noisy, insert))
+	commands.SetAdmission(nil)
+	assert.NoError(t, commands.ExecuteCommand(noisy, insert))
+}
+
+func TestAdmissionThrottlesBytes(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "admission.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("t"))
+
+	commands := application.NewCommandHandler(db, &mockLogger{})
+	commands.SetAdmission(application.NewAdmission(application.AdmissionLimits{BytesPerSecond: 100}))
+	big := strings.Repeat("x", 60)
+	assert.NoError(t, commands.ExecuteCommand(context.Background(), &application.InsertCommand{TableName: "t", Key: "a", Value: big}))
+	err = commands.ExecuteCommand(context.Background(), &application.InsertCommand{TableName: "t", Key: "b", Value: big})
+	assert.ErrorIs(t, err, application.ErrThrottled)
+	assert.ErrorContains(t, err, "bytes per second")
+	_, err = db.Get("t", "b")
+	assert.Error(t, err, "throttled command is not executed")
+	// 바이트를 쓰지 않는 명령은 바이트 한도에 걸리지 않음
+	assert.NoError(t, commands.ExecuteCommand(context.Background(), &application.SyncCommand{}))
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestAttachAddressesTablesAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	db, err := domain.NewDatabase(managedConfig("main", filepath.Join(dir, "main.db")), &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+
+	archivePath := filepath.Join(dir, "archive.db")
+	assert.NoError(t, db.Attach("archive", archivePath))
+	assert.Equal(t, []string{"archive"}, db.Attached())
+
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.CreateTable("archive.users"))
+	assert.NoError(t, db.Insert("users", "k", "live"))
+	assert.NoError(t, db.Insert("archive.users", "k", "old"))
+	assert.NoError(t, db.Insert("archive.users", "k2", "older"))
+
+	v, err := db.Get("users", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "live", v)
+	v, err = db.Get("archive.users", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", v)
+	n, err := db.Count("archive.users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	var keys []string
+	assert.NoError(t, db.Scan("archive.users", "", func(key, _ string) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"k", "k2"}, keys)
+
+	assert.NoError(t, db.Delete("archive.users", "k2"))
+	_, err = db.Get("archive.users", "k2")
+	assert.Error(t, err)
+
+	assert.NoError(t, db.Detach("archive"))
+	assert.Empty(t, db.Attached())
+	_, err = db.Get("archive.users", "k")
+	assert.Error(t, err, "detached tables are no longer addressable")
+	assert.ErrorIs(t, db.Detach("archive"), domain.ErrAliasNotFound)
+
+	// 분리한 파일은 그 자체로 열 수 있음
+	archive, err := domain.NewDatabase(managedConfig("archive", archivePath), &mockLogger{})
+	assert.NoError(t, err)
+	defer archive.Close()
+	v, err = archive.Get("users", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", v)
+}
+
+func TestAttachRejectsInvalidAliases(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.db")
+	db, err := domain.NewDatabase(managedConfig("main", mainPath), &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.Error(t, db.Attach("", filepath.Join(dir, "a.db")))
+	assert.Error(t, db.Attach("a.b", filepath.Join(dir, "a.db")))
+	assert.ErrorIs(t, db.Attach("self", mainPath), domain.ErrAliasExists)
+
+	assert.NoError(t, db.Attach("a", filepath.Join(dir, "a.db")))
+	assert.ErrorIs(t, db.Attach("a", filepath.Join(dir, "b.db")), domain.ErrAliasExists)
+	assert.ErrorIs(t, db.Attach("b", filepath.Join(dir, ".", "a.db")), domain.ErrAliasExists)
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/server"
+)
+
+func TestACLPerTablePermissions(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "acl.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.CreateTable("orders"))
+
+	acl := server.NewACL()
+	assert.NoError(t, acl.Grant("writer", "users", application.PermRead|application.PermWrite))
+	assert.NoError(t, acl.Grant("reader", "users", application.PermRead))
+	assert.NoError(t, acl.Grant("admin", server.AllTables, application.PermRead|application.PermWrite))
+	assert.Error(t, acl.Grant("", "users", application.PermRead))
+
+	commands := application.NewCommandHandler(db, &mockLogger{})
+	queries := application.NewQueryHandler(db, &mockLogger{})
+	commands.SetAuthorizer(acl)
+	queries.SetAuthorizer(acl)
+
+	writer := server.WithToken(context.Background(), "writer")
+	reader := server.WithToken(context.Background(), "reader")
+	admin := server.WithToken(context.Background(), "admin")
+
+	assert.NoError(t, commands.ExecuteCommand(writer, &application.InsertCommand{TableName: "users", Key: "k", Value: "v"}))
+	value, err := queries.ExecuteQuery(reader, &application.GetValueQuery{TableName: "users", Key: "k"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	// 읽기 권한만 있는 토큰은 쓸 수 없고, 권한이 없는 테이블은 읽을 수도 없음
+	err = commands.ExecuteCommand(reader, &application.InsertCommand{TableName: "users", Key: "k", Value: "x"})
+	assert.ErrorIs(t, err, application.ErrForbidden)
+	_, err = queries.ExecuteQuery(writer, &application.GetValueQuery{TableName: "orders", Key: "k"})
+	assert.ErrorIs(t, err, application.ErrForbidden)
+	got, _ := db.Get("users", "k")
+	assert.Equal(t, "v", got, "denied command must not be executed")
+
+	// 데이터베이스 전체 연산은 AllTables 권한이 필요함
+	assert.ErrorIs(t, commands.ExecuteCommand(writer, &application.SyncCommand{}), application.ErrForbidden)
+	assert.NoError(t, commands.ExecuteCommand(admin, &application.SyncCommand{}))
+	assert.NoError(t, commands.ExecuteCommand(admin, &application.InsertCommand{TableName: "orders", Key: "o", Value: "1"}))
+
+	// 토큰이 없거나 알 수 없는 토큰
+	_, err = queries.ExecuteQuery(context.Background(), &application.GetValueQuery{TableName: "users", Key: "k"})
+	assert.ErrorIs(t, err, application.ErrUnauthenticated)
+	acl.Revoke("reader")
+	_, err = queries.ExecuteQuery(reader, &application.GetValueQuery{TableName: "users", Key: "k"})
+	assert.ErrorIs(t, err, application.ErrUnauthenticated)
+
+	// 검사를 끄면 토큰 없이 실행됨
+	queries.SetAuthorizer(nil)
+	_, err = queries.ExecuteQuery(context.Background(), &application.GetValueQuery{TableName: "orders", Key: "o"})
+	assert.NoError(t, err)
+}
+
+func TestACLOutboxChecksOnEnqueue(t *testing.T) {
+	dir := t.TempDir()
+	db, err := openBtreeDatabase(filepath.Join(dir, "acl.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+
+	acl := server.NewACL()
+	assert.NoError(t, acl.Grant("writer", "users", application.PermWrite))
+	commands := application.NewCommandHandler(db, &mockLogger{})
+	commands.SetAuthorizer(acl)
+	_, err = commands.EnableOutbox(filepath.Join(dir, "outbox.db"))
+	assert.NoError(t, err)
+	defer commands.CloseOutbox()
+
+	err = commands.EnqueueCommand(context.Background(), &application.InsertCommand{TableName: "users", Key: "a", Value: "1"})
+	assert.ErrorIs(t, err, application.ErrUnauthenticated)
+	assert.NoError(t, commands.EnqueueCommand(server.WithToken(context.Background(), "writer"),
+		&application.InsertCommand{TableName: "users", Key: "b", Value: "2"}))
+	commands.Wait()
+	value, err := db.Get("users", "b")
+	assert.NoError(t, err, "accepted command runs without the caller's token")
+	assert.Equal(t, "2", value)
+	_, err = db.Get("users", "a")
+	assert.Error(t, err)
+}
+
+func TestAuthenticateMiddleware(t *testing.T) {
+	var token string
+	h := server.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = server.TokenFrom(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "secret", token)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "", token)
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestWriteBatchAndScan(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.CreateTable("other"))
+
+	batch := domain.NewWriteBatch()
+	for i := 9; i >= 0; i-- {
+		batch.Insert("users", fmt.Sprintf("user%02d", i), fmt.Sprintf("v%d", i))
+	}
+	batch.Insert("other", "user99", "x").Delete("users", "user05").Delete("users", "nope")
+	assert.NoError(t, db.Write(batch))
+	assert.NoError(t, db.Insert("users", "user03", "updated"), "overwrite must not duplicate the key")
+
+	var keys []string
+	assert.NoError(t, db.Scan("users", "user0", func(key, value string) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"user00", "user01", "user02", "user03", "user04", "user06", "user07", "user08", "user09"}, keys)
+
+	var first []string
+	assert.NoError(t, db.Scan("users", "", func(key, value string) bool {
+		first = append(first, key+"="+value)
+		return len(first) < 4
+	}))
+	assert.Equal(t, []string{"user00=v0", "user01=v1", "user02=v2", "user03=updated"}, first)
+
+	count, err := db.Count("users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), count)
+
+	err = db.Write(domain.NewWriteBatch().Insert("users", "a", "1").Insert("missing", "b", "2"))
+	assert.Error(t, err)
+	_, err = db.Get("users", "a")
+	assert.Error(t, err, "a batch naming a missing table applies nothing")
+}
+
+func TestWriteBatchCoalescesSameKey(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("hot"))
+	assert.NoError(t, db.Insert("hot", "gone", "x"))
+
+	var muts []domain.Mutation
+	remove := db.AddMutationListener(func(m domain.Mutation) { muts = append(muts, m) })
+	defer remove()
+
+	batch := domain.NewWriteBatch()
+	for i := 0; i < 100; i++ {
+		batch.Insert("hot", "counter", fmt.Sprint(i))
+	}
+	batch.Insert("hot", "gone", "y").Delete("hot", "gone")
+	batch.Insert("hot", "temp", "t").Delete("hot", "temp") // 배치 안에서 생겼다 사라진 키
+	batch.Delete("hot", "back").Insert("hot", "back", "b")
+	assert.NoError(t, db.Write(batch))
+
+	// counter, gone, back만 저장소와 리스너에 도달함 (temp의 삭제는 없는 키라 무시됨)
+	assert.Len(t, muts, 3)
+	val, err := db.Get("hot", "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "99", val)
+	_, err = db.Get("hot", "gone")
+	assert.Error(t, err)
+	_, err = db.Get("hot", "temp")
+	assert.Error(t, err)
+	val, err = db.Get("hot", "back")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", val)
+	count, err := db.Count("hot")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+)
+
+func bulkItems(n int) []btree.Item {
+	items := make([]btree.Item, n)
+	for i := range items {
+		key := fmt.Sprintf("key-%06d", i)
+		items[i] = btree.Item{Key: key, Value: []byte("v" + key)}
+	}
+	return items
+}
+
+func TestBtreeBulkLoad(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 7, 8, 15, 16, 50, 1000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bulk.db")
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+			assert.NoError(t, err)
+			bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true, CacheSize: 16})
+
+			loaded, err := bt.BulkLoad(btree.ItemIterator(bulkItems(n)))
+			assert.NoError(t, err)
+			assert.Equal(t, n, loaded)
+			assert.Equal(t, n, bt.GetLength())
+			check := bt.CheckTree(bt.RootOffset, bt.Length, map[int64]bool{}, nil)
+			assert.Empty(t, check.Problems)
+
+			var keys []string
+			assert.NoError(t, bt.Scan("", func(key string, _ interface{}) bool {
+				keys = append(keys, key)
+				return true
+			}))
+			assert.Len(t, keys, n)
+			for _, item := range bulkItems(n) {
+				v, err := bt.Get(item.Key)
+				assert.NoError(t, err)
+				assert.Equal(t, string(item.Value), v)
+			}
+
+			// 적재한 트리도 보통의 삽입과 삭제를 그대로 받음
+			assert.NoError(t, bt.Insert("key-000000a", "x"))
+			for _, item := range bulkItems(n / 2) {
+				assert.NoError(t, bt.Delete(item.Key))
+			}
+			check = bt.CheckTree(bt.RootOffset, bt.Length, map[int64]bool{}, nil)
+			assert.Empty(t, check.Problems)
+			assert.Equal(t, n-n/2+1, bt.GetLength())
+			assert.NoError(t, bt.Close())
+			f.Close()
+
+			f, err = os.OpenFile(path, os.O_RDWR, 0644)
+			assert.NoError(t, err)
+			defer f.Close()
+			bt = btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+			defer bt.Close()
+			assert.Equal(t, n-n/2+1, bt.GetLength())
+		})
+	}
+}
+
+func TestBtreeBulkLoadRejectsBadInput(t *testing.T) {
+	f, err := os.OpenFile(filepath.Join(t.TempDir(), "bulk.db"), os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+	defer bt.Close()
+
+	items := bulkItems(20)
+	items[10], items[11] = items[11], items[10]
+	_, err = bt.BulkLoad(btree.ItemIterator(items))
+	assert.ErrorIs(t, err, btree.ErrUnsortedInput)
+	assert.Equal(t, 0, bt.GetLength(), "a failed bulk load should leave the tree empty")
+
+	_, err = bt.BulkLoad(btree.ItemIterator([]btree.Item{{Key: "big", Value: make([]byte, 4096)}}))
+	assert.Error(t, err)
+
+	assert.NoError(t, bt.Insert("a", "1"))
+	_, err = bt.BulkLoad(btree.ItemIterator(bulkItems(3)))
+	assert.ErrorIs(t, err, btree.ErrTreeNotEmpty)
+}
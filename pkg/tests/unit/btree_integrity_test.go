@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+)
+
+// corruptPageAt는 지정된 오프셋의 페이지를 0xFF로 덮어씁니다.
+func corruptPageAt(t *testing.T, path string, offset int64, pageSize int) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0666)
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteAt(bytes.Repeat([]byte{0xFF}, pageSize), offset)
+	assert.NoError(t, err)
+}
+
+func TestBtreeCorruptPageRepairedFromMirror(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.db")
+	mirror := filepath.Join(dir, "mirror.db")
+	config := btree.BtConfig{Degree: 4, PageSize: 512, CacheSize: 10, FallbackPath: mirror, MirrorWrites: true}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	bt := btree.NewBtree(f, config)
+	assert.NoError(t, bt.Insert("k1", "v1"))
+	assert.NoError(t, bt.Insert("k2", "v2"))
+	root := bt.GetRootOffset()
+	f.Close()
+
+	corruptPageAt(t, path, root, 512)
+
+	f, err = os.OpenFile(path, os.O_RDWR, 0666)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt = btree.NewBtree(f, config)
+	val, err := bt.Get("k2")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", val)
+	assert.Equal(t, int64(1), bt.CorruptionCount())
+	assert.Equal(t, int64(1), bt.RepairCount())
+	assert.Empty(t, bt.QuarantinedPages())
+
+	// 복구된 페이지는 미러 없이도 읽을 수 있어야 함.
+	bt = btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+	val, err = bt.Get("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", val)
+}
+
+func TestBtreeCorruptPageWithoutFallbackIsQuarantined(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.db")
+	config := btree.BtConfig{Degree: 4, PageSize: 512}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, config)
+	assert.NoError(t, bt.Insert("k1", "v1"))
+	root := bt.GetRootOffset()
+
+	corruptPageAt(t, path, root, 512)
+
+	_, err = bt.Get("k1")
+	assert.True(t, errors.Is(err, btree.ErrPageCorrupted))
+	assert.Equal(t, []int64{root}, bt.QuarantinedPages())
+}
+
+func TestBtreePageChecksumDetectsBitRot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.db")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, Checksums: true})
+	assert.NoError(t, bt.Insert("key", "value"))
+	root := bt.GetRootOffset()
+
+	// 값의 한 바이트만 바꾸면 구조 검증은 통과하지만 체크섬은 실패해야 함.
+	page := make([]byte, 512)
+	_, err = f.ReadAt(page, root)
+	assert.NoError(t, err)
+	i := bytes.Index(page, []byte("value"))
+	assert.True(t, i > 0)
+	_, err = f.WriteAt([]byte("V"), root+int64(i))
+	assert.NoError(t, err)
+
+	// 체크섬 모드는 헤더에 기록되므로 설정 없이 다시 열어도 검증되어야 함.
+	bt = btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+	_, err = bt.Get("key")
+	var corrupt *btree.CorruptPageError
+	assert.True(t, errors.As(err, &corrupt))
+	assert.Equal(t, root, corrupt.Offset)
+	assert.True(t, errors.Is(err, btree.ErrPageCorrupted))
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+)
+
+func TestBtreeConcurrentInsertsWithLatches(t *testing.T) {
+	configs := map[string]btree.BtConfig{
+		"uncached":   {Degree: 4, PageSize: 512, ThreadSafe: true},
+		"cached":     {Degree: 4, PageSize: 512, ThreadSafe: true, CacheSize: 32},
+		"write-back": {Degree: 4, PageSize: 512, ThreadSafe: true, CacheBytes: 16 << 10, WriteBack: true, FlushInterval: time.Millisecond},
+	}
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "latch.db")
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+			assert.NoError(t, err)
+			bt := btree.NewBtree(f, config)
+
+			const writers, perWriter = 8, 200
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < perWriter; i++ {
+						key := fmt.Sprintf("%03d-%02d", i, w) // 작성자들이 트리 전체에 흩어져 씀
+						assert.NoError(t, bt.Insert(key, key))
+						if i%10 == 0 {
+							assert.NoError(t, bt.Insert(key, "updated")) // 기존 키 덮어쓰기
+						}
+						if val, err := bt.Get(key); assert.NoError(t, err) && i%10 != 0 {
+							assert.Equal(t, key, val)
+						}
+					}
+				}(w)
+			}
+			// 삽입과 함께 도는 Scan은 항상 정렬된 키를 봄
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					var keys []string
+					assert.NoError(t, bt.Scan("", func(key string, _ interface{}) bool {
+						keys = append(keys, key)
+						return true
+					}))
+					assert.True(t, sort.StringsAreSorted(keys))
+				}
+			}()
+			wg.Wait()
+			assert.Equal(t, writers*perWriter, bt.GetLength())
+			assert.NoError(t, bt.Close())
+
+			bt = btree.NewBtree(f, config)
+			defer f.Close()
+			defer bt.Close()
+			assert.Equal(t, writers*perWriter, bt.GetLength())
+			for w := 0; w < writers; w++ {
+				for i := 0; i < perWriter; i++ {
+					key := fmt.Sprintf("%03d-%02d", i, w)
+					want := key
+					if i%10 == 0 {
+						want = "updated"
+					}
+					val, err := bt.Get(key)
+					assert.NoError(t, err)
+					assert.Equal(t, want, val)
+				}
+			}
+		})
+	}
+}
+
+func TestBtreeInsertDoesNotWaitForScan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.db")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true, CacheSize: 64})
+	defer bt.Close()
+	for i := 0; i < 40; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("k%02d", i), "v"))
+	}
+	assert.NoError(t, bt.Delete("k39")) // 가장 오른쪽 잎에 자리를 만듦
+
+	// Scan이 왼쪽 경로의 래치를 잡고 멈춰 있는 동안, 오른쪽 잎으로 가는 삽입과 조회는 끝남
+	scanning, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		bt.Scan("", func(key string, _ interface{}) bool {
+			close(scanning)
+			<-release
+			return false
+		})
+	}()
+	<-scanning
+	done := make(chan error, 1)
+	go func() { done <- bt.Insert("k39", "again") }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("insert blocked behind a scan of another subtree")
+	}
+	val, err := bt.Get("k39")
+	assert.NoError(t, err)
+	assert.Equal(t, "again", val)
+	close(release)
+}
@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestNestedBuckets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+
+	settings, err := db.CreateBucket("users/alice/settings")
+	assert.NoError(t, err)
+	assert.Equal(t, "users/alice/settings", settings.Path())
+	_, err = db.CreateBucket("users/alice/settings")
+	assert.ErrorIs(t, err, domain.ErrBucketExists)
+	again, err := db.CreateBucketIfNotExists("users/alice/settings")
+	assert.NoError(t, err)
+	assert.Equal(t, settings.Table(), again.Table())
+
+	users, err := db.Bucket("users")
+	assert.NoError(t, err, "parents are created")
+	bob, err := users.CreateBucket("bob")
+	assert.NoError(t, err)
+	assert.NoError(t, bob.Put("theme", "light"))
+	assert.NoError(t, settings.Put("theme", "dark"))
+	assert.NoError(t, settings.Put("lang", "ko"))
+	v, err := settings.Get("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", v, "buckets are separate namespaces")
+	names, err := users.Buckets()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, names)
+	names, err = db.Buckets("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users"}, names)
+
+	for _, bad := range []string{"", "/a", "a/", "a//b", "a$search"} {
+		_, err := db.CreateBucket(bad)
+		assert.Error(t, err, bad)
+	}
+	_, err = db.Bucket("users/carol")
+	assert.ErrorIs(t, err, domain.ErrBucketNotFound)
+
+	assert.NoError(t, db.Close())
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	settings, err = db.Bucket("users/alice/settings")
+	assert.NoError(t, err, "buckets survive reopen")
+	v, err = settings.Get("lang")
+	assert.NoError(t, err)
+	assert.Equal(t, "ko", v)
+
+	assert.NoError(t, db.DeleteBucket("users/alice"))
+	_, err = db.Bucket("users/alice/settings")
+	assert.ErrorIs(t, err, domain.ErrBucketNotFound, "nested buckets are deleted")
+	_, err = settings.Get("lang")
+	assert.Error(t, err)
+	names, err = db.Buckets("users")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, names)
+}
+
+func TestBucketCursor(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "cursor.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	b, err := db.CreateBucket("items")
+	assert.NoError(t, err)
+	for _, k := range []string{"b", "d", "a", "c"} {
+		assert.NoError(t, b.Put(k, "v"+k))
+	}
+	_, err = b.CreateBucket("nested")
+	assert.NoError(t, err)
+
+	c, err := b.Cursor()
+	assert.NoError(t, err)
+	var keys []string
+	for k, v, ok := c.First(); ok; k, v, ok = c.Next() {
+		assert.Equal(t, "v"+k, v)
+		keys = append(keys, k)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, keys, "nested buckets are not entries")
+	_, _, ok := c.Next()
+	assert.False(t, ok)
+	k, _, ok := c.Prev()
+	assert.True(t, ok)
+	assert.Equal(t, "d", k)
+
+	k, _, ok = c.Seek("bb")
+	assert.True(t, ok)
+	assert.Equal(t, "c", k)
+	k, _, _ = c.Prev()
+	assert.Equal(t, "b", k)
+	k, _, _ = c.Last()
+	assert.Equal(t, "d", k)
+	_, _, ok = c.Seek("z")
+	assert.False(t, ok)
+
+	// 커서는 만든 시점의 내용을 보여 줌
+	assert.NoError(t, b.Put("e", "ve"))
+	k, _, _ = c.Last()
+	assert.Equal(t, "d", k)
+}
@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+)
+
+func TestBtreeShardedCacheConcurrentReads(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "cache.db"))
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true, CacheSize: 256})
+	assert.Equal(t, 16, bt.Stats()["cache_shards"])
+
+	const n = 500
+	for i := 0; i < n; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("key%04d", i), fmt.Sprintf("v%d", i)))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				k := (i*7 + g) % n
+				val, err := bt.Get(fmt.Sprintf("key%04d", k))
+				if assert.NoError(t, err) {
+					assert.Equal(t, fmt.Sprintf("v%d", k), val)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := bt.Stats()
+	assert.LessOrEqual(t, stats["cached_nodes"], 256)
+	assert.Greater(t, stats["cache_hits"], int64(0))
+
+	bt.SetCacheSize(16)
+	assert.LessOrEqual(t, bt.GetCacheSize(), 16)
+	bt.SetCacheSize(0)
+	assert.Equal(t, 0, bt.GetCacheSize())
+	_, err = bt.Get("key0001")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, bt.GetCacheSize())
+}
+
+func TestBtreeSmallCacheUsesFewerShards(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "small.db"))
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, CacheSize: 10})
+	assert.Equal(t, 1, bt.Stats()["cache_shards"])
+
+	bt = btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, CacheSize: 10, CacheShards: 6})
+	assert.Equal(t, 4, bt.Stats()["cache_shards"])
+}
+
+func TestLSMCacheShardsStayWithinCapacity(t *testing.T) {
+	c := lsmtree.NewCache(64 * 160) // 160 entries, 10 per shard
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("k%d-%d", g, i)
+				c.Put(key, "v")
+				c.Get(key)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	c.Put("hot", "1")
+	val, ok := c.Get("hot")
+	assert.True(t, ok)
+	assert.Equal(t, "1", val)
+	_, ok = c.Get("never")
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.LessOrEqual(t, stats["entries"], 160)
+	assert.Equal(t, 16, stats["shards"])
+	assert.Equal(t, int64(1), stats["misses"])
+}
+
+func TestBtreeMmapReads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mmap.db")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	// 캐시 없이 모든 노드 읽기가 매핑(또는 매핑 밖 끝부분은 ReadAt)을 거치게 함
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true, UseMmap: true})
+
+	const n = 400
+	for i := 0; i < n; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("key%04d", i), fmt.Sprintf("v%d", i)))
+		if i%50 == 0 { // 파일이 자라는 동안 읽어 다시 매핑되게 함
+			val, err := bt.Get(fmt.Sprintf("key%04d", i/2))
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("v%d", i/2), val)
+		}
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < n; i += 4 {
+				val, err := bt.Get(fmt.Sprintf("key%04d", i))
+				if assert.NoError(t, err) {
+					assert.Equal(t, fmt.Sprintf("v%d", i), val)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Greater(t, bt.Stats()["mmap_bytes"], 0)
+
+	// 매핑을 통해 읽은 노드를 덮어쓴 뒤에도 새 값이 보여야 함
+	assert.NoError(t, bt.Insert("key0007", "updated"))
+	val, err := bt.Get("key0007")
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", val)
+	assert.NoError(t, bt.Close())
+
+	// 다시 열면 기존 파일 전체가 매핑됨
+	reopened := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, UseMmap: true})
+	val, err = reopened.Get("key0399")
+	assert.NoError(t, err)
+	assert.Equal(t, "v399", val)
+	stat, err := f.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, int(stat.Size()), reopened.Stats()["mmap_bytes"])
+	assert.NoError(t, reopened.Close())
+}
+
+func TestBtreeBufferPoolWriteBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writeback.db")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	config := btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true, CacheBytes: 1 << 20, WriteBack: true, FlushInterval: time.Hour}
+	bt := btree.NewBtree(f, config)
+
+	const n = 300
+	for i := 0; i < n; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("key%04d", i), fmt.Sprintf("v%d", i)))
+	}
+	stats := bt.Stats()
+	assert.Greater(t, stats["dirty_pages"], 0) // 아직 디스크에 쓰지 않음
+	assert.Greater(t, stats["cache_bytes"], int64(0))
+	assert.LessOrEqual(t, stats["cache_bytes"], int64(1<<20))
+
+	// 큰 노드는 페이지에 들어가는지 쓰기 시점에 검사됨
+	assert.Error(t, bt.Insert("big", strings.Repeat("x", 600)))
+
+	// 예산을 줄이면 dirty 노드가 먼저 기록된 뒤 퇴출됨
+	bt.SetCacheBytes(2048)
+	stats = bt.Stats()
+	assert.LessOrEqual(t, stats["cache_bytes"], int64(2048)+512)
+	assert.Greater(t, stats["write_backs"], int64(0))
+
+	assert.NoError(t, bt.Sync())
+	assert.Equal(t, 0, bt.Stats()["dirty_pages"])
+	assert.NoError(t, bt.Close())
+
+	reopened := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+	for _, i := range []int{0, 150, n - 1} {
+		val, err := reopened.Get(fmt.Sprintf("key%04d", i))
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("v%d", i), val)
+	}
+	assert.Equal(t, n, reopened.GetLength())
+}
+
+func TestBtreeBufferPoolBackgroundFlush(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "flusher.db"))
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true, CacheSize: 1000, WriteBack: true, FlushInterval: 10 * time.Millisecond})
+	defer bt.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				key := fmt.Sprintf("g%d-%03d", g, i)
+				assert.NoError(t, bt.Insert(key, key))
+				_, err := bt.Get(key)
+				assert.NoError(t, err)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Eventually(t, func() bool { return bt.Stats()["dirty_pages"] == 0 }, 2*time.Second, 10*time.Millisecond)
+
+	// flusher가 쓴 페이지와 헤더만으로 다른 핸들에서 읽을 수 있어야 함
+	other := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+	assert.Equal(t, 200, other.GetLength())
+	val, err := other.Get("g3-049")
+	assert.NoError(t, err)
+	assert.Equal(t, "g3-049", val)
+}
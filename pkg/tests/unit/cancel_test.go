@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestContextEntryPointsRejectDoneContext(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, db.InsertContext(ctx, "users", "k", "v", domain.WriteAck{}), context.Canceled)
+	_, err := db.Get("users", "k")
+	assert.Error(t, err, "a cancelled insert must not be applied")
+	_, err = db.GetContext(ctx, "users", "k")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, db.CreateTableContext(ctx, "orders"), context.Canceled)
+	assert.ErrorIs(t, db.ScanContext(ctx, "users", "", func(_, _ string) bool { return true }), context.Canceled)
+}
+
+func TestScanContextStopsWhenCancelled(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, db.Insert("users", fmt.Sprintf("k%02d", i), "v"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seen := 0
+	err := db.ScanContext(ctx, "users", "", func(_, _ string) bool {
+		seen++
+		if seen == 3 {
+			cancel()
+		}
+		return true
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, seen)
+
+	handler := application.NewQueryHandler(db, &mockLogger{})
+	result, err := handler.ExecuteQuery(context.Background(), &application.ScanQuery{TableName: "users", Prefix: "k0", Limit: 4})
+	assert.NoError(t, err)
+	entries := result.([]application.KeyValue)
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "k00", entries[0].Key)
+	_, err = handler.ExecuteQuery(ctx, &application.ScanQuery{TableName: "users"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAckWaitEndsWithContext(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	db.SetReplicaAcknowledger(&stubAcknowledger{available: 0})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := db.InsertContext(ctx, "users", "k", "v", domain.WriteAck{Level: domain.AckFollowers, Followers: 1, Timeout: 5 * time.Second})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, errors.Is(err, domain.ErrAckTimeout))
+	assert.Less(t, time.Since(start), time.Second, "the caller's deadline must bound the ack wait")
+	val, err := db.Get("users", "k")
+	assert.NoError(t, err, "the write is applied even though its ack was abandoned")
+	assert.Equal(t, "v", val)
+}
+
+func TestFileAdapterContextOperations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cancel.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	defer f.Close()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, f.Insert(fmt.Sprintf("k%d", i), "v"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, f.ScanContext(ctx, "k", func(string, interface{}) bool { return true }), context.Canceled)
+	assert.ErrorIs(t, f.CompactContext(ctx), context.Canceled)
+	assert.ErrorIs(t, f.SyncContext(ctx), context.Canceled)
+
+	assert.NoError(t, f.CompactContext(context.Background()))
+	keys := 0
+	assert.NoError(t, f.ScanContext(context.Background(), "k", func(string, interface{}) bool { keys++; return true }))
+	assert.Equal(t, 5, keys)
+}
+
+func TestLSMTreeCompactContext(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+	assert.NoError(t, lsm.Insert("k", "v"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, lsm.CompactContext(ctx), context.Canceled)
+	assert.NoError(t, lsm.CompactContext(context.Background()))
+	val, err := lsm.Get("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/capture"
+)
+
+func TestCaptureReplay(t *testing.T) {
+	src, cleanupSrc := setupDatabaseTest(t)
+	defer cleanupSrc()
+	path := filepath.Join(t.TempDir(), "workload.cap")
+	rec, err := capture.Start(src, path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, src.CreateTable("users"))
+	assert.NoError(t, src.Insert("users", "a", "1"))
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, src.Insert("users", "b", "2"))
+	assert.NoError(t, src.Delete("users", "a"))
+	assert.NoError(t, rec.Close())
+	assert.Equal(t, int64(4), rec.Records())
+
+	// 최대 속도 재생은 캡처된 간격을 기다리지 않음.
+	dst, cleanupDst := setupDatabaseTest(t)
+	defer cleanupDst()
+	r, err := capture.Open(path)
+	assert.NoError(t, err)
+	stats, err := capture.Replay(context.Background(), r, dst, capture.ReplayOptions{})
+	r.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Applied)
+	assert.Zero(t, stats.Failed)
+	assert.Less(t, stats.Elapsed, 50*time.Millisecond)
+	_, err = dst.Get("users", "a")
+	assert.Error(t, err)
+	v, err := dst.Get("users", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", v)
+
+	// 1x 재생은 원래 간격을 유지하며, 기존 데이터가 있는 대상에도 다시 적용할 수 있음.
+	r, err = capture.Open(path)
+	assert.NoError(t, err)
+	defer r.Close()
+	stats, err = capture.Replay(context.Background(), r, dst, capture.ReplayOptions{Speed: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Applied)
+	assert.GreaterOrEqual(t, stats.Elapsed, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, stats.Captured, 50*time.Millisecond)
+}
+
+func TestCaptureTornTail(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	path := filepath.Join(t.TempDir(), "torn.cap")
+	rec, err := capture.Start(db, path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("t"))
+	assert.NoError(t, db.Insert("t", "k", "v"))
+	assert.NoError(t, rec.Close())
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-3))
+
+	r, err := capture.Open(path)
+	assert.NoError(t, err)
+	defer r.Close()
+	n := 0
+	for {
+		if _, err := r.Next(); err != nil {
+			break
+		}
+		n++
+	}
+	assert.Equal(t, 1, n, "the truncated last record is skipped")
+}
+
+func TestParseReplaySpeed(t *testing.T) {
+	for in, want := range map[string]float64{"max": 0, "1x": 1, "2.5x": 2.5, "10": 10} {
+		got, err := capture.ParseSpeed(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "0x", "-1x", "fast"} {
+		_, err := capture.ParseSpeed(in)
+		assert.Error(t, err, in)
+	}
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestCompareAndSwapCounterWithoutLocks(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "cas.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTableWithOptions("counters", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, db.InsertIfAbsent("counters", "hits", "0"))
+	assert.ErrorIs(t, db.InsertIfAbsent("counters", "hits", "100"), domain.ErrKeyExists)
+
+	// 각 작성자는 읽고, 더하고, 그사이 값이 바뀌었으면 다시 시도함
+	const writers, increments = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				for {
+					current, err := db.Get("counters", "hits")
+					assert.NoError(t, err)
+					n, _ := strconv.Atoi(current)
+					err = db.Writer().CompareAndSwap("counters", "hits", current, strconv.Itoa(n+1))
+					if err == nil {
+						break
+					}
+					if !errors.Is(err, domain.ErrCompareFailed) {
+						t.Errorf("unexpected error: %v", err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	value, meta, err := db.GetWithMeta("counters", "hits")
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(writers*increments), value)
+	assert.False(t, meta.Updated.Before(meta.Created))
+
+	assert.ErrorIs(t, db.CompareAndSwap("counters", "missing", "", "1"), domain.ErrCompareFailed)
+	assert.Error(t, db.CompareAndSwap("nope", "hits", "0", "1"))
+}
+
+func TestIncrementCounter(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "incr.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("counters"))
+
+	// 없는 키는 0에서 시작
+	n, err := db.Increment("counters", "visits", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	const writers, increments = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				_, err := db.Writer().Increment("counters", "visits", 2)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	n, err = db.Increment("counters", "visits", -3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5+2*writers*increments-3), n)
+	value, err := db.Get("counters", "visits")
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(n, 10), value)
+
+	// 정수가 아닌 값과 오버플로는 값을 바꾸지 않음
+	assert.NoError(t, db.Insert("counters", "name", "abc"))
+	_, err = db.Increment("counters", "name", 1)
+	assert.ErrorIs(t, err, domain.ErrNotInteger)
+	assert.NoError(t, db.Insert("counters", "max", strconv.FormatInt(math.MaxInt64, 10)))
+	_, err = db.Increment("counters", "max", 1)
+	assert.ErrorIs(t, err, domain.ErrNotInteger)
+	value, err = db.Get("counters", "max")
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(math.MaxInt64, 10), value)
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestChangefeedRecordsCommittedChangesInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.db")
+	db, err := openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Changefeed = true })
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("kv"))
+	assert.NoError(t, db.CreateCountView("kv", "a"))
+	assert.NoError(t, db.Insert("kv", "a1", "1"))
+	batch := domain.NewWriteBatch()
+	batch.Insert("kv", "b1", "2")
+	batch.Delete("kv", "a1")
+	assert.NoError(t, db.Write(batch))
+
+	// 보조 테이블($counts)의 변경은 빠지고 사용자 테이블의 변경만 커밋 순서대로 남음
+	records, err := db.ChangesSince(0, 0)
+	assert.NoError(t, err)
+	var ops []string
+	for i, rec := range records {
+		ops = append(ops, fmt.Sprintf("%s %s %s", rec.Op, rec.Table, rec.Key))
+		assert.False(t, rec.Time.IsZero())
+		if i > 0 {
+			assert.Greater(t, rec.Seq, records[i-1].Seq)
+		}
+	}
+	assert.Equal(t, []string{"create_table kv ", "insert kv a1", "insert kv b1", "delete kv a1"}, ops)
+
+	// 마지막으로 처리한 시퀀스부터 이어 읽기
+	page, err := db.Reader().ChangesSince(records[1].Seq, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ChangeRecord{records[2]}, page)
+	assert.NoError(t, db.Close())
+
+	// 재시작 후에도 남아 있고 이어서 기록됨
+	db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Changefeed = true })
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Insert("kv", "c1", "3"))
+	tail, err := db.ChangesSince(records[3].Seq, 0)
+	assert.NoError(t, err)
+	assert.Len(t, tail, 1)
+	assert.Equal(t, "c1", tail[0].Key)
+}
+
+func TestChangefeedRetentionTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "retention.db")
+	db, err := openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Changefeed, c.ChangefeedRetention = true, 3 })
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("kv"))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Insert("kv", fmt.Sprintf("k%d", i), "v"))
+	}
+	// 레코드 1(create_table)~6 중 3까지 잘림
+	_, err = db.ChangesSince(2, 0)
+	assert.ErrorIs(t, err, domain.ErrChangefeedTruncated)
+	records, err := db.ChangesSince(3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, 3, db.Stats()["changefeed_records"])
+	assert.NoError(t, db.Close())
+
+	// 보존 개수를 줄여 다시 열면 열 때 잘라냄
+	db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Changefeed, c.ChangefeedRetention = true, 2 })
+	assert.NoError(t, err)
+	defer db.Close()
+	_, err = db.ChangesSince(3, 0)
+	assert.ErrorIs(t, err, domain.ErrChangefeedTruncated)
+	records, err = db.ChangesSince(4, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5, 6}, []uint64{records[0].Seq, records[1].Seq})
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/codec"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+type codecUser struct {
+	Name    string
+	Age     int
+	Tags    []string
+	Created time.Time
+}
+
+func TestDatabaseStoresStructsWithCodecs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typed.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.Equal(t, "json", db.Codec().Name())
+
+	want := codecUser{Name: "Alice", Age: 30, Tags: []string{"a", "b"}, Created: time.Unix(1700000000, 0).UTC()}
+	for _, name := range []string{"json", "gob", "msgpack"} {
+		c, err := codec.ByName(name)
+		assert.NoError(t, err)
+		assert.NoError(t, db.InsertValueWithCodec("users", name, want, c), name)
+
+		var got codecUser
+		assert.NoError(t, db.GetValueWithCodec("users", name, &got, c), name)
+		assert.Equal(t, want.Name, got.Name, name)
+		assert.Equal(t, want.Tags, got.Tags, name)
+		assert.True(t, want.Created.Equal(got.Created), name)
+	}
+
+	assert.NoError(t, db.InsertValue("users", "default", want))
+	var got codecUser
+	assert.NoError(t, db.GetValue("users", "default", &got))
+	assert.Equal(t, 30, got.Age)
+	assert.Error(t, db.GetValueWithCodec("users", "json", &got, codec.Gob))
+
+	_, err = codec.ByName("xml")
+	assert.Error(t, err)
+}
+
+func TestGetOrDefault(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTableWithOptions("settings", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, db.Insert("settings", "theme", "dark"))
+
+	got, err := db.GetOrDefault("settings", "theme", "light")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", got)
+	got, err = db.GetOrDefault("settings", "lang", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", got)
+	_, err = db.GetOrDefault("missing", "lang", "en")
+	assert.Error(t, err)
+
+	values, err := db.MultiGetOrDefault("settings", []string{"theme", "lang"}, "unset")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"theme": "dark", "lang": "unset"}, values)
+
+	assert.NoError(t, db.InsertValue("settings", "user", codecUser{Name: "Bob", Age: 41}))
+	user := codecUser{Name: "guest"}
+	found, err := db.GetValueOrDefault("settings", "nobody", &user)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, "guest", user.Name)
+	found, err = db.GetValueOrDefault("settings", "user", &user)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 41, user.Age)
+}
+
+func TestStorageAcceptsByteValues(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "bytes.db"))
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 4096})
+	assert.NoError(t, bt.Insert("k", []byte{0x00, 0xff, 'x'}))
+	val, err := bt.Get("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00\xffx", val)
+	assert.ErrorIs(t, bt.Insert("n", 42), ports.ErrInvalidValue)
+}
+
+func TestBinaryValuesRoundTrip(t *testing.T) {
+	blob := []byte{0x00, 'a', 0x00, 0xff, 0xfe, 0xc3, 0x28, 0x00}
+	dir := t.TempDir()
+
+	// B-tree: 재오픈 후에도 바이트가 그대로 보존되어야 함
+	path := filepath.Join(dir, "bin.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("blobs"))
+	assert.NoError(t, db.InsertBytes("blobs", "k\x00\xff", blob))
+	assert.NoError(t, db.Close())
+
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	got, err := db.GetBytes("blobs", "k\x00\xff")
+	assert.NoError(t, err)
+	assert.Equal(t, blob, got)
+	got[0] = 'X' // 반환된 슬라이스를 수정해도 저장된 값은 바뀌지 않음
+	again, err := db.GetBytes("blobs", "k\x00\xff")
+	assert.NoError(t, err)
+	assert.Equal(t, blob, again)
+
+	// File adapter
+	fpath := filepath.Join(dir, "bin.file")
+	f, err := file.NewFile(file.FileConfig{FilePath: fpath, ThreadSafe: true})
+	assert.NoError(t, err)
+	assert.NoError(t, f.InsertBytes([]byte("k"), blob))
+	assert.NoError(t, f.Close())
+	f, err = file.NewFile(file.FileConfig{FilePath: fpath, ThreadSafe: true})
+	assert.NoError(t, err)
+	defer f.Close()
+	got, err = f.GetBytes([]byte("k"))
+	assert.NoError(t, err)
+	assert.Equal(t, blob, got)
+}
+
+func TestBtreeInsertBytesCopiesValue(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "copy.db"))
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 4096, CacheSize: 16})
+
+	buf := []byte("original")
+	assert.NoError(t, bt.InsertBytes([]byte("k"), buf))
+	copy(buf, "mutated!")
+	val, err := bt.GetBytes([]byte("k"))
+	assert.NoError(t, err)
+	assert.Equal(t, "original", string(val))
+
+	_, err = bt.GetBytes([]byte("missing"))
+	assert.ErrorIs(t, err, ports.ErrKeyNotFound)
+	assert.NoError(t, bt.DeleteBytes([]byte("k")))
+}
@@ -87,3 +87,38 @@ func TestCommandHandler_AsyncExecution(t *testing.T) {
 	assert.NoError(t, err, "Get should succeed after async insert")
 	assert.Equal(t, "Alice", value, "Inserted value should match")
 }
+
+func TestCommandHandler_ConditionalWrites(t *testing.T) {
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	handler.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "locks"})
+	err := handler.ExecuteCommand(ctx, &application.InsertIfAbsentCommand{TableName: "locks", Key: "job", Value: "worker-1"})
+	assert.NoError(t, err, "First InsertIfAbsentCommand should succeed")
+	err = handler.ExecuteCommand(ctx, &application.InsertIfAbsentCommand{TableName: "locks", Key: "job", Value: "worker-2"})
+	assert.ErrorIs(t, err, domain.ErrKeyExists, "Second InsertIfAbsentCommand should fail")
+
+	err = handler.ExecuteCommand(ctx, &application.CompareAndSwapCommand{TableName: "locks", Key: "job", ExpectedValue: "worker-2", NewValue: "worker-3"})
+	assert.ErrorIs(t, err, domain.ErrCompareFailed, "CompareAndSwapCommand with a stale value should fail")
+	err = handler.ExecuteCommand(ctx, &application.CompareAndSwapCommand{TableName: "locks", Key: "job", ExpectedValue: "worker-1", NewValue: "worker-3"})
+	assert.NoError(t, err, "CompareAndSwapCommand with the current value should succeed")
+
+	value, err := handler.DB().Get("locks", "job")
+	assert.NoError(t, err)
+	assert.Equal(t, "worker-3", value)
+}
+
+func TestCommandHandler_Increment(t *testing.T) {
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	handler.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "stats"})
+	cmd := &application.IncrementCommand{TableName: "stats", Key: "hits", Delta: 3}
+	assert.NoError(t, handler.ExecuteCommand(ctx, cmd), "IncrementCommand should succeed")
+	assert.Equal(t, int64(3), cmd.Value, "IncrementCommand should report the new value")
+	cmd = &application.IncrementCommand{TableName: "stats", Key: "hits", Delta: -1}
+	assert.NoError(t, handler.ExecuteCommand(ctx, cmd))
+	assert.Equal(t, int64(2), cmd.Value)
+}
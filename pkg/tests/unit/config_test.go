@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/config"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func writeConfig(t *testing.T, path, body string) {
+	assert.NoError(t, os.WriteFile(path, []byte(body), 0644))
+}
+
+func TestConfigReloadAppliesMutableSubset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golite.json")
+	writeConfig(t, path, `{"file": "a.db", "log_level": "info", "cache_size": 10}`)
+	initial, err := config.Load(path, config.Defaults())
+	assert.NoError(t, err)
+
+	logger := &mockLogger{}
+	r := config.NewReloader(path, initial, logger)
+	var applied []config.Config
+	r.OnReload(func(c config.Config) error {
+		applied = append(applied, c)
+		return nil
+	})
+
+	writeConfig(t, path, `{"file": "a.db", "log_level": "warn", "cache_size": 64, "compaction_interval": "30s"}`)
+	changes, err := r.Reload()
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "log_level: info -> warn", changes[0].String())
+	assert.Len(t, applied, 1)
+	assert.Equal(t, 64, r.Current().CacheSize)
+	assert.Equal(t, config.Duration(30*time.Second), r.Current().CompactionInterval)
+	assert.Contains(t, logger.logs, "INFO: Config reloaded field=cache_size old=10 new=64")
+}
+
+func TestConfigReloadRejectsRestartOnlyChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golite.json")
+	writeConfig(t, path, `{"file": "a.db"}`)
+	initial, err := config.Load(path, config.Defaults())
+	assert.NoError(t, err)
+
+	r := config.NewReloader(path, initial, &mockLogger{})
+	calls := 0
+	r.OnReload(func(config.Config) error { calls++; return nil })
+
+	// 재적용 가능한 항목이 함께 바뀌어도 전체가 거부됨
+	writeConfig(t, path, `{"file": "b.db", "cache_size": 99}`)
+	_, err = r.Reload()
+	assert.ErrorContains(t, err, "restart required to change file: a.db -> b.db")
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, initial, r.Current())
+
+	writeConfig(t, path, `{"file": "a.db", "cache_sise": 99}`)
+	_, err = r.Reload()
+	assert.ErrorContains(t, err, "unknown field")
+
+	writeConfig(t, path, `{"file": "a.db", "log_level": "loud"}`)
+	_, err = r.Reload()
+	assert.ErrorContains(t, err, "invalid log_level")
+}
+
+func TestConfigReloadRollsBackFailedApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golite.json")
+	initial := config.Defaults()
+	writeConfig(t, path, `{"write_rate_limit": 5, "cache_size": 20}`)
+
+	limiter := utils.NewRateLimiter(initial.WriteRateLimit, 1)
+	r := config.NewReloader(path, initial, &mockLogger{})
+	r.OnReload(func(c config.Config) error { limiter.SetRate(c.WriteRateLimit); return nil })
+	r.OnReload(func(c config.Config) error {
+		if c.CacheSize > 10 {
+			return fmt.Errorf("cache too large")
+		}
+		return nil
+	})
+
+	_, err := r.Reload()
+	assert.ErrorContains(t, err, "cache too large")
+	assert.Equal(t, 0.0, limiter.Rate(), "earlier appliers are rolled back")
+	assert.Equal(t, initial, r.Current())
+}
+
+func TestConfigOverrideKeepsFlagValues(t *testing.T) {
+	cfg := config.Defaults()
+	flags := config.Defaults()
+	flags.CacheSize = 5
+	flags.FilePath = "flag.db"
+	config.Override(&cfg, flags, []string{"cache_size", "config"})
+	assert.Equal(t, 5, cfg.CacheSize)
+	assert.Equal(t, "golite.db", cfg.FilePath)
+}
+
+func TestLevelLoggerFiltersBelowLevel(t *testing.T) {
+	inner := &mockLogger{}
+	logger := utils.NewLevelLogger(inner, utils.LevelWarn)
+	logger.Info("hidden")
+	logger.Warn("shown")
+	logger.SetLevel(utils.LevelInfo)
+	logger.Info("now shown")
+	assert.Equal(t, []string{"WARN: shown", "INFO: now shown"}, inner.logs)
+}
+
+func TestStructuredLoggerWritesJSONFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := utils.With(utils.NewStructuredLogger(&buf, utils.LogFormatJSON), "table", "users")
+	logger.Warn("Slow insert", "key", "user1", "ms", 12)
+
+	var rec map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, "WARN", rec["level"])
+	assert.Equal(t, "Slow insert", rec["msg"])
+	assert.Equal(t, "users", rec["table"])
+	assert.Equal(t, "user1", rec["key"])
+	assert.Equal(t, float64(12), rec["ms"])
+}
+
+func TestWithSharesLevelOfLevelLogger(t *testing.T) {
+	inner := &mockLogger{}
+	logger := utils.NewLevelLogger(inner, utils.LevelWarn)
+	child := utils.With(logger, "adapter", "file")
+	child.Info("hidden")
+	logger.SetLevel(utils.LevelInfo)
+	child.Info("Compacting", "entries", 3)
+	assert.Equal(t, []string{"INFO: Compacting adapter=file entries=3"}, inner.logs)
+
+	_, err := utils.ParseLogFormat("xml")
+	assert.Error(t, err)
+	cfg := config.Defaults()
+	cfg.LogFormat = "xml"
+	assert.Error(t, cfg.Validate())
+}
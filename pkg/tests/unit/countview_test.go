@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestCountViewsFollowWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counts.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("kv"))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Insert("kv", fmt.Sprintf("users:%d", i), "u"))
+	}
+	assert.NoError(t, db.Insert("kv", "orders:1", "o"))
+
+	// 기존 키는 등록할 때 한 번 셈
+	assert.NoError(t, db.CreateCountView("kv", "users:"))
+	assert.NoError(t, db.CreateCountView("kv", "orders:"))
+	n, err := db.CountPrefix("kv", "users:")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	// 새 키만 늘리고, 덮어쓰기는 그대로, 삭제는 줄임
+	assert.NoError(t, db.Insert("kv", "users:5", "u"))
+	assert.NoError(t, db.Insert("kv", "users:0", "again"))
+	assert.NoError(t, db.Delete("kv", "users:1"))
+	assert.Error(t, db.Delete("kv", "users:missing"))
+	batch := domain.NewWriteBatch()
+	batch.Insert("kv", "orders:2", "o")
+	batch.Insert("kv", "orders:3", "o")
+	batch.Delete("kv", "users:2")
+	assert.NoError(t, db.Write(batch))
+
+	n, err = db.Reader().CountPrefix("kv", "users:")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+	n, err = db.CountPrefix("kv", "orders:")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	_, err = db.CountPrefix("kv", "items:")
+	assert.Error(t, err)
+	assert.Error(t, db.CreateCountView("kv", ""))
+	assert.Error(t, db.CreateCountView(domain.CountViewTable("kv"), "x"))
+	assert.NoError(t, db.Close())
+
+	// 다시 열면 저장된 뷰를 이어서 유지함
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Insert("kv", "users:9", "u"))
+	n, err = db.CountPrefix("kv", "users:")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	assert.NoError(t, db.DropCountView("kv", "orders:"))
+	_, err = db.CountPrefix("kv", "orders:")
+	assert.Error(t, err)
+	assert.NoError(t, db.DropTable("kv"))
+	assert.NotContains(t, db.GetSpec().Tables, domain.CountViewTable("kv"))
+	assert.NoError(t, db.Close())
+}
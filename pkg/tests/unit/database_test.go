@@ -3,12 +3,15 @@ package unit
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
 	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
 // mockLogger는 테스트용 간단한 로거입니다.
@@ -16,9 +19,15 @@ type mockLogger struct {
 	logs []string
 }
 
-func (m *mockLogger) Info(msg string)  { m.logs = append(m.logs, "INFO: "+msg) }
-func (m *mockLogger) Warn(msg string)  { m.logs = append(m.logs, "WARN: "+msg) }
-func (m *mockLogger) Error(msg string) { m.logs = append(m.logs, "ERROR: "+msg) }
+func (m *mockLogger) Info(msg string, args ...any) {
+	m.logs = append(m.logs, "INFO: "+msg+utils.FormatArgs(args...))
+}
+func (m *mockLogger) Warn(msg string, args ...any) {
+	m.logs = append(m.logs, "WARN: "+msg+utils.FormatArgs(args...))
+}
+func (m *mockLogger) Error(msg string, args ...any) {
+	m.logs = append(m.logs, "ERROR: "+msg+utils.FormatArgs(args...))
+}
 
 // TestDatabaseBasicOperations tests basic Database operations.
 func TestDatabaseBasicOperations(t *testing.T) {
@@ -182,3 +191,160 @@ func TestDatabaseLimits(t *testing.T) {
 	assert.Equal(t, 1, db.GetStatus().TableCount, "Table count should not exceed limit")
 	assert.Equal(t, "max tables limit reached: 1", db.GetStatus().Error, "Status should reflect error")
 }
+
+// TestDatabaseCloseClosesStorage checks that Database.Close stops the storage adapter.
+func TestDatabaseCloseClosesStorage(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "golite.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: dbPath, ThreadSafe: true})
+	assert.NoError(t, err)
+	handle, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	db, err := domain.NewDatabaseWithStorage(domain.DatabaseConfig{Name: "testdb", FilePath: dbPath, ThreadSafe: true}, f, handle, &mockLogger{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	storage := db.Stats()["storage"].(map[string]interface{})
+	assert.Equal(t, 1, storage["entries"])
+	assert.NoError(t, db.Close())
+	assert.NoError(t, f.Close(), "closing the adapter again should be a no-op")
+
+	reopened, err := file.NewFile(file.FileConfig{FilePath: dbPath, ThreadSafe: true})
+	assert.NoError(t, err)
+	defer reopened.Close()
+	value, err := reopened.Get("users:user1")
+	assert.NoError(t, err, "Close should have flushed the adapter")
+	assert.Equal(t, "Alice", value)
+}
+
+func TestDatabaseStatsIncludesBtree(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	stats := db.Stats()
+	assert.Equal(t, 1, stats["tables"])
+	storage := stats["storage"].(map[string]interface{})
+	assert.Equal(t, 2, storage["degree"])
+	assert.Equal(t, int64(0), storage["corruptions"])
+}
+
+// TestDatabaseTablesAreIsolated checks that tables own separate key spaces.
+func TestDatabaseTablesAreIsolated(t *testing.T) {
+	logger := &mockLogger{}
+	path := filepath.Join(t.TempDir(), "golite.db")
+	config := domain.DatabaseConfig{
+		Name:       "testdb",
+		FilePath:   path,
+		BtConfig:   btree.BtConfig{Degree: 2, PageSize: 4096, ThreadSafe: true},
+		ThreadSafe: true,
+	}
+	db, err := domain.NewDatabase(config, logger)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("a"))
+	assert.NoError(t, db.CreateTable("a:b"))
+	// 접두사 방식이라면 두 키 모두 "a:b:c"가 되어 충돌함.
+	assert.NoError(t, db.Insert("a", "b:c", "first"))
+	assert.NoError(t, db.Insert("a:b", "c", "second"))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, db.Insert("a", fmt.Sprintf("key%02d", i), "v"))
+	}
+	assert.NoError(t, db.Close())
+
+	db, err = domain.NewDatabase(config, logger)
+	assert.NoError(t, err)
+	defer db.Close()
+	value, err := db.Get("a", "b:c")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", value)
+	value, err = db.Get("a:b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", value)
+	value, err = db.Get("a", "key19")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	// 다시 만든 테이블은 이전 데이터를 보지 않아야 함.
+	assert.NoError(t, db.DropTable("a"))
+	assert.NoError(t, db.CreateTable("a"))
+	_, err = db.Get("a", "b:c")
+	assert.Error(t, err)
+	value, err = db.Get("a:b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", value)
+}
+
+// TestDropTableDeletesPrefixedData checks that dropping a table removes its keys from storage.
+func TestDropTableDeletesPrefixedData(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "golite.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: dbPath, ThreadSafe: true})
+	assert.NoError(t, err)
+	handle, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	db, err := domain.NewDatabaseWithStorage(domain.DatabaseConfig{Name: "testdb", FilePath: dbPath, ThreadSafe: true}, f, handle, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.CreateTable("orders"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	assert.NoError(t, db.Insert("users", "user2", "Bob"))
+	assert.NoError(t, db.Insert("orders", "order1", "book"))
+
+	assert.NoError(t, db.DropTable("users"))
+	_, err = f.Get("users:user1")
+	assert.Error(t, err, "dropped table data should be removed from storage")
+	assert.NoError(t, db.CreateTable("users"))
+	_, err = db.Get("users", "user2")
+	assert.Error(t, err)
+	value, err := db.Get("orders", "order1")
+	assert.NoError(t, err)
+	assert.Equal(t, "book", value)
+}
+
+func TestBtreeDeletePrefix(t *testing.T) {
+	f, err := os.OpenFile(filepath.Join(t.TempDir(), "tree.db"), os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 2, PageSize: 512, ThreadSafe: true})
+	for i := 0; i < 40; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("a:%02d", i), "v"))
+		assert.NoError(t, bt.Insert(fmt.Sprintf("b:%02d", i), "v"))
+	}
+	n, err := bt.DeletePrefix("a:")
+	assert.NoError(t, err)
+	assert.Equal(t, 40, n)
+	assert.Equal(t, 40, bt.GetLength())
+	_, err = bt.Get("a:07")
+	assert.Error(t, err)
+	val, err := bt.Get("b:07")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+}
+
+// setupDatabaseTest creates a thread-safe B-tree backed Database on a temp file.
+func setupDatabaseTest(t *testing.T) (*domain.Database, func()) {
+	logger := &mockLogger{}
+	file, err := os.CreateTemp("", "db_test_*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	file.Close()
+	config := domain.DatabaseConfig{
+		Name:       "testdb",
+		FilePath:   file.Name(),
+		BtConfig:   btree.BtConfig{Degree: 2, PageSize: 4096, ThreadSafe: true},
+		MaxTables:  10,
+		ThreadSafe: true,
+	}
+	db, err := domain.NewDatabase(config, logger)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	cleanup := func() {
+		db.Close()
+		os.Remove(file.Name())
+	}
+	return db, cleanup
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestDeleteIf(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "deleteif.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTableWithOptions("locks", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, db.Insert("locks", "job", "worker-1"))
+
+	assert.ErrorIs(t, db.DeleteIf("locks", "job", "worker-2"), domain.ErrCompareFailed)
+	assert.ErrorIs(t, db.DeleteIf("locks", "missing", ""), domain.ErrCompareFailed)
+	_, err = db.Get("locks", "job")
+	assert.NoError(t, err, "a failed DeleteIf should leave the key")
+
+	assert.NoError(t, db.Writer().DeleteIf("locks", "job", "worker-1"))
+	_, err = db.Get("locks", "job")
+	assert.Error(t, err)
+	assert.Error(t, db.DeleteIf("nope", "job", "worker-1"))
+}
+
+func TestDeleteRange(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "range.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("events"))
+	assert.NoError(t, db.CreateTable("other"))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, db.Insert("events", fmt.Sprintf("e%02d", i), "v"))
+	}
+	assert.NoError(t, db.Insert("other", "e05", "keep"))
+
+	var deleted []string
+	remove := db.AddMutationListener(func(m domain.Mutation) {
+		if m.Op == domain.MutationDelete {
+			deleted = append(deleted, m.Key)
+		}
+	})
+	n, err := db.DeleteRange("events", "e05", "e10")
+	remove()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, []string{"e05", "e06", "e07", "e08", "e09"}, deleted)
+
+	count, err := db.Count("events")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), count)
+	_, err = db.Get("events", "e04")
+	assert.NoError(t, err)
+	_, err = db.Get("events", "e10")
+	assert.NoError(t, err)
+	_, err = db.Get("events", "e07")
+	assert.Error(t, err)
+
+	// 상한이 없으면 테이블 끝까지 지우고, 다른 테이블은 그대로 둠
+	n, err = db.Writer().DeleteRange("events", "e15", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	n, err = db.DeleteRange("events", "e10", "e05")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	value, err := db.Get("other", "e05")
+	assert.NoError(t, err)
+	assert.Equal(t, "keep", value)
+}
+
+func TestDeleteRangeSoftDelete(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "rangesoft.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("items"))
+	assert.NoError(t, db.Insert("items", "a", "1"))
+	assert.NoError(t, db.Insert("items", "b", "2"))
+	assert.NoError(t, db.SetSoftDelete("items", 1<<40))
+
+	n, err := db.DeleteRange("items", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, db.Undelete("items", "b"), "range deletes should keep soft-deleted keys")
+}
+
+func TestCommandHandler_DeleteIfAndRange(t *testing.T) {
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	handler.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "jobs"})
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, handler.ExecuteCommand(ctx, &application.InsertCommand{TableName: "jobs", Key: key, Value: "done"}))
+	}
+	err := handler.ExecuteCommand(ctx, &application.DeleteIfCommand{TableName: "jobs", Key: "a", ExpectedValue: "running"})
+	assert.ErrorIs(t, err, domain.ErrCompareFailed)
+	assert.NoError(t, handler.ExecuteCommand(ctx, &application.DeleteIfCommand{TableName: "jobs", Key: "a", ExpectedValue: "done"}))
+
+	cmd := &application.DeleteRangeCommand{TableName: "jobs", StartKey: "b", EndKey: "d"}
+	assert.NoError(t, handler.ExecuteCommand(ctx, cmd))
+	assert.Equal(t, 2, cmd.Deleted)
+	count, err := handler.DB().Count("jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestDumpLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src, err := openBtreeDatabase(filepath.Join(dir, "src.db"))
+	assert.NoError(t, err)
+	defer src.Close()
+	assert.NoError(t, src.CreateTable("users"))
+	assert.NoError(t, src.CreateTableWithOptions("events", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, src.CreateTable("empty"))
+	assert.NoError(t, src.Insert("users", "u1", "Alice"))
+	assert.NoError(t, src.Insert("users", "u2", "Bob\x00\xff"))
+	assert.NoError(t, src.Insert("events", "e1", "login"))
+
+	var dump bytes.Buffer
+	assert.NoError(t, src.DumpTo(&dump))
+	var again bytes.Buffer
+	assert.NoError(t, src.DumpTo(&again))
+	assert.Equal(t, dump.Bytes(), again.Bytes(), "dumps of the same data should be identical")
+
+	dst, err := openBtreeDatabase(filepath.Join(dir, "dst.db"))
+	assert.NoError(t, err)
+	defer dst.Close()
+	assert.NoError(t, dst.CreateTable("users"))
+	assert.NoError(t, dst.Insert("users", "u3", "Carol"))
+	assert.NoError(t, dst.LoadFrom(bytes.NewReader(dump.Bytes())))
+
+	value, err := dst.Get("users", "u2")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob\x00\xff", value)
+	value, err = dst.Get("users", "u3")
+	assert.NoError(t, err, "keys missing from the dump should be kept")
+	assert.Equal(t, "Carol", value)
+	opts, err := dst.TableOptions("events")
+	assert.NoError(t, err)
+	assert.True(t, opts.Metadata)
+	value, _, err = dst.GetWithMeta("events", "e1")
+	assert.NoError(t, err)
+	assert.Equal(t, "login", value)
+	_, err = dst.TableOptions("empty")
+	assert.NoError(t, err, "empty tables should be loaded")
+}
+
+func TestLoadFromRejectsCorruptDump(t *testing.T) {
+	src, err := openBtreeDatabase(filepath.Join(t.TempDir(), "src.db"))
+	assert.NoError(t, err)
+	defer src.Close()
+	assert.NoError(t, src.CreateTable("users"))
+	assert.NoError(t, src.Insert("users", "u1", "Alice"))
+	var dump bytes.Buffer
+	assert.NoError(t, src.DumpTo(&dump))
+	data := dump.Bytes()
+
+	for name, stream := range map[string][]byte{
+		"not a dump": []byte("hello world"),
+		"truncated":  data[:len(data)-6],
+		"flipped":    append(append([]byte(nil), data[:20]...), append([]byte{data[20] ^ 1}, data[21:]...)...),
+	} {
+		dst, err := openBtreeDatabase(filepath.Join(t.TempDir(), "dst.db"))
+		assert.NoError(t, err)
+		err = dst.LoadFrom(bytes.NewReader(stream))
+		assert.True(t, errors.Is(err, domain.ErrDumpCorrupt), "%s: %v", name, err)
+		assert.NoError(t, dst.Close())
+	}
+}
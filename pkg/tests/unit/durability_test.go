@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+func TestParseDurability(t *testing.T) {
+	d, err := ports.ParseDurability("")
+	assert.NoError(t, err)
+	assert.Equal(t, ports.DurabilityAsync, d)
+	d, err = ports.ParseDurability("fsync-per-batch")
+	assert.NoError(t, err)
+	assert.True(t, d.Fsync())
+	_, err = ports.ParseDurability("sometimes")
+	assert.Error(t, err)
+}
+
+func TestDatabaseFsyncPerBatchSharesFsyncs(t *testing.T) {
+	cfg := testDatabaseConfig(filepath.Join(t.TempDir(), "dur.db"), func(c *domain.DatabaseConfig) { c.Durability = "sometimes" })
+	_, err := domain.NewDatabase(cfg, &mockLogger{})
+	assert.Error(t, err)
+
+	cfg.Durability = ports.DurabilityFsyncPerBatch
+	db, err := domain.NewDatabase(cfg, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("t"))
+
+	const writers, perWriter = 8, 20
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				assert.NoError(t, db.Insert("t", fmt.Sprintf("%d-%d", w, i), "v"))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := db.Stats()
+	assert.Equal(t, "fsync-per-batch", stats["durability"])
+	fsyncs := stats["group_fsyncs"].(uint64)
+	assert.Greater(t, fsyncs, uint64(0))
+	assert.LessOrEqual(t, fsyncs, uint64(writers*perWriter+1))
+	count, err := db.Count("t")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(writers*perWriter), count)
+}
+
+func walSize(t *testing.T, path string) int64 {
+	st, err := os.Stat(path + ".wal")
+	assert.NoError(t, err)
+	return st.Size()
+}
+
+func TestFileDurabilityLevels(t *testing.T) {
+	for _, tc := range []struct {
+		durability ports.Durability
+		threadSafe bool
+		written    bool // Insert 직후 WAL에 기록되어 있어야 하는지
+	}{
+		{ports.DurabilityNone, true, false},
+		{ports.DurabilityAsync, true, false},
+		{ports.DurabilityFsyncPerCommit, true, true},
+		{ports.DurabilityFsyncPerBatch, true, true},
+		{ports.DurabilityFsyncPerCommit, false, true},
+	} {
+		path := filepath.Join(t.TempDir(), "dur.db")
+		f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: tc.threadSafe, Durability: tc.durability})
+		assert.NoError(t, err)
+		assert.NoError(t, f.Insert("k", "v"))
+		assert.Equal(t, tc.written, walSize(t, path) > 4, tc.durability)
+		assert.NoError(t, f.Close())
+
+		f, err = file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: tc.threadSafe})
+		assert.NoError(t, err)
+		val, err := f.Get("k")
+		assert.NoError(t, err, "clean shutdown keeps writes at every level")
+		assert.Equal(t, "v", val)
+		assert.NoError(t, f.Close())
+	}
+}
+
+func TestLSMDurabilityLevels(t *testing.T) {
+	for _, tc := range []struct {
+		durability ports.Durability
+		entries    int64
+		syncs      int64
+	}{
+		{ports.DurabilityNone, 0, 0},
+		{ports.DurabilityFsyncPerCommit, 5, 5},
+	} {
+		config := lsmtree.DefaultConfig()
+		config.FilePath = t.TempDir()
+		config.Durability = tc.durability
+		lsm, err := lsmtree.NewLSMTree(config)
+		assert.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, lsm.Insert(fmt.Sprint(i), "v"))
+		}
+		wal := lsm.Stats()["wal"].(map[string]interface{})
+		assert.Equal(t, tc.entries, wal["entries"], tc.durability)
+		assert.Equal(t, tc.syncs, wal["syncs"], tc.durability)
+		assert.NoError(t, lsm.Close())
+	}
+
+	config := lsmtree.DefaultConfig()
+	config.Durability = "sometimes"
+	assert.Error(t, config.Validate())
+}
+
+func TestDatabaseSyncIsACommitPoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.db")
+	store, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	f, err := os.OpenFile(path+".meta", os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	db, err := domain.NewDatabaseWithStorage(domain.DatabaseConfig{Name: "sync", FilePath: path, ThreadSafe: true}, store, f, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("t"))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, db.Insert("t", fmt.Sprint(i), "v"))
+	}
+
+	// async 쓰기도 Sync가 돌아온 뒤에는 장애 직후의 파일에 남아 있음
+	assert.NoError(t, db.Writer().Sync(context.Background()))
+	recovered, err := file.NewFile(file.FileConfig{FilePath: crashImage(t, path), ThreadSafe: true})
+	assert.NoError(t, err)
+	defer recovered.Close()
+	assert.Equal(t, 10, recovered.Stats()["keys"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, db.Sync(ctx), context.Canceled)
+}
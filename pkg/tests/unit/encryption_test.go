@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+var testMasterKey = bytes.Repeat([]byte{0x42}, 32)
+
+func TestSelectiveTableEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secure.db")
+	db, err := openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.MasterKey = testMasterKey })
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTableWithOptions("secrets", domain.TableOptions{Encrypted: true, Metadata: true}))
+	assert.NoError(t, db.CreateTable("telemetry"))
+
+	assert.NoError(t, db.Insert("secrets", "card", "4111-1111-1111-1111"))
+	assert.NoError(t, db.Insert("telemetry", "cpu", "plain-telemetry-sample"))
+	got, err := db.Get("secrets", "card")
+	assert.NoError(t, err)
+	assert.Equal(t, "4111-1111-1111-1111", got)
+	raw, err := db.GetBytes("secrets", "card")
+	assert.NoError(t, err)
+	assert.Equal(t, "4111-1111-1111-1111", string(raw))
+	assert.NoError(t, db.Scan("secrets", "", func(key, value string) bool {
+		assert.Equal(t, "4111-1111-1111-1111", value)
+		return true
+	}))
+
+	spec := db.GetSpec()
+	assert.True(t, spec.Tables["secrets"].Encrypted)
+	assert.NotEmpty(t, spec.Tables["secrets"].KeyID)
+	assert.False(t, spec.Tables["telemetry"].Encrypted)
+	keyID := spec.Tables["secrets"].KeyID
+	assert.NoError(t, db.Close())
+
+	// 암호화 테이블의 값만 파일에서 평문으로 보이지 않음
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(data, []byte("4111-1111-1111-1111")))
+	assert.True(t, bytes.Contains(data, []byte("plain-telemetry-sample")))
+
+	// 같은 마스터 키로 다시 열면 키 ID와 옵션이 복원됨
+	db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.MasterKey = testMasterKey })
+	assert.NoError(t, err)
+	opts, err := db.TableOptions("secrets")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TableOptions{Encrypted: true, Metadata: true}, opts)
+	assert.Equal(t, keyID, db.GetSpec().Tables["secrets"].KeyID)
+	got, err = db.Get("secrets", "card")
+	assert.NoError(t, err)
+	assert.Equal(t, "4111-1111-1111-1111", got)
+	assert.NoError(t, db.Close())
+
+	// 다른 마스터 키나 키 없이 열면 암호화 테이블만 사용할 수 없음
+	for _, key := range [][]byte{bytes.Repeat([]byte{0x07}, 32), nil} {
+		db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.MasterKey = key })
+		assert.NoError(t, err)
+		_, err = db.Get("secrets", "card")
+		assert.True(t, errors.Is(err, domain.ErrMasterKeyRequired))
+		assert.Error(t, db.Insert("secrets", "card", "x"))
+		got, err = db.Get("telemetry", "cpu")
+		assert.NoError(t, err)
+		assert.Equal(t, "plain-telemetry-sample", got)
+		assert.NoError(t, db.Close())
+	}
+}
+
+func TestEncryptedTableRequiresMasterKey(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "nokey.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	err = db.CreateTableWithOptions("secrets", domain.TableOptions{Encrypted: true})
+	assert.True(t, errors.Is(err, domain.ErrMasterKeyRequired))
+	assert.NotContains(t, db.GetSpec().Tables, "secrets")
+
+	_, err = domain.NewDatabase(domain.DatabaseConfig{
+		Name:      "bad",
+		FilePath:  filepath.Join(t.TempDir(), "bad.db"),
+		MasterKey: []byte("short"),
+	}, &mockLogger{})
+	assert.Error(t, err)
+
+	assert.Equal(t, "metadata,encrypted", domain.TableOptions{Metadata: true, Encrypted: true}.String())
+	assert.Equal(t, domain.TableOptions{Encrypted: true}, domain.ParseTableOptions("encrypted"))
+}
@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/events"
+)
+
+// nextEvent는 구독 채널에서 이벤트 하나를 기다립니다.
+func nextEvent(t *testing.T, sub *events.Subscription) events.Event {
+	select {
+	case e := <-sub.C:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for event")
+		return events.Event{}
+	}
+}
+
+func TestDatabaseLifecycleEvents(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+
+	sub := db.Subscribe(events.TableCreated, events.TableDropped, events.BackupTaken)
+	defer sub.Close()
+
+	assert.NoError(t, db.CreateTable("users"))
+	e := nextEvent(t, sub)
+	assert.Equal(t, events.TableCreated, e.Type)
+	assert.Equal(t, "users", e.Table)
+
+	assert.NoError(t, db.Snapshot(filepath.Join(t.TempDir(), "backup.db")))
+	assert.Equal(t, events.BackupTaken, nextEvent(t, sub).Type)
+
+	assert.NoError(t, db.DropTable("users"))
+	e = nextEvent(t, sub)
+	assert.Equal(t, events.TableDropped, e.Type)
+	assert.Equal(t, "users", e.Table)
+}
+
+func TestLSMTreeFlushAndCompactionEvents(t *testing.T) {
+	bus := events.NewBus()
+	sub := bus.Subscribe(16)
+	defer sub.Close()
+
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.CompactionInterval = time.Hour
+	config.Events = bus
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+
+	assert.NoError(t, lsm.Insert("a", "1"))
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.Equal(t, events.FlushCompleted, nextEvent(t, sub).Type)
+}
+
+func TestBusDropsForSlowSubscribers(t *testing.T) {
+	bus := events.NewBus()
+	sub := bus.Subscribe(1)
+	defer sub.Close()
+
+	bus.Publish(events.Event{Type: events.FlushCompleted})
+	bus.Publish(events.Event{Type: events.FlushCompleted})
+	assert.Equal(t, int64(1), bus.Dropped(), "second event should be dropped without blocking")
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// TestFileCompactionTriggers는 WAL 크기와 죽은 엔트리 비율이 기준을 넘으면 주기를 기다리지 않고
+// compaction이 실행되는지 검증합니다.
+func TestFileCompactionTriggers(t *testing.T) {
+	t.Run("wal_bytes", func(t *testing.T) {
+		f, err := file.NewFile(file.FileConfig{
+			FilePath:            filepath.Join(t.TempDir(), "wal.db"),
+			Durability:          ports.DurabilityFsyncPerCommit,
+			CompactionWALBytes:  4096,
+			CompactionDeadRatio: -1,
+		})
+		assert.NoError(t, err)
+		defer f.Close()
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, f.Insert(fmt.Sprintf("key%03d", i), "value-of-some-length"))
+		}
+		assert.Eventually(t, func() bool { return f.RecoveryDebt() < 4096 }, 5*time.Second, 5*time.Millisecond)
+		assert.Greater(t, f.Stats()["compaction_triggers"], int64(0))
+	})
+
+	t.Run("dead_ratio", func(t *testing.T) {
+		f, err := file.NewFile(file.FileConfig{
+			FilePath:           filepath.Join(t.TempDir(), "dead.db"),
+			CompactionWALBytes: -1,
+		})
+		assert.NoError(t, err)
+		defer f.Close()
+		for round := 0; round < 3; round++ {
+			for i := 0; i < 1000; i++ {
+				assert.NoError(t, f.Insert(fmt.Sprintf("key%03d", i), fmt.Sprint(round)))
+			}
+		}
+		assert.Eventually(t, func() bool {
+			stats := f.Stats()
+			return stats["dead_entries"].(int) < 1000
+		}, 5*time.Second, 5*time.Millisecond)
+		assert.Equal(t, 1000, f.Stats()["keys"])
+		assert.Greater(t, f.Stats()["compaction_triggers"], int64(0))
+	})
+
+	_, err := file.NewFile(file.FileConfig{FilePath: filepath.Join(t.TempDir(), "x.db"), CompactionDeadRatio: 2})
+	assert.Error(t, err)
+}
+
+// TestFileCompactionDuringWrites는 쓰기와 겹친 compaction이 쓰기를 잃지 않고, 장애 직후의 파일에서도
+// 메모리와 같은 상태로 복구되며, 쓰기가 멈춘 뒤의 ForceCompaction은 WAL을 비우는지 검증합니다.
+func TestFileCompactionDuringWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "race.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, Durability: ports.DurabilityFsyncPerBatch})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	const writers, rounds, keys = 4, 200, 50
+	done := make(chan struct{})
+	compacted := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				compacted <- nil
+				return
+			default:
+			}
+			if err := f.ForceCompaction(); err != nil {
+				compacted <- err
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				key := fmt.Sprintf("k%d", i%keys)
+				if (i+w)%5 == 0 {
+					f.Delete(key) // 없는 키면 ErrKeyNotFound
+					continue
+				}
+				assert.NoError(t, f.Insert(key, fmt.Sprintf("w%d-%d", w, i)))
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(done)
+	assert.NoError(t, <-compacted)
+
+	check := func(recovered *file.File) {
+		for i := 0; i < keys; i++ {
+			key := fmt.Sprintf("k%d", i)
+			want, wantErr := f.Get(key)
+			got, err := recovered.Get(key)
+			assert.Equal(t, wantErr, err, key)
+			assert.Equal(t, want, got, key)
+		}
+	}
+	recovered, err := file.NewFile(file.FileConfig{FilePath: crashImage(t, path)})
+	assert.NoError(t, err)
+	check(recovered)
+	assert.NoError(t, recovered.Close())
+
+	assert.NoError(t, f.ForceCompaction())
+	assert.Equal(t, int64(0), f.RecoveryDebt())
+	recovered, err = file.NewFile(file.FileConfig{FilePath: crashImage(t, path)})
+	assert.NoError(t, err)
+	defer recovered.Close()
+	check(recovered)
+}
@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+// crashImage copies the files of the file adapter at path as they are on disk right now,
+// without closing the adapter, as if the process had died at this point.
+func crashImage(t *testing.T, path string) string {
+	image := filepath.Join(t.TempDir(), "crash.db")
+	for _, suffix := range []string{"", ".wal"} {
+		data, err := os.ReadFile(path + suffix)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(image+suffix, data, 0644))
+	}
+	return image
+}
+
+func TestFileCrashKeepsWALOrderOfConcurrentWrites(t *testing.T) {
+	for _, durability := range []ports.Durability{ports.DurabilityAsync, ports.DurabilityFsyncPerCommit, ports.DurabilityFsyncPerBatch} {
+		t.Run(string(durability), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "order.db")
+			f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, Durability: durability})
+			assert.NoError(t, err)
+			defer f.Close()
+
+			// 작성자들이 같은 키를 번갈아 덮어쓰고 지움: 재생 결과는 메모리에서 마지막으로 보인 쓰기와 같아야 함
+			const writers, rounds, keys = 8, 100, 10
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < rounds; i++ {
+						key := fmt.Sprintf("k%d", i%keys)
+						if (i+w)%7 == 0 {
+							f.Delete(key) // 없는 키면 ErrKeyNotFound
+							continue
+						}
+						assert.NoError(t, f.Insert(key, fmt.Sprintf("w%d-%d", w, i)))
+					}
+				}(w)
+			}
+			wg.Wait()
+			if !durability.Fsync() {
+				assert.NoError(t, f.Sync())
+			}
+
+			recovered, err := file.NewFile(file.FileConfig{FilePath: crashImage(t, path), ThreadSafe: true})
+			assert.NoError(t, err)
+			defer recovered.Close()
+			for i := 0; i < keys; i++ {
+				key := fmt.Sprintf("k%d", i)
+				want, wantErr := f.Get(key)
+				got, err := recovered.Get(key)
+				assert.Equal(t, wantErr, err, key)
+				assert.Equal(t, want, got, key)
+			}
+		})
+	}
+}
+
+func TestFileCrashAfterFsyncedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commit.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, Durability: ports.DurabilityFsyncPerCommit})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, f.Insert("a", "1"))
+	assert.NoError(t, f.InsertBatch([]file.WalEntry{{Op: "INSERT", Key: "b", Value: "2"}, {Op: "DELETE", Key: "a"}}))
+
+	// 반환된 쓰기는 모두 파일에 있음
+	recovered, err := file.NewFile(file.FileConfig{FilePath: crashImage(t, path), ThreadSafe: true})
+	assert.NoError(t, err)
+	defer recovered.Close()
+	_, err = recovered.Get("a")
+	assert.ErrorIs(t, err, ports.ErrKeyNotFound)
+	val, err := recovered.Get("b")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", val)
+	assert.Equal(t, 0, int(f.Stats()["wal_pending"].(uint64)))
+}
+
+func TestFileDeleteIsLoggedOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delete.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: false})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, f.Insert("a", "1"))
+	assert.NoError(t, f.Insert("b", "2"))
+	assert.NoError(t, f.Sync())
+	assert.NoError(t, f.Delete("a"))
+	assert.ErrorIs(t, f.Delete("a"), ports.ErrKeyNotFound)
+	assert.NoError(t, f.Sync())
+
+	recovered, err := file.NewFile(file.FileConfig{FilePath: crashImage(t, path), ThreadSafe: false})
+	assert.NoError(t, err)
+	defer recovered.Close()
+	assert.Equal(t, 3, recovered.Recovery().Records)
+	_, err = recovered.Get("a")
+	assert.ErrorIs(t, err, ports.ErrKeyNotFound)
+}
+
+// TestFileWriteIsVisibleOnReturn은 모든 Durability에서 Insert와 Delete가 반환된 직후의 Get이 그 쓰기를
+// 보는지, 여러 작성자가 동시에 써도 그런지 검증합니다.
+func TestFileWriteIsVisibleOnReturn(t *testing.T) {
+	for _, durability := range []ports.Durability{ports.DurabilityNone, ports.DurabilityAsync, ports.DurabilityFsyncPerCommit, ports.DurabilityFsyncPerBatch} {
+		t.Run(string(durability), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "visible.db")
+			f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, Durability: durability})
+			assert.NoError(t, err)
+			defer f.Close()
+
+			var wg sync.WaitGroup
+			for w := 0; w < 8; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						key := fmt.Sprintf("w%d-%d", w, i)
+						assert.NoError(t, f.Insert(key, "v"))
+						val, err := f.Get(key)
+						assert.NoError(t, err, key)
+						assert.Equal(t, "v", val, key)
+						assert.NoError(t, f.Delete(key))
+						_, err = f.Get(key)
+						assert.ErrorIs(t, err, ports.ErrKeyNotFound, key)
+					}
+				}(w)
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestFileLargeValuesSurviveReopen(t *testing.T) {
+	big := bytes.Repeat([]byte{0x00, 0xff, 'x'}, 1<<20) // 3MB
+	for _, threadSafe := range []bool{true, false} {
+		path := filepath.Join(t.TempDir(), "large.db")
+		f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: threadSafe})
+		assert.NoError(t, err)
+		assert.NoError(t, f.InsertBytes([]byte("big"), big))
+		assert.NoError(t, f.Insert("small", "v"))
+
+		// WAL만으로 복구
+		assert.NoError(t, f.Snapshot(path+".copy"))
+		val, err := reopenFile(t, path, "big")
+		assert.NoError(t, err)
+		assert.Equal(t, string(big), val)
+
+		// 메인 파일(compaction 결과)에서 복구
+		assert.NoError(t, f.Persist())
+		assert.NoError(t, f.Close())
+		f, err = file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: threadSafe})
+		assert.NoError(t, err)
+		got, err := f.GetBytes([]byte("big"))
+		assert.NoError(t, err)
+		assert.Equal(t, big, got)
+		assert.NoError(t, f.Close())
+	}
+}
+
+// glb1Record encodes a WAL record in the old uint16-length format.
+func glb1Record(op byte, key, value string) []byte {
+	buf := []byte{op}
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(key)))
+	buf = append(buf, key...)
+	if op == file.OpInsert {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(value)))
+		buf = append(buf, value...)
+	}
+	return buf
+}
+
+// glb3Record encodes a checksummed WAL record numbered seq.
+func glb3Record(seq uint64, op byte, key, value string) []byte {
+	body := binary.AppendUvarint(nil, seq)
+	body = append(body, op)
+	body = binary.AppendUvarint(body, uint64(len(key)))
+	body = append(body, key...)
+	if op == file.OpInsert {
+		body = binary.AppendUvarint(body, uint64(len(value)))
+		body = append(body, value...)
+	}
+	rec := binary.AppendUvarint(nil, uint64(len(body)))
+	rec = append(rec, body...)
+	return append(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(rec)), rec...)
+}
+
+func TestFileUpgradesLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	main := []byte("GLB1")
+	main = binary.LittleEndian.AppendUint32(main, 2)
+	for _, kv := range [][2]string{{"a", "1"}, {"b", "2"}} {
+		main = binary.LittleEndian.AppendUint16(main, uint16(len(kv[0])))
+		main = binary.LittleEndian.AppendUint16(main, uint16(len(kv[1])))
+		main = append(main, kv[0]+kv[1]...)
+	}
+	wal := []byte("GLB1")
+	wal = append(wal, glb1Record(file.OpInsert, "c", "3")...)
+	wal = append(wal, glb1Record(file.OpDelete, "a", "")...)
+	assert.NoError(t, os.WriteFile(path, main, 0644))
+	assert.NoError(t, os.WriteFile(path+".wal", wal, 0644))
+
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	_, err = f.Get("a")
+	assert.Error(t, err)
+	for k, v := range map[string]string{"b": "2", "c": "3"} {
+		val, err := f.Get(k)
+		assert.NoError(t, err)
+		assert.Equal(t, v, val)
+	}
+
+	// 열자마자 메인 파일은 GLB4, WAL은 GLB3으로 다시 쓰임
+	for p, magic := range map[string]string{path: "GLB4", path + ".wal": "GLB3"} {
+		data, err := os.ReadFile(p)
+		assert.NoError(t, err)
+		assert.Equal(t, magic, string(data[:4]), p)
+	}
+
+	assert.NoError(t, f.Insert("d", "4"))
+	assert.NoError(t, f.Close())
+	f, err = file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	defer f.Close()
+	val, err := f.Get("d")
+	assert.NoError(t, err)
+	assert.Equal(t, "4", val)
+}
+
+func TestFileCoalescesPendingWALRecords(t *testing.T) {
+	for _, threadSafe := range []bool{true, false} {
+		path := filepath.Join(t.TempDir(), "hot.db")
+		f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: threadSafe})
+		assert.NoError(t, err)
+		for i := 0; i < 1000; i++ {
+			assert.NoError(t, f.Insert("hot", fmt.Sprint(i)))
+		}
+		assert.NoError(t, f.Insert("cold", "c"))
+		assert.NoError(t, f.Delete("cold"))
+		assert.NoError(t, f.Sync())
+		assert.GreaterOrEqual(t, f.Stats()["wal_coalesced"], int64(999))
+
+		wal, err := os.Stat(path + ".wal")
+		assert.NoError(t, err)
+		assert.Less(t, wal.Size(), int64(100), "only the final record of each key is written")
+
+		assert.NoError(t, f.Snapshot(path+".copy"))
+		val, err := reopenFile(t, path, "hot")
+		assert.NoError(t, err)
+		assert.Equal(t, "999", val)
+		_, err = reopenFile(t, path, "cold")
+		assert.Error(t, err)
+		assert.NoError(t, f.Close())
+	}
+}
+
+func TestFileCompactsAfterLargeRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debt.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, f.Insert(fmt.Sprintf("k%03d", i), "value"))
+	}
+	assert.NoError(t, f.Sync())
+	debt := f.RecoveryDebt()
+	assert.Greater(t, debt, int64(1000))
+	assert.NoError(t, f.Snapshot(path+".copy"))
+	assert.NoError(t, f.Close())
+
+	// 임계값 이하: 재생만 하고 부채는 그대로
+	small, err := file.NewFile(file.FileConfig{FilePath: path + ".copy", ThreadSafe: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, small.Recovery().Records)
+	assert.Equal(t, debt, small.Recovery().WALBytes)
+	assert.False(t, small.Recovery().Compacted)
+	assert.Equal(t, debt, small.RecoveryDebt())
+	assert.NoError(t, small.Snapshot(path+".copy2"))
+	assert.NoError(t, small.Close())
+
+	// 임계값 초과: 복구 직후 compaction이 예약되어 부채가 0이 됨
+	big, err := file.NewFile(file.FileConfig{FilePath: path + ".copy2", ThreadSafe: true, RecoveryMaxWAL: 100})
+	assert.NoError(t, err)
+	defer big.Close()
+	assert.True(t, big.Recovery().Compacted)
+	assert.Eventually(t, func() bool { return big.RecoveryDebt() == 0 }, time.Second, 5*time.Millisecond)
+	val, err := big.Get("k099")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val)
+}
+
+// TestFileWALChecksums는 체크섬이 맞지 않는 WAL 레코드에서 strict 복구는 재생을 멈추고 WAL을 잘라
+// 새 레코드가 이어지게 하며, best_effort 복구는 그 레코드만 건너뛰는지 검증합니다.
+func TestFileWALChecksums(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crc.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	for _, k := range []string{"a", "b", "c"} {
+		assert.NoError(t, f.Insert(k, "value-"+k))
+	}
+	assert.NoError(t, f.Sync())
+	image := crashImage(t, path)
+	assert.NoError(t, f.Close())
+	wal, err := os.ReadFile(image + ".wal")
+	assert.NoError(t, err)
+	assert.Equal(t, "GLB3", string(wal[:4]))
+	wal[bytes.Index(wal, []byte("value-b"))] ^= 0xff
+
+	open := func(mode string) (*file.File, string) {
+		dir := filepath.Join(t.TempDir(), "crc.db")
+		assert.NoError(t, os.WriteFile(dir, nil, 0644))
+		assert.NoError(t, os.WriteFile(dir+".wal", wal, 0644))
+		f, err := file.NewFile(file.FileConfig{FilePath: dir, ThreadSafe: true, RecoveryMode: mode})
+		assert.NoError(t, err)
+		return f, dir
+	}
+	has := func(f *file.File, key string) bool {
+		_, err := f.Get(key)
+		return err == nil
+	}
+
+	strict, dir := open("")
+	assert.True(t, has(strict, "a"))
+	assert.False(t, has(strict, "b"))
+	assert.False(t, has(strict, "c"), "replay stops at the corrupted record")
+	assert.Equal(t, 1, strict.Recovery().Corrupted)
+	assert.Equal(t, 1, strict.Recovery().Records)
+	assert.NoError(t, strict.Insert("d", "value-d"))
+	assert.NoError(t, strict.Sync())
+	recovered, err := file.NewFile(file.FileConfig{FilePath: crashImage(t, dir), ThreadSafe: true})
+	assert.NoError(t, err)
+	assert.True(t, has(recovered, "d"), "new records follow the valid ones")
+	assert.Equal(t, 0, recovered.Recovery().Corrupted)
+	assert.NoError(t, recovered.Close())
+	assert.NoError(t, strict.Close())
+
+	bestEffort, _ := open("best_effort")
+	assert.True(t, has(bestEffort, "a"))
+	assert.False(t, has(bestEffort, "b"))
+	assert.True(t, has(bestEffort, "c"), "only the corrupted record is skipped")
+	assert.Equal(t, 1, bestEffort.Recovery().Corrupted)
+	assert.NoError(t, bestEffort.Close())
+
+	_, err = file.NewFile(file.FileConfig{FilePath: filepath.Join(t.TempDir(), "x.db"), RecoveryMode: "lenient"})
+	assert.Error(t, err)
+}
+
+// TestDatabaseRecoveryInfo는 Database.RecoveryInfo가 저장소를 열 때 재생한 WAL 레코드와 건너뛴 손상
+// 레코드를 보고하고, 복구를 보고하지 않는 저장소에는 false를 돌려주는지 검증합니다.
+func TestDatabaseRecoveryInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recovery.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	for _, k := range []string{"a", "b", "c"} {
+		assert.NoError(t, f.Insert(k, "value-"+k))
+	}
+	assert.NoError(t, f.Sync())
+	image := crashImage(t, path)
+	assert.NoError(t, f.Close())
+	wal, err := os.ReadFile(image + ".wal")
+	assert.NoError(t, err)
+	wal[bytes.Index(wal, []byte("value-b"))] ^= 0xff
+	assert.NoError(t, os.WriteFile(image+".wal", wal, 0644))
+
+	store, err := file.NewFile(file.FileConfig{FilePath: image, ThreadSafe: true, RecoveryMode: "best_effort"})
+	assert.NoError(t, err)
+	meta, err := os.OpenFile(image+".meta", os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	db, err := domain.NewDatabaseWithStorage(domain.DatabaseConfig{Name: "recovery", FilePath: image, ThreadSafe: true}, store, meta, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+	info, ok := db.RecoveryInfo()
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), info.WALRecords)
+	assert.Equal(t, int64(1), info.Corrupted)
+	assert.Equal(t, int64(0), info.Quarantined)
+	assert.Greater(t, info.WALBytes, int64(0))
+	readerInfo, _ := db.Reader().RecoveryInfo()
+	assert.Equal(t, info, readerInfo)
+
+	btreeDB, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	_, ok = btreeDB.RecoveryInfo()
+	assert.False(t, ok, "the B-tree has no WAL to replay")
+}
+
+// TestFileMainChecksum은 메인 파일이 체크섬과 함께 기록되고, 바이트가 바뀌거나 잘리거나 뒤에 바이트가
+// 붙은 메인 파일은 열지 않는지 검증합니다.
+func TestFileMainChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sum.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path})
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, f.Insert(fmt.Sprintf("key%d", i), fmt.Sprintf("value-%d", i)))
+	}
+	assert.NoError(t, f.Close())
+	main, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "GLB4", string(main[:4]))
+
+	for name, damage := range map[string]func([]byte) []byte{
+		"flipped": func(b []byte) []byte {
+			b[bytes.Index(b, []byte("value-5"))] ^= 0xff
+			return b
+		},
+		"truncated": func(b []byte) []byte { return b[:len(b)-2] },
+		"trailing":  func(b []byte) []byte { return append(b, 0) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "sum.db")
+			assert.NoError(t, os.WriteFile(dir, damage(append([]byte(nil), main...)), 0644))
+			_, err := file.NewFile(file.FileConfig{FilePath: dir})
+			assert.ErrorContains(t, err, "corrupted main file")
+			check := file.CheckFiles(dir, nil)
+			assert.NotEmpty(t, check.Problems)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+)
+
+// reopenFile는 현재 디스크에 기록된 내용만으로 새 어댑터를 열어 key를 조회합니다.
+func reopenFile(t *testing.T, path, key string) (interface{}, error) {
+	f, err := file.NewFile(file.FileConfig{FilePath: path + ".copy", ThreadSafe: true})
+	assert.NoError(t, err)
+	defer f.Close()
+	return f.Get(key)
+}
+
+func TestFileMemoryModePersistsOnDemand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mem.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, MemoryMode: true})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	assert.NoError(t, f.Insert("k1", "v1"))
+	val, err := f.Get("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", val)
+	assert.Equal(t, true, f.Stats()["dirty"])
+
+	wal, err := os.Stat(path + ".wal")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), wal.Size(), "memory mode should not write the WAL")
+
+	assert.NoError(t, f.Persist())
+	assert.Equal(t, false, f.Stats()["dirty"])
+	assert.NoError(t, f.Snapshot(path+".copy"))
+	val, err = reopenFile(t, path, "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", val)
+}
+
+func TestFileMemoryModePersistsOnInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mem.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, MemoryMode: true, PersistInterval: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	assert.NoError(t, f.Insert("k1", "v1"))
+	assert.NoError(t, f.Delete("k1"))
+	assert.NoError(t, f.Insert("k2", "v2"))
+	assert.Eventually(t, func() bool { return f.Stats()["dirty"] == false }, time.Second, 5*time.Millisecond)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "k2")
+	assert.NotContains(t, string(data), "k1")
+}
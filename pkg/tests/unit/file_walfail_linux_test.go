@@ -0,0 +1,46 @@
+//go:build linux
+
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func TestFileRejectsWritesAfterWALFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "walfail.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, f.Insert("before", "1"))
+	assert.NoError(t, f.Sync())
+
+	// 파일 크기 제한을 지금의 WAL 크기로 낮추면 다음 WAL 기록이 EFBIG로 실패함 (Go는 SIGXFSZ를 무시)
+	info, err := os.Stat(path + ".wal")
+	assert.NoError(t, err)
+	var limit syscall.Rlimit
+	assert.NoError(t, syscall.Getrlimit(syscall.RLIMIT_FSIZE, &limit))
+	lowered := limit
+	lowered.Cur = uint64(info.Size())
+	assert.NoError(t, syscall.Setrlimit(syscall.RLIMIT_FSIZE, &lowered))
+	assert.NoError(t, f.Insert("lost", "2")) // async: 버퍼에만 들어감
+	syncErr := f.Sync()
+	assert.NoError(t, syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limit))
+	assert.Error(t, syncErr)
+
+	// 제한을 되돌려도 실패한 WAL 뒤에는 기록할 수 없으므로 쓰기는 반영되기 전에 실패해야 함
+	assert.Error(t, f.Insert("after", "3"))
+	_, err = f.Get("after")
+	assert.ErrorIs(t, err, ports.ErrKeyNotFound)
+	assert.Error(t, f.Delete("before"))
+	value, err := f.Get("before")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+}
@@ -0,0 +1,64 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func TestDatabaseFileIsLockedWhileOpen(t *testing.T) {
+	cfg := testDatabaseConfig(filepath.Join(t.TempDir(), "locked.db"))
+	db, err := domain.NewDatabase(cfg, &mockLogger{})
+	assert.NoError(t, err)
+
+	_, err = domain.NewDatabase(cfg, &mockLogger{})
+	assert.ErrorIs(t, err, utils.ErrLocked)
+	assert.ErrorContains(t, err, "database is locked")
+
+	// 닫으면 잠금이 풀려 다시 열 수 있음
+	assert.NoError(t, db.Close())
+	db, err = domain.NewDatabase(cfg, &mockLogger{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	// 다른 바이트 순서로 거부된 파일은 잠긴 채 남지 않음
+	f, err := os.OpenFile(cfg.FilePath, os.O_RDWR, 0644)
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteAt([]byte{1, 2, 3, 4}, 16)
+	assert.NoError(t, err)
+	_, err = btree.OpenBtree(f, btree.BtConfig{Degree: 4, PageSize: 4096})
+	assert.ErrorIs(t, err, btree.ErrByteOrder)
+	assert.NoError(t, utils.LockFile(f))
+	assert.NoError(t, utils.UnlockFile(f))
+}
+
+func TestFileAdapterIsLockedAcrossCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locked.file")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true})
+	assert.NoError(t, err)
+	assert.NoError(t, f.Insert("k", "v"))
+
+	_, err = file.NewFile(file.FileConfig{FilePath: path})
+	assert.ErrorIs(t, err, utils.ErrLocked)
+
+	// 컴팩션이 메인 파일을 바꿔도 잠금은 유지됨
+	assert.NoError(t, f.CompactContext(context.Background()))
+	_, err = file.NewFile(file.FileConfig{FilePath: path})
+	assert.ErrorIs(t, err, utils.ErrLocked)
+
+	assert.NoError(t, f.Close())
+	f, err = file.NewFile(file.FileConfig{FilePath: path})
+	assert.NoError(t, err)
+	val, err := f.Get("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+	assert.NoError(t, f.Close())
+}
@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// TestWriteAtomic은 WriteAtomic이 성공하면 새 내용으로 바꾸고, 쓰기가 실패하면 옛 내용과 디렉터리를
+// 그대로 두는지 검증합니다.
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	assert.NoError(t, utils.WriteFileAtomic(path, []byte("old")))
+
+	failed := errors.New("disk full")
+	err := utils.WriteAtomic(path, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(data))
+	names, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, names, 1, "the temp file is removed")
+
+	assert.NoError(t, utils.WriteAtomic(path, func(w io.Writer) error {
+		_, err := w.Write([]byte("new"))
+		return err
+	}))
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	assert.NoError(t, utils.SyncDir(dir))
+}
+
+// TestLSMSSTableTempFiles는 SSTable이 임시 파일을 거쳐 만들어져 임시 파일이 남지 않고, rename 전에
+// 멈춘 쓰기가 남긴 임시 파일은 다시 열 때 지우는지 검증합니다.
+func TestLSMSSTableTempFiles(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.MemTableSize = 1024
+	config.CompactionInterval = time.Hour
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		assert.NoError(t, lsm.Insert(fmt.Sprintf("key%03d", i), "value"))
+	}
+	assert.NoError(t, lsm.ForceCompaction())
+	temps := func() []string {
+		names, err := filepath.Glob(filepath.Join(config.FilePath, "*.tmp"))
+		assert.NoError(t, err)
+		return names
+	}
+	assert.Empty(t, temps())
+	assert.NoError(t, lsm.Close())
+
+	// 장애로 남은 부분 SSTable
+	partial := filepath.Join(config.FilePath, "99999.l0.sst.tmp")
+	assert.NoError(t, os.WriteFile(partial, []byte("torn"), 0644))
+	lsm, err = lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+	assert.Empty(t, temps())
+	v, err := lsm.Get("key199")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
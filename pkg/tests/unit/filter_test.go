@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, tc := range []struct {
+		expr       string
+		key, value string
+		want       bool
+	}{
+		{`key = "a"`, "a", "", true},
+		{`key != "a"`, "a", "", false},
+		{`key >= "b" and key < "d"`, "c", "", true},
+		{`key >= "b" AND key < "d"`, "d", "", false},
+		{`value CONTAINS "x"`, "k", "axb", true},
+		{`value prefix "ab" OR value suffix "yz"`, "k", "xyz", true},
+		{`NOT (key <= "m" OR value = "")`, "z", "v", true},
+		{`NOT (key <= "m" OR value = "")`, "z", "", false},
+		{`value = "say \"hi\""`, "k", `say "hi"`, true},
+	} {
+		f, err := application.ParseFilter(tc.expr)
+		if assert.NoError(t, err, tc.expr) {
+			assert.Equal(t, tc.want, f(tc.key, tc.value), "%s on %q=%q", tc.expr, tc.key, tc.value)
+		}
+	}
+
+	for _, bad := range []string{``, `key`, `key = `, `key = a`, `name = "a"`, `"key" = "a"`, `key ~ "a"`,
+		`key = "a" AND`, `(key = "a"`, `key = "a")`, `key = "unterminated`, `key =! "a"`} {
+		_, err := application.ParseFilter(bad)
+		assert.ErrorIs(t, err, application.ErrInvalidFilter, bad)
+	}
+}
+
+func TestScanQueriesFilter(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	for i := 0; i < 10; i++ {
+		role := "user"
+		if i%3 == 0 {
+			role = "admin"
+		}
+		assert.NoError(t, db.Insert("users", fmt.Sprintf("k%d", i), role))
+	}
+	handler := application.NewQueryHandler(db, &mockLogger{})
+	ctx := context.Background()
+	keys := func(entries []application.KeyValue) []string {
+		var keys []string
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+		return keys
+	}
+
+	result, err := handler.ExecuteQuery(ctx, &application.ScanQuery{TableName: "users", Filter: `value = "admin"`, Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0", "k3", "k6"}, keys(result.([]application.KeyValue)), "the limit counts matches")
+
+	result, err = handler.ExecuteQuery(ctx, &application.ScanQuery{
+		TableName: "users",
+		Filter:    `value = "admin"`,
+		Match:     func(key, _ string) bool { return key != "k3" },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0", "k6", "k9"}, keys(result.([]application.KeyValue)), "both filters apply")
+
+	_, err = handler.ExecuteQuery(ctx, &application.ScanQuery{TableName: "users", Filter: `value ==`})
+	assert.ErrorIs(t, err, application.ErrInvalidFilter)
+
+	// 페이지는 맞는 항목만 세며, 토큰은 같은 필터에서만 쓸 수 있음
+	var paged []string
+	token := ""
+	for pages := 0; pages < 5; pages++ {
+		result, err := handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Filter: `value = "user"`, Limit: 4, Token: token})
+		assert.NoError(t, err)
+		page := result.(*application.ScanPage)
+		paged = append(paged, keys(page.Entries)...)
+		if token = page.NextToken; token == "" {
+			break
+		}
+		_, err = handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Filter: `value = "admin"`, Token: token})
+		assert.ErrorIs(t, err, application.ErrInvalidPageToken)
+	}
+	assert.Equal(t, []string{"k1", "k2", "k4", "k5", "k7", "k8"}, paged)
+}
@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/server"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func TestHealthCheckRoundTrip(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.Nil(t, db.GetStatus().Health)
+
+	for i := 0; i < 3; i++ {
+		report, err := db.HealthCheck(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, report.Healthy)
+		assert.Len(t, report.Checks, 3)
+	}
+	health := db.GetStatus().Health
+	if assert.NotNil(t, health) {
+		assert.True(t, health.Healthy)
+		assert.Equal(t, "round_trip", health.Checks[1].Name)
+	}
+	// 점검은 같은 키를 덮어쓰므로 데이터가 늘지 않음
+	count, err := db.Count(domain.HealthTable)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestHealthCheckFailsOnClosedDatabase(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.Close())
+
+	report, err := db.HealthCheck(context.Background())
+	assert.ErrorIs(t, err, domain.ErrUnhealthy)
+	assert.False(t, report.Healthy)
+	assert.Equal(t, []domain.HealthCheckResult{{Name: "ready", Error: "database is closed", Duration: report.Checks[0].Duration}}, report.Checks)
+}
+
+func TestFileAdapterHealthChecksWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.file")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, f.CheckHealth())
+
+	wal, err := os.OpenFile(path+".wal", os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = wal.WriteAt([]byte("XXXX"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, wal.Close())
+	assert.ErrorContains(t, f.CheckHealth(), "wal starts with")
+}
+
+func TestHealthHTTPProbes(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	srv := httptest.NewServer(server.NewHealthHandler(application.NewQueryHandler(db, &utils.SilentLogger{}), 0))
+	defer srv.Close()
+
+	get := func(path string) (int, map[string]interface{}) {
+		res, err := http.Get(srv.URL + path)
+		if !assert.NoError(t, err) {
+			return 0, nil
+		}
+		defer res.Body.Close()
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body
+	}
+
+	code, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+	assert.Len(t, body["checks"], 3)
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+
+	res, err := http.Post(srv.URL+"/healthz", "text/plain", nil)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	assert.NoError(t, db.Close())
+	code, body = get("/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", body["status"])
+	code, _ = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
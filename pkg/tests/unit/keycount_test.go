@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/events"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func TestKeyCountsTrackInsertsAndDeletes(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+
+	assert.NoError(t, db.Insert("users", "alice", "1"))
+	assert.NoError(t, db.Insert("users", "anna", "2"))
+	assert.NoError(t, db.Insert("users", "bob", "3"))
+	assert.NoError(t, db.Insert("users", "bob", "4"), "overwrite should not change the count")
+	assert.NoError(t, db.Delete("users", "anna"))
+
+	stats, err := db.KeyStats("users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Count)
+	assert.Equal(t, map[byte]int64{'a': 1, 'b': 1}, stats.ByFirstByte)
+	assert.True(t, stats.Exact)
+
+	_, err = db.Count("missing")
+	assert.Error(t, err)
+
+	sub := db.Subscribe(events.TableDropped)
+	defer sub.Close()
+	assert.NoError(t, db.DropTable("users"))
+	ev := nextEvent(t, sub)
+	assert.Equal(t, int64(2), ev.Details["keys"])
+}
+
+func TestKeyCountsPersistAcrossCleanClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counts.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	assert.NoError(t, db.Insert("users", "user2", "Bob"))
+
+	// 닫지 않은 상태의 파일을 열면 카운터는 부정확(Exact=false)으로 보고되어야 함.
+	// 열려 있는 파일은 잠겨 있으므로 그 시점의 사본을 엶.
+	_, err = openBtreeDatabase(path)
+	assert.ErrorIs(t, err, utils.ErrLocked)
+	image := filepath.Join(t.TempDir(), "image.db")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(image, data, 0644))
+	dirty, err := openBtreeDatabase(image)
+	assert.NoError(t, err)
+	stats, err := dirty.KeyStats("users")
+	assert.NoError(t, err)
+	assert.False(t, stats.Exact)
+	dirty.Close()
+
+	assert.NoError(t, db.Close())
+	reopened, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	stats, err = reopened.KeyStats("users")
+	assert.NoError(t, err)
+	assert.True(t, stats.Exact)
+	assert.Equal(t, int64(2), stats.Count)
+	assert.Equal(t, map[byte]int64{'u': 2}, stats.ByFirstByte)
+}
+
+// failingGetStorage는 failKey를 읽을 때만 err를 돌려주는 저장소입니다.
+type failingGetStorage struct {
+	*file.File
+	failKey string
+	err     error
+}
+
+func (s *failingGetStorage) Get(key string) (interface{}, error) {
+	if key == s.failKey {
+		return nil, s.err
+	}
+	return s.File.Get(key)
+}
+
+func TestInsertFailsWhenExistingKeyCannotBeRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lookup.db")
+	store, err := file.NewFile(file.FileConfig{FilePath: path, ThreadSafe: true, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	storage := &failingGetStorage{File: store}
+	meta, err := os.OpenFile(path+".meta", os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	db, err := domain.NewDatabaseWithStorage(testDatabaseConfig(path), storage, meta, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+
+	// 읽기 실패를 없는 키로 보면 덮어쓰기가 새 키로 세어짐
+	storage.failKey, storage.err = "users:user1", errors.New("read failed")
+	assert.ErrorIs(t, db.Insert("users", "user1", "Bob"), storage.err)
+	storage.failKey = ""
+	stats, err := db.KeyStats("users")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Count)
+	value, err := db.Get("users", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", value)
+}
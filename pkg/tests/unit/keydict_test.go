@@ -0,0 +1,106 @@
+package unit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/keydict"
+)
+
+func TestKeyDictPromotesHotPrefixes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.dict")
+	dict, err := keydict.Open(path, keydict.Config{Threshold: 3})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		dict.Observe(fmt.Sprintf("billing:prod:tenant-%d", i))
+	}
+	dict.Observe("x:once")
+	assert.Equal(t, []string{"billing:", "billing:prod:"}, dict.Prefixes())
+
+	stored, ok := dict.Encode("billing:prod:tenant-9")
+	assert.True(t, ok)
+	assert.Equal(t, "\x02tenant-9", stored) // 가장 긴 prefix가 선택됨
+	key, err := dict.Decode(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, "billing:prod:tenant-9", key)
+
+	_, ok = dict.Encode("other:key")
+	assert.False(t, ok)
+
+	reopened, err := keydict.Open(path, keydict.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, dict.Prefixes(), reopened.Prefixes())
+	_, err = reopened.Decode("\x09abc")
+	assert.ErrorIs(t, err, keydict.ErrUnknownID)
+}
+
+func TestBtreeStoresKeysWithDictionary(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.db")
+	dictPath := filepath.Join(dir, "main.keydict")
+	dict, err := keydict.Open(dictPath, keydict.Config{Threshold: 8})
+	assert.NoError(t, err)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	bt := btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, KeyDict: dict})
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("service:staging:tenant:%04d", i), "v"))
+	}
+	f.Close()
+
+	raw, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Less(t, bytes.Count(raw, []byte("service:staging:tenant:")), 50)
+
+	dict, err = keydict.Open(dictPath, keydict.Config{})
+	assert.NoError(t, err)
+	f, err = os.OpenFile(path, os.O_RDWR, 0666)
+	assert.NoError(t, err)
+	defer f.Close()
+	bt = btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, KeyDict: dict})
+	for i := 0; i < 50; i++ {
+		val, err := bt.Get(fmt.Sprintf("service:staging:tenant:%04d", i))
+		assert.NoError(t, err)
+		assert.Equal(t, "v", val)
+	}
+
+	// 사전 없이 열면 인코딩된 키를 해석할 수 없음.
+	bt = btree.NewBtree(f, btree.BtConfig{Degree: 4, PageSize: 512})
+	_, err = bt.Get("service:staging:tenant:0001")
+	assert.Error(t, err)
+}
+
+func TestSSTableStoresKeysWithDictionary(t *testing.T) {
+	dir := t.TempDir()
+	dict := keydict.New(keydict.Config{})
+	_, err := dict.Add("service:prod:")
+	assert.NoError(t, err)
+
+	data := map[string]string{"service:prod:a": "1", "service:prod:b": "2", "plain": "3"}
+	path := filepath.Join(dir, "t.sst")
+	_, err = lsmtree.CreateSSTableWithDict(path, data, "none", false, dict)
+	assert.NoError(t, err)
+
+	raw, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "service:prod:")
+
+	sst, err := lsmtree.OpenSSTableWithDict(path, false, dict)
+	assert.NoError(t, err)
+	for k, v := range data {
+		got, ok := sst.Get(k)
+		assert.True(t, ok, k)
+		assert.Equal(t, v, got)
+	}
+
+	_, err = lsmtree.OpenSSTable(path, false)
+	assert.Error(t, err)
+}
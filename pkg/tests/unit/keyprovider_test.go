@@ -0,0 +1,106 @@
+package unit
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+func TestEnvKeyProvider(t *testing.T) {
+	t.Setenv("GOLITE_TEST_KEY", hex.EncodeToString(storageKey1))
+	current, previous, err := pagecrypt.EnvKey("GOLITE_TEST_KEY").Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, storageKey1, current)
+	assert.Nil(t, previous)
+
+	t.Setenv("GOLITE_TEST_KEY_PREVIOUS", hex.EncodeToString(storageKey2))
+	_, previous, err = pagecrypt.EnvKey("GOLITE_TEST_KEY").Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, storageKey2, previous)
+
+	_, _, err = pagecrypt.EnvKey("GOLITE_TEST_KEY_UNSET").Keys(context.Background())
+	assert.Error(t, err)
+	t.Setenv("GOLITE_TEST_KEY", "not hex")
+	_, _, err = pagecrypt.EnvKey("GOLITE_TEST_KEY").Keys(context.Background())
+	assert.Error(t, err)
+}
+
+// rotatingKeys는 테스트가 바꿀 수 있는 키를 내주는 KMS 역할을 합니다.
+type rotatingKeys struct {
+	mu  sync.Mutex
+	key []byte
+}
+
+func (r *rotatingKeys) provider() ports.KeyProvider {
+	return pagecrypt.KeyFunc(func(ctx context.Context) ([]byte, []byte, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.key, nil, nil
+	})
+}
+
+func (r *rotatingKeys) set(key []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.key = key
+}
+
+// keyedBy는 저장소 키를 keys에서 받도록 설정합니다.
+func keyedBy(keys ports.KeyProvider) func(*domain.DatabaseConfig) {
+	return func(c *domain.DatabaseConfig) { c.KeyProvider = keys }
+}
+
+func TestDatabaseKeyProviderRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyed.db")
+	kms := &rotatingKeys{key: storageKey1}
+	rekey := domain.MaintenanceTask{Kind: domain.MaintenanceRekey, Every: time.Hour}
+	db, err := openBtreeDatabase(path, keyedBy(kms.provider()), func(c *domain.DatabaseConfig) {
+		c.Maintenance = []domain.MaintenanceTask{rekey}
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("t"))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, db.Insert("t", fmt.Sprintf("k%02d", i), "secret-value"))
+	}
+	// 공급자가 같은 키를 내주는 동안은 아무것도 하지 않음
+	assert.NoError(t, db.RunMaintenance(context.Background(), "rekey"))
+
+	kms.set(storageKey2)
+	assert.NoError(t, db.RunMaintenance(context.Background(), "rekey"))
+	status := db.GetStatus().Maintenance
+	assert.Equal(t, int64(2), status[0].Runs)
+	assert.Empty(t, status[0].LastError)
+	assert.NoError(t, db.Close())
+	assert.False(t, fileContains(t, path, "secret-value"))
+
+	_, err = openBtreeDatabase(path, keyedBy(pagecrypt.StaticKey(storageKey1)))
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "%v", err)
+	db, err = openBtreeDatabase(path, keyedBy(pagecrypt.StaticKey(storageKey2)))
+	assert.NoError(t, err)
+	defer db.Close()
+	value, err := db.Get("t", "k07")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-value", value)
+}
+
+func TestRotateStorageKeyUnencrypted(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "plain.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.Error(t, db.RotateStorageKey(context.Background()), "no key provider")
+
+	_, err = openBtreeDatabase(filepath.Join(t.TempDir(), "bad.db"), keyedBy(pagecrypt.KeyFunc(func(ctx context.Context) ([]byte, []byte, error) {
+		return nil, nil, errors.New("kms unavailable")
+	})))
+	assert.ErrorContains(t, err, "kms unavailable")
+}
@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/keys"
+)
+
+func TestKeysPreserveNumericOrder(t *testing.T) {
+	ints := []int64{math.MinInt64, -1000, -1, 0, 1, 9, 10, 1000, math.MaxInt64}
+	floats := []float64{math.Inf(-1), -1e9, -2.5, -math.SmallestNonzeroFloat64, 0, 1e-9, 2.5, 10, math.Inf(1)}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{time.Unix(0, 0).Add(-time.Hour), base, base.Add(time.Nanosecond), base.Add(24 * time.Hour)}
+
+	var encoded []string
+	for _, v := range ints {
+		encoded = append(encoded, keys.Int64(v))
+		got, err := keys.DecodeInt64(keys.Int64(v))
+		assert.NoError(t, err)
+		assert.Equal(t, v, got)
+	}
+	assert.True(t, sort.StringsAreSorted(encoded), "ints")
+
+	encoded = encoded[:0]
+	for _, v := range floats {
+		encoded = append(encoded, keys.Float64(v))
+		got, err := keys.DecodeFloat64(keys.Float64(v))
+		assert.NoError(t, err)
+		assert.Equal(t, v, got)
+	}
+	assert.True(t, sort.StringsAreSorted(encoded), "floats")
+
+	encoded = encoded[:0]
+	for _, v := range times {
+		encoded = append(encoded, keys.Time(v))
+		got, err := keys.DecodeTime(keys.Time(v))
+		assert.NoError(t, err)
+		assert.True(t, v.Equal(got))
+	}
+	assert.True(t, sort.StringsAreSorted(encoded), "times")
+
+	_, err := keys.DecodeUint64("short")
+	assert.ErrorIs(t, err, keys.ErrInvalidKey)
+}
+
+func TestKeysTupleOrderAndPrefix(t *testing.T) {
+	tuples := [][]interface{}{
+		{"a", int64(-5)},
+		{"a", int64(2)},
+		{"a", int64(10)},
+		{"a\x00", int64(0)},
+		{"a\x00b", int64(0)},
+		{"ab", int64(0)},
+		{"b"},
+	}
+	var encoded []string
+	for _, tuple := range tuples {
+		s, err := keys.Tuple(tuple...)
+		assert.NoError(t, err)
+		encoded = append(encoded, s)
+		decoded, err := keys.DecodeTuple(s)
+		assert.NoError(t, err)
+		assert.Equal(t, tuple, decoded)
+	}
+	assert.True(t, sort.StringsAreSorted(encoded))
+
+	// 앞쪽 원소의 인코딩은 그 원소로 시작하는 튜플의 접두어이고, 다른 문자열로 시작하는 튜플과는 겹치지 않음
+	prefix, err := keys.Tuple("a")
+	assert.NoError(t, err)
+	matched := 0
+	for _, s := range encoded {
+		if len(s) >= len(prefix) && s[:len(prefix)] == prefix {
+			matched++
+		}
+	}
+	assert.Equal(t, 3, matched)
+
+	_, err = keys.Tuple(struct{}{})
+	assert.Error(t, err)
+	_, err = keys.DecodeTuple("\x01abc")
+	assert.ErrorIs(t, err, keys.ErrInvalidKey)
+}
+
+func TestKeysRangeScanInDatabase(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("events"))
+	for _, n := range []int64{100, -3, 7, 20, 0} {
+		k, err := keys.Tuple("sensor-1", n)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Insert("events", k, "x"))
+	}
+	other, _ := keys.Tuple("sensor-10", int64(1))
+	assert.NoError(t, db.Insert("events", other, "y"))
+
+	prefix, _ := keys.Tuple("sensor-1")
+	var got []int64
+	assert.NoError(t, db.Scan("events", prefix, func(key, _ string) bool {
+		elems, err := keys.DecodeTuple(key)
+		assert.NoError(t, err)
+		got = append(got, elems[1].(int64))
+		return true
+	}))
+	assert.Equal(t, []int64{-3, 0, 7, 20, 100}, got)
+}
@@ -8,9 +8,9 @@ import (
 
 func TestLockFreeSSTableIndex(t *testing.T) {
 	entries := []lockfree.SSTableIndexEntry{
-		{"a", 100},
-		{"b", 200},
-		{"c", 300},
+		{Key: "a", Offset: 100},
+		{Key: "b", Offset: 200},
+		{Key: "c", Offset: 300},
 	}
 	index := lockfree.NewLockFreeSSTableIndex(entries)
 
@@ -29,8 +29,8 @@ func TestLockFreeSSTableIndex(t *testing.T) {
 
 	// Update 테스트
 	newEntries := []lockfree.SSTableIndexEntry{
-		{"x", 1000},
-		{"y", 2000},
+		{Key: "x", Offset: 1000},
+		{Key: "y", Offset: 2000},
 	}
 	index.Update(newEntries)
 	if index.Length() != 2 {
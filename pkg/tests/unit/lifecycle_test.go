@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/lifecycle"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+func TestLifecycleShutsDownInReverseOrder(t *testing.T) {
+	lc := lifecycle.New(&mockLogger{})
+	var order []string
+	for _, name := range []string{"database", "replication", "handlers"} {
+		lc.Add(name, func(context.Context) error {
+			order = append(order, name)
+			if name == "replication" {
+				return errors.New("listener already closed")
+			}
+			return nil
+		})
+	}
+
+	err := lc.Shutdown(context.Background())
+	assert.ErrorContains(t, err, "replication: listener already closed")
+	assert.Equal(t, []string{"handlers", "replication", "database"}, order, "a failure should not stop later components")
+	assert.NoError(t, lc.Shutdown(context.Background()))
+	assert.Len(t, order, 3)
+}
+
+func TestLifecycleShutdownTimeoutSkipsRemainingComponents(t *testing.T) {
+	lc := lifecycle.New(&utils.SilentLogger{})
+	closed := false
+	release := make(chan struct{})
+	defer close(release)
+	lc.Add("database", func(context.Context) error { closed = true; return nil })
+	lc.Add("handlers", func(context.Context) error { <-release; return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := lc.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "shutdown of handlers did not finish")
+	assert.False(t, closed, "components the stuck one depends on must stay open")
+}
+
+func TestLifecycleDrainsHandlersBeforeClosingDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "golite.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: dbPath, ThreadSafe: true, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	handle, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	logger := &utils.SilentLogger{}
+	db, err := domain.NewDatabaseWithStorage(domain.DatabaseConfig{Name: "testdb", FilePath: dbPath, ThreadSafe: true}, f, handle, logger)
+	assert.NoError(t, err)
+	handler := application.NewCommandHandler(db, logger)
+
+	lc := lifecycle.New(logger)
+	lc.AddCloser("database", db)
+	lc.Add("handlers", func(context.Context) error { handler.Wait(); return nil })
+
+	assert.NoError(t, db.CreateTable("users"))
+	for _, key := range []string{"a", "b", "c"} {
+		handler.ExecuteCommandAsync(context.Background(), &application.InsertCommand{TableName: "users", Key: key, Value: key})
+	}
+	assert.NoError(t, lc.Shutdown(context.Background()))
+
+	reopened, err := file.NewFile(file.FileConfig{FilePath: dbPath, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	defer reopened.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		value, err := reopened.Get("users:" + key)
+		assert.NoError(t, err)
+		assert.Equal(t, key, value)
+	}
+}
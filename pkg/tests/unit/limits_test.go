@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/events"
+)
+
+// limitWarnings drains the LimitWarning events published so far.
+func limitWarnings(sub *events.Subscription) []events.Event {
+	var got []events.Event
+	for {
+		select {
+		case e := <-sub.C:
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+func TestSoftLimitTablesWarnOnceAndRearm(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t) // MaxTables 10 → 8개에서 경고
+	defer cleanup()
+	sub := db.Subscribe(events.LimitWarning)
+	defer sub.Close()
+
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, db.CreateTable(fmt.Sprintf("t%d", i)))
+	}
+	assert.Empty(t, limitWarnings(sub))
+
+	assert.NoError(t, db.CreateTable("t7"))
+	assert.NoError(t, db.CreateTable("t8"))
+	got := limitWarnings(sub)
+	if assert.Len(t, got, 1, "crossing the threshold should warn exactly once") {
+		assert.Equal(t, domain.LimitTables, got[0].Details["limit"])
+		assert.Equal(t, 8, got[0].Details["used"])
+		assert.Equal(t, 10, got[0].Details["max"])
+	}
+
+	// 임계값 아래로 내려가면 재무장되어 다시 넘을 때 경고.
+	assert.NoError(t, db.DropTable("t8"))
+	assert.NoError(t, db.DropTable("t7"))
+	assert.NoError(t, db.CreateTable("t7"))
+	assert.Len(t, limitWarnings(sub), 1)
+	assert.Equal(t, int64(2), db.Stats()["soft_limit_warnings"])
+}
+
+func TestSoftLimitEntrySize(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t) // Degree 2, PageSize 4096 → 가득 찬 노드 기준 엔트리 1348바이트
+	defer cleanup()
+	sub := db.Subscribe(events.LimitWarning)
+	defer sub.Close()
+	assert.NoError(t, db.CreateTable("blobs"))
+
+	assert.NoError(t, db.Insert("blobs", "small", "v"))
+	assert.Empty(t, limitWarnings(sub))
+
+	big := strings.Repeat("x", 1200)
+	assert.NoError(t, db.Insert("blobs", "big", big), "the entry is still below the hard limit")
+	assert.NoError(t, db.Insert("blobs", "big2", big))
+	got := limitWarnings(sub)
+	if assert.Len(t, got, 1, "entry warnings are sent once per table") {
+		assert.Equal(t, domain.LimitEntrySize, got[0].Details["limit"])
+		assert.Equal(t, "blobs", got[0].Table)
+	}
+}
+
+func TestSoftLimitDisabled(t *testing.T) {
+	logger := &mockLogger{}
+	config := domain.DatabaseConfig{
+		Name:       "limits",
+		FilePath:   t.TempDir() + "/limits.db",
+		MaxTables:  2,
+		ThreadSafe: true,
+		SoftLimits: domain.SoftLimits{Tables: -1},
+	}
+	config.BtConfig.PageSize = 4096
+	db, err := domain.NewDatabase(config, logger)
+	assert.NoError(t, err)
+	defer db.Close()
+	sub := db.Subscribe(events.LimitWarning)
+	defer sub.Close()
+
+	assert.NoError(t, db.CreateTable("a"))
+	assert.NoError(t, db.CreateTable("b"))
+	assert.Empty(t, limitWarnings(sub))
+	assert.Error(t, db.CreateTable("c"), "the hard limit still applies")
+}
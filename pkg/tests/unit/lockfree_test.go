@@ -323,3 +323,76 @@ func TestStressWithContention(t *testing.T) {
 		t.Error("Queue should be empty after stress test")
 	}
 }
+
+// TestFIFOPerProducerUnderContention checks that, with many producers and one consumer,
+// every item arrives exactly once and each producer's items keep their order. It uses only
+// portable code, so it can run as-is under -race and with GOARCH=386 or arm64.
+func TestFIFOPerProducerUnderContention(t *testing.T) {
+	q := lockfree.NewLFQueue[[2]int]()
+	producers := 8
+	perProducer := 20000
+	if testing.Short() {
+		perProducer = 2000
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for p := 0; p < producers; p++ {
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.Enqueue([2]int{id, i})
+			}
+		}(p)
+	}
+
+	next := make([]int, producers)
+	total := producers * perProducer
+	for received := 0; received < total; {
+		item, ok := q.Dequeue()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+		id, seq := item[0], item[1]
+		if seq != next[id] {
+			t.Fatalf("producer %d: expected item %d, got %d", id, next[id], seq)
+		}
+		next[id]++
+		received++
+	}
+	wg.Wait()
+
+	if _, ok := q.Dequeue(); ok {
+		t.Error("Queue should be empty after all items were consumed")
+	}
+	if q.Length() != 0 {
+		t.Errorf("Expected length 0, got %d", q.Length())
+	}
+}
+
+// TestPeekDuringConcurrentDequeue checks that Peek never observes a value that was not enqueued.
+func TestPeekDuringConcurrentDequeue(t *testing.T) {
+	q := lockfree.NewLFQueue[int]()
+	for i := 1; i <= 10000; i++ {
+		q.Enqueue(i)
+	}
+	var wg sync.WaitGroup
+	wg.Add(4)
+	for g := 0; g < 4; g++ {
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := q.Dequeue(); !ok {
+					return
+				}
+			}
+		}()
+	}
+	for !q.IsEmpty() {
+		if v, ok := q.Peek(); ok && (v < 1 || v > 10000) {
+			t.Fatalf("Peek returned unexpected value %d", v)
+		}
+	}
+	wg.Wait()
+}
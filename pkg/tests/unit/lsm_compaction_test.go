@@ -0,0 +1,294 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+)
+
+// TestLSMParallelCompaction은 여러 워커가 병합하는 동안에도 쓰기와 읽기가 계속되고, 컴팩션 뒤와
+// 재시작 뒤에 모든 키가 최신 값을 돌려주는지 검증합니다.
+func TestLSMParallelCompaction(t *testing.T) {
+	for _, strategy := range []string{"leveling", "sizing"} {
+		t.Run(strategy, func(t *testing.T) {
+			config := lsmtree.DefaultConfig()
+			config.FilePath = t.TempDir()
+			config.MemTableSize = 1024 // 쓰는 동안 여러 번 flush되도록 작게 설정
+			config.SSTableSize = 512
+			config.CompressionType = "none"
+			config.CompactionInterval = time.Hour
+			config.CompactionStrategy = strategy
+			config.Level0CompactionTrigger = 2
+			config.LevelSizeMultiplier = 2
+			config.CompactionWorkers = 4
+			lsm, err := lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+
+			const keys, rounds = 200, 5
+			done := make(chan struct{})
+			compacted := make(chan error, 1)
+			go func() {
+				for {
+					select {
+					case <-done:
+						compacted <- nil
+						return
+					default:
+					}
+					if err := lsm.ForceCompaction(); err != nil {
+						compacted <- err
+						return
+					}
+				}
+			}()
+			for r := 0; r < rounds; r++ {
+				for i := 0; i < keys; i++ {
+					key := fmt.Sprintf("key%03d", i)
+					assert.NoError(t, lsm.Insert(key, fmt.Sprintf("v%d", r)))
+					v, err := lsm.Get(key)
+					assert.NoError(t, err)
+					assert.Equal(t, fmt.Sprintf("v%d", r), v, key)
+				}
+			}
+			close(done)
+			assert.NoError(t, <-compacted)
+			assert.NoError(t, lsm.ForceCompaction())
+
+			check := func(lsm *lsmtree.LSMTree) {
+				for i := 0; i < keys; i++ {
+					key := fmt.Sprintf("key%03d", i)
+					v, err := lsm.Get(key)
+					assert.NoError(t, err)
+					assert.Equal(t, fmt.Sprintf("v%d", rounds-1), v, key)
+				}
+			}
+			check(lsm)
+			levels := lsm.Stats()["levels"].([]int)
+			assert.NoError(t, lsm.Close())
+
+			lsm, err = lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+			defer lsm.Close()
+			assert.Equal(t, levels, lsm.Stats()["levels"].([]int))
+			check(lsm)
+		})
+	}
+}
+
+// TestLSMCompactionWorkersValidation은 음수 워커 수를 거절하는지 검증합니다.
+func TestLSMCompactionWorkersValidation(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.CompactionWorkers = -1
+	_, err := lsmtree.NewLSMTree(config)
+	assert.Error(t, err)
+}
+
+// TestLSMCompactionRateLimit은 컴팩션이 설정한 속도 아래로 쓰고, 기다리는 동안 취소되면 결과 없이
+// 입력을 그대로 두며, 그동안 CompactionDebt가 밀린 바이트를 보고하는지 검증합니다.
+func TestLSMCompactionRateLimit(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.MemTableSize = 16 * 1024
+	config.CompressionType = "none"
+	config.CompactionInterval = time.Hour
+	config.CompactionStrategy = "sizing"
+	config.Level0CompactionTrigger = 100
+	config.CompactionBytesPerSecond = 256 * 1024
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+
+	value := strings.Repeat("v", 100)
+	for i := 0; i < 4000; i++ { // 약 450KB, 여러 테이블로 flush
+		assert.NoError(t, lsm.Insert(fmt.Sprintf("key%05d", i), value))
+	}
+	assert.Equal(t, int64(0), lsm.CompactionDebt(), "below the trigger nothing is due")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, lsm.CompactContext(ctx), context.DeadlineExceeded)
+	outputs, err := filepath.Glob(filepath.Join(config.FilePath, "*.l0.sst"))
+	assert.NoError(t, err)
+	assert.Empty(t, outputs, "a cancelled merge leaves no outputs behind")
+	assert.Greater(t, lsm.Stats()["levels"].([]int)[0], 1, "and keeps its inputs")
+
+	start := time.Now()
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "450KB at 256KB/s")
+	stats := lsm.Stats()
+	assert.Equal(t, []int{1}, stats["levels"])
+	assert.Equal(t, int64(256*1024), stats["compaction_rate_limit"])
+	assert.Greater(t, stats["compaction_throttled"].(time.Duration), time.Duration(0))
+	v, err := lsm.Get("key03999")
+	assert.NoError(t, err)
+	assert.Equal(t, value, v)
+
+	lsm.SetCompactionRateLimit(0)
+	for i := 0; i < 4000; i++ {
+		assert.NoError(t, lsm.Insert(fmt.Sprintf("key%05d", i), value+"2"))
+	}
+	start = time.Now()
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.Less(t, time.Since(start), time.Second, "the limit was removed")
+}
+
+// TestLSMCompactionDebt은 level0이 트리거에 이르면 CompactionDebt가 밀린 바이트를 보고하고, 컴팩션
+// 뒤에는 0으로 돌아오는지 검증합니다.
+func TestLSMCompactionDebt(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.MemTableSize = 1024
+	config.CompactionInterval = time.Hour
+	config.Level0CompactionTrigger = 2
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+
+	for i := 0; i < 400; i++ { // 여러 테이블로 flush
+		assert.NoError(t, lsm.Insert(fmt.Sprintf("key%03d", i), "value"))
+	}
+	assert.Greater(t, lsm.CompactionDebt(), int64(0))
+	assert.Equal(t, lsm.CompactionDebt(), lsm.Stats()["compaction_debt"])
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.Equal(t, int64(0), lsm.CompactionDebt())
+}
+
+// TestLSMCompactRange는 범위 컴팩션이 범위와 겹치는 테이블만 다시 써서 지운 키의 공간을 되찾고,
+// 범위 밖의 테이블은 그대로 두는지 검증합니다.
+func TestLSMCompactRange(t *testing.T) {
+	for _, strategy := range []string{"leveling", "sizing"} {
+		t.Run(strategy, func(t *testing.T) {
+			config := lsmtree.DefaultConfig()
+			config.FilePath = t.TempDir()
+			config.MemTableSize = 8 * 1024
+			config.SSTableSize = 4 * 1024
+			config.CompressionType = "none"
+			config.CompactionInterval = time.Hour
+			config.CompactionStrategy = strategy
+			config.Level0CompactionTrigger = 100
+			lsm, err := lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+			defer lsm.Close()
+
+			value := strings.Repeat("v", 50)
+			for _, prefix := range []string{"a", "b"} { // 삽입 중에 flush되어 a만 든 테이블이 생김
+				for i := 0; i < 200; i++ {
+					assert.NoError(t, lsm.Insert(fmt.Sprintf("%s%03d", prefix, i), value))
+				}
+				if strategy == "leveling" {
+					assert.NoError(t, lsm.ForceCompaction())
+				}
+			}
+			for i := 0; i < 200; i++ {
+				assert.NoError(t, lsm.Delete(fmt.Sprintf("b%03d", i)))
+			}
+			files := func() map[string]bool {
+				names, err := filepath.Glob(filepath.Join(config.FilePath, "*.sst"))
+				assert.NoError(t, err)
+				set := make(map[string]bool)
+				for _, name := range names {
+					set[filepath.Base(name)] = true
+				}
+				return set
+			}
+			before, size := files(), lsm.DiskSize()
+
+			assert.NoError(t, lsm.CompactRange("b", "c"))
+			after := files()
+			kept := 0
+			for name := range before {
+				if after[name] {
+					kept++
+				}
+			}
+			assert.Greater(t, kept, 0, "tables outside the range are not rewritten")
+			assert.Less(t, kept, len(before))
+			assert.Less(t, lsm.DiskSize(), size/2+size/4, "deleted keys no longer take space")
+			for i := 0; i < 200; i++ {
+				v, err := lsm.Get(fmt.Sprintf("a%03d", i))
+				assert.NoError(t, err)
+				assert.Equal(t, value, v)
+				_, err = lsm.Get(fmt.Sprintf("b%03d", i))
+				assert.ErrorIs(t, err, lsmtree.ErrKeyNotFound)
+			}
+
+			assert.NoError(t, lsm.CompactRange("c", "b"), "an empty range compacts nothing")
+			assert.Equal(t, after, files())
+		})
+	}
+}
+
+// TestLSMWriteStall은 level0이 slowdown 수에 이르면 쓰기를 늦추며 컴팩터를 깨워 level0을 stop 수 아래로
+// 유지하고, 막힌 쓰기가 Close에 ErrDBClosed로 끝나는지 검증합니다.
+func TestLSMWriteStall(t *testing.T) {
+	newTree := func(t *testing.T, bytesPerSecond int64) *lsmtree.LSMTree {
+		config := lsmtree.DefaultConfig()
+		config.FilePath = t.TempDir()
+		config.MemTableSize = 1024
+		config.CompactionInterval = time.Hour // 깨우지 않으면 컴팩션하지 않음
+		config.Level0CompactionTrigger = 100
+		config.Level0SlowdownTrigger = 2
+		config.Level0StopTrigger = 3
+		config.CompactionBytesPerSecond = bytesPerSecond
+		lsm, err := lsmtree.NewLSMTree(config)
+		assert.NoError(t, err)
+		return lsm
+	}
+
+	t.Run("slowdown", func(t *testing.T) {
+		lsm := newTree(t, 0)
+		defer lsm.Close()
+		for i := 0; i < 2000; i++ {
+			assert.NoError(t, lsm.Insert(fmt.Sprintf("key%04d", i), "value"))
+			assert.LessOrEqual(t, lsm.Stats()["levels"].([]int)[0], 3)
+		}
+		stalls := lsm.Stats()["write_stalls"].(map[string]interface{})
+		assert.Greater(t, stalls["slowdowns"], int64(0))
+		assert.Greater(t, stalls["stalled"], time.Duration(0))
+		v, err := lsm.Get("key0000")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", v)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		lsm := newTree(t, 1) // 컴팩션이 사실상 끝나지 않음
+		stalled := make(chan error, 1)
+		go func() {
+			for i := 0; ; i++ {
+				if err := lsm.Insert(fmt.Sprintf("key%04d", i), "value"); err != nil {
+					stalled <- err
+					return
+				}
+			}
+		}()
+		assert.Eventually(t, func() bool {
+			stops, _ := lsm.Stats()["write_stalls"].(map[string]interface{})["stops"].(int64)
+			return stops > 0
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, lsm.Close())
+		select {
+		case err := <-stalled:
+			assert.ErrorIs(t, err, lsmtree.ErrDBClosed)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the stalled write was not released by Close")
+		}
+	})
+}
+
+// TestLSMWriteStallConfig는 stop 수보다 큰 slowdown 수를 거절하는지 검증합니다.
+func TestLSMWriteStallConfig(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.Level0SlowdownTrigger = 10
+	config.Level0StopTrigger = 5
+	_, err := lsmtree.NewLSMTree(config)
+	assert.Error(t, err)
+}
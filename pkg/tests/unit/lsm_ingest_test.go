@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+)
+
+// TestLSMIngestSSTable은 밖에서 만든 SSTable을 수집하면 그 전의 값을 가리고, 재시작과 컴팩션 뒤에도
+// 남는지 검증합니다.
+func TestLSMIngestSSTable(t *testing.T) {
+	for name, key := range map[string][]byte{"plain": nil, "encrypted": bytes.Repeat([]byte{7}, pagecrypt.KeySize)} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := lsmtree.DefaultConfig()
+			config.FilePath = filepath.Join(dir, "db")
+			config.EncryptionKey = key
+			lsm, err := lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+
+			assert.NoError(t, lsm.Insert("k0001", "old"))
+			assert.NoError(t, lsm.Insert("z", "kept"))
+			v, err := lsm.Get("k0001")
+			assert.NoError(t, err)
+			assert.Equal(t, "old", v)
+
+			data := make(map[string]string)
+			for i := 0; i < 1000; i++ {
+				data[fmt.Sprintf("k%04d", i)] = fmt.Sprintf("v%d", i)
+			}
+			path := filepath.Join(dir, "import.sst")
+			_, err = lsmtree.CreateSSTable(path, data, "snappy", false)
+			assert.NoError(t, err)
+			assert.NoError(t, lsm.IngestSSTable(path))
+
+			v, err = lsm.Get("k0001")
+			assert.NoError(t, err)
+			assert.Equal(t, "v1", v, "the ingested table should shadow older writes")
+			v, err = lsm.Get("z")
+			assert.NoError(t, err)
+			assert.Equal(t, "kept", v)
+			assert.NoError(t, lsm.Insert("k0002", "new"))
+			assert.NoError(t, lsm.Close())
+
+			lsm, err = lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+			defer lsm.Close()
+			assert.NoError(t, lsm.ForceCompaction())
+			for k, want := range map[string]string{"k0001": "v1", "k0002": "new", "k0999": "v999", "z": "kept"} {
+				v, err := lsm.Get(k)
+				assert.NoError(t, err)
+				assert.Equal(t, want, v)
+			}
+		})
+	}
+}
+
+func TestLSMIngestRejectsCorruptTable(t *testing.T) {
+	dir := t.TempDir()
+	config := lsmtree.DefaultConfig()
+	config.FilePath = filepath.Join(dir, "db")
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+
+	assert.Error(t, lsm.IngestSSTable(filepath.Join(dir, "missing.sst")))
+	path := filepath.Join(dir, "merge.sst")
+	_, err = lsmtree.CreateSSTable(path, map[string]string{"k": "\x00<MERGE>x"}, "none", false)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, lsm.IngestSSTable(path), lsmtree.ErrInvalidValue)
+}
+
+// TestLSMIngestExternalFile은 SSTableWriter로 만든 파일이 겹치지 않으면 깊은 레벨에, 겹치면 level0에
+// 들어가고 tombstone도 수집되는지 검증합니다.
+func TestLSMIngestExternalFile(t *testing.T) {
+	dir := t.TempDir()
+	config := lsmtree.DefaultConfig()
+	config.FilePath = filepath.Join(dir, "db")
+	config.MaxLevels = 4
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+
+	write := func(name string, build func(w *lsmtree.SSTableWriter)) string {
+		path := filepath.Join(dir, name)
+		w, err := lsmtree.NewSSTableWriter(path, lsmtree.SSTableWriterOptions{})
+		assert.NoError(t, err)
+		build(w)
+		assert.NoError(t, w.Finish())
+		return path
+	}
+
+	assert.NoError(t, lsm.Insert("a", "1"))
+	assert.NoError(t, lsm.ForceCompaction()) // "a"는 level1로 내려감
+
+	// 어느 레벨과도 겹치지 않으면 가장 깊은 레벨에 둠
+	level, err := lsm.IngestExternalFile(write("users.sst", func(w *lsmtree.SSTableWriter) {
+		for i := 0; i < 500; i++ {
+			assert.NoError(t, w.Add(fmt.Sprintf("user:%04d", i), fmt.Sprint(i)))
+		}
+		assert.Equal(t, 500, w.Count())
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, level)
+
+	// level1의 "a"와 겹치면 level0에 두고, tombstone이 그 값을 가림
+	level, err = lsm.IngestExternalFile(write("fix.sst", func(w *lsmtree.SSTableWriter) {
+		assert.NoError(t, w.Delete("a"))
+		assert.NoError(t, w.Add("b", "2"))
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, level)
+
+	_, err = lsm.Get("a")
+	assert.ErrorIs(t, err, lsmtree.ErrKeyNotFound)
+	for k, want := range map[string]string{"b": "2", "user:0000": "0", "user:0499": "499"} {
+		v, err := lsm.Get(k)
+		assert.NoError(t, err)
+		assert.Equal(t, want, v)
+	}
+	assert.NoError(t, lsm.ForceCompaction())
+	v, err := lsm.Get("user:0250")
+	assert.NoError(t, err)
+	assert.Equal(t, "250", v)
+	_, err = lsm.Get("a")
+	assert.ErrorIs(t, err, lsmtree.ErrKeyNotFound)
+}
+
+func TestSSTableWriterRejectsUnsortedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.sst")
+	w, err := lsmtree.NewSSTableWriter(path, lsmtree.SSTableWriterOptions{CompressionType: "none"})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Add("b", "1"))
+	assert.ErrorIs(t, w.Add("a", "2"), lsmtree.ErrInvalidKey)
+	assert.ErrorIs(t, w.Add("b", "2"), lsmtree.ErrInvalidKey)
+	w.Abort()
+	assert.NoFileExists(t, path)
+	assert.ErrorIs(t, w.Finish(), lsmtree.ErrInvalidOperation)
+
+	_, err = lsmtree.NewSSTableWriter(path, lsmtree.SSTableWriterOptions{CompressionType: "lz4"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+)
+
+func openMergeLSM(t *testing.T, dir string, op lsmtree.MergeOperator) *lsmtree.LSMTree {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = dir
+	config.MergeOperator = op
+	lsm, err := lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to create LSMTree: %v", err)
+	}
+	return lsm
+}
+
+// TestLSMMergeCounter는 카운터 피연산자가 플러시, 컴팩션, 재시작을 거쳐도 접히는지 검증합니다.
+func TestLSMMergeCounter(t *testing.T) {
+	dir := t.TempDir()
+	lsm := openMergeLSM(t, dir, lsmtree.CounterMerge{})
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, lsm.Merge("hits", "1"))
+	}
+	v, err := lsm.Get("hits")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", v)
+
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.NoError(t, lsm.Merge("hits", "5"))
+	assert.NoError(t, lsm.Insert("base", "100"))
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.NoError(t, lsm.Merge("base", "-1"))
+	assert.NoError(t, lsm.Merge("hits", "2"))
+
+	v, err = lsm.Get("hits")
+	assert.NoError(t, err)
+	assert.Equal(t, "17", v)
+	v, err = lsm.Get("base")
+	assert.NoError(t, err)
+	assert.Equal(t, "99", v)
+	assert.NoError(t, lsm.Close())
+
+	// WAL 재생 후에도 같은 값
+	lsm = openMergeLSM(t, dir, lsmtree.CounterMerge{})
+	defer lsm.Close()
+	v, err = lsm.Get("hits")
+	assert.NoError(t, err)
+	assert.Equal(t, "17", v)
+	assert.NoError(t, lsm.ForceCompaction())
+	v, err = lsm.Get("base")
+	assert.NoError(t, err)
+	assert.Equal(t, "99", v)
+}
+
+// TestLSMMergeAppend는 리스트 추가와 삭제 후의 머지를 검증합니다.
+func TestLSMMergeAppend(t *testing.T) {
+	lsm := openMergeLSM(t, t.TempDir(), lsmtree.AppendMerge{Separator: ","})
+	defer lsm.Close()
+
+	assert.NoError(t, lsm.Insert("list", "a"))
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.NoError(t, lsm.Merge("list", "b"))
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.NoError(t, lsm.Merge("list", "c"))
+	v, err := lsm.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b,c", v)
+
+	// 삭제 뒤의 머지는 기존 값 없이 시작
+	assert.NoError(t, lsm.Delete("list"))
+	assert.NoError(t, lsm.Merge("list", "x"))
+	v, err = lsm.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", v)
+
+	// 머지 레코드처럼 보이는 값은 삽입할 수 없음
+	assert.ErrorIs(t, lsm.Insert("bad", "\x00<MERGE>x"), lsmtree.ErrInvalidValue)
+}
+
+// TestLSMMergeScan은 Scan이 머지 피연산자를 접어 돌려주는지 검증합니다.
+func TestLSMMergeScan(t *testing.T) {
+	lsm := openMergeLSM(t, t.TempDir(), lsmtree.CounterMerge{})
+	defer lsm.Close()
+
+	for i := 0; i < 3; i++ {
+		key := "k" + strconv.Itoa(i)
+		assert.NoError(t, lsm.Insert(key, "10"))
+	}
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.NoError(t, lsm.Merge("k1", "5"))
+	assert.NoError(t, lsm.Merge("k3", "7"))
+
+	it, err := lsm.Scan("", "")
+	assert.NoError(t, err)
+	defer it.Close()
+	got := map[string]string{}
+	for it.Next() {
+		got[it.Key()] = it.Value()
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, map[string]string{"k0": "10", "k1": "15", "k2": "10", "k3": "7"}, got)
+}
+
+// TestLSMMergeWithoutOperator는 연산자 없이 Merge가 실패하는지 검증합니다.
+func TestLSMMergeWithoutOperator(t *testing.T) {
+	lsm := openMergeLSM(t, t.TempDir(), nil)
+	defer lsm.Close()
+	assert.ErrorIs(t, lsm.Merge("k", "1"), lsmtree.ErrNoMergeOperator)
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+)
+
+// TestLSMWALSegments는 WAL이 세그먼트 크기마다 새 파일로 넘어가고, 중단된 트리가 모든 세그먼트를
+// 재생해 복구되며, flush가 내보낸 memTable의 세그먼트를 지우는지 검증합니다.
+func TestLSMWALSegments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		key  []byte
+	}{{"plain", nil}, {"encrypted", storageKey1}} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := lsmtree.DefaultConfig()
+			config.FilePath = t.TempDir()
+			config.MemTableSize = 1 << 20
+			config.CompactionInterval = time.Hour
+			config.SyncWrites = true
+			config.WALSegmentSize = 1024
+			config.EncryptionKey = tc.key
+			lsm, err := lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+
+			segments := func(dir string) []string {
+				names, err := filepath.Glob(filepath.Join(dir, "db.wal*"))
+				assert.NoError(t, err)
+				return names
+			}
+			check := func(lsm *lsmtree.LSMTree, n int) {
+				for i := 0; i < n; i++ {
+					v, err := lsm.Get(fmt.Sprintf("key%04d", i))
+					assert.NoError(t, err)
+					assert.Equal(t, fmt.Sprintf("value-%04d", i), v)
+				}
+			}
+			for i := 0; i < 300; i++ { // 엔트리 약 10KB
+				assert.NoError(t, lsm.Insert(fmt.Sprintf("key%04d", i), fmt.Sprintf("value-%04d", i)))
+			}
+			assert.Greater(t, len(segments(config.FilePath)), 5)
+			assert.Greater(t, lsm.Stats()["wal"].(map[string]interface{})["rotations"], int64(5))
+
+			// 중단된 트리: 모든 세그먼트를 재생
+			image := config
+			image.FilePath = copyLSMDir(t, config.FilePath)
+			recovered, err := lsmtree.NewLSMTree(image)
+			assert.NoError(t, err)
+			check(recovered, 300)
+			assert.NoError(t, recovered.Close())
+
+			assert.NoError(t, lsm.ForceCompaction())
+			assert.Len(t, segments(config.FilePath), 1, "flushed segments are removed")
+			assert.Less(t, lsm.RecoveryDebt(), int64(64))
+
+			for i := 300; i < 400; i++ {
+				assert.NoError(t, lsm.Insert(fmt.Sprintf("key%04d", i), fmt.Sprintf("value-%04d", i)))
+			}
+			image.FilePath = copyLSMDir(t, config.FilePath)
+			recovered, err = lsmtree.NewLSMTree(image)
+			assert.NoError(t, err)
+			check(recovered, 400)
+			assert.NoError(t, recovered.Close())
+			assert.NoError(t, lsm.Close())
+		})
+	}
+}
+
+// TestLSMWALSegmentSizeValidation은 음수 세그먼트 크기를 거절하는지 검증합니다.
+func TestLSMWALSegmentSizeValidation(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.WALSegmentSize = -1
+	_, err := lsmtree.NewLSMTree(config)
+	assert.Error(t, err)
+}
+
+// TestLSMWALChecksums는 체크섬이 맞지 않는 WAL 레코드에서 strict 복구는 세그먼트 재생을 멈추고,
+// best_effort 복구는 그 레코드만 건너뛰는지 검증합니다.
+func TestLSMWALChecksums(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.CompactionInterval = time.Hour
+	config.SyncWrites = true
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	for _, k := range []string{"a", "b", "c"} {
+		assert.NoError(t, lsm.Insert(k, "value-"+k))
+	}
+	image := copyLSMDir(t, config.FilePath)
+	assert.NoError(t, lsm.Close())
+	wal, err := os.ReadFile(filepath.Join(image, "db.wal"))
+	assert.NoError(t, err)
+	assert.Equal(t, "GLW2", string(wal[:4]))
+	wal[bytes.Index(wal, []byte("value-b"))] ^= 0xff
+	assert.NoError(t, os.WriteFile(filepath.Join(image, "db.wal"), wal, 0644))
+
+	for _, tc := range []struct {
+		mode string
+		c    bool
+	}{{"strict", false}, {"best_effort", true}} {
+		t.Run(tc.mode, func(t *testing.T) {
+			config := config
+			config.FilePath = copyLSMDir(t, image)
+			config.RecoveryMode = tc.mode
+			recovered, err := lsmtree.NewLSMTree(config)
+			assert.NoError(t, err)
+			defer recovered.Close()
+			_, err = recovered.Get("a")
+			assert.NoError(t, err)
+			_, err = recovered.Get("b")
+			assert.ErrorIs(t, err, lsmtree.ErrKeyNotFound)
+			_, err = recovered.Get("c")
+			assert.Equal(t, tc.c, err == nil, "%v", err)
+			report := recovered.RecoveryReport()
+			assert.Equal(t, int64(1), report.Corrupted)
+			if tc.c {
+				assert.Equal(t, int64(2), report.WALRecords)
+			} else {
+				assert.Equal(t, int64(1), report.WALRecords)
+			}
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package unit
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/events"
 )
 
 // createTempDir는 테스트용 임시 디렉토리를 생성합니다.
@@ -232,3 +237,577 @@ func TestForceCompaction(t *testing.T) {
 		}
 	}
 }
+
+// TestWALGroupCommit는 동시 AppendSync가 fsync를 공유하고 모두 복구되는지 검증합니다.
+func TestWALGroupCommit(t *testing.T) {
+	tempDir := createTempDir(t)
+	defer removeTempDir(t, tempDir)
+	walPath := tempDir + "/group.wal"
+
+	wal, err := lsmtree.NewWALWithGroupCommit(walPath, true, lsmtree.GroupCommit{MaxEntries: 64, MaxDelay: 2 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to open WAL: %v", err)
+	}
+	const writers, perWriter = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				key := fmt.Sprintf("w%d-%d", w, i)
+				if err := wal.AppendSync(lsmtree.WalEntry{Op: 0x00, Key: key, Value: key}); err != nil {
+					t.Errorf("AppendSync failed: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := wal.Stats()
+	if syncs := stats["syncs"].(int64); syncs >= writers*perWriter {
+		t.Errorf("expected fewer fsyncs than entries, got %d for %d entries", syncs, writers*perWriter)
+	}
+
+	// 비동기 Append도 Flush 이후에는 기록되어 있어야 함.
+	if err := wal.Append(lsmtree.WalEntry{Op: 0x01, Key: "w0-0"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	wal.Flush()
+
+	mt := lsmtree.NewMemTable(1 << 20)
+	if err := lsmtree.RecoverFromWAL(walPath, mt); err != nil {
+		t.Fatalf("recovery failed: %v", err)
+	}
+	if val, ok := mt.Get("w7-49"); !ok || val != "w7-49" {
+		t.Errorf("expected w7-49 to be recovered, got %q (%v)", val, ok)
+	}
+	if len(mt.Dump()) != writers*perWriter-1 {
+		t.Errorf("expected %d live keys, got %d", writers*perWriter-1, len(mt.Dump()))
+	}
+
+	if err := wal.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if err := wal.AppendSync(lsmtree.WalEntry{Key: "late"}); err != lsmtree.ErrWALClosed {
+		t.Errorf("expected ErrWALClosed after close, got %v", err)
+	}
+}
+
+// TestCompactionStrategies는 leveling과 sizing 컴팩션 후 최신 값이 유지되고 재시작 후에도
+// 레벨 구성이 복원되는지 검증합니다.
+func TestCompactionStrategies(t *testing.T) {
+	for _, strategy := range []string{"leveling", "sizing"} {
+		t.Run(strategy, func(t *testing.T) {
+			config := lsmtree.DefaultConfig()
+			config.FilePath = t.TempDir()
+			config.MemTableSize = 512 // 삽입 중에 여러 번 flush되도록 작게 설정
+			config.SSTableSize = 256
+			config.CompressionType = "none" // 레벨 크기가 압축률에 좌우되지 않도록
+			config.CompactionInterval = time.Hour
+			config.CompactionStrategy = strategy
+			config.Level0CompactionTrigger = 2
+			config.LevelSizeMultiplier = 2
+
+			lsm, err := lsmtree.NewLSMTree(config)
+			if err != nil {
+				t.Fatalf("failed to create LSMTree: %v", err)
+			}
+			for round := 0; round < 3; round++ {
+				for i := 0; i < 40; i++ {
+					if err := lsm.Insert(fmt.Sprintf("key%03d", i), fmt.Sprintf("v%d-%d", round, i)); err != nil {
+						t.Fatalf("insert failed: %v", err)
+					}
+				}
+			}
+			if err := lsm.ForceCompaction(); err != nil {
+				t.Fatalf("force compaction failed: %v", err)
+			}
+
+			levels := lsm.Stats()["levels"].([]int)
+			if strategy == "sizing" {
+				if len(levels) != 1 || levels[0] != 1 {
+					t.Errorf("expected one level0 table after size-tiered compaction, got %v", levels)
+				}
+			} else if len(levels) < 3 || levels[0] != 0 {
+				t.Errorf("expected level0 to be empty and data pushed below level1, got %v", levels)
+			}
+
+			check := func(lsm *lsmtree.LSMTree) {
+				for i := 0; i < 40; i++ {
+					val, err := lsm.Get(fmt.Sprintf("key%03d", i))
+					if err != nil || val != fmt.Sprintf("v2-%d", i) {
+						t.Errorf("key%03d: expected v2-%d, got %q (%v)", i, i, val, err)
+					}
+				}
+			}
+			check(lsm)
+			if err := lsm.Close(); err != nil {
+				t.Fatalf("close failed: %v", err)
+			}
+
+			reopened, err := lsmtree.NewLSMTree(config)
+			if err != nil {
+				t.Fatalf("failed to reopen LSMTree: %v", err)
+			}
+			defer reopened.Close()
+			if got := reopened.Stats()["levels"].([]int); fmt.Sprint(got) != fmt.Sprint(levels) {
+				t.Errorf("expected levels %v after reopen, got %v", levels, got)
+			}
+			check(reopened)
+		})
+	}
+}
+
+// TestSSTableBlockCompression은 압축 SSTable이 더 작게 기록되고, 조회 시 해당 블록만 읽는지 검증합니다.
+func TestSSTableBlockCompression(t *testing.T) {
+	data := make(map[string]string)
+	for i := 0; i < 2000; i++ {
+		data[fmt.Sprintf("key%05d", i)] = fmt.Sprintf("value-%d-%s", i, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	}
+	dir := t.TempDir()
+	if _, err := lsmtree.CreateSSTable(dir+"/plain.sst", data, "none", false); err != nil {
+		t.Fatalf("failed to create uncompressed SSTable: %v", err)
+	}
+	plainInfo, _ := os.Stat(dir + "/plain.sst")
+
+	for _, compression := range []string{"snappy", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			path := dir + "/" + compression + ".sst"
+			if _, err := lsmtree.CreateSSTable(path, data, compression, false); err != nil {
+				t.Fatalf("failed to create SSTable: %v", err)
+			}
+			info, _ := os.Stat(path)
+			if info.Size() >= plainInfo.Size()/2 {
+				t.Errorf("expected %s SSTable to be much smaller than %d bytes, got %d", compression, plainInfo.Size(), info.Size())
+			}
+
+			sst, err := lsmtree.OpenSSTable(path, false)
+			if err != nil {
+				t.Fatalf("failed to open SSTable: %v", err)
+			}
+			for k, v := range data {
+				if got, ok := sst.Get(k); !ok || got != v {
+					t.Fatalf("key %s: expected %q, got %q (%v)", k, v, got, ok)
+				}
+			}
+			if _, ok := sst.Get("key99999"); ok {
+				t.Errorf("missing key should not be found")
+			}
+
+			// 마지막 블록을 손상시켜도 앞쪽 블록의 키는 그대로 읽혀야 함 (조회가 블록 하나만 읽음).
+			raw, _ := os.ReadFile(path)
+			raw[len(raw)/2] ^= 0xFF
+			os.WriteFile(path, raw, 0644)
+			if got, ok := sst.Get("key00000"); !ok || got != data["key00000"] {
+				t.Errorf("first block should still be readable, got %q (%v)", got, ok)
+			}
+			if sst.Quarantined() {
+				t.Errorf("reading an intact block should not quarantine the table")
+			}
+		})
+	}
+}
+
+// TestSSTableSparseIndex는 비압축 SSTable도 블록 단위로 기록되어 블록 인덱스만으로 조회되고,
+// 블록 이전의 flat 포맷 파일도 계속 읽히는지 검증합니다.
+func TestSSTableSparseIndex(t *testing.T) {
+	data := make(map[string]string)
+	for i := 0; i < 2000; i += 2 {
+		data[fmt.Sprintf("key%05d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	path := t.TempDir() + "/sparse.sst"
+	if _, err := lsmtree.CreateSSTable(path, data, "none", false); err != nil {
+		t.Fatalf("failed to create SSTable: %v", err)
+	}
+	sst, err := lsmtree.OpenSSTable(path, false)
+	if err != nil {
+		t.Fatalf("failed to open SSTable: %v", err)
+	}
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("key%05d", i)
+		got, ok := sst.Get(key)
+		if want, exists := data[key]; ok != exists || got != want {
+			t.Fatalf("key %s: expected %q (%v), got %q (%v)", key, want, exists, got, ok)
+		}
+	}
+	raw, _ := os.ReadFile(path)
+	raw[len(raw)/2] ^= 0xFF
+	os.WriteFile(path, raw, 0644)
+	if got, ok := sst.Get("key00000"); !ok || got != data["key00000"] {
+		t.Errorf("first block should still be readable, got %q (%v)", got, ok)
+	}
+
+	// flat 포맷: [keyLen u16][key][valLen u16][value]... + CRC32
+	var flat []byte
+	for _, kv := range [][2]string{{"a", "1"}, {"b", "2"}} {
+		flat = binary.BigEndian.AppendUint16(flat, uint16(len(kv[0])))
+		flat = append(flat, kv[0]...)
+		flat = binary.BigEndian.AppendUint16(flat, uint16(len(kv[1])))
+		flat = append(flat, kv[1]...)
+	}
+	flat = binary.BigEndian.AppendUint32(flat, crc32.ChecksumIEEE(flat))
+	legacy := t.TempDir() + "/legacy.sst"
+	os.WriteFile(legacy, flat, 0644)
+	old, err := lsmtree.OpenSSTable(legacy, false)
+	if err != nil {
+		t.Fatalf("failed to open flat SSTable: %v", err)
+	}
+	if got, ok := old.Get("b"); !ok || got != "2" {
+		t.Errorf("expected flat SSTable value 2, got %q (%v)", got, ok)
+	}
+}
+
+// TestTombstonesSurviveFlushAndCompaction은 삭제가 flush 후에도 이전 SSTable의 값을 가리고,
+// 컴팩션에서 더 오래된 값이 남을 수 없는 tombstone만 제거되는지 검증합니다.
+func TestTombstonesSurviveFlushAndCompaction(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.CompactionInterval = time.Hour
+	config.CompactionStrategy = "sizing"
+	config.Level0CompactionTrigger = 100
+	config.Events = events.NewBus()
+	sub := config.Events.Subscribe(16, events.CompactionFinished)
+	defer sub.Close()
+
+	lsm, err := lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to create LSMTree: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if err := lsm.Insert(fmt.Sprintf("key%03d", i), fmt.Sprintf("v%d", i)); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+	}
+	if err := lsm.ForceCompaction(); err != nil { // 테이블 하나로 flush
+		t.Fatalf("force compaction failed: %v", err)
+	}
+	if _, err := lsm.Get("key005"); err != nil { // 캐시에 올려 둠
+		t.Fatalf("get failed: %v", err)
+	}
+	for _, key := range []string{"key005", "key010"} {
+		if err := lsm.Delete(key); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+	}
+	if err := lsm.Insert("key005x", "new"); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if err := lsm.Insert("bad", "<TOMBSTONE>"); err != lsmtree.ErrInvalidValue {
+		t.Errorf("expected the tombstone value to be rejected, got %v", err)
+	}
+	if _, err := lsm.Get("key005"); err != lsmtree.ErrKeyNotFound {
+		t.Errorf("expected deleted key to be missing before flush, got %v", err)
+	}
+	if err := lsm.Close(); err != nil { // tombstone이 새 SSTable로 flush됨
+		t.Fatalf("close failed: %v", err)
+	}
+
+	lsm, err = lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to reopen LSMTree: %v", err)
+	}
+	defer lsm.Close()
+	check := func() {
+		for _, key := range []string{"key005", "key010"} {
+			if val, err := lsm.Get(key); err != lsmtree.ErrKeyNotFound {
+				t.Errorf("%s: expected ErrKeyNotFound, got %q (%v)", key, val, err)
+			}
+		}
+		if val, err := lsm.Get("key006"); err != nil || val != "v6" {
+			t.Errorf("key006: expected v6, got %q (%v)", val, err)
+		}
+	}
+	check()
+
+	if err := lsm.ForceCompaction(); err != nil {
+		t.Fatalf("force compaction failed: %v", err)
+	}
+	check()
+	select {
+	case e := <-sub.C:
+		if e.Details["tombstones_dropped"] != 2 {
+			t.Errorf("expected 2 tombstones dropped, got %v", e.Details["tombstones_dropped"])
+		}
+	default:
+		t.Errorf("expected a compaction event")
+	}
+}
+
+// TestDeleteConcurrentWithReadsSurvivesFlush는 삭제와 겹친 읽기가 이전 SSTable 값을 캐시에 넣더라도
+// flush 뒤에 지운 키가 다시 보이지 않는지 검증합니다.
+func TestDeleteConcurrentWithReadsSurvivesFlush(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.CompactionInterval = time.Hour
+	config.Level0CompactionTrigger = 100
+	lsm, err := lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to create LSMTree: %v", err)
+	}
+	defer lsm.Close()
+
+	const rounds, keys, readers = 10, 200, 4
+	for round := 0; round < rounds; round++ {
+		key := func(i int) string { return fmt.Sprintf("r%02d-key%03d", round, i) }
+		for i := 0; i < keys; i++ {
+			if err := lsm.Insert(key(i), "old"); err != nil {
+				t.Fatalf("insert failed: %v", err)
+			}
+		}
+		if err := lsm.ForceCompaction(); err != nil { // 값이 SSTable에만 있고 캐시에는 없도록 flush
+			t.Fatalf("force compaction failed: %v", err)
+		}
+		// reader는 삭제할 차례의 키를 계속 읽어, 캐시에 없는 키의 읽기가 삭제와 겹치게 함
+		var next atomic.Int64
+		var wg sync.WaitGroup
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					i := next.Load()
+					if i >= keys {
+						return
+					}
+					lsm.Get(key(int(i)))
+				}
+			}()
+		}
+		for i := 0; i < keys; i++ {
+			if err := lsm.Delete(key(i)); err != nil {
+				t.Fatalf("delete failed: %v", err)
+			}
+			next.Add(1)
+		}
+		wg.Wait()
+		if err := lsm.ForceCompaction(); err != nil { // tombstone을 flush
+			t.Fatalf("force compaction failed: %v", err)
+		}
+		for i := 0; i < keys; i++ {
+			if val, err := lsm.Get(key(i)); err != lsmtree.ErrKeyNotFound {
+				t.Fatalf("%s: deleted key came back as %q (%v)", key(i), val, err)
+			}
+		}
+	}
+}
+
+// TestLSMTreeScan은 memTable과 여러 레벨의 SSTable에 흩어진 키를 범위 스캔할 때 정렬 순서와
+// 최신 값 우선, 삭제된 키 제외가 지켜지는지 검증합니다.
+func TestLSMTreeScan(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.MemTableSize = 512 // 여러 SSTable로 flush되도록 작게 설정
+	config.CompactionInterval = time.Hour
+	config.Level0CompactionTrigger = 100
+
+	lsm, err := lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to create LSMTree: %v", err)
+	}
+	defer lsm.Close()
+	want := make(map[string]string)
+	for round := 0; round < 3; round++ {
+		for i := round; i < 60; i += round + 1 {
+			key, value := fmt.Sprintf("key%03d", i), fmt.Sprintf("v%d-%d", round, i)
+			if err := lsm.Insert(key, value); err != nil {
+				t.Fatalf("insert failed: %v", err)
+			}
+			want[key] = value
+		}
+	}
+	for i := 0; i < 60; i += 7 {
+		key := fmt.Sprintf("key%03d", i)
+		if err := lsm.Delete(key); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+		delete(want, key)
+	}
+	if levels := lsm.Stats()["levels"].([]int); levels[0] < 2 {
+		t.Fatalf("expected several level0 tables, got %v", levels)
+	}
+
+	it, err := lsm.Scan("key010", "key050")
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	defer it.Close()
+	var keys []string
+	for it.Next() {
+		if it.Value() != want[it.Key()] {
+			t.Errorf("%s: expected %q, got %q", it.Key(), want[it.Key()], it.Value())
+		}
+		keys = append(keys, it.Key())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	var expected []string
+	for i := 10; i < 50; i++ {
+		if key := fmt.Sprintf("key%03d", i); want[key] != "" {
+			expected = append(expected, key)
+		}
+	}
+	if fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+
+	// 끝이 비어 있으면 마지막 키까지.
+	all, err := lsm.Scan("", "")
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	defer all.Close()
+	n := 0
+	for all.Next() {
+		n++
+	}
+	if n != len(want) {
+		t.Errorf("expected %d keys in a full scan, got %d", len(want), n)
+	}
+}
+
+// TestMemTableOrdered는 memTable이 키 순서를 유지하고, 쓰기 중에도 락 없는 읽기가
+// 안전한지 검사합니다.
+func TestMemTableOrdered(t *testing.T) {
+	mt := lsmtree.NewMemTable(1 << 20)
+	const n = 2000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := n - 1; i >= 0; i-- {
+			key := fmt.Sprintf("k%05d", i)
+			if err := mt.Insert(key, key); err != nil {
+				t.Errorf("Insert failed: %v", err)
+				return
+			}
+		}
+	}()
+	// 쓰기와 동시에 읽기: 보이는 값은 항상 온전해야 함 (-race로 함께 검사).
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			if val, ok := mt.Get(fmt.Sprintf("k%05d", n-1)); ok && val != fmt.Sprintf("k%05d", n-1) {
+				t.Fatalf("unexpected value %q", val)
+			}
+		}
+	}
+
+	if err := mt.Delete("k00010"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := mt.Insert("k00011", "updated"); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	var keys []string
+	mt.Ascend("k00009", "k00013", func(key, value string) bool {
+		keys = append(keys, key+"="+value)
+		return true
+	})
+	want := []string{"k00009=k00009", "k00010=<TOMBSTONE>", "k00011=updated", "k00012=k00012"}
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, keys)
+	}
+	prev := ""
+	count := 0
+	mt.Ascend("", "", func(key, value string) bool {
+		if key <= prev {
+			t.Fatalf("keys out of order: %q after %q", key, prev)
+		}
+		prev = key
+		count++
+		return true
+	})
+	if count != n {
+		t.Errorf("expected %d entries, got %d", n, count)
+	}
+	if data := mt.Swap(); len(data) != n || data["k00010"] == "" {
+		t.Errorf("expected %d entries including the tombstone, got %d", n, len(data))
+	}
+	if len(mt.Dump()) != 0 || mt.Size() != 0 {
+		t.Errorf("expected an empty memTable after Swap")
+	}
+}
+
+// TestBloomSidecarRebuild는 블룸 사이드카가 없거나 손상된 SSTable이 필터 없이 열려 조회가 계속
+// 되고, 백그라운드에서 사이드카가 다시 만들어지며 진행 이벤트가 발행되는지 검증합니다.
+func TestBloomSidecarRebuild(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.CompactionInterval = time.Hour
+	config.Level0CompactionTrigger = 100
+	config.Events = events.NewBus()
+
+	lsm, err := lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to create LSMTree: %v", err)
+	}
+	for round := 0; round < 2; round++ { // SSTable 두 개
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("r%d-key%03d", round, i)
+			if err := lsm.Insert(key, key); err != nil {
+				t.Fatalf("insert failed: %v", err)
+			}
+		}
+		if err := lsm.Close(); err != nil {
+			t.Fatalf("close failed: %v", err)
+		}
+		if lsm, err = lsmtree.NewLSMTree(config); err != nil {
+			t.Fatalf("failed to reopen LSMTree: %v", err)
+		}
+	}
+	lsm.Close()
+
+	sidecars, _ := filepath.Glob(filepath.Join(config.FilePath, "*.sst.bloom"))
+	if len(sidecars) != 2 {
+		t.Fatalf("expected 2 bloom sidecars, got %v", sidecars)
+	}
+	os.Remove(sidecars[0])
+	if err := os.WriteFile(sidecars[1], []byte("GLBF garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(config.FilePath, "db.sst.1.sst.bloom")
+	os.WriteFile(orphan, []byte("x"), 0644)
+
+	sub := config.Events.Subscribe(16, events.RebuildProgress)
+	defer sub.Close()
+	lsm, err = lsmtree.NewLSMTree(config)
+	if err != nil {
+		t.Fatalf("failed to reopen LSMTree: %v", err)
+	}
+	defer lsm.Close()
+	// 재구축 중에도 조회는 정확해야 함.
+	if val, err := lsm.Get("r1-key007"); err != nil || val != "r1-key007" {
+		t.Errorf("expected r1-key007, got %q (%v)", val, err)
+	}
+	for done := 0; done < 2; {
+		select {
+		case e := <-sub.C:
+			if e.Err != nil {
+				t.Fatalf("rebuild failed: %v", e.Err)
+			}
+			done = e.Details["done"].(int)
+			if e.Details["total"] != 2 {
+				t.Errorf("expected total 2, got %v", e.Details["total"])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the rebuild")
+		}
+	}
+	if pending := lsm.Stats()["bloom_rebuilds_pending"]; pending != int64(0) {
+		t.Errorf("expected no pending rebuilds, got %v", pending)
+	}
+	for _, path := range sidecars {
+		if data, err := os.ReadFile(path); err != nil || len(data) < 20 || string(data[:4]) != "GLBF" {
+			t.Errorf("expected %s to be rebuilt (%v)", path, err)
+		}
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("expected the orphan sidecar to be removed")
+	}
+	if _, err := lsm.Get("r0-missing"); err != lsmtree.ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/config"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func maintenanceStatus(db *domain.Database, name string) domain.MaintenanceStatus {
+	for _, s := range db.GetStatus().Maintenance {
+		if s.Name == name {
+			return s
+		}
+	}
+	return domain.MaintenanceStatus{}
+}
+
+func TestMaintenanceSchedulerRunsBackups(t *testing.T) {
+	dir := t.TempDir()
+	db, err := openBtreeDatabase(filepath.Join(dir, "maint.db"), func(c *domain.DatabaseConfig) {
+		c.Maintenance = []domain.MaintenanceTask{{
+			Name:   "nightly",
+			Kind:   domain.MaintenanceBackup,
+			Every:  20 * time.Millisecond,
+			Jitter: 5 * time.Millisecond,
+			Target: filepath.Join(dir, "backup-{time}.db"),
+		}}
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "k", "v"))
+
+	assert.Eventually(t, func() bool { return maintenanceStatus(db, "nightly").Runs > 0 }, 2*time.Second, 5*time.Millisecond)
+	s := maintenanceStatus(db, "nightly")
+	assert.Equal(t, domain.MaintenanceBackup, s.Kind)
+	assert.Empty(t, s.LastError)
+	assert.False(t, s.LastRun.IsZero())
+	assert.True(t, s.NextRun.After(s.LastRun))
+	backups, _ := filepath.Glob(filepath.Join(dir, "backup-*.db"))
+	assert.NotEmpty(t, backups)
+}
+
+func TestRunMaintenanceRecordsResults(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "maint.db"), func(c *domain.DatabaseConfig) {
+		c.Maintenance = []domain.MaintenanceTask{
+			{Kind: domain.MaintenanceVerify, Every: time.Hour},
+			{Kind: domain.MaintenanceVacuum, Every: time.Hour},
+		}
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, db.Insert("users", fmt.Sprintf("k%02d", i), "v"))
+	}
+
+	report, err := db.Verify(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, report.Problems)
+	assert.GreaterOrEqual(t, report.Keys, int64(20))
+
+	assert.NoError(t, db.RunMaintenance(context.Background(), "verify"))
+	s := maintenanceStatus(db, "verify")
+	assert.Equal(t, int64(1), s.Runs)
+	assert.Empty(t, s.LastError)
+	assert.True(t, s.NextRun.After(time.Now().Add(50*time.Minute)))
+
+	// B-tree 저장소는 요청 시 컴팩션을 지원하지 않으므로 실패가 상태에 남음
+	assert.ErrorIs(t, db.RunMaintenance(context.Background(), "vacuum"), domain.ErrCompactUnsupported)
+	assert.Contains(t, maintenanceStatus(db, "vacuum").LastError, "compaction")
+	assert.Error(t, db.RunMaintenance(context.Background(), "missing"))
+}
+
+func TestMaintenanceConfigValidation(t *testing.T) {
+	_, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:        "maint",
+		FilePath:    filepath.Join(t.TempDir(), "maint.db"),
+		Maintenance: []domain.MaintenanceTask{{Kind: "defrag", Every: time.Hour}},
+	}, &mockLogger{})
+	assert.ErrorContains(t, err, "unknown kind")
+
+	cfg := config.Defaults()
+	cfg.Maintenance = []config.MaintenanceTask{{Kind: "backup", Every: config.Duration(time.Hour)}}
+	assert.ErrorContains(t, cfg.Validate(), "requires a target")
+	cfg.Maintenance[0].Target = "backup-{time}.db"
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func managedConfig(name, path string) domain.DatabaseConfig {
+	return testDatabaseConfig(path, func(c *domain.DatabaseConfig) { c.Name = name })
+}
+
+func TestManagerOpenListClose(t *testing.T) {
+	dir := t.TempDir()
+	m := domain.NewManager(&mockLogger{})
+	defer m.CloseAll()
+
+	_, err := m.Open(managedConfig("b", filepath.Join(dir, "b.db")))
+	assert.NoError(t, err)
+	a, err := m.Open(managedConfig("a", filepath.Join(dir, "a.db")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, m.List())
+
+	_, err = m.Open(managedConfig("a", filepath.Join(dir, "other.db")))
+	assert.ErrorIs(t, err, domain.ErrDatabaseExists)
+	_, err = m.Open(managedConfig("c", filepath.Join(dir, ".", "a.db")))
+	assert.ErrorIs(t, err, domain.ErrDatabaseExists, "the same file can't be open twice")
+
+	got, err := m.Get("a")
+	assert.NoError(t, err)
+	assert.Same(t, a, got)
+	assert.NoError(t, m.Close("a"))
+	assert.False(t, a.GetStatus().Ready)
+	_, err = m.Get("a")
+	assert.ErrorIs(t, err, domain.ErrDatabaseNotFound)
+	assert.ErrorIs(t, m.Close("a"), domain.ErrDatabaseNotFound)
+
+	assert.NoError(t, m.CloseAll())
+	assert.Empty(t, m.List())
+	_, err = m.Open(managedConfig("d", filepath.Join(dir, "d.db")))
+	assert.Error(t, err, "a closed manager opens nothing")
+}
+
+func TestManagerHandlerRoutesByContext(t *testing.T) {
+	dir := t.TempDir()
+	m := domain.NewManager(&mockLogger{})
+	defer m.CloseAll()
+	for _, name := range []string{"tenant1", "tenant2"} {
+		_, err := m.Open(managedConfig(name, filepath.Join(dir, name+".db")))
+		assert.NoError(t, err)
+	}
+	h := application.NewManagerHandler(m, &mockLogger{})
+	var created int
+	h.SetHandlerSetup(func(*application.CommandHandler, *application.QueryHandler) { created++ })
+
+	ctx1 := domain.WithDatabase(context.Background(), "tenant1")
+	ctx2 := domain.WithDatabase(context.Background(), "tenant2")
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		assert.NoError(t, h.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "t"}))
+	}
+	assert.NoError(t, h.ExecuteCommand(ctx1, &application.InsertCommand{TableName: "t", Key: "k", Value: "one"}))
+	assert.NoError(t, h.ExecuteCommand(ctx2, &application.InsertCommand{TableName: "t", Key: "k", Value: "two"}))
+
+	value, err := h.ExecuteQuery(ctx1, &application.GetValueQuery{TableName: "t", Key: "k"})
+	assert.NoError(t, err)
+	assert.Equal(t, "one", value)
+	value, err = h.ExecuteQuery(ctx2, &application.GetValueQuery{TableName: "t", Key: "k"})
+	assert.NoError(t, err)
+	assert.Equal(t, "two", value)
+	assert.Equal(t, 2, created, "handlers are created once per database")
+
+	_, err = h.ExecuteQuery(context.Background(), &application.GetValueQuery{TableName: "t", Key: "k"})
+	assert.ErrorIs(t, err, domain.ErrDatabaseNotFound)
+	err = h.ExecuteCommand(domain.WithDatabase(context.Background(), "missing"), &application.SyncCommand{})
+	assert.ErrorIs(t, err, domain.ErrDatabaseNotFound)
+
+	// 닫았다가 같은 이름으로 다시 열면 새 핸들러를 씀
+	assert.NoError(t, h.Manager().Close("tenant1"))
+	_, err = m.Open(managedConfig("tenant1", filepath.Join(dir, "tenant1.db")))
+	assert.NoError(t, err)
+	value, err = h.ExecuteQuery(ctx1, &application.GetValueQuery{TableName: "t", Key: "k"})
+	assert.NoError(t, err)
+	assert.Equal(t, "one", value)
+	assert.Equal(t, 3, created)
+	h.Wait()
+}
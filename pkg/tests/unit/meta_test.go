@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestEntryMetadataTimestampsAndFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTableWithOptions("sessions", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, db.CreateTable("plain"))
+
+	before := time.Now()
+	assert.NoError(t, db.Insert("sessions", "s1", "alice"))
+	value, meta, err := db.GetWithMeta("sessions", "s1")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", value)
+	assert.False(t, meta.Created.Before(before.Round(0)))
+	assert.Equal(t, meta.Created, meta.Updated)
+	assert.Equal(t, byte(0), meta.Flags)
+
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(t, db.InsertWithFlags("sessions", "s1", "alice2", 0x05))
+	_, meta2, err := db.GetWithMeta("sessions", "s1")
+	assert.NoError(t, err)
+	assert.True(t, meta2.Created.Equal(meta.Created))
+	assert.True(t, meta2.Updated.After(meta.Updated))
+	assert.Equal(t, byte(0x05), meta2.Flags)
+
+	// 일반 Insert는 플래그를 유지하고, Get/Scan/GetBytes는 값만 반환
+	assert.NoError(t, db.Insert("sessions", "s1", "alice3"))
+	got, err := db.Get("sessions", "s1")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice3", got)
+	raw, err := db.GetBytes("sessions", "s1")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice3", string(raw))
+	assert.NoError(t, db.Scan("sessions", "", func(key, value string) bool {
+		assert.Equal(t, "alice3", value)
+		return true
+	}))
+
+	assert.Error(t, db.InsertWithFlags("plain", "k", "v", 1))
+	assert.NoError(t, db.Insert("plain", "k", "v"))
+	value, meta, err = db.GetWithMeta("plain", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.True(t, meta.Created.IsZero())
+	assert.NoError(t, db.Close())
+
+	// 재오픈 후에도 테이블 옵션과 메타데이터가 유지됨
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	opts, err := db.TableOptions("sessions")
+	assert.NoError(t, err)
+	assert.True(t, opts.Metadata)
+	_, meta3, err := db.GetWithMeta("sessions", "s1")
+	assert.NoError(t, err)
+	assert.True(t, meta3.Created.Equal(meta2.Created))
+	assert.Equal(t, byte(0x05), meta3.Flags)
+}
+
+func TestEntryMetadataReplicatesExactly(t *testing.T) {
+	dir := t.TempDir()
+	primary, err := openBtreeDatabase(filepath.Join(dir, "primary.db"))
+	assert.NoError(t, err)
+	defer primary.Close()
+	replica, err := openBtreeDatabase(filepath.Join(dir, "replica.db"))
+	assert.NoError(t, err)
+	defer replica.Close()
+
+	var muts []domain.Mutation
+	primary.AddMutationListener(func(m domain.Mutation) { muts = append(muts, m) })
+	assert.NoError(t, primary.CreateTableWithOptions("t", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, primary.InsertWithFlags("t", "k", "v", 9))
+	time.Sleep(2 * time.Millisecond)
+
+	for _, m := range muts {
+		assert.NoError(t, replica.ApplyMutation(m))
+	}
+	_, want, err := primary.GetWithMeta("t", "k")
+	assert.NoError(t, err)
+	value, got, err := replica.GetWithMeta("t", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.True(t, want.Updated.Equal(got.Updated))
+	assert.Equal(t, want.Flags, got.Flags)
+}
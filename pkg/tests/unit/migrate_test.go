@@ -0,0 +1,72 @@
+package unit
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestMigrationsRunOncePerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrate.db")
+	runs := make(map[int]int)
+	migrations := []domain.Migration{
+		{Version: 2, Name: "seed admin", Up: func(db *domain.Database) error {
+			runs[2]++
+			return db.Insert("users", "admin", "root")
+		}},
+		{Version: 1, Name: "create users", Up: func(db *domain.Database) error {
+			runs[1]++
+			return db.CreateTable("users")
+		}},
+	}
+
+	// dry run은 대기 중인 마이그레이션만 보고하고 실행하지 않음
+	db, err := openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Migrations, c.MigrationDryRun = migrations, true })
+	assert.NoError(t, err)
+	pending, err := db.Migrate(true)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Empty(t, runs)
+	assert.NoError(t, db.Close())
+
+	// 버전 순으로 실행되고 (1이 2보다 먼저), 다시 열어도 재실행되지 않음
+	for i := 0; i < 2; i++ {
+		db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Migrations = migrations })
+		assert.NoError(t, err)
+		value, err := db.Get("users", "admin")
+		assert.NoError(t, err)
+		assert.Equal(t, "root", value)
+		assert.NoError(t, db.Close())
+	}
+	assert.Equal(t, map[int]int{1: 1, 2: 1}, runs)
+
+	// 새 마이그레이션만 실행되고, 실패하면 오픈이 실패하며 기록되지 않음
+	failing := domain.Migration{Version: 3, Name: "broken", Up: func(db *domain.Database) error {
+		return errors.New("boom")
+	}}
+	_, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.Migrations = append(migrations, failing) })
+	assert.ErrorContains(t, err, "migration 3 (broken) failed")
+
+	db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) {
+		c.Migrations, c.MigrationDryRun = []domain.Migration{migrations[0]}, true
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	status, err := db.MigrationStatus()
+	assert.NoError(t, err)
+	assert.Len(t, status, 2)
+	assert.Equal(t, 1, status[0].Version)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[0].Registered) // 기록은 있지만 등록되지 않은 버전
+	assert.Equal(t, "create users", status[0].Name)
+	assert.True(t, status[1].Applied && status[1].Registered)
+	assert.False(t, status[1].AppliedAt.IsZero())
+
+	_, err = openBtreeDatabase(filepath.Join(t.TempDir(), "dup.db"), func(c *domain.DatabaseConfig) {
+		c.Migrations = []domain.Migration{migrations[0], migrations[0]}
+	})
+	assert.ErrorContains(t, err, "duplicate migration version 2")
+}
@@ -0,0 +1,38 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestOpStatsAccountsPerOperation(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "ops.db"), func(c *domain.DatabaseConfig) { c.OpStats = true })
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+
+	assert.NoError(t, db.Insert("kv", "key1", "value1"))
+	assert.NoError(t, db.Insert("kv", "key2", "value2"))
+	_, err = db.Get("kv", "key1")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Scan("kv", "key", func(string, string) bool { return true }))
+	assert.NoError(t, db.Delete("kv", "key2"))
+	assert.NoError(t, db.Write(domain.NewWriteBatch().Insert("kv", "k", "v")))
+	_, err = db.Increment("kv", "n", 5)
+	assert.NoError(t, err)
+
+	ops := db.Stats()["ops"].(map[string]domain.OpStats)
+	assert.Equal(t, int64(2), ops["insert"].Count)
+	assert.Equal(t, int64(20), ops["insert"].BytesWritten)
+	assert.Greater(t, ops["insert"].AllocBytes, uint64(0))
+	assert.Greater(t, ops["insert"].AllocBytesPerOp(), 0.0)
+	assert.Equal(t, int64(6), ops["get"].BytesRead)
+	assert.Equal(t, int64(20), ops["scan"].BytesRead)
+	assert.Equal(t, int64(4), ops["delete"].BytesWritten)
+	assert.Equal(t, int64(2), ops["write_batch"].BytesWritten)
+	assert.Equal(t, int64(2), ops["update"].BytesWritten)
+	assert.Equal(t, int64(1), ops["update"].Count)
+}
@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// gateCommand는 outboxGate가 닫힐 때까지 실행을 멈추는 테스트용 명령입니다.
+type gateCommand struct {
+	Name string
+}
+
+var outboxGate chan struct{}
+
+func (c *gateCommand) Execute(ctx context.Context, handler *application.CommandHandler) error {
+	<-outboxGate
+	return nil
+}
+
+func init() {
+	application.RegisterCommand(&gateCommand{})
+}
+
+func TestOutboxRunsAsyncCommandsInOrder(t *testing.T) {
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	pending, err := handler.EnableOutbox(filepath.Join(t.TempDir(), "outbox.db"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	ctx := context.Background()
+	// 테이블 생성도 비동기: 순서가 보장되지 않으면 뒤의 삽입이 먼저 실행되어 실패함
+	assert.NoError(t, handler.EnqueueCommand(ctx, &application.CreateTableCommand{TableName: "users"}))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, handler.EnqueueCommand(ctx, &application.InsertCommand{TableName: "users", Key: "k", Value: fmt.Sprint(i)}))
+	}
+	tenantCtx := domain.WithTenant(ctx, "acme")
+	assert.NoError(t, handler.EnqueueCommand(tenantCtx, &application.CreateTableCommand{TableName: "orders"}))
+	assert.NoError(t, handler.EnqueueCommand(tenantCtx, &application.InsertCommand{TableName: "orders", Key: "o1", Value: "x"}))
+	handler.Wait()
+
+	assert.Equal(t, 0, handler.OutboxPending())
+	val, err := handler.DB().Get("users", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "19", val)
+	val, err = handler.DB().GetContext(tenantCtx, "orders", "o1")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", val)
+	assert.NoError(t, handler.CloseOutbox())
+}
+
+func TestOutboxRejectsUnregisteredCommands(t *testing.T) {
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	_, err := handler.EnableOutbox(filepath.Join(t.TempDir(), "outbox.db"))
+	assert.NoError(t, err)
+	defer handler.CloseOutbox()
+
+	type unregistered struct{ application.SyncCommand }
+	err = handler.EnqueueCommand(context.Background(), &unregistered{})
+	assert.ErrorContains(t, err, "not registered")
+}
+
+func TestOutboxReexecutesCommandsAfterCrash(t *testing.T) {
+	outboxGate = make(chan struct{})
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	_, err := handler.EnableOutbox(path)
+	assert.NoError(t, err)
+
+	// 워커가 첫 명령에서 멈춘 동안 수락된 명령은 아웃박스에만 있음
+	ctx := context.Background()
+	assert.NoError(t, handler.EnqueueCommand(ctx, &gateCommand{Name: "gate"}))
+	assert.NoError(t, handler.EnqueueCommand(ctx, &application.CreateTableCommand{TableName: "users"}))
+	assert.NoError(t, handler.EnqueueCommand(ctx, &application.InsertCommand{TableName: "users", Key: "k", Value: "first"}))
+	assert.NoError(t, handler.EnqueueCommand(ctx, &application.InsertCommand{TableName: "users", Key: "k", Value: "second"}))
+	assert.Equal(t, 4, handler.OutboxPending())
+	image := crashImage(t, path)
+	close(outboxGate)
+	assert.NoError(t, handler.CloseOutbox())
+
+	restarted, cleanup2 := setupCommandTest(t)
+	defer cleanup2()
+	pending, err := restarted.EnableOutbox(image)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, pending)
+	restarted.Wait()
+	val, err := restarted.DB().Get("users", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", val)
+	assert.Equal(t, 0, restarted.OutboxPending())
+	assert.NoError(t, restarted.CloseOutbox())
+
+	// 실행이 끝난 명령은 지워져 다시 열어도 남지 않음
+	pending, err = restarted.EnableOutbox(image)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+	assert.NoError(t, restarted.CloseOutbox())
+}
+
+func TestOutboxDropsCommandsWithInvalidKeys(t *testing.T) {
+	outboxGate = make(chan struct{})
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	handler, cleanup := setupCommandTest(t)
+	defer cleanup()
+	_, err := handler.EnableOutbox(path)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	assert.NoError(t, handler.EnqueueCommand(ctx, &gateCommand{Name: "gate"}))
+	assert.NoError(t, handler.EnqueueCommand(ctx, &application.CreateTableCommand{TableName: "users"}))
+	image := crashImage(t, path)
+	close(outboxGate)
+	assert.NoError(t, handler.CloseOutbox())
+
+	// 읽을 수 있는 기록이라도 시퀀스가 아닌 키에 있으면 실행 후 지울 수 없으므로 버려야 함
+	store, err := file.NewFile(file.FileConfig{FilePath: image, ThreadSafe: true, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	record, err := store.Get("00000000000000000002")
+	assert.NoError(t, err)
+	assert.NoError(t, store.Insert("bogus", record))
+	assert.NoError(t, store.Close())
+
+	restarted, cleanup2 := setupCommandTest(t)
+	defer cleanup2()
+	pending, err := restarted.EnableOutbox(image)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pending)
+	restarted.Wait()
+	assert.NoError(t, restarted.CloseOutbox())
+	pending, err = restarted.EnableOutbox(image)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+	assert.NoError(t, restarted.CloseOutbox())
+}
@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+)
+
+func TestScanPageQueryPagesWithTokens(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, db.Insert("users", fmt.Sprintf("k%d", i), fmt.Sprintf("v%d", i)))
+	}
+	assert.NoError(t, db.Insert("users", "other", "x"))
+	handler := application.NewQueryHandler(db, &mockLogger{})
+	ctx := context.Background()
+
+	var keys []string
+	token := ""
+	pages := 0
+	for {
+		result, err := handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Prefix: "k", Limit: 3, Token: token})
+		assert.NoError(t, err)
+		page := result.(*application.ScanPage)
+		pages++
+		for _, e := range page.Entries {
+			keys = append(keys, e.Key)
+		}
+		token = page.NextToken
+		if token == "" || pages > 5 {
+			break
+		}
+	}
+	assert.Equal(t, 3, pages, "the last page has no next token")
+	assert.Equal(t, []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6"}, keys)
+
+	// 다음 페이지 사이에 지워진 키가 있어도 이어서 읽음
+	result, err := handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Prefix: "k", Limit: 2})
+	assert.NoError(t, err)
+	first := result.(*application.ScanPage)
+	assert.NoError(t, db.Delete("users", "k1"))
+	result, err = handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Prefix: "k", Limit: 2, Token: first.NextToken})
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", result.(*application.ScanPage).Entries[0].Key)
+
+	// 다른 접두어나 깨진 토큰은 거절
+	_, err = handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Prefix: "o", Token: first.NextToken})
+	assert.ErrorIs(t, err, application.ErrInvalidPageToken)
+	_, err = handler.ExecuteQuery(ctx, &application.ScanPageQuery{TableName: "users", Token: "!!"})
+	assert.ErrorIs(t, err, application.ErrInvalidPageToken)
+}
@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestBtreeHeaderByteOrderMark(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.db")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	defer f.Close()
+	config := btree.BtConfig{Degree: 4, PageSize: 512, ThreadSafe: true}
+	bt, err := btree.OpenBtree(f, config)
+	assert.NoError(t, err)
+	assert.NoError(t, bt.Insert("k", "v"))
+	assert.NoError(t, bt.Close())
+
+	// 헤더는 리틀 엔디안: rootOffset(i64) length(i32) flags(u32) mark(u32)
+	header := make([]byte, 20)
+	_, err = f.ReadAt(header, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(bt.GetRootOffset()), binary.LittleEndian.Uint64(header))
+	assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(header[8:]))
+	assert.Equal(t, []byte{4, 3, 2, 1}, header[16:20])
+
+	// 표시가 없는 이전 헤더는 그대로 열림
+	_, err = f.WriteAt([]byte{0, 0, 0, 0}, 16)
+	assert.NoError(t, err)
+	bt, err = btree.OpenBtree(f, config)
+	assert.NoError(t, err)
+	val, err := bt.Get("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+	assert.NoError(t, bt.Close())
+
+	// 다른 바이트 순서의 헤더는 거부되고 덮어쓰이지 않음
+	_, err = f.WriteAt([]byte{1, 2, 3, 4}, 16)
+	assert.NoError(t, err)
+	_, err = btree.OpenBtree(f, config)
+	assert.ErrorIs(t, err, btree.ErrByteOrder)
+	_, err = f.ReadAt(header, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4}, header[16:20])
+	assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(header[8:]))
+}
+
+// portableEntries is the data every engine writes for the portability round trip.
+func portableEntries() map[string]string {
+	entries := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		entries[fmt.Sprintf("key-%03d", i)] = fmt.Sprintf("value-%d-%x", i, i*7919)
+	}
+	return entries
+}
+
+// writePortableFiles writes portableEntries with the B-tree database, the file adapter and
+// the LSM tree under dir.
+func writePortableFiles(t *testing.T, dir string) {
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "lsm"), 0755))
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:     "portable",
+		FilePath: filepath.Join(dir, "btree.db"),
+		BtConfig: btree.BtConfig{Degree: 8, PageSize: 4096, ThreadSafe: true, Checksums: true},
+	}, &mockLogger{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("t"))
+
+	f, err := file.NewFile(file.FileConfig{FilePath: filepath.Join(dir, "file.db"), ThreadSafe: true})
+	assert.NoError(t, err)
+
+	config := lsmtree.DefaultConfig()
+	config.FilePath = filepath.Join(dir, "lsm")
+	config.CompressionType = "snappy"
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+
+	for k, v := range portableEntries() {
+		assert.NoError(t, db.Insert("t", k, v))
+		assert.NoError(t, f.Insert(k, v))
+		assert.NoError(t, lsm.Insert(k, v))
+	}
+	assert.NoError(t, db.Close())
+	assert.NoError(t, f.Persist())
+	assert.NoError(t, f.Close())
+	assert.NoError(t, lsm.Close())
+}
+
+// checkPortableFiles opens a copy of the files writePortableFiles left in dir.
+func checkPortableFiles(t *testing.T, dir string) {
+	work := t.TempDir()
+	assert.NoError(t, copyTree(dir, work))
+
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:     "portable",
+		FilePath: filepath.Join(work, "btree.db"),
+		BtConfig: btree.BtConfig{Degree: 8, PageSize: 4096, ThreadSafe: true, Checksums: true},
+	}, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+	f, err := file.NewFile(file.FileConfig{FilePath: filepath.Join(work, "file.db"), ThreadSafe: true})
+	assert.NoError(t, err)
+	defer f.Close()
+	config := lsmtree.DefaultConfig()
+	config.FilePath = filepath.Join(work, "lsm")
+	config.CompressionType = "snappy"
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+
+	for k, v := range portableEntries() {
+		got, err := db.Get("t", k)
+		assert.NoError(t, err, "btree %s", k)
+		assert.Equal(t, v, got)
+		fv, err := f.Get(k)
+		assert.NoError(t, err, "file %s", k)
+		assert.Equal(t, v, fv)
+		lv, err := lsm.Get(k)
+		assert.NoError(t, err, "lsm %s", k)
+		assert.Equal(t, v, lv)
+	}
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
+
+// TestFormatsPortableAcrossBuilds writes files with every engine into GOLITE_PORTABILITY_DIR/<GOARCH>
+// and reads back the files of every architecture found there, so running it once per GOARCH
+// (make portability) round-trips files between builds. Without the variable it only checks
+// this build's files.
+func TestFormatsPortableAcrossBuilds(t *testing.T) {
+	root := os.Getenv("GOLITE_PORTABILITY_DIR")
+	if root == "" {
+		root = t.TempDir()
+	}
+	own := filepath.Join(root, runtime.GOARCH)
+	assert.NoError(t, os.RemoveAll(own))
+	writePortableFiles(t, own)
+
+	archs, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	for _, arch := range archs {
+		if arch.IsDir() {
+			t.Run(arch.Name(), func(t *testing.T) {
+				checkPortableFiles(t, filepath.Join(root, arch.Name()))
+			})
+		}
+	}
+}
@@ -2,13 +2,18 @@ package unit
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
 	"github.com/sukryu/GoLite/pkg/application"
 	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/utils"
 )
 
 func setupQueryTest(t *testing.T) (*application.QueryHandler, func()) {
@@ -86,3 +91,57 @@ func TestQueryHandler_AsyncExecution(t *testing.T) {
 	assert.Equal(t, "Alice", res.Result, "Queried value should match")
 	handler.Wait()
 }
+
+func TestGetStatusQueryReportsStorage(t *testing.T) {
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:       "status",
+		FilePath:   filepath.Join(t.TempDir(), "status.db"),
+		BtConfig:   btree.BtConfig{Degree: 2, PageSize: 4096, ThreadSafe: true, CacheSize: 64},
+		ThreadSafe: true,
+	}, &mockLogger{})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.CreateTable("orders"))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, db.Insert("users", fmt.Sprintf("u%02d", i), "v"))
+	}
+	assert.NoError(t, db.Insert("orders", "o1", "v"))
+	for i := 0; i < 20; i++ {
+		_, err := db.Get("users", "u00")
+		assert.NoError(t, err)
+	}
+
+	handler := application.NewQueryHandler(db, &mockLogger{})
+	result, err := handler.ExecuteQuery(context.Background(), &application.GetStatusQuery{})
+	assert.NoError(t, err)
+	storage := result.(domain.DatabaseStatus).Storage
+	assert.Equal(t, map[string]int64{"users": 20, "orders": 1}, storage.TableKeys)
+	assert.Greater(t, storage.DiskBytes, int64(0))
+	assert.Greater(t, storage.CacheHits, int64(0))
+	assert.Greater(t, storage.CacheHitRatio, 0.0)
+	assert.LessOrEqual(t, storage.CacheHitRatio, 1.0)
+	assert.True(t, storage.LastCompaction.IsZero(), "the B-tree is never compacted")
+}
+
+func TestStorageStatusOfFileAndLSM(t *testing.T) {
+	f, err := file.NewFile(file.FileConfig{FilePath: filepath.Join(t.TempDir(), "status.file"), ThreadSafe: true, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.True(t, f.StorageStatus().LastCompaction.IsZero())
+	assert.NoError(t, f.Insert("k", "v"))
+	assert.NoError(t, f.CompactContext(context.Background()))
+	assert.False(t, f.StorageStatus().LastCompaction.IsZero())
+	assert.Greater(t, f.DiskSize(), int64(0))
+
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.SyncWrites = true
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+	assert.NoError(t, lsm.Insert("k", "v"))
+	assert.True(t, lsm.StorageStatus().LastCompaction.IsZero())
+	assert.Greater(t, lsm.RecoveryDebt(), int64(0), "the insert is only in the WAL")
+	assert.GreaterOrEqual(t, lsm.DiskSize(), lsm.RecoveryDebt())
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestMaxKeysPerTable(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "quota.db"), func(c *domain.DatabaseConfig) { c.MaxKeysPerTable = 2 })
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("a"))
+	assert.NoError(t, db.CreateTable("b"))
+	assert.NoError(t, db.Insert("a", "k1", "v"))
+	assert.NoError(t, db.Insert("a", "k2", "v"))
+
+	err = db.Insert("a", "k3", "v")
+	assert.ErrorIs(t, err, domain.ErrTableFull)
+	assert.False(t, errors.Is(err, domain.ErrDatabaseFull))
+	var qerr *domain.QuotaError
+	if assert.True(t, errors.As(err, &qerr)) {
+		assert.Equal(t, domain.QuotaTableKeys, qerr.Quota)
+		assert.Equal(t, "a", qerr.Table)
+		assert.Equal(t, int64(2), qerr.Used)
+		assert.Equal(t, int64(2), qerr.Limit)
+	}
+
+	assert.NoError(t, db.Insert("a", "k1", "updated"), "existing keys can still be updated")
+	assert.NoError(t, db.Insert("b", "k1", "v"), "the quota is per table")
+	assert.NoError(t, db.Delete("a", "k2"))
+	assert.NoError(t, db.Insert("a", "k3", "v"), "deleting frees room")
+
+	usage := db.GetStatus().Quota
+	assert.Equal(t, int64(2), usage.MaxKeysPerTable)
+	assert.Equal(t, map[string]int64{"a": 2, "b": 1}, usage.TableKeys)
+}
+
+func TestMaxDatabaseBytes(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "quota.db"), func(c *domain.DatabaseConfig) { c.MaxDatabaseBytes = 64 << 10 })
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("t"))
+	value := strings.Repeat("x", 1000)
+	for i := 0; i < 1000 && err == nil; i++ {
+		err = db.Insert("t", fmt.Sprintf("key%04d", i), value)
+	}
+	assert.ErrorIs(t, err, domain.ErrDatabaseFull)
+
+	usage := db.GetStatus().Quota
+	assert.Equal(t, int64(64<<10), usage.MaxDatabaseBytes)
+	assert.GreaterOrEqual(t, usage.DatabaseBytes, usage.MaxDatabaseBytes)
+	assert.ErrorIs(t, db.Insert("t", "key0000", "v"), domain.ErrDatabaseFull, "overwrites are refused too")
+	assert.NoError(t, db.Delete("t", "key0000"), "deletes still work")
+
+	// 시스템 테이블(헬스 체크)은 쿼터에 걸리지 않음
+	_, err = db.HealthCheck(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestQuotaConfigRejectsNegative(t *testing.T) {
+	_, err := openBtreeDatabase(filepath.Join(t.TempDir(), "quota.db"), func(c *domain.DatabaseConfig) { c.MaxKeysPerTable = -1 })
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/replication"
+)
+
+// startRaftCluster는 인메모리 네트워크 위에 size개 노드의 클러스터를 시작합니다.
+func startRaftCluster(t *testing.T, size int) (*replication.InmemNetwork, []*replication.RaftNode, []*domain.Database) {
+	network := replication.NewInmemNetwork()
+	ids := make([]string, size)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("n%d", i+1)
+	}
+	dir := t.TempDir()
+	nodes := make([]*replication.RaftNode, size)
+	dbs := make([]*domain.Database, size)
+	for i, id := range ids {
+		db, err := openBtreeDatabase(filepath.Join(dir, id+".db"))
+		assert.NoError(t, err)
+		var peers []string
+		for _, other := range ids {
+			if other != id {
+				peers = append(peers, other)
+			}
+		}
+		node, err := replication.NewRaftNode(db, replication.RaftConfig{
+			ID:                id,
+			Peers:             peers,
+			Transport:         network.Transport(id),
+			ElectionTimeout:   100 * time.Millisecond,
+			HeartbeatInterval: 20 * time.Millisecond,
+			StatePath:         filepath.Join(dir, id+".raft"),
+		}, &mockLogger{})
+		assert.NoError(t, err)
+		network.Register(node)
+		nodes[i], dbs[i] = node, db
+	}
+	for _, node := range nodes {
+		node.Start()
+	}
+	t.Cleanup(func() {
+		for i := range nodes {
+			nodes[i].Close()
+			dbs[i].Close()
+		}
+	})
+	return network, nodes, dbs
+}
+
+// raftLeader는 클러스터에 리더가 선출될 때까지 기다린 뒤 그 인덱스를 반환합니다.
+func raftLeader(t *testing.T, nodes []*replication.RaftNode) int {
+	leader := -1
+	waitFor(t, func() bool {
+		for i, node := range nodes {
+			if node.IsLeader() {
+				leader = i
+				return true
+			}
+		}
+		return false
+	})
+	return leader
+}
+
+func TestRaftReplicatesCommands(t *testing.T) {
+	_, nodes, dbs := startRaftCluster(t, 3)
+	leader := raftLeader(t, nodes)
+
+	handler := application.NewCommandHandler(dbs[leader], &mockLogger{})
+	handler.SetProposer(nodes[leader])
+	ctx := context.Background()
+	assert.NoError(t, handler.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "users"}))
+	assert.NoError(t, handler.ExecuteCommand(ctx, &application.InsertCommand{TableName: "users", Key: "user1", Value: "Alice"}))
+	assert.Error(t, handler.ExecuteCommand(ctx, &application.InsertCommand{TableName: "missing", Key: "k", Value: "v"}))
+
+	for _, db := range dbs {
+		waitFor(t, func() bool {
+			v, err := db.Get("users", "user1")
+			return err == nil && v == "Alice"
+		})
+	}
+
+	follower := (leader + 1) % len(nodes)
+	err := nodes[follower].Propose(ctx, domain.Mutation{Op: domain.MutationInsert, Table: "users", Key: "k", Value: "v"})
+	assert.True(t, errors.Is(err, replication.ErrNotLeader))
+}
+
+func TestRaftLeaderFailover(t *testing.T) {
+	network, nodes, dbs := startRaftCluster(t, 3)
+	leader := raftLeader(t, nodes)
+	ctx := context.Background()
+	assert.NoError(t, nodes[leader].Propose(ctx, domain.Mutation{Op: domain.MutationCreateTable, Table: "users"}))
+
+	// 리더를 분리하면 남은 두 노드가 새 리더를 선출하고 계속 커밋할 수 있어야 함.
+	network.Disconnect(nodes[leader].ID())
+	var rest []*replication.RaftNode
+	for i, node := range nodes {
+		if i != leader {
+			rest = append(rest, node)
+		}
+	}
+	newLeader := rest[raftLeader(t, rest)]
+	assert.NotEqual(t, nodes[leader].ID(), newLeader.ID())
+	proposeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	assert.NoError(t, newLeader.Propose(proposeCtx, domain.Mutation{Op: domain.MutationInsert, Table: "users", Key: "user1", Value: "Alice"}))
+
+	// 재연결된 이전 리더는 팔로워로 돌아와 누락된 변경을 따라잡아야 함.
+	network.Reconnect(nodes[leader].ID())
+	waitFor(t, func() bool {
+		v, err := dbs[leader].Get("users", "user1")
+		return err == nil && v == "Alice" && !nodes[leader].IsLeader()
+	})
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+func TestExplainReportsBtreePagesPerRead(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "amp.db"), func(c *domain.DatabaseConfig) { c.ReadAmplification = true })
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("users"))
+	for i := 0; i < 200; i++ {
+		assert.NoError(t, db.Insert("users", fmt.Sprintf("user%03d", i), "v"))
+	}
+
+	// 차수 2의 트리에 200개 키이므로 조회는 루트부터 여러 페이지를 거침
+	ex, err := db.ExplainGet("users", "user150")
+	assert.NoError(t, err)
+	assert.True(t, ex.Measured)
+	assert.Equal(t, "get", ex.Op)
+	assert.Equal(t, 1, ex.Keys)
+	assert.Contains(t, ex.Adapter, "btree")
+	assert.GreaterOrEqual(t, ex.Cost.Pages, int64(3))
+	assert.LessOrEqual(t, ex.Cost.PageReads, ex.Cost.Pages)
+
+	missing, err := db.ExplainGet("users", "nobody")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, missing.Keys)
+	_, err = db.ExplainGet("absent", "k")
+	assert.Error(t, err)
+
+	// 좁은 범위 스캔은 전체 스캔보다 적은 페이지를 읽음
+	narrow, err := db.ExplainScan("users", "user15")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, narrow.Keys)
+	full, err := db.ExplainScan("users", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, full.Keys)
+	assert.Less(t, narrow.Cost.Pages, full.Cost.Pages)
+
+	// Explain은 누계에 들어가지 않고, 일반 읽기만 누적됨
+	_, _ = db.Get("users", "user001")
+	_, _ = db.Get("users", "nobody")
+	assert.NoError(t, db.Scan("users", "user01", func(_, _ string) bool { return true }))
+	amp := db.Stats()["read_amplification"].(map[string]domain.ReadAmplification)
+	assert.Equal(t, int64(2), amp["get"].Reads)
+	assert.Equal(t, int64(1), amp["get"].Keys)
+	assert.Greater(t, amp["get"].PerRead(amp["get"].Cost.Pages), 1.0)
+	assert.Equal(t, int64(1), amp["scan"].Reads)
+	assert.Equal(t, int64(10), amp["scan"].Keys)
+}
+
+func TestLSMTreeReadCostCountsBloomSkipsAndBlocks(t *testing.T) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = t.TempDir()
+	config.MemTableSize = 512 // 여러 SSTable로 flush되도록 작게 설정
+	config.CompactionInterval = time.Hour
+	config.Level0CompactionTrigger = 100
+	lsm, err := lsmtree.NewLSMTree(config)
+	assert.NoError(t, err)
+	defer lsm.Close()
+	for i := 0; i < 120; i++ {
+		assert.NoError(t, lsm.Insert(fmt.Sprintf("key%03d", i*2), "value"))
+	}
+	levels := lsm.Stats()["levels"].([]int)
+	assert.GreaterOrEqual(t, levels[0], 2)
+
+	// 가장 오래된 키는 최신 level0 테이블부터 차례로 확인한 뒤에 찾음
+	var cost ports.ReadCost
+	value, err := lsm.GetCost("key000", &cost)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, int64(1), cost.Blocks)
+	assert.GreaterOrEqual(t, cost.SSTables+cost.BloomSkips, int64(1))
+
+	// 홀수 키는 어느 테이블에도 없으므로 블록을 읽지 않고 블룸 필터로 대부분 건너뜀
+	var miss ports.ReadCost
+	_, err = lsm.GetCost("key001", &miss)
+	assert.ErrorIs(t, err, lsmtree.ErrKeyNotFound)
+	assert.GreaterOrEqual(t, miss.BloomSkips, int64(1))
+	assert.LessOrEqual(t, miss.Blocks, miss.SSTables)
+
+	it, err := lsm.Scan("key000", "key100")
+	assert.NoError(t, err)
+	defer it.Close()
+	for it.Next() {
+	}
+	assert.NoError(t, it.Err())
+	scan := it.ReadCost()
+	assert.Equal(t, int64(levels[0]), scan.SSTables)
+	assert.GreaterOrEqual(t, scan.Blocks, int64(1))
+
+	amp := lsm.Stats()["read_amplification"].(map[string]interface{})
+	assert.Equal(t, int64(2), amp["gets"])
+}
+
+func TestFileReadCostAcceptsNilCost(t *testing.T) {
+	f, err := file.NewFile(file.FileConfig{FilePath: filepath.Join(t.TempDir(), "cost.db"), ThreadSafe: true})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, f.Insert("k", "v"))
+
+	// nil cost는 세지 않는 일반 읽기
+	value, err := f.GetCost("k", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.NoError(t, f.ScanCost("", func(string, interface{}) bool { return true }, nil))
+
+	var cost ports.ReadCost
+	_, err = f.GetCost("k", &cost)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), cost.CacheHits)
+}
@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/repair"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+// checksummed는 fsck가 손상된 페이지를 찾을 수 있도록 페이지 체크섬을 켭니다.
+func checksummed(c *domain.DatabaseConfig) {
+	c.BtConfig.Checksums = true
+}
+
+// writeRepairDatabase는 두 테이블에 n개씩 키를 넣고 닫은 데이터베이스를 만듭니다.
+func writeRepairDatabase(t *testing.T, n int) string {
+	path := filepath.Join(t.TempDir(), "repair.db")
+	db, err := openBtreeDatabase(path, checksummed)
+	assert.NoError(t, err)
+	for _, table := range []string{"users", "orders"} {
+		assert.NoError(t, db.CreateTable(table))
+		for i := 0; i < n; i++ {
+			assert.NoError(t, db.Insert(table, fmt.Sprintf("key%03d", i), fmt.Sprintf("value%d", i)))
+		}
+	}
+	assert.NoError(t, db.Close())
+	return path
+}
+
+func TestRepairCheckIntactDatabase(t *testing.T) {
+	path := writeRepairDatabase(t, 50)
+
+	report, err := repair.Check(path, repair.Options{})
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Equal(t, repair.FormatBtree, report.Format)
+	assert.Equal(t, 2, report.Tables)
+	assert.Equal(t, 100, report.Records)
+	assert.Greater(t, report.Pages, 2)
+
+	// 열려 있는 데이터베이스는 점검하지 않음
+	db, err := openBtreeDatabase(path, checksummed)
+	assert.NoError(t, err)
+	_, err = repair.Check(path, repair.Options{})
+	assert.ErrorIs(t, err, utils.ErrLocked)
+	assert.NoError(t, db.Close())
+}
+
+func TestRepairSalvagesReadableRecords(t *testing.T) {
+	path := writeRepairDatabase(t, 50)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	// 노드 페이지 하나를 손상시킴 (page 0, 1은 헤더)
+	data[3*4096+20] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+
+	out := filepath.Join(t.TempDir(), "salvaged.db")
+	report, err := repair.Check(path, repair.Options{Salvage: out})
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Contains(t, fmt.Sprint(report.Problems), "checksum mismatch")
+	assert.Equal(t, report.Records, report.Salvaged)
+	assert.Greater(t, report.Salvaged, 0)
+	assert.Less(t, report.Salvaged, 100)
+
+	// 살린 파일은 점검을 통과하고 그대로 열림
+	salvaged, err := repair.Check(out, repair.Options{})
+	assert.NoError(t, err)
+	assert.True(t, salvaged.OK(), "%v", salvaged.Problems)
+	assert.Equal(t, report.Salvaged, salvaged.Records)
+
+	db, err := openBtreeDatabase(out, checksummed)
+	assert.NoError(t, err)
+	defer db.Close()
+	found := 0
+	for _, table := range []string{"users", "orders"} {
+		for i := 0; i < 50; i++ {
+			if value, err := db.Get(table, fmt.Sprintf("key%03d", i)); err == nil {
+				assert.Equal(t, fmt.Sprintf("value%d", i), value)
+				found++
+			}
+		}
+	}
+	assert.Equal(t, report.Salvaged, found)
+
+	_, err = repair.Check(path, repair.Options{Salvage: out})
+	assert.Error(t, err, "existing salvage target must not be overwritten")
+}
+
+func TestRepairDetectsDanglingTableRoot(t *testing.T) {
+	path := writeRepairDatabase(t, 5)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	header, err := domain.ReadHeader(data[4096:8192])
+	assert.NoError(t, err)
+	tables := header.Tables
+	for i := range tables {
+		if tables[i].Name == "orders" {
+			tables[i].Root = 1 << 30 // 파일 밖
+		}
+	}
+	page, err := header.WithRoots(tables)
+	assert.NoError(t, err)
+	copy(data[4096:], page)
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+
+	report, err := repair.Check(path, repair.Options{})
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Contains(t, fmt.Sprint(report.Problems), `table "orders"`)
+	assert.Equal(t, 5, report.Records)
+	assert.Greater(t, report.Orphaned, 0)
+}
+
+func TestRepairChecksFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repair.db")
+	f, err := file.NewFile(file.FileConfig{FilePath: path, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	assert.NoError(t, f.Insert("a", "1"))
+	assert.NoError(t, f.Insert("b", "2"))
+	assert.NoError(t, f.Delete("a"))
+	assert.NoError(t, f.Close())
+
+	report, err := repair.Check(path, repair.Options{})
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Equal(t, repair.FormatFile, report.Format)
+	assert.Equal(t, 1, report.Records)
+
+	// 잘린 마지막 레코드는 경고, 체크섬이 맞지 않는 레코드는 문제
+	wal, err := os.OpenFile(path+".wal", os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	torn := glb3Record(10, file.OpInsert, "c", "3")
+	_, err = wal.Write(torn[:len(torn)-1])
+	assert.NoError(t, err)
+	report, err = repair.Check(path, repair.Options{})
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Len(t, report.Warnings, 1)
+
+	assert.NoError(t, wal.Truncate(4))
+	corrupt := glb3Record(11, file.OpInsert, "c", "3")
+	corrupt[len(corrupt)-1] ^= 0xff
+	_, err = wal.Write(append(corrupt, glb3Record(12, file.OpInsert, "defg", "x")...))
+	assert.NoError(t, err)
+	assert.NoError(t, wal.Close())
+	out := filepath.Join(t.TempDir(), "salvaged.db")
+	report, err = repair.Check(path, repair.Options{Salvage: out})
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Contains(t, fmt.Sprint(report.Problems), "checksum mismatch")
+	assert.Equal(t, 2, report.Salvaged) // b, 그리고 손상된 레코드 뒤의 defg
+
+	salvaged, err := file.NewFile(file.FileConfig{FilePath: out, Logger: &utils.SilentLogger{}})
+	assert.NoError(t, err)
+	defer salvaged.Close()
+	value, err := salvaged.Get("defg")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", value)
+}
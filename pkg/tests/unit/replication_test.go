@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/replication"
+)
+
+// testDatabaseConfig는 filePath에 둘 테스트용 스레드 안전 B-tree 데이터베이스의 설정입니다. 각 opt는
+// 테스트가 다루는 필드만 바꿉니다.
+func testDatabaseConfig(filePath string, opts ...func(*domain.DatabaseConfig)) domain.DatabaseConfig {
+	config := domain.DatabaseConfig{
+		Name:       "test",
+		FilePath:   filePath,
+		BtConfig:   btree.BtConfig{Degree: 2, PageSize: 4096, ThreadSafe: true},
+		MaxTables:  10,
+		ThreadSafe: true,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// openBtreeDatabase는 testDatabaseConfig(filePath, opts...)로 테스트용 데이터베이스를 엽니다.
+func openBtreeDatabase(filePath string, opts ...func(*domain.DatabaseConfig)) (*domain.Database, error) {
+	return domain.NewDatabase(testDatabaseConfig(filePath, opts...), &mockLogger{})
+}
+
+// waitFor는 조건이 참이 될 때까지 기다립니다.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before timeout")
+}
+
+func TestPrimaryReplicaReplication(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+
+	primary, err := replication.NewPrimary(db, replication.PrimaryConfig{Addr: "127.0.0.1:0", SnapshotDir: t.TempDir()}, &mockLogger{})
+	assert.NoError(t, err)
+	assert.NoError(t, primary.Start())
+	defer primary.Close()
+
+	replica, err := replication.NewReplica(replication.ReplicaConfig{
+		PrimaryAddr:   primary.Addr(),
+		FilePath:      filepath.Join(t.TempDir(), "replica.db"),
+		RetryInterval: 50 * time.Millisecond,
+	}, func(filePath string) (*domain.Database, error) { return openBtreeDatabase(filePath) }, &mockLogger{})
+	assert.NoError(t, err)
+	replica.Start()
+	defer replica.Close()
+
+	// 스냅샷을 통한 초기 동기화.
+	waitFor(t, func() bool {
+		value, err := replica.DB().Get("users", "user1")
+		return err == nil && value == "Alice"
+	})
+
+	// 스냅샷 이후의 변경은 스트리밍으로 전달됩니다.
+	assert.NoError(t, db.InsertWithAck("users", "user2", "Bob", domain.WriteAck{Level: domain.AckFollowers, Followers: 1, Timeout: 5 * time.Second}))
+	value, err := replica.DB().Get("users", "user2")
+	assert.NoError(t, err, "acked write must be visible on the replica")
+	assert.Equal(t, "Bob", value)
+
+	assert.NoError(t, db.Delete("users", "user1"))
+	waitFor(t, func() bool {
+		_, err := replica.DB().Get("users", "user1")
+		return err != nil
+	})
+	assert.Equal(t, db.Seq(), replica.LastSeq())
+}
+
+// failingOpen은 호출 순번(1부터)을 받은 fail이 참을 돌려주는 호출에서만 실패하는 OpenFunc입니다.
+func failingOpen(calls *atomic.Int32, fail func(call int32) bool) replication.OpenFunc {
+	return func(filePath string) (*domain.Database, error) {
+		if fail(calls.Add(1)) {
+			return nil, errors.New("open failed")
+		}
+		return openBtreeDatabase(filePath)
+	}
+}
+
+func TestReplicaKeepsDatabaseWhenSnapshotFailsToOpen(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	primary, err := replication.NewPrimary(db, replication.PrimaryConfig{Addr: "127.0.0.1:0", SnapshotDir: t.TempDir()}, &mockLogger{})
+	assert.NoError(t, err)
+	assert.NoError(t, primary.Start())
+	defer primary.Close()
+
+	path := filepath.Join(t.TempDir(), "replica.db")
+	local, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, local.CreateTable("local"))
+	assert.NoError(t, local.Insert("local", "key", "old"))
+	assert.NoError(t, local.Close())
+
+	// 두 번째 호출(스냅샷을 연 데이터베이스)만 실패: 세 번째 호출이 이전 파일을 다시 엶
+	var calls atomic.Int32
+	replica, err := replication.NewReplica(replication.ReplicaConfig{
+		PrimaryAddr:   primary.Addr(),
+		FilePath:      path,
+		RetryInterval: 300 * time.Millisecond,
+	}, failingOpen(&calls, func(call int32) bool { return call == 2 }), &mockLogger{})
+	assert.NoError(t, err)
+	replica.Start()
+	defer replica.Close()
+
+	waitFor(t, func() bool { return calls.Load() >= 3 })
+	value, err := replica.DB().Get("local", "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+	assert.NoError(t, replica.Err())
+
+	// 다시 연결하면 스냅샷이 설치되고 옆에 옮겨 둔 이전 파일은 지워짐
+	waitFor(t, func() bool {
+		value, err := replica.DB().Get("users", "user1")
+		return err == nil && value == "Alice"
+	})
+	_, err = os.Stat(path + ".previous")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReplicaStopsWhenDatabaseCannotBeReopened(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+	primary, err := replication.NewPrimary(db, replication.PrimaryConfig{Addr: "127.0.0.1:0", SnapshotDir: t.TempDir()}, &mockLogger{})
+	assert.NoError(t, err)
+	assert.NoError(t, primary.Start())
+	defer primary.Close()
+
+	var calls atomic.Int32
+	replica, err := replication.NewReplica(replication.ReplicaConfig{
+		PrimaryAddr:   primary.Addr(),
+		FilePath:      filepath.Join(t.TempDir(), "replica.db"),
+		RetryInterval: 50 * time.Millisecond,
+	}, failingOpen(&calls, func(call int32) bool { return call > 1 }), &mockLogger{})
+	assert.NoError(t, err)
+	replica.Start()
+
+	waitFor(t, func() bool { return replica.Err() != nil })
+	assert.Nil(t, replica.DB())
+	assert.Equal(t, int32(3), calls.Load(), "a failed replica should not reconnect")
+	assert.NoError(t, replica.Close())
+}
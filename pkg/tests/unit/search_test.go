@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestSearchIndexMaintainedOnWrites(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+
+	assert.NoError(t, db.CreateTable("notes"))
+	assert.NoError(t, db.Insert("notes", "n1", "Buy milk and eggs"))
+	assert.NoError(t, db.CreateSearchIndex("notes", nil)) // 기존 행도 인덱싱
+	assert.NoError(t, db.Insert("notes", "n2", "Call the milkman"))
+	assert.NoError(t, db.Insert("notes", "n3", "eggs, flour"))
+
+	keys, err := db.Search("notes", "MILK")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1", "n2"}, keys) // 접두어 "milk"가 milkman도 찾음
+	keys, err = db.Search("notes", "eggs")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1", "n3"}, keys)
+
+	// 값이 바뀌거나 삭제되면 인덱스도 갱신됨
+	assert.NoError(t, db.Insert("notes", "n1", "Buy bread"))
+	assert.NoError(t, db.Delete("notes", "n3"))
+	keys, err = db.Search("notes", "eggs")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	keys, err = db.Search("notes", "bread")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1"}, keys)
+
+	// 배치 쓰기도 같은 경로로 인덱싱됨
+	assert.NoError(t, db.Write(domain.NewWriteBatch().Insert("notes", "n4", "bread crumbs")))
+	handler := application.NewQueryHandler(db, &mockLogger{})
+	result, err := handler.ExecuteQuery(context.Background(), &application.SearchQuery{TableName: "notes", Term: "bread"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1", "n4"}, result)
+
+	_, err = db.Search("missing", "x")
+	assert.Error(t, err)
+
+	// 테이블을 지우면 인덱스 테이블도 함께 지워짐
+	assert.NoError(t, db.DropTable("notes"))
+	assert.NotContains(t, db.GetSpec().Tables, domain.SearchIndexTable("notes"))
+}
+
+func TestSearchIndexCustomTokenizerAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search.db")
+	tags := func(value string) []string { return strings.Split(value, ",") }
+
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTableWithOptions("items", domain.TableOptions{Metadata: true}))
+	assert.NoError(t, db.CreateSearchIndex("items", tags))
+	assert.NoError(t, db.Insert("items", "a", "red,large"))
+	assert.NoError(t, db.Insert("items", "b", "blue,large"))
+	assert.NoError(t, db.Close())
+
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateSearchIndex("items", tags)) // 기존 인덱스 테이블에 연결
+	keys, err := db.Search("items", "large")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.NoError(t, db.Insert("items", "b", "blue,small"))
+	keys, err = db.Search("items", "large")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+}
@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/events"
+)
+
+func TestLatencySLOShedsLowestPriorityFirst(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "slo.db"), func(c *domain.DatabaseConfig) {
+		// 어떤 조회도 1ns 안에 끝나지 않으므로 창마다 목표를 넘음
+		c.LatencySLO = domain.LatencySLO{ReadP99: time.Nanosecond, Window: 20 * time.Millisecond, Sustain: 1, MinSamples: 1}
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	sub := db.Subscribe(events.LoadShedding)
+	defer sub.Close()
+	assert.NoError(t, db.CreateTable("t"))
+	assert.NoError(t, db.Insert("t", "k", "v"))
+
+	background := domain.WithPriority(context.Background(), domain.PriorityBackground)
+	critical := domain.WithPriority(context.Background(), domain.PriorityCritical)
+	assert.Equal(t, domain.PriorityNormal, domain.PriorityFrom(context.Background()))
+
+	// 위반이 이어지는 동안 단계가 올라 Background, 다음에 우선순위 없는 요청(Normal)이 거절됨
+	shedUntil := func(ctx context.Context) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := db.GetContext(ctx, "t", "k"); err != nil {
+				assert.ErrorIs(t, err, domain.ErrOverloaded)
+				return
+			}
+			db.Get("t", "k")
+		}
+		t.Fatal("request was never shed")
+	}
+	shedUntil(background)
+	select {
+	case e := <-sub.C:
+		assert.Equal(t, 1, e.Details["level"])
+		assert.Equal(t, "read", e.Details["op"])
+		assert.Equal(t, time.Nanosecond, e.Details["target"])
+	case <-time.After(time.Second):
+		t.Fatal("no load shedding event")
+	}
+	shedUntil(context.Background())
+	val, err := db.GetContext(critical, "t", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+	assert.NoError(t, db.InsertContext(critical, "t", "k2", "v2", domain.WriteAck{}))
+
+	stats := db.Stats()["latency_slo"].(map[string]interface{})
+	assert.GreaterOrEqual(t, stats["shed_level"], int(domain.PriorityNormal)+1)
+	assert.Greater(t, stats["shed_requests"], int64(1))
+	assert.Greater(t, stats["read_p99"], time.Duration(0))
+
+	// 요청 없이 지나간 창은 목표 안으로 세므로 단계가 모두 내려감
+	time.Sleep(300 * time.Millisecond)
+	_, err = db.GetContext(background, "t", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, db.Stats()["latency_slo"].(map[string]interface{})["shed_level"])
+}
+
+func TestLatencySLODisabledByDefault(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "noslo.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("t"))
+	background := domain.WithPriority(context.Background(), domain.PriorityBackground)
+	assert.NoError(t, db.Writer().InsertContext(background, "t", "k", "v", domain.WriteAck{}))
+	val, err := db.Reader().GetContext(background, "t", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", val)
+	assert.NotContains(t, db.Stats(), "latency_slo")
+
+	// 취소된 컨텍스트는 부하와 관계없이 거절됨
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = db.GetContext(ctx, "t", "k")
+	assert.ErrorIs(t, err, context.Canceled)
+}
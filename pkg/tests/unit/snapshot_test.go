@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestSnapshotAndRestoreFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "golite.db")
+	snapPath := filepath.Join(dir, "backup.db")
+
+	f, err := file.NewFile(file.FileConfig{FilePath: dbPath, ThreadSafe: true})
+	assert.NoError(t, err)
+	handle, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE, 0666)
+	assert.NoError(t, err)
+	db, err := domain.NewDatabaseWithStorage(domain.DatabaseConfig{Name: "testdb", FilePath: dbPath, ThreadSafe: true}, f, handle, &mockLogger{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	assert.NoError(t, db.Snapshot(snapPath), "Snapshot should succeed")
+	assert.NoError(t, db.Insert("users", "user2", "Bob"))
+	assert.Error(t, db.Snapshot(dbPath), "Snapshot onto the live file should fail")
+
+	assert.NoError(t, f.Close())
+	assert.NoError(t, db.Close())
+
+	assert.NoError(t, domain.RestoreFromSnapshot(snapPath, dbPath), "Restore should succeed")
+	restored, err := file.NewFile(file.FileConfig{FilePath: dbPath, ThreadSafe: true})
+	assert.NoError(t, err)
+	defer restored.Close()
+
+	value, err := restored.Get("users:user1")
+	assert.NoError(t, err, "snapshotted key should be restored")
+	assert.Equal(t, "Alice", value)
+	_, err = restored.Get("users:user2")
+	assert.Error(t, err, "writes after the snapshot should not be restored")
+}
+
+func TestSnapshotBtreeStorage(t *testing.T) {
+	db, cleanup := setupDatabaseTest(t)
+	defer cleanup()
+
+	snapPath := filepath.Join(t.TempDir(), "backup.db")
+	assert.NoError(t, db.CreateTable("users"))
+	assert.NoError(t, db.Insert("users", "user1", "Alice"))
+	assert.NoError(t, db.Snapshot(snapPath))
+
+	info, err := os.Stat(snapPath)
+	assert.NoError(t, err, "snapshot file should exist")
+	assert.True(t, info.Size() > 0, "snapshot should not be empty")
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"github.com/sukryu/GoLite/pkg/ports"
+)
+
+func TestSoftDeleteUndelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soft.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTableWithOptions("kv", domain.TableOptions{Metadata: true, SoftDelete: time.Hour}))
+	assert.NoError(t, db.InsertWithFlags("kv", "a", "1", 7))
+	_, before, err := db.GetWithMeta("kv", "a")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Delete("kv", "a"))
+
+	// 삭제된 키는 보이지 않고 카운트에서도 빠짐
+	_, err = db.Get("kv", "a")
+	assert.Error(t, err)
+	n, err := db.Count("kv")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	assert.NoError(t, db.Close())
+
+	// 유예 기간은 다시 열어도 이어지고, 되살리면 생성 시각과 플래그가 유지됨
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	opts, err := db.TableOptions("kv")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, opts.SoftDelete)
+	assert.NoError(t, db.Undelete("kv", "a"))
+	value, meta, err := db.GetWithMeta("kv", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+	assert.Equal(t, byte(7), meta.Flags)
+	assert.True(t, before.Created.Equal(meta.Created))
+	assert.ErrorIs(t, db.Undelete("kv", "a"), ports.ErrKeyNotFound, "the tombstone is gone once restored")
+
+	// 삭제된 키에 다시 쓰면 툼스톤이 사라져 이전 값으로 되돌릴 수 없음
+	assert.NoError(t, db.Delete("kv", "a"))
+	assert.NoError(t, db.Insert("kv", "a", "2"))
+	assert.ErrorIs(t, db.Undelete("kv", "a"), ports.ErrKeyNotFound)
+	_, err = db.Get(domain.TrashTable("kv"), "d:a")
+	assert.Error(t, err)
+}
+
+func TestSoftDeletePurge(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "soft.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	assert.Error(t, db.Undelete("kv", "a"), "soft delete is off")
+	assert.NoError(t, db.SetSoftDelete("kv", 200*time.Millisecond))
+	assert.NoError(t, db.Insert("kv", "a", "1"))
+	assert.NoError(t, db.Insert("kv", "b", "2"))
+	assert.NoError(t, db.Delete("kv", "a"))
+	time.Sleep(250 * time.Millisecond)
+	assert.NoError(t, db.Delete("kv", "b"))
+
+	// 유예 기간이 지난 툼스톤만 지워지고, 지난 키는 되살릴 수 없음
+	assert.ErrorIs(t, db.Undelete("kv", "a"), ports.ErrKeyNotFound)
+	purged, err := db.PurgeDeleted(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.NoError(t, db.Undelete("kv", "b"))
+
+	// 끄면 보조 테이블이 지워짐
+	assert.NoError(t, db.SetSoftDelete("kv", 0))
+	_, err = db.Count(domain.TrashTable("kv"))
+	assert.Error(t, err)
+	assert.NoError(t, db.Delete("kv", "b"))
+	assert.Error(t, db.Undelete("kv", "b"))
+}
+
+func TestSoftDeleteRejectsEncryptedTables(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "soft.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.Error(t, db.CreateTableWithOptions("kv", domain.TableOptions{Encrypted: true, SoftDelete: time.Hour}))
+	assert.Error(t, db.CreateTableWithOptions("kv", domain.TableOptions{SoftDelete: -time.Second}))
+}
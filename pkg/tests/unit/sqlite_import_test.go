@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/migrate/sqlite"
+)
+
+// testdata/sample.sqlite는 SQLite 3.40으로 만든 1KB 페이지 데이터베이스입니다:
+//
+//	users(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, age INTEGER, score REAL, avatar BLOB)
+//	  id 1..300 중 7을 삭제, name='user<id>' (42는 3000바이트로 오버플로 페이지 사용),
+//	  age=20+id%50 (id%7==0이면 NULL), score=id*1.5, avatar=[id%256,0,255] (id%3==0일 때만)
+//	"order items"([code] TEXT PRIMARY KEY, qty INT DEFAULT 1, big INTEGER) + ALTER TABLE ADD COLUMN note TEXT
+//	  ('A-1',3,-5), ('B-2',1,2^40), ('C-3',0,-2^63), ('D-4',2,0,'late')
+//	kv(k TEXT, v TEXT, PRIMARY KEY (k)) WITHOUT ROWID
+//	logs(msg TEXT, total INTEGER GENERATED ALWAYS AS (length(msg)) VIRTUAL, at INTEGER)
+//	  ('hello', 1), ('bye', 2)
+const sqliteFixture = "testdata/sample.sqlite"
+
+func TestSQLiteSchema(t *testing.T) {
+	src, err := sqlite.Open(sqliteFixture)
+	assert.NoError(t, err)
+	defer src.Close()
+
+	tables := src.Tables()
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	assert.Equal(t, []string{"users", "order items", "kv", "logs"}, names) // sqlite_sequence 제외
+
+	users := tables[0]
+	assert.Equal(t, []string{"id", "name", "age", "score", "avatar"}, users.Columns)
+	assert.Equal(t, "id", users.RowidAlias)
+	assert.Equal(t, "", users.PrimaryKey)
+	assert.Equal(t, "code", tables[1].PrimaryKey)
+	assert.Equal(t, []string{"code", "qty", "big", "note"}, tables[1].Columns)
+	assert.True(t, tables[2].WithoutRowid)
+	assert.Equal(t, []string{"msg", "at"}, tables[3].Columns)
+}
+
+func TestSQLiteImport(t *testing.T) {
+	src, err := sqlite.Open(sqliteFixture)
+	assert.NoError(t, err)
+	defer src.Close()
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "import.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	results, err := sqlite.Import(context.Background(), src, db, sqlite.ImportOptions{Prefix: "legacy_", BatchSize: 64})
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	assert.Equal(t, "legacy_users", results[0].Target)
+	assert.Equal(t, 299, results[0].Rows)
+	assert.Equal(t, 4, results[1].Rows)
+	assert.True(t, errors.Is(results[2].Skipped, sqlite.ErrUnsupported))
+	assert.Equal(t, 2, results[3].Rows)
+
+	row := func(table, key string) map[string]interface{} {
+		value, err := db.Get(table, key)
+		assert.NoError(t, err)
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(value), &m))
+		return m
+	}
+	assert.Equal(t, map[string]interface{}{"id": 3.0, "name": "user3", "age": 23.0, "score": 4.5, "avatar": "AwD/"}, row("legacy_users", "3"))
+	assert.Nil(t, row("legacy_users", "14")["age"])
+	assert.Equal(t, strings.Repeat("x", 3000), row("legacy_users", "42")["name"])
+	_, err = db.Get("legacy_users", "7")
+	assert.Error(t, err)
+
+	value, err := db.Get("legacy_order items", "C-3")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"code":"C-3","qty":0,"big":-9223372036854775808,"note":null}`, value)
+	assert.Equal(t, "late", row("legacy_order items", "D-4")["note"])
+	assert.Equal(t, map[string]interface{}{"msg": "bye", "at": 2.0}, row("legacy_logs", "2"))
+
+	_, err = sqlite.Import(context.Background(), src, db, sqlite.ImportOptions{Tables: []string{"missing"}})
+	assert.Error(t, err)
+}
+
+func TestSQLiteOpenRejectsOtherFiles(t *testing.T) {
+	_, err := sqlite.Open(filepath.Join(t.TempDir(), "missing.sqlite"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "golite.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+	_, err = sqlite.Open(path)
+	assert.ErrorContains(t, err, "not an SQLite database")
+}
@@ -0,0 +1,316 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/adapters/file"
+	"github.com/sukryu/GoLite/pkg/adapters/lsmtree"
+	"github.com/sukryu/GoLite/pkg/pagecrypt"
+	"github.com/sukryu/GoLite/pkg/ports"
+	"github.com/sukryu/GoLite/pkg/repair"
+	"github.com/sukryu/GoLite/pkg/utils"
+)
+
+var (
+	storageKey1 = bytes.Repeat([]byte{1}, pagecrypt.KeySize)
+	storageKey2 = bytes.Repeat([]byte{2}, pagecrypt.KeySize)
+)
+
+// fileContains는 파일에 평문 조각이 그대로 들어 있는지 확인합니다.
+func fileContains(t *testing.T, path, s string) bool {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return bytes.Contains(data, []byte(s))
+}
+
+func openEncryptedBtree(path string, key, previous []byte) (*btree.Btree, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, nil, err
+	}
+	bt, err := btree.OpenBtree(f, btree.BtConfig{Degree: 4, PageSize: 512, Checksums: true,
+		EncryptionKey: key, PreviousEncryptionKey: previous})
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return bt, f, nil
+}
+
+func TestBtreeEncryptionAtRest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	bt, f, err := openEncryptedBtree(path, storageKey1, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("secret-key-%02d", i), fmt.Sprintf("secret-value-%02d", i)))
+	}
+	assert.NoError(t, bt.Close())
+	f.Close()
+	assert.False(t, fileContains(t, path, "secret-"), "pages should not hold plaintext")
+
+	_, _, err = openEncryptedBtree(path, nil, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrKeyRequired), "%v", err)
+	_, _, err = openEncryptedBtree(path, storageKey2, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "%v", err)
+
+	bt, f, err = openEncryptedBtree(path, storageKey1, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, bt.Rekey(storageKey2))
+	value, err := bt.Get("secret-key-07")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-value-07", value)
+	assert.NoError(t, bt.Insert("after-rekey", "v"))
+	assert.NoError(t, bt.Close())
+	f.Close()
+
+	_, _, err = openEncryptedBtree(path, storageKey1, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "old key should no longer open the tree")
+	bt, f, err = openEncryptedBtree(path, storageKey2, nil)
+	assert.NoError(t, err)
+	defer f.Close()
+	defer bt.Close()
+	for i := 0; i < 50; i++ {
+		value, err := bt.Get(fmt.Sprintf("secret-key-%02d", i))
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("secret-value-%02d", i), value)
+	}
+	assert.Zero(t, bt.CorruptionCount())
+}
+
+func TestBtreeRekeyResumesWithBothKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	bt, f, err := openEncryptedBtree(path, storageKey1, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, bt.Insert(fmt.Sprintf("k%02d", i), "v"))
+	}
+	assert.NoError(t, bt.Close())
+	f.Close()
+
+	// 헤더가 아직 이전 키를 가리키는 파일을 두 키로 열면 열면서 키를 바꿈
+	bt, f, err = openEncryptedBtree(path, storageKey2, storageKey1)
+	assert.NoError(t, err)
+	assert.NoError(t, bt.Close())
+	f.Close()
+
+	_, _, err = openEncryptedBtree(path, storageKey1, nil)
+	assert.Error(t, err)
+	bt, f, err = openEncryptedBtree(path, storageKey2, nil)
+	assert.NoError(t, err)
+	defer f.Close()
+	defer bt.Close()
+	value, err := bt.Get("k42")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+}
+
+func TestBtreeKeyOnUnencryptedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.db")
+	bt, f, err := openEncryptedBtree(path, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, bt.Insert("k", "v"))
+	assert.True(t, errors.Is(bt.Rekey(storageKey1), pagecrypt.ErrNotEncrypted))
+	assert.NoError(t, bt.Close())
+	f.Close()
+
+	_, _, err = openEncryptedBtree(path, storageKey1, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrNotEncrypted), "%v", err)
+}
+
+func openEncryptedFile(path string, key, previous []byte) (*file.File, error) {
+	return file.NewFile(file.FileConfig{FilePath: path, Logger: &utils.SilentLogger{},
+		EncryptionKey: key, PreviousEncryptionKey: previous})
+}
+
+func TestFileEncryptionAtRest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	f, err := openEncryptedFile(path, storageKey1, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, f.Insert(fmt.Sprintf("secret-key-%02d", i), fmt.Sprintf("secret-value-%02d", i)))
+	}
+	assert.NoError(t, f.CompactContext(context.Background()))
+	assert.NoError(t, f.Insert("secret-wal-only", "secret-in-wal"))
+	assert.NoError(t, f.Delete("secret-key-03"))
+	assert.NoError(t, f.Close())
+	assert.False(t, fileContains(t, path, "secret-"), "main file should not hold plaintext")
+	assert.False(t, fileContains(t, path+".wal", "secret-"), "WAL should not hold plaintext")
+
+	_, err = openEncryptedFile(path, nil, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrKeyRequired), "%v", err)
+	_, err = openEncryptedFile(path, storageKey2, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "%v", err)
+	_, err = repair.Check(path, repair.Options{})
+	assert.True(t, errors.Is(err, pagecrypt.ErrKeyRequired), "%v", err)
+
+	f, err = openEncryptedFile(path, storageKey1, nil)
+	assert.NoError(t, err)
+	value, err := f.Get("secret-wal-only")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-in-wal", value)
+	_, err = f.Get("secret-key-03")
+	assert.Error(t, err)
+	assert.NoError(t, f.Rekey(storageKey2))
+	assert.NoError(t, f.Insert("after-rekey", "v"))
+	assert.NoError(t, f.Close())
+
+	_, err = openEncryptedFile(path, storageKey1, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "old key should no longer open the storage")
+	f, err = openEncryptedFile(path, storageKey2, nil)
+	assert.NoError(t, err)
+	defer f.Close()
+	for _, key := range []string{"secret-key-19", "secret-wal-only", "after-rekey"} {
+		_, err := f.Get(key)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestFileTornEncryptedWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	f, err := openEncryptedFile(path, storageKey1, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Insert("a", "1"))
+	assert.NoError(t, f.Insert("b", "2"))
+	assert.NoError(t, f.Close())
+
+	// 마지막 프레임의 일부만 기록된 채 멈춘 WAL
+	wal, err := os.ReadFile(path + ".wal")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path+".wal", append(wal, 0, 0, 1, 0, 9, 9), 0666))
+
+	f, err = openEncryptedFile(path, storageKey1, nil)
+	assert.NoError(t, err)
+	defer f.Close()
+	value, err := f.Get("b")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+	assert.NoError(t, f.Insert("c", "3"))
+}
+
+func TestFileKeyOnUnencryptedStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.db")
+	f, err := openEncryptedFile(path, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Insert("k", "v"))
+	assert.True(t, errors.Is(f.Rekey(storageKey1), pagecrypt.ErrNotEncrypted))
+	assert.NoError(t, f.Close())
+
+	_, err = openEncryptedFile(path, storageKey1, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrNotEncrypted), "%v", err)
+}
+
+func openEncryptedLSM(dir string, key, previous []byte) (*lsmtree.LSMTree, error) {
+	config := lsmtree.DefaultConfig()
+	config.FilePath = dir
+	config.Durability = ports.DurabilityFsyncPerCommit
+	config.CompactionInterval = time.Hour
+	config.EncryptionKey = key
+	config.PreviousEncryptionKey = previous
+	return lsmtree.NewLSMTree(config)
+}
+
+// copyLSMDir는 열려 있는 LSM 디렉터리의 파일을 복사해 장애 직후의 상태를 만듭니다.
+func copyLSMDir(t *testing.T, dir string) string {
+	image := t.TempDir()
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(image, e.Name()), data, 0644))
+	}
+	return image
+}
+
+func TestLSMEncryptionAtRest(t *testing.T) {
+	dir := t.TempDir()
+	lsm, err := openEncryptedLSM(dir, storageKey1, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, lsm.Insert(fmt.Sprintf("secret-key-%02d", i), fmt.Sprintf("secret-value-%02d", i)))
+	}
+	assert.False(t, fileContains(t, filepath.Join(dir, "db.wal"), "secret-"), "WAL should not hold plaintext")
+
+	// WAL만으로 복구
+	recovered, err := openEncryptedLSM(copyLSMDir(t, dir), storageKey1, nil)
+	assert.NoError(t, err)
+	value, err := recovered.Get("secret-key-11")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-value-11", value)
+	assert.NoError(t, recovered.Close())
+
+	assert.NoError(t, lsm.Close())
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, e := range entries {
+		assert.False(t, fileContains(t, filepath.Join(dir, e.Name()), "secret-"), e.Name())
+	}
+
+	_, err = openEncryptedLSM(dir, nil, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrKeyRequired), "%v", err)
+	_, err = openEncryptedLSM(dir, storageKey2, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "%v", err)
+
+	lsm, err = openEncryptedLSM(dir, storageKey1, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, lsm.Insert("after-open", "v"))
+	assert.NoError(t, lsm.Rekey(storageKey2))
+	assert.NoError(t, lsm.Insert("after-rekey", "v"))
+	assert.NoError(t, lsm.Close())
+
+	_, err = openEncryptedLSM(dir, storageKey1, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrWrongKey), "old key should no longer open the tree")
+	lsm, err = openEncryptedLSM(dir, storageKey2, nil)
+	assert.NoError(t, err)
+	defer lsm.Close()
+	for _, key := range []string{"secret-key-00", "secret-key-19", "after-open", "after-rekey"} {
+		_, err := lsm.Get(key)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestLSMRekeyResumesWithBothKeys(t *testing.T) {
+	dir := t.TempDir()
+	lsm, err := openEncryptedLSM(dir, storageKey1, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, lsm.Insert("flushed", "v"))
+	assert.NoError(t, lsm.ForceCompaction())
+	assert.NoError(t, lsm.Insert("in-wal", "v"))
+
+	// 이전 키로 봉인된 테이블과 WAL을 두 키로 열면 열면서 새 키로 다시 씀
+	image := copyLSMDir(t, dir)
+	assert.NoError(t, lsm.Close())
+	lsm, err = openEncryptedLSM(image, storageKey2, storageKey1)
+	assert.NoError(t, err)
+	assert.NoError(t, lsm.Close())
+
+	lsm, err = openEncryptedLSM(image, storageKey2, nil)
+	assert.NoError(t, err)
+	defer lsm.Close()
+	for _, key := range []string{"flushed", "in-wal"} {
+		value, err := lsm.Get(key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, "v", value)
+	}
+}
+
+func TestLSMKeyOnUnencryptedTree(t *testing.T) {
+	dir := t.TempDir()
+	lsm, err := openEncryptedLSM(dir, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, lsm.Insert("k", "v"))
+	assert.True(t, errors.Is(lsm.Rekey(storageKey1), pagecrypt.ErrNotEncrypted))
+	assert.NoError(t, lsm.Close())
+
+	_, err = openEncryptedLSM(dir, storageKey1, nil)
+	assert.True(t, errors.Is(err, pagecrypt.ErrNotEncrypted), "%v", err)
+}
@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestTenantsAreIsolatedNamespaces(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "tenants.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	// 같은 테이블 이름이 테넌트마다 따로 존재함
+	for _, ctx := range []context.Context{acme, globex} {
+		assert.NoError(t, db.CreateTableContext(ctx, "users"))
+	}
+	assert.NoError(t, db.InsertContext(acme, "users", "u1", "alice", domain.WriteAck{}))
+	assert.NoError(t, db.InsertContext(globex, "users", "u1", "bob", domain.WriteAck{}))
+	v, err := db.GetContext(acme, "users", "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", v)
+	v, err = db.Get(domain.TenantTable("globex", "users"), "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", v)
+	_, err = db.GetContext(context.Background(), "users", "u1")
+	assert.Error(t, err)
+
+	var keys []string
+	assert.NoError(t, db.ScanContext(globex, "users", "", func(key, _ string) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"u1"}, keys)
+	_, err = db.GetContext(domain.WithTenant(context.Background(), "a/b"), "users", "u1")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"acme", "globex"}, db.Tenants())
+}
+
+func TestTenantQuotasAndDrop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.db")
+	db, err := openBtreeDatabase(path)
+	assert.NoError(t, err)
+	acme := domain.WithTenant(context.Background(), "acme")
+	assert.NoError(t, db.SetTenantQuota("acme", domain.TenantQuota{MaxTables: 2, MaxKeys: 3}))
+	assert.Error(t, db.SetTenantQuota("acme", domain.TenantQuota{MaxKeys: -1}))
+
+	assert.NoError(t, db.CreateTableContext(acme, "a"))
+	assert.NoError(t, db.CreateTableContext(acme, "b"))
+	assert.ErrorIs(t, db.CreateTableContext(acme, "c"), domain.ErrQuotaExceeded)
+	assert.NoError(t, db.CreateSearchIndex(domain.TenantTable("acme", "a"), nil)) // 보조 테이블은 세지 않음
+
+	// 키 쿼터는 테이블 전체에 걸쳐 적용되고, 덮어쓰기는 새 키가 아님
+	assert.NoError(t, db.InsertContext(acme, "a", "k1", "v", domain.WriteAck{}))
+	assert.NoError(t, db.InsertContext(acme, "b", "k1", "v", domain.WriteAck{}))
+	batch := domain.NewWriteBatch().Insert("a", "k2", "v").Insert("a", "k3", "v")
+	assert.ErrorIs(t, db.WriteContext(acme, batch, domain.WriteAck{}), domain.ErrQuotaExceeded)
+	assert.NoError(t, db.InsertContext(acme, "a", "k1", "v2", domain.WriteAck{}))
+	assert.NoError(t, db.DeleteContext(acme, "b", "k1", domain.WriteAck{}))
+	assert.NoError(t, db.InsertContext(acme, "b", "k4", "v", domain.WriteAck{}))
+
+	stats, err := db.TenantStats("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, stats.Tables)
+	assert.Equal(t, int64(3), stats.Keys)
+	assert.Equal(t, domain.TenantQuota{MaxTables: 2, MaxKeys: 3}, stats.Quota)
+	assert.NoError(t, db.Close())
+
+	// 쿼터는 재시작 후에도 유지됨
+	db, err = openBtreeDatabase(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.ErrorIs(t, db.InsertContext(acme, "a", "k9", "v", domain.WriteAck{}), domain.ErrQuotaExceeded)
+
+	assert.NoError(t, db.DropTenant("acme"))
+	stats, err = db.Reader().TenantStats("acme")
+	assert.NoError(t, err)
+	assert.Empty(t, stats.Tables)
+	assert.Equal(t, domain.TenantQuota{}, stats.Quota)
+	assert.Empty(t, db.Tenants())
+	for name := range db.GetSpec().Tables {
+		assert.NotContains(t, name, "@acme/", fmt.Sprintf("table %s left behind", name))
+	}
+}
+
+func TestCommandsUseTenantOfContext(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "tenantcmd.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	commands := application.NewCommandHandler(db, &mockLogger{})
+	queries := application.NewQueryHandler(db, &mockLogger{})
+	ctx := domain.WithTenant(context.Background(), "acme")
+
+	assert.NoError(t, commands.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "kv"}))
+	assert.NoError(t, commands.ExecuteCommand(ctx, &application.InsertCommand{TableName: "kv", Key: "k", Value: "v"}))
+	inc := &application.IncrementCommand{TableName: "kv", Key: "n", Delta: 2}
+	assert.NoError(t, commands.ExecuteCommand(ctx, inc))
+	value, err := queries.ExecuteQuery(ctx, &application.GetValueQuery{TableName: "kv", Key: "k"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.Contains(t, db.GetSpec().Tables, domain.TenantTable("acme", "kv"))
+	assert.NotContains(t, db.GetSpec().Tables, "kv")
+}
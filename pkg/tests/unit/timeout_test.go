@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/adapters/btree"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// blockingCommand는 ctx가 끝날 때까지 실행을 멈추는 테스트용 명령입니다.
+type blockingCommand struct{}
+
+func (c *blockingCommand) Execute(ctx context.Context, handler *application.CommandHandler) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestDefaultTimeoutsReturnErrTimeout(t *testing.T) {
+	logger := &mockLogger{}
+	db, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:         "timeouts",
+		FilePath:     filepath.Join(t.TempDir(), "timeouts.db"),
+		BtConfig:     btree.BtConfig{Degree: 4, PageSize: 4096},
+		ReadTimeout:  time.Nanosecond,
+		WriteTimeout: 20 * time.Millisecond,
+	}, logger)
+	assert.NoError(t, err)
+	defer db.Close()
+	commands := application.NewCommandHandler(db, logger)
+	queries := application.NewQueryHandler(db, logger)
+	ctx := context.Background()
+
+	// 기본 제한 시간 안에 끝나는 명령은 영향을 받지 않음
+	assert.NoError(t, commands.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "users"}))
+	assert.NoError(t, commands.ExecuteCommand(ctx, &application.InsertCommand{TableName: "users", Key: "k", Value: "v"}))
+
+	err = commands.ExecuteCommand(ctx, &blockingCommand{})
+	assert.ErrorIs(t, err, domain.ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var timeout *domain.TimeoutError
+	if assert.True(t, errors.As(err, &timeout)) {
+		assert.Equal(t, "blockingCommand", timeout.Op)
+		assert.Equal(t, 20*time.Millisecond, timeout.Timeout)
+	}
+
+	// 호출자가 정한 더 이른 마감 시각은 ErrTimeout이 아님
+	short, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	err = commands.ExecuteCommand(short, &blockingCommand{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, domain.ErrTimeout)
+
+	_, err = queries.ExecuteQuery(ctx, &application.ScanQuery{TableName: "users"})
+	assert.ErrorIs(t, err, domain.ErrTimeout)
+	assert.ErrorContains(t, err, "ScanQuery timed out after 1ns")
+
+	// 스트림 질의는 제한 시간으로 닫히지 않음
+	watchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	result, err := queries.ExecuteQuery(watchCtx, &application.WatchQuery{TableName: "users"})
+	assert.NoError(t, err)
+	w := result.(*domain.Watcher)
+	assert.NoError(t, db.Insert("users", "k2", "v2"))
+	select {
+	case change, ok := <-w.C:
+		assert.True(t, ok)
+		assert.Equal(t, "k2", change.Key)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not receive the change")
+	}
+}
+
+func TestNegativeTimeoutRejected(t *testing.T) {
+	_, err := domain.NewDatabase(domain.DatabaseConfig{
+		Name:        "timeouts",
+		FilePath:    filepath.Join(t.TempDir(), "timeouts.db"),
+		ReadTimeout: -time.Second,
+	}, &mockLogger{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range s.Attributes() {
+		if kv.Key == attribute.Key(key) {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestHandlersTraceCommandsAndStorageOperations(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "traced.db"), func(c *domain.DatabaseConfig) {
+		c.Name, c.TracerProvider = "traced", tp // 스팬의 db.name으로 확인
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	commands := application.NewCommandHandler(db, &mockLogger{})
+	queries := application.NewQueryHandler(db, &mockLogger{})
+	ctx := context.Background()
+
+	assert.NoError(t, commands.ExecuteCommand(ctx, &application.CreateTableCommand{TableName: "users"}))
+	assert.NoError(t, commands.ExecuteCommand(ctx, &application.InsertCommand{TableName: "users", Key: "u1", Value: "alice"}))
+	_, err = queries.ExecuteQuery(ctx, &application.GetValueQuery{TableName: "users", Key: "missing"})
+	assert.Error(t, err)
+	assert.Error(t, commands.ExecuteCommand(ctx, &application.InsertCommand{TableName: "absent", Key: "k", Value: "v"}))
+
+	byName := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		byName[s.Name()] = append(byName[s.Name()], s)
+	}
+	assert.Len(t, byName["golite.command.CreateTableCommand"], 1)
+	assert.Len(t, byName["golite.command.InsertCommand"], 2)
+	assert.Len(t, byName["golite.storage.insert"], 2)
+
+	// 저장소 스팬은 명령 스팬의 자식이며 테이블과 어댑터를 속성으로 가짐
+	insert := byName["golite.command.InsertCommand"][0]
+	storage := byName["golite.storage.insert"][0]
+	assert.Equal(t, insert.SpanContext().SpanID(), storage.Parent().SpanID())
+	assert.Equal(t, "users", spanAttr(storage, "golite.table"))
+	assert.Equal(t, "traced", spanAttr(storage, "db.name"))
+	assert.Contains(t, spanAttr(storage, "golite.adapter"), "btree")
+	assert.Equal(t, codes.Unset, storage.Status().Code)
+
+	// 없는 키는 오류가 아니고, 없는 테이블은 명령과 저장소 스팬 모두 오류
+	get := byName["golite.storage.get"]
+	if assert.Len(t, get, 1) {
+		assert.Equal(t, codes.Unset, get[0].Status().Code)
+		assert.Equal(t, byName["golite.query.GetValueQuery"][0].SpanContext().SpanID(), get[0].Parent().SpanID())
+	}
+	assert.Equal(t, codes.Error, byName["golite.storage.insert"][1].Status().Code)
+	assert.Equal(t, codes.Error, byName["golite.command.InsertCommand"][1].Status().Code)
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/transfer"
+)
+
+func TestTransferRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, err := openBtreeDatabase(filepath.Join(t.TempDir(), "src.db"))
+	assert.NoError(t, err)
+	defer src.Close()
+	assert.NoError(t, src.CreateTable("users"))
+	want := map[string]string{
+		"alice":  "plain",
+		"bob":    "comma, \"quote\"\nnewline",
+		"binary": "\xff\x00\xfe",
+	}
+	for k, v := range want {
+		assert.NoError(t, src.Insert("users", k, v))
+	}
+
+	for _, format := range []transfer.Format{transfer.FormatJSON, transfer.FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := transfer.Export(ctx, src, "users", format, &buf)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, n)
+
+			dst, err := openBtreeDatabase(filepath.Join(t.TempDir(), "dst.db"))
+			assert.NoError(t, err)
+			defer dst.Close()
+			n, err = transfer.Import(ctx, dst, "users", format, &buf, 2)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, n)
+			for k, v := range want {
+				got, err := dst.Get("users", k)
+				assert.NoError(t, err)
+				assert.Equal(t, v, got)
+			}
+		})
+	}
+}
+
+func TestTransferFormats(t *testing.T) {
+	ctx := context.Background()
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "formats.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("empty"))
+
+	var buf bytes.Buffer
+	n, err := transfer.Export(ctx, db, "empty", transfer.FormatJSON, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "[]\n", buf.String())
+
+	// 가져오기는 없는 테이블을 만듦
+	n, err = transfer.Import(ctx, db, "new", transfer.FormatJSON, strings.NewReader(`[{"key":"k","value":"v"}]`), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, err := db.Get("new", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", got)
+
+	_, err = transfer.Import(ctx, db, "new", transfer.FormatCSV, strings.NewReader("id,name\n1,x\n"), 0)
+	assert.ErrorContains(t, err, "expected header key,value")
+	_, err = transfer.Import(ctx, db, "new", transfer.FormatJSON, strings.NewReader(`{"key":"k"}`), 0)
+	assert.Error(t, err)
+	_, err = transfer.ParseFormat("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+func TestGetVersionsKeepsLastN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.db")
+	db, err := openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.VersionHistory = 3 })
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateTable("kv"))
+	assert.NoError(t, db.CreateTable("kv2")) // 키 이름이 겹치는 다른 테이블
+	for _, v := range []string{"v1", "v2", "v3", "v4"} {
+		assert.NoError(t, db.Insert("kv", "a", v))
+	}
+	assert.NoError(t, db.Insert("kv", "ab", "other key"))
+	assert.NoError(t, db.Insert("kv2", "a", "other table"))
+	assert.NoError(t, db.Delete("kv", "a"))
+
+	// 최신 버전부터, 삭제도 버전으로 남고 가장 오래된 버전은 정리됨
+	versions, err := db.GetVersions("kv", "a")
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 3) {
+		assert.Equal(t, uint64(5), versions[0].Version)
+		assert.True(t, versions[0].Deleted)
+		assert.Equal(t, "v4", versions[1].Value)
+		assert.Equal(t, "v3", versions[2].Value)
+		assert.False(t, versions[0].Time.Before(versions[2].Time))
+	}
+	assert.NoError(t, db.Close())
+
+	// 재시작 후에도 남고 버전 번호가 이어짐
+	db, err = openBtreeDatabase(path, func(c *domain.DatabaseConfig) { c.VersionHistory = 3 })
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Insert("kv", "a", "v6"))
+	versions, err = db.GetVersions("kv", "a")
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 3) {
+		assert.Equal(t, uint64(6), versions[0].Version)
+		assert.Equal(t, "v6", versions[0].Value)
+	}
+
+	// 테이블을 지우면 그 테이블의 버전도 지워짐
+	assert.NoError(t, db.DropTable("kv"))
+	assert.NoError(t, db.CreateTable("kv"))
+	versions, err = db.GetVersions("kv", "a")
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+	versions, err = db.GetVersions("kv2", "a")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
+
+func TestGetVersionsRetentionWindow(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "versions.db"), func(c *domain.DatabaseConfig) {
+		c.VersionRetention = 50 * time.Millisecond
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	assert.NoError(t, db.Insert("kv", "a", "old"))
+	time.Sleep(60 * time.Millisecond)
+
+	// 보존 기간이 지난 버전은 다시 쓰기 전에도 돌려주지 않음
+	versions, err := db.GetVersions("kv", "a")
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+
+	assert.NoError(t, db.Insert("kv", "a", "new"))
+	versions, err = db.GetVersions("kv", "a")
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 1) {
+		assert.Equal(t, "new", versions[0].Value)
+		assert.Equal(t, uint64(2), versions[0].Version)
+	}
+}
+
+func TestGetVersionsRequiresVersioning(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "plain.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	_, err = db.GetVersions("kv", "a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sukryu/GoLite/pkg/application"
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+type recordingPort struct {
+	events []string
+}
+
+func (p *recordingPort) OnInsert(key string, value interface{}) {
+	p.events = append(p.events, "+"+key+"="+value.(string))
+}
+
+func (p *recordingPort) OnDelete(key string) {
+	p.events = append(p.events, "-"+key)
+}
+
+func TestStorageEventPortReceivesCommittedWrites(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "events.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	assert.NoError(t, db.CreateTable("other"))
+
+	port := &recordingPort{}
+	remove := db.AddStorageEventPort("kv", port)
+	assert.NoError(t, db.Insert("kv", "a", "1"))
+	assert.NoError(t, db.Insert("other", "x", "1"))
+	assert.Error(t, db.Delete("kv", "missing"))
+	assert.NoError(t, db.Delete("kv", "a"))
+	remove()
+	assert.NoError(t, db.Insert("kv", "b", "2"))
+	assert.Equal(t, []string{"+a=1", "-a"}, port.events)
+}
+
+func TestWatchQueryStreamsPrefixChanges(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "watch.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	handler := application.NewQueryHandler(db, &mockLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := handler.ExecuteQuery(ctx, &application.WatchQuery{TableName: "kv", Prefix: "users:", Buffer: 2})
+	assert.NoError(t, err)
+	w := result.(*domain.Watcher)
+
+	assert.NoError(t, db.Insert("kv", "users:1", "alice"))
+	assert.NoError(t, db.Insert("kv", "orders:1", "o"))
+	assert.NoError(t, db.Delete("kv", "users:1"))
+	assert.NoError(t, db.Insert("kv", "users:2", "bob")) // 버퍼가 가득 차 버려짐
+	assert.Equal(t, domain.Change{Table: "kv", Key: "users:1", Value: "alice"}, <-w.C)
+	assert.Equal(t, domain.Change{Table: "kv", Key: "users:1", Deleted: true}, <-w.C)
+	assert.Equal(t, int64(1), w.Dropped())
+
+	// 컨텍스트가 끝나면 채널이 닫힘
+	cancel()
+	_, open := <-w.C
+	assert.False(t, open)
+	w.Close()
+
+	_, err = handler.ExecuteQuery(context.Background(), &application.WatchQuery{TableName: "missing"})
+	assert.Error(t, err)
+}
+
+func TestSubscribeFromCatchesUpWithoutGaps(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "subscribe.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, db.Insert("kv", fmt.Sprintf("k%03d", i), "v"))
+	}
+
+	// 구독하는 동안에도 쓰기가 계속됨: 스냅샷과 실시간 변경을 합치면 모든 키가 정확히 한 번씩 보여야 함
+	writing := make(chan struct{})
+	go func() {
+		defer close(writing)
+		for i := 50; i < 200; i++ {
+			assert.NoError(t, db.Insert("kv", fmt.Sprintf("k%03d", i), "v"))
+			assert.NoError(t, db.Insert("kv", fmt.Sprintf("other%03d", i), "v"))
+		}
+	}()
+	s, err := db.SubscribeFrom("kv", "k", 0)
+	assert.NoError(t, err)
+	defer s.Close()
+	<-writing
+	assert.NoError(t, db.Delete("kv", "k000"))
+
+	seen := make(map[string]int)
+	snapshot := true
+	for c := range s.C {
+		if c.Deleted {
+			assert.Equal(t, "k000", c.Key)
+			break
+		}
+		if !c.Snapshot {
+			snapshot = false
+		}
+		assert.Equal(t, snapshot, c.Snapshot, "snapshot entries come first")
+		seen[c.Key]++
+	}
+	assert.Len(t, seen, 200)
+	for key, n := range seen {
+		assert.Equal(t, 1, n, key)
+	}
+	assert.NoError(t, s.Err())
+}
+
+func TestSubscribeFromFailsWhenConsumerFallsBehind(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "overflow.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	_, err = db.SubscribeFrom("missing", "", 0)
+	assert.Error(t, err)
+
+	s, err := db.Reader().SubscribeFrom("kv", "", 1)
+	assert.NoError(t, err)
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, db.Insert("kv", fmt.Sprintf("k%d", i), "v"))
+	}
+	for range s.C {
+	}
+	assert.ErrorIs(t, s.Err(), domain.ErrSubscriptionOverflow)
+}
+
+func TestSubscribeQueryClosesWithContext(t *testing.T) {
+	db, err := openBtreeDatabase(filepath.Join(t.TempDir(), "subquery.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.CreateTable("kv"))
+	assert.NoError(t, db.Insert("kv", "a", "1"))
+	handler := application.NewQueryHandler(db, &mockLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := handler.ExecuteQuery(ctx, &application.SubscribeQuery{TableName: "kv"})
+	assert.NoError(t, err)
+	s := result.(*domain.Subscription)
+	assert.Equal(t, domain.Change{Table: "kv", Key: "a", Value: "1", Snapshot: true}, <-s.C)
+	cancel()
+	for range s.C {
+	}
+	assert.NoError(t, s.Err())
+}
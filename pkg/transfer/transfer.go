@@ -0,0 +1,223 @@
+// Package transfer는 테이블 하나를 JSON이나 CSV로 내보내고 다시 가져옵니다 (golite export/import).
+// Go 코드를 작성하지 않고 데이터를 다른 데이터베이스나 도구와 주고받는 용도입니다.
+//
+// 내보내기는 domain.Database.ScanContext로 키 순서대로 읽으면서 바로 기록하므로 테이블 크기와
+// 관계없이 메모리를 적게 씁니다. 값은 메타데이터와 암호화가 벗겨진 논리 값입니다.
+//
+//   - JSON: {"key": ..., "value": ...} 객체의 배열입니다. 키나 값이 UTF-8이 아니면 두 필드를
+//     base64로 쓰고 "base64": true를 붙여 바이트 그대로 되돌릴 수 있게 합니다.
+//   - CSV: 첫 줄이 "key,value" 헤더인 두 열입니다 (RFC 4180). CSV 리더는 따옴표 안의 \r\n을 \n으로
+//     바꾸므로 그런 값을 바이트 그대로 옮기려면 JSON을 사용합니다.
+//
+// 가져오기는 테이블이 없으면 만들고, 레코드를 batchSize개씩 WriteBatch로 기록합니다. 같은 키는
+// 덮어쓰며, 오류가 나면 그 앞의 배치까지는 기록된 채로 남습니다.
+package transfer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/sukryu/GoLite/pkg/domain"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// ParseFormat validates a format name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatJSON, FormatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (use json or csv)", s)
+}
+
+// DefaultBatchSize is the number of records Import writes per batch when none is given.
+const DefaultBatchSize = 1000
+
+// record is one exported key-value pair in the JSON format.
+type record struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Base64 bool   `json:"base64,omitempty"`
+}
+
+// Export writes every key of tableName to w in format and returns the number of records.
+func Export(ctx context.Context, db *domain.Database, tableName string, format Format, w io.Writer) (int, error) {
+	var write func(key, value string) error
+	var finish func() error
+	switch format {
+	case FormatJSON:
+		sep := "[\n"
+		write = func(key, value string) error {
+			rec := record{Key: key, Value: value}
+			if !utf8.ValidString(key) || !utf8.ValidString(value) {
+				rec = record{
+					Key:    base64.StdEncoding.EncodeToString([]byte(key)),
+					Value:  base64.StdEncoding.EncodeToString([]byte(value)),
+					Base64: true,
+				}
+			}
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, sep); err != nil {
+				return err
+			}
+			sep = ",\n"
+			_, err = w.Write(data)
+			return err
+		}
+		finish = func() error {
+			end := "\n]\n"
+			if sep == "[\n" {
+				end = "[]\n" // 빈 테이블
+			}
+			_, err := io.WriteString(w, end)
+			return err
+		}
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"key", "value"}); err != nil {
+			return 0, err
+		}
+		write = func(key, value string) error {
+			return cw.Write([]string{key, value})
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unknown format %q", format)
+	}
+
+	count := 0
+	var writeErr error
+	err := db.ScanContext(ctx, tableName, "", func(key, value string) bool {
+		if writeErr = write(key, value); writeErr != nil {
+			return false
+		}
+		count++
+		return true
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		return count, fmt.Errorf("failed to export table %s: %v", tableName, err)
+	}
+	return count, nil
+}
+
+// Import reads records in format from r and inserts them into tableName, creating the table
+// if it doesn't exist. Records are written batchSize at a time (DefaultBatchSize if <= 0).
+// It returns the number of records written.
+func Import(ctx context.Context, db *domain.Database, tableName string, format Format, r io.Reader, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if err := db.CreateTableContext(ctx, tableName); err != nil && !errors.Is(err, domain.ErrTableExists) {
+		return 0, fmt.Errorf("failed to create table %s: %v", tableName, err)
+	}
+
+	var next func() (key, value string, err error)
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(r)
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return 0, fmt.Errorf("invalid JSON import: expected an array of records")
+		}
+		next = func() (string, string, error) {
+			if !dec.More() {
+				return "", "", io.EOF
+			}
+			var rec record
+			if err := dec.Decode(&rec); err != nil {
+				return "", "", err
+			}
+			if !rec.Base64 {
+				return rec.Key, rec.Value, nil
+			}
+			key, err := base64.StdEncoding.DecodeString(rec.Key)
+			if err != nil {
+				return "", "", fmt.Errorf("invalid base64 key: %v", err)
+			}
+			value, err := base64.StdEncoding.DecodeString(rec.Value)
+			if err != nil {
+				return "", "", fmt.Errorf("invalid base64 value: %v", err)
+			}
+			return string(key), string(value), nil
+		}
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = 2
+		header, err := cr.Read()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid CSV import: %v", err)
+		}
+		if header[0] != "key" || header[1] != "value" {
+			return 0, fmt.Errorf("invalid CSV import: expected header key,value, got %q", header)
+		}
+		next = func() (string, string, error) {
+			fields, err := cr.Read()
+			if err != nil {
+				return "", "", err
+			}
+			return fields[0], fields[1], nil
+		}
+	default:
+		return 0, fmt.Errorf("unknown format %q", format)
+	}
+
+	count := 0
+	batch := domain.NewWriteBatch()
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		if err := db.WriteContext(ctx, batch, domain.WriteAck{}); err != nil {
+			return err
+		}
+		count += batch.Len()
+		batch = domain.NewWriteBatch()
+		return nil
+	}
+	for {
+		key, value, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read record %d: %v", count+batch.Len()+1, err)
+		}
+		batch.Insert(tableName, key, value)
+		if batch.Len() >= batchSize {
+			if err := flush(); err != nil {
+				return count, fmt.Errorf("failed to import into table %s: %v", tableName, err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return count, fmt.Errorf("failed to import into table %s: %v", tableName, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// 단일 작성자 잠금: 두 프로세스가 같은 데이터베이스 파일을 열면 서로의 쓰기를 덮어써 파일이
+// 손상됩니다. 저장소 어댑터는 파일을 열 때 LockFile로 배타적 권고 잠금(Unix는 flock, Windows는
+// LockFileEx)을 잡고, 이미 다른 프로세스가 잡고 있으면 기다리지 않고 ErrLocked로 실패합니다.
+// 잠금은 열린 파일 단위이므로 같은 프로세스에서 같은 경로를 따로 두 번 열어도 두 번째는 실패하며,
+// 파일을 닫거나 프로세스가 끝나면(비정상 종료 포함) 운영체제가 풀어 줍니다. 권고 잠금이므로
+// 잠금을 확인하지 않는 다른 프로그램의 쓰기는 막지 못합니다. 잠금을 지원하지 않는 플랫폼에서는
+// 아무것도 하지 않습니다.
+
+// ErrLocked is returned when a database file is already opened by another process.
+var ErrLocked = errors.New("database is locked")
+
+// LockFile takes an exclusive advisory lock on f without waiting. It fails with ErrLocked if
+// the lock is held through another open file.
+func LockFile(f *os.File) error {
+	locked, err := lockFile(f)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %v", f.Name(), err)
+	}
+	if !locked {
+		return fmt.Errorf("%w: %s is in use by another process", ErrLocked, f.Name())
+	}
+	return nil
+}
+
+// UnlockFile releases the lock taken by LockFile. Closing f releases it too.
+func UnlockFile(f *os.File) error {
+	return unlockFile(f)
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package utils
+
+import "os"
+
+func lockFile(f *os.File) (bool, error) {
+	return true, nil // 잠금을 지원하지 않는 플랫폼
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
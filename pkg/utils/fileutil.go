@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// 파일 교체: 기존 파일을 제자리에서 덮어쓰면 쓰는 도중 전원이 나갈 때 옛 내용도 새 내용도 남지 않을 수
+// 있습니다. 그래서 파일을 통째로 바꾸는 쓰기는 모두 같은 순서를 따릅니다.
+//
+//  1. 같은 디렉터리의 임시 파일(dst + ".tmp")에 새 내용을 씁니다.
+//  2. 임시 파일을 fsync합니다. rename보다 내용이 먼저 디스크에 닿아야 합니다.
+//  3. 임시 파일을 dst로 rename합니다. 같은 파일 시스템 안의 rename은 원자적입니다.
+//  4. 디렉터리를 fsync해 rename 자체를 디스크에 남깁니다.
+//
+// 어느 단계에서 멈춰도 dst는 옛 내용이나 새 내용 중 하나이며, 남은 임시 파일은 다음에 열 때 지워도
+// 됩니다.
+
+// CopyFileAtomic copies src to dst via a temp file, fsync and rename,
+// so dst is either the previous content or a complete copy of src.
+func CopyFileAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return WriteAtomic(dst, func(out io.Writer) error {
+		_, err := io.Copy(out, in)
+		return err
+	})
+}
+
+// WriteFileAtomic writes data to path via a temp file, fsync and rename.
+func WriteFileAtomic(path string, data []byte) error {
+	return WriteAtomic(path, func(out io.Writer) error {
+		_, err := out.Write(data)
+		return err
+	})
+}
+
+// WriteAtomic fills a temp file next to dst with write, fsyncs it, renames it over dst and
+// fsyncs the directory. On error dst is left as it was and the temp file is removed.
+func WriteAtomic(dst string, write func(io.Writer) error) error {
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if err := write(out); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return SyncDir(filepath.Dir(dst))
+}
+
+// SyncDir fsyncs the directory dir, making the creation, rename or removal of its entries
+// durable. It does nothing on Windows, where directories cannot be fsynced and renames are
+// journaled by the file system.
+func SyncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// LogLevel is the minimum severity a LevelLogger forwards.
+type LogLevel int32
+
+const (
+	LevelInfo LogLevel = iota
+	LevelWarn
+	LevelError
+	LevelSilent
+)
+
+// ParseLogLevel parses "info", "warn", "error" or "silent" (case-insensitive).
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "info", "debug":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "silent", "off":
+		return LevelSilent, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "silent"
+	}
+}
+
+// LevelLogger filters messages below a level that can be changed at runtime.
+// Loggers derived from it with With share its level.
+type LevelLogger struct {
+	inner Logger
+	level *atomic.Int32
+}
+
+// NewLevelLogger wraps inner with a minimum level.
+func NewLevelLogger(inner Logger, level LogLevel) *LevelLogger {
+	l := &LevelLogger{inner: inner, level: new(atomic.Int32)}
+	l.level.Store(int32(level))
+	return l
+}
+
+// SetLevel changes the minimum level; safe to call concurrently with logging.
+func (l *LevelLogger) SetLevel(level LogLevel) { l.level.Store(int32(level)) }
+
+// Level returns the current minimum level.
+func (l *LevelLogger) Level() LogLevel { return LogLevel(l.level.Load()) }
+
+func (l *LevelLogger) Info(msg string, args ...any) {
+	if l.Level() <= LevelInfo {
+		l.inner.Info(msg, args...)
+	}
+}
+
+func (l *LevelLogger) Warn(msg string, args ...any) {
+	if l.Level() <= LevelWarn {
+		l.inner.Warn(msg, args...)
+	}
+}
+
+func (l *LevelLogger) Error(msg string, args ...any) {
+	if l.Level() <= LevelError {
+		l.inner.Error(msg, args...)
+	}
+}
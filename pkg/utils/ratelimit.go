@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket whose rate can be changed at runtime.
+// A rate of 0 or less disables limiting.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing perSecond events with bursts of up to burst events.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// SetRate changes the rate; tokens already accumulated are kept.
+func (r *RateLimiter) SetRate(perSecond float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refill(time.Now())
+	r.rate = perSecond
+}
+
+// Rate returns the current rate.
+func (r *RateLimiter) Rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rate
+}
+
+// Wait blocks until an event is allowed or ctx is done.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	return r.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens can be taken or ctx is done, and takes them. Like AllowN, an
+// n larger than the burst is taken once the bucket is full, leaving it in debt.
+func (r *RateLimiter) WaitN(ctx context.Context, n float64) error {
+	for {
+		r.mu.Lock()
+		if r.rate <= 0 {
+			r.mu.Unlock()
+			return nil
+		}
+		now := time.Now()
+		r.refill(now)
+		if r.tokens >= n || r.tokens >= r.burst {
+			r.tokens -= n
+			r.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((math.Min(n, r.burst) - r.tokens) / r.rate * float64(time.Second))
+		r.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// AllowN reports whether n tokens can be taken now and takes them if so, without waiting.
+// An n larger than the burst is allowed once the bucket is full, leaving it in debt, so
+// oversized events are slowed down rather than rejected forever.
+func (r *RateLimiter) AllowN(n float64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rate <= 0 {
+		return true
+	}
+	r.refill(time.Now())
+	if r.tokens < n && r.tokens < r.burst {
+		return false
+	}
+	r.tokens -= n
+	return true
+}
+
+// refill adds the tokens earned since the last call. Caller holds r.mu.
+func (r *RateLimiter) refill(now time.Time) {
+	if r.rate > 0 {
+		r.tokens += now.Sub(r.last).Seconds() * r.rate
+		if r.tokens > r.burst {
+			r.tokens = r.burst
+		}
+	}
+	r.last = now
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// 구조화 로깅: SlogLogger는 log/slog 위에 Logger를 구현합니다. 메시지와 함께 넘긴 키-값 쌍은 필드가
+// 되어 text(key=value) 또는 JSON 형식으로 출력되고, With로 붙인 필드(예: 어댑터 이름, 파일 경로)는
+// 이후 모든 메시지에 포함됩니다. 레벨 필터는 LevelLogger가 맡으므로 핸들러는 모든 레벨을 씁니다.
+
+// LogFormat is the output format of a structured logger.
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text"
+	LogFormatJSON LogFormat = "json"
+)
+
+// ParseLogFormat parses "text" (the default for "") or "json" (case-insensitive).
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "text":
+		return LogFormatText, nil
+	case "json":
+		return LogFormatJSON, nil
+	}
+	return LogFormatText, fmt.Errorf("unknown log format %q", s)
+}
+
+// SlogLogger is a Logger writing through a *slog.Logger.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger wraps l.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: l}
+}
+
+// NewStructuredLogger writes records of every level to w in format.
+func NewStructuredLogger(w io.Writer, format LogFormat) *SlogLogger {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler
+	if format == LogFormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return NewSlogLogger(slog.New(h))
+}
+
+// DefaultLogger returns a Logger writing through slog.Default(), for components created
+// without one.
+func DefaultLogger() Logger {
+	return NewSlogLogger(slog.Default())
+}
+
+func (l *SlogLogger) Info(msg string, args ...any)  { l.logger.Info(msg, args...) }
+func (l *SlogLogger) Warn(msg string, args ...any)  { l.logger.Warn(msg, args...) }
+func (l *SlogLogger) Error(msg string, args ...any) { l.logger.Error(msg, args...) }
+
+// Slog returns the underlying *slog.Logger.
+func (l *SlogLogger) Slog() *slog.Logger { return l.logger }
+
+// Enabled reports whether the handler writes records of level.
+func (l *SlogLogger) Enabled(level slog.Level) bool {
+	return l.logger.Enabled(context.Background(), level)
+}
+
+// With returns a logger that adds args to every message of l. Loggers other than
+// SlogLogger and LevelLogger get the fields prepended to each call's args.
+func With(l Logger, args ...any) Logger {
+	if len(args) == 0 {
+		return l
+	}
+	switch l := l.(type) {
+	case *SlogLogger:
+		return NewSlogLogger(l.logger.With(args...))
+	case *LevelLogger:
+		return &LevelLogger{inner: With(l.inner, args...), level: l.level}
+	case *SilentLogger:
+		return l
+	}
+	return &withLogger{inner: l, args: args}
+}
+
+type withLogger struct {
+	inner Logger
+	args  []any
+}
+
+func (l *withLogger) join(args []any) []any {
+	return append(append(make([]any, 0, len(l.args)+len(args)), l.args...), args...)
+}
+
+func (l *withLogger) Info(msg string, args ...any)  { l.inner.Info(msg, l.join(args)...) }
+func (l *withLogger) Warn(msg string, args ...any)  { l.inner.Warn(msg, l.join(args)...) }
+func (l *withLogger) Error(msg string, args ...any) { l.inner.Error(msg, l.join(args)...) }
@@ -1,25 +1,56 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
 
+// Logger is a leveled, structured logger. args are alternating key-value pairs or slog.Attr
+// values attached to the message, as in log/slog:
+//
+//	logger.Info("Inserted key", "table", tableName, "key", key)
 type Logger interface {
-	Info(msg string)
-	Warn(msg string)
-	Error(msg string)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
 }
 
+// SimpleLogger prints "LEVEL: message key=value ..." lines to stdout.
 type SimpleLogger struct{}
 
 func NewSimpleLogger() *SimpleLogger {
 	return &SimpleLogger{}
 }
 
-func (l *SimpleLogger) Info(msg string)  { fmt.Println("INFO: " + msg) }
-func (l *SimpleLogger) Warn(msg string)  { fmt.Println("WARN: " + msg) }
-func (l *SimpleLogger) Error(msg string) { fmt.Println("ERROR: " + msg) }
+func (l *SimpleLogger) Info(msg string, args ...any) {
+	fmt.Println("INFO: " + msg + FormatArgs(args...))
+}
+func (l *SimpleLogger) Warn(msg string, args ...any) {
+	fmt.Println("WARN: " + msg + FormatArgs(args...))
+}
+func (l *SimpleLogger) Error(msg string, args ...any) {
+	fmt.Println("ERROR: " + msg + FormatArgs(args...))
+}
 
 type SilentLogger struct{}
 
-func (l *SilentLogger) Info(msg string)  {}
-func (l *SilentLogger) Warn(msg string)  {}
-func (l *SilentLogger) Error(msg string) {}
+func (l *SilentLogger) Info(msg string, args ...any)  {}
+func (l *SilentLogger) Warn(msg string, args ...any)  {}
+func (l *SilentLogger) Error(msg string, args ...any) {}
+
+// FormatArgs renders logger args as " key=value" pairs, the way slog pairs them up.
+func FormatArgs(args ...any) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	r.Add(args...)
+	r.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		return true
+	})
+	return b.String()
+}